@@ -63,8 +63,18 @@ func main() {
 	// Initialize services AFTER migrations
 	paymentFailureService := services.NewPaymentFailureService(db, logger)
 
+	// Initialize customer identity resolution across providers
+	customerIdentityService := services.NewCustomerIdentityService(db, logger)
+
+	// Initialize the automation decision log, business calendars, customer
+	// segments and company scripts shared by the services below
+	decisionLogService := services.NewDecisionLogService(db, logger)
+	calendarService := services.NewBusinessCalendarService(db, logger)
+	segmentService := services.NewCustomerSegmentService(db, logger)
+	scriptService := services.NewCompanyScriptService(db, calendarService, logger)
+
 	// Initialize analytics service for Sprint 3
-	analyticsService := services.NewAnalyticsService(db, logger)
+	analyticsService := services.NewAnalyticsService(db, customerIdentityService, logger)
 
 	// Initialize enhanced webhook service with Sprint 2 features
 	webhookSecret := viper.GetString("stripe.webhook_secret")
@@ -81,7 +91,8 @@ func main() {
 	communicationService := services.NewCommunicationService(db, nil, nil)
 
 	// Initialize recovery orchestration service
-	recoveryOrchestrationService := services.NewRecoveryOrchestrationService(db, retryService, communicationService, analyticsService, logger)
+	recoveryOrchestrationService := services.NewRecoveryOrchestrationService(db, retryService, communicationService, analyticsService,
+		customerIdentityService, decisionLogService, calendarService, segmentService, logger)
 
 	// Initialize monitoring service for Sprint 2 observability
 	monitoringService := services.NewMonitoringService(webhookService.GetMetrics())
@@ -134,8 +145,18 @@ func main() {
 		logger.Info("Xero configuration not found, skipping Xero mediator initialization")
 	}
 
+	// The API only queues work. Export jobs, scheduled reports, workflow timers
+	// and executions run in the background loops of cmd/worker.
+
+	// Initialize export service for streaming exports and queueing export jobs
+	exportService := services.NewExportService(db, logger)
+
+	// Initialize scheduled report management and on-demand report runs
+	recoveryAnalyticsService := services.NewRecoveryAnalyticsService(db, logger)
+	reportService := services.NewReportService(db, recoveryAnalyticsService, analyticsService, communicationService, logger)
+
 	// Initialize API handlers with services
-	apiHandlers := api.NewHandlers(paymentFailureService, webhookService, alertService, retryService, dataQualityService, analyticsService, recoveryOrchestrationService, communicationService, logger)
+	apiHandlers := api.NewHandlers(paymentFailureService, webhookService, alertService, retryService, dataQualityService, analyticsService, recoveryOrchestrationService, communicationService, exportService, logger)
 
 	// Initialize Gin router
 	router := gin.New()
@@ -221,6 +242,8 @@ func main() {
 		{
 			dashboardGroup.GET("/stats", apiHandlers.GetDashboardStats)
 			dashboardGroup.GET("/export", apiHandlers.ExportData)
+			dashboardGroup.GET("/export/jobs/:id", apiHandlers.GetExportJob)
+			dashboardGroup.GET("/export/download/:token", apiHandlers.DownloadExport)
 			dashboardGroup.GET("/quality", apiHandlers.GetDataQualityReport)
 			dashboardGroup.GET("/quality/trends", apiHandlers.GetDataQualityTrends)
 		}
@@ -293,6 +316,41 @@ func main() {
 			})
 		}
 
+		// Recovery workflow, execution and template endpoints
+		recoveryHandlers := api.NewRecoveryHandlers(recoveryOrchestrationService, communicationService)
+		api.RegisterRecoveryRoutes(apiV1, recoveryHandlers)
+
+		// Customer identity endpoints
+		customer360Service := services.NewCustomer360Service(db, customerIdentityService, analyticsService, logger)
+		customerHandlers := api.NewCustomerHandlers(customerIdentityService, analyticsService, customer360Service, logger)
+		api.RegisterCustomerRoutes(apiV1, customerHandlers)
+
+		// Declarative rule definition endpoints
+		ruleDefinitionService := services.NewRuleDefinitionService(db, logger)
+		ruleBacktestService := services.NewRuleBacktestService(db, ruleDefinitionService, logger)
+		ruleHandlers := api.NewRuleHandlers(ruleDefinitionService, ruleBacktestService, logger)
+		api.RegisterRuleRoutes(apiV1, ruleHandlers)
+
+		// Automation decision log and shadow comparison endpoints
+		decisionHandlers := api.NewDecisionHandlers(decisionLogService, logger)
+		api.RegisterDecisionRoutes(apiV1, decisionHandlers)
+
+		// Business hours and holiday calendar endpoints
+		calendarHandlers := api.NewCalendarHandlers(calendarService, logger)
+		api.RegisterCalendarRoutes(apiV1, calendarHandlers)
+
+		// Company script endpoints
+		scriptHandlers := api.NewScriptHandlers(scriptService, logger)
+		api.RegisterScriptRoutes(apiV1, scriptHandlers)
+
+		// Customer segment endpoints
+		segmentHandlers := api.NewSegmentHandlers(segmentService, logger)
+		api.RegisterSegmentRoutes(apiV1, segmentHandlers)
+
+		// Scheduled report endpoints
+		reportHandlers := api.NewReportHandlers(reportService, logger)
+		api.RegisterReportRoutes(apiV1, reportHandlers)
+
 		// Xero integration endpoints (using mediator pattern)
 		if xeroMediator != nil {
 			xeroHandlers := api.NewXeroHandlers(xeroMediator, logger)
@@ -377,14 +435,7 @@ func initLogger() (*zap.Logger, error) {
 }
 
 func initDatabase(logger *zap.Logger) (*gorm.DB, error) {
-	host := viper.GetString("database.host")
-	user := viper.GetString("database.user")
-	password := viper.GetString("database.password")
-	dbname := viper.GetString("database.name")
-	port := viper.GetInt("database.port")
-
-	dsn := fmt.Sprintf("host=%s user=%s password=%s dbname=%s port=%d sslmode=disable",
-		host, user, password, dbname, port)
+	dsn := config.Get().Database.DSN()
 
 	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{
 		DisableForeignKeyConstraintWhenMigrating: true,
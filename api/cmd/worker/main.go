@@ -0,0 +1,120 @@
+// Command worker runs queued recovery workflow executions and their timers
+// without serving the API. Any number of replicas can run alongside the API;
+// executions are claimed from Postgres, so each runs on one replica at a time
+// and is taken over when a replica stops.
+//
+// The worker also relays the payment failures the API records from the outbox
+// to Redis, consumes them and runs the enterprise rules, whose actions raise
+// alerts, schedule retries and contact customers, and the company rule
+// definitions on each of them. It runs the queued data exports and deletes
+// their files once the download links expire, and delivers scheduled reports.
+//
+// These are all of the background loops; the API only queues their work. Each
+// loop claims its work in Postgres, so every replica can run all of them.
+package main
+
+import (
+	"context"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+
+	"github.com/sambitmohanty1/payment-watchdog/api/internal/config"
+	"github.com/sambitmohanty1/payment-watchdog/api/internal/eventbus"
+	"github.com/sambitmohanty1/payment-watchdog/api/internal/rules"
+	"github.com/sambitmohanty1/payment-watchdog/api/internal/services"
+)
+
+func main() {
+	if err := config.Load(); err != nil {
+		log.Fatalf("Failed to load configuration: %v", err)
+	}
+
+	zapConfig := zap.NewProductionConfig()
+	if err := zapConfig.Level.UnmarshalText([]byte(viper.GetString("log.level"))); err != nil {
+		zapConfig.Level = zap.NewAtomicLevelAt(zap.InfoLevel)
+	}
+	logger, err := zapConfig.Build()
+	if err != nil {
+		log.Fatalf("Failed to initialize logger: %v", err)
+	}
+	defer logger.Sync()
+
+	// The API runs the migrations; workers only use the schema
+	db, err := gorm.Open(postgres.Open(config.Get().Database.DSN()), &gorm.Config{})
+	if err != nil {
+		logger.Fatal("Failed to connect to database", zap.Error(err))
+	}
+
+	bus, err := eventbus.NewRedisEventBusFromURL(viper.GetString("redis.url"), logger)
+	if err != nil {
+		logger.Fatal("Failed to connect to event bus", zap.Error(err))
+	}
+	defer bus.Close()
+
+	// Shared by every service below, so each keeps one cache per replica
+	identityService := services.NewCustomerIdentityService(db, logger)
+	decisionLogService := services.NewDecisionLogService(db, logger)
+	calendarService := services.NewBusinessCalendarService(db, logger)
+	segmentService := services.NewCustomerSegmentService(db, logger)
+	scriptService := services.NewCompanyScriptService(db, calendarService, logger)
+
+	analyticsService := services.NewAnalyticsService(db, identityService, logger)
+	alertService := services.NewAlertService(db, logger)
+	retryService := services.NewRetryService(db, 3, 2*time.Second, 30*time.Second, 5)
+	communicationService := services.NewCommunicationService(db, nil, nil)
+	recoveryOrchestrationService := services.NewRecoveryOrchestrationService(db, retryService, communicationService, analyticsService,
+		identityService, decisionLogService, calendarService, segmentService, logger)
+	actionService := services.NewEnterpriseActionService(db, alertService, retryService, communicationService, recoveryOrchestrationService, logger)
+
+	ruleEngineFactory := rules.NewRuleEngineFactory(logger)
+	enterpriseEngine, err := ruleEngineFactory.CreateEnterpriseRuleEngine(actionService)
+	if err != nil {
+		logger.Fatal("Failed to create enterprise rule engine", zap.Error(err))
+	}
+	ruleEngineService := services.NewRuleEngineService(db, ruleEngineFactory.CreateComprehensiveRuleEngine(), services.NewRuleDefinitionService(db, logger),
+		decisionLogService, segmentService, calendarService, logger)
+	ruleEngineService.SetActionService(actionService)
+
+	architectureBus := eventbus.NewArchitectureEventBus(bus)
+	eventProcessor := services.NewEventProcessorService(db, nil, architectureBus,
+		identityService, decisionLogService, calendarService, segmentService, logger)
+	eventProcessor.SetEnterpriseRuleEngine(enterpriseEngine, scriptService)
+	eventProcessor.SetRuleEngineService(ruleEngineService)
+
+	ctx, stop := context.WithCancel(context.Background())
+	defer stop()
+
+	workers := viper.GetInt("workflows.execution_workers")
+	if workers <= 0 {
+		workers = 10
+	}
+	recoveryOrchestrationService.StartScheduler(ctx, 5*time.Second)
+	recoveryOrchestrationService.StartExecutionWorkers(ctx, workers, viper.GetInt("workflows.company_execution_limit"), 2*time.Second)
+	logger.Info("Workflow execution worker started", zap.Int("workers", workers))
+
+	services.NewOutboxRelay(db, architectureBus, logger).Start(ctx, time.Second)
+	services.NewExportService(db, logger).Start(ctx, 5*time.Second)
+	services.NewReportService(db, services.NewRecoveryAnalyticsService(db, logger), analyticsService, communicationService, logger).
+		Start(ctx, time.Minute)
+
+	if err := eventProcessor.StartEventProcessing(ctx); err != nil {
+		logger.Fatal("Failed to start event processing", zap.Error(err))
+	}
+
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	<-quit
+
+	// Executions still running keep their checkpoints; their leases expire and
+	// another replica continues them
+	logger.Info("Shutting down workflow execution worker...")
+	stop()
+}
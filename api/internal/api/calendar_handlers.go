@@ -0,0 +1,264 @@
+package api
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"github.com/sambitmohanty1/payment-watchdog/api/internal/models"
+	"github.com/sambitmohanty1/payment-watchdog/api/internal/services"
+)
+
+// maxICSUploadBytes bounds the size of an uploaded holiday calendar
+const maxICSUploadBytes = 5 << 20
+
+// CalendarHandlers handles business hours and holiday calendar endpoints
+type CalendarHandlers struct {
+	calendarService *services.BusinessCalendarService
+	logger          *zap.Logger
+}
+
+// NewCalendarHandlers creates new calendar handlers
+func NewCalendarHandlers(calendarService *services.BusinessCalendarService, logger *zap.Logger) *CalendarHandlers {
+	return &CalendarHandlers{
+		calendarService: calendarService,
+		logger:          logger,
+	}
+}
+
+// businessCalendarRequest is the body for replacing a company's timezone and hours
+type businessCalendarRequest struct {
+	Timezone      string                 `json:"timezone" binding:"required"`
+	BusinessHours []models.BusinessHours `json:"business_hours"`
+}
+
+// GetSettings returns the company's timezone, business hours and holiday calendars
+func (h *CalendarHandlers) GetSettings(c *gin.Context) {
+	companyID, ok := h.companyID(c)
+	if !ok {
+		return
+	}
+
+	settings, err := h.calendarService.GetSettings(c.Request.Context(), companyID)
+	if err != nil {
+		h.respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": settings})
+}
+
+// UpdateSettings replaces the company's timezone and business hours
+func (h *CalendarHandlers) UpdateSettings(c *gin.Context) {
+	companyID, ok := h.companyID(c)
+	if !ok {
+		return
+	}
+
+	var req businessCalendarRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.calendarService.UpdateSettings(c.Request.Context(), companyID, req.Timezone, req.BusinessHours); err != nil {
+		h.respondError(c, err)
+		return
+	}
+
+	settings, err := h.calendarService.GetSettings(c.Request.Context(), companyID)
+	if err != nil {
+		h.respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": settings})
+}
+
+// ImportHolidays imports an iCalendar file, uploaded as the "file" form field or
+// sent as a text/calendar body, into the calendar named by the name parameter
+func (h *CalendarHandlers) ImportHolidays(c *gin.Context) {
+	companyID, ok := h.companyID(c)
+	if !ok {
+		return
+	}
+
+	var (
+		data   io.Reader
+		source string
+	)
+	if strings.HasPrefix(c.ContentType(), "multipart/") {
+		header, err := c.FormFile("file")
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "file form field is required"})
+			return
+		}
+		if header.Size > maxICSUploadBytes {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "Holiday calendar file is too large"})
+			return
+		}
+		file, err := header.Open()
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read uploaded file"})
+			return
+		}
+		defer file.Close()
+		data, source = file, header.Filename
+	} else {
+		data = http.MaxBytesReader(c.Writer, c.Request.Body, maxICSUploadBytes)
+	}
+
+	name := c.PostForm("name")
+	if name == "" {
+		name = c.Query("name")
+	}
+	if name == "" {
+		name = strings.TrimSuffix(source, ".ics")
+	}
+
+	imported, err := h.calendarService.ImportHolidays(c.Request.Context(), companyID, name, source, data, c.GetString("user_id"))
+	if err != nil {
+		h.respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"data": imported})
+}
+
+// ListHolidays lists holidays between the from and to dates, defaulting to the next year
+func (h *CalendarHandlers) ListHolidays(c *gin.Context) {
+	companyID, ok := h.companyID(c)
+	if !ok {
+		return
+	}
+
+	from := time.Now().UTC().Truncate(24 * time.Hour)
+	to := from.AddDate(1, 0, 0)
+	for param, target := range map[string]*time.Time{"from": &from, "to": &to} {
+		if value := c.Query(param); value != "" {
+			parsed, err := time.Parse("2006-01-02", value)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": param + " must be a date in YYYY-MM-DD format"})
+				return
+			}
+			*target = parsed
+		}
+	}
+
+	holidays, err := h.calendarService.ListHolidays(c.Request.Context(), companyID, from, to)
+	if err != nil {
+		h.respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": holidays})
+}
+
+// DeleteHolidayCalendar removes an imported holiday calendar
+func (h *CalendarHandlers) DeleteHolidayCalendar(c *gin.Context) {
+	companyID, ok := h.companyID(c)
+	if !ok {
+		return
+	}
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid calendar ID"})
+		return
+	}
+
+	if err := h.calendarService.DeleteHolidayCalendar(c.Request.Context(), companyID, id); err != nil {
+		h.respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Holiday calendar deleted"})
+}
+
+// NextBusinessTime previews a schedule such as "next_business_day 10:00" from the
+// optional RFC 3339 from parameter, returning the time in the company's timezone
+func (h *CalendarHandlers) NextBusinessTime(c *gin.Context) {
+	companyID, ok := h.companyID(c)
+	if !ok {
+		return
+	}
+
+	schedule := c.Query("schedule")
+	if schedule == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "schedule query parameter is required"})
+		return
+	}
+	from := time.Now()
+	if value := c.Query("from"); value != "" {
+		parsed, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "from must be an RFC 3339 timestamp"})
+			return
+		}
+		from = parsed
+	}
+
+	ctx := c.Request.Context()
+	next, err := h.calendarService.Resolve(ctx, companyID, schedule, from)
+	if err != nil {
+		h.respondError(c, err)
+		return
+	}
+	cal, err := h.calendarService.Calendar(ctx, companyID)
+	if err != nil {
+		h.respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": gin.H{
+		"schedule": schedule,
+		"from":     from.In(cal.Location()),
+		"next":     next.In(cal.Location()),
+		"timezone": cal.Location().String(),
+	}})
+}
+
+func (h *CalendarHandlers) companyID(c *gin.Context) (string, bool) {
+	companyID := c.Query("company_id")
+	if companyID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "company_id query parameter is required"})
+		return "", false
+	}
+	return companyID, true
+}
+
+func (h *CalendarHandlers) respondError(c *gin.Context, err error) {
+	var tooLarge *http.MaxBytesError
+	switch {
+	case errors.Is(err, services.ErrCompanyNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Company not found"})
+	case errors.Is(err, services.ErrHolidayCalendarNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Holiday calendar not found"})
+	case errors.As(err, &tooLarge):
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "Holiday calendar file is too large"})
+	case errors.Is(err, services.ErrInvalidBusinessCalendar):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		h.logger.Error("Business calendar request failed", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Business calendar request failed"})
+	}
+}
+
+// RegisterCalendarRoutes registers business hours and holiday calendar routes
+func RegisterCalendarRoutes(router *gin.RouterGroup, calendarHandlers *CalendarHandlers) {
+	calendar := router.Group("/business-calendar")
+	{
+		calendar.GET("", calendarHandlers.GetSettings)
+		calendar.PUT("", calendarHandlers.UpdateSettings)
+		calendar.GET("/next", calendarHandlers.NextBusinessTime)
+		calendar.GET("/holidays", calendarHandlers.ListHolidays)
+		calendar.POST("/holidays", calendarHandlers.ImportHolidays)
+		calendar.DELETE("/calendars/:id", calendarHandlers.DeleteHolidayCalendar)
+	}
+}
@@ -0,0 +1,321 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"github.com/sambitmohanty1/payment-watchdog/api/internal/services"
+)
+
+// CustomerHandlers handles customer identity endpoints
+type CustomerHandlers struct {
+	identityService    *services.CustomerIdentityService
+	analyticsService   *services.AnalyticsService
+	customer360Service *services.Customer360Service
+	logger             *zap.Logger
+}
+
+// NewCustomerHandlers creates new customer handlers
+func NewCustomerHandlers(
+	identityService *services.CustomerIdentityService,
+	analyticsService *services.AnalyticsService,
+	customer360Service *services.Customer360Service,
+	logger *zap.Logger,
+) *CustomerHandlers {
+	return &CustomerHandlers{
+		identityService:    identityService,
+		analyticsService:   analyticsService,
+		customer360Service: customer360Service,
+		logger:             logger,
+	}
+}
+
+// GetCustomer360 returns the aggregated Customer 360 view. A provider customer ID
+// may be qualified with the provider query parameter. Each section is paginated
+// independently with <section>_page and <section>_limit query parameters.
+func (h *CustomerHandlers) GetCustomer360(c *gin.Context) {
+	companyID := c.Query("company_id")
+	if companyID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "company_id query parameter is required"})
+		return
+	}
+
+	opts := services.Customer360Options{
+		Provider:       c.Query("provider"),
+		Failures:       parseSectionPage(c, "failures"),
+		Invoices:       parseSectionPage(c, "invoices"),
+		Executions:     parseSectionPage(c, "executions"),
+		Actions:        parseSectionPage(c, "actions"),
+		Communications: parseSectionPage(c, "communications"),
+		Timeline:       parseSectionPage(c, "timeline"),
+	}
+
+	customer, err := h.customer360Service.GetCustomer360(c.Request.Context(), companyID, c.Param("id"), opts)
+	if err != nil {
+		if errors.Is(err, services.ErrCustomerNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Customer not found"})
+			return
+		}
+		if errors.Is(err, services.ErrAmbiguousCustomerID) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "provider query parameter is required for this customer ID"})
+			return
+		}
+		h.logger.Error("Failed to get customer 360", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get customer"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":       customer,
+		"risk_level": getRiskLevel(customer.RiskScore),
+	})
+}
+
+// ResolveIdentity links a provider customer record to a customer identity
+func (h *CustomerHandlers) ResolveIdentity(c *gin.Context) {
+	companyID := c.Query("company_id")
+	if companyID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "company_id query parameter is required"})
+		return
+	}
+
+	var record services.CustomerRecord
+	if err := c.ShouldBindJSON(&record); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	record.CompanyID = companyID
+
+	if record.Provider == "" || record.ProviderCustomerID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "provider and provider_customer_id are required"})
+		return
+	}
+
+	identity, err := h.identityService.ResolveCustomer(c.Request.Context(), record)
+	if err != nil {
+		h.logger.Error("Failed to resolve customer identity", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to resolve customer identity"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": identity})
+}
+
+// GetIdentity returns a customer identity by internal ID or any linked provider
+// customer ID, qualified with the provider query parameter
+func (h *CustomerHandlers) GetIdentity(c *gin.Context) {
+	companyID := c.Query("company_id")
+	if companyID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "company_id query parameter is required"})
+		return
+	}
+
+	identity, err := h.identityService.FindIdentity(c.Request.Context(), companyID, c.Query("provider"), c.Param("id"))
+	if err != nil {
+		h.respondIdentityError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": identity})
+}
+
+// GetIdentityRiskScore returns the risk score computed across all linked provider records
+func (h *CustomerHandlers) GetIdentityRiskScore(c *gin.Context) {
+	companyID := c.Query("company_id")
+	if companyID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "company_id query parameter is required"})
+		return
+	}
+
+	customerID := c.Param("id")
+	scoredID := customerID
+	identity, err := h.identityService.FindIdentity(c.Request.Context(), companyID, c.Query("provider"), customerID)
+	if err == nil {
+		scoredID = identity.ID.String()
+	} else if !errors.Is(err, services.ErrCustomerIdentityNotFound) {
+		h.respondIdentityError(c, err)
+		return
+	}
+
+	riskScore, err := h.analyticsService.GetCustomerRiskScore(c.Request.Context(), companyID, c.Query("provider"), scoredID)
+	if err != nil {
+		h.logger.Error("Failed to get customer risk score", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get customer risk score"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"customer_id": customerID,
+		"risk_score":  riskScore,
+		"risk_level":  getRiskLevel(riskScore),
+	})
+}
+
+// MergeIdentities merges the source identity into the identity in the path
+func (h *CustomerHandlers) MergeIdentities(c *gin.Context) {
+	companyID := c.Query("company_id")
+	if companyID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "company_id query parameter is required"})
+		return
+	}
+
+	targetID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid identity ID"})
+		return
+	}
+
+	var req struct {
+		SourceID string `json:"source_id" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	sourceID, err := uuid.Parse(req.SourceID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid source identity ID"})
+		return
+	}
+
+	identity, err := h.identityService.MergeIdentities(c.Request.Context(), companyID, targetID, sourceID)
+	if err != nil {
+		h.respondIdentityError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": identity})
+}
+
+// SplitIdentityLink detaches a provider customer record into its own identity
+func (h *CustomerHandlers) SplitIdentityLink(c *gin.Context) {
+	companyID := c.Query("company_id")
+	if companyID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "company_id query parameter is required"})
+		return
+	}
+
+	linkID, err := uuid.Parse(c.Param("link_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid link ID"})
+		return
+	}
+
+	identity, err := h.identityService.SplitLink(c.Request.Context(), companyID, linkID)
+	if err != nil {
+		h.respondIdentityError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": identity})
+}
+
+// ListIdentitySuggestions returns the pending suggestions to link customers by name
+func (h *CustomerHandlers) ListIdentitySuggestions(c *gin.Context) {
+	companyID := c.Query("company_id")
+	if companyID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "company_id query parameter is required"})
+		return
+	}
+
+	suggestions, err := h.identityService.ListSuggestions(c.Request.Context(), companyID)
+	if err != nil {
+		h.logger.Error("Failed to list identity suggestions", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list identity suggestions"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": suggestions})
+}
+
+// AcceptIdentitySuggestion links the suggested customer records by merging their identities
+func (h *CustomerHandlers) AcceptIdentitySuggestion(c *gin.Context) {
+	companyID := c.Query("company_id")
+	if companyID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "company_id query parameter is required"})
+		return
+	}
+
+	suggestionID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid suggestion ID"})
+		return
+	}
+
+	identity, err := h.identityService.AcceptSuggestion(c.Request.Context(), companyID, suggestionID)
+	if err != nil {
+		h.respondIdentityError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": identity})
+}
+
+// DismissIdentitySuggestion keeps the suggested customer records apart
+func (h *CustomerHandlers) DismissIdentitySuggestion(c *gin.Context) {
+	companyID := c.Query("company_id")
+	if companyID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "company_id query parameter is required"})
+		return
+	}
+
+	suggestionID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid suggestion ID"})
+		return
+	}
+
+	if err := h.identityService.DismissSuggestion(c.Request.Context(), companyID, suggestionID); err != nil {
+		h.respondIdentityError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Suggestion dismissed"})
+}
+
+// parseSectionPage reads <section>_page and <section>_limit query parameters
+func parseSectionPage(c *gin.Context, section string) services.SectionPage {
+	page, _ := strconv.Atoi(c.DefaultQuery(section+"_page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery(section+"_limit", "20"))
+	return services.SectionPage{Page: page, Limit: limit}
+}
+
+func (h *CustomerHandlers) respondIdentityError(c *gin.Context, err error) {
+	if errors.Is(err, services.ErrCustomerIdentityNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Customer identity not found"})
+		return
+	}
+	if errors.Is(err, services.ErrAmbiguousCustomerID) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "provider query parameter is required for this customer ID"})
+		return
+	}
+
+	h.logger.Error("Customer identity request failed", zap.Error(err))
+	c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+}
+
+// RegisterCustomerRoutes registers customer and customer identity routes
+func RegisterCustomerRoutes(router *gin.RouterGroup, customerHandlers *CustomerHandlers) {
+	customers := router.Group("/customers")
+	{
+		customers.GET("/:id", customerHandlers.GetCustomer360)
+	}
+
+	identities := router.Group("/identities")
+	{
+		identities.POST("/resolve", customerHandlers.ResolveIdentity)
+		identities.GET("/suggestions", customerHandlers.ListIdentitySuggestions)
+		identities.POST("/suggestions/:id/accept", customerHandlers.AcceptIdentitySuggestion)
+		identities.POST("/suggestions/:id/dismiss", customerHandlers.DismissIdentitySuggestion)
+		identities.GET("/:id", customerHandlers.GetIdentity)
+		identities.GET("/:id/risk-score", customerHandlers.GetIdentityRiskScore)
+		identities.POST("/:id/merge", customerHandlers.MergeIdentities)
+		identities.POST("/:id/links/:link_id/split", customerHandlers.SplitIdentityLink)
+	}
+}
@@ -0,0 +1,116 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"github.com/sambitmohanty1/payment-watchdog/api/internal/models"
+	"github.com/sambitmohanty1/payment-watchdog/api/internal/services"
+)
+
+// DecisionHandlers handles automation decision log endpoints
+type DecisionHandlers struct {
+	decisionService *services.DecisionLogService
+	logger          *zap.Logger
+}
+
+// NewDecisionHandlers creates new decision handlers
+func NewDecisionHandlers(decisionService *services.DecisionLogService, logger *zap.Logger) *DecisionHandlers {
+	return &DecisionHandlers{
+		decisionService: decisionService,
+		logger:          logger,
+	}
+}
+
+// FailureDecisions explains why automation acted on a payment failure: the rules
+// considered, their condition values and outcomes, and the actions that ran
+func (h *DecisionHandlers) FailureDecisions(c *gin.Context) {
+	companyID := c.Query("company_id")
+	if companyID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "company_id query parameter is required"})
+		return
+	}
+
+	failureID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid failure ID"})
+		return
+	}
+
+	decisionLog, err := h.decisionService.FailureDecisions(c.Request.Context(), companyID, failureID)
+	if err != nil {
+		h.respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": decisionLog})
+}
+
+// ShadowReport compares shadow rule or workflow decisions with the live ones
+func (h *DecisionHandlers) ShadowReport(c *gin.Context) {
+	h.shadowReport(c, c.DefaultQuery("subject_type", models.DecisionSubjectRule))
+}
+
+// WorkflowShadowReport compares shadow workflow decisions with the live ones
+func (h *DecisionHandlers) WorkflowShadowReport(c *gin.Context) {
+	h.shadowReport(c, models.DecisionSubjectWorkflow)
+}
+
+func (h *DecisionHandlers) shadowReport(c *gin.Context, subjectType string) {
+	companyID := c.Query("company_id")
+	if companyID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "company_id query parameter is required"})
+		return
+	}
+
+	var start, end time.Time
+	var err error
+	if raw := c.Query("start"); raw != "" {
+		if start, err = parseFilterDate(raw, false); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "start must be YYYY-MM-DD or RFC3339"})
+			return
+		}
+	}
+	if raw := c.Query("end"); raw != "" {
+		if end, err = parseFilterDate(raw, true); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "end must be YYYY-MM-DD or RFC3339"})
+			return
+		}
+	}
+
+	report, err := h.decisionService.ShadowReport(c.Request.Context(), companyID, subjectType, start, end)
+	if err != nil {
+		h.respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": report})
+}
+
+func (h *DecisionHandlers) respondError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrPaymentFailureNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Payment failure not found"})
+	case errors.Is(err, services.ErrInvalidShadowReport):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		h.logger.Error("Decision log request failed", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Decision log request failed"})
+	}
+}
+
+// RegisterDecisionRoutes registers automation decision routes, including the
+// shadow report alongside the recovery workflow routes
+func RegisterDecisionRoutes(router *gin.RouterGroup, decisionHandlers *DecisionHandlers) {
+	decisionGroup := router.Group("/decisions")
+	{
+		decisionGroup.GET("/shadow-report", decisionHandlers.ShadowReport)
+	}
+	router.GET("/failures/:id/decisions", decisionHandlers.FailureDecisions)
+	router.GET("/recovery/workflows/shadow-report", decisionHandlers.WorkflowShadowReport)
+}
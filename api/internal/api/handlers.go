@@ -1,9 +1,12 @@
 package api
 
 import (
+	"errors"
 	"fmt"
+	"math"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/sambitmohanty1/payment-watchdog/api/internal/models"
@@ -23,6 +26,7 @@ type Handlers struct {
 	analyticsService         *services.AnalyticsService
 	recoveryService          *services.RecoveryOrchestrationService
 	communicationService     *services.CommunicationService
+	exportService            *services.ExportService
 	recoveryHandlers         *RecoveryHandlers
 	logger                   *zap.Logger
 }
@@ -37,6 +41,7 @@ func NewHandlers(
 	analyticsService *services.AnalyticsService,
 	recoveryService *services.RecoveryOrchestrationService,
 	communicationService *services.CommunicationService,
+	exportService *services.ExportService,
 	logger *zap.Logger,
 ) *Handlers {
 	recoveryHandlers := NewRecoveryHandlers(recoveryService, communicationService)
@@ -50,6 +55,7 @@ func NewHandlers(
 		analyticsService:      analyticsService,
 		recoveryService:       recoveryService,
 		communicationService:  communicationService,
+		exportService:         exportService,
 		recoveryHandlers:      recoveryHandlers,
 		logger:                logger,
 	}
@@ -63,112 +69,134 @@ func (h *Handlers) GetPaymentFailures(c *gin.Context) {
 		return
 	}
 
-	// Parse pagination parameters
-	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
-	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
-	if page < 1 {
-		page = 1
-	}
-	if limit < 1 || limit > 100 {
-		limit = 20
+	filter, err := parsePaymentFailureFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
 	}
 
-	// Parse filters
-	filters := make(map[string]interface{})
-	if status := c.Query("status"); status != "" {
-		filters["status"] = status
+	result, err := h.paymentFailureService.GetPaymentFailures(c.Request.Context(), companyID, filter)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidPaymentFailureQuery) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		h.logger.Error("Failed to get payment failures", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get payment failures"})
+		return
 	}
-	if provider := c.Query("provider_id"); provider != "" {
-		filters["provider_id"] = provider
+
+	pagination := gin.H{
+		"limit":       result.Limit,
+		"has_more":    result.HasMore,
+		"next_cursor": result.NextCursor,
 	}
-	if customerEmail := c.Query("customer_email"); customerEmail != "" {
-		filters["customer_email"] = customerEmail
+	if filter.Cursor == "" {
+		pagination["page"] = result.Page
+		pagination["total"] = result.Total
+		pagination["total_pages"] = (result.Total + int64(result.Limit) - 1) / int64(result.Limit)
+	} else if filter.IncludeTotal {
+		pagination["total"] = result.Total
 	}
-	if failureReason := c.Query("failure_reason"); failureReason != "" {
-		filters["failure_reason"] = failureReason
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":       result.Failures,
+		"filters":    filter,
+		"pagination": pagination,
+	})
+}
+
+// parsePaymentFailureFilter builds a payment failure filter from query parameters.
+// List parameters accept comma-separated or repeated values; amounts are in major
+// currency units; dates accept YYYY-MM-DD or RFC3339.
+func parsePaymentFailureFilter(c *gin.Context) (*services.PaymentFailureFilter, error) {
+	filter := &services.PaymentFailureFilter{
+		Statuses:      parseListParam(c, "status"),
+		Providers:     append(parseListParam(c, "provider"), parseListParam(c, "provider_id")...),
+		Reasons:       parseListParam(c, "failure_reason"),
+		Tags:          parseListParam(c, "tags"),
+		MatchAllTags:  c.Query("tags_match") == "all",
+		Segments:      parseListParam(c, "segment"),
+		Currency:      c.Query("currency"),
+		CustomerID:    c.Query("customer_id"),
+		CustomerEmail: c.Query("customer_email"),
+		Query:         c.Query("q"),
+		Cursor:        c.Query("cursor"),
+		IncludeTotal:  c.Query("include_total") == "true",
+	}
+
+	filter.Page, _ = strconv.Atoi(c.DefaultQuery("page", "1"))
+	filter.Limit, _ = strconv.Atoi(c.DefaultQuery("limit", "20"))
+
+	for param, dest := range map[string]**int64{"min_amount": &filter.MinAmountCents, "max_amount": &filter.MaxAmountCents} {
+		if raw := c.Query(param); raw != "" {
+			amount, err := strconv.ParseFloat(raw, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid %s: %s", param, raw)
+			}
+			cents := int64(math.Round(amount * 100))
+			*dest = &cents
+		}
 	}
-	if startDateStr := c.Query("start_date"); startDateStr != "" {
-		if startDate, err := time.Parse("2006-01-02", startDateStr); err == nil {
-			filters["start_date"] = startDate
+
+	dates := []struct {
+		param      string
+		dest       **time.Time
+		endOfRange bool
+	}{
+		{"start_date", &filter.StartDate, false},
+		{"end_date", &filter.EndDate, true},
+		{"due_from", &filter.DueFrom, false},
+		{"due_to", &filter.DueTo, true},
+	}
+	for _, d := range dates {
+		if raw := c.Query(d.param); raw != "" {
+			t, err := parseFilterDate(raw, d.endOfRange)
+			if err != nil {
+				return nil, fmt.Errorf("invalid %s: %s", d.param, raw)
+			}
+			*d.dest = &t
 		}
 	}
-	if endDateStr := c.Query("end_date"); endDateStr != "" {
-		if endDate, err := time.Parse("2006-01-02", endDateStr); err == nil {
-			filters["end_date"] = endDate
+
+	if sortExpr := c.Query("sort"); sortExpr != "" {
+		sort, err := services.ParseSortFields(sortExpr)
+		if err != nil {
+			return nil, err
 		}
+		filter.Sort = sort
 	}
 
-	// For MVP demonstration, return sample data when no real data exists
-	// This allows the frontend to show how data visualization works
-	sampleFailures := []gin.H{
-		{
-			"id":                  "pf-001",
-			"company_id":          companyID,
-			"provider_id":         "stripe",
-			"event_id":            "evt_123456789",
-			"event_type":          "payment_intent.payment_failed",
-			"payment_intent_id":   "pi_123456789",
-			"amount":              1250.00,
-			"currency":            "AUD",
-			"customer_id":         "cus_123456789",
-			"customer_email":      "john.doe@example.com",
-			"customer_name":       "John Doe",
-			"failure_reason":      "insufficient_funds",
-			"failure_code":        "card_declined",
-			"failure_message":     "Your card was declined.",
-			"status":              "received",
-			"webhook_received_at": time.Now().UTC().Format(time.RFC3339),
-			"created_at":          time.Now().UTC().Format(time.RFC3339),
-		},
-		{
-			"id":                  "pf-002",
-			"company_id":          companyID,
-			"provider_id":         "stripe",
-			"event_id":            "evt_123456790",
-			"event_type":          "payment_intent.payment_failed",
-			"payment_intent_id":   "pi_123456790",
-			"amount":              750.50,
-			"currency":            "AUD",
-			"customer_id":         "cus_123456790",
-			"customer_email":      "jane.smith@example.com",
-			"customer_name":       "Jane Smith",
-			"failure_reason":      "card_declined",
-			"failure_code":        "card_declined",
-			"failure_message":     "Your card was declined.",
-			"status":              "processing",
-			"webhook_received_at": time.Now().UTC().Format(time.RFC3339),
-			"created_at":          time.Now().UTC().Format(time.RFC3339),
-		},
-		{
-			"id":                  "pf-003",
-			"company_id":          companyID,
-			"provider_id":         "paypal",
-			"event_id":            "evt_123456791",
-			"event_type":          "payment_intent.payment_failed",
-			"payment_intent_id":   "pi_123456791",
-			"amount":              2000.00,
-			"currency":            "AUD",
-			"customer_id":         "cus_123456791",
-			"customer_email":      "bob.wilson@example.com",
-			"customer_name":       "Bob Wilson",
-			"failure_reason":      "expired_card",
-			"failure_code":        "expired_card",
-			"failure_message":     "Your card has expired.",
-			"status":              "received",
-			"webhook_received_at": time.Now().UTC().Format(time.RFC3339),
-			"created_at":          time.Now().UTC().Format(time.RFC3339),
-		},
+	return filter, nil
+}
+
+// parseListParam returns comma-separated and repeated values of a query parameter
+func parseListParam(c *gin.Context, key string) []string {
+	var values []string
+	for _, raw := range c.QueryArray(key) {
+		for _, v := range strings.Split(raw, ",") {
+			if v = strings.TrimSpace(v); v != "" {
+				values = append(values, v)
+			}
+		}
 	}
+	return values
+}
 
-	c.JSON(http.StatusOK, gin.H{
-		"data":    sampleFailures,
-		"filters": filters,
-		"pagination": gin.H{
-			"page":  page,
-			"limit": limit,
-			"total": 12,
-		},
-	})
+// parseFilterDate parses YYYY-MM-DD or RFC3339. Plain dates at the end of a range
+// include the whole day.
+func parseFilterDate(raw string, endOfRange bool) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, raw); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse("2006-01-02", raw)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if endOfRange {
+		t = t.Add(24*time.Hour - time.Nanosecond)
+	}
+	return t, nil
 }
 
 // GetPaymentFailure returns a specific payment failure
@@ -365,7 +393,9 @@ func (h *Handlers) GetDashboardStats(c *gin.Context) {
 	})
 }
 
-// ExportData exports data in various formats
+// ExportData exports payment failures, recovery actions, workflow executions or
+// communications as CSV, JSONL or XLSX using the same filters as the list endpoints.
+// Small exports stream in the response; large ones (or async=true) return an export job.
 func (h *Handlers) ExportData(c *gin.Context) {
 	companyID := c.Query("company_id")
 	if companyID == "" {
@@ -373,39 +403,107 @@ func (h *Handlers) ExportData(c *gin.Context) {
 		return
 	}
 
-	dataType := c.Query("type")
-	if dataType == "" {
-		dataType = "payment_failures"
+	filter, err := parsePaymentFailureFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
 	}
 
-	startDateStr := c.Query("start_date")
-	endDateStr := c.Query("end_date")
+	req := &services.ExportRequest{
+		CompanyID: companyID,
+		Dataset:   c.DefaultQuery("type", "payment_failures"),
+		Format:    c.DefaultQuery("format", services.ExportFormatCSV),
+		Filter:    filter,
+	}
 
-	var startDate, endDate time.Time
-	if startDateStr != "" {
-		if parsed, err := time.Parse("2006-01-02", startDateStr); err == nil {
-			startDate = parsed
+	ctx := c.Request.Context()
+	total, err := h.exportService.CountRows(ctx, req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if c.Query("async") == "true" || total > services.SyncExportRowLimit {
+		job, err := h.exportService.StartExportJob(ctx, req)
+		if err != nil {
+			h.logger.Error("Failed to start export job", zap.Error(err))
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start export"})
+			return
 		}
+		c.JSON(http.StatusAccepted, gin.H{"data": job})
+		return
+	}
+
+	fileName := services.ExportFileName(req.Dataset, req.Format, time.Now())
+	c.Header("Content-Type", services.ExportContentType(req.Format))
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", fileName))
+	c.Header("X-Export-Rows", strconv.FormatInt(total, 10))
+	c.Status(http.StatusOK)
+
+	if _, err := h.exportService.Stream(ctx, req, c.Writer); err != nil {
+		// Headers are already sent; the truncated body is all we can signal
+		h.logger.Error("Export stream failed",
+			zap.String("company_id", companyID),
+			zap.String("dataset", req.Dataset),
+			zap.Error(err))
+	}
+}
+
+// GetExportJob returns the status and progress of an export job
+func (h *Handlers) GetExportJob(c *gin.Context) {
+	companyID := c.Query("company_id")
+	if companyID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "company_id query parameter is required"})
+		return
+	}
+
+	jobID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid export job ID"})
+		return
 	}
-	if endDateStr != "" {
-		if parsed, err := time.Parse("2006-01-02", endDateStr); err == nil {
-			endDate = parsed
+
+	job, err := h.exportService.GetExportJob(c.Request.Context(), companyID, jobID)
+	if err != nil {
+		if errors.Is(err, services.ErrExportNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Export job not found"})
+			return
 		}
+		h.logger.Error("Failed to get export job", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get export job"})
+		return
+	}
+
+	var progress float64
+	if job.TotalRows > 0 {
+		progress = float64(job.ProcessedRows) / float64(job.TotalRows) * 100
+	} else if job.Status == "completed" {
+		progress = 100
 	}
 
-	// TODO: Implement actual export functionality
 	c.JSON(http.StatusOK, gin.H{
-		"message":    "Export functionality not yet implemented",
-		"company_id": companyID,
-		"filters": gin.H{
-			"start_date": startDate,
-			"end_date":   endDate,
-		},
-		"pagination": gin.H{
-			"page":  1,
-			"limit": 20,
-			"total": 0,
-		},
+		"data":     job,
+		"progress": progress,
+	})
+}
+
+// DownloadExport serves a completed export file for a download token
+func (h *Handlers) DownloadExport(c *gin.Context) {
+	job, file, err := h.exportService.OpenDownload(c.Request.Context(), c.Param("token"))
+	if err != nil {
+		if errors.Is(err, services.ErrExportNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Export not found or link expired"})
+			return
+		}
+		h.logger.Error("Failed to open export download", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to download export"})
+		return
+	}
+	defer file.Close()
+
+	fileName := services.ExportFileName(job.Dataset, job.Format, job.CreatedAt)
+	c.DataFromReader(http.StatusOK, job.FileSize, services.ExportContentType(job.Format), file, map[string]string{
+		"Content-Disposition": fmt.Sprintf("attachment; filename=%q", fileName),
 	})
 }
 
@@ -564,7 +662,11 @@ func (h *Handlers) AnalyzeCustomerPaymentFailures(c *gin.Context) {
 	}
 
 	// Perform analysis
-	result, err := h.analyticsService.AnalyzeCustomerPaymentFailures(c.Request.Context(), companyID, customerID, timeRange)
+	result, err := h.analyticsService.AnalyzeCustomerPaymentFailures(c.Request.Context(), companyID, c.Query("provider"), customerID, timeRange)
+	if errors.Is(err, services.ErrAmbiguousCustomerID) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "provider query parameter is required for this customer ID"})
+		return
+	}
 	if err != nil {
 		h.logger.Error("Failed to analyze customer payment failures", zap.Error(err))
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to analyze customer payment failures"})
@@ -589,7 +691,11 @@ func (h *Handlers) GetCustomerRiskScore(c *gin.Context) {
 	}
 
 	// Get customer risk score
-	riskScore, err := h.analyticsService.GetCustomerRiskScore(c.Request.Context(), companyID, customerID)
+	riskScore, err := h.analyticsService.GetCustomerRiskScore(c.Request.Context(), companyID, c.Query("provider"), customerID)
+	if errors.Is(err, services.ErrAmbiguousCustomerID) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "provider query parameter is required for this customer ID"})
+		return
+	}
 	if err != nil {
 		h.logger.Error("Failed to get customer risk score", zap.Error(err))
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get customer risk score"})
@@ -2,8 +2,11 @@ package api
 
 import (
 	"encoding/json"
+	"errors"
+	"io"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -16,6 +19,9 @@ import (
 	"github.com/sambitmohanty1/payment-watchdog/api/internal/services"
 )
 
+// maxWorkflowDocumentSize bounds imported and validated workflow definitions
+const maxWorkflowDocumentSize = 512 << 10
+
 // RecoveryHandlers contains handlers for recovery workflow endpoints
 type RecoveryHandlers struct {
 	recoveryService      *services.RecoveryOrchestrationService
@@ -35,14 +41,76 @@ func NewRecoveryHandlers(
 	}
 }
 
+// workflowStepRequest is a workflow step as submitted with a workflow or one of
+// its versions
+type workflowStepRequest struct {
+	StepOrder    int                     `json:"step_order" binding:"required"`
+	StepType     string                  `json:"step_type" binding:"required"`
+	StepName     string                  `json:"step_name" binding:"required"`
+	Description  string                  `json:"description"`
+	Config       map[string]interface{}  `json:"config"`
+	Conditions   map[string]interface{}  `json:"conditions"`
+	DelayMinutes int                     `json:"delay_minutes"`
+	IsParallel   bool                    `json:"is_parallel"`
+	IsCritical   bool                    `json:"is_critical"`
+	Key          string                  `json:"key"`
+	Next         models.StepTransitions  `json:"next"`
+	Join         string                  `json:"join"`
+	Timeout      int                     `json:"timeout_seconds"`
+	RetryPolicy  *models.StepRetryPolicy `json:"retry_policy"`
+}
+
+// workflowSteps converts submitted steps to active workflow steps
+func workflowSteps(stepReqs []workflowStepRequest) ([]models.RecoveryWorkflowStep, error) {
+	steps := make([]models.RecoveryWorkflowStep, 0, len(stepReqs))
+	for _, stepReq := range stepReqs {
+		step := models.RecoveryWorkflowStep{
+			StepOrder:      stepReq.StepOrder,
+			StepType:       stepReq.StepType,
+			StepName:       stepReq.StepName,
+			Description:    stepReq.Description,
+			DelayMinutes:   stepReq.DelayMinutes,
+			IsParallel:     stepReq.IsParallel,
+			IsCritical:     stepReq.IsCritical,
+			IsActive:       true,
+			Key:            stepReq.Key,
+			Next:           stepReq.Next,
+			Join:           stepReq.Join,
+			TimeoutSeconds: stepReq.Timeout,
+			RetryPolicy:    stepReq.RetryPolicy,
+		}
+
+		// Convert config to JSON
+		if stepReq.Config != nil {
+			configJSON, err := json.Marshal(stepReq.Config)
+			if err != nil {
+				return nil, errors.New("Invalid step config")
+			}
+			step.Config = configJSON
+		}
+
+		// Convert conditions to JSON
+		if stepReq.Conditions != nil {
+			conditionsJSON, err := json.Marshal(stepReq.Conditions)
+			if err != nil {
+				return nil, errors.New("Invalid step conditions")
+			}
+			step.Conditions = conditionsJSON
+		}
+
+		steps = append(steps, step)
+	}
+	return steps, nil
+}
+
 // CreateWorkflow creates a new recovery workflow
 func (h *RecoveryHandlers) CreateWorkflow(c *gin.Context) {
 	ctx, span := h.tracer.Start(c.Request.Context(), "create_workflow")
 	defer span.End()
 
-	companyID := c.GetString("company_id")
+	companyID := c.Query("company_id")
 	if companyID == "" {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Company ID required"})
+		c.JSON(http.StatusBadRequest, gin.H{"error": "company_id query parameter is required"})
 		return
 	}
 
@@ -56,17 +124,9 @@ func (h *RecoveryHandlers) CreateWorkflow(c *gin.Context) {
 		Name              string                 `json:"name" binding:"required"`
 		Description       string                 `json:"description"`
 		Priority          int                    `json:"priority"`
+		Shadow            bool                   `json:"shadow"`
 		TriggerConditions map[string]interface{} `json:"trigger_conditions"`
-		Steps             []struct {
-			StepOrder    int                    `json:"step_order" binding:"required"`
-			StepType     string                 `json:"step_type" binding:"required"`
-			StepName     string                 `json:"step_name" binding:"required"`
-			Description  string                 `json:"description"`
-			Config       map[string]interface{} `json:"config"`
-			Conditions   map[string]interface{} `json:"conditions"`
-			DelayMinutes int                    `json:"delay_minutes"`
-			IsParallel   bool                   `json:"is_parallel"`
-		} `json:"steps" binding:"required"`
+		Steps             []workflowStepRequest  `json:"steps" binding:"required"`
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -81,6 +141,7 @@ func (h *RecoveryHandlers) CreateWorkflow(c *gin.Context) {
 		Name:        req.Name,
 		Description: req.Description,
 		Priority:    req.Priority,
+		Shadow:      req.Shadow,
 		IsActive:    true,
 		CreatedBy:   c.GetString("user_id"),
 	}
@@ -97,45 +158,22 @@ func (h *RecoveryHandlers) CreateWorkflow(c *gin.Context) {
 	}
 
 	// Create workflow steps
-	for _, stepReq := range req.Steps {
-		step := models.RecoveryWorkflowStep{
-			StepOrder:    stepReq.StepOrder,
-			StepType:     stepReq.StepType,
-			StepName:     stepReq.StepName,
-			Description:  stepReq.Description,
-			DelayMinutes: stepReq.DelayMinutes,
-			IsParallel:   stepReq.IsParallel,
-			IsActive:     true,
-		}
-
-		// Convert config to JSON
-		if stepReq.Config != nil {
-			configJSON, err := json.Marshal(stepReq.Config)
-			if err != nil {
-				span.RecordError(err)
-				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid step config"})
-				return
-			}
-			step.Config = configJSON
-		}
-
-		// Convert conditions to JSON
-		if stepReq.Conditions != nil {
-			conditionsJSON, err := json.Marshal(stepReq.Conditions)
-			if err != nil {
-				span.RecordError(err)
-				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid step conditions"})
-				return
-			}
-			step.Conditions = conditionsJSON
-		}
-
-		workflow.Steps = append(workflow.Steps, step)
+	steps, err := workflowSteps(req.Steps)
+	if err != nil {
+		span.RecordError(err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
 	}
+	workflow.Steps = steps
 
 	// Save to database
 	if err := h.recoveryService.CreateWorkflow(ctx, workflow); err != nil {
 		span.RecordError(err)
+		if errors.Is(err, services.ErrInvalidWorkflowCondition) || errors.Is(err, services.ErrInvalidWorkflowGraph) ||
+			errors.Is(err, services.ErrInvalidRetryPolicy) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create workflow"})
 		return
 	}
@@ -157,9 +195,9 @@ func (h *RecoveryHandlers) GetWorkflows(c *gin.Context) {
 	ctx, span := h.tracer.Start(c.Request.Context(), "get_workflows")
 	defer span.End()
 
-	companyID := c.GetString("company_id")
+	companyID := c.Query("company_id")
 	if companyID == "" {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Company ID required"})
+		c.JSON(http.StatusBadRequest, gin.H{"error": "company_id query parameter is required"})
 		return
 	}
 
@@ -184,6 +222,9 @@ func (h *RecoveryHandlers) GetWorkflows(c *gin.Context) {
 	if isActive := c.Query("is_active"); isActive != "" {
 		filters["is_active"] = isActive == "true"
 	}
+	if shadow := c.Query("shadow"); shadow != "" {
+		filters["shadow"] = shadow == "true"
+	}
 
 	workflows, total, err := h.recoveryService.GetWorkflows(ctx, companyUUID, filters, page, limit)
 	if err != nil {
@@ -222,7 +263,7 @@ func (h *RecoveryHandlers) GetWorkflow(c *gin.Context) {
 		return
 	}
 
-	companyID := c.GetString("company_id")
+	companyID := c.Query("company_id")
 	companyUUID, err := uuid.Parse(companyID)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid company ID"})
@@ -244,7 +285,8 @@ func (h *RecoveryHandlers) GetWorkflow(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"workflow": workflow})
 }
 
-// UpdateWorkflow updates an existing recovery workflow
+// UpdateWorkflow updates an existing recovery workflow. Changed trigger
+// conditions are published as a new version.
 func (h *RecoveryHandlers) UpdateWorkflow(c *gin.Context) {
 	ctx, span := h.tracer.Start(c.Request.Context(), "update_workflow")
 	defer span.End()
@@ -256,7 +298,7 @@ func (h *RecoveryHandlers) UpdateWorkflow(c *gin.Context) {
 		return
 	}
 
-	companyID := c.GetString("company_id")
+	companyID := c.Query("company_id")
 	companyUUID, err := uuid.Parse(companyID)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid company ID"})
@@ -268,6 +310,7 @@ func (h *RecoveryHandlers) UpdateWorkflow(c *gin.Context) {
 		Description       *string                 `json:"description"`
 		Priority          *int                    `json:"priority"`
 		IsActive          *bool                   `json:"is_active"`
+		Shadow            *bool                   `json:"shadow"`
 		TriggerConditions *map[string]interface{} `json:"trigger_conditions"`
 	}
 
@@ -290,6 +333,9 @@ func (h *RecoveryHandlers) UpdateWorkflow(c *gin.Context) {
 	if req.IsActive != nil {
 		updates["is_active"] = *req.IsActive
 	}
+	if req.Shadow != nil {
+		updates["shadow"] = *req.Shadow
+	}
 	if req.TriggerConditions != nil {
 		conditionsJSON, err := json.Marshal(*req.TriggerConditions)
 		if err != nil {
@@ -302,6 +348,14 @@ func (h *RecoveryHandlers) UpdateWorkflow(c *gin.Context) {
 
 	if err := h.recoveryService.UpdateWorkflow(ctx, workflowUUID, companyUUID, updates); err != nil {
 		span.RecordError(err)
+		if errors.Is(err, services.ErrInvalidWorkflowCondition) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if errors.Is(err, services.ErrWorkflowNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Workflow not found"})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update workflow"})
 		return
 	}
@@ -323,7 +377,7 @@ func (h *RecoveryHandlers) DeleteWorkflow(c *gin.Context) {
 		return
 	}
 
-	companyID := c.GetString("company_id")
+	companyID := c.Query("company_id")
 	companyUUID, err := uuid.Parse(companyID)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid company ID"})
@@ -341,12 +395,422 @@ func (h *RecoveryHandlers) DeleteWorkflow(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "Workflow deleted successfully"})
 }
 
+// ListWorkflowVersions returns the version history of a recovery workflow
+func (h *RecoveryHandlers) ListWorkflowVersions(c *gin.Context) {
+	ctx, span := h.tracer.Start(c.Request.Context(), "list_workflow_versions")
+	defer span.End()
+
+	workflowUUID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid workflow ID"})
+		return
+	}
+
+	companyUUID, err := uuid.Parse(c.Query("company_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid company ID"})
+		return
+	}
+
+	versions, err := h.recoveryService.ListWorkflowVersions(ctx, workflowUUID, companyUUID)
+	if err != nil {
+		span.RecordError(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve workflow versions"})
+		return
+	}
+
+	span.SetAttributes(
+		attribute.String("workflow_id", workflowUUID.String()),
+		attribute.Int("versions_count", len(versions)),
+	)
+
+	c.JSON(http.StatusOK, gin.H{"versions": versions})
+}
+
+// GetWorkflowVersion retrieves one version of a recovery workflow with its steps
+func (h *RecoveryHandlers) GetWorkflowVersion(c *gin.Context) {
+	ctx, span := h.tracer.Start(c.Request.Context(), "get_workflow_version")
+	defer span.End()
+
+	workflowUUID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid workflow ID"})
+		return
+	}
+
+	version, err := strconv.Atoi(c.Param("version"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid workflow version"})
+		return
+	}
+
+	companyUUID, err := uuid.Parse(c.Query("company_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid company ID"})
+		return
+	}
+
+	workflowVersion, err := h.recoveryService.GetWorkflowVersion(ctx, workflowUUID, companyUUID, version)
+	if err != nil {
+		span.RecordError(err)
+		if errors.Is(err, services.ErrWorkflowVersionNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Workflow version not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve workflow version"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"version": workflowVersion})
+}
+
+// PublishWorkflowVersion publishes a new version of a recovery workflow.
+// Omitted steps or trigger conditions carry over from the current version.
+// Executions already started stay on their version until migrated.
+func (h *RecoveryHandlers) PublishWorkflowVersion(c *gin.Context) {
+	ctx, span := h.tracer.Start(c.Request.Context(), "publish_workflow_version")
+	defer span.End()
+
+	workflowUUID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid workflow ID"})
+		return
+	}
+
+	companyUUID, err := uuid.Parse(c.Query("company_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid company ID"})
+		return
+	}
+
+	var req struct {
+		Steps             []workflowStepRequest   `json:"steps"`
+		TriggerConditions *map[string]interface{} `json:"trigger_conditions"`
+		ChangeNote        string                  `json:"change_note"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		span.RecordError(err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	change := services.WorkflowVersionChange{
+		ChangeNote: req.ChangeNote,
+		CreatedBy:  c.GetString("user_id"),
+	}
+	if req.Steps != nil {
+		if change.Steps, err = workflowSteps(req.Steps); err != nil {
+			span.RecordError(err)
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	if req.TriggerConditions != nil {
+		conditionsJSON, err := json.Marshal(*req.TriggerConditions)
+		if err != nil {
+			span.RecordError(err)
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid trigger conditions"})
+			return
+		}
+		change.TriggerConditions = conditionsJSON
+	}
+
+	workflowVersion, err := h.recoveryService.PublishWorkflowVersion(ctx, workflowUUID, companyUUID, change)
+	if err != nil {
+		span.RecordError(err)
+		switch {
+		case errors.Is(err, services.ErrWorkflowNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Workflow not found"})
+		case errors.Is(err, services.ErrInvalidWorkflowCondition) || errors.Is(err, services.ErrInvalidWorkflowGraph) ||
+			errors.Is(err, services.ErrInvalidRetryPolicy):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to publish workflow version"})
+		}
+		return
+	}
+
+	span.SetAttributes(
+		attribute.String("workflow_id", workflowUUID.String()),
+		attribute.Int("version", workflowVersion.Version),
+		attribute.Int("steps_count", len(workflowVersion.Steps)),
+	)
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Workflow version published successfully",
+		"version": workflowVersion,
+	})
+}
+
+// MigrateWorkflowExecutions moves in-flight executions of a recovery workflow
+// from one version to another, mapping old step keys to new ones
+func (h *RecoveryHandlers) MigrateWorkflowExecutions(c *gin.Context) {
+	ctx, span := h.tracer.Start(c.Request.Context(), "migrate_workflow_executions")
+	defer span.End()
+
+	workflowUUID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid workflow ID"})
+		return
+	}
+
+	companyUUID, err := uuid.Parse(c.Query("company_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid company ID"})
+		return
+	}
+
+	var req services.ExecutionMigration
+	if err := c.ShouldBindJSON(&req); err != nil {
+		span.RecordError(err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := h.recoveryService.MigrateExecutions(ctx, workflowUUID, companyUUID, req)
+	if err != nil {
+		span.RecordError(err)
+		switch {
+		case errors.Is(err, services.ErrWorkflowVersionNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case errors.Is(err, services.ErrInvalidExecutionMigration) || errors.Is(err, services.ErrInvalidWorkflowGraph):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to migrate workflow executions"})
+		}
+		return
+	}
+
+	span.SetAttributes(
+		attribute.String("workflow_id", workflowUUID.String()),
+		attribute.Int("migrated_count", len(result.Migrated)),
+		attribute.Int("skipped_count", len(result.Skipped)),
+	)
+
+	c.JSON(http.StatusOK, result)
+}
+
+// SimulateWorkflow previews what a recovery workflow would do for a payment
+// failure, on a virtual clock and without side effects
+func (h *RecoveryHandlers) SimulateWorkflow(c *gin.Context) {
+	ctx, span := h.tracer.Start(c.Request.Context(), "simulate_workflow")
+	defer span.End()
+
+	workflowUUID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid workflow ID"})
+		return
+	}
+
+	companyUUID, err := uuid.Parse(c.Query("company_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid company ID"})
+		return
+	}
+
+	var req services.WorkflowSimulation
+	if err := c.ShouldBindJSON(&req); err != nil {
+		span.RecordError(err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := h.recoveryService.SimulateWorkflow(ctx, workflowUUID, companyUUID, req)
+	if err != nil {
+		span.RecordError(err)
+		switch {
+		case errors.Is(err, services.ErrWorkflowNotFound) || errors.Is(err, services.ErrWorkflowVersionNotFound) ||
+			errors.Is(err, services.ErrPaymentFailureNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case errors.Is(err, services.ErrInvalidSimulation) || errors.Is(err, services.ErrInvalidWorkflowGraph):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to simulate workflow"})
+		}
+		return
+	}
+
+	span.SetAttributes(
+		attribute.String("workflow_id", workflowUUID.String()),
+		attribute.String("status", result.Status),
+		attribute.Int("event_count", len(result.Timeline)),
+	)
+
+	c.JSON(http.StatusOK, result)
+}
+
+// ExportWorkflow returns the current version of a recovery workflow as a
+// definition for source control, in YAML unless ?format=json
+func (h *RecoveryHandlers) ExportWorkflow(c *gin.Context) {
+	ctx, span := h.tracer.Start(c.Request.Context(), "export_workflow")
+	defer span.End()
+
+	workflowUUID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid workflow ID"})
+		return
+	}
+
+	companyUUID, err := uuid.Parse(c.Query("company_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid company ID"})
+		return
+	}
+
+	format := strings.ToLower(c.DefaultQuery("format", "yaml"))
+	definition, err := h.recoveryService.ExportWorkflow(ctx, workflowUUID, companyUUID, format)
+	if err != nil {
+		span.RecordError(err)
+		switch {
+		case errors.Is(err, services.ErrWorkflowNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Workflow not found"})
+		case errors.Is(err, services.ErrInvalidWorkflowDefinition):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to export workflow"})
+		}
+		return
+	}
+
+	contentType := "application/json"
+	if format != "json" {
+		contentType = "application/yaml"
+	}
+	c.Data(http.StatusOK, contentType, definition)
+}
+
+// ImportWorkflow creates a recovery workflow from a JSON or YAML definition, or
+// publishes it as the next version of the company's workflow of the same name
+func (h *RecoveryHandlers) ImportWorkflow(c *gin.Context) {
+	ctx, span := h.tracer.Start(c.Request.Context(), "import_workflow")
+	defer span.End()
+
+	companyUUID, err := uuid.Parse(c.Query("company_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid company ID"})
+		return
+	}
+
+	source, format, ok := readWorkflowDocument(c)
+	if !ok {
+		return
+	}
+
+	workflow, created, err := h.recoveryService.ImportWorkflow(ctx, companyUUID, source, format, c.GetString("user_id"))
+	if err != nil {
+		span.RecordError(err)
+		var definitionErrs services.WorkflowDefinitionErrors
+		switch {
+		case errors.As(err, &definitionErrs):
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid workflow definition", "errors": definitionErrs})
+		case errors.Is(err, services.ErrInvalidWorkflowDefinition) || errors.Is(err, services.ErrInvalidWorkflowCondition) ||
+			errors.Is(err, services.ErrInvalidWorkflowGraph) || errors.Is(err, services.ErrInvalidRetryPolicy):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to import workflow"})
+		}
+		return
+	}
+
+	span.SetAttributes(
+		attribute.String("workflow_id", workflow.ID.String()),
+		attribute.Bool("created", created),
+		attribute.Int("current_version", workflow.CurrentVersion),
+	)
+
+	status, message := http.StatusOK, "Workflow updated from definition"
+	if created {
+		status, message = http.StatusCreated, "Workflow created from definition"
+	}
+	c.JSON(status, gin.H{
+		"message":  message,
+		"created":  created,
+		"workflow": workflow,
+	})
+}
+
+// ValidateWorkflowDefinition checks a JSON or YAML workflow definition without
+// importing it. Problems are listed with the line of the definition they are on.
+func (h *RecoveryHandlers) ValidateWorkflowDefinition(c *gin.Context) {
+	ctx, span := h.tracer.Start(c.Request.Context(), "validate_workflow_definition")
+	defer span.End()
+
+	companyUUID, err := uuid.Parse(c.Query("company_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid company ID"})
+		return
+	}
+
+	source, format, ok := readWorkflowDocument(c)
+	if !ok {
+		return
+	}
+
+	definition, err := h.recoveryService.ValidateWorkflowDefinition(ctx, companyUUID, source, format)
+	if err != nil {
+		var definitionErrs services.WorkflowDefinitionErrors
+		switch {
+		case errors.As(err, &definitionErrs):
+			c.JSON(http.StatusOK, gin.H{"valid": false, "errors": definitionErrs})
+		case errors.Is(err, services.ErrInvalidWorkflowDefinition):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			span.RecordError(err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to validate workflow definition"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"valid": true, "data": definition})
+}
+
+// ValidateConditions type checks trigger or conditional step conditions
+// without saving a workflow
+func (h *RecoveryHandlers) ValidateConditions(c *gin.Context) {
+	var conditions services.WorkflowTriggerConditions
+	if err := c.ShouldBindJSON(&conditions); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := services.ValidateConditions(conditions); err != nil {
+		c.JSON(http.StatusOK, gin.H{"valid": false, "error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"valid": true})
+}
+
+// readWorkflowDocument reads the request body as a workflow definition. YAML is
+// selected by a YAML content type or ?format=yaml; everything else is JSON.
+func readWorkflowDocument(c *gin.Context) ([]byte, string, bool) {
+	source, err := io.ReadAll(io.LimitReader(c.Request.Body, maxWorkflowDocumentSize+1))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read request body"})
+		return nil, "", false
+	}
+	if len(source) > maxWorkflowDocumentSize {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "Workflow definition is too large"})
+		return nil, "", false
+	}
+
+	format := strings.ToLower(c.Query("format"))
+	if format == "" {
+		format = "json"
+		if strings.Contains(c.ContentType(), "yaml") {
+			format = "yaml"
+		}
+	}
+	return source, format, true
+}
+
 // GetWorkflowExecutions retrieves workflow executions
 func (h *RecoveryHandlers) GetWorkflowExecutions(c *gin.Context) {
 	ctx, span := h.tracer.Start(c.Request.Context(), "get_workflow_executions")
 	defer span.End()
 
-	companyID := c.GetString("company_id")
+	companyID := c.Query("company_id")
 	companyUUID, err := uuid.Parse(companyID)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid company ID"})
@@ -395,6 +859,76 @@ func (h *RecoveryHandlers) GetWorkflowExecutions(c *gin.Context) {
 	})
 }
 
+// GetWorkflowExecution retrieves a workflow execution and the path it has
+// taken through the workflow graph
+func (h *RecoveryHandlers) GetWorkflowExecution(c *gin.Context) {
+	ctx, span := h.tracer.Start(c.Request.Context(), "get_workflow_execution")
+	defer span.End()
+
+	executionUUID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid execution ID"})
+		return
+	}
+
+	companyUUID, err := uuid.Parse(c.Query("company_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid company ID"})
+		return
+	}
+
+	execution, err := h.recoveryService.GetWorkflowExecution(ctx, executionUUID, companyUUID)
+	if err != nil {
+		if errors.Is(err, services.ErrWorkflowExecutionNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Execution not found"})
+			return
+		}
+		span.RecordError(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve execution"})
+		return
+	}
+
+	span.SetAttributes(
+		attribute.String("execution_id", execution.ID.String()),
+		attribute.Int("path_length", len(execution.Path)),
+	)
+
+	c.JSON(http.StatusOK, gin.H{"execution": execution})
+}
+
+// GetStepAttempts retrieves the attempt records of a workflow execution's steps
+func (h *RecoveryHandlers) GetStepAttempts(c *gin.Context) {
+	ctx, span := h.tracer.Start(c.Request.Context(), "get_step_attempts")
+	defer span.End()
+
+	executionUUID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid execution ID"})
+		return
+	}
+
+	companyUUID, err := uuid.Parse(c.Query("company_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid company ID"})
+		return
+	}
+
+	attempts, err := h.recoveryService.GetStepAttempts(ctx, executionUUID, companyUUID)
+	if err != nil {
+		if errors.Is(err, services.ErrWorkflowExecutionNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Execution not found"})
+			return
+		}
+		span.RecordError(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve step attempts"})
+		return
+	}
+
+	span.SetAttributes(attribute.Int("attempts_count", len(attempts)))
+
+	c.JSON(http.StatusOK, gin.H{"attempts": attempts})
+}
+
 // PauseWorkflowExecution pauses a running workflow execution
 func (h *RecoveryHandlers) PauseWorkflowExecution(c *gin.Context) {
 	ctx, span := h.tracer.Start(c.Request.Context(), "pause_workflow_execution")
@@ -492,7 +1026,7 @@ func (h *RecoveryHandlers) TriggerWorkflow(c *gin.Context) {
 		return
 	}
 
-	companyID := c.GetString("company_id")
+	companyID := c.Query("company_id")
 	companyUUID, err := uuid.Parse(companyID)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid company ID"})
@@ -520,7 +1054,7 @@ func (h *RecoveryHandlers) CreateTemplate(c *gin.Context) {
 	ctx, span := h.tracer.Start(c.Request.Context(), "create_template")
 	defer span.End()
 
-	companyID := c.GetString("company_id")
+	companyID := c.Query("company_id")
 	companyUUID, err := uuid.Parse(companyID)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid company ID"})
@@ -601,7 +1135,7 @@ func (h *RecoveryHandlers) GetTemplates(c *gin.Context) {
 	ctx, span := h.tracer.Start(c.Request.Context(), "get_templates")
 	defer span.End()
 
-	companyID := c.GetString("company_id")
+	companyID := c.Query("company_id")
 	companyUUID, err := uuid.Parse(companyID)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid company ID"})
@@ -648,7 +1182,7 @@ func (h *RecoveryHandlers) GetRecoveryMetrics(c *gin.Context) {
 	ctx, span := h.tracer.Start(c.Request.Context(), "get_recovery_metrics")
 	defer span.End()
 
-	companyID := c.GetString("company_id")
+	companyID := c.Query("company_id")
 	companyUUID, err := uuid.Parse(companyID)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid company ID"})
@@ -683,3 +1217,35 @@ func (h *RecoveryHandlers) GetRecoveryMetrics(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{"metrics": metrics})
 }
+
+// RegisterRecoveryRoutes registers recovery workflow, execution and
+// communication template routes
+func RegisterRecoveryRoutes(router *gin.RouterGroup, recoveryHandlers *RecoveryHandlers) {
+	recovery := router.Group("/recovery")
+	{
+		recovery.POST("/workflows", recoveryHandlers.CreateWorkflow)
+		recovery.GET("/workflows", recoveryHandlers.GetWorkflows)
+		recovery.POST("/workflows/trigger", recoveryHandlers.TriggerWorkflow)
+		recovery.POST("/workflows/import", recoveryHandlers.ImportWorkflow)
+		recovery.POST("/workflows/validate", recoveryHandlers.ValidateWorkflowDefinition)
+		recovery.GET("/workflows/:id", recoveryHandlers.GetWorkflow)
+		recovery.PUT("/workflows/:id", recoveryHandlers.UpdateWorkflow)
+		recovery.DELETE("/workflows/:id", recoveryHandlers.DeleteWorkflow)
+		recovery.GET("/workflows/:id/export", recoveryHandlers.ExportWorkflow)
+		recovery.GET("/workflows/:id/versions", recoveryHandlers.ListWorkflowVersions)
+		recovery.POST("/workflows/:id/versions", recoveryHandlers.PublishWorkflowVersion)
+		recovery.GET("/workflows/:id/versions/:version", recoveryHandlers.GetWorkflowVersion)
+		recovery.POST("/workflows/:id/migrate-executions", recoveryHandlers.MigrateWorkflowExecutions)
+		recovery.POST("/workflows/:id/simulate", recoveryHandlers.SimulateWorkflow)
+		recovery.POST("/conditions/validate", recoveryHandlers.ValidateConditions)
+		recovery.GET("/executions", recoveryHandlers.GetWorkflowExecutions)
+		recovery.GET("/executions/:id", recoveryHandlers.GetWorkflowExecution)
+		recovery.GET("/executions/:id/attempts", recoveryHandlers.GetStepAttempts)
+		recovery.POST("/executions/:id/pause", recoveryHandlers.PauseWorkflowExecution)
+		recovery.POST("/executions/:id/resume", recoveryHandlers.ResumeWorkflowExecution)
+		recovery.POST("/executions/:id/cancel", recoveryHandlers.CancelWorkflowExecution)
+		recovery.POST("/templates", recoveryHandlers.CreateTemplate)
+		recovery.GET("/templates", recoveryHandlers.GetTemplates)
+		recovery.GET("/metrics", recoveryHandlers.GetRecoveryMetrics)
+	}
+}
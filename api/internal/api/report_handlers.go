@@ -0,0 +1,248 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"github.com/sambitmohanty1/payment-watchdog/api/internal/models"
+	"github.com/sambitmohanty1/payment-watchdog/api/internal/services"
+)
+
+// ReportHandlers handles scheduled report endpoints
+type ReportHandlers struct {
+	reportService *services.ReportService
+	logger        *zap.Logger
+}
+
+// NewReportHandlers creates new report handlers
+func NewReportHandlers(reportService *services.ReportService, logger *zap.Logger) *ReportHandlers {
+	return &ReportHandlers{
+		reportService: reportService,
+		logger:        logger,
+	}
+}
+
+// reportScheduleRequest is the body for creating or replacing a report schedule
+type reportScheduleRequest struct {
+	Name           string   `json:"name" binding:"required"`
+	CronExpression string   `json:"cron_expression" binding:"required"`
+	Timezone       string   `json:"timezone"`
+	Format         string   `json:"format"`
+	Recipients     []string `json:"recipients" binding:"required"`
+	PeriodDays     int      `json:"period_days"`
+	TopCustomers   int      `json:"top_customers"`
+	IsActive       *bool    `json:"is_active"`
+}
+
+func (r *reportScheduleRequest) toSchedule(companyID string) *models.ReportSchedule {
+	active := true
+	if r.IsActive != nil {
+		active = *r.IsActive
+	}
+	return &models.ReportSchedule{
+		CompanyID:      companyID,
+		Name:           r.Name,
+		CronExpression: r.CronExpression,
+		Timezone:       r.Timezone,
+		Format:         r.Format,
+		Recipients:     r.Recipients,
+		PeriodDays:     r.PeriodDays,
+		TopCustomers:   r.TopCustomers,
+		IsActive:       active,
+	}
+}
+
+// CreateSchedule creates a report schedule
+func (h *ReportHandlers) CreateSchedule(c *gin.Context) {
+	companyID := c.Query("company_id")
+	if companyID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "company_id query parameter is required"})
+		return
+	}
+
+	var req reportScheduleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	schedule := req.toSchedule(companyID)
+	schedule.CreatedBy = c.GetString("user_id")
+	if err := h.reportService.CreateSchedule(c.Request.Context(), schedule); err != nil {
+		h.respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"data": schedule})
+}
+
+// ListSchedules lists a company's report schedules
+func (h *ReportHandlers) ListSchedules(c *gin.Context) {
+	companyID := c.Query("company_id")
+	if companyID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "company_id query parameter is required"})
+		return
+	}
+
+	schedules, err := h.reportService.ListSchedules(c.Request.Context(), companyID)
+	if err != nil {
+		h.respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": schedules})
+}
+
+// GetSchedule returns a report schedule
+func (h *ReportHandlers) GetSchedule(c *gin.Context) {
+	companyID, id, ok := h.scheduleParams(c)
+	if !ok {
+		return
+	}
+
+	schedule, err := h.reportService.GetSchedule(c.Request.Context(), companyID, id)
+	if err != nil {
+		h.respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": schedule})
+}
+
+// UpdateSchedule replaces a report schedule's settings
+func (h *ReportHandlers) UpdateSchedule(c *gin.Context) {
+	companyID, id, ok := h.scheduleParams(c)
+	if !ok {
+		return
+	}
+
+	var req reportScheduleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	schedule, err := h.reportService.UpdateSchedule(c.Request.Context(), companyID, id, req.toSchedule(companyID))
+	if err != nil {
+		h.respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": schedule})
+}
+
+// DeleteSchedule deletes a report schedule and its delivery history
+func (h *ReportHandlers) DeleteSchedule(c *gin.Context) {
+	companyID, id, ok := h.scheduleParams(c)
+	if !ok {
+		return
+	}
+
+	if err := h.reportService.DeleteSchedule(c.Request.Context(), companyID, id); err != nil {
+		h.respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Report schedule deleted"})
+}
+
+// RunSchedule builds and sends a report immediately, outside the schedule
+func (h *ReportHandlers) RunSchedule(c *gin.Context) {
+	companyID, id, ok := h.scheduleParams(c)
+	if !ok {
+		return
+	}
+
+	schedule, err := h.reportService.GetSchedule(c.Request.Context(), companyID, id)
+	if err != nil {
+		h.respondError(c, err)
+		return
+	}
+
+	delivery, err := h.reportService.RunSchedule(c.Request.Context(), schedule, nil)
+	if err != nil {
+		h.respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": delivery})
+}
+
+// ListDeliveries returns the delivery history of a report schedule
+func (h *ReportHandlers) ListDeliveries(c *gin.Context) {
+	companyID, id, ok := h.scheduleParams(c)
+	if !ok {
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	deliveries, total, err := h.reportService.ListDeliveries(c.Request.Context(), companyID, id, page, limit)
+	if err != nil {
+		h.respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": deliveries,
+		"pagination": gin.H{
+			"page":        page,
+			"limit":       limit,
+			"total":       total,
+			"total_pages": (total + int64(limit) - 1) / int64(limit),
+		},
+	})
+}
+
+func (h *ReportHandlers) scheduleParams(c *gin.Context) (string, uuid.UUID, bool) {
+	companyID := c.Query("company_id")
+	if companyID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "company_id query parameter is required"})
+		return "", uuid.Nil, false
+	}
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid schedule ID"})
+		return "", uuid.Nil, false
+	}
+	return companyID, id, true
+}
+
+func (h *ReportHandlers) respondError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrReportScheduleNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Report schedule not found"})
+	case errors.Is(err, services.ErrInvalidReportSchedule):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		h.logger.Error("Report request failed", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Report request failed"})
+	}
+}
+
+// RegisterReportRoutes registers scheduled report routes
+func RegisterReportRoutes(router *gin.RouterGroup, reportHandlers *ReportHandlers) {
+	reports := router.Group("/reports/schedules")
+	{
+		reports.POST("", reportHandlers.CreateSchedule)
+		reports.GET("", reportHandlers.ListSchedules)
+		reports.GET("/:id", reportHandlers.GetSchedule)
+		reports.PUT("/:id", reportHandlers.UpdateSchedule)
+		reports.DELETE("/:id", reportHandlers.DeleteSchedule)
+		reports.POST("/:id/run", reportHandlers.RunSchedule)
+		reports.GET("/:id/deliveries", reportHandlers.ListDeliveries)
+	}
+}
@@ -0,0 +1,447 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"github.com/sambitmohanty1/payment-watchdog/api/internal/rules"
+	"github.com/sambitmohanty1/payment-watchdog/api/internal/services"
+)
+
+// maxRuleDocumentSize bounds the size of a submitted rule document
+const maxRuleDocumentSize = 256 << 10
+
+// RuleHandlers handles declarative rule definition endpoints
+type RuleHandlers struct {
+	definitionService *services.RuleDefinitionService
+	backtestService   *services.RuleBacktestService
+	logger            *zap.Logger
+}
+
+// NewRuleHandlers creates new rule handlers
+func NewRuleHandlers(definitionService *services.RuleDefinitionService, backtestService *services.RuleBacktestService, logger *zap.Logger) *RuleHandlers {
+	return &RuleHandlers{
+		definitionService: definitionService,
+		backtestService:   backtestService,
+		logger:            logger,
+	}
+}
+
+// ListActions returns the action catalogue available to rule definitions
+func (h *RuleHandlers) ListActions(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"data":   h.definitionService.Catalogue().List(),
+		"fields": ruleFieldDescriptions(),
+	})
+}
+
+// ValidateDefinition checks a rule document without saving it
+func (h *RuleHandlers) ValidateDefinition(c *gin.Context) {
+	source, format, ok := readRuleDocument(c)
+	if !ok {
+		return
+	}
+
+	def, err := h.definitionService.Validate(source, format)
+	if err != nil {
+		c.JSON(http.StatusOK, gin.H{"valid": false, "error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"valid": true, "data": def})
+}
+
+// CreateDefinition stores a new rule definition
+func (h *RuleHandlers) CreateDefinition(c *gin.Context) {
+	companyID := c.Query("company_id")
+	if companyID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "company_id query parameter is required"})
+		return
+	}
+
+	source, format, ok := readRuleDocument(c)
+	if !ok {
+		return
+	}
+
+	record, err := h.definitionService.Create(c.Request.Context(), companyID, source, format, c.GetString("user_id"))
+	if err != nil {
+		h.respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"data": record, "analysis": h.analysis(c, companyID)})
+}
+
+// ListDefinitions lists a company's rule definitions
+func (h *RuleHandlers) ListDefinitions(c *gin.Context) {
+	companyID := c.Query("company_id")
+	if companyID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "company_id query parameter is required"})
+		return
+	}
+
+	records, err := h.definitionService.List(c.Request.Context(), companyID)
+	if err != nil {
+		h.respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": records})
+}
+
+// GetDefinition returns a rule definition
+func (h *RuleHandlers) GetDefinition(c *gin.Context) {
+	companyID, id, ok := h.definitionParams(c)
+	if !ok {
+		return
+	}
+
+	record, err := h.definitionService.Get(c.Request.Context(), companyID, id)
+	if err != nil {
+		h.respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": record})
+}
+
+// UpdateDefinition replaces a rule definition
+func (h *RuleHandlers) UpdateDefinition(c *gin.Context) {
+	companyID, id, ok := h.definitionParams(c)
+	if !ok {
+		return
+	}
+
+	source, format, ok := readRuleDocument(c)
+	if !ok {
+		return
+	}
+
+	record, err := h.definitionService.Update(c.Request.Context(), companyID, id, source, format, c.GetString("user_id"))
+	if err != nil {
+		h.respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": record, "analysis": h.analysis(c, companyID)})
+}
+
+// DeleteDefinition deletes a rule definition
+func (h *RuleHandlers) DeleteDefinition(c *gin.Context) {
+	companyID, id, ok := h.definitionParams(c)
+	if !ok {
+		return
+	}
+
+	if err := h.definitionService.Delete(c.Request.Context(), companyID, id, c.GetString("user_id")); err != nil {
+		h.respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Rule definition deleted"})
+}
+
+// Analyze reports overlapping, contradictory, unreachable and unused rules in the
+// current definitions, or in ?version=<version>
+func (h *RuleHandlers) Analyze(c *gin.Context) {
+	companyID := c.Query("company_id")
+	if companyID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "company_id query parameter is required"})
+		return
+	}
+
+	version := 0
+	if raw := c.Query("version"); raw != "" {
+		var err error
+		if version, err = strconv.Atoi(raw); err != nil || version < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "version must be a version number"})
+			return
+		}
+	}
+
+	analysis, err := h.definitionService.Analyze(c.Request.Context(), companyID, version)
+	if err != nil {
+		h.respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": analysis})
+}
+
+// analysis analyses a company's rules after a save. A failed analysis is logged
+// and left out of the response rather than failing a save that succeeded.
+func (h *RuleHandlers) analysis(c *gin.Context, companyID string) *rules.RuleSetAnalysis {
+	analysis, err := h.definitionService.Analyze(c.Request.Context(), companyID, 0)
+	if err != nil {
+		h.logger.Error("Rule set analysis failed", zap.String("company_id", companyID), zap.Error(err))
+		return nil
+	}
+	return analysis
+}
+
+// ListVersions returns a company's rule set version history
+func (h *RuleHandlers) ListVersions(c *gin.Context) {
+	companyID := c.Query("company_id")
+	if companyID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "company_id query parameter is required"})
+		return
+	}
+
+	versions, err := h.definitionService.ListVersions(c.Request.Context(), companyID)
+	if err != nil {
+		h.respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": versions})
+}
+
+// GetVersion returns a rule set version with its rules
+func (h *RuleHandlers) GetVersion(c *gin.Context) {
+	companyID, version, ok := h.versionParams(c)
+	if !ok {
+		return
+	}
+
+	record, err := h.definitionService.GetVersion(c.Request.Context(), companyID, version)
+	if err != nil {
+		h.respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": record})
+}
+
+// DiffVersion compares a rule set version with ?against=<version>, or with the
+// published version by default
+func (h *RuleHandlers) DiffVersion(c *gin.Context) {
+	companyID, version, ok := h.versionParams(c)
+	if !ok {
+		return
+	}
+
+	against := 0
+	if raw := c.Query("against"); raw != "" {
+		var err error
+		if against, err = strconv.Atoi(raw); err != nil || against < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "against must be a version number"})
+			return
+		}
+	}
+
+	diff, err := h.definitionService.DiffVersions(c.Request.Context(), companyID, version, against)
+	if err != nil {
+		h.respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": diff})
+}
+
+// PublishVersion makes a draft rule set version live
+func (h *RuleHandlers) PublishVersion(c *gin.Context) {
+	companyID, version, ok := h.versionParams(c)
+	if !ok {
+		return
+	}
+
+	record, err := h.definitionService.Publish(c.Request.Context(), companyID, version, c.GetString("user_id"))
+	if err != nil {
+		h.respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": record})
+}
+
+// RollbackVersion republishes the rules of an earlier published version
+func (h *RuleHandlers) RollbackVersion(c *gin.Context) {
+	companyID, version, ok := h.versionParams(c)
+	if !ok {
+		return
+	}
+
+	record, err := h.definitionService.Rollback(c.Request.Context(), companyID, version, c.GetString("user_id"))
+	if err != nil {
+		h.respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": record})
+}
+
+// Backtest replays historical payment failures through a stored or draft rule set
+// and compares the outcome with the active rule set
+func (h *RuleHandlers) Backtest(c *gin.Context) {
+	companyID := c.Query("company_id")
+	if companyID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "company_id query parameter is required"})
+		return
+	}
+
+	var req struct {
+		Start         string            `json:"start"`
+		End           string            `json:"end"`
+		IncludeActive bool              `json:"include_active"`
+		RuleIDs       []uuid.UUID       `json:"rule_ids"`
+		Rules         []json.RawMessage `json:"rules"`
+		SampleSize    int               `json:"sample_size"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	backtest := services.RuleBacktestRequest{
+		Selection: services.RuleSetSelection{
+			IncludeActive: req.IncludeActive,
+			RuleIDs:       req.RuleIDs,
+		},
+		SampleSize: req.SampleSize,
+	}
+	var err error
+	if req.Start != "" {
+		if backtest.Start, err = parseFilterDate(req.Start, false); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "start must be YYYY-MM-DD or RFC3339"})
+			return
+		}
+	}
+	if req.End != "" {
+		if backtest.End, err = parseFilterDate(req.End, true); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "end must be YYYY-MM-DD or RFC3339"})
+			return
+		}
+	}
+	for i, raw := range req.Rules {
+		def, err := rules.ParseRuleDefinition(raw, "json")
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("rules[%d]: %v", i, err)})
+			return
+		}
+		backtest.Selection.Drafts = append(backtest.Selection.Drafts, *def)
+	}
+
+	result, err := h.backtestService.Run(c.Request.Context(), companyID, backtest)
+	if err != nil {
+		h.respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": result})
+}
+
+// readRuleDocument reads the request body as a rule document. YAML is selected by
+// a YAML content type or ?format=yaml; everything else is treated as JSON.
+func readRuleDocument(c *gin.Context) ([]byte, string, bool) {
+	source, err := io.ReadAll(io.LimitReader(c.Request.Body, maxRuleDocumentSize+1))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read request body"})
+		return nil, "", false
+	}
+	if len(source) > maxRuleDocumentSize {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "Rule document is too large"})
+		return nil, "", false
+	}
+
+	format := strings.ToLower(c.Query("format"))
+	if format == "" {
+		format = "json"
+		if strings.Contains(c.ContentType(), "yaml") {
+			format = "yaml"
+		}
+	}
+	return source, format, true
+}
+
+func ruleFieldDescriptions() []gin.H {
+	names := make([]string, 0, len(rules.EventFields))
+	for name := range rules.EventFields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fields := make([]gin.H, len(names))
+	for i, name := range names {
+		field := rules.EventFields[name]
+		fields[i] = gin.H{"name": name, "kind": field.Kind, "description": field.Description}
+	}
+	return fields
+}
+
+func (h *RuleHandlers) definitionParams(c *gin.Context) (string, uuid.UUID, bool) {
+	companyID := c.Query("company_id")
+	if companyID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "company_id query parameter is required"})
+		return "", uuid.Nil, false
+	}
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid rule definition ID"})
+		return "", uuid.Nil, false
+	}
+	return companyID, id, true
+}
+
+func (h *RuleHandlers) versionParams(c *gin.Context) (string, int, bool) {
+	companyID := c.Query("company_id")
+	if companyID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "company_id query parameter is required"})
+		return "", 0, false
+	}
+
+	version, err := strconv.Atoi(c.Param("version"))
+	if err != nil || version < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid rule set version"})
+		return "", 0, false
+	}
+	return companyID, version, true
+}
+
+func (h *RuleHandlers) respondError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrRuleDefinitionNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Rule definition not found"})
+	case errors.Is(err, services.ErrRuleSetVersionNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Rule set version not found"})
+	case errors.Is(err, services.ErrRuleDefinitionExists), errors.Is(err, services.ErrRuleSetVersionState):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, rules.ErrInvalidRuleDefinition), errors.Is(err, services.ErrInvalidBacktest):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		h.logger.Error("Rule definition request failed", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Rule definition request failed"})
+	}
+}
+
+// RegisterRuleRoutes registers declarative rule routes
+func RegisterRuleRoutes(router *gin.RouterGroup, ruleHandlers *RuleHandlers) {
+	ruleGroup := router.Group("/rules")
+	{
+		ruleGroup.GET("/actions", ruleHandlers.ListActions)
+		ruleGroup.POST("/definitions/validate", ruleHandlers.ValidateDefinition)
+		ruleGroup.POST("/backtest", ruleHandlers.Backtest)
+		ruleGroup.GET("/analysis", ruleHandlers.Analyze)
+		ruleGroup.POST("/definitions", ruleHandlers.CreateDefinition)
+		ruleGroup.GET("/definitions", ruleHandlers.ListDefinitions)
+		ruleGroup.GET("/definitions/:id", ruleHandlers.GetDefinition)
+		ruleGroup.PUT("/definitions/:id", ruleHandlers.UpdateDefinition)
+		ruleGroup.DELETE("/definitions/:id", ruleHandlers.DeleteDefinition)
+		ruleGroup.GET("/versions", ruleHandlers.ListVersions)
+		ruleGroup.GET("/versions/:version", ruleHandlers.GetVersion)
+		ruleGroup.GET("/versions/:version/diff", ruleHandlers.DiffVersion)
+		ruleGroup.POST("/versions/:version/publish", ruleHandlers.PublishVersion)
+		ruleGroup.POST("/versions/:version/rollback", ruleHandlers.RollbackVersion)
+	}
+}
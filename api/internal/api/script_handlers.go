@@ -0,0 +1,205 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"github.com/sambitmohanty1/payment-watchdog/api/internal/script"
+	"github.com/sambitmohanty1/payment-watchdog/api/internal/services"
+)
+
+// ScriptHandlers handles company script endpoints
+type ScriptHandlers struct {
+	scriptService *services.CompanyScriptService
+	logger        *zap.Logger
+}
+
+// NewScriptHandlers creates new company script handlers
+func NewScriptHandlers(scriptService *services.CompanyScriptService, logger *zap.Logger) *ScriptHandlers {
+	return &ScriptHandlers{
+		scriptService: scriptService,
+		logger:        logger,
+	}
+}
+
+// ListScripts lists the company's scripts
+func (h *ScriptHandlers) ListScripts(c *gin.Context) {
+	companyID, ok := h.companyID(c)
+	if !ok {
+		return
+	}
+
+	scripts, err := h.scriptService.List(c.Request.Context(), companyID)
+	if err != nil {
+		h.respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": scripts})
+}
+
+// GetScript returns one company script
+func (h *ScriptHandlers) GetScript(c *gin.Context) {
+	companyID, ok := h.companyID(c)
+	if !ok {
+		return
+	}
+	id, ok := h.scriptID(c)
+	if !ok {
+		return
+	}
+
+	record, err := h.scriptService.Get(c.Request.Context(), companyID, id)
+	if err != nil {
+		h.respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": record})
+}
+
+// CreateScript validates and stores a new company script
+func (h *ScriptHandlers) CreateScript(c *gin.Context) {
+	companyID, ok := h.companyID(c)
+	if !ok {
+		return
+	}
+
+	var input services.CompanyScriptInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	record, err := h.scriptService.Create(c.Request.Context(), companyID, input, c.GetString("user_id"))
+	if err != nil {
+		h.respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"data": record})
+}
+
+// UpdateScript validates and replaces a company script
+func (h *ScriptHandlers) UpdateScript(c *gin.Context) {
+	companyID, ok := h.companyID(c)
+	if !ok {
+		return
+	}
+	id, ok := h.scriptID(c)
+	if !ok {
+		return
+	}
+
+	var input services.CompanyScriptInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	record, err := h.scriptService.Update(c.Request.Context(), companyID, id, input, c.GetString("user_id"))
+	if err != nil {
+		h.respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": record})
+}
+
+// DeleteScript removes a company script
+func (h *ScriptHandlers) DeleteScript(c *gin.Context) {
+	companyID, ok := h.companyID(c)
+	if !ok {
+		return
+	}
+	id, ok := h.scriptID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.scriptService.Delete(c.Request.Context(), companyID, id); err != nil {
+		h.respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Script deleted"})
+}
+
+// TestScript runs a script against sample failures without saving it or
+// dispatching any actions
+func (h *ScriptHandlers) TestScript(c *gin.Context) {
+	companyID, ok := h.companyID(c)
+	if !ok {
+		return
+	}
+
+	var req services.ScriptTestRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	report, err := h.scriptService.Test(c.Request.Context(), companyID, req)
+	if err != nil {
+		h.respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": report})
+}
+
+func (h *ScriptHandlers) companyID(c *gin.Context) (string, bool) {
+	companyID := c.Query("company_id")
+	if companyID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "company_id query parameter is required"})
+		return "", false
+	}
+	return companyID, true
+}
+
+func (h *ScriptHandlers) scriptID(c *gin.Context) (uuid.UUID, bool) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid script ID"})
+		return uuid.Nil, false
+	}
+	return id, true
+}
+
+func (h *ScriptHandlers) respondError(c *gin.Context, err error) {
+	var scriptErr *script.Error
+	switch {
+	case errors.Is(err, services.ErrScriptNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Script not found"})
+	case errors.Is(err, services.ErrScriptExists):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrInvalidScript) && errors.As(err, &scriptErr):
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":  err.Error(),
+			"line":   scriptErr.Pos.Line,
+			"column": scriptErr.Pos.Col,
+		})
+	case errors.Is(err, services.ErrInvalidScript):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		h.logger.Error("Company script request failed", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Company script request failed"})
+	}
+}
+
+// RegisterScriptRoutes registers company script routes
+func RegisterScriptRoutes(router *gin.RouterGroup, scriptHandlers *ScriptHandlers) {
+	scripts := router.Group("/scripts")
+	{
+		scripts.GET("", scriptHandlers.ListScripts)
+		scripts.POST("", scriptHandlers.CreateScript)
+		scripts.POST("/test", scriptHandlers.TestScript)
+		scripts.GET("/:id", scriptHandlers.GetScript)
+		scripts.PUT("/:id", scriptHandlers.UpdateScript)
+		scripts.DELETE("/:id", scriptHandlers.DeleteScript)
+	}
+}
@@ -0,0 +1,191 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"github.com/sambitmohanty1/payment-watchdog/api/internal/services"
+)
+
+// SegmentHandlers handles customer segment endpoints
+type SegmentHandlers struct {
+	segmentService *services.CustomerSegmentService
+	logger         *zap.Logger
+}
+
+// NewSegmentHandlers creates new customer segment handlers
+func NewSegmentHandlers(segmentService *services.CustomerSegmentService, logger *zap.Logger) *SegmentHandlers {
+	return &SegmentHandlers{
+		segmentService: segmentService,
+		logger:         logger,
+	}
+}
+
+// customerTagsRequest adds and removes manual tags on a customer
+type customerTagsRequest struct {
+	Add    []string `json:"add"`
+	Remove []string `json:"remove"`
+}
+
+// ListSegments lists the company's segment definitions, built-in ones included
+func (h *SegmentHandlers) ListSegments(c *gin.Context) {
+	companyID, ok := h.companyID(c)
+	if !ok {
+		return
+	}
+
+	segments, err := h.segmentService.ListSegments(c.Request.Context(), companyID)
+	if err != nil {
+		h.respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": segments})
+}
+
+// SaveSegment creates or redefines a segment
+func (h *SegmentHandlers) SaveSegment(c *gin.Context) {
+	companyID, ok := h.companyID(c)
+	if !ok {
+		return
+	}
+
+	var input services.CustomerSegmentInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	segment, err := h.segmentService.SaveSegment(c.Request.Context(), companyID, c.Param("key"), input, c.GetString("user_id"))
+	if err != nil {
+		h.respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": segment})
+}
+
+// DeleteSegment removes a company segment, reverting built-in segments to their defaults
+func (h *SegmentHandlers) DeleteSegment(c *gin.Context) {
+	companyID, ok := h.companyID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.segmentService.DeleteSegment(c.Request.Context(), companyID, c.Param("key")); err != nil {
+		h.respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Segment deleted"})
+}
+
+// RecomputeSegments re-evaluates the segments of all the company's customers
+func (h *SegmentHandlers) RecomputeSegments(c *gin.Context) {
+	companyID, ok := h.companyID(c)
+	if !ok {
+		return
+	}
+
+	changed, err := h.segmentService.Recompute(c.Request.Context(), companyID)
+	if err != nil {
+		h.respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": gin.H{"changed": changed}})
+}
+
+// GetSegmentCounts returns how many customers are in each segment
+func (h *SegmentHandlers) GetSegmentCounts(c *gin.Context) {
+	companyID, ok := h.companyID(c)
+	if !ok {
+		return
+	}
+
+	counts, err := h.segmentService.CountBySegment(c.Request.Context(), companyID)
+	if err != nil {
+		h.respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": counts})
+}
+
+// GetCustomerProfile returns a customer's segment profile and current segments
+func (h *SegmentHandlers) GetCustomerProfile(c *gin.Context) {
+	companyID, ok := h.companyID(c)
+	if !ok {
+		return
+	}
+
+	profile, err := h.segmentService.GetProfile(c.Request.Context(), companyID, c.Param("customer_id"))
+	if err != nil {
+		h.respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": profile})
+}
+
+// UpdateCustomerTags adds and removes a customer's manual tags
+func (h *SegmentHandlers) UpdateCustomerTags(c *gin.Context) {
+	companyID, ok := h.companyID(c)
+	if !ok {
+		return
+	}
+
+	var req customerTagsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	profile, err := h.segmentService.UpdateManualTags(c.Request.Context(), companyID, c.Param("customer_id"), req.Add, req.Remove)
+	if err != nil {
+		h.respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": profile})
+}
+
+func (h *SegmentHandlers) companyID(c *gin.Context) (string, bool) {
+	companyID := c.Query("company_id")
+	if companyID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "company_id query parameter is required"})
+		return "", false
+	}
+	return companyID, true
+}
+
+func (h *SegmentHandlers) respondError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrCustomerSegmentNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Segment not found"})
+	case errors.Is(err, services.ErrCustomerSegmentProfileNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrInvalidCustomerSegment):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		h.logger.Error("Customer segment request failed", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Customer segment request failed"})
+	}
+}
+
+// RegisterSegmentRoutes registers customer segment routes
+func RegisterSegmentRoutes(router *gin.RouterGroup, segmentHandlers *SegmentHandlers) {
+	segments := router.Group("/customer-segments")
+	{
+		segments.GET("", segmentHandlers.ListSegments)
+		segments.GET("/counts", segmentHandlers.GetSegmentCounts)
+		segments.POST("/recompute", segmentHandlers.RecomputeSegments)
+		segments.GET("/customers/:customer_id", segmentHandlers.GetCustomerProfile)
+		segments.PUT("/customers/:customer_id/tags", segmentHandlers.UpdateCustomerTags)
+		segments.PUT("/definitions/:key", segmentHandlers.SaveSegment)
+		segments.DELETE("/definitions/:key", segmentHandlers.DeleteSegment)
+	}
+}
@@ -0,0 +1,268 @@
+package calendar
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrInvalidSchedule is returned for schedule specs that cannot be parsed
+var ErrInvalidSchedule = errors.New("invalid schedule")
+
+// searchDays bounds how far ahead the calendar looks for the next opening
+const searchDays = 366
+
+// Window is a span of opening hours within one day, in minutes since local
+// midnight. Close is exclusive and may be 24*60 for a day open until midnight.
+type Window struct {
+	Open  int `json:"open"`
+	Close int `json:"close"`
+}
+
+// Holiday is a date on which the company is closed. Only the year, month and day
+// of Date are used.
+type Holiday struct {
+	Date time.Time `json:"date"`
+	Name string    `json:"name"`
+	UID  string    `json:"uid,omitempty"`
+}
+
+// Calendar answers business time questions in a company's timezone
+type Calendar struct {
+	location *time.Location
+	hours    [7][]Window
+	holidays map[string]string
+}
+
+// DefaultHours is Monday to Friday, 09:00 to 17:00
+func DefaultHours() map[time.Weekday][]Window {
+	hours := make(map[time.Weekday][]Window)
+	for day := time.Monday; day <= time.Friday; day++ {
+		hours[day] = []Window{{Open: 9 * 60, Close: 17 * 60}}
+	}
+	return hours
+}
+
+// New creates a calendar. A nil location means UTC.
+func New(location *time.Location, hours map[time.Weekday][]Window, holidays []Holiday) *Calendar {
+	if location == nil {
+		location = time.UTC
+	}
+	c := &Calendar{location: location, holidays: make(map[string]string, len(holidays))}
+	for day, windows := range hours {
+		if day < time.Sunday || day > time.Saturday {
+			continue
+		}
+		sorted := append([]Window(nil), windows...)
+		sort.Slice(sorted, func(i, j int) bool { return sorted[i].Open < sorted[j].Open })
+		c.hours[day] = sorted
+	}
+	for _, holiday := range holidays {
+		key := dateKey(holiday.Date)
+		if _, exists := c.holidays[key]; !exists {
+			c.holidays[key] = holiday.Name
+		}
+	}
+	return c
+}
+
+// Default returns a UTC calendar with DefaultHours and no holidays
+func Default() *Calendar {
+	return New(time.UTC, DefaultHours(), nil)
+}
+
+// Location returns the calendar's timezone
+func (c *Calendar) Location() *time.Location {
+	return c.location
+}
+
+// Holiday returns the name of the holiday on t's local date
+func (c *Calendar) Holiday(t time.Time) (string, bool) {
+	name, ok := c.holidays[dateKey(t.In(c.location))]
+	return name, ok
+}
+
+// IsBusinessDay reports whether t's local date has opening hours and is not a holiday
+func (c *Calendar) IsBusinessDay(t time.Time) bool {
+	local := t.In(c.location)
+	if len(c.hours[local.Weekday()]) == 0 {
+		return false
+	}
+	_, holiday := c.holidays[dateKey(local)]
+	return !holiday
+}
+
+// IsOpen reports whether t falls within opening hours on a business day
+func (c *Calendar) IsOpen(t time.Time) bool {
+	if !c.IsBusinessDay(t) {
+		return false
+	}
+	local := t.In(c.location)
+	minute := local.Hour()*60 + local.Minute()
+	for _, window := range c.hours[local.Weekday()] {
+		if minute >= window.Open && minute < window.Close {
+			return true
+		}
+	}
+	return false
+}
+
+// NextOpen returns t if the company is open at t and otherwise the start of the
+// next opening window. It returns t when the calendar has no opening hours.
+func (c *Calendar) NextOpen(t time.Time) time.Time {
+	if c.IsOpen(t) {
+		return t
+	}
+	local := t.In(c.location)
+	for i := 0; i < searchDays; i++ {
+		day := time.Date(local.Year(), local.Month(), local.Day()+i, 0, 0, 0, 0, c.location)
+		if !c.IsBusinessDay(day) {
+			continue
+		}
+		for _, window := range c.hours[day.Weekday()] {
+			if open := atMinute(day, window.Open); open.After(t) {
+				return open
+			}
+		}
+	}
+	return t
+}
+
+// NextBusinessDayAt returns hour:minute local time on the first business day
+// after t's local date. It returns t when the calendar has no business days.
+func (c *Calendar) NextBusinessDayAt(t time.Time, hour, minute int) time.Time {
+	return c.businessDayAt(t, 1, hour, minute)
+}
+
+// AddBusinessDays moves t forward by n business days, keeping its local clock time
+func (c *Calendar) AddBusinessDays(t time.Time, n int) time.Time {
+	local := t.In(c.location)
+	for ; n > 0; n-- {
+		next := c.businessDayAt(local, 1, local.Hour(), local.Minute())
+		if next.Equal(local) {
+			return t
+		}
+		local = time.Date(next.Year(), next.Month(), next.Day(), local.Hour(), local.Minute(), local.Second(), local.Nanosecond(), c.location)
+	}
+	return local
+}
+
+// businessDayAt returns hour:minute on the first business day at least offset
+// days after t's local date
+func (c *Calendar) businessDayAt(t time.Time, offset, hour, minute int) time.Time {
+	local := t.In(c.location)
+	for i := offset; i < offset+searchDays; i++ {
+		day := time.Date(local.Year(), local.Month(), local.Day()+i, hour, minute, 0, 0, c.location)
+		if c.IsBusinessDay(day) {
+			return day
+		}
+	}
+	return t
+}
+
+// Schedule kinds
+const (
+	ScheduleBusinessHours   = "business_hours"    // the next time the company is open
+	ScheduleBusinessDay     = "business_day"      // HH:MM today if still ahead on a business day, else the next business day
+	ScheduleNextBusinessDay = "next_business_day" // HH:MM on the first business day after today
+)
+
+// Schedule is a parsed schedule spec such as "next_business_day 10:00"
+type Schedule struct {
+	Kind   string
+	Hour   int
+	Minute int
+}
+
+// ParseSchedule parses "business_hours", "business_day HH:MM" or
+// "next_business_day HH:MM"
+func ParseSchedule(spec string) (Schedule, error) {
+	fields := strings.Fields(strings.ToLower(spec))
+	if len(fields) == 0 {
+		return Schedule{}, fmt.Errorf("%w: empty schedule", ErrInvalidSchedule)
+	}
+
+	schedule := Schedule{Kind: fields[0]}
+	switch schedule.Kind {
+	case ScheduleBusinessHours:
+		if len(fields) != 1 {
+			return Schedule{}, fmt.Errorf("%w: %q takes no time of day", ErrInvalidSchedule, schedule.Kind)
+		}
+		return schedule, nil
+	case ScheduleBusinessDay, ScheduleNextBusinessDay:
+		if len(fields) != 2 {
+			return Schedule{}, fmt.Errorf("%w: %q needs a time of day such as 10:00", ErrInvalidSchedule, schedule.Kind)
+		}
+		minutes, err := ParseClock(fields[1])
+		if err != nil {
+			return Schedule{}, err
+		}
+		schedule.Hour, schedule.Minute = minutes/60, minutes%60
+		return schedule, nil
+	}
+	return Schedule{}, fmt.Errorf("%w: unknown schedule %q", ErrInvalidSchedule, fields[0])
+}
+
+// ParseClock parses an HH:MM time of day into minutes since midnight. "24:00" is
+// accepted as the end of the day.
+func ParseClock(value string) (int, error) {
+	parts := strings.Split(value, ":")
+	if len(parts) != 2 {
+		return 0, fmt.Errorf("%w: time of day %q must be HH:MM", ErrInvalidSchedule, value)
+	}
+	hour, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return 0, fmt.Errorf("%w: time of day %q must be HH:MM", ErrInvalidSchedule, value)
+	}
+	minute, err := strconv.Atoi(parts[1])
+	if err != nil || len(parts[1]) != 2 {
+		return 0, fmt.Errorf("%w: time of day %q must be HH:MM", ErrInvalidSchedule, value)
+	}
+	if hour < 0 || minute < 0 || minute > 59 || hour > 24 || (hour == 24 && minute != 0) {
+		return 0, fmt.Errorf("%w: time of day %q is out of range", ErrInvalidSchedule, value)
+	}
+	return hour*60 + minute, nil
+}
+
+// FormatClock formats minutes since midnight as HH:MM
+func FormatClock(minutes int) string {
+	return fmt.Sprintf("%02d:%02d", minutes/60, minutes%60)
+}
+
+// Next returns the first time at or after from that satisfies the schedule
+func (c *Calendar) Next(schedule Schedule, from time.Time) time.Time {
+	switch schedule.Kind {
+	case ScheduleBusinessHours:
+		return c.NextOpen(from)
+	case ScheduleBusinessDay:
+		local := from.In(c.location)
+		today := time.Date(local.Year(), local.Month(), local.Day(), schedule.Hour, schedule.Minute, 0, 0, c.location)
+		if !today.Before(from) && c.IsBusinessDay(today) {
+			return today
+		}
+		return c.businessDayAt(from, 1, schedule.Hour, schedule.Minute)
+	case ScheduleNextBusinessDay:
+		return c.businessDayAt(from, 1, schedule.Hour, schedule.Minute)
+	}
+	return from
+}
+
+// Resolve parses spec and returns the first time at or after from that satisfies it
+func (c *Calendar) Resolve(spec string, from time.Time) (time.Time, error) {
+	schedule, err := ParseSchedule(spec)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return c.Next(schedule, from), nil
+}
+
+func dateKey(t time.Time) string {
+	return t.Format("2006-01-02")
+}
+
+func atMinute(day time.Time, minute int) time.Time {
+	return time.Date(day.Year(), day.Month(), day.Day(), minute/60, minute%60, 0, 0, day.Location())
+}
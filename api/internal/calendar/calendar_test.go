@@ -0,0 +1,152 @@
+package calendar
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func sydneyCalendar(t *testing.T, holidays ...Holiday) *Calendar {
+	t.Helper()
+	loc, err := time.LoadLocation("Australia/Sydney")
+	require.NoError(t, err)
+	return New(loc, DefaultHours(), holidays)
+}
+
+func holiday(date, name string) Holiday {
+	d, _ := time.Parse("2006-01-02", date)
+	return Holiday{Date: d, Name: name}
+}
+
+func TestCalendarUsesCompanyTimezone(t *testing.T) {
+	cal := sydneyCalendar(t)
+
+	// 23:30 UTC on a Sunday is 10:30 on Monday in Sydney (AEST, UTC+10)
+	mondayMorning := time.Date(2025, 6, 1, 23, 30, 0, 0, time.UTC)
+	assert.True(t, cal.IsOpen(mondayMorning))
+	assert.False(t, Default().IsOpen(mondayMorning))
+
+	// 08:00 UTC on a Monday is 18:00 in Sydney, after closing
+	evening := time.Date(2025, 6, 2, 8, 0, 0, 0, time.UTC)
+	assert.False(t, cal.IsOpen(evening))
+	next := cal.NextOpen(evening)
+	assert.Equal(t, "2025-06-03 09:00 AEST", next.In(cal.Location()).Format("2006-01-02 15:04 MST"))
+}
+
+func TestCalendarSkipsWeekendsAndHolidays(t *testing.T) {
+	cal := sydneyCalendar(t, holiday("2025-06-09", "King's Birthday"))
+	loc := cal.Location()
+
+	friday := time.Date(2025, 6, 6, 16, 0, 0, 0, loc)
+	assert.Equal(t, time.Date(2025, 6, 10, 10, 0, 0, 0, loc), cal.NextBusinessDayAt(friday, 10, 0))
+
+	name, ok := cal.Holiday(time.Date(2025, 6, 9, 12, 0, 0, 0, loc))
+	assert.True(t, ok)
+	assert.Equal(t, "King's Birthday", name)
+	assert.False(t, cal.IsBusinessDay(time.Date(2025, 6, 9, 12, 0, 0, 0, loc)))
+
+	assert.Equal(t, time.Date(2025, 6, 11, 16, 0, 0, 0, loc), cal.AddBusinessDays(friday, 2))
+}
+
+func TestCalendarSplitWindowsAndDaylightSaving(t *testing.T) {
+	loc, err := time.LoadLocation("America/New_York")
+	require.NoError(t, err)
+	cal := New(loc, map[time.Weekday][]Window{
+		time.Sunday: {{Open: 13 * 60, Close: 17 * 60}, {Open: 8 * 60, Close: 12 * 60}},
+	}, nil)
+
+	// DST starts on 9 March 2025; opening hours stay at local clock time
+	lunch := time.Date(2025, 3, 9, 12, 30, 0, 0, loc)
+	assert.False(t, cal.IsOpen(lunch))
+	assert.Equal(t, time.Date(2025, 3, 9, 13, 0, 0, 0, loc), cal.NextOpen(lunch))
+	assert.Equal(t, time.Date(2025, 3, 16, 8, 0, 0, 0, loc), cal.NextOpen(time.Date(2025, 3, 9, 17, 0, 0, 0, loc)))
+
+	closed := New(loc, nil, nil)
+	assert.Equal(t, lunch, closed.NextOpen(lunch))
+}
+
+func TestResolveSchedules(t *testing.T) {
+	cal := sydneyCalendar(t)
+	loc := cal.Location()
+	monday := time.Date(2025, 6, 2, 8, 0, 0, 0, loc)
+
+	cases := map[string]time.Time{
+		"business_hours":          time.Date(2025, 6, 2, 9, 0, 0, 0, loc),
+		"business_day 10:00":      time.Date(2025, 6, 2, 10, 0, 0, 0, loc),
+		"business_day 07:30":      time.Date(2025, 6, 3, 7, 30, 0, 0, loc),
+		"next_business_day 10:00": time.Date(2025, 6, 3, 10, 0, 0, 0, loc),
+		"Next_Business_Day 9:05":  time.Date(2025, 6, 3, 9, 5, 0, 0, loc),
+	}
+	for spec, want := range cases {
+		got, err := cal.Resolve(spec, monday)
+		require.NoError(t, err, spec)
+		assert.Equal(t, want, got, spec)
+	}
+
+	for _, spec := range []string{"", "tomorrow", "business_hours 10:00", "next_business_day", "business_day 25:00", "business_day 10:5"} {
+		_, err := cal.Resolve(spec, monday)
+		assert.True(t, errors.Is(err, ErrInvalidSchedule), spec)
+	}
+}
+
+const testICS = "BEGIN:VCALENDAR\r\n" +
+	"VERSION:2.0\r\n" +
+	"PRODID:-//Test//Holidays//EN\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"UID:christmas@example.com\r\n" +
+	"DTSTART;VALUE=DATE:20241225\r\n" +
+	"DTEND;VALUE=DATE:20241227\r\n" +
+	"SUMMARY:Christmas Day and\r\n" +
+	"  Boxing Day\r\n" +
+	"END:VEVENT\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"UID:anzac@example.com\r\n" +
+	"DTSTART;VALUE=DATE:20240425\r\n" +
+	"RRULE:FREQ=YEARLY;COUNT=5\r\n" +
+	"EXDATE;VALUE=DATE:20260425\r\n" +
+	"SUMMARY:Anzac Day\\, observed\r\n" +
+	"END:VEVENT\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"UID:cancelled@example.com\r\n" +
+	"DTSTART:20240101T000000Z\r\n" +
+	"STATUS:CANCELLED\r\n" +
+	"SUMMARY:Cancelled\r\n" +
+	"END:VEVENT\r\n" +
+	"END:VCALENDAR\r\n"
+
+func TestParseICS(t *testing.T) {
+	holidays, err := ParseICS(strings.NewReader(testICS), time.Date(2026, 12, 31, 0, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+
+	var got []string
+	for _, h := range holidays {
+		got = append(got, h.Date.Format("2006-01-02")+" "+h.Name)
+	}
+	assert.Equal(t, []string{
+		"2024-04-25 Anzac Day, observed",
+		"2024-12-25 Christmas Day and Boxing Day",
+		"2024-12-26 Christmas Day and Boxing Day",
+		"2025-04-25 Anzac Day, observed",
+	}, got)
+	assert.Equal(t, "christmas@example.com", holidays[1].UID)
+}
+
+func TestParseICSErrors(t *testing.T) {
+	cases := map[string]string{
+		"missing BEGIN:VCALENDAR":       "BEGIN:VEVENT\nDTSTART:20240101\nEND:VEVENT\n",
+		"line 3: event has no DTSTART":  "BEGIN:VCALENDAR\nVERSION:2.0\nBEGIN:VEVENT\nSUMMARY:x\nEND:VEVENT\nEND:VCALENDAR\n",
+		"line 3: DTSTART: invalid date": "BEGIN:VCALENDAR\nBEGIN:VEVENT\nDTSTART:2024-01-01\nEND:VEVENT\nEND:VCALENDAR\n",
+		"only FREQ=YEARLY is supported": "BEGIN:VCALENDAR\nBEGIN:VEVENT\nDTSTART:20240101\nRRULE:FREQ=WEEKLY\nEND:VEVENT\nEND:VCALENDAR\n",
+		"event is not closed":           "BEGIN:VCALENDAR\nBEGIN:VEVENT\nDTSTART:20240101\n",
+	}
+	for want, source := range cases {
+		_, err := ParseICS(strings.NewReader(source), time.Now())
+		require.Error(t, err, want)
+		assert.True(t, errors.Is(err, ErrInvalidICS), want)
+		assert.Contains(t, err.Error(), want)
+	}
+}
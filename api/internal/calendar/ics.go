@@ -0,0 +1,262 @@
+package calendar
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrInvalidICS is returned for iCalendar data that cannot be imported
+var ErrInvalidICS = errors.New("invalid iCalendar data")
+
+// maxEventDays bounds how many dates a single multi-day event may cover
+const maxEventDays = 31
+
+// icsEvent is the subset of a VEVENT needed to derive holidays
+type icsEvent struct {
+	line      int
+	uid       string
+	summary   string
+	start     time.Time
+	end       time.Time
+	hasEnd    bool
+	rrule     string
+	exdates   map[string]bool
+	cancelled bool
+}
+
+// ParseICS reads the events of an iCalendar (.ics) file as holidays. Each date an
+// event covers becomes one holiday; DTEND is exclusive as in RFC 5545. Yearly
+// recurrences are expanded up to until. Errors reference the line of the event.
+func ParseICS(r io.Reader, until time.Time) ([]Holiday, error) {
+	lines, err := unfoldICS(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		events   []*icsEvent
+		current  *icsEvent
+		calendar bool
+	)
+	for _, l := range lines {
+		name, params, value := splitICSProperty(l.text)
+		switch {
+		case name == "BEGIN" && strings.EqualFold(value, "VCALENDAR"):
+			calendar = true
+		case name == "BEGIN" && strings.EqualFold(value, "VEVENT"):
+			current = &icsEvent{line: l.number, exdates: make(map[string]bool)}
+		case name == "END" && strings.EqualFold(value, "VEVENT"):
+			if current == nil {
+				return nil, fmt.Errorf("%w: line %d: END:VEVENT without BEGIN:VEVENT", ErrInvalidICS, l.number)
+			}
+			if current.start.IsZero() {
+				return nil, fmt.Errorf("%w: line %d: event has no DTSTART", ErrInvalidICS, current.line)
+			}
+			events = append(events, current)
+			current = nil
+		case current == nil:
+			continue
+		case name == "UID":
+			current.uid = value
+		case name == "SUMMARY":
+			current.summary = unescapeICSText(value)
+		case name == "STATUS":
+			current.cancelled = strings.EqualFold(value, "CANCELLED")
+		case name == "RRULE":
+			current.rrule = strings.ToUpper(value)
+		case name == "DTSTART", name == "DTEND", name == "EXDATE":
+			for _, part := range strings.Split(value, ",") {
+				date, err := parseICSDate(part, params)
+				if err != nil {
+					return nil, fmt.Errorf("%w: line %d: %s: %v", ErrInvalidICS, l.number, name, err)
+				}
+				switch name {
+				case "DTSTART":
+					current.start = date
+				case "DTEND":
+					current.end, current.hasEnd = date, true
+				default:
+					current.exdates[dateKey(date)] = true
+				}
+			}
+		}
+	}
+	if !calendar {
+		return nil, fmt.Errorf("%w: missing BEGIN:VCALENDAR", ErrInvalidICS)
+	}
+	if current != nil {
+		return nil, fmt.Errorf("%w: line %d: event is not closed with END:VEVENT", ErrInvalidICS, current.line)
+	}
+
+	seen := make(map[string]bool)
+	var holidays []Holiday
+	for _, event := range events {
+		if event.cancelled {
+			continue
+		}
+		starts, err := event.occurrences(until)
+		if err != nil {
+			return nil, fmt.Errorf("%w: line %d: %v", ErrInvalidICS, event.line, err)
+		}
+		days := 1
+		if event.hasEnd {
+			days = int(event.end.Sub(event.start).Hours()+12) / 24
+			if days < 1 {
+				days = 1
+			}
+			if days > maxEventDays {
+				return nil, fmt.Errorf("%w: line %d: event spans %d days, at most %d are supported", ErrInvalidICS, event.line, days, maxEventDays)
+			}
+		}
+		for _, start := range starts {
+			for i := 0; i < days; i++ {
+				date := start.AddDate(0, 0, i)
+				key := dateKey(date) + "\x00" + event.summary
+				if seen[key] {
+					continue
+				}
+				seen[key] = true
+				holidays = append(holidays, Holiday{Date: date, Name: event.summary, UID: event.uid})
+			}
+		}
+	}
+
+	sort.SliceStable(holidays, func(i, j int) bool { return holidays[i].Date.Before(holidays[j].Date) })
+	return holidays, nil
+}
+
+// occurrences returns the start dates of the event up to until. Only yearly
+// recurrence rules are supported since that is how holiday calendars repeat.
+func (e *icsEvent) occurrences(until time.Time) ([]time.Time, error) {
+	if e.rrule == "" {
+		return []time.Time{e.start}, nil
+	}
+
+	rule := make(map[string]string)
+	for _, part := range strings.Split(e.rrule, ";") {
+		if key, value, ok := strings.Cut(part, "="); ok {
+			rule[key] = value
+		}
+	}
+	if rule["FREQ"] != "YEARLY" {
+		return nil, fmt.Errorf("unsupported recurrence %q, only FREQ=YEARLY is supported", e.rrule)
+	}
+	for key := range rule {
+		switch key {
+		case "FREQ", "INTERVAL", "COUNT", "UNTIL":
+		default:
+			return nil, fmt.Errorf("unsupported recurrence part %s", key)
+		}
+	}
+
+	interval := 1
+	if value, ok := rule["INTERVAL"]; ok {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 {
+			return nil, fmt.Errorf("invalid INTERVAL %q", value)
+		}
+		interval = n
+	}
+	count := -1
+	if value, ok := rule["COUNT"]; ok {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 {
+			return nil, fmt.Errorf("invalid COUNT %q", value)
+		}
+		count = n
+	}
+	if value, ok := rule["UNTIL"]; ok {
+		limit, err := parseICSDate(value, "")
+		if err != nil {
+			return nil, fmt.Errorf("invalid UNTIL: %v", err)
+		}
+		if limit.Before(until) {
+			until = limit
+		}
+	}
+
+	var starts []time.Time
+	for i := 0; count < 0 || i < count; i++ {
+		start := e.start.AddDate(i*interval, 0, 0)
+		if start.After(until) {
+			break
+		}
+		// AddDate normalises 29 February to 1 March in common years
+		if start.Day() != e.start.Day() {
+			continue
+		}
+		if !e.exdates[dateKey(start)] {
+			starts = append(starts, start)
+		}
+	}
+	return starts, nil
+}
+
+type icsLine struct {
+	number int
+	text   string
+}
+
+// unfoldICS joins folded content lines: a line starting with a space or tab
+// continues the previous one
+func unfoldICS(r io.Reader) ([]icsLine, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	var lines []icsLine
+	number := 0
+	for scanner.Scan() {
+		number++
+		text := strings.TrimRight(scanner.Text(), "\r")
+		if text == "" {
+			continue
+		}
+		if (text[0] == ' ' || text[0] == '\t') && len(lines) > 0 {
+			lines[len(lines)-1].text += text[1:]
+			continue
+		}
+		lines = append(lines, icsLine{number: number, text: text})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read iCalendar data: %w", err)
+	}
+	return lines, nil
+}
+
+// splitICSProperty splits "NAME;PARAM=X:VALUE" into its name, parameters and value
+func splitICSProperty(line string) (string, string, string) {
+	head, value, _ := strings.Cut(line, ":")
+	name, params, _ := strings.Cut(head, ";")
+	return strings.ToUpper(name), strings.ToUpper(params), value
+}
+
+// parseICSDate parses DATE and DATE-TIME values to a UTC midnight date. Times are
+// dropped because holidays are whole days in the company's own timezone.
+func parseICSDate(value, params string) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	if len(value) < 8 {
+		return time.Time{}, fmt.Errorf("invalid date %q", value)
+	}
+	date, err := time.Parse("20060102", value[:8])
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid date %q", value)
+	}
+	if len(value) > 8 && value[8] != 'T' {
+		return time.Time{}, fmt.Errorf("invalid date-time %q", value)
+	}
+	if strings.Contains(params, "VALUE=DATE") && !strings.Contains(params, "VALUE=DATE-TIME") && len(value) != 8 {
+		return time.Time{}, fmt.Errorf("invalid date %q", value)
+	}
+	return date, nil
+}
+
+// unescapeICSText reverses RFC 5545 TEXT escaping
+func unescapeICSText(value string) string {
+	return strings.NewReplacer(`\n`, " ", `\N`, " ", `\,`, ",", `\;`, ";", `\\`, `\`).Replace(value)
+}
@@ -35,6 +35,16 @@ type DatabaseConfig struct {
 	SSLMode  string `mapstructure:"ssl_mode"`
 }
 
+// DSN returns the Postgres connection string for the database
+func (c DatabaseConfig) DSN() string {
+	sslMode := c.SSLMode
+	if sslMode == "" {
+		sslMode = "disable"
+	}
+	return fmt.Sprintf("host=%s user=%s password=%s dbname=%s port=%d sslmode=%s",
+		c.Host, c.User, c.Password, c.Name, c.Port, sslMode)
+}
+
 // StripeConfig holds Stripe configuration
 type StripeConfig struct {
 	SecretKey      string `mapstructure:"secret_key"`
@@ -81,7 +91,10 @@ func Load() error {
 	viper.SetDefault("database.user", "postgres")
 	viper.SetDefault("database.password", "password")
 	viper.SetDefault("database.ssl_mode", "disable")
+	viper.SetDefault("redis.url", "redis://localhost:6379")
 	viper.SetDefault("log.level", "info")
+	viper.SetDefault("workflows.execution_workers", 10)
+	viper.SetDefault("workflows.company_execution_limit", 3)
 
 	fmt.Println("🔍 CONFIG DEBUG: Defaults set")
 
@@ -150,6 +163,14 @@ func Load() error {
 		fmt.Printf("🔍 CONFIG DEBUG: Failed to bind DATABASE_PASSWORD: %v\n", err)
 		return fmt.Errorf("failed to bind DATABASE_PASSWORD: %w", err)
 	}
+	if err := viper.BindEnv("database.ssl_mode", "DATABASE_SSL_MODE"); err != nil {
+		fmt.Printf("🔍 CONFIG DEBUG: Failed to bind DATABASE_SSL_MODE: %v\n", err)
+		return fmt.Errorf("failed to bind DATABASE_SSL_MODE: %w", err)
+	}
+	if err := viper.BindEnv("redis.url", "REDIS_URL"); err != nil {
+		fmt.Printf("🔍 CONFIG DEBUG: Failed to bind REDIS_URL: %v\n", err)
+		return fmt.Errorf("failed to bind REDIS_URL: %w", err)
+	}
 	if err := viper.BindEnv("stripe.secret_key", "STRIPE_SECRET_KEY"); err != nil {
 		fmt.Printf("🔍 CONFIG DEBUG: Failed to bind STRIPE_SECRET_KEY: %v\n", err)
 		return fmt.Errorf("failed to bind STRIPE_SECRET_KEY: %w", err)
@@ -178,6 +199,14 @@ func Load() error {
 		fmt.Printf("🔍 CONFIG DEBUG: Failed to bind LOG_LEVEL: %v\n", err)
 		return fmt.Errorf("failed to bind LOG_LEVEL: %v", err)
 	}
+	if err := viper.BindEnv("workflows.execution_workers", "WORKFLOW_EXECUTION_WORKERS"); err != nil {
+		fmt.Printf("🔍 CONFIG DEBUG: Failed to bind WORKFLOW_EXECUTION_WORKERS: %v\n", err)
+		return fmt.Errorf("failed to bind WORKFLOW_EXECUTION_WORKERS: %v", err)
+	}
+	if err := viper.BindEnv("workflows.company_execution_limit", "WORKFLOW_COMPANY_EXECUTION_LIMIT"); err != nil {
+		fmt.Printf("🔍 CONFIG DEBUG: Failed to bind WORKFLOW_COMPANY_EXECUTION_LIMIT: %v\n", err)
+		return fmt.Errorf("failed to bind WORKFLOW_COMPANY_EXECUTION_LIMIT: %v", err)
+	}
 
 	fmt.Println("🔍 CONFIG DEBUG: All environment variables bound")
 
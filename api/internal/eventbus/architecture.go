@@ -0,0 +1,44 @@
+package eventbus
+
+import (
+	"context"
+
+	"github.com/sambitmohanty1/payment-watchdog/api/internal/architecture"
+)
+
+// ArchitectureEventBus lets services written against architecture.EventBus
+// publish and consume events through a RedisEventBus
+type ArchitectureEventBus struct {
+	bus *RedisEventBus
+}
+
+// NewArchitectureEventBus wraps bus as an architecture.EventBus
+func NewArchitectureEventBus(bus *RedisEventBus) *ArchitectureEventBus {
+	return &ArchitectureEventBus{bus: bus}
+}
+
+var _ architecture.EventBus = (*ArchitectureEventBus)(nil)
+
+func (a *ArchitectureEventBus) Publish(ctx context.Context, topic string, event interface{}) error {
+	return a.bus.Publish(ctx, topic, event)
+}
+
+func (a *ArchitectureEventBus) PublishAsync(ctx context.Context, topic string, event interface{}) error {
+	return a.bus.PublishAsync(ctx, topic, event)
+}
+
+func (a *ArchitectureEventBus) Subscribe(ctx context.Context, topic string, handler architecture.EventHandler) (architecture.Subscription, error) {
+	return a.bus.Subscribe(ctx, topic, EventHandler(handler))
+}
+
+func (a *ArchitectureEventBus) SubscribeAsync(ctx context.Context, topic string, handler architecture.EventHandler) (architecture.Subscription, error) {
+	return a.bus.SubscribeAsync(ctx, topic, EventHandler(handler))
+}
+
+func (a *ArchitectureEventBus) Unsubscribe(subscription architecture.Subscription) error {
+	return subscription.Unsubscribe()
+}
+
+func (a *ArchitectureEventBus) Close() error {
+	return a.bus.Close()
+}
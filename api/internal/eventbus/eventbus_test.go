@@ -2,9 +2,12 @@ package eventbus
 
 import (
 	"context"
+	"errors"
+	"sync/atomic"
 	"testing"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"go.uber.org/zap"
@@ -159,3 +162,37 @@ func TestRedisEventBus_Integration(t *testing.T) {
 		t.Skip("Redis not available or event not received, skipping integration test")
 	}
 }
+
+func TestRedisEventBus_DeadLettersAfterMaxDeliveries(t *testing.T) {
+	eventBus, err := NewRedisEventBus("localhost:6379", "", 0, zap.NewNop())
+	if err != nil {
+		t.Skip("Redis not available, skipping integration test")
+	}
+	defer eventBus.Close()
+	eventBus.reclaimIdle = 100 * time.Millisecond
+	eventBus.maxDeliveries = 2
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	topic := "dead_letter_test_" + uuid.New().String()
+	defer eventBus.client.Del(context.Background(), topic, DeadLetterTopic(topic))
+
+	// The handler never succeeds, so the event is redelivered until it is dead lettered
+	var deliveries int32
+	_, err = eventBus.Subscribe(ctx, topic, func(ctx context.Context, event interface{}) error {
+		atomic.AddInt32(&deliveries, 1)
+		return errors.New("handler failed")
+	})
+	require.NoError(t, err)
+	require.NoError(t, eventBus.Publish(ctx, topic, map[string]string{"test": "data"}))
+
+	require.Eventually(t, func() bool {
+		dead, err := eventBus.client.XRange(ctx, DeadLetterTopic(topic), "-", "+").Result()
+		return err == nil && len(dead) == 1
+	}, 15*time.Second, 100*time.Millisecond)
+	assert.GreaterOrEqual(t, atomic.LoadInt32(&deliveries), int32(2))
+
+	pending, err := eventBus.client.XPending(ctx, topic, consumerGroup).Result()
+	require.NoError(t, err)
+	assert.Zero(t, pending.Count)
+}
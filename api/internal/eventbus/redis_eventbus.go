@@ -12,6 +12,19 @@ import (
 	"go.uber.org/zap"
 )
 
+const (
+	// consumerGroup is the consumer group every subscriber reads its topic in
+	consumerGroup = "payment-watchdog-workers"
+	// defaultReclaimIdle is how long a delivered message stays unacknowledged
+	// before another consumer reclaims it
+	defaultReclaimIdle = time.Minute
+	// defaultMaxDeliveries is how often a message is delivered before it is
+	// moved to its topic's dead letter stream
+	defaultMaxDeliveries = 5
+	// reclaimBatch caps how many pending messages one sweep reclaims
+	reclaimBatch = 100
+)
+
 // RedisEventBus implements EventBus using Redis Streams for durability
 type RedisEventBus struct {
 	client      *redis.Client
@@ -20,6 +33,11 @@ type RedisEventBus struct {
 	mutex       sync.RWMutex
 	ctx         context.Context
 	cancel      context.CancelFunc
+
+	// reclaimIdle and maxDeliveries govern how messages whose handler failed, or
+	// whose consumer stopped, are redelivered
+	reclaimIdle   time.Duration
+	maxDeliveries int64
 }
 
 type RedisSubscription struct {
@@ -32,12 +50,25 @@ type RedisSubscription struct {
 }
 
 func NewRedisEventBus(redisAddr, redisPassword string, db int, logger *zap.Logger) (*RedisEventBus, error) {
-	ctx, cancel := context.WithCancel(context.Background())
-	client := redis.NewClient(&redis.Options{
+	return newRedisEventBus(&redis.Options{
 		Addr:     redisAddr,
 		Password: redisPassword,
 		DB:       db,
-	})
+	}, logger)
+}
+
+// NewRedisEventBusFromURL connects to the Redis server at a redis:// URL
+func NewRedisEventBusFromURL(redisURL string, logger *zap.Logger) (*RedisEventBus, error) {
+	options, err := redis.ParseURL(redisURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid Redis URL: %w", err)
+	}
+	return newRedisEventBus(options, logger)
+}
+
+func newRedisEventBus(options *redis.Options, logger *zap.Logger) (*RedisEventBus, error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	client := redis.NewClient(options)
 
 	if err := client.Ping(ctx).Err(); err != nil {
 		cancel()
@@ -50,6 +81,9 @@ func NewRedisEventBus(redisAddr, redisPassword string, db int, logger *zap.Logge
 		subscribers: make(map[string][]*RedisSubscription),
 		ctx:         ctx,
 		cancel:      cancel,
+
+		reclaimIdle:   defaultReclaimIdle,
+		maxDeliveries: defaultMaxDeliveries,
 	}, nil
 }
 
@@ -105,20 +139,27 @@ func (r *RedisEventBus) Subscribe(ctx context.Context, topic string, handler Eve
 }
 
 func (r *RedisEventBus) listenForEvents(subscription *RedisSubscription) {
-	groupName := "payment-watchdog-workers"
 	consumerName := "worker-" + subscription.id
 
 	// Create Group if not exists
-	r.client.XGroupCreateMkStream(r.ctx, subscription.topic, groupName, "0").Err()
+	r.client.XGroupCreateMkStream(r.ctx, subscription.topic, consumerGroup, "0").Err()
 
+	lastReclaim := time.Now()
 	for {
 		select {
 		case <-subscription.ctx.Done():
 			return
 		default:
+			// Messages left unacknowledged by a failed handler or a stopped
+			// consumer are delivered again once they have been idle long enough
+			if time.Since(lastReclaim) >= r.reclaimIdle {
+				r.reclaimPending(subscription, consumerName)
+				lastReclaim = time.Now()
+			}
+
 			// Read from Stream
 			streams, err := r.client.XReadGroup(r.ctx, &redis.XReadGroupArgs{
-				Group:    groupName,
+				Group:    consumerGroup,
 				Consumer: consumerName,
 				Streams:  []string{subscription.topic, ">"},
 				Count:    10,
@@ -132,26 +173,143 @@ func (r *RedisEventBus) listenForEvents(subscription *RedisSubscription) {
 
 			for _, stream := range streams {
 				for _, msg := range stream.Messages {
-					payloadStr, ok := msg.Values["payload"].(string)
-					if !ok {
-						continue
-					}
-
-					var eventData map[string]interface{}
-					if err := json.Unmarshal([]byte(payloadStr), &eventData); err == nil {
-						if err := subscription.handler(subscription.ctx, eventData); err == nil {
-							// Ack on success
-							r.client.XAck(r.ctx, subscription.topic, groupName, msg.ID)
-						} else {
-							r.logger.Error("Handler failed", zap.Error(err))
-						}
-					}
+					r.handleMessage(subscription, msg)
 				}
 			}
 		}
 	}
 }
 
+// handleMessage passes a message to the subscriber's handler and acknowledges
+// it once handled. Messages that fail stay pending and are reclaimed later.
+func (r *RedisEventBus) handleMessage(subscription *RedisSubscription, msg redis.XMessage) {
+	payloadStr, ok := msg.Values["payload"].(string)
+	if !ok {
+		r.logger.Error("Event has no payload",
+			zap.String("topic", subscription.topic),
+			zap.String("message_id", msg.ID))
+		return
+	}
+
+	var eventData map[string]interface{}
+	if err := json.Unmarshal([]byte(payloadStr), &eventData); err != nil {
+		r.logger.Error("Failed to decode event",
+			zap.String("topic", subscription.topic),
+			zap.String("message_id", msg.ID),
+			zap.Error(err))
+		return
+	}
+	if err := subscription.handler(subscription.ctx, eventData); err != nil {
+		r.logger.Error("Handler failed",
+			zap.String("topic", subscription.topic),
+			zap.String("message_id", msg.ID),
+			zap.Error(err))
+		return
+	}
+	// Ack on success
+	r.client.XAck(r.ctx, subscription.topic, consumerGroup, msg.ID)
+}
+
+// reclaimPending redelivers the topic's messages that have been pending longer
+// than reclaimIdle to this consumer. Messages delivered maxDeliveries times
+// already are moved to the topic's dead letter stream instead.
+func (r *RedisEventBus) reclaimPending(subscription *RedisSubscription, consumerName string) {
+	pending, err := r.client.XPendingExt(r.ctx, &redis.XPendingExtArgs{
+		Stream: subscription.topic,
+		Group:  consumerGroup,
+		Idle:   r.reclaimIdle,
+		Start:  "-",
+		End:    "+",
+		Count:  reclaimBatch,
+	}).Result()
+	if err != nil {
+		r.logger.Error("Failed to list pending events",
+			zap.String("topic", subscription.topic),
+			zap.Error(err))
+		return
+	}
+	var reclaim []string
+	for _, entry := range pending {
+		if entry.RetryCount >= r.maxDeliveries {
+			r.deadLetter(subscription.topic, entry)
+			continue
+		}
+		reclaim = append(reclaim, entry.ID)
+	}
+	if len(reclaim) == 0 {
+		return
+	}
+
+	// XCLAIM skips messages another consumer reclaimed in the meantime, as they
+	// are no longer idle
+	messages, err := r.client.XClaim(r.ctx, &redis.XClaimArgs{
+		Stream:   subscription.topic,
+		Group:    consumerGroup,
+		Consumer: consumerName,
+		MinIdle:  r.reclaimIdle,
+		Messages: reclaim,
+	}).Result()
+	if err != nil {
+		r.logger.Error("Failed to reclaim pending events",
+			zap.String("topic", subscription.topic),
+			zap.Error(err))
+		return
+	}
+	for _, msg := range messages {
+		r.logger.Warn("Redelivering pending event",
+			zap.String("topic", subscription.topic),
+			zap.String("message_id", msg.ID))
+		r.handleMessage(subscription, msg)
+	}
+}
+
+// deadLetter copies a message that exhausted its deliveries to the topic's dead
+// letter stream and acknowledges it, so it is no longer redelivered
+func (r *RedisEventBus) deadLetter(topic string, entry redis.XPendingExt) {
+	messages, err := r.client.XRangeN(r.ctx, topic, entry.ID, entry.ID, 1).Result()
+	if err != nil {
+		r.logger.Error("Failed to read dead event",
+			zap.String("topic", topic),
+			zap.String("message_id", entry.ID),
+			zap.Error(err))
+		return
+	}
+
+	// A message trimmed from the stream has nothing left to keep
+	if len(messages) > 0 {
+		values := map[string]interface{}{
+			"source_id":  entry.ID,
+			"consumer":   entry.Consumer,
+			"deliveries": entry.RetryCount,
+		}
+		for key, value := range messages[0].Values {
+			values[key] = value
+		}
+		if err := r.client.XAdd(r.ctx, &redis.XAddArgs{
+			Stream: DeadLetterTopic(topic),
+			Values: values,
+		}).Err(); err != nil {
+			r.logger.Error("Failed to dead letter event",
+				zap.String("topic", topic),
+				zap.String("message_id", entry.ID),
+				zap.Error(err))
+			return
+		}
+	}
+
+	r.client.XAck(r.ctx, topic, consumerGroup, entry.ID)
+	r.logger.Error("Event moved to dead letter stream",
+		zap.String("topic", topic),
+		zap.String("message_id", entry.ID),
+		zap.Int64("deliveries", entry.RetryCount))
+}
+
+// DeadLetterTopic is the stream the messages of topic that could not be handled
+// are moved to
+func DeadLetterTopic(topic string) string {
+	return topic + ".dlq"
+}
+
 func (r *RedisEventBus) SubscribeAsync(ctx context.Context, topic string, handler EventHandler) (Subscription, error) {
 	return r.Subscribe(ctx, topic, handler)
 }
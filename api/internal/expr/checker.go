@@ -0,0 +1,496 @@
+package expr
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+var (
+	// ErrSyntax is returned for expressions that do not parse
+	ErrSyntax = errors.New("syntax error")
+	// ErrType is returned for expressions that parse but do not type check
+	ErrType = errors.New("type error")
+	// ErrEvaluation is returned when a well-typed expression fails at runtime,
+	// for example on division by zero or a dyn value of the wrong type
+	ErrEvaluation = errors.New("evaluation error")
+)
+
+// Kind is the kind of a Type
+type Kind int
+
+// Type kinds. Dyn is the type of values that are only known at runtime, such as
+// metadata entries; it is compatible with every other type.
+const (
+	KindDyn Kind = iota
+	KindNull
+	KindBool
+	KindNumber
+	KindString
+	KindTimestamp
+	KindDuration
+	KindList
+	KindMap
+	KindObject
+)
+
+// Type is the static type of an expression
+type Type struct {
+	Kind   Kind
+	Elem   *Type            // list and map element type
+	Fields map[string]*Type // object fields
+}
+
+// Primitive types
+var (
+	Dyn       = &Type{Kind: KindDyn}
+	Null      = &Type{Kind: KindNull}
+	Bool      = &Type{Kind: KindBool}
+	Number    = &Type{Kind: KindNumber}
+	String    = &Type{Kind: KindString}
+	Timestamp = &Type{Kind: KindTimestamp}
+	Duration  = &Type{Kind: KindDuration}
+)
+
+// ListOf returns the type of lists of elem
+func ListOf(elem *Type) *Type { return &Type{Kind: KindList, Elem: elem} }
+
+// MapOf returns the type of string-keyed maps of elem
+func MapOf(elem *Type) *Type { return &Type{Kind: KindMap, Elem: elem} }
+
+// ObjectOf returns the type of an object with a fixed set of fields
+func ObjectOf(fields map[string]*Type) *Type { return &Type{Kind: KindObject, Fields: fields} }
+
+func (t *Type) String() string {
+	switch t.Kind {
+	case KindNull:
+		return "null"
+	case KindBool:
+		return "bool"
+	case KindNumber:
+		return "number"
+	case KindString:
+		return "string"
+	case KindTimestamp:
+		return "timestamp"
+	case KindDuration:
+		return "duration"
+	case KindList:
+		return "list(" + t.Elem.String() + ")"
+	case KindMap:
+		return "map(" + t.Elem.String() + ")"
+	case KindObject:
+		names := make([]string, 0, len(t.Fields))
+		for name := range t.Fields {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return "object{" + strings.Join(names, ", ") + "}"
+	}
+	return "dyn"
+}
+
+// compatible reports whether values of a and b may be compared or combined.
+// Null is compatible with everything so that optional fields can be tested
+// against null.
+func compatible(a, b *Type) bool {
+	if a.Kind == KindDyn || b.Kind == KindDyn || a.Kind == KindNull || b.Kind == KindNull {
+		return true
+	}
+	if a.Kind != b.Kind {
+		return false
+	}
+	if a.Kind == KindList || a.Kind == KindMap {
+		return compatible(a.Elem, b.Elem)
+	}
+	return true
+}
+
+func is(t *Type, kinds ...Kind) bool {
+	for _, k := range kinds {
+		if t.Kind == k {
+			return true
+		}
+	}
+	return false
+}
+
+// unify returns the common type of a and b, falling back to dyn
+func unify(a, b *Type) *Type {
+	switch {
+	case a.Kind == KindNull:
+		return b
+	case b.Kind == KindNull:
+		return a
+	case a.Kind != b.Kind:
+		return Dyn
+	case a.Kind == KindList:
+		return ListOf(unify(a.Elem, b.Elem))
+	case a.Kind == KindMap:
+		return MapOf(unify(a.Elem, b.Elem))
+	case a.Kind == KindObject:
+		return Dyn
+	}
+	return a
+}
+
+// Env declares the variables an expression may reference
+type Env struct {
+	vars map[string]*Type
+}
+
+// NewEnv creates an environment declaring vars
+func NewEnv(vars map[string]*Type) *Env {
+	env := &Env{vars: make(map[string]*Type, len(vars))}
+	for name, t := range vars {
+		env.vars[name] = t
+	}
+	return env
+}
+
+// Extend returns a copy of the environment with additional variables
+func (e *Env) Extend(vars map[string]*Type) *Env {
+	env := NewEnv(e.vars)
+	for name, t := range vars {
+		env.vars[name] = t
+	}
+	return env
+}
+
+// Lookup returns the declared type of a variable
+func (e *Env) Lookup(name string) (*Type, bool) {
+	t, ok := e.vars[name]
+	return t, ok
+}
+
+// Names returns the declared variable names in sorted order
+func (e *Env) Names() []string {
+	names := make([]string, 0, len(e.vars))
+	for name := range e.vars {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// checker assigns a type to every node, rejecting ill-typed expressions before
+// they are stored
+type checker struct {
+	src     string
+	env     *Env
+	regexps map[*callNode]*regexp.Regexp
+}
+
+func (c *checker) errorf(n Node, format string, args ...interface{}) error {
+	return newError(c.src, n.Pos(), ErrType, format, args...)
+}
+
+func (c *checker) check(n Node) (*Type, error) {
+	switch n := n.(type) {
+	case *literalNode:
+		switch n.value.(type) {
+		case float64:
+			return Number, nil
+		case string:
+			return String, nil
+		case bool:
+			return Bool, nil
+		}
+		return Null, nil
+
+	case *identNode:
+		t, ok := c.env.Lookup(n.name)
+		if !ok {
+			return nil, c.errorf(n, "undeclared reference to %q", n.name)
+		}
+		return t, nil
+
+	case *selectNode:
+		operand, err := c.check(n.operand)
+		if err != nil {
+			return nil, err
+		}
+		return c.checkField(n, operand, n.field)
+
+	case *indexNode:
+		operand, err := c.check(n.operand)
+		if err != nil {
+			return nil, err
+		}
+		index, err := c.check(n.index)
+		if err != nil {
+			return nil, err
+		}
+		switch operand.Kind {
+		case KindList:
+			if !compatible(index, Number) {
+				return nil, c.errorf(n, "list index must be a number, not %s", index)
+			}
+			return operand.Elem, nil
+		case KindMap:
+			if !compatible(index, String) {
+				return nil, c.errorf(n, "map key must be a string, not %s", index)
+			}
+			return operand.Elem, nil
+		case KindObject:
+			if key, ok := n.index.(*literalNode); ok {
+				if name, ok := key.value.(string); ok {
+					return c.checkField(n, operand, name)
+				}
+			}
+			return Dyn, nil
+		case KindDyn, KindNull:
+			return Dyn, nil
+		}
+		return nil, c.errorf(n, "cannot index %s", operand)
+
+	case *unaryNode:
+		operand, err := c.check(n.operand)
+		if err != nil {
+			return nil, err
+		}
+		if n.op == "!" {
+			if !compatible(operand, Bool) || operand.Kind == KindNull {
+				return nil, c.errorf(n, "operator ! expects bool, not %s", operand)
+			}
+			return Bool, nil
+		}
+		if !is(operand, KindNumber, KindDuration, KindDyn) {
+			return nil, c.errorf(n, "operator - expects number or duration, not %s", operand)
+		}
+		return operand, nil
+
+	case *binaryNode:
+		return c.checkBinary(n)
+
+	case *condNode:
+		cond, err := c.check(n.cond)
+		if err != nil {
+			return nil, err
+		}
+		if !is(cond, KindBool, KindDyn) {
+			return nil, c.errorf(n.cond, "condition must be bool, not %s", cond)
+		}
+		then, err := c.check(n.then)
+		if err != nil {
+			return nil, err
+		}
+		orElse, err := c.check(n.orElse)
+		if err != nil {
+			return nil, err
+		}
+		return unify(then, orElse), nil
+
+	case *listNode:
+		elem := Null
+		for _, e := range n.elems {
+			t, err := c.check(e)
+			if err != nil {
+				return nil, err
+			}
+			elem = unify(elem, t)
+		}
+		if elem.Kind == KindNull {
+			elem = Dyn
+		}
+		return ListOf(elem), nil
+
+	case *callNode:
+		return c.checkCall(n)
+	}
+	return nil, c.errorf(n, "unsupported expression")
+}
+
+func (c *checker) checkField(n Node, operand *Type, field string) (*Type, error) {
+	switch operand.Kind {
+	case KindObject:
+		t, ok := operand.Fields[field]
+		if !ok {
+			return nil, c.errorf(n, "no field %q on %s", field, operand)
+		}
+		return t, nil
+	case KindMap:
+		return operand.Elem, nil
+	case KindDyn, KindNull:
+		return Dyn, nil
+	}
+	return nil, c.errorf(n, "type %s has no field %q", operand, field)
+}
+
+func (c *checker) checkBinary(n *binaryNode) (*Type, error) {
+	left, err := c.check(n.left)
+	if err != nil {
+		return nil, err
+	}
+	right, err := c.check(n.right)
+	if err != nil {
+		return nil, err
+	}
+	mismatch := func() error {
+		return c.errorf(n, "operator %s cannot be applied to %s and %s", n.op, left, right)
+	}
+	dyn := left.Kind == KindDyn || right.Kind == KindDyn
+
+	switch n.op {
+	case "&&", "||":
+		if !is(left, KindBool, KindDyn) || !is(right, KindBool, KindDyn) {
+			return nil, mismatch()
+		}
+		return Bool, nil
+
+	case "==", "!=":
+		if !compatible(left, right) {
+			return nil, mismatch()
+		}
+		return Bool, nil
+
+	case "<", "<=", ">", ">=":
+		if !compatible(left, right) || !is(left, KindNumber, KindString, KindTimestamp, KindDuration, KindDyn, KindNull) ||
+			!is(right, KindNumber, KindString, KindTimestamp, KindDuration, KindDyn, KindNull) {
+			return nil, mismatch()
+		}
+		return Bool, nil
+
+	case "in":
+		switch right.Kind {
+		case KindList:
+			if !compatible(left, right.Elem) {
+				return nil, mismatch()
+			}
+		case KindMap:
+			if !compatible(left, String) {
+				return nil, mismatch()
+			}
+		case KindDyn:
+		default:
+			return nil, mismatch()
+		}
+		return Bool, nil
+
+	case "+":
+		switch {
+		case dyn:
+			return Dyn, nil
+		case left.Kind == KindNumber && right.Kind == KindNumber:
+			return Number, nil
+		case left.Kind == KindString && right.Kind == KindString:
+			return String, nil
+		case left.Kind == KindTimestamp && right.Kind == KindDuration,
+			left.Kind == KindDuration && right.Kind == KindTimestamp:
+			return Timestamp, nil
+		case left.Kind == KindDuration && right.Kind == KindDuration:
+			return Duration, nil
+		case left.Kind == KindList && right.Kind == KindList:
+			return ListOf(unify(left.Elem, right.Elem)), nil
+		}
+		return nil, mismatch()
+
+	case "-":
+		switch {
+		case dyn:
+			return Dyn, nil
+		case left.Kind == KindNumber && right.Kind == KindNumber:
+			return Number, nil
+		case left.Kind == KindTimestamp && right.Kind == KindTimestamp:
+			return Duration, nil
+		case left.Kind == KindTimestamp && right.Kind == KindDuration:
+			return Timestamp, nil
+		case left.Kind == KindDuration && right.Kind == KindDuration:
+			return Duration, nil
+		}
+		return nil, mismatch()
+	}
+
+	// * / %
+	switch {
+	case left.Kind == KindNumber && right.Kind == KindNumber:
+		return Number, nil
+	case n.op == "*" && (left.Kind == KindDuration && right.Kind == KindNumber || left.Kind == KindNumber && right.Kind == KindDuration):
+		return Duration, nil
+	case dyn && is(left, KindNumber, KindDuration, KindDyn) && is(right, KindNumber, KindDuration, KindDyn):
+		return Dyn, nil
+	}
+	return nil, mismatch()
+}
+
+func (c *checker) checkCall(n *callNode) (*Type, error) {
+	fn, ok := functions[n.fn]
+	if !ok {
+		return nil, c.errorf(n, "unknown function %q", n.fn)
+	}
+	args := make([]*Type, len(n.args))
+	for i, arg := range n.args {
+		t, err := c.check(arg)
+		if err != nil {
+			return nil, err
+		}
+		args[i] = t
+	}
+
+	result, ok := fn.resolve(args)
+	if !ok {
+		names := make([]string, len(args))
+		for i, t := range args {
+			names[i] = t.String()
+		}
+		return nil, c.errorf(n, "no overload of %s accepts (%s); expected %s", n.fn, strings.Join(names, ", "), fn.signatures())
+	}
+
+	// Precompile literal patterns so a bad regex is a save-time error
+	if n.fn == "matches" {
+		if lit, ok := n.args[1].(*literalNode); ok {
+			pattern, _ := lit.value.(string)
+			re, err := regexp.Compile(pattern)
+			if err != nil {
+				return nil, c.errorf(lit, "invalid regular expression: %v", err)
+			}
+			c.regexps[n] = re
+		}
+	}
+	return result, nil
+}
+
+// Program is a parsed and type-checked expression
+type Program struct {
+	src     string
+	root    Node
+	typ     *Type
+	regexps map[*callNode]*regexp.Regexp
+}
+
+// Compile parses and type checks src against env
+func Compile(src string, env *Env) (*Program, error) {
+	if strings.TrimSpace(src) == "" {
+		return nil, &Error{Kind: ErrSyntax, Line: 1, Column: 1, Message: "expression is empty"}
+	}
+	root, err := Parse(src)
+	if err != nil {
+		return nil, err
+	}
+	c := &checker{src: src, env: env, regexps: make(map[*callNode]*regexp.Regexp)}
+	typ, err := c.check(root)
+	if err != nil {
+		return nil, err
+	}
+	return &Program{src: src, root: root, typ: typ, regexps: c.regexps}, nil
+}
+
+// CompileBool compiles src and requires it to produce a bool, as conditions must
+func CompileBool(src string, env *Env) (*Program, error) {
+	p, err := Compile(src, env)
+	if err != nil {
+		return nil, err
+	}
+	if !is(p.typ, KindBool, KindDyn) {
+		return nil, &Error{Kind: ErrType, Line: 1, Column: 1, Message: fmt.Sprintf("condition must be bool, not %s", p.typ)}
+	}
+	return p, nil
+}
+
+// Source returns the expression text
+func (p *Program) Source() string { return p.src }
+
+// Type returns the static result type
+func (p *Program) Type() *Type { return p.typ }
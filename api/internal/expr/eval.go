@@ -0,0 +1,533 @@
+package expr
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// Eval evaluates the program against vars. Values are normalised before use so
+// that every integer type, float32 and json.Number become float64 and typed
+// slices and maps become []interface{} and map[string]interface{}.
+func (p *Program) Eval(vars map[string]interface{}) (interface{}, error) {
+	e := &evaluator{p: p, vars: vars}
+	return e.eval(p.root)
+}
+
+// EvalBool evaluates a condition. A null result, such as a comparison against a
+// missing metadata key, counts as false.
+func (p *Program) EvalBool(vars map[string]interface{}) (bool, error) {
+	v, err := p.Eval(vars)
+	if err != nil {
+		return false, err
+	}
+	switch b := v.(type) {
+	case nil:
+		return false, nil
+	case bool:
+		return b, nil
+	}
+	return false, &Error{Kind: ErrEvaluation, Line: 1, Column: 1, Message: fmt.Sprintf("condition produced %s, not bool", typeName(v))}
+}
+
+type evaluator struct {
+	p    *Program
+	vars map[string]interface{}
+}
+
+func (e *evaluator) errorf(n Node, format string, args ...interface{}) error {
+	return newError(e.p.src, n.Pos(), ErrEvaluation, format, args...)
+}
+
+func (e *evaluator) eval(n Node) (interface{}, error) {
+	switch n := n.(type) {
+	case *literalNode:
+		return n.value, nil
+
+	case *identNode:
+		return Normalize(e.vars[n.name]), nil
+
+	case *selectNode:
+		operand, err := e.eval(n.operand)
+		if err != nil {
+			return nil, err
+		}
+		return e.field(n, operand, n.field)
+
+	case *indexNode:
+		operand, err := e.eval(n.operand)
+		if err != nil {
+			return nil, err
+		}
+		index, err := e.eval(n.index)
+		if err != nil {
+			return nil, err
+		}
+		switch o := operand.(type) {
+		case nil:
+			return nil, nil
+		case []interface{}:
+			i, ok := index.(float64)
+			if !ok || i != math.Trunc(i) {
+				return nil, e.errorf(n, "list index must be a whole number, not %s", typeName(index))
+			}
+			if i < 0 || int(i) >= len(o) {
+				return nil, nil
+			}
+			return Normalize(o[int(i)]), nil
+		case map[string]interface{}:
+			key, ok := index.(string)
+			if !ok {
+				return nil, e.errorf(n, "map key must be a string, not %s", typeName(index))
+			}
+			return Normalize(o[key]), nil
+		}
+		return nil, e.errorf(n, "cannot index %s", typeName(operand))
+
+	case *unaryNode:
+		operand, err := e.eval(n.operand)
+		if err != nil {
+			return nil, err
+		}
+		if n.op == "!" {
+			b, ok := truthy(operand)
+			if !ok {
+				return nil, e.errorf(n, "operator ! expects bool, not %s", typeName(operand))
+			}
+			return !b, nil
+		}
+		switch v := operand.(type) {
+		case float64:
+			return -v, nil
+		case time.Duration:
+			return -v, nil
+		}
+		return nil, e.errorf(n, "operator - expects number or duration, not %s", typeName(operand))
+
+	case *binaryNode:
+		return e.binary(n)
+
+	case *condNode:
+		cond, err := e.eval(n.cond)
+		if err != nil {
+			return nil, err
+		}
+		b, ok := truthy(cond)
+		if !ok {
+			return nil, e.errorf(n.cond, "condition must be bool, not %s", typeName(cond))
+		}
+		if b {
+			return e.eval(n.then)
+		}
+		return e.eval(n.orElse)
+
+	case *listNode:
+		list := make([]interface{}, len(n.elems))
+		for i, elem := range n.elems {
+			v, err := e.eval(elem)
+			if err != nil {
+				return nil, err
+			}
+			list[i] = v
+		}
+		return list, nil
+
+	case *callNode:
+		args := make([]interface{}, len(n.args))
+		for i, arg := range n.args {
+			v, err := e.eval(arg)
+			if err != nil {
+				return nil, err
+			}
+			args[i] = v
+		}
+		if re, ok := e.p.regexps[n]; ok {
+			s, _ := args[0].(string)
+			return re.MatchString(s), nil
+		}
+		v, err := functions[n.fn].call(args)
+		if err != nil {
+			return nil, e.errorf(n, "%s: %v", n.fn, err)
+		}
+		return v, nil
+	}
+	return nil, e.errorf(n, "unsupported expression")
+}
+
+func (e *evaluator) field(n Node, operand interface{}, name string) (interface{}, error) {
+	switch o := operand.(type) {
+	case nil:
+		return nil, nil
+	case map[string]interface{}:
+		return Normalize(o[name]), nil
+	}
+	return nil, e.errorf(n, "cannot select field %q on %s", name, typeName(operand))
+}
+
+// truthy interprets a condition value; null is false
+func truthy(v interface{}) (bool, bool) {
+	switch b := v.(type) {
+	case nil:
+		return false, true
+	case bool:
+		return b, true
+	}
+	return false, false
+}
+
+func (e *evaluator) binary(n *binaryNode) (interface{}, error) {
+	left, err := e.eval(n.left)
+	if err != nil {
+		return nil, err
+	}
+
+	if n.op == "&&" || n.op == "||" {
+		l, ok := truthy(left)
+		if !ok {
+			return nil, e.errorf(n, "operator %s expects bool, not %s", n.op, typeName(left))
+		}
+		if n.op == "&&" && !l || n.op == "||" && l {
+			return l, nil
+		}
+		right, err := e.eval(n.right)
+		if err != nil {
+			return nil, err
+		}
+		r, ok := truthy(right)
+		if !ok {
+			return nil, e.errorf(n, "operator %s expects bool, not %s", n.op, typeName(right))
+		}
+		return r, nil
+	}
+
+	right, err := e.eval(n.right)
+	if err != nil {
+		return nil, err
+	}
+
+	switch n.op {
+	case "==":
+		return Equal(left, right), nil
+	case "!=":
+		return !Equal(left, right), nil
+	case "<", "<=", ">", ">=":
+		// Ordering against null is false rather than an error so rules over
+		// optional fields simply do not match
+		if left == nil || right == nil {
+			return false, nil
+		}
+		cmp, ok := Compare(left, right)
+		if !ok {
+			return nil, e.errorf(n, "cannot compare %s and %s", typeName(left), typeName(right))
+		}
+		switch n.op {
+		case "<":
+			return cmp < 0, nil
+		case "<=":
+			return cmp <= 0, nil
+		case ">":
+			return cmp > 0, nil
+		}
+		return cmp >= 0, nil
+	case "in":
+		switch r := right.(type) {
+		case nil:
+			return false, nil
+		case []interface{}:
+			for _, item := range r {
+				if Equal(left, item) {
+					return true, nil
+				}
+			}
+			return false, nil
+		case map[string]interface{}:
+			key, ok := left.(string)
+			if !ok {
+				return false, nil
+			}
+			_, found := r[key]
+			return found, nil
+		case string:
+			s, ok := left.(string)
+			return ok && strings.Contains(r, s), nil
+		}
+		return nil, e.errorf(n, "operator in expects a list or map, not %s", typeName(right))
+	}
+
+	v, err := arithmetic(n.op, left, right)
+	if err != nil {
+		return nil, e.errorf(n, "%v", err)
+	}
+	return v, nil
+}
+
+func arithmetic(op string, left, right interface{}) (interface{}, error) {
+	switch l := left.(type) {
+	case float64:
+		switch r := right.(type) {
+		case float64:
+			switch op {
+			case "+":
+				return l + r, nil
+			case "-":
+				return l - r, nil
+			case "*":
+				return l * r, nil
+			case "/":
+				if r == 0 {
+					return nil, fmt.Errorf("division by zero")
+				}
+				return l / r, nil
+			case "%":
+				if r == 0 {
+					return nil, fmt.Errorf("modulo by zero")
+				}
+				return math.Mod(l, r), nil
+			}
+		case time.Duration:
+			if op == "*" {
+				return time.Duration(l * float64(r)), nil
+			}
+		}
+	case string:
+		if r, ok := right.(string); ok && op == "+" {
+			return l + r, nil
+		}
+	case time.Time:
+		switch r := right.(type) {
+		case time.Duration:
+			switch op {
+			case "+":
+				return l.Add(r), nil
+			case "-":
+				return l.Add(-r), nil
+			}
+		case time.Time:
+			if op == "-" {
+				return l.Sub(r), nil
+			}
+		}
+	case time.Duration:
+		switch r := right.(type) {
+		case time.Duration:
+			switch op {
+			case "+":
+				return l + r, nil
+			case "-":
+				return l - r, nil
+			}
+		case time.Time:
+			if op == "+" {
+				return r.Add(l), nil
+			}
+		case float64:
+			if op == "*" {
+				return time.Duration(float64(l) * r), nil
+			}
+		}
+	case []interface{}:
+		if r, ok := right.([]interface{}); ok && op == "+" {
+			joined := make([]interface{}, 0, len(l)+len(r))
+			return append(append(joined, l...), r...), nil
+		}
+	}
+	return nil, fmt.Errorf("operator %s cannot be applied to %s and %s", op, typeName(left), typeName(right))
+}
+
+// Normalize converts a Go value into the runtime representation used by the
+// evaluator: nil, bool, float64, string, time.Time, time.Duration,
+// []interface{} or map[string]interface{}. Nested values are normalised when
+// they are accessed.
+func Normalize(v interface{}) interface{} {
+	switch x := v.(type) {
+	case nil, bool, float64, string, time.Time, time.Duration, []interface{}, map[string]interface{}:
+		return v
+	case int:
+		return float64(x)
+	case int8:
+		return float64(x)
+	case int16:
+		return float64(x)
+	case int32:
+		return float64(x)
+	case int64:
+		return float64(x)
+	case uint:
+		return float64(x)
+	case uint8:
+		return float64(x)
+	case uint16:
+		return float64(x)
+	case uint32:
+		return float64(x)
+	case uint64:
+		return float64(x)
+	case float32:
+		return float64(x)
+	case json.Number:
+		if f, err := x.Float64(); err == nil {
+			return f
+		}
+		return x.String()
+	case *time.Time:
+		if x == nil {
+			return nil
+		}
+		return *x
+	case []string:
+		list := make([]interface{}, len(x))
+		for i, s := range x {
+			list[i] = s
+		}
+		return list
+	case map[string]string:
+		m := make(map[string]interface{}, len(x))
+		for k, s := range x {
+			m[k] = s
+		}
+		return m
+	case fmt.Stringer:
+		return x.String()
+	}
+
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Ptr:
+		if rv.IsNil() {
+			return nil
+		}
+		return Normalize(rv.Elem().Interface())
+	case reflect.Slice, reflect.Array:
+		list := make([]interface{}, rv.Len())
+		for i := range list {
+			list[i] = rv.Index(i).Interface()
+		}
+		return list
+	case reflect.Map:
+		if rv.Type().Key().Kind() != reflect.String {
+			return v
+		}
+		m := make(map[string]interface{}, rv.Len())
+		iter := rv.MapRange()
+		for iter.Next() {
+			m[iter.Key().String()] = iter.Value().Interface()
+		}
+		return m
+	}
+	return v
+}
+
+// Equal compares two values by their normalised form, so 100, int64(100),
+// float64(100) and json.Number("100") are all equal
+func Equal(a, b interface{}) bool {
+	a, b = Normalize(a), Normalize(b)
+	switch x := a.(type) {
+	case nil:
+		return b == nil
+	case bool:
+		y, ok := b.(bool)
+		return ok && x == y
+	case float64:
+		y, ok := b.(float64)
+		return ok && x == y
+	case string:
+		y, ok := b.(string)
+		return ok && x == y
+	case time.Time:
+		y, ok := b.(time.Time)
+		return ok && x.Equal(y)
+	case time.Duration:
+		y, ok := b.(time.Duration)
+		return ok && x == y
+	case []interface{}:
+		y, ok := b.([]interface{})
+		if !ok || len(x) != len(y) {
+			return false
+		}
+		for i := range x {
+			if !Equal(x[i], y[i]) {
+				return false
+			}
+		}
+		return true
+	case map[string]interface{}:
+		y, ok := b.(map[string]interface{})
+		if !ok || len(x) != len(y) {
+			return false
+		}
+		for k, v := range x {
+			w, found := y[k]
+			if !found || !Equal(v, w) {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// Compare orders two numbers, strings, timestamps or durations. The second
+// result is false when the values are not of the same orderable kind.
+func Compare(a, b interface{}) (int, bool) {
+	a, b = Normalize(a), Normalize(b)
+	switch x := a.(type) {
+	case float64:
+		if y, ok := b.(float64); ok {
+			return compareOrdered(x, y), true
+		}
+	case string:
+		if y, ok := b.(string); ok {
+			return strings.Compare(x, y), true
+		}
+	case time.Time:
+		if y, ok := b.(time.Time); ok {
+			switch {
+			case x.Before(y):
+				return -1, true
+			case x.After(y):
+				return 1, true
+			}
+			return 0, true
+		}
+	case time.Duration:
+		if y, ok := b.(time.Duration); ok {
+			return compareOrdered(float64(x), float64(y)), true
+		}
+	}
+	return 0, false
+}
+
+func compareOrdered(x, y float64) int {
+	switch {
+	case x < y:
+		return -1
+	case x > y:
+		return 1
+	}
+	return 0
+}
+
+func typeName(v interface{}) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "bool"
+	case float64:
+		return "number"
+	case string:
+		return "string"
+	case time.Time:
+		return "timestamp"
+	case time.Duration:
+		return "duration"
+	case []interface{}:
+		return "list"
+	case map[string]interface{}:
+		return "map"
+	}
+	return fmt.Sprintf("%T", v)
+}
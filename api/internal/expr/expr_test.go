@@ -0,0 +1,136 @@
+package expr
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sambitmohanty1/payment-watchdog/api/internal/models"
+)
+
+func testEvent() *models.PaymentFailureEvent {
+	due := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	return &models.PaymentFailureEvent{
+		ProviderID:       "stripe",
+		AmountCents:      125050,
+		Currency:         "AUD",
+		CustomerEmail:    "billing@acme.example",
+		CustomerName:     "Acme Pty Ltd",
+		FailureReason:    "insufficient_funds",
+		RetryCount:       2,
+		DueDate:          &due,
+		InvoiceNumber:    "INV-1042",
+		Tags:             []string{"enterprise", "annual"},
+		CustomerSegments: []string{"recurring", "vip"},
+		NormalizedData:   `{"plan": "gold", "seats": 100, "owner": {"region": "apac"}}`,
+		CreatedAt:        due.Add(48 * time.Hour),
+	}
+}
+
+func TestPaymentFailureExpressions(t *testing.T) {
+	env := PaymentFailureEnv()
+	now := time.Date(2024, 3, 31, 12, 0, 0, 0, time.UTC)
+	vars := PaymentFailureActivation(testEvent(), now)
+
+	tests := []struct {
+		src  string
+		want bool
+	}{
+		{`amount > 1000 && currency == "AUD"`, true},
+		{`amount_cents == 125050`, true},
+		{`amount * 2 - 1 >= 2500`, true},
+		{`failure_reason in ["insufficient_funds", "card_declined"]`, true},
+		{`provider in ["paypal"]`, false},
+		{`"annual" in tags && size(tags) == 2`, true},
+		{`customer.email.endsWith("@acme.example")`, true},
+		{`lower(customer.name).contains("acme")`, true},
+		{`"vip" in customer.segments && !("hardship" in customer.segments)`, true},
+		{`invoice.number.matches('^INV-\d+$')`, true},
+		{`invoice.days_overdue == 30 && days_overdue >= 30`, true},
+		{`now - due_date > days(29)`, true},
+		{`due_date + duration("30d") <= now`, true},
+		{`daysBetween(due_date, created_at) == 2`, true},
+		{`created_at > timestamp("2024-03-02")`, true},
+		{`metadata.plan == "gold" && metadata["seats"] == 100`, true},
+		{`metadata.owner.region == "apac"`, true},
+		{`metadata.missing == null`, true},
+		{`metadata.missing > 10`, false},
+		{`metadata.missing.deeper == "x"`, false},
+		{`retry_count < 3 ? amount > 1000 : false`, true},
+		{`!(status == "resolved")`, true},
+		{`getDayOfWeek(due_date) == 5`, true},
+		{`getHour(now, "Australia/Sydney") == 23`, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.src, func(t *testing.T) {
+			program, err := CompileBool(tt.src, env)
+			require.NoError(t, err)
+			got, err := program.EvalBool(vars)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestTypeErrorsAreReportedAtCompileTime(t *testing.T) {
+	env := PaymentFailureEnv()
+	tests := []struct {
+		src  string
+		kind error
+		pos  string
+	}{
+		{`amount > "100"`, ErrType, "1:8"},
+		{`currency == 1`, ErrType, "1:10"},
+		{`customer.address == "x"`, ErrType, "1:10"},
+		{`unknown_field == 1`, ErrType, "1:1"},
+		{`upper(amount) == "X"`, ErrType, "1:1"},
+		{`amount + 1`, ErrType, "1:1"},
+		{"amount > 1 &&\n  failure_reason.matches('[')", ErrType, "2:26"},
+		{`amount > `, ErrSyntax, "1:10"},
+		{`(amount > 1`, ErrSyntax, "1:12"},
+		{`amount @ 1`, ErrSyntax, "1:8"},
+		{`'unterminated`, ErrSyntax, "1:1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.src, func(t *testing.T) {
+			_, err := CompileBool(tt.src, env)
+			require.Error(t, err)
+			assert.True(t, errors.Is(err, tt.kind), "got %v", err)
+			assert.Contains(t, err.Error(), tt.pos)
+		})
+	}
+}
+
+func TestEqualNormalisesNumbers(t *testing.T) {
+	var decoded interface{}
+	require.NoError(t, json.Unmarshal([]byte(`100`), &decoded))
+
+	assert.True(t, Equal(decoded, 100))
+	assert.True(t, Equal(int64(100), json.Number("100")))
+	assert.True(t, Equal([]string{"a"}, []interface{}{"a"}))
+	assert.False(t, Equal(100, "100"))
+	assert.False(t, Equal(nil, 0))
+
+	// Dyn values from the activation compare by value, not Go type
+	program, err := CompileBool(`threshold == amount`, NewEnv(map[string]*Type{"threshold": Dyn, "amount": Number}))
+	require.NoError(t, err)
+	ok, err := program.EvalBool(map[string]interface{}{"threshold": decoded, "amount": 100})
+	require.NoError(t, err)
+	assert.True(t, ok)
+}
+
+func TestEvaluationErrors(t *testing.T) {
+	program, err := Compile(`amount / divisor`, NewEnv(map[string]*Type{"amount": Number, "divisor": Number}))
+	require.NoError(t, err)
+	_, err = program.Eval(map[string]interface{}{"amount": 10, "divisor": 0})
+	assert.True(t, errors.Is(err, ErrEvaluation))
+
+	program, err = CompileBool(`metadata.flag`, PaymentFailureEnv())
+	require.NoError(t, err)
+	_, err = program.EvalBool(map[string]interface{}{"metadata": map[string]interface{}{"flag": "yes"}})
+	assert.True(t, errors.Is(err, ErrEvaluation))
+}
@@ -0,0 +1,339 @@
+package expr
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// signature is one overload of a function
+type signature struct {
+	params []*Type
+	result *Type
+}
+
+// function is a builtin. Overloads are resolved at check time; call dispatches
+// on the runtime values.
+type function struct {
+	overloads []signature
+	call      func(args []interface{}) (interface{}, error)
+}
+
+func (f function) resolve(args []*Type) (*Type, bool) {
+	for _, sig := range f.overloads {
+		if len(sig.params) != len(args) {
+			continue
+		}
+		ok := true
+		for i, param := range sig.params {
+			if !compatible(param, args[i]) {
+				ok = false
+				break
+			}
+		}
+		if ok {
+			return sig.result, true
+		}
+	}
+	return nil, false
+}
+
+func (f function) signatures() string {
+	list := make([]string, len(f.overloads))
+	for i, sig := range f.overloads {
+		params := make([]string, len(sig.params))
+		for j, p := range sig.params {
+			params[j] = p.String()
+		}
+		list[i] = "(" + strings.Join(params, ", ") + ")"
+	}
+	return strings.Join(list, " or ")
+}
+
+func sig(result *Type, params ...*Type) signature {
+	return signature{params: params, result: result}
+}
+
+// functions are the builtins available to every expression. Methods such as
+// email.endsWith("@example.com") call the function with the receiver first.
+var functions = map[string]function{
+	"size": {
+		overloads: []signature{sig(Number, String), sig(Number, ListOf(Dyn)), sig(Number, MapOf(Dyn))},
+		call: func(args []interface{}) (interface{}, error) {
+			switch v := args[0].(type) {
+			case nil:
+				return float64(0), nil
+			case string:
+				return float64(utf8.RuneCountInString(v)), nil
+			case []interface{}:
+				return float64(len(v)), nil
+			case map[string]interface{}:
+				return float64(len(v)), nil
+			}
+			return nil, fmt.Errorf("unsupported argument %s", typeName(args[0]))
+		},
+	},
+	"lower":      stringFunc(strings.ToLower),
+	"upper":      stringFunc(strings.ToUpper),
+	"trim":       stringFunc(strings.TrimSpace),
+	"contains":   stringPredicate(strings.Contains),
+	"startsWith": stringPredicate(strings.HasPrefix),
+	"endsWith":   stringPredicate(strings.HasSuffix),
+	"matches": {
+		overloads: []signature{sig(Bool, String, String)},
+		call: func(args []interface{}) (interface{}, error) {
+			// Non-literal patterns are compiled per call; literals are
+			// precompiled by the checker and never reach here
+			s, pattern := stringArg(args[0]), stringArg(args[1])
+			re, err := regexp.Compile(pattern)
+			if err != nil {
+				return nil, err
+			}
+			return re.MatchString(s), nil
+		},
+	},
+	"timestamp": {
+		overloads: []signature{sig(Timestamp, String)},
+		call: func(args []interface{}) (interface{}, error) {
+			if args[0] == nil {
+				return nil, nil
+			}
+			return ParseTimestamp(stringArg(args[0]))
+		},
+	},
+	"duration": {
+		overloads: []signature{sig(Duration, String)},
+		call: func(args []interface{}) (interface{}, error) {
+			if args[0] == nil {
+				return nil, nil
+			}
+			return ParseDuration(stringArg(args[0]))
+		},
+	},
+	"days":    durationFunc(24 * time.Hour),
+	"hours":   durationFunc(time.Hour),
+	"minutes": durationFunc(time.Minute),
+	"inDays":  durationIn(24 * time.Hour),
+	"inHours": durationIn(time.Hour),
+	"daysBetween": {
+		overloads: []signature{sig(Number, Timestamp, Timestamp)},
+		call: func(args []interface{}) (interface{}, error) {
+			from, ok1 := args[0].(time.Time)
+			to, ok2 := args[1].(time.Time)
+			if !ok1 || !ok2 {
+				return nil, nil
+			}
+			return math.Floor(to.Sub(from).Hours() / 24), nil
+		},
+	},
+	"getHour":       timePart(func(t time.Time) int { return t.Hour() }),
+	"getDayOfWeek":  timePart(func(t time.Time) int { return int(t.Weekday()) }),
+	"getDayOfMonth": timePart(func(t time.Time) int { return t.Day() }),
+	"getMonth":      timePart(func(t time.Time) int { return int(t.Month()) }),
+	"floor":         numberFunc(math.Floor),
+	"ceil":          numberFunc(math.Ceil),
+	"round":         numberFunc(math.Round),
+	"abs":           numberFunc(math.Abs),
+	"min": {
+		overloads: []signature{sig(Number, Number, Number)},
+		call: func(args []interface{}) (interface{}, error) {
+			a, b, err := numberArgs(args)
+			if err != nil {
+				return nil, err
+			}
+			return math.Min(a, b), nil
+		},
+	},
+	"max": {
+		overloads: []signature{sig(Number, Number, Number)},
+		call: func(args []interface{}) (interface{}, error) {
+			a, b, err := numberArgs(args)
+			if err != nil {
+				return nil, err
+			}
+			return math.Max(a, b), nil
+		},
+	},
+	"string": {
+		overloads: []signature{sig(String, Dyn)},
+		call: func(args []interface{}) (interface{}, error) {
+			return FormatValue(args[0]), nil
+		},
+	},
+	"number": {
+		overloads: []signature{sig(Number, Dyn)},
+		call: func(args []interface{}) (interface{}, error) {
+			switch v := args[0].(type) {
+			case nil:
+				return nil, nil
+			case float64:
+				return v, nil
+			case bool:
+				if v {
+					return float64(1), nil
+				}
+				return float64(0), nil
+			case string:
+				n, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+				if err != nil {
+					return nil, fmt.Errorf("%q is not a number", v)
+				}
+				return n, nil
+			}
+			return nil, fmt.Errorf("cannot convert %s to number", typeName(args[0]))
+		},
+	},
+}
+
+func stringArg(v interface{}) string {
+	s, _ := v.(string)
+	return s
+}
+
+func stringFunc(fn func(string) string) function {
+	return function{
+		overloads: []signature{sig(String, String)},
+		call: func(args []interface{}) (interface{}, error) {
+			if args[0] == nil {
+				return nil, nil
+			}
+			return fn(stringArg(args[0])), nil
+		},
+	}
+}
+
+func stringPredicate(fn func(string, string) bool) function {
+	return function{
+		overloads: []signature{sig(Bool, String, String)},
+		call: func(args []interface{}) (interface{}, error) {
+			if args[0] == nil {
+				return false, nil
+			}
+			return fn(stringArg(args[0]), stringArg(args[1])), nil
+		},
+	}
+}
+
+func numberFunc(fn func(float64) float64) function {
+	return function{
+		overloads: []signature{sig(Number, Number)},
+		call: func(args []interface{}) (interface{}, error) {
+			n, ok := args[0].(float64)
+			if !ok {
+				return nil, fmt.Errorf("expected number, got %s", typeName(args[0]))
+			}
+			return fn(n), nil
+		},
+	}
+}
+
+func numberArgs(args []interface{}) (float64, float64, error) {
+	a, ok1 := args[0].(float64)
+	b, ok2 := args[1].(float64)
+	if !ok1 || !ok2 {
+		return 0, 0, fmt.Errorf("expected numbers, got %s and %s", typeName(args[0]), typeName(args[1]))
+	}
+	return a, b, nil
+}
+
+func durationFunc(unit time.Duration) function {
+	return function{
+		overloads: []signature{sig(Duration, Number)},
+		call: func(args []interface{}) (interface{}, error) {
+			n, ok := args[0].(float64)
+			if !ok {
+				return nil, fmt.Errorf("expected number, got %s", typeName(args[0]))
+			}
+			return time.Duration(n * float64(unit)), nil
+		},
+	}
+}
+
+func durationIn(unit time.Duration) function {
+	return function{
+		overloads: []signature{sig(Number, Duration)},
+		call: func(args []interface{}) (interface{}, error) {
+			d, ok := args[0].(time.Duration)
+			if !ok {
+				return nil, nil
+			}
+			return float64(d) / float64(unit), nil
+		},
+	}
+}
+
+// timePart extracts a calendar field in UTC, or in the IANA zone given as the
+// second argument
+func timePart(part func(time.Time) int) function {
+	return function{
+		overloads: []signature{sig(Number, Timestamp), sig(Number, Timestamp, String)},
+		call: func(args []interface{}) (interface{}, error) {
+			t, ok := args[0].(time.Time)
+			if !ok {
+				return nil, nil
+			}
+			loc := time.UTC
+			if len(args) == 2 {
+				var err error
+				if loc, err = time.LoadLocation(stringArg(args[1])); err != nil {
+					return nil, fmt.Errorf("unknown time zone %q", stringArg(args[1]))
+				}
+			}
+			return float64(part(t.In(loc))), nil
+		},
+	}
+}
+
+// ParseTimestamp accepts RFC 3339 timestamps and plain dates
+func ParseTimestamp(s string) (time.Time, error) {
+	for _, layout := range []string{time.RFC3339Nano, "2006-01-02"} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid timestamp %q", s)
+}
+
+// ParseDuration accepts Go durations such as "90m" plus whole days such as "30d"
+func ParseDuration(s string) (time.Duration, error) {
+	if strings.HasSuffix(s, "d") {
+		days, err := strconv.ParseFloat(strings.TrimSuffix(s, "d"), 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid duration %q", s)
+		}
+		return time.Duration(days * float64(24*time.Hour)), nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid duration %q", s)
+	}
+	return d, nil
+}
+
+// FormatValue renders a runtime value as a string
+func FormatValue(v interface{}) string {
+	switch x := Normalize(v).(type) {
+	case nil:
+		return ""
+	case string:
+		return x
+	case float64:
+		return strconv.FormatFloat(x, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(x)
+	case time.Time:
+		return x.Format(time.RFC3339)
+	case time.Duration:
+		return x.String()
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(data)
+}
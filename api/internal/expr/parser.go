@@ -0,0 +1,452 @@
+package expr
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Node is a parsed expression
+type Node interface {
+	Pos() int
+}
+
+type (
+	literalNode struct {
+		pos   int
+		value interface{}
+	}
+	identNode struct {
+		pos  int
+		name string
+	}
+	selectNode struct {
+		pos     int
+		operand Node
+		field   string
+	}
+	indexNode struct {
+		pos     int
+		operand Node
+		index   Node
+	}
+	callNode struct {
+		pos  int
+		fn   string
+		args []Node
+	}
+	unaryNode struct {
+		pos     int
+		op      string
+		operand Node
+	}
+	binaryNode struct {
+		pos         int
+		op          string
+		left, right Node
+	}
+	condNode struct {
+		pos                int
+		cond, then, orElse Node
+	}
+	listNode struct {
+		pos   int
+		elems []Node
+	}
+)
+
+func (n *literalNode) Pos() int { return n.pos }
+func (n *identNode) Pos() int   { return n.pos }
+func (n *selectNode) Pos() int  { return n.pos }
+func (n *indexNode) Pos() int   { return n.pos }
+func (n *callNode) Pos() int    { return n.pos }
+func (n *unaryNode) Pos() int   { return n.pos }
+func (n *binaryNode) Pos() int  { return n.pos }
+func (n *condNode) Pos() int    { return n.pos }
+func (n *listNode) Pos() int    { return n.pos }
+
+// Token kinds
+const (
+	tokEOF = iota
+	tokNumber
+	tokString
+	tokIdent
+	tokOp
+)
+
+type token struct {
+	kind int
+	text string
+	pos  int
+	num  float64
+}
+
+func lex(src string) ([]token, error) {
+	var tokens []token
+	i := 0
+	for i < len(src) {
+		c := src[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+
+		case c >= '0' && c <= '9' || c == '.' && i+1 < len(src) && src[i+1] >= '0' && src[i+1] <= '9':
+			start := i
+			for i < len(src) && (src[i] >= '0' && src[i] <= '9' || src[i] == '.' || src[i] == '_') {
+				i++
+			}
+			if i < len(src) && (src[i] == 'e' || src[i] == 'E') {
+				i++
+				if i < len(src) && (src[i] == '+' || src[i] == '-') {
+					i++
+				}
+				for i < len(src) && src[i] >= '0' && src[i] <= '9' {
+					i++
+				}
+			}
+			text := src[start:i]
+			n, err := strconv.ParseFloat(strings.ReplaceAll(text, "_", ""), 64)
+			if err != nil {
+				return nil, newError(src, start, ErrSyntax, "invalid number %q", text)
+			}
+			tokens = append(tokens, token{kind: tokNumber, text: text, pos: start, num: n})
+
+		case c == '"' || c == '\'':
+			start := i
+			s, end, err := lexString(src, i)
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, token{kind: tokString, text: s, pos: start})
+			i = end
+
+		case c == '_' || unicode.IsLetter(rune(c)):
+			start := i
+			for i < len(src) && (src[i] == '_' || unicode.IsLetter(rune(src[i])) || src[i] >= '0' && src[i] <= '9') {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokIdent, text: src[start:i], pos: start})
+
+		default:
+			op := ""
+			for _, candidate := range []string{"==", "!=", "<=", ">=", "&&", "||", "+", "-", "*", "/", "%", "<", ">", "!", "(", ")", "[", "]", ",", ".", "?", ":"} {
+				if strings.HasPrefix(src[i:], candidate) {
+					op = candidate
+					break
+				}
+			}
+			if op == "" {
+				return nil, newError(src, i, ErrSyntax, "unexpected character %q", c)
+			}
+			tokens = append(tokens, token{kind: tokOp, text: op, pos: i})
+			i += len(op)
+		}
+	}
+	return append(tokens, token{kind: tokEOF, pos: len(src)}), nil
+}
+
+func lexString(src string, start int) (string, int, error) {
+	quote := src[start]
+	var b strings.Builder
+	for i := start + 1; i < len(src); i++ {
+		c := src[i]
+		switch {
+		case c == quote:
+			return b.String(), i + 1, nil
+		case c == '\\' && i+1 < len(src):
+			i++
+			switch src[i] {
+			case 'n':
+				b.WriteByte('\n')
+			case 't':
+				b.WriteByte('\t')
+			case '\\', '"', '\'':
+				b.WriteByte(src[i])
+			default:
+				// Keep unknown escapes so regex patterns like '\d' survive
+				b.WriteByte('\\')
+				b.WriteByte(src[i])
+			}
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return "", 0, newError(src, start, ErrSyntax, "unterminated string")
+}
+
+// parser is a precedence-climbing parser over the token stream:
+//
+//	cond    = or ["?" cond ":" cond]
+//	or      = and {"||" and}
+//	and     = rel {"&&" rel}
+//	rel     = add {("==" | "!=" | "<" | "<=" | ">" | ">=" | "in") add}
+//	add     = mul {("+" | "-") mul}
+//	mul     = unary {("*" | "/" | "%") unary}
+//	unary   = ("!" | "-") unary | member
+//	member  = primary {"." ident ["(" args ")"] | "[" cond "]"}
+//	primary = number | string | true | false | null | ident ["(" args ")"] | "(" cond ")" | "[" args "]"
+type parser struct {
+	src    string
+	tokens []token
+	i      int
+}
+
+// Parse parses src into an expression tree without type checking it
+func Parse(src string) (Node, error) {
+	tokens, err := lex(src)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{src: src, tokens: tokens}
+	node, err := p.parseCond()
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.kind != tokEOF {
+		return nil, p.errorf(tok, "unexpected %q", tok.text)
+	}
+	return node, nil
+}
+
+func (p *parser) peek() token { return p.tokens[p.i] }
+
+func (p *parser) next() token {
+	tok := p.tokens[p.i]
+	if tok.kind != tokEOF {
+		p.i++
+	}
+	return tok
+}
+
+func (p *parser) isOp(text string) bool {
+	tok := p.peek()
+	return tok.kind == tokOp && tok.text == text
+}
+
+func (p *parser) expect(text string) error {
+	tok := p.next()
+	if tok.kind != tokOp || tok.text != text {
+		if tok.kind == tokEOF {
+			return p.errorf(tok, "expected %q but expression ended", text)
+		}
+		return p.errorf(tok, "expected %q, found %q", text, tok.text)
+	}
+	return nil
+}
+
+func (p *parser) errorf(tok token, format string, args ...interface{}) error {
+	return newError(p.src, tok.pos, ErrSyntax, format, args...)
+}
+
+func (p *parser) parseCond() (Node, error) {
+	cond, err := p.parseOr()
+	if err != nil || !p.isOp("?") {
+		return cond, err
+	}
+	tok := p.next()
+	then, err := p.parseCond()
+	if err != nil {
+		return nil, err
+	}
+	if err := p.expect(":"); err != nil {
+		return nil, err
+	}
+	orElse, err := p.parseCond()
+	if err != nil {
+		return nil, err
+	}
+	return &condNode{pos: tok.pos, cond: cond, then: then, orElse: orElse}, nil
+}
+
+func (p *parser) parseBinary(ops []string, operand func() (Node, error)) (Node, error) {
+	left, err := operand()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		tok := p.peek()
+		matched := ""
+		for _, op := range ops {
+			if (tok.kind == tokOp || tok.kind == tokIdent && op == "in") && tok.text == op {
+				matched = op
+				break
+			}
+		}
+		if matched == "" {
+			return left, nil
+		}
+		p.next()
+		right, err := operand()
+		if err != nil {
+			return nil, err
+		}
+		left = &binaryNode{pos: tok.pos, op: matched, left: left, right: right}
+	}
+}
+
+func (p *parser) parseOr() (Node, error) {
+	return p.parseBinary([]string{"||"}, p.parseAnd)
+}
+
+func (p *parser) parseAnd() (Node, error) {
+	return p.parseBinary([]string{"&&"}, p.parseRel)
+}
+
+func (p *parser) parseRel() (Node, error) {
+	return p.parseBinary([]string{"==", "!=", "<=", ">=", "<", ">", "in"}, p.parseAdd)
+}
+
+func (p *parser) parseAdd() (Node, error) {
+	return p.parseBinary([]string{"+", "-"}, p.parseMul)
+}
+
+func (p *parser) parseMul() (Node, error) {
+	return p.parseBinary([]string{"*", "/", "%"}, p.parseUnary)
+}
+
+func (p *parser) parseUnary() (Node, error) {
+	if p.isOp("!") || p.isOp("-") {
+		tok := p.next()
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &unaryNode{pos: tok.pos, op: tok.text, operand: operand}, nil
+	}
+	return p.parseMember()
+}
+
+func (p *parser) parseMember() (Node, error) {
+	node, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		switch {
+		case p.isOp("."):
+			p.next()
+			tok := p.next()
+			if tok.kind != tokIdent {
+				return nil, p.errorf(tok, "expected field name after '.'")
+			}
+			if p.isOp("(") {
+				// Method call: receiver becomes the first argument
+				p.next()
+				args, err := p.parseArgs(")")
+				if err != nil {
+					return nil, err
+				}
+				node = &callNode{pos: tok.pos, fn: tok.text, args: append([]Node{node}, args...)}
+				continue
+			}
+			node = &selectNode{pos: tok.pos, operand: node, field: tok.text}
+		case p.isOp("["):
+			tok := p.next()
+			index, err := p.parseCond()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expect("]"); err != nil {
+				return nil, err
+			}
+			node = &indexNode{pos: tok.pos, operand: node, index: index}
+		default:
+			return node, nil
+		}
+	}
+}
+
+func (p *parser) parsePrimary() (Node, error) {
+	tok := p.next()
+	switch tok.kind {
+	case tokNumber:
+		return &literalNode{pos: tok.pos, value: tok.num}, nil
+	case tokString:
+		return &literalNode{pos: tok.pos, value: tok.text}, nil
+	case tokIdent:
+		switch tok.text {
+		case "true":
+			return &literalNode{pos: tok.pos, value: true}, nil
+		case "false":
+			return &literalNode{pos: tok.pos, value: false}, nil
+		case "null":
+			return &literalNode{pos: tok.pos, value: nil}, nil
+		}
+		if p.isOp("(") {
+			p.next()
+			args, err := p.parseArgs(")")
+			if err != nil {
+				return nil, err
+			}
+			return &callNode{pos: tok.pos, fn: tok.text, args: args}, nil
+		}
+		return &identNode{pos: tok.pos, name: tok.text}, nil
+	case tokOp:
+		switch tok.text {
+		case "(":
+			node, err := p.parseCond()
+			if err != nil {
+				return nil, err
+			}
+			return node, p.expect(")")
+		case "[":
+			elems, err := p.parseArgs("]")
+			if err != nil {
+				return nil, err
+			}
+			return &listNode{pos: tok.pos, elems: elems}, nil
+		}
+	case tokEOF:
+		return nil, p.errorf(tok, "unexpected end of expression")
+	}
+	return nil, p.errorf(tok, "unexpected %q", tok.text)
+}
+
+func (p *parser) parseArgs(closing string) ([]Node, error) {
+	var args []Node
+	if p.isOp(closing) {
+		p.next()
+		return args, nil
+	}
+	for {
+		arg, err := p.parseCond()
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, arg)
+		if p.isOp(",") {
+			p.next()
+			continue
+		}
+		return args, p.expect(closing)
+	}
+}
+
+// Error is a syntax or type error with its position in the source
+type Error struct {
+	Kind    error
+	Line    int
+	Column  int
+	Message string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%d:%d: %s", e.Line, e.Column, e.Message)
+}
+
+// Unwrap lets errors.Is match ErrSyntax and ErrType
+func (e *Error) Unwrap() error { return e.Kind }
+
+func newError(src string, pos int, kind error, format string, args ...interface{}) *Error {
+	line, col := 1, 1
+	for i := 0; i < pos && i < len(src); i++ {
+		if src[i] == '\n' {
+			line++
+			col = 1
+		} else {
+			col++
+		}
+	}
+	return &Error{Kind: kind, Line: line, Column: col, Message: fmt.Sprintf(format, args...)}
+}
@@ -0,0 +1,122 @@
+package expr
+
+import (
+	"encoding/json"
+	"math"
+	"time"
+
+	"github.com/sambitmohanty1/payment-watchdog/api/internal/models"
+)
+
+var customerType = ObjectOf(map[string]*Type{
+	"id":       String,
+	"email":    String,
+	"name":     String,
+	"phone":    String,
+	"segments": ListOf(String),
+})
+
+var invoiceType = ObjectOf(map[string]*Type{
+	"number":       String,
+	"due_date":     Timestamp,
+	"days_overdue": Number,
+	"amount":       Number,
+	"amount_cents": Number,
+	"currency":     String,
+})
+
+// PaymentFailureEnv declares the variables available to conditions evaluated
+// against a payment failure: rule definitions, workflow triggers and
+// conditional workflow steps
+func PaymentFailureEnv() *Env {
+	return NewEnv(map[string]*Type{
+		"amount":          Number,
+		"amount_cents":    Number,
+		"currency":        String,
+		"provider":        String,
+		"event_type":      String,
+		"failure_reason":  String,
+		"failure_code":    String,
+		"failure_message": String,
+		"status":          String,
+		"retry_count":     Number,
+		"tags":            ListOf(String),
+		"due_date":        Timestamp,
+		"days_overdue":    Number,
+		"created_at":      Timestamp,
+		"now":             Timestamp,
+		"customer":        customerType,
+		"invoice":         invoiceType,
+		"metadata":        MapOf(Dyn),
+	})
+}
+
+// PaymentFailureActivation builds the variables declared by PaymentFailureEnv
+// for event, with now as the evaluation time
+func PaymentFailureActivation(event *models.PaymentFailureEvent, now time.Time) map[string]interface{} {
+	amount := float64(event.AmountCents) / 100
+
+	var dueDate interface{}
+	daysOverdue := float64(0)
+	if event.DueDate != nil {
+		dueDate = *event.DueDate
+		if now.After(*event.DueDate) {
+			daysOverdue = math.Floor(now.Sub(*event.DueDate).Hours() / 24)
+		}
+	}
+
+	provider := event.ProviderID
+	if provider == "" {
+		provider = event.Provider
+	}
+
+	tags := make([]interface{}, len(event.Tags))
+	for i, tag := range event.Tags {
+		tags[i] = tag
+	}
+	segments := make([]interface{}, len(event.CustomerSegments))
+	for i, segment := range event.CustomerSegments {
+		segments[i] = segment
+	}
+
+	// Metadata is the provider-normalised payload; an unparseable payload is
+	// treated as empty so that conditions on it simply do not match
+	metadata := map[string]interface{}{}
+	if event.NormalizedData != "" {
+		_ = json.Unmarshal([]byte(event.NormalizedData), &metadata)
+	}
+
+	return map[string]interface{}{
+		"amount":          amount,
+		"amount_cents":    float64(event.AmountCents),
+		"currency":        event.Currency,
+		"provider":        provider,
+		"event_type":      event.EventType,
+		"failure_reason":  event.FailureReason,
+		"failure_code":    event.FailureCode,
+		"failure_message": event.FailureMessage,
+		"status":          event.Status,
+		"retry_count":     float64(event.RetryCount),
+		"tags":            tags,
+		"due_date":        dueDate,
+		"days_overdue":    daysOverdue,
+		"created_at":      event.CreatedAt,
+		"now":             now,
+		"customer": map[string]interface{}{
+			"id":       event.CustomerID,
+			"email":    event.CustomerEmail,
+			"name":     event.CustomerName,
+			"phone":    event.CustomerPhone,
+			"segments": segments,
+		},
+		"invoice": map[string]interface{}{
+			"number":       event.InvoiceNumber,
+			"due_date":     dueDate,
+			"days_overdue": daysOverdue,
+			"amount":       amount,
+			"amount_cents": float64(event.AmountCents),
+			"currency":     event.Currency,
+		},
+		"metadata": metadata,
+	}
+}
@@ -0,0 +1,38 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/datatypes"
+)
+
+// Automation decision subjects and modes
+const (
+	DecisionSubjectRule     = "rule"
+	DecisionSubjectWorkflow = "workflow"
+
+	DecisionModeLive   = "live"
+	DecisionModeShadow = "shadow"
+)
+
+// AutomationDecision records what a rule or workflow decided for a payment
+// failure. Shadow decisions describe what would have happened; their actions
+// were never executed.
+type AutomationDecision struct {
+	ID               uuid.UUID      `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	CompanyID        string         `json:"company_id" gorm:"not null;index:idx_automation_decisions_company_created"`
+	PaymentFailureID uuid.UUID      `json:"payment_failure_id" gorm:"type:uuid;not null;index"`
+	SubjectType      string         `json:"subject_type" gorm:"size:20;not null;index:idx_automation_decisions_company_created"` // rule, workflow
+	SubjectID        string         `json:"subject_id,omitempty"`
+	SubjectName      string         `json:"subject_name" gorm:"not null"`
+	Mode             string         `json:"mode" gorm:"size:10;not null"` // live, shadow
+	Matched          bool           `json:"matched"`
+	Actions          datatypes.JSON `json:"actions,omitempty" gorm:"type:jsonb"` // action or step types, in order
+	Details          datatypes.JSON `json:"details,omitempty" gorm:"type:jsonb"`
+	RuleSetVersion   *int           `json:"rule_set_version,omitempty"` // published rule set version a company rule was evaluated from
+	CreatedAt        time.Time      `json:"created_at" gorm:"autoCreateTime;index:idx_automation_decisions_company_created"`
+}
+
+// TableName specifies the table name for GORM
+func (d *AutomationDecision) TableName() string { return "automation_decisions" }
@@ -0,0 +1,44 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// BusinessHours is a company's opening hours on one weekday. A weekday may have
+// several entries, for example to close over lunch.
+type BusinessHours struct {
+	Weekday int    `json:"weekday"` // 0 = Sunday
+	Opens   string `json:"opens"`   // HH:MM local time
+	Closes  string `json:"closes"`  // HH:MM local time, exclusive; 24:00 for midnight
+}
+
+// HolidayCalendar is a set of holidays imported from an iCalendar (.ics) file.
+// Re-importing a calendar with the same name replaces its holidays.
+type HolidayCalendar struct {
+	ID           uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	CompanyID    string    `json:"company_id" gorm:"not null;uniqueIndex:idx_holiday_calendars_company_name"`
+	Name         string    `json:"name" gorm:"not null;uniqueIndex:idx_holiday_calendars_company_name"`
+	Source       string    `json:"source,omitempty"` // uploaded file name
+	HolidayCount int       `json:"holiday_count"`
+	ImportedBy   string    `json:"imported_by,omitempty"`
+	CreatedAt    time.Time `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt    time.Time `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+// CompanyHoliday is one date on which a company is closed
+type CompanyHoliday struct {
+	ID         uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	CompanyID  string    `json:"company_id" gorm:"not null;index:idx_company_holidays_company_date"`
+	CalendarID uuid.UUID `json:"calendar_id" gorm:"type:uuid;not null;index"`
+	Date       time.Time `json:"date" gorm:"type:date;not null;index:idx_company_holidays_company_date"`
+	Name       string    `json:"name"`
+	UID        string    `json:"uid,omitempty"` // iCalendar UID of the source event
+}
+
+// TableName specifies the table name for GORM
+func (h *HolidayCalendar) TableName() string { return "holiday_calendars" }
+
+// TableName specifies the table name for GORM
+func (h *CompanyHoliday) TableName() string { return "company_holidays" }
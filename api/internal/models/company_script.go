@@ -0,0 +1,33 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/datatypes"
+)
+
+// CompanyScript is a sandboxed script a company runs as an enterprise rule. It
+// defines evaluate(failure, ctx) and optionally actions(failure, ctx); Tables is
+// JSON data, such as risk thresholds, the script reads through the tables global.
+type CompanyScript struct {
+	ID          uuid.UUID      `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	CompanyID   string         `json:"company_id" gorm:"not null;uniqueIndex:idx_company_scripts_company_name"`
+	Name        string         `json:"name" gorm:"not null;uniqueIndex:idx_company_scripts_company_name"`
+	Description string         `json:"description,omitempty" gorm:"type:text"`
+	Source      string         `json:"source" gorm:"type:text;not null"`
+	Tables      datatypes.JSON `json:"tables,omitempty" gorm:"type:jsonb"`
+	Priority    int            `json:"priority"`
+	Enabled     bool           `json:"enabled" gorm:"default:true"`
+	Shadow      bool           `json:"shadow" gorm:"default:false"`
+	MaxSteps    int64          `json:"max_steps,omitempty"`  // 0 uses the default step limit
+	TimeoutMs   int            `json:"timeout_ms,omitempty"` // 0 uses the default time limit
+	Version     int            `json:"version" gorm:"not null;default:1"`
+	CreatedBy   string         `json:"created_by,omitempty"`
+	UpdatedBy   string         `json:"updated_by,omitempty"`
+	CreatedAt   time.Time      `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt   time.Time      `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+// TableName specifies the table name for GORM
+func (s *CompanyScript) TableName() string { return "company_scripts" }
@@ -0,0 +1,35 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// CustomerCommunication records an email or SMS sent to a customer
+type CustomerCommunication struct {
+	ID                uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	PaymentFailureID  *uuid.UUID `json:"payment_failure_id,omitempty" gorm:"type:uuid;index"`
+	CompanyID         string     `json:"company_id" gorm:"not null;index"`
+	Channel           string     `json:"channel"`
+	CommunicationType string     `json:"communication_type" gorm:"index"` // email, sms
+	Recipient         string     `json:"recipient"`
+	TemplateID        string     `json:"template_id"`
+	Subject           string     `json:"subject"`
+	Content           string     `json:"content" gorm:"type:text"`
+	Status            string     `json:"status" gorm:"default:'pending'"`
+	ExternalID        string     `json:"external_id"`
+	IdempotencyKey    string     `json:"idempotency_key,omitempty"` // sender's key; a repeated send returns this record
+
+	Metadata map[string]interface{} `json:"metadata,omitempty" gorm:"type:jsonb;serializer:json"`
+
+	SentAt      *time.Time `json:"sent_at,omitempty"`
+	DeliveredAt *time.Time `json:"delivered_at,omitempty"`
+	OpenedAt    *time.Time `json:"opened_at,omitempty"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// TableName specifies the table name for GORM
+func (c *CustomerCommunication) TableName() string { return "customer_communications" }
@@ -0,0 +1,106 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// CustomerIdentity is the stable internal identity for a customer that may
+// exist under different IDs across payment and accounting providers
+// (Stripe, Xero, QuickBooks, ...).
+type CustomerIdentity struct {
+	ID             uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	CompanyID      string     `json:"company_id" gorm:"not null;index"`
+	DisplayName    string     `json:"display_name"`
+	PrimaryEmail   string     `json:"primary_email"`
+	PrimaryPhone   string     `json:"primary_phone"`
+	BusinessNumber string     `json:"business_number"`
+	Status         string     `json:"status" gorm:"default:'active'"` // active, merged
+	MergedIntoID   *uuid.UUID `json:"merged_into_id,omitempty" gorm:"type:uuid"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	// Relationships
+	Links []CustomerIdentityLink `json:"links,omitempty" gorm:"foreignKey:IdentityID"`
+}
+
+// CustomerIdentityLink ties a provider-specific customer record to a CustomerIdentity
+type CustomerIdentityLink struct {
+	ID                 uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	IdentityID         uuid.UUID `json:"identity_id" gorm:"type:uuid;not null;index"`
+	CompanyID          string    `json:"company_id" gorm:"not null;index"`
+	Provider           string    `json:"provider" gorm:"not null"` // stripe, xero, quickbooks
+	ProviderCustomerID string    `json:"provider_customer_id" gorm:"not null"`
+
+	// Normalized match keys
+	Email          string `json:"email"`
+	Phone          string `json:"phone"`
+	BusinessNumber string `json:"business_number"`
+	Name           string `json:"name"`
+
+	MatchReason string  `json:"match_reason"` // new, email, phone, business_number, name, manual
+	Confidence  float64 `json:"confidence"`
+	IsManual    bool    `json:"is_manual" gorm:"default:false"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// CustomerIdentitySuggestion proposes that a provider customer record belongs to
+// an identity it shares only a similar name with. Names alone are not enough to
+// link customers, so someone accepts or dismisses each suggestion.
+type CustomerIdentitySuggestion struct {
+	ID         uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	CompanyID  string    `json:"company_id" gorm:"not null;index"`
+	LinkID     uuid.UUID `json:"link_id" gorm:"type:uuid;not null"`     // the record resolved on its own
+	IdentityID uuid.UUID `json:"identity_id" gorm:"type:uuid;not null"` // the identity it may belong to
+	Reason     string    `json:"reason"`                                // name
+	Score      float64   `json:"score"`
+	Status     string    `json:"status" gorm:"default:'pending'"` // pending, accepted, dismissed
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Customer identity statuses
+const (
+	CustomerIdentityStatusActive = "active"
+	CustomerIdentityStatusMerged = "merged"
+)
+
+// Customer identity suggestion statuses
+const (
+	CustomerIdentitySuggestionPending   = "pending"
+	CustomerIdentitySuggestionAccepted  = "accepted"
+	CustomerIdentitySuggestionDismissed = "dismissed"
+)
+
+// Table names
+func (c *CustomerIdentity) TableName() string           { return "customer_identities" }
+func (c *CustomerIdentityLink) TableName() string       { return "customer_identity_links" }
+func (c *CustomerIdentitySuggestion) TableName() string { return "customer_identity_suggestions" }
+
+// Hooks
+func (c *CustomerIdentity) BeforeCreate(tx *gorm.DB) error {
+	if c.ID == uuid.Nil {
+		c.ID = uuid.New()
+	}
+	return nil
+}
+
+func (c *CustomerIdentityLink) BeforeCreate(tx *gorm.DB) error {
+	if c.ID == uuid.Nil {
+		c.ID = uuid.New()
+	}
+	return nil
+}
+
+func (c *CustomerIdentitySuggestion) BeforeCreate(tx *gorm.DB) error {
+	if c.ID == uuid.Nil {
+		c.ID = uuid.New()
+	}
+	return nil
+}
@@ -0,0 +1,81 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/datatypes"
+)
+
+// Built-in customer segments. Companies may redefine their criteria or add
+// segments of their own.
+const (
+	CustomerSegmentVIP       = "vip"
+	CustomerSegmentRecurring = "recurring"
+	CustomerSegmentNew       = "new"
+	CustomerSegmentHardship  = "hardship"
+)
+
+// CustomerSegment is a company's definition of a customer segment. Criteria is a
+// JSON SegmentCriteria over the customer's payment history and manual tags.
+type CustomerSegment struct {
+	ID          uuid.UUID      `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	CompanyID   string         `json:"company_id" gorm:"not null;uniqueIndex:idx_customer_segments_company_key"`
+	Key         string         `json:"key" gorm:"not null;uniqueIndex:idx_customer_segments_company_key"`
+	Name        string         `json:"name" gorm:"not null"`
+	Description string         `json:"description,omitempty" gorm:"type:text"`
+	Criteria    datatypes.JSON `json:"criteria" gorm:"type:jsonb;not null"`
+	Enabled     bool           `json:"enabled" gorm:"default:true"`
+	CreatedBy   string         `json:"created_by,omitempty"`
+	UpdatedBy   string         `json:"updated_by,omitempty"`
+	CreatedAt   time.Time      `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt   time.Time      `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+// CustomerSegmentProfile is the payment history summary of one customer from
+// which segments are computed. It is updated incrementally as events arrive.
+type CustomerSegmentProfile struct {
+	ID         uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	CompanyID  string    `json:"company_id" gorm:"not null;uniqueIndex:idx_customer_segment_profiles_customer"`
+	CustomerID string    `json:"customer_id" gorm:"not null;uniqueIndex:idx_customer_segment_profiles_customer"`
+
+	FirstSeenAt time.Time `json:"first_seen_at"`
+	LastEventAt time.Time `json:"last_event_at"`
+
+	FailureCount      int64 `json:"failure_count"`
+	FailedAmountCents int64 `json:"failed_amount_cents"`
+	// LifetimeValueCents is the amount collected from the customer: recovered
+	// failures, or the provider's own figure when it reports a larger one
+	LifetimeValueCents int64 `json:"lifetime_value_cents"`
+
+	SubscriptionID     string `json:"subscription_id,omitempty"`
+	SubscriptionStatus string `json:"subscription_status,omitempty"`
+
+	// RecentEvents are the latest events, newest last, used for failure
+	// frequency and to apply each event only once
+	RecentEvents []CustomerSegmentEvent `json:"recent_events,omitempty" gorm:"type:jsonb;serializer:json"`
+
+	// ManualTags are set by users, e.g. hardship, and never change on recompute
+	ManualTags []string `json:"manual_tags,omitempty" gorm:"type:jsonb;serializer:json"`
+	Segments   []string `json:"segments" gorm:"type:jsonb;serializer:json"`
+
+	SegmentsChangedAt *time.Time `json:"segments_changed_at,omitempty"`
+	CreatedAt         time.Time  `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt         time.Time  `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+// CustomerSegmentEvent is one payment event in a segment profile's history
+type CustomerSegmentEvent struct {
+	EventID     string    `json:"event_id"`
+	At          time.Time `json:"at"`
+	Reason      string    `json:"reason,omitempty"`
+	AmountCents int64     `json:"amount_cents"`
+	Failure     bool      `json:"failure"`             // recorded as a failed payment
+	Recovered   bool      `json:"recovered,omitempty"` // collected, counting towards lifetime value
+}
+
+// TableName specifies the table name for GORM
+func (s *CustomerSegment) TableName() string { return "customer_segments" }
+
+// TableName specifies the table name for GORM
+func (p *CustomerSegmentProfile) TableName() string { return "customer_segment_profiles" }
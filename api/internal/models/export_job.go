@@ -0,0 +1,49 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/datatypes"
+)
+
+// ExportJob tracks an asynchronous data export and its downloadable result
+type ExportJob struct {
+	ID        uuid.UUID      `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	CompanyID string         `json:"company_id" gorm:"not null;index"`
+	Dataset   string         `json:"dataset" gorm:"size:50;not null"` // payment_failures, recovery_actions, workflow_executions, communications
+	Format    string         `json:"format" gorm:"size:10;not null"`  // csv, jsonl, xlsx
+	Filter    datatypes.JSON `json:"filter,omitempty" gorm:"type:jsonb"`
+
+	// Progress
+	Status        string    `json:"status" gorm:"size:50;not null;index"` // pending, running, completed, failed, expired
+	TotalRows     int64     `json:"total_rows"`
+	ProcessedRows int64     `json:"processed_rows"`
+	ErrorMessage  string    `json:"error_message,omitempty" gorm:"type:text"`
+	Attempts      int       `json:"attempts"`
+	AvailableAt   time.Time `json:"-" gorm:"not null;index"` // when a worker may next claim the job
+
+	// Result, stored as ExportFileChunks
+	FileSize       int64      `json:"file_size"`
+	DownloadToken  string     `json:"download_token,omitempty" gorm:"size:64;uniqueIndex"`
+	TokenExpiresAt *time.Time `json:"token_expires_at,omitempty"`
+
+	StartedAt   *time.Time `json:"started_at,omitempty"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+	CreatedAt   time.Time  `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt   time.Time  `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+// TableName specifies the table name for GORM
+func (e *ExportJob) TableName() string { return "export_jobs" }
+
+// ExportFileChunk is one piece of a completed export's file. Files are kept in
+// Postgres so that any replica can serve the download.
+type ExportFileChunk struct {
+	ExportJobID uuid.UUID `gorm:"type:uuid;primaryKey"`
+	Seq         int       `gorm:"primaryKey"`
+	Data        []byte    `gorm:"type:bytea;not null"`
+}
+
+// TableName specifies the table name for GORM
+func (c *ExportFileChunk) TableName() string { return "export_file_chunks" }
@@ -0,0 +1,36 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Outbox event statuses
+const (
+	OutboxEventPending   = "pending"
+	OutboxEventPublished = "published"
+	OutboxEventFailed    = "failed"
+)
+
+// OutboxEvent is an event committed in the same transaction as the change it
+// announces and published to the event bus afterwards by the outbox relay, so
+// a change is never committed without its event
+type OutboxEvent struct {
+	ID          uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	CompanyID   string     `json:"company_id" gorm:"not null"`
+	Topic       string     `json:"topic" gorm:"size:255;not null"`
+	Payload     string     `json:"payload" gorm:"type:jsonb;not null"`
+	Status      string     `json:"status" gorm:"size:50;not null;default:'pending'"`
+	AvailableAt time.Time  `json:"available_at" gorm:"not null"`
+	Attempts    int        `json:"attempts" gorm:"default:0"`
+	LastError   string     `json:"last_error,omitempty" gorm:"type:text"`
+	PublishedAt *time.Time `json:"published_at,omitempty"`
+	CreatedAt   time.Time  `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt   time.Time  `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+// TableName specifies the table name for GORM
+func (OutboxEvent) TableName() string {
+	return "outbox_events"
+}
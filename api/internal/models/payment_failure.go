@@ -30,6 +30,13 @@ type PaymentFailureEvent struct {
 	Provider        string     `json:"provider"`
 	RetryCount      int        `json:"retry_count" gorm:"default:0"`
 	DueDate         *time.Time `json:"due_date,omitempty"`
+	InvoiceNumber   string     `json:"invoice_number,omitempty"`
+	Tags            []string   `json:"tags,omitempty" gorm:"type:jsonb;serializer:json"`
+	RiskScore       *float64   `json:"risk_score,omitempty"` // 0-100, set by company rules
+
+	// CustomerSegments are the customer's segments, such as vip or hardship, when
+	// the event is processed. They are resolved per event and not stored.
+	CustomerSegments []string `json:"customer_segments,omitempty" gorm:"-"`
 
 	// Failure Information
 	FailureReason  string `json:"failure_reason"` // card_declined, etc.
@@ -68,6 +75,14 @@ type Company struct {
 	Domain string    `json:"domain"`
 	Status string    `json:"status" gorm:"default:'active'"`
 
+	// Timezone is an IANA zone name used for schedules and business hours
+	Timezone string `json:"timezone" gorm:"default:'UTC'"`
+	// BusinessHours are the opening hours per weekday; empty means Monday to Friday 09:00-17:00
+	BusinessHours []BusinessHours `json:"business_hours,omitempty" gorm:"type:jsonb;serializer:json"`
+	// MaxConcurrentExecutions caps the workflow executions the company runs at
+	// once across all workers; 0 uses the service default
+	MaxConcurrentExecutions int `json:"max_concurrent_executions" gorm:"default:0"`
+
 	// Configuration
 	StripeAccountID string                 `json:"stripe_account_id"`
 	AlertSettings   map[string]interface{} `json:"alert_settings" gorm:"type:jsonb"`
@@ -15,11 +15,16 @@ type RecoveryWorkflow struct {
 	Description string         `json:"description" gorm:"type:text"`
 	IsActive    bool           `json:"is_active" gorm:"default:true;index"`
 	Priority    int            `json:"priority" gorm:"default:1;index"` // Higher number = higher priority
+	Shadow      bool           `json:"shadow" gorm:"default:false"`     // Evaluated and logged but never started
+
+	// CurrentVersion is the version new executions start on. Steps and trigger
+	// conditions only change by publishing a new version.
+	CurrentVersion int `json:"current_version" gorm:"default:1"`
 	
 	// Trigger conditions
 	TriggerConditions datatypes.JSON `json:"trigger_conditions" gorm:"type:jsonb"` // JSON conditions for when to trigger
 	
-	// Workflow steps
+	// Workflow steps of the current version
 	Steps []RecoveryWorkflowStep `json:"steps" gorm:"foreignKey:WorkflowID;constraint:OnDelete:CASCADE"`
 	
 	// Execution tracking
@@ -38,6 +43,7 @@ type RecoveryWorkflow struct {
 type RecoveryWorkflowStep struct {
 	ID         uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
 	WorkflowID uuid.UUID `json:"workflow_id" gorm:"type:uuid;not null;index"`
+	Version    int       `json:"version" gorm:"default:1"` // workflow version the step belongs to
 	StepOrder  int       `json:"step_order" gorm:"not null;index"`
 	
 	// Step configuration
@@ -50,8 +56,18 @@ type RecoveryWorkflowStep struct {
 	Conditions datatypes.JSON `json:"conditions,omitempty" gorm:"type:jsonb"` // Conditions for step execution
 	
 	// Timing
-	DelayMinutes int  `json:"delay_minutes" gorm:"default:0"` // Delay before executing this step
-	IsParallel   bool `json:"is_parallel" gorm:"default:false"` // Can run in parallel with next step
+	DelayMinutes   int  `json:"delay_minutes" gorm:"default:0"` // Delay before executing this step
+	IsParallel     bool `json:"is_parallel" gorm:"default:false"` // Can run in parallel with next step
+	TimeoutSeconds int  `json:"timeout_seconds,omitempty" gorm:"default:0"` // Outcome is timeout when the step runs longer
+
+	// Graph: successors per outcome, referenced by key. Workflows that declare no
+	// successors run their steps in StepOrder.
+	Key  string          `json:"key,omitempty" gorm:"column:step_key;size:100"`
+	Next StepTransitions `json:"next,omitempty" gorm:"type:jsonb;serializer:json"`
+	Join string          `json:"join,omitempty" gorm:"column:join_mode;size:10"` // all (default) or any
+
+	// RetryPolicy controls how a failed step is attempted again; nil uses the default policy
+	RetryPolicy *StepRetryPolicy `json:"retry_policy,omitempty" gorm:"type:jsonb;serializer:json"`
 	
 	// Status
 	IsActive   bool `json:"is_active" gorm:"default:true"`
@@ -66,22 +82,90 @@ type RecoveryWorkflowStep struct {
 	Executions []RecoveryStepExecution     `json:"executions,omitempty" gorm:"foreignKey:StepID;constraint:OnDelete:CASCADE"`
 }
 
+// Step outcomes, which select the successors a step hands over to
+const (
+	StepOutcomeSuccess = "success"
+	StepOutcomeFailure = "failure"
+	StepOutcomeTrue    = "true"
+	StepOutcomeFalse   = "false"
+	StepOutcomeTimeout = "timeout"
+	// StepOutcomeSkipped marks a step no taken branch leads to
+	StepOutcomeSkipped = "skipped"
+)
+
+// Join modes for steps with several predecessors
+const (
+	StepJoinAll = "all" // run once every incoming branch has arrived or been skipped
+	StepJoinAny = "any" // run as soon as the first incoming branch arrives
+)
+
+// StepTransitions lists the keys of the steps that run after a step, per
+// outcome. Several keys start parallel branches. A condition outcome without
+// its own list follows OnSuccess; a timeout without one counts as a failure; a
+// failure without one follows OnSuccess unless the step is critical.
+type StepTransitions struct {
+	OnSuccess []string `json:"on_success,omitempty" yaml:"on_success,omitempty"`
+	OnFailure []string `json:"on_failure,omitempty" yaml:"on_failure,omitempty"`
+	OnTrue    []string `json:"on_true,omitempty" yaml:"on_true,omitempty"`
+	OnFalse   []string `json:"on_false,omitempty" yaml:"on_false,omitempty"`
+	OnTimeout []string `json:"on_timeout,omitempty" yaml:"on_timeout,omitempty"`
+}
+
+// IsEmpty reports whether no successor is declared for any outcome
+func (t StepTransitions) IsEmpty() bool {
+	return len(t.OnSuccess) == 0 && len(t.OnFailure) == 0 && len(t.OnTrue) == 0 &&
+		len(t.OnFalse) == 0 && len(t.OnTimeout) == 0
+}
+
+// Step error classes, which retry policies select the failures to retry by
+const (
+	StepErrorRetryable    = "retryable"    // the executor reported a transient failure
+	StepErrorTimeout      = "timeout"      // the attempt ran past the step's timeout
+	StepErrorError        = "error"        // the executor returned an error
+	StepErrorUnsuccessful = "unsuccessful" // the executor reported a failure not worth retrying
+)
+
+// StepRetryPolicy controls how a failed step is attempted again. Backoff grows
+// from InitialDelaySeconds by BackoffMultiplier per attempt up to
+// MaxDelaySeconds, unless the step asks for its own NextDelay, and Jitter
+// randomizes that fraction of each delay. Zero values take the defaults.
+type StepRetryPolicy struct {
+	MaxAttempts         int      `json:"max_attempts,omitempty" yaml:"max_attempts,omitempty"` // including the first; 1 disables retries
+	InitialDelaySeconds int      `json:"initial_delay_seconds,omitempty" yaml:"initial_delay_seconds,omitempty"`
+	MaxDelaySeconds     int      `json:"max_delay_seconds,omitempty" yaml:"max_delay_seconds,omitempty"`
+	BackoffMultiplier   float64  `json:"backoff_multiplier,omitempty" yaml:"backoff_multiplier,omitempty"`
+	Jitter              float64  `json:"jitter,omitempty" yaml:"jitter,omitempty"`     // 0 to 1
+	RetryOn             []string `json:"retry_on,omitempty" yaml:"retry_on,omitempty"` // error classes to retry
+}
+
+// ExecutionPathEntry records one step an execution ran and its outcome
+type ExecutionPathEntry struct {
+	StepID  uuid.UUID `json:"step_id"`
+	StepKey string    `json:"step_key"`
+	Outcome string    `json:"outcome"`
+	At      time.Time `json:"at"`
+}
+
 // RecoveryWorkflowExecution tracks the execution of a workflow for a specific payment failure
 type RecoveryWorkflowExecution struct {
 	ID                uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
 	WorkflowID        uuid.UUID `json:"workflow_id" gorm:"type:uuid;not null;index"`
 	PaymentFailureID  uuid.UUID `json:"payment_failure_id" gorm:"type:uuid;not null;index"`
 	CompanyID         uuid.UUID `json:"company_id" gorm:"type:uuid;not null;index"`
+
+	// WorkflowVersion is the version the execution runs, pinned when it starts
+	WorkflowVersion int `json:"workflow_version" gorm:"default:1"`
 	
 	// Execution status
-	Status        string    `json:"status" gorm:"size:50;not null;index"` // pending, running, completed, failed, paused, cancelled
+	Status        string    `json:"status" gorm:"size:50;not null;index"` // pending, running, waiting, completed, failed, paused, cancelled
 	CurrentStepID *uuid.UUID `json:"current_step_id,omitempty" gorm:"type:uuid;index"`
 	
 	// Timing
 	StartedAt   time.Time  `json:"started_at" gorm:"autoCreateTime"`
 	CompletedAt *time.Time `json:"completed_at,omitempty"`
 	PausedAt    *time.Time `json:"paused_at,omitempty"`
-	
+	WaitingUntil *time.Time `json:"waiting_until,omitempty"` // when a waiting execution resumes
+
 	// Results
 	TotalSteps      int             `json:"total_steps" gorm:"default:0"`
 	CompletedSteps  int             `json:"completed_steps" gorm:"default:0"`
@@ -93,6 +177,17 @@ type RecoveryWorkflowExecution struct {
 	LastError    string         `json:"last_error,omitempty" gorm:"type:text"`
 	RetryCount   int            `json:"retry_count" gorm:"default:0"`
 	NextRetryAt  *time.Time     `json:"next_retry_at,omitempty"`
+
+	// Crash-safe resumption: progress written after each step, and the lease of
+	// the instance running the execution
+	Checkpoint     datatypes.JSON `json:"checkpoint,omitempty" gorm:"type:jsonb"`
+	CheckpointedAt *time.Time     `json:"checkpointed_at,omitempty"`
+	LeaseOwner     *string        `json:"lease_owner,omitempty" gorm:"size:255"`
+	LeaseExpiresAt *time.Time     `json:"lease_expires_at,omitempty"`
+	HeartbeatAt    *time.Time     `json:"heartbeat_at,omitempty"`
+
+	// Path is the steps the execution ran through the workflow graph, in order
+	Path []ExecutionPathEntry `json:"path,omitempty" gorm:"type:jsonb;serializer:json"`
 	
 	// Metadata
 	CreatedAt time.Time `json:"created_at" gorm:"autoCreateTime"`
@@ -106,6 +201,24 @@ type RecoveryWorkflowExecution struct {
 	StepExecutions []RecoveryStepExecution `json:"step_executions,omitempty" gorm:"foreignKey:WorkflowExecutionID;constraint:OnDelete:CASCADE"`
 }
 
+// RecoveryWorkflowVersion is an immutable published version of a workflow: its
+// trigger conditions and, through Steps, the steps with the same version
+type RecoveryWorkflowVersion struct {
+	ID                uuid.UUID      `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	WorkflowID        uuid.UUID      `json:"workflow_id" gorm:"type:uuid;not null;index"`
+	CompanyID         uuid.UUID      `json:"company_id" gorm:"type:uuid;not null;index"`
+	Version           int            `json:"version" gorm:"not null"`
+	Name              string         `json:"name" gorm:"size:255;not null"`
+	Description       string         `json:"description" gorm:"type:text"`
+	TriggerConditions datatypes.JSON `json:"trigger_conditions" gorm:"type:jsonb"`
+	ChangeNote        string         `json:"change_note,omitempty" gorm:"type:text"`
+	CreatedBy         string         `json:"created_by" gorm:"size:255"`
+	CreatedAt         time.Time      `json:"created_at" gorm:"autoCreateTime"`
+
+	// Steps are loaded by workflow and version; they are not a relation
+	Steps []RecoveryWorkflowStep `json:"steps,omitempty" gorm:"-"`
+}
+
 // RecoveryStepExecution tracks the execution of individual workflow steps
 type RecoveryStepExecution struct {
 	ID                  uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
@@ -113,7 +226,7 @@ type RecoveryStepExecution struct {
 	StepID              uuid.UUID `json:"step_id" gorm:"type:uuid;not null;index"`
 	
 	// Execution details
-	Status      string         `json:"status" gorm:"size:50;not null;index"` // pending, running, completed, failed, skipped
+	Status      string         `json:"status" gorm:"size:50;not null;index"` // pending, running, deferred, retrying, completed, failed, exhausted, skipped
 	StartedAt   time.Time      `json:"started_at" gorm:"autoCreateTime"`
 	CompletedAt *time.Time     `json:"completed_at,omitempty"`
 	Duration    int64          `json:"duration_ms,omitempty"` // Duration in milliseconds
@@ -122,6 +235,17 @@ type RecoveryStepExecution struct {
 	Result       datatypes.JSON `json:"result,omitempty" gorm:"type:jsonb"`
 	ErrorMessage string         `json:"error_message,omitempty" gorm:"type:text"`
 	RetryCount   int            `json:"retry_count" gorm:"default:0"`
+
+	// Each attempt at a step has its own record. A failed attempt that will be
+	// retried is retrying until NextAttemptAt; the last one of a step that ran
+	// out of attempts is exhausted.
+	Attempt       int        `json:"attempt" gorm:"default:1"`
+	ErrorClass    string     `json:"error_class,omitempty" gorm:"size:50"`
+	NextAttemptAt *time.Time `json:"next_attempt_at,omitempty"`
+	
+	// IdempotencyKey identifies the step attempt within its execution, so a
+	// resumed step reuses this record instead of running a second time
+	IdempotencyKey string `json:"idempotency_key,omitempty" gorm:"size:255"`
 	
 	// Action tracking
 	ActionType   string         `json:"action_type" gorm:"size:100"` // payment_retry, email_sent, sms_sent, etc.
@@ -184,6 +308,7 @@ type RecoveryAction struct {
 	// Provider information
 	Provider     string `json:"provider" gorm:"size:100;index"` // stripe, xero, quickbooks, etc.
 	ExternalID   string `json:"external_id,omitempty" gorm:"size:255;index"`
+	IdempotencyKey string `json:"idempotency_key,omitempty" gorm:"size:255"` // step that took the action; recorded once
 	
 	// Results
 	Result       datatypes.JSON `json:"result,omitempty" gorm:"type:jsonb"`
@@ -253,6 +378,10 @@ func (RecoveryWorkflowStep) TableName() string {
 	return "recovery_workflow_steps"
 }
 
+func (RecoveryWorkflowVersion) TableName() string {
+	return "recovery_workflow_versions"
+}
+
 func (RecoveryWorkflowExecution) TableName() string {
 	return "recovery_workflow_executions"
 }
@@ -0,0 +1,60 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/datatypes"
+)
+
+// Report delivery statuses
+const (
+	ReportDeliveryStatusSent    = "sent"
+	ReportDeliveryStatusPartial = "partial"
+	ReportDeliveryStatusFailed  = "failed"
+)
+
+// ReportSchedule emails a summary report to a recipient list on a cron schedule
+type ReportSchedule struct {
+	ID             uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	CompanyID      string    `json:"company_id" gorm:"not null;index"`
+	Name           string    `json:"name" gorm:"not null"`
+	CronExpression string    `json:"cron_expression" gorm:"size:100;not null"` // minute hour day-of-month month day-of-week
+	Timezone       string    `json:"timezone,omitempty" gorm:"size:64"`        // overrides the company timezone when set
+	Format         string    `json:"format" gorm:"size:10;not null"`           // pdf, csv
+	Recipients     []string  `json:"recipients" gorm:"type:jsonb;serializer:json"`
+	PeriodDays     int       `json:"period_days" gorm:"default:7"`
+	TopCustomers   int       `json:"top_customers" gorm:"default:10"`
+	IsActive       bool      `json:"is_active" gorm:"default:true"`
+
+	LastRunAt *time.Time `json:"last_run_at,omitempty"`
+	NextRunAt *time.Time `json:"next_run_at,omitempty" gorm:"index"`
+	CreatedBy string     `json:"created_by,omitempty"`
+	CreatedAt time.Time  `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt time.Time  `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+// ReportDelivery records one run of a report schedule
+type ReportDelivery struct {
+	ID               uuid.UUID      `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	ScheduleID       uuid.UUID      `json:"schedule_id" gorm:"type:uuid;not null;index"`
+	CompanyID        string         `json:"company_id" gorm:"not null"`
+	Status           string         `json:"status" gorm:"size:50;not null"` // sent, partial, failed
+	Format           string         `json:"format" gorm:"size:10;not null"`
+	Recipients       []string       `json:"recipients" gorm:"type:jsonb;serializer:json"`
+	FailedRecipients []string       `json:"failed_recipients,omitempty" gorm:"type:jsonb;serializer:json"`
+	MessageIDs       []string       `json:"message_ids,omitempty" gorm:"type:jsonb;serializer:json"`
+	ScheduledFor     *time.Time     `json:"scheduled_for,omitempty"`
+	PeriodStart      time.Time      `json:"period_start"`
+	PeriodEnd        time.Time      `json:"period_end"`
+	Summary          datatypes.JSON `json:"summary,omitempty" gorm:"type:jsonb"`
+	ErrorMessage     string         `json:"error_message,omitempty" gorm:"type:text"`
+	SentAt           *time.Time     `json:"sent_at,omitempty"`
+	CreatedAt        time.Time      `json:"created_at" gorm:"autoCreateTime"`
+}
+
+// TableName specifies the table name for GORM
+func (r *ReportSchedule) TableName() string { return "report_schedules" }
+
+// TableName specifies the table name for GORM
+func (r *ReportDelivery) TableName() string { return "report_deliveries" }
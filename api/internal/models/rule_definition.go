@@ -0,0 +1,30 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/datatypes"
+)
+
+// RuleDefinitionRecord stores a company's declarative rule. Definition holds the
+// normalised JSON form; Source keeps the document as submitted.
+type RuleDefinitionRecord struct {
+	ID           uuid.UUID      `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	CompanyID    string         `json:"company_id" gorm:"not null;uniqueIndex:idx_rule_definitions_company_name"`
+	Name         string         `json:"name" gorm:"not null;uniqueIndex:idx_rule_definitions_company_name"`
+	Description  string         `json:"description,omitempty" gorm:"type:text"`
+	Priority     int            `json:"priority"`
+	Enabled      bool           `json:"enabled" gorm:"default:true"`
+	Shadow       bool           `json:"shadow" gorm:"default:false"`
+	SourceFormat string         `json:"source_format" gorm:"size:10;default:'json'"` // json, yaml
+	Source       string         `json:"source,omitempty" gorm:"type:text"`
+	Definition   datatypes.JSON `json:"definition" gorm:"type:jsonb;not null"`
+	CreatedBy    string         `json:"created_by,omitempty"`
+	UpdatedBy    string         `json:"updated_by,omitempty"`
+	CreatedAt    time.Time      `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt    time.Time      `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+// TableName specifies the table name for GORM
+func (r *RuleDefinitionRecord) TableName() string { return "rule_definitions" }
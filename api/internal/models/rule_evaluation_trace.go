@@ -0,0 +1,57 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/datatypes"
+)
+
+// Rule engines that produce evaluation traces
+const (
+	RuleTraceEngineEnterprise = "enterprise"
+	RuleTraceEngineBasic      = "basic"
+)
+
+// RuleEvaluationTrace records how one rule was evaluated for a payment failure:
+// the outcome and observed value of each condition and the result of each action
+type RuleEvaluationTrace struct {
+	ID               uuid.UUID      `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	CompanyID        string         `json:"company_id" gorm:"not null;index:idx_rule_evaluation_traces_company_failure"`
+	PaymentFailureID uuid.UUID      `json:"payment_failure_id" gorm:"type:uuid;not null;index:idx_rule_evaluation_traces_company_failure"`
+	Engine           string         `json:"engine" gorm:"size:20;not null"` // enterprise, basic
+	RuleID           string         `json:"rule_id,omitempty"`
+	RuleName         string         `json:"rule_name" gorm:"not null"`
+	Triggered        bool           `json:"triggered"`
+	Shadow           bool           `json:"shadow"`
+	Conditions       datatypes.JSON `json:"conditions,omitempty" gorm:"type:jsonb"` // []RuleTraceCondition
+	Actions          datatypes.JSON `json:"actions,omitempty" gorm:"type:jsonb"`    // []RuleTraceAction
+	ErrorMessage     string         `json:"error_message,omitempty" gorm:"type:text"`
+	DurationMicros   int64          `json:"duration_micros"`
+	RuleSetVersion   *int           `json:"rule_set_version,omitempty"` // published rule set version a company rule was evaluated from
+	EvaluatedAt      time.Time      `json:"evaluated_at" gorm:"not null"`
+	CreatedAt        time.Time      `json:"created_at" gorm:"autoCreateTime"`
+}
+
+// RuleTraceCondition is the traced evaluation of one rule condition
+type RuleTraceCondition struct {
+	Type           string      `json:"type"`
+	Description    string      `json:"description"`
+	Met            bool        `json:"met"`
+	Value          interface{} `json:"value,omitempty"` // the value the condition observed, when known
+	Error          string      `json:"error,omitempty"` // why the condition could not be evaluated
+	DurationMicros int64       `json:"duration_micros"`
+}
+
+// RuleTraceAction is the traced result of one rule action
+type RuleTraceAction struct {
+	Type           string                 `json:"type"`
+	Description    string                 `json:"description,omitempty"`
+	Executed       bool                   `json:"executed"`
+	Error          string                 `json:"error,omitempty"`
+	Output         map[string]interface{} `json:"output,omitempty"`
+	DurationMicros int64                  `json:"duration_micros"`
+}
+
+// TableName specifies the table name for GORM
+func (t *RuleEvaluationTrace) TableName() string { return "rule_evaluation_traces" }
@@ -0,0 +1,51 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/datatypes"
+)
+
+// Rule set version statuses
+const (
+	RuleSetVersionDraft      = "draft"
+	RuleSetVersionPublished  = "published"
+	RuleSetVersionSuperseded = "superseded"
+)
+
+// RuleSetVersion is an immutable snapshot of a company's rule definitions. Every
+// change to the definitions records a draft version; only a published version is
+// evaluated against payment failures.
+type RuleSetVersion struct {
+	ID           uuid.UUID      `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	CompanyID    string         `json:"company_id" gorm:"not null;uniqueIndex:idx_rule_set_versions_company_version"`
+	Version      int            `json:"version" gorm:"not null;uniqueIndex:idx_rule_set_versions_company_version"`
+	Status       string         `json:"status" gorm:"size:20;not null;default:'draft'"` // draft, published, superseded
+	Author       string         `json:"author,omitempty"`
+	Message      string         `json:"message,omitempty" gorm:"type:text"`
+	BaseVersion  *int           `json:"base_version,omitempty"`                     // the version Diff is relative to
+	RestoredFrom *int           `json:"restored_from,omitempty"`                    // set on versions created by a rollback
+	Rules        datatypes.JSON `json:"rules,omitempty" gorm:"type:jsonb;not null"` // []RuleDefinitionRecord
+	Diff         datatypes.JSON `json:"diff,omitempty" gorm:"type:jsonb"`           // RuleSetDiff
+	Analysis     datatypes.JSON `json:"analysis,omitempty" gorm:"type:jsonb"`       // static rules.RuleSetAnalysis of the snapshot
+	PublishedBy  string         `json:"published_by,omitempty"`
+	PublishedAt  *time.Time     `json:"published_at,omitempty"`
+	CreatedAt    time.Time      `json:"created_at" gorm:"autoCreateTime"`
+}
+
+// RuleSetDiff lists the rules added, removed and changed between two versions
+type RuleSetDiff struct {
+	Added   []string        `json:"added"`
+	Removed []string        `json:"removed"`
+	Changed []RuleSetChange `json:"changed"`
+}
+
+// RuleSetChange names the fields of a rule that differ between two versions
+type RuleSetChange struct {
+	Name   string   `json:"name"`
+	Fields []string `json:"fields"`
+}
+
+// TableName specifies the table name for GORM
+func (v *RuleSetVersion) TableName() string { return "rule_set_versions" }
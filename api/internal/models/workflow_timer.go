@@ -0,0 +1,46 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Workflow timer kinds. A wait timer resumes the execution after its step; the
+// others run the step itself, with the delay, send time or retry backoff they
+// record served.
+const (
+	WorkflowTimerWait      = "wait"
+	WorkflowTimerStepDelay = "step_delay"
+	WorkflowTimerSendTime  = "send_time"
+	WorkflowTimerStepRetry = "step_retry"
+)
+
+// Workflow timer statuses
+const (
+	WorkflowTimerPending   = "pending"
+	WorkflowTimerFired     = "fired"
+	WorkflowTimerCancelled = "cancelled"
+	WorkflowTimerFailed    = "failed"
+)
+
+// WorkflowTimer is a persisted point at which a waiting workflow execution resumes
+type WorkflowTimer struct {
+	ID                  uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	CompanyID           uuid.UUID  `json:"company_id" gorm:"type:uuid;not null"`
+	WorkflowExecutionID uuid.UUID  `json:"workflow_execution_id" gorm:"type:uuid;not null;index"`
+	StepID              uuid.UUID  `json:"step_id" gorm:"type:uuid;not null"`
+	Kind                string     `json:"kind" gorm:"size:50;not null"`
+	FireAt              time.Time  `json:"fire_at" gorm:"not null"`
+	Status              string     `json:"status" gorm:"size:50;not null;default:'pending'"`
+	Attempts            int        `json:"attempts" gorm:"default:0"`
+	LastError           string     `json:"last_error,omitempty" gorm:"type:text"`
+	FiredAt             *time.Time `json:"fired_at,omitempty"`
+	CreatedAt           time.Time  `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt           time.Time  `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+// TableName specifies the table name for GORM
+func (WorkflowTimer) TableName() string {
+	return "workflow_timers"
+}
@@ -0,0 +1,262 @@
+package rules
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/sambitmohanty1/payment-watchdog/api/internal/models"
+)
+
+// Action parameter types
+const (
+	ParamTypeString     = "string"
+	ParamTypeNumber     = "number"
+	ParamTypeBool       = "bool"
+	ParamTypeStringList = "string_list"
+)
+
+// ActionParam describes one parameter of a catalogue action
+type ActionParam struct {
+	Type        string      `json:"type"`
+	Required    bool        `json:"required,omitempty"`
+	Enum        []string    `json:"enum,omitempty"`
+	Default     interface{} `json:"default,omitempty"`
+	Description string      `json:"description,omitempty"`
+}
+
+// ActionType is an action that declarative rules may reference by name. Executing a
+// catalogue action only produces result data; services act on that data.
+type ActionType struct {
+	Name        string                 `json:"name"`
+	Description string                 `json:"description"`
+	Params      map[string]ActionParam `json:"params"`
+	Terminal    bool                   `json:"terminal,omitempty"`  // lower-priority rules are not evaluated once a rule with this action matches
+	Conflicts   []string               `json:"conflicts,omitempty"` // actions that contradict this one for the same payment
+}
+
+// ActionCatalogue is the registry of actions available to declarative rules
+type ActionCatalogue struct {
+	actions map[string]ActionType
+}
+
+// NewActionCatalogue creates an empty catalogue
+func NewActionCatalogue() *ActionCatalogue {
+	return &ActionCatalogue{actions: make(map[string]ActionType)}
+}
+
+// DefaultActionCatalogue returns the catalogue of actions the platform knows how to perform
+func DefaultActionCatalogue() *ActionCatalogue {
+	c := NewActionCatalogue()
+	c.Register(ActionType{
+		Name:        "alert",
+		Description: "Raise an internal alert",
+		Params: map[string]ActionParam{
+			"severity": {Type: ParamTypeString, Enum: []string{"low", "medium", "high", "critical"}, Default: "medium"},
+			"channels": {Type: ParamTypeStringList, Description: "Alert channels such as email or slack"},
+			"message":  {Type: ParamTypeString},
+		},
+	})
+	c.Register(ActionType{
+		Name:        "retry",
+		Description: "Schedule a payment retry",
+		Params: map[string]ActionParam{
+			"delay_minutes": {Type: ParamTypeNumber, Default: float64(60)},
+			"max_attempts":  {Type: ParamTypeNumber, Default: float64(3)},
+		},
+	})
+	c.Register(ActionType{
+		Name:        "no_retry",
+		Description: "Prevent automatic retries for the payment",
+		Params: map[string]ActionParam{
+			"reason": {Type: ParamTypeString},
+		},
+		Conflicts: []string{"retry"},
+	})
+	c.Register(ActionType{
+		Name:        "block",
+		Description: "Block the payment from further automated recovery",
+		Params: map[string]ActionParam{
+			"reason": {Type: ParamTypeString},
+		},
+		Terminal:  true,
+		Conflicts: []string{"retry", "customer_contact", "start_workflow"},
+	})
+	c.Register(ActionType{
+		Name:        "customer_contact",
+		Description: "Contact the customer",
+		Params: map[string]ActionParam{
+			"channel":  {Type: ParamTypeString, Required: true, Enum: []string{"email", "sms", "phone"}},
+			"template": {Type: ParamTypeString},
+			"urgency":  {Type: ParamTypeString, Enum: []string{"low", "normal", "immediate"}, Default: "normal"},
+		},
+	})
+	c.Register(ActionType{
+		Name:        "escalate",
+		Description: "Escalate to a team for manual handling",
+		Params: map[string]ActionParam{
+			"team":   {Type: ParamTypeString, Required: true},
+			"reason": {Type: ParamTypeString},
+		},
+	})
+	c.Register(ActionType{
+		Name:        "tag",
+		Description: "Add tags to the payment failure",
+		Params: map[string]ActionParam{
+			"tags": {Type: ParamTypeStringList, Required: true},
+		},
+	})
+	c.Register(ActionType{
+		Name:        "set_risk_score",
+		Description: "Assign a risk score between 0 and 100",
+		Params: map[string]ActionParam{
+			"score": {Type: ParamTypeNumber, Required: true},
+		},
+	})
+	c.Register(ActionType{
+		Name:        "start_workflow",
+		Description: "Start a recovery workflow",
+		Params: map[string]ActionParam{
+			"workflow_id": {Type: ParamTypeString, Required: true},
+		},
+	})
+	return c
+}
+
+// Register adds or replaces an action type
+func (c *ActionCatalogue) Register(action ActionType) {
+	c.actions[action.Name] = action
+}
+
+// Get returns an action type by name
+func (c *ActionCatalogue) Get(name string) (ActionType, bool) {
+	action, ok := c.actions[name]
+	return action, ok
+}
+
+// Contradicts reports whether two actions contradict each other. Conflicts are
+// symmetric, so they only need declaring on one of the two actions.
+func (c *ActionCatalogue) Contradicts(a, b string) bool {
+	return containsString(c.actions[a].Conflicts, b) || containsString(c.actions[b].Conflicts, a)
+}
+
+// List returns the registered action types sorted by name
+func (c *ActionCatalogue) List() []ActionType {
+	list := make([]ActionType, 0, len(c.actions))
+	for _, action := range c.actions {
+		list = append(list, action)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list
+}
+
+// boundAction is a catalogue action with validated parameters
+type boundAction struct {
+	Type   string
+	Params map[string]interface{}
+}
+
+func (c *ActionCatalogue) bind(spec ActionSpec) (boundAction, error) {
+	action, ok := c.actions[spec.Type]
+	if !ok {
+		return boundAction{}, fmt.Errorf("unknown action type %q", spec.Type)
+	}
+
+	params := make(map[string]interface{}, len(action.Params))
+	for name := range spec.Params {
+		if _, ok := action.Params[name]; !ok {
+			return boundAction{}, fmt.Errorf("%s: unknown parameter %q", spec.Type, name)
+		}
+	}
+	for name, param := range action.Params {
+		raw, ok := spec.Params[name]
+		if !ok || raw == nil {
+			if param.Required {
+				return boundAction{}, fmt.Errorf("%s: parameter %q is required", spec.Type, name)
+			}
+			if param.Default != nil {
+				params[name] = param.Default
+			}
+			continue
+		}
+		v, err := coerceParam(raw, param)
+		if err != nil {
+			return boundAction{}, fmt.Errorf("%s: parameter %q: %v", spec.Type, name, err)
+		}
+		params[name] = v
+	}
+
+	if spec.Type == "set_risk_score" {
+		if score := params["score"].(float64); score < 0 || score > 100 {
+			return boundAction{}, fmt.Errorf("set_risk_score: score must be between 0 and 100")
+		}
+	}
+
+	return boundAction{Type: spec.Type, Params: params}, nil
+}
+
+func coerceParam(raw interface{}, param ActionParam) (interface{}, error) {
+	switch param.Type {
+	case ParamTypeNumber:
+		return coerceValue(raw, FieldKindNumber)
+	case ParamTypeBool:
+		b, ok := raw.(bool)
+		if !ok {
+			return nil, fmt.Errorf("expected a boolean, got %T", raw)
+		}
+		return b, nil
+	case ParamTypeStringList:
+		items, ok := raw.([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("expected a list of strings, got %T", raw)
+		}
+		list := make([]string, len(items))
+		for i, item := range items {
+			s, ok := item.(string)
+			if !ok {
+				return nil, fmt.Errorf("expected a list of strings, got %T", item)
+			}
+			list[i] = s
+		}
+		return list, nil
+	}
+
+	s, ok := raw.(string)
+	if !ok {
+		return nil, fmt.Errorf("expected a string, got %T", raw)
+	}
+	if len(param.Enum) > 0 && !containsString(param.Enum, s) {
+		return nil, fmt.Errorf("must be one of %s", strings.Join(param.Enum, ", "))
+	}
+	return s, nil
+}
+
+// runActions produces the combined result of a declarative rule's actions. The first
+// action's type is exposed as Data["action"] and all of them under Data["actions"].
+func runActions(ruleName string, actions []boundAction, event *models.PaymentFailureEvent) (*ActionResult, error) {
+	entries := make([]map[string]interface{}, len(actions))
+	types := make([]string, len(actions))
+	for i, action := range actions {
+		entry := map[string]interface{}{"type": action.Type}
+		for k, v := range action.Params {
+			entry[k] = v
+		}
+		entries[i] = entry
+		types[i] = action.Type
+	}
+
+	return &ActionResult{
+		RuleID:     ruleName,
+		RuleName:   ruleName,
+		Success:    true,
+		ExecutedAt: time.Now(),
+		Message:    fmt.Sprintf("Rule %s matched: %s", ruleName, strings.Join(types, ", ")),
+		Data: map[string]interface{}{
+			"action":      actions[0].Type,
+			"actions":     entries,
+			"event_id":    event.ID.String(),
+			"declarative": true,
+		},
+	}, nil
+}
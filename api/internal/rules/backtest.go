@@ -0,0 +1,41 @@
+package rules
+
+import (
+	"sort"
+
+	"github.com/sambitmohanty1/payment-watchdog/api/internal/models"
+)
+
+// MatchRules returns the enabled rules of engine whose conditions hold for event,
+// in priority order. Like ExecuteRules, no lower-priority rule matches once a
+// terminal rule has; shadow rules never stop evaluation and are always evaluated,
+// as they are live. Unlike ExecuteRules it never runs rule actions, so it is safe
+// for replaying historical events.
+func MatchRules(engine RuleEngine, event *models.PaymentFailureEvent) []*Rule {
+	ordered := append([]*Rule(nil), engine.GetRules()...)
+	sort.SliceStable(ordered, func(i, j int) bool { return ordered[i].Priority > ordered[j].Priority })
+
+	var matched []*Rule
+	terminated := false
+	for _, rule := range ordered {
+		if !rule.Enabled || (terminated && !rule.Shadow) {
+			continue
+		}
+		if conditionHolds(rule, event) {
+			matched = append(matched, rule)
+			terminated = terminated || (rule.Terminal && !rule.Shadow)
+		}
+	}
+	return matched
+}
+
+// conditionHolds evaluates a rule condition, treating a panic as no match so that
+// one malformed historical event cannot abort a replay
+func conditionHolds(rule *Rule, event *models.PaymentFailureEvent) (ok bool) {
+	defer func() {
+		if recover() != nil {
+			ok = false
+		}
+	}()
+	return rule.Condition(event)
+}
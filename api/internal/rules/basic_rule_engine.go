@@ -17,6 +17,9 @@ type BasicRule struct {
 	Action      func(*models.PaymentFailureEvent) (*BasicActionResult, error)
 	Priority    int
 	Enabled     bool
+	Shadow      bool
+	Terminal    bool
+	ActionTypes []string
 }
 
 // BasicActionResult represents the result of executing a rule
@@ -65,7 +68,8 @@ func (e *BasicRuleEngine) ExecuteRules(event *models.PaymentFailureEvent) []*Bas
 		zap.String("failure_reason", event.FailureReason))
 
 	for _, rule := range e.rules {
-		if !rule.Enabled {
+		// Shadow rules are only evaluated through EvaluateShadowRules
+		if !rule.Enabled || rule.Shadow {
 			continue
 		}
 
@@ -91,6 +95,14 @@ func (e *BasicRuleEngine) ExecuteRules(event *models.PaymentFailureEvent) []*Bas
 			}
 
 			results = append(results, result)
+
+			// A terminal rule ends evaluation for the event
+			if rule.Terminal && result.Success {
+				e.logger.Debug("Terminal rule matched, skipping lower-priority rules",
+					zap.String("rule_name", rule.Name),
+					zap.String("event_id", event.ID.String()))
+				break
+			}
 		}
 	}
 
@@ -0,0 +1,453 @@
+package rules
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/bits"
+	"sort"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/sambitmohanty1/payment-watchdog/api/internal/models"
+)
+
+// indexedFields are the event fields the compiled engine discriminates on before
+// evaluating any condition. Most rules pin at least one of them.
+var indexedFields = []struct {
+	name string
+	get  func(*models.PaymentFailureEvent) string
+}{
+	{"provider", func(e *models.PaymentFailureEvent) string { return e.ProviderID }},
+	{"failure_reason", func(e *models.PaymentFailureEvent) string { return e.FailureReason }},
+	{"currency", func(e *models.PaymentFailureEvent) string { return e.Currency }},
+}
+
+// CompiledRuleEngine evaluates large sets of declarative rules. Rules are indexed
+// on provider, failure reason and currency so an event only evaluates the rules
+// that can match it, and identical condition subtrees are shared between rules
+// and evaluated at most once per event.
+//
+// It is safe for concurrent use. Rules added through AddRule rather than compiled
+// from a definition are not indexed and are always evaluated.
+type CompiledRuleEngine struct {
+	mu      sync.RWMutex
+	rules   []*compiledRule // evaluation order: priority, then insertion
+	nodes   []sharedNode
+	nodeIDs map[string]int // canonical condition → node
+	index   []fieldIndex
+	dirty   bool
+	logger  *zap.Logger
+}
+
+type compiledRule struct {
+	rule   *Rule
+	root   int                 // shared node of the condition, -1 for opaque conditions
+	values map[string][]string // indexed field → values the rule can match; absent when any value can
+	never  bool                // the condition can never hold
+}
+
+// sharedNode is one distinct condition subtree
+type sharedNode struct {
+	leaf     conditionFunc
+	all      bool
+	not      bool
+	children []int
+}
+
+// fieldIndex maps the values of one indexed field to the rules that may match them
+type fieldIndex struct {
+	get      func(*models.PaymentFailureEvent) string
+	byValue  map[string]ruleSet
+	wildcard ruleSet
+}
+
+// ruleSet is a bitset of rule positions
+type ruleSet []uint64
+
+func newRuleSet(size int) ruleSet { return make(ruleSet, (size+63)/64) }
+
+func (s ruleSet) add(i int) { s[i/64] |= 1 << (i % 64) }
+func (s ruleSet) intersect(o ruleSet) {
+	for i := range s {
+		s[i] &= o[i]
+	}
+}
+
+// NewCompiledRuleEngine compiles definitions into an indexed engine
+func NewCompiledRuleEngine(defs []RuleDefinition, catalogue *ActionCatalogue, logger *zap.Logger) (*CompiledRuleEngine, error) {
+	e := &CompiledRuleEngine{nodeIDs: make(map[string]int), logger: logger}
+	for i := range defs {
+		if err := e.AddDefinition(&defs[i], catalogue); err != nil {
+			return nil, fmt.Errorf("rule %q: %w", defs[i].Name, err)
+		}
+	}
+	return e, nil
+}
+
+// AddDefinition compiles and adds a declarative rule, replacing a rule of the same name
+func (e *CompiledRuleEngine) AddDefinition(def *RuleDefinition, catalogue *ActionCatalogue) error {
+	rule, err := CompileRuleDefinition(def, catalogue)
+	if err != nil {
+		return err
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	root, err := e.intern(&def.When)
+	if err != nil {
+		return err
+	}
+	compiled := &compiledRule{rule: rule, root: root}
+	rule.Condition = func(event *models.PaymentFailureEvent) bool {
+		e.mu.RLock()
+		defer e.mu.RUnlock()
+		return e.evaluate(root, event, make([]uint8, len(e.nodes)))
+	}
+
+	// Discriminating values come from the same normal form the analyser uses. A
+	// field is only indexed when every alternative of the condition pins it.
+	if terms, ok := conditionTerms(&def.When, false); ok {
+		compiled.never = len(terms) == 0
+		compiled.values = make(map[string][]string)
+		for _, field := range indexedFields {
+			if values, ok := pinnedValues(terms, field.name); ok {
+				compiled.values[field.name] = values
+			}
+		}
+	}
+
+	e.removeLocked(rule.ID)
+	e.insertLocked(compiled)
+	return nil
+}
+
+// intern returns the shared node for a condition, creating nodes for any subtree
+// not seen before
+func (e *CompiledRuleEngine) intern(node *ConditionNode) (int, error) {
+	canonical, err := json.Marshal(node)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %v", ErrInvalidRuleDefinition, err)
+	}
+	key := string(canonical)
+	if id, ok := e.nodeIDs[key]; ok {
+		return id, nil
+	}
+
+	var shared sharedNode
+	switch {
+	case node.All != nil || node.Any != nil:
+		children := node.All
+		shared.all = node.All != nil
+		if !shared.all {
+			children = node.Any
+		}
+		for i := range children {
+			child, err := e.intern(&children[i])
+			if err != nil {
+				return 0, err
+			}
+			shared.children = append(shared.children, child)
+		}
+	case node.Not != nil:
+		child, err := e.intern(node.Not)
+		if err != nil {
+			return 0, err
+		}
+		shared.not = true
+		shared.children = []int{child}
+	default:
+		// Leaves were validated by CompileRuleDefinition; compile them once here
+		leaf, err := compileCondition(node, "when")
+		if err != nil {
+			return 0, err
+		}
+		shared.leaf = leaf
+	}
+
+	e.nodes = append(e.nodes, shared)
+	id := len(e.nodes) - 1
+	e.nodeIDs[key] = id
+	return id, nil
+}
+
+// evaluate evaluates a shared node, memoising results for the current event.
+// memo holds 0 for unevaluated nodes, 1 for true and 2 for false.
+func (e *CompiledRuleEngine) evaluate(id int, event *models.PaymentFailureEvent, memo []uint8) bool {
+	if memo[id] != 0 {
+		return memo[id] == 1
+	}
+
+	node := &e.nodes[id]
+	var result bool
+	switch {
+	case node.leaf != nil:
+		result = node.leaf(event)
+	case node.not:
+		result = !e.evaluate(node.children[0], event, memo)
+	case node.all:
+		result = true
+		for _, child := range node.children {
+			if !e.evaluate(child, event, memo) {
+				result = false
+				break
+			}
+		}
+	default:
+		for _, child := range node.children {
+			if e.evaluate(child, event, memo) {
+				result = true
+				break
+			}
+		}
+	}
+
+	memo[id] = 2
+	if result {
+		memo[id] = 1
+	}
+	return result
+}
+
+// pinnedValues returns the values of field that every term restricts it to
+func pinnedValues(terms []conditionTerm, field string) ([]string, bool) {
+	seen := make(map[string]bool)
+	var values []string
+	for _, term := range terms {
+		r, ok := term.ranges[field]
+		if !ok || r.allowed == nil {
+			return nil, false
+		}
+		for _, v := range r.allowed {
+			s, isString := v.(string)
+			if !isString {
+				return nil, false
+			}
+			if r.contains(v) && !seen[s] {
+				seen[s] = true
+				values = append(values, s)
+			}
+		}
+	}
+	sort.Strings(values)
+	return values, true
+}
+
+func (e *CompiledRuleEngine) insertLocked(compiled *compiledRule) {
+	position := sort.Search(len(e.rules), func(i int) bool { return e.rules[i].rule.Priority < compiled.rule.Priority })
+	e.rules = append(e.rules, nil)
+	copy(e.rules[position+1:], e.rules[position:])
+	e.rules[position] = compiled
+	e.dirty = true
+}
+
+func (e *CompiledRuleEngine) removeLocked(ruleID string) {
+	for i, compiled := range e.rules {
+		if compiled.rule.ID == ruleID {
+			e.rules = append(e.rules[:i], e.rules[i+1:]...)
+			e.dirty = true
+			return
+		}
+	}
+}
+
+// buildIndexLocked rebuilds the field indexes after the rules changed
+func (e *CompiledRuleEngine) buildIndexLocked() {
+	e.index = make([]fieldIndex, len(indexedFields))
+	for f, field := range indexedFields {
+		idx := fieldIndex{get: field.get, byValue: make(map[string]ruleSet), wildcard: newRuleSet(len(e.rules))}
+		for i, compiled := range e.rules {
+			if compiled.never {
+				continue
+			}
+			values, pinned := compiled.values[field.name]
+			if !pinned {
+				idx.wildcard.add(i)
+				continue
+			}
+			for _, v := range values {
+				set, ok := idx.byValue[v]
+				if !ok {
+					set = newRuleSet(len(e.rules))
+					idx.byValue[v] = set
+				}
+				set.add(i)
+			}
+		}
+		e.index[f] = idx
+	}
+	e.dirty = false
+}
+
+// candidates returns the rules that may match event
+func (e *CompiledRuleEngine) candidates(event *models.PaymentFailureEvent) ruleSet {
+	result := newRuleSet(len(e.rules))
+	for i := range result {
+		result[i] = ^uint64(0)
+	}
+	mask := newRuleSet(len(e.rules))
+	for _, idx := range e.index {
+		copy(mask, idx.wildcard)
+		if set, ok := idx.byValue[idx.get(event)]; ok {
+			for i := range mask {
+				mask[i] |= set[i]
+			}
+		}
+		result.intersect(mask)
+	}
+	return result
+}
+
+// ExecuteRules executes the matching rules for an event in priority order,
+// stopping after a terminal rule
+func (e *CompiledRuleEngine) ExecuteRules(event *models.PaymentFailureEvent) []*ActionResult {
+	if e.needsIndex() {
+		e.mu.Lock()
+		if e.dirty || e.index == nil {
+			e.buildIndexLocked()
+		}
+		e.mu.Unlock()
+	}
+
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	candidates := e.candidates(event)
+	memo := make([]uint8, len(e.nodes))
+	var results []*ActionResult
+	for word, bitsLeft := range candidates {
+		for bitsLeft != 0 {
+			i := word*64 + bits.TrailingZeros64(bitsLeft)
+			bitsLeft &= bitsLeft - 1
+			if i >= len(e.rules) {
+				break
+			}
+
+			compiled := e.rules[i]
+			rule := compiled.rule
+			if !rule.Enabled || rule.Shadow {
+				continue
+			}
+			matched := false
+			if compiled.root >= 0 {
+				matched = e.evaluate(compiled.root, event, memo)
+			} else {
+				matched = rule.Condition(event)
+			}
+			if !matched {
+				continue
+			}
+
+			result, err := rule.Action(event)
+			if err != nil {
+				e.logger.Error("Rule execution failed",
+					zap.String("rule_name", rule.Name),
+					zap.Error(err))
+				result = &ActionResult{
+					RuleID:     rule.ID,
+					RuleName:   rule.Name,
+					Success:    false,
+					Error:      err,
+					ExecutedAt: time.Now(),
+					Message:    fmt.Sprintf("Rule execution failed: %v", err),
+				}
+			}
+			results = append(results, result)
+			if rule.Terminal && result.Success {
+				return results
+			}
+		}
+	}
+	return results
+}
+
+func (e *CompiledRuleEngine) needsIndex() bool {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.dirty || e.index == nil
+}
+
+// GetRules returns the rules in evaluation order
+func (e *CompiledRuleEngine) GetRules() []*Rule {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	rules := make([]*Rule, len(e.rules))
+	for i, compiled := range e.rules {
+		rules[i] = compiled.rule
+	}
+	return rules
+}
+
+// AddRule adds a rule whose condition is opaque to the engine. It is not
+// indexed and is evaluated for every event.
+func (e *CompiledRuleEngine) AddRule(rule *Rule) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.removeLocked(rule.ID)
+	e.insertLocked(&compiledRule{rule: rule, root: -1})
+}
+
+// RemoveRule removes a rule from the engine
+func (e *CompiledRuleEngine) RemoveRule(ruleID string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.removeLocked(ruleID)
+}
+
+// EnableRule enables a specific rule
+func (e *CompiledRuleEngine) EnableRule(ruleID string) {
+	e.setEnabled(ruleID, true)
+}
+
+// DisableRule disables a specific rule
+func (e *CompiledRuleEngine) DisableRule(ruleID string) {
+	e.setEnabled(ruleID, false)
+}
+
+func (e *CompiledRuleEngine) setEnabled(ruleID string, enabled bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for _, compiled := range e.rules {
+		if compiled.rule.ID == ruleID {
+			compiled.rule.Enabled = enabled
+			return
+		}
+	}
+}
+
+// GetStats returns statistics about the rule engine
+func (e *CompiledRuleEngine) GetStats() map[string]interface{} {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	enabled, indexed := 0, 0
+	for _, compiled := range e.rules {
+		if compiled.rule.Enabled {
+			enabled++
+		}
+		if len(compiled.values) > 0 {
+			indexed++
+		}
+	}
+	return map[string]interface{}{
+		"total_rules":    len(e.rules),
+		"enabled_rules":  enabled,
+		"disabled_rules": len(e.rules) - enabled,
+		"indexed_rules":  indexed,
+		"shared_nodes":   len(e.nodes),
+	}
+}
+
+// GetRuleByName returns a rule by its name
+func (e *CompiledRuleEngine) GetRuleByName(name string) *Rule {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	for _, compiled := range e.rules {
+		if compiled.rule.Name == name {
+			return compiled.rule
+		}
+	}
+	return nil
+}
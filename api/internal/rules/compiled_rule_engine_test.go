@@ -0,0 +1,134 @@
+package rules
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/sambitmohanty1/payment-watchdog/api/internal/models"
+)
+
+var compiledTestRules = []string{
+	`{"name":"stripe_declined","priority":50,"when":{"all":[{"field":"provider","operator":"eq","value":"stripe"},{"field":"failure_reason","operator":"in","value":["card_declined","do_not_honor"]},{"field":"amount","operator":"gt","value":100}]},"actions":[{"type":"retry"}]}`,
+	`{"name":"stripe_or_xero_aud","priority":40,"when":{"any":[{"all":[{"field":"provider","operator":"eq","value":"stripe"},{"field":"currency","operator":"eq","value":"AUD"}]},{"all":[{"field":"provider","operator":"eq","value":"xero"},{"field":"currency","operator":"eq","value":"AUD"}]}]},"actions":[{"type":"alert"}]}`,
+	`{"name":"large_anywhere","priority":30,"when":{"field":"amount","operator":"gt","value":100},"actions":[{"type":"tag","params":{"tags":["large"]}}]}`,
+	`{"name":"not_paypal","priority":20,"when":{"not":{"field":"provider","operator":"eq","value":"paypal"}},"actions":[{"type":"alert","params":{"severity":"low"}}]}`,
+	`{"name":"expr_retries","priority":20,"when":{"expr":"retry_count >= 2 && currency == \"USD\""},"actions":[{"type":"no_retry"}]}`,
+	`{"name":"block_fraud","priority":10,"when":{"field":"failure_reason","operator":"eq","value":"fraudulent"},"actions":[{"type":"block"}]}`,
+	`{"name":"after_block","priority":5,"when":{"field":"amount","operator":"gte","value":0},"actions":[{"type":"alert"}]}`,
+	`{"name":"impossible","when":{"all":[{"field":"currency","operator":"eq","value":"AUD"},{"field":"currency","operator":"eq","value":"USD"}]},"actions":[{"type":"alert"}]}`,
+	`{"name":"disabled","enabled":false,"when":{"field":"provider","operator":"eq","value":"stripe"},"actions":[{"type":"alert"}]}`,
+	`{"name":"shadowed","shadow":true,"when":{"field":"provider","operator":"eq","value":"stripe"},"actions":[{"type":"alert"}]}`,
+}
+
+func resultNames(results []*ActionResult) []string {
+	names := make([]string, len(results))
+	for i, result := range results {
+		names[i] = result.RuleName
+	}
+	return names
+}
+
+func TestCompiledRuleEngineMatchesLinearEngine(t *testing.T) {
+	defs := parseTestDefinitions(t, compiledTestRules...)
+	linear, err := NewRuleEngineFactory(zap.NewNop()).CompileRuleSet(defs, DefaultActionCatalogue())
+	require.NoError(t, err)
+	compiled, err := NewCompiledRuleEngine(defs, DefaultActionCatalogue(), zap.NewNop())
+	require.NoError(t, err)
+
+	for _, provider := range []string{"stripe", "xero", "paypal"} {
+		for _, reason := range []string{"card_declined", "fraudulent", "insufficient_funds"} {
+			for _, currency := range []string{"AUD", "USD"} {
+				for _, cents := range []int64{5000, 50000} {
+					for _, retries := range []int{0, 3} {
+						event := &models.PaymentFailureEvent{
+							ID:            uuid.New(),
+							ProviderID:    provider,
+							FailureReason: reason,
+							Currency:      currency,
+							AmountCents:   cents,
+							RetryCount:    retries,
+						}
+						name := fmt.Sprintf("%s/%s/%s/%d/%d", provider, reason, currency, cents, retries)
+						// Rules of equal priority may run in either order in the linear engine
+						assert.ElementsMatch(t, resultNames(linear.ExecuteRules(event)), resultNames(compiled.ExecuteRules(event)), name)
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestCompiledRuleEngineIndexesAndSharesConditions(t *testing.T) {
+	defs := parseTestDefinitions(t, compiledTestRules...)
+	engine, err := NewCompiledRuleEngine(defs, DefaultActionCatalogue(), zap.NewNop())
+	require.NoError(t, err)
+
+	byName := make(map[string]*compiledRule)
+	for _, compiled := range engine.rules {
+		byName[compiled.rule.Name] = compiled
+	}
+	assert.Equal(t, []string{"stripe"}, byName["stripe_declined"].values["provider"])
+	assert.Equal(t, []string{"card_declined", "do_not_honor"}, byName["stripe_declined"].values["failure_reason"])
+	assert.Equal(t, []string{"stripe", "xero"}, byName["stripe_or_xero_aud"].values["provider"])
+	assert.Equal(t, []string{"AUD"}, byName["stripe_or_xero_aud"].values["currency"])
+	assert.NotContains(t, byName["not_paypal"].values, "provider")
+	assert.Empty(t, byName["expr_retries"].values)
+	assert.True(t, byName["impossible"].never)
+
+	// "amount > 100" and "provider == stripe" are each stored once
+	stats := engine.GetStats()
+	assert.Equal(t, len(compiledTestRules), stats["total_rules"])
+	leaves := 0
+	for _, node := range engine.nodes {
+		if node.leaf != nil {
+			leaves++
+		}
+	}
+	assert.Equal(t, 10, leaves)
+
+	event := &models.PaymentFailureEvent{ProviderID: "paypal", FailureReason: "insufficient_funds", Currency: "USD", AmountCents: 50000}
+	engine.ExecuteRules(event)
+	candidates := engine.candidates(event)
+	var names []string
+	for i, compiled := range engine.rules {
+		if candidates[i/64]&(1<<(i%64)) != 0 {
+			names = append(names, compiled.rule.Name)
+		}
+	}
+	// Disabled and shadow rules never run, so they are left out of the index
+	assert.ElementsMatch(t, []string{"large_anywhere", "not_paypal", "expr_retries", "after_block"}, names)
+}
+
+func TestCompiledRuleEngineChanges(t *testing.T) {
+	defs := parseTestDefinitions(t, compiledTestRules[0], compiledTestRules[5], compiledTestRules[6])
+	engine, err := NewCompiledRuleEngine(defs, DefaultActionCatalogue(), zap.NewNop())
+	require.NoError(t, err)
+
+	fraud := &models.PaymentFailureEvent{ProviderID: "stripe", FailureReason: "fraudulent", AmountCents: 100}
+	assert.Equal(t, []string{"block_fraud"}, resultNames(engine.ExecuteRules(fraud)))
+
+	engine.DisableRule("block_fraud")
+	assert.Equal(t, []string{"after_block"}, resultNames(engine.ExecuteRules(fraud)))
+
+	engine.RemoveRule("after_block")
+	engine.AddRule(&Rule{
+		ID:        "opaque",
+		Name:      "opaque",
+		Enabled:   true,
+		Priority:  100,
+		Condition: func(e *models.PaymentFailureEvent) bool { return e.FailureReason == "fraudulent" },
+		Action: func(e *models.PaymentFailureEvent) (*ActionResult, error) {
+			return &ActionResult{RuleID: "opaque", RuleName: "opaque", Success: true}, nil
+		},
+	})
+	assert.Equal(t, []string{"opaque"}, resultNames(engine.ExecuteRules(fraud)))
+	require.NotNil(t, engine.GetRuleByName("stripe_declined"))
+	assert.True(t, engine.GetRuleByName("stripe_declined").Condition(&models.PaymentFailureEvent{
+		ProviderID: "stripe", FailureReason: "card_declined", AmountCents: 50000,
+	}))
+}
@@ -189,7 +189,6 @@ func (cpr *ComprehensivePaymentFailureRules) createRecurringPaymentFailureRule()
 		Priority:    120,
 		Enabled:     true,
 		Condition: func(event *models.PaymentFailureEvent) bool {
-			// TODO: Check if this is a recurring payment and has failed before
 			return cpr.isRecurringPayment(event)
 		},
 		Action: func(event *models.PaymentFailureEvent) (*BasicActionResult, error) {
@@ -222,8 +221,7 @@ func (cpr *ComprehensivePaymentFailureRules) createVIPCustomerRule() *BasicRule
 		Priority:    110,
 		Enabled:     true,
 		Condition: func(event *models.PaymentFailureEvent) bool {
-			// TODO: Check if customer is VIP based on business rules
-			return cpr.isVIPCustomer(event.CustomerID)
+			return cpr.isVIPCustomer(event)
 		},
 		Action: func(event *models.PaymentFailureEvent) (*BasicActionResult, error) {
 			cpr.logger.Info("VIP customer payment failure",
@@ -426,14 +424,15 @@ func (cpr *ComprehensivePaymentFailureRules) isSuspiciousPattern(event *models.P
 	return event.Amount > 5000.0 && event.FailureReason == "card_declined"
 }
 
+// isRecurringPayment reports whether the customer is billed on a subscription,
+// going by the segments resolved for the event
 func (cpr *ComprehensivePaymentFailureRules) isRecurringPayment(event *models.PaymentFailureEvent) bool {
-	// TODO: Check database for recurring payment failures
-	return false
+	return containsString(event.CustomerSegments, models.CustomerSegmentRecurring)
 }
 
-func (cpr *ComprehensivePaymentFailureRules) isVIPCustomer(customerID string) bool {
-	// TODO: Check customer database for VIP status
-	return false
+// isVIPCustomer reports whether the customer is in the VIP segment, by history or manual tag
+func (cpr *ComprehensivePaymentFailureRules) isVIPCustomer(event *models.PaymentFailureEvent) bool {
+	return containsString(event.CustomerSegments, models.CustomerSegmentVIP)
 }
 
 func (cpr *ComprehensivePaymentFailureRules) calculateRetryDelay(event *models.PaymentFailureEvent) time.Duration {
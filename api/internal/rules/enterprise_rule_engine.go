@@ -1,6 +1,7 @@
 package rules
 
 import (
+	"context"
 	"fmt"
 	"sort"
 	"sync"
@@ -9,16 +10,19 @@ import (
 	"go.uber.org/zap"
 
 	"github.com/sambitmohanty1/payment-watchdog/api/internal/architecture"
+	"github.com/sambitmohanty1/payment-watchdog/api/internal/calendar"
 )
 
 // EnterpriseRuleEngine represents the core business rules engine
 type EnterpriseRuleEngine struct {
-	rules    []EnterpriseRule
-	executor EnterpriseRuleExecutor
-	context  EnterpriseRuleContext
-	metrics  *EnterpriseRuleMetrics
-	logger   *zap.Logger
-	mutex    sync.RWMutex
+	rules      []EnterpriseRule
+	executor   EnterpriseRuleExecutor
+	context    EnterpriseRuleContext
+	dispatcher EnterpriseActionDispatcher
+	provider   EnterpriseRuleProvider
+	metrics    *EnterpriseRuleMetrics
+	logger     *zap.Logger
+	mutex      sync.RWMutex
 }
 
 // EnterpriseRule represents a configurable business rule
@@ -30,6 +34,7 @@ type EnterpriseRule struct {
 	Conditions  []EnterpriseCondition `json:"conditions"`
 	Actions     []EnterpriseAction    `json:"actions"`
 	Enabled     bool                  `json:"enabled"`
+	Shadow      bool                  `json:"shadow"` // evaluate and report without executing actions
 	Tags        []string              `json:"tags"`
 	CreatedAt   time.Time             `json:"created_at"`
 	UpdatedAt   time.Time             `json:"updated_at"`
@@ -41,11 +46,13 @@ type EnterpriseRuleContext struct {
 	PaymentFailure *architecture.PaymentFailure `json:"payment_failure"`
 	Customer       *architecture.Customer       `json:"customer"`
 	Company        *EnterpriseCompany           `json:"company"`
-	Timestamp      time.Time                    `json:"timestamp"`
-	Environment    string                       `json:"environment"`
-	UserID         string                       `json:"user_id"`
-	SessionID      string                       `json:"session_id"`
-	Metadata       map[string]interface{}       `json:"metadata"`
+	// CustomerSegments are the customer's segments, such as vip or hardship
+	CustomerSegments []string               `json:"customer_segments,omitempty"`
+	Timestamp        time.Time              `json:"timestamp"`
+	Environment      string                 `json:"environment"`
+	UserID           string                 `json:"user_id"`
+	SessionID        string                 `json:"session_id"`
+	Metadata         map[string]interface{} `json:"metadata"`
 }
 
 // EnterpriseCompany represents company information for rule context
@@ -58,6 +65,9 @@ type EnterpriseCompany struct {
 	Settings    map[string]string `json:"settings"`
 	CreatedAt   time.Time         `json:"created_at"`
 	UpdatedAt   time.Time         `json:"updated_at"`
+
+	// Calendar holds the company's timezone, business hours and holidays
+	Calendar *calendar.Calendar `json:"-"`
 }
 
 // EnterpriseCondition represents a rule condition that must be met
@@ -67,6 +77,26 @@ type EnterpriseCondition interface {
 	GetDescription() string
 }
 
+// EnterpriseValueObserver is implemented by conditions that can report the value
+// they compared, recorded in EnterpriseConditionResult.Value to explain decisions
+type EnterpriseValueObserver interface {
+	ObservedValue(ctx EnterpriseRuleContext) interface{}
+}
+
+// EnterpriseConditionKeyer is implemented by conditions whose outcome depends only
+// on their parameters and the rule context. Conditions with equal keys are
+// evaluated once per execution and their result shared between rules.
+type EnterpriseConditionKeyer interface {
+	ConditionKey() string
+}
+
+// EnterpriseCheckedCondition is implemented by conditions that can fail to
+// evaluate, such as company scripts. The engine prefers EvaluateChecked over
+// Evaluate; an error counts as not met and is recorded in the condition result.
+type EnterpriseCheckedCondition interface {
+	EvaluateChecked(goCtx context.Context, ctx EnterpriseRuleContext) (met bool, value interface{}, err error)
+}
+
 // EnterpriseAction represents an action to be executed when a rule is triggered
 type EnterpriseAction interface {
 	Execute(ctx EnterpriseRuleContext) error
@@ -75,6 +105,46 @@ type EnterpriseAction interface {
 	GetPriority() int
 }
 
+// EnterpriseParameterizedAction is implemented by actions whose work is performed
+// by an EnterpriseActionDispatcher. ActionParams describes the action to it.
+type EnterpriseParameterizedAction interface {
+	ActionParams() map[string]interface{}
+}
+
+// EnterpriseActionPlanner is implemented by actions that decide at execution
+// time which actions to take. Each planned action is dispatched, or reported
+// without executing for shadow rules, in place of the planner itself.
+type EnterpriseActionPlanner interface {
+	PlanActions(goCtx context.Context, ctx EnterpriseRuleContext) ([]EnterprisePlannedAction, error)
+}
+
+// EnterprisePlannedAction is one action chosen by an EnterpriseActionPlanner
+type EnterprisePlannedAction struct {
+	Type   string                 `json:"type"`
+	Params map[string]interface{} `json:"params,omitempty"`
+}
+
+// EnterpriseRuleProvider supplies rules that belong to a single company, such as
+// company scripts. They run after being merged by priority with the engine's rules.
+type EnterpriseRuleProvider interface {
+	CompanyRules(ctx context.Context, companyID string) ([]EnterpriseRule, error)
+}
+
+// EnterpriseActionRequest asks a dispatcher to perform one action of a triggered rule
+type EnterpriseActionRequest struct {
+	RuleID   string
+	RuleName string
+	Type     string
+	Params   map[string]interface{}
+}
+
+// EnterpriseActionDispatcher performs enterprise rule actions against real
+// services. It is injected into the engine so that the rules package does not
+// depend on the services that carry actions out.
+type EnterpriseActionDispatcher interface {
+	Dispatch(ctx context.Context, ruleCtx EnterpriseRuleContext, request EnterpriseActionRequest) (map[string]interface{}, error)
+}
+
 // EnterpriseRuleExecutor handles the execution of rules
 type EnterpriseRuleExecutor interface {
 	ExecuteRule(rule EnterpriseRule, ctx EnterpriseRuleContext) (*EnterpriseRuleResult, error)
@@ -86,6 +156,7 @@ type EnterpriseRuleResult struct {
 	RuleID     string                       `json:"rule_id"`
 	RuleName   string                       `json:"rule_name"`
 	Triggered  bool                         `json:"triggered"`
+	Shadow     bool                         `json:"shadow"`
 	ExecutedAt time.Time                    `json:"executed_at"`
 	Duration   time.Duration                `json:"duration"`
 	Actions    []*EnterpriseActionResult    `json:"actions"`
@@ -114,6 +185,7 @@ type EnterpriseConditionResult struct {
 	EvaluatedAt   time.Time              `json:"evaluated_at"`
 	Duration      time.Duration          `json:"duration"`
 	Value         interface{}            `json:"value"`
+	Error         string                 `json:"error,omitempty"`
 	Metadata      map[string]interface{} `json:"metadata"`
 }
 
@@ -141,6 +213,22 @@ func NewEnterpriseRuleEngine(logger *zap.Logger) *EnterpriseRuleEngine {
 	}
 }
 
+// SetActionDispatcher binds parameterized actions to dispatcher. Without a
+// dispatcher actions run their own Execute method.
+func (e *EnterpriseRuleEngine) SetActionDispatcher(dispatcher EnterpriseActionDispatcher) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	e.dispatcher = dispatcher
+}
+
+// SetRuleProvider adds the rules of the company in each rule context to every
+// execution
+func (e *EnterpriseRuleEngine) SetRuleProvider(provider EnterpriseRuleProvider) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	e.provider = provider
+}
+
 // AddRule adds a rule to the engine
 func (e *EnterpriseRuleEngine) AddRule(rule EnterpriseRule) error {
 	e.mutex.Lock()
@@ -267,6 +355,11 @@ func (e *EnterpriseRuleEngine) DisableRule(ruleID string) error {
 
 // ExecuteRules executes all applicable rules for a given context
 func (e *EnterpriseRuleEngine) ExecuteRules(ctx EnterpriseRuleContext) ([]*EnterpriseRuleResult, error) {
+	return e.ExecuteRulesContext(context.Background(), ctx)
+}
+
+// ExecuteRulesContext is like ExecuteRules but passes goCtx to dispatched actions
+func (e *EnterpriseRuleEngine) ExecuteRulesContext(goCtx context.Context, ctx EnterpriseRuleContext) ([]*EnterpriseRuleResult, error) {
 	e.mutex.RLock()
 	enabledRules := make([]EnterpriseRule, 0)
 	for _, rule := range e.rules {
@@ -274,8 +367,27 @@ func (e *EnterpriseRuleEngine) ExecuteRules(ctx EnterpriseRuleContext) ([]*Enter
 			enabledRules = append(enabledRules, rule)
 		}
 	}
+	dispatcher := e.dispatcher
+	provider := e.provider
 	e.mutex.RUnlock()
 
+	if provider != nil && ctx.Company != nil && ctx.Company.ID != "" {
+		companyRules, err := provider.CompanyRules(goCtx, ctx.Company.ID)
+		if err != nil {
+			e.logger.Error("Failed to load company rules",
+				zap.String("company_id", ctx.Company.ID),
+				zap.Error(err))
+		}
+		for _, rule := range companyRules {
+			if rule.Enabled {
+				enabledRules = append(enabledRules, rule)
+			}
+		}
+		sort.SliceStable(enabledRules, func(i, j int) bool {
+			return enabledRules[i].Priority > enabledRules[j].Priority
+		})
+	}
+
 	var results []*EnterpriseRuleResult
 	startTime := time.Now()
 
@@ -283,8 +395,9 @@ func (e *EnterpriseRuleEngine) ExecuteRules(ctx EnterpriseRuleContext) ([]*Enter
 		zap.Int("total_rules", len(enabledRules)),
 		zap.String("context_id", fmt.Sprintf("%v", ctx.PaymentFailure.ID)))
 
+	shared := make(map[string]*EnterpriseConditionResult)
 	for _, rule := range enabledRules {
-		result, err := e.executeRule(rule, ctx)
+		result, err := e.executeRule(goCtx, rule, ctx, dispatcher, shared)
 		if err != nil {
 			e.logger.Error("Enterprise rule execution failed",
 				zap.String("rule_id", rule.ID),
@@ -310,8 +423,9 @@ func (e *EnterpriseRuleEngine) ExecuteRules(ctx EnterpriseRuleContext) ([]*Enter
 	return results, nil
 }
 
-// executeRule executes a single rule
-func (e *EnterpriseRuleEngine) executeRule(rule EnterpriseRule, ctx EnterpriseRuleContext) (*EnterpriseRuleResult, error) {
+// executeRule executes a single rule. shared holds the results of keyed
+// conditions already evaluated for other rules in the same execution.
+func (e *EnterpriseRuleEngine) executeRule(goCtx context.Context, rule EnterpriseRule, ctx EnterpriseRuleContext, dispatcher EnterpriseActionDispatcher, shared map[string]*EnterpriseConditionResult) (*EnterpriseRuleResult, error) {
 	startTime := time.Now()
 
 	// Evaluate conditions
@@ -319,8 +433,32 @@ func (e *EnterpriseRuleEngine) executeRule(rule EnterpriseRule, ctx EnterpriseRu
 	conditionsMet := true
 
 	for _, condition := range rule.Conditions {
+		var key string
+		if keyer, ok := condition.(EnterpriseConditionKeyer); ok && shared != nil {
+			key = keyer.ConditionKey()
+			if previous, ok := shared[key]; ok {
+				reused := *previous
+				conditionResults = append(conditionResults, &reused)
+				if !reused.Met {
+					conditionsMet = false
+				}
+				continue
+			}
+		}
+
 		conditionStart := time.Now()
-		met := condition.Evaluate(ctx)
+		var (
+			met      bool
+			observed interface{}
+			evalErr  error
+		)
+		checked, isChecked := condition.(EnterpriseCheckedCondition)
+		if isChecked {
+			met, observed, evalErr = checked.EvaluateChecked(goCtx, ctx)
+			met = met && evalErr == nil
+		} else {
+			met = condition.Evaluate(ctx)
+		}
 		conditionDuration := time.Since(conditionStart)
 
 		conditionResult := &EnterpriseConditionResult{
@@ -329,6 +467,20 @@ func (e *EnterpriseRuleEngine) executeRule(rule EnterpriseRule, ctx EnterpriseRu
 			Met:           met,
 			EvaluatedAt:   time.Now(),
 			Duration:      conditionDuration,
+			Value:         observed,
+		}
+		if evalErr != nil {
+			conditionResult.Error = evalErr.Error()
+			e.logger.Warn("Enterprise rule condition failed",
+				zap.String("rule_id", rule.ID),
+				zap.String("condition_type", conditionResult.ConditionType),
+				zap.Error(evalErr))
+		}
+		if observer, ok := condition.(EnterpriseValueObserver); ok && !isChecked {
+			conditionResult.Value = observer.ObservedValue(ctx)
+		}
+		if key != "" {
+			shared[key] = conditionResult
 		}
 		conditionResults = append(conditionResults, conditionResult)
 
@@ -337,12 +489,28 @@ func (e *EnterpriseRuleEngine) executeRule(rule EnterpriseRule, ctx EnterpriseRu
 		}
 	}
 
-	// Execute actions if conditions are met
+	// Execute actions if conditions are met. Shadow rules report the actions
+	// they would have taken without executing them.
 	var actionResults []*EnterpriseActionResult
 	if conditionsMet {
 		for _, action := range rule.Actions {
+			if planner, ok := action.(EnterpriseActionPlanner); ok {
+				actionResults = append(actionResults, executePlannedActions(goCtx, rule, action, planner, ctx, dispatcher)...)
+				continue
+			}
+			if rule.Shadow {
+				actionResults = append(actionResults, &EnterpriseActionResult{
+					ActionType: action.GetType(),
+					ActionName: action.GetDescription(),
+					Executed:   false,
+					ExecutedAt: time.Now(),
+					Metadata:   map[string]interface{}{"shadow": true},
+				})
+				continue
+			}
+
 			actionStart := time.Now()
-			err := action.Execute(ctx)
+			output, err := executeAction(goCtx, rule, action, ctx, dispatcher)
 			actionDuration := time.Since(actionStart)
 
 			actionResult := &EnterpriseActionResult{
@@ -352,6 +520,7 @@ func (e *EnterpriseRuleEngine) executeRule(rule EnterpriseRule, ctx EnterpriseRu
 				ExecutedAt: time.Now(),
 				Duration:   actionDuration,
 				Error:      err,
+				Output:     output,
 			}
 			actionResults = append(actionResults, actionResult)
 		}
@@ -363,6 +532,7 @@ func (e *EnterpriseRuleEngine) executeRule(rule EnterpriseRule, ctx EnterpriseRu
 		RuleID:     rule.ID,
 		RuleName:   rule.Name,
 		Triggered:  conditionsMet,
+		Shadow:     rule.Shadow,
 		ExecutedAt: time.Now(),
 		Duration:   executionTime,
 		Actions:    actionResults,
@@ -370,6 +540,68 @@ func (e *EnterpriseRuleEngine) executeRule(rule EnterpriseRule, ctx EnterpriseRu
 	}, nil
 }
 
+// executeAction runs action through dispatcher when both support it, otherwise
+// through the action's own Execute method
+func executeAction(goCtx context.Context, rule EnterpriseRule, action EnterpriseAction, ctx EnterpriseRuleContext, dispatcher EnterpriseActionDispatcher) (map[string]interface{}, error) {
+	parameterized, ok := action.(EnterpriseParameterizedAction)
+	if dispatcher == nil || !ok {
+		return nil, action.Execute(ctx)
+	}
+	return dispatcher.Dispatch(goCtx, ctx, EnterpriseActionRequest{
+		RuleID:   rule.ID,
+		RuleName: rule.Name,
+		Type:     action.GetType(),
+		Params:   parameterized.ActionParams(),
+	})
+}
+
+// executePlannedActions asks planner for its actions and dispatches each of them,
+// or reports them unexecuted for shadow rules. Planned actions carry no Execute
+// method, so they fail without a dispatcher.
+func executePlannedActions(goCtx context.Context, rule EnterpriseRule, action EnterpriseAction, planner EnterpriseActionPlanner, ctx EnterpriseRuleContext, dispatcher EnterpriseActionDispatcher) []*EnterpriseActionResult {
+	planStart := time.Now()
+	planned, err := planner.PlanActions(goCtx, ctx)
+	if err != nil {
+		return []*EnterpriseActionResult{{
+			ActionType: action.GetType(),
+			ActionName: action.GetDescription(),
+			Executed:   false,
+			ExecutedAt: time.Now(),
+			Duration:   time.Since(planStart),
+			Error:      err,
+		}}
+	}
+
+	results := make([]*EnterpriseActionResult, 0, len(planned))
+	for _, plan := range planned {
+		result := &EnterpriseActionResult{
+			ActionType: plan.Type,
+			ActionName: action.GetDescription(),
+			Metadata:   map[string]interface{}{"planned_by": action.GetType()},
+		}
+		actionStart := time.Now()
+		switch {
+		case rule.Shadow:
+			result.Metadata["shadow"] = true
+			result.Output = plan.Params
+		case dispatcher == nil:
+			result.Error = fmt.Errorf("no action dispatcher for planned %s action", plan.Type)
+		default:
+			result.Output, result.Error = dispatcher.Dispatch(goCtx, ctx, EnterpriseActionRequest{
+				RuleID:   rule.ID,
+				RuleName: rule.Name,
+				Type:     plan.Type,
+				Params:   plan.Params,
+			})
+			result.Executed = result.Error == nil
+		}
+		result.ExecutedAt = time.Now()
+		result.Duration = time.Since(actionStart)
+		results = append(results, result)
+	}
+	return results
+}
+
 // GetMetrics returns the current rule engine metrics
 func (e *EnterpriseRuleEngine) GetMetrics() *EnterpriseRuleMetrics {
 	e.mutex.RLock()
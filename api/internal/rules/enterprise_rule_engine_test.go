@@ -1,6 +1,7 @@
 package rules
 
 import (
+	"context"
 	"testing"
 	"time"
 
@@ -9,6 +10,8 @@ import (
 	"go.uber.org/zap"
 
 	"github.com/sambitmohanty1/payment-watchdog/api/internal/architecture"
+	"github.com/sambitmohanty1/payment-watchdog/api/internal/calendar"
+	"github.com/sambitmohanty1/payment-watchdog/api/internal/script"
 )
 
 // TestEnterpriseRuleEngineCreation tests the creation of a new enterprise rule engine
@@ -151,6 +154,7 @@ func TestEnterpriseRuleEngineExecution(t *testing.T) {
 	conditionResult := result.Conditions[0]
 	assert.True(t, conditionResult.Met)
 	assert.Equal(t, "high_value", conditionResult.ConditionType)
+	assert.Equal(t, 15000.0, conditionResult.Value, "the observed amount is recorded")
 
 	// Verify actions were executed
 	for _, actionResult := range result.Actions {
@@ -159,6 +163,198 @@ func TestEnterpriseRuleEngineExecution(t *testing.T) {
 	}
 }
 
+// TestEnterpriseRuleEngineShadowRule tests that shadow rules report actions without executing them
+func TestEnterpriseRuleEngineShadowRule(t *testing.T) {
+	engine := NewEnterpriseRuleEngine(zap.NewNop())
+	action := &countingEnterpriseAction{}
+	assert.NoError(t, engine.AddRule(EnterpriseRule{
+		ID:         "shadow_rule",
+		Name:       "Shadow Rule",
+		Priority:   10,
+		Enabled:    true,
+		Shadow:     true,
+		Conditions: []EnterpriseCondition{&MockEnterpriseCondition{}},
+		Actions:    []EnterpriseAction{action},
+	}))
+
+	results, err := engine.ExecuteRules(EnterpriseRuleContext{
+		PaymentFailure: &architecture.PaymentFailure{ID: uuid.New(), OccurredAt: time.Now()},
+		Timestamp:      time.Now(),
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(results))
+	assert.True(t, results[0].Triggered)
+	assert.True(t, results[0].Shadow)
+	assert.Equal(t, 1, len(results[0].Actions))
+	assert.False(t, results[0].Actions[0].Executed)
+	assert.Equal(t, "mock", results[0].Actions[0].ActionType)
+	assert.Equal(t, 0, action.calls, "shadow actions must not run")
+}
+
+// TestEnterpriseRuleEngineDispatchesActions tests that parameterized actions run through the injected dispatcher
+func TestEnterpriseRuleEngineDispatchesActions(t *testing.T) {
+	dispatcher := &recordingDispatcher{}
+	engine, err := NewRuleEngineFactory(zap.NewNop()).CreateEnterpriseRuleEngine(dispatcher)
+	assert.NoError(t, err)
+
+	results, err := engine.ExecuteRules(EnterpriseRuleContext{
+		PaymentFailure: &architecture.PaymentFailure{ID: uuid.New(), Amount: 15000.0, OccurredAt: time.Now()},
+		Timestamp:      time.Now(),
+	})
+	assert.NoError(t, err)
+
+	var highValue *EnterpriseRuleResult
+	for _, result := range results {
+		if result.RuleID == "high_value_payment_rule" {
+			highValue = result
+		}
+	}
+	if assert.NotNil(t, highValue) && assert.True(t, highValue.Triggered) {
+		assert.Equal(t, "immediate_alert", highValue.Actions[0].ActionType)
+		assert.Equal(t, "immediate_alert", highValue.Actions[0].Output["dispatched"])
+	}
+
+	var alert *EnterpriseActionRequest
+	for i := range dispatcher.requests {
+		if dispatcher.requests[i].Type == "immediate_alert" {
+			alert = &dispatcher.requests[i]
+		}
+	}
+	if assert.NotNil(t, alert) {
+		assert.Equal(t, "high_value_payment_rule", alert.RuleID)
+		assert.Equal(t, "sms", alert.Params["channel"])
+		assert.Equal(t, "critical", alert.Params["priority"])
+	}
+}
+
+// TestEnterpriseRuleEngineSharesKeyedConditions tests that a keyed condition used by several rules is evaluated once per execution
+func TestEnterpriseRuleEngineSharesKeyedConditions(t *testing.T) {
+	engine := NewEnterpriseRuleEngine(zap.NewNop())
+	condition := &countingKeyedCondition{}
+	for _, id := range []string{"first", "second"} {
+		assert.NoError(t, engine.AddRule(EnterpriseRule{
+			ID:         id,
+			Name:       id,
+			Priority:   10,
+			Enabled:    true,
+			Conditions: []EnterpriseCondition{condition, &MockEnterpriseCondition{}},
+			Actions:    []EnterpriseAction{&MockEnterpriseAction{}},
+		}))
+	}
+
+	results, err := engine.ExecuteRules(EnterpriseRuleContext{
+		PaymentFailure: &architecture.PaymentFailure{ID: uuid.New(), OccurredAt: time.Now()},
+		Timestamp:      time.Now(),
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(results))
+	assert.Equal(t, 1, condition.calls)
+	for _, result := range results {
+		assert.True(t, result.Triggered)
+		assert.Equal(t, 2, len(result.Conditions))
+		assert.Equal(t, "counting", result.Conditions[0].ConditionType)
+	}
+
+	_, err = engine.ExecuteRules(EnterpriseRuleContext{
+		PaymentFailure: &architecture.PaymentFailure{ID: uuid.New(), OccurredAt: time.Now()},
+		Timestamp:      time.Now(),
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, condition.calls, "results are only shared within one execution")
+}
+
+// TestBusinessHoursConditionUsesCompanyCalendar tests that business hours follow the company's timezone and holidays
+func TestBusinessHoursConditionUsesCompanyCalendar(t *testing.T) {
+	sydney, err := time.LoadLocation("Australia/Sydney")
+	assert.NoError(t, err)
+	christmas := time.Date(2025, 12, 25, 0, 0, 0, 0, time.UTC)
+	company := &EnterpriseCompany{
+		ID:       "company-1",
+		Calendar: calendar.New(sydney, calendar.DefaultHours(), []calendar.Holiday{{Date: christmas, Name: "Christmas Day"}}),
+	}
+	condition := &BusinessHoursCondition{startHour: 9, endHour: 17}
+
+	// 23:30 UTC on Sunday 1 June is 09:30 on Monday in Sydney
+	monday := time.Date(2025, 6, 1, 23, 30, 0, 0, time.UTC)
+	assert.True(t, condition.Evaluate(EnterpriseRuleContext{Company: company, Timestamp: monday}))
+	assert.Equal(t, "Mon 09:30 AEST", condition.ObservedValue(EnterpriseRuleContext{Company: company, Timestamp: monday}))
+	assert.False(t, condition.Evaluate(EnterpriseRuleContext{Timestamp: monday}), "without a calendar hours are UTC")
+
+	christmasMorning := time.Date(2025, 12, 24, 23, 30, 0, 0, time.UTC)
+	assert.False(t, condition.Evaluate(EnterpriseRuleContext{Company: company, Timestamp: christmasMorning}))
+}
+
+// TestEnterpriseRuleEngineRunsCompanyScripts tests that company scripts run as conditions and plan dispatched actions
+func TestEnterpriseRuleEngineRunsCompanyScripts(t *testing.T) {
+	const src = `
+def evaluate(failure, ctx):
+    return failure.amount > 500 and ctx.company.id == "company-1"
+
+def actions(failure, ctx):
+    return [
+        action("schedule_retry", delay = "4h"),
+        action("manager_escalation", reason = "large failure for %s" % failure.customer_name),
+    ]
+`
+	prog, err := script.Compile("large.star", src)
+	assert.NoError(t, err)
+	module, err := prog.Init(context.Background(), nil, script.DefaultLimits)
+	assert.NoError(t, err)
+	broken, err := script.Compile("broken.star", "def evaluate(failure, ctx):\n    return failure.missing\n")
+	assert.NoError(t, err)
+	brokenModule, err := broken.Init(context.Background(), nil, script.DefaultLimits)
+	assert.NoError(t, err)
+
+	dispatcher := &recordingDispatcher{}
+	engine := NewEnterpriseRuleEngine(zap.NewNop())
+	engine.SetActionDispatcher(dispatcher)
+	engine.SetRuleProvider(staticRuleProvider{"company-1": {
+		NewScriptRule("script-large", "large", 50, module, false),
+		NewScriptRule("script-broken", "broken", 40, brokenModule, false),
+		NewScriptRule("script-shadow", "shadow", 30, module, true),
+	}})
+
+	ruleCtx := EnterpriseRuleContext{
+		PaymentFailure: &architecture.PaymentFailure{ID: uuid.New(), Amount: 750, CustomerName: "Acme", OccurredAt: time.Now()},
+		Company:        &EnterpriseCompany{ID: "company-1"},
+		Timestamp:      time.Now(),
+	}
+	results, err := engine.ExecuteRules(ruleCtx)
+	assert.NoError(t, err)
+	if !assert.Equal(t, 3, len(results)) {
+		return
+	}
+
+	large := results[0]
+	assert.Equal(t, "script-large", large.RuleID)
+	assert.True(t, large.Triggered)
+	assert.Equal(t, "script", large.Conditions[0].ConditionType)
+	assert.Equal(t, true, large.Conditions[0].Value.(map[string]interface{})["result"])
+	if assert.Equal(t, 2, len(large.Actions)) {
+		assert.Equal(t, "schedule_retry", large.Actions[0].ActionType)
+		assert.True(t, large.Actions[1].Executed)
+	}
+	assert.Equal(t, 2, len(dispatcher.requests), "shadow scripts plan actions without dispatching them")
+	assert.Equal(t, "large failure for Acme", dispatcher.requests[1].Params["reason"])
+	assert.Equal(t, "script-large", dispatcher.requests[1].RuleID)
+
+	brokenResult := results[1]
+	assert.False(t, brokenResult.Triggered)
+	assert.Contains(t, brokenResult.Conditions[0].Error, "line 2:20: failure has no field \"missing\"")
+
+	shadow := results[2]
+	assert.True(t, shadow.Triggered)
+	if assert.Equal(t, 2, len(shadow.Actions)) {
+		assert.False(t, shadow.Actions[0].Executed)
+		assert.Equal(t, "4h", shadow.Actions[0].Output["delay"])
+	}
+
+	ruleCtx.Company = &EnterpriseCompany{ID: "company-2"}
+	results, err = engine.ExecuteRules(ruleCtx)
+	assert.NoError(t, err)
+	assert.Empty(t, results, "company scripts only run for their own company")
+}
+
 // TestEnterpriseRuleEngineMetrics tests metrics collection
 func TestEnterpriseRuleEngineMetrics(t *testing.T) {
 	logger, _ := zap.NewDevelopment()
@@ -315,6 +511,53 @@ func (a *MockEnterpriseAction) GetPriority() int {
 	return 50
 }
 
+// countingEnterpriseAction counts how often it is executed
+type countingEnterpriseAction struct {
+	MockEnterpriseAction
+	calls int
+}
+
+func (a *countingEnterpriseAction) Execute(ctx EnterpriseRuleContext) error {
+	a.calls++
+	return nil
+}
+
+// countingKeyedCondition is a shareable condition that counts its evaluations
+type countingKeyedCondition struct {
+	MockEnterpriseCondition
+	calls int
+}
+
+func (c *countingKeyedCondition) Evaluate(ctx EnterpriseRuleContext) bool {
+	c.calls++
+	return true
+}
+
+func (c *countingKeyedCondition) GetType() string {
+	return "counting"
+}
+
+func (c *countingKeyedCondition) ConditionKey() string {
+	return "counting"
+}
+
+// recordingDispatcher records dispatched action requests
+type recordingDispatcher struct {
+	requests []EnterpriseActionRequest
+}
+
+func (d *recordingDispatcher) Dispatch(ctx context.Context, ruleCtx EnterpriseRuleContext, request EnterpriseActionRequest) (map[string]interface{}, error) {
+	d.requests = append(d.requests, request)
+	return map[string]interface{}{"dispatched": request.Type}, nil
+}
+
+// staticRuleProvider serves fixed rules per company
+type staticRuleProvider map[string][]EnterpriseRule
+
+func (p staticRuleProvider) CompanyRules(ctx context.Context, companyID string) ([]EnterpriseRule, error) {
+	return p[companyID], nil
+}
+
 // TestEnterpriseRuleEngineIntegration tests integration with concrete rule implementations
 func TestEnterpriseRuleEngineIntegration(t *testing.T) {
 	logger, _ := zap.NewDevelopment()
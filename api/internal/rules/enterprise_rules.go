@@ -2,6 +2,7 @@ package rules
 
 import (
 	"fmt"
+	"strings"
 	"time"
 
 	"go.uber.org/zap"
@@ -120,7 +121,7 @@ func (r *TimeBasedRules) BusinessHoursRule() EnterpriseRule {
 			&BusinessHoursCondition{startHour: 9, endHour: 17},
 		},
 		Actions: []EnterpriseAction{
-			&ScheduleRetryAction{delay: 2 * time.Hour},
+			&ScheduleRetryAction{delay: 2 * time.Hour, businessHours: true},
 			&BusinessHoursNotificationAction{message: "Payment will be processed during business hours"},
 		},
 		Tags:      []string{"business-hours", "scheduling", "retry"},
@@ -185,6 +186,13 @@ func (c *HighValueCondition) Evaluate(ctx EnterpriseRuleContext) bool {
 	return ctx.PaymentFailure.Amount >= c.threshold
 }
 
+func (c *HighValueCondition) ObservedValue(ctx EnterpriseRuleContext) interface{} {
+	if ctx.PaymentFailure == nil {
+		return nil
+	}
+	return ctx.PaymentFailure.Amount
+}
+
 func (c *HighValueCondition) GetType() string {
 	return "high_value"
 }
@@ -193,6 +201,10 @@ func (c *HighValueCondition) GetDescription() string {
 	return fmt.Sprintf("Payment amount >= $%.2f", c.threshold)
 }
 
+func (c *HighValueCondition) ConditionKey() string {
+	return fmt.Sprintf("high_value:%g", c.threshold)
+}
+
 // OverdueCondition checks if payment is overdue by threshold days
 type OverdueCondition struct {
 	thresholdDays int
@@ -206,6 +218,13 @@ func (c *OverdueCondition) Evaluate(ctx EnterpriseRuleContext) bool {
 	return daysOverdue >= float64(c.thresholdDays)
 }
 
+func (c *OverdueCondition) ObservedValue(ctx EnterpriseRuleContext) interface{} {
+	if ctx.PaymentFailure == nil {
+		return nil
+	}
+	return int(time.Since(ctx.PaymentFailure.OccurredAt).Hours() / 24)
+}
+
 func (c *OverdueCondition) GetType() string {
 	return "overdue"
 }
@@ -214,6 +233,10 @@ func (c *OverdueCondition) GetDescription() string {
 	return fmt.Sprintf("Payment overdue by %d+ days", c.thresholdDays)
 }
 
+func (c *OverdueCondition) ConditionKey() string {
+	return fmt.Sprintf("overdue:%d", c.thresholdDays)
+}
+
 // RecurringFailureCondition checks for recurring failures within time window
 type RecurringFailureCondition struct {
 	minFailures int
@@ -237,18 +260,43 @@ func (c *RecurringFailureCondition) GetDescription() string {
 	return fmt.Sprintf("Recurring failures: %d+ in %v", c.minFailures, c.timeWindow)
 }
 
-// BusinessHoursCondition checks if current time is within business hours
+func (c *RecurringFailureCondition) ConditionKey() string {
+	return fmt.Sprintf("recurring_failure:%d:%v", c.minFailures, c.timeWindow)
+}
+
+// BusinessHoursCondition checks if the evaluation time is within business hours.
+// It uses the company's calendar when the context has one, so timezone, weekday
+// hours and holidays apply; otherwise startHour to endHour UTC on any day.
 type BusinessHoursCondition struct {
 	startHour int
 	endHour   int
 }
 
 func (c *BusinessHoursCondition) Evaluate(ctx EnterpriseRuleContext) bool {
-	now := time.Now()
+	now := c.evaluationTime(ctx)
+	if ctx.Company != nil && ctx.Company.Calendar != nil {
+		return ctx.Company.Calendar.IsOpen(now)
+	}
 	hour := now.Hour()
 	return hour >= c.startHour && hour < c.endHour
 }
 
+func (c *BusinessHoursCondition) ObservedValue(ctx EnterpriseRuleContext) interface{} {
+	now := c.evaluationTime(ctx)
+	if ctx.Company != nil && ctx.Company.Calendar != nil {
+		now = now.In(ctx.Company.Calendar.Location())
+	}
+	return now.Format("Mon 15:04 MST")
+}
+
+// evaluationTime is the context timestamp, or now for contexts without one
+func (c *BusinessHoursCondition) evaluationTime(ctx EnterpriseRuleContext) time.Time {
+	if ctx.Timestamp.IsZero() {
+		return time.Now().UTC()
+	}
+	return ctx.Timestamp.UTC()
+}
+
 func (c *BusinessHoursCondition) GetType() string {
 	return "business_hours"
 }
@@ -257,6 +305,10 @@ func (c *BusinessHoursCondition) GetDescription() string {
 	return fmt.Sprintf("Business hours: %d:00-%d:00", c.startHour, c.endHour)
 }
 
+func (c *BusinessHoursCondition) ConditionKey() string {
+	return fmt.Sprintf("business_hours:%d:%d", c.startHour, c.endHour)
+}
+
 // FraudPatternCondition checks for fraud patterns
 type FraudPatternCondition struct {
 	riskThreshold float64
@@ -272,6 +324,13 @@ func (c *FraudPatternCondition) Evaluate(ctx EnterpriseRuleContext) bool {
 	return riskScore >= c.riskThreshold
 }
 
+func (c *FraudPatternCondition) ObservedValue(ctx EnterpriseRuleContext) interface{} {
+	if ctx.PaymentFailure == nil {
+		return nil
+	}
+	return c.calculateFraudRisk(ctx)
+}
+
 func (c *FraudPatternCondition) GetType() string {
 	return "fraud_pattern"
 }
@@ -280,6 +339,10 @@ func (c *FraudPatternCondition) GetDescription() string {
 	return fmt.Sprintf("Fraud risk score >= %.2f", c.riskThreshold)
 }
 
+func (c *FraudPatternCondition) ConditionKey() string {
+	return fmt.Sprintf("fraud_pattern:%g", c.riskThreshold)
+}
+
 func (c *FraudPatternCondition) calculateFraudRisk(ctx EnterpriseRuleContext) float64 {
 	// Simplified fraud risk calculation
 	// In reality, this would use ML models and multiple factors
@@ -307,6 +370,42 @@ func (c *FraudPatternCondition) calculateFraudRisk(ctx EnterpriseRuleContext) fl
 	return risk
 }
 
+// CustomerSegmentCondition checks whether the customer is in any of the given
+// segments, as resolved for the rule context
+type CustomerSegmentCondition struct {
+	segments []string
+}
+
+// NewCustomerSegmentCondition creates a condition met for customers in any of segments
+func NewCustomerSegmentCondition(segments ...string) *CustomerSegmentCondition {
+	return &CustomerSegmentCondition{segments: segments}
+}
+
+func (c *CustomerSegmentCondition) Evaluate(ctx EnterpriseRuleContext) bool {
+	for _, segment := range c.segments {
+		if containsString(ctx.CustomerSegments, segment) {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *CustomerSegmentCondition) ObservedValue(ctx EnterpriseRuleContext) interface{} {
+	return ctx.CustomerSegments
+}
+
+func (c *CustomerSegmentCondition) GetType() string {
+	return "customer_segment"
+}
+
+func (c *CustomerSegmentCondition) GetDescription() string {
+	return fmt.Sprintf("Customer in segment %s", strings.Join(c.segments, " or "))
+}
+
+func (c *CustomerSegmentCondition) ConditionKey() string {
+	return "customer_segment:" + strings.Join(c.segments, ",")
+}
+
 // Concrete Action Implementations
 
 // ImmediateAlertAction sends immediate alerts
@@ -320,6 +419,10 @@ func (a *ImmediateAlertAction) Execute(ctx EnterpriseRuleContext) error {
 	return nil
 }
 
+func (a *ImmediateAlertAction) ActionParams() map[string]interface{} {
+	return map[string]interface{}{"channel": a.channel, "priority": a.priority}
+}
+
 func (a *ImmediateAlertAction) GetType() string {
 	return "immediate_alert"
 }
@@ -342,6 +445,10 @@ func (a *ManagerEscalationAction) Execute(ctx EnterpriseRuleContext) error {
 	return nil
 }
 
+func (a *ManagerEscalationAction) ActionParams() map[string]interface{} {
+	return map[string]interface{}{"level": a.level}
+}
+
 func (a *ManagerEscalationAction) GetType() string {
 	return "manager_escalation"
 }
@@ -365,6 +472,10 @@ func (a *CustomerContactAction) Execute(ctx EnterpriseRuleContext) error {
 	return nil
 }
 
+func (a *CustomerContactAction) ActionParams() map[string]interface{} {
+	return map[string]interface{}{"method": a.method, "urgency": a.urgency}
+}
+
 func (a *CustomerContactAction) GetType() string {
 	return "customer_contact"
 }
@@ -387,6 +498,10 @@ func (a *CollectionAgencyAction) Execute(ctx EnterpriseRuleContext) error {
 	return nil
 }
 
+func (a *CollectionAgencyAction) ActionParams() map[string]interface{} {
+	return map[string]interface{}{"trigger": a.trigger}
+}
+
 func (a *CollectionAgencyAction) GetType() string {
 	return "collection_agency"
 }
@@ -409,6 +524,10 @@ func (a *CustomerEducationAction) Execute(ctx EnterpriseRuleContext) error {
 	return nil
 }
 
+func (a *CustomerEducationAction) ActionParams() map[string]interface{} {
+	return map[string]interface{}{"content": a.content}
+}
+
 func (a *CustomerEducationAction) GetType() string {
 	return "customer_education"
 }
@@ -431,6 +550,10 @@ func (a *AlternativePaymentAction) Execute(ctx EnterpriseRuleContext) error {
 	return nil
 }
 
+func (a *AlternativePaymentAction) ActionParams() map[string]interface{} {
+	return map[string]interface{}{"methods": a.methods}
+}
+
 func (a *AlternativePaymentAction) GetType() string {
 	return "alternative_payment"
 }
@@ -443,9 +566,11 @@ func (a *AlternativePaymentAction) GetPriority() int {
 	return 50
 }
 
-// ScheduleRetryAction schedules payment retry
+// ScheduleRetryAction schedules payment retry. With businessHours set the retry is
+// moved to the company's next opening time after the delay.
 type ScheduleRetryAction struct {
-	delay time.Duration
+	delay         time.Duration
+	businessHours bool
 }
 
 func (a *ScheduleRetryAction) Execute(ctx EnterpriseRuleContext) error {
@@ -453,6 +578,14 @@ func (a *ScheduleRetryAction) Execute(ctx EnterpriseRuleContext) error {
 	return nil
 }
 
+func (a *ScheduleRetryAction) ActionParams() map[string]interface{} {
+	params := map[string]interface{}{"delay": a.delay.String()}
+	if a.businessHours {
+		params["business_hours"] = true
+	}
+	return params
+}
+
 func (a *ScheduleRetryAction) GetType() string {
 	return "schedule_retry"
 }
@@ -475,6 +608,10 @@ func (a *BusinessHoursNotificationAction) Execute(ctx EnterpriseRuleContext) err
 	return nil
 }
 
+func (a *BusinessHoursNotificationAction) ActionParams() map[string]interface{} {
+	return map[string]interface{}{"message": a.message}
+}
+
 func (a *BusinessHoursNotificationAction) GetType() string {
 	return "business_hours_notification"
 }
@@ -497,6 +634,10 @@ func (a *BlockTransactionAction) Execute(ctx EnterpriseRuleContext) error {
 	return nil
 }
 
+func (a *BlockTransactionAction) ActionParams() map[string]interface{} {
+	return map[string]interface{}{"reason": a.reason}
+}
+
 func (a *BlockTransactionAction) GetType() string {
 	return "block_transaction"
 }
@@ -519,6 +660,10 @@ func (a *SecurityAlertAction) Execute(ctx EnterpriseRuleContext) error {
 	return nil
 }
 
+func (a *SecurityAlertAction) ActionParams() map[string]interface{} {
+	return map[string]interface{}{"level": a.level}
+}
+
 func (a *SecurityAlertAction) GetType() string {
 	return "security_alert"
 }
@@ -541,6 +686,10 @@ func (a *FraudInvestigationAction) Execute(ctx EnterpriseRuleContext) error {
 	return nil
 }
 
+func (a *FraudInvestigationAction) ActionParams() map[string]interface{} {
+	return map[string]interface{}{"priority": a.priority}
+}
+
 func (a *FraudInvestigationAction) GetType() string {
 	return "fraud_investigation"
 }
@@ -59,3 +59,31 @@ func (f *RuleEngineFactory) CreateEmptyBasicRuleEngine() RuleEngine {
 	f.logger.Info("Empty basic rule engine created")
 	return NewRuleEngineAdapter(engine)
 }
+
+// CreateEnterpriseRuleEngine creates an enterprise rule engine with the standard
+// risk, time and pattern based rules. Their actions are performed by dispatcher,
+// or only logged when dispatcher is nil.
+func (f *RuleEngineFactory) CreateEnterpriseRuleEngine(dispatcher EnterpriseActionDispatcher) (*EnterpriseRuleEngine, error) {
+	engine := NewEnterpriseRuleEngine(f.logger)
+	engine.SetActionDispatcher(dispatcher)
+
+	riskRules := NewRiskBasedRules(f.logger)
+	timeRules := NewTimeBasedRules(f.logger)
+	patternRules := NewPatternBasedRules(f.logger)
+	for _, rule := range []EnterpriseRule{
+		riskRules.HighValuePaymentRule(),
+		riskRules.OverduePaymentRule(),
+		riskRules.RecurringFailureRule(),
+		timeRules.BusinessHoursRule(),
+		patternRules.FraudDetectionRule(),
+	} {
+		if err := engine.AddRule(rule); err != nil {
+			return nil, err
+		}
+	}
+
+	f.logger.Info("Enterprise rule engine created",
+		zap.Int("total_rules", len(engine.GetRules())),
+		zap.Bool("actions_dispatched", dispatcher != nil))
+	return engine, nil
+}
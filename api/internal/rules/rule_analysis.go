@@ -0,0 +1,560 @@
+package rules
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Rule analysis issue kinds
+const (
+	RuleIssueOverlap       = "overlap"
+	RuleIssueContradiction = "contradiction"
+	RuleIssueUnreachable   = "unreachable"
+	RuleIssueUnused        = "unused"
+)
+
+// Rule analysis issue severities
+const (
+	RuleIssueInfo    = "info"
+	RuleIssueWarning = "warning"
+)
+
+// MinEvaluationsForUnused is how many recent evaluations a rule needs without
+// triggering before analysis reports it as unused
+const MinEvaluationsForUnused = 100
+
+// analysisTermLimit bounds the disjunctive normal form of a condition. Rules with
+// larger conditions are skipped rather than analysed slowly.
+const analysisTermLimit = 64
+
+// RuleIssue is one finding of rule set analysis
+type RuleIssue struct {
+	Kind     string   `json:"kind"`
+	Severity string   `json:"severity"`
+	Rules    []string `json:"rules"`
+	Message  string   `json:"message"`
+}
+
+// RuleUsage counts how often a rule was recently evaluated and triggered
+type RuleUsage struct {
+	Evaluated int64 `json:"evaluated"`
+	Triggered int64 `json:"triggered"`
+}
+
+// RuleSetAnalysis is the result of analysing a rule set
+type RuleSetAnalysis struct {
+	Rules   int         `json:"rules"`
+	Issues  []RuleIssue `json:"issues"`
+	Skipped []string    `json:"skipped,omitempty"` // rules whose conditions are too large to reason about
+}
+
+// AnalyzeRuleSet looks for live rules that overlap, take contradictory actions on
+// the same failures, can never run, or have not triggered recently according to
+// usage, which may be nil. Rules are considered in evaluation order: priority
+// first, then the order given.
+//
+// Conditions are reasoned about symbolically. Field comparisons are understood;
+// expressions and text matching are treated as opaque predicates that are only
+// equal to themselves, so rules are only compared when they test the same
+// expressions.
+func AnalyzeRuleSet(defs []RuleDefinition, catalogue *ActionCatalogue, usage map[string]RuleUsage) *RuleSetAnalysis {
+	type liveRule struct {
+		def      *RuleDefinition
+		terms    []conditionTerm
+		terminal string
+	}
+
+	analysis := &RuleSetAnalysis{Rules: len(defs), Issues: []RuleIssue{}}
+	var live []liveRule
+	for i := range defs {
+		def := &defs[i]
+		if !def.Enabled || def.Shadow {
+			continue
+		}
+
+		if conflicts := actionConflicts(catalogue, def.Actions, def.Actions); len(conflicts) > 0 {
+			analysis.add(RuleIssueContradiction, RuleIssueWarning, fmt.Sprintf("Rule %q takes contradictory actions: %s",
+				def.Name, strings.Join(conflicts, ", ")), def.Name)
+		}
+		if u, ok := usage[def.Name]; ok && u.Evaluated >= MinEvaluationsForUnused && u.Triggered == 0 {
+			analysis.add(RuleIssueUnused, RuleIssueInfo, fmt.Sprintf("Rule %q did not trigger in its last %d evaluations",
+				def.Name, u.Evaluated), def.Name)
+		}
+
+		terms, ok := conditionTerms(&def.When, false)
+		if !ok {
+			analysis.Skipped = append(analysis.Skipped, def.Name)
+			continue
+		}
+		if len(terms) == 0 {
+			analysis.add(RuleIssueUnreachable, RuleIssueWarning, fmt.Sprintf("Rule %q can never match: its condition contradicts itself",
+				def.Name), def.Name)
+			continue
+		}
+
+		rule := liveRule{def: def, terms: terms}
+		for _, action := range def.Actions {
+			if actionType, _ := catalogue.Get(action.Type); actionType.Terminal {
+				rule.terminal = action.Type
+				break
+			}
+		}
+		live = append(live, rule)
+	}
+	sort.SliceStable(live, func(i, j int) bool { return live[i].def.Priority > live[j].def.Priority })
+
+	unreachable := make(map[string]bool)
+	for j := range live {
+		later := live[j]
+		for i := 0; i < j; i++ {
+			earlier := live[i]
+			if unreachable[earlier.def.Name] || !termsOverlap(earlier.terms, later.terms) {
+				continue
+			}
+			names := []string{earlier.def.Name, later.def.Name}
+			covered := termsWithin(later.terms, earlier.terms)
+
+			if earlier.terminal != "" {
+				if covered {
+					unreachable[later.def.Name] = true
+					analysis.add(RuleIssueUnreachable, RuleIssueWarning, fmt.Sprintf("Rule %q never runs: every failure it matches is first matched by %q, whose %s action stops evaluation",
+						later.def.Name, earlier.def.Name, earlier.terminal), names...)
+					break
+				}
+				analysis.add(RuleIssueOverlap, RuleIssueWarning, fmt.Sprintf("Rule %q stops evaluation with %s before %q runs for some of the failures both match",
+					earlier.def.Name, earlier.terminal, later.def.Name), names...)
+				continue
+			}
+
+			if conflicts := actionConflicts(catalogue, earlier.def.Actions, later.def.Actions); len(conflicts) > 0 {
+				analysis.add(RuleIssueContradiction, RuleIssueWarning, fmt.Sprintf("Rules %q and %q match the same failures but take contradictory actions: %s",
+					earlier.def.Name, later.def.Name, strings.Join(conflicts, ", ")), names...)
+				continue
+			}
+
+			var message string
+			switch {
+			case covered && termsWithin(earlier.terms, later.terms):
+				message = fmt.Sprintf("Rules %q and %q have equivalent conditions", earlier.def.Name, later.def.Name)
+			case covered:
+				message = fmt.Sprintf("Every failure matched by %q is also matched by %q", later.def.Name, earlier.def.Name)
+			case termsWithin(earlier.terms, later.terms):
+				message = fmt.Sprintf("Every failure matched by %q is also matched by %q", earlier.def.Name, later.def.Name)
+			default:
+				message = fmt.Sprintf("Rules %q and %q match some of the same failures", earlier.def.Name, later.def.Name)
+			}
+			analysis.add(RuleIssueOverlap, RuleIssueInfo, message, names...)
+		}
+	}
+	return analysis
+}
+
+func (a *RuleSetAnalysis) add(kind, severity, message string, rules ...string) {
+	a.Issues = append(a.Issues, RuleIssue{Kind: kind, Severity: severity, Rules: rules, Message: message})
+}
+
+// actionConflicts lists the contradictory pairs between two lists of actions
+func actionConflicts(catalogue *ActionCatalogue, left, right []ActionSpec) []string {
+	seen := make(map[string]bool)
+	var conflicts []string
+	for _, a := range left {
+		for _, b := range right {
+			if !catalogue.Contradicts(a.Type, b.Type) {
+				continue
+			}
+			pair := a.Type + " vs " + b.Type
+			if a.Type > b.Type {
+				pair = b.Type + " vs " + a.Type
+			}
+			if !seen[pair] {
+				seen[pair] = true
+				conflicts = append(conflicts, pair)
+			}
+		}
+	}
+	return conflicts
+}
+
+// valueRange is what a conjunction of comparisons allows for one field: an
+// interval for numbers and times, and sets of allowed and excluded values
+type valueRange struct {
+	hasLo, hasHi   bool
+	lo, hi         float64
+	loOpen, hiOpen bool
+	allowed        map[string]interface{} // nil when not restricted to a set
+	excluded       map[string]interface{}
+}
+
+// conditionTerm is a conjunction of field ranges and opaque predicates. Opaque
+// predicates are keyed by their source and map to their polarity.
+type conditionTerm struct {
+	ranges map[string]*valueRange
+	opaque map[string]bool
+	never  bool
+}
+
+// conditionTerms expands a condition, negated when negate is set, into a
+// disjunction of terms that can match. ok is false when the condition is too large.
+func conditionTerms(node *ConditionNode, negate bool) ([]conditionTerm, bool) {
+	switch {
+	case node.All != nil || node.Any != nil:
+		children, conjunctive := node.All, true
+		if node.Any != nil {
+			children, conjunctive = node.Any, false
+		}
+		// De Morgan: a negated conjunction is a disjunction of negations
+		if negate {
+			conjunctive = !conjunctive
+		}
+
+		var result []conditionTerm
+		if conjunctive {
+			result = []conditionTerm{{}}
+		}
+		for i := range children {
+			terms, ok := conditionTerms(&children[i], negate)
+			if !ok {
+				return nil, false
+			}
+			if conjunctive {
+				var product []conditionTerm
+				for _, left := range result {
+					for _, right := range terms {
+						if merged := left.and(right); !merged.never {
+							product = append(product, merged)
+						}
+					}
+					if len(product) > analysisTermLimit {
+						return nil, false
+					}
+				}
+				result = product
+			} else {
+				result = append(result, terms...)
+			}
+		}
+		if len(result) > analysisTermLimit {
+			return nil, false
+		}
+		return result, true
+
+	case node.Not != nil:
+		return conditionTerms(node.Not, !negate)
+	}
+
+	term := comparisonTerm(node, negate)
+	if term.never {
+		return nil, true
+	}
+	return []conditionTerm{term}, true
+}
+
+// negatedOperators maps each comparison to its complement
+var negatedOperators = map[string]string{
+	"eq": "ne", "ne": "eq",
+	"gt": "lte", "lte": "gt",
+	"gte": "lt", "lt": "gte",
+	"in": "not_in", "not_in": "in",
+}
+
+// comparisonTerm turns a single comparison or expression into a term
+func comparisonTerm(node *ConditionNode, negate bool) conditionTerm {
+	opaque := func(key string, polarity bool) conditionTerm {
+		return conditionTerm{opaque: map[string]bool{key: polarity != negate}}
+	}
+	if node.Expr != "" {
+		return opaque("expr "+strings.TrimSpace(node.Expr), true)
+	}
+
+	field, known := EventFields[node.Field]
+	op := node.Operator
+	if op == "exists" {
+		want, ok := node.Value.(bool)
+		if node.Value == nil {
+			want, ok = true, true
+		}
+		if ok {
+			return opaque(node.Field+" exists", want)
+		}
+	}
+	if !known || negatedOperators[op] == "" || (field.Kind != FieldKindNumber && field.Kind != FieldKindTime && field.Kind != FieldKindString) {
+		return opaque(fmt.Sprintf("%s %s %v", node.Field, node.Operator, node.Value), true)
+	}
+	if negate {
+		op = negatedOperators[op]
+	}
+
+	name, scale := node.Field, 1.0
+	// amount_cents is the same quantity as amount
+	if name == "amount_cents" {
+		name, scale = "amount", 0.01
+	}
+
+	var values []interface{}
+	if list, ok := node.Value.([]interface{}); ok && (op == "in" || op == "not_in") {
+		values = list
+	} else {
+		values = []interface{}{node.Value}
+	}
+	normalised := make(map[string]interface{}, len(values))
+	for _, raw := range values {
+		v, err := coerceValue(raw, field.Kind)
+		if err != nil {
+			return opaque(fmt.Sprintf("%s %s %v", node.Field, node.Operator, node.Value), true)
+		}
+		v = rangeValue(v, scale)
+		normalised[rangeKey(v)] = v
+	}
+
+	r := &valueRange{}
+	switch op {
+	case "in", "eq":
+		r.allowed = normalised
+	case "not_in", "ne":
+		r.excluded = normalised
+	default:
+		if field.Kind == FieldKindString {
+			return opaque(fmt.Sprintf("%s %s %v", node.Field, node.Operator, node.Value), true)
+		}
+		for _, v := range normalised {
+			bound := v.(float64)
+			switch op {
+			case "gt", "gte":
+				r.hasLo, r.lo, r.loOpen = true, bound, op == "gt"
+			case "lt", "lte":
+				r.hasHi, r.hi, r.hiOpen = true, bound, op == "lt"
+			}
+		}
+	}
+	return conditionTerm{ranges: map[string]*valueRange{name: r}}
+}
+
+// rangeValue normalises numbers and times to float64 so they can be ordered
+func rangeValue(v interface{}, scale float64) interface{} {
+	switch x := v.(type) {
+	case float64:
+		return x * scale
+	case time.Time:
+		return float64(x.Unix())
+	}
+	return v
+}
+
+func rangeKey(v interface{}) string {
+	if f, ok := v.(float64); ok {
+		return "n:" + strconv.FormatFloat(f, 'g', -1, 64)
+	}
+	return fmt.Sprintf("s:%v", v)
+}
+
+// and intersects two terms
+func (t conditionTerm) and(o conditionTerm) conditionTerm {
+	merged := conditionTerm{
+		ranges: make(map[string]*valueRange, len(t.ranges)+len(o.ranges)),
+		opaque: make(map[string]bool, len(t.opaque)+len(o.opaque)),
+		never:  t.never || o.never,
+	}
+	for key, polarity := range t.opaque {
+		merged.opaque[key] = polarity
+	}
+	for key, polarity := range o.opaque {
+		if existing, ok := merged.opaque[key]; ok && existing != polarity {
+			merged.never = true
+		}
+		merged.opaque[key] = polarity
+	}
+	for name, r := range t.ranges {
+		merged.ranges[name] = r
+	}
+	for name, r := range o.ranges {
+		if existing, ok := merged.ranges[name]; ok {
+			r = existing.intersect(r)
+		}
+		merged.ranges[name] = r
+		if !r.satisfiable() {
+			merged.never = true
+		}
+	}
+	return merged
+}
+
+// within reports whether every failure matching t also matches o
+func (t conditionTerm) within(o conditionTerm) bool {
+	if t.never {
+		return true
+	}
+	for key, polarity := range o.opaque {
+		if existing, ok := t.opaque[key]; !ok || existing != polarity {
+			return false
+		}
+	}
+	for name, outer := range o.ranges {
+		inner, ok := t.ranges[name]
+		if !ok || !inner.within(outer) {
+			return false
+		}
+	}
+	return true
+}
+
+// opaqueWithin reports whether t's opaque predicates are a subset of o's
+func (t conditionTerm) opaqueWithin(o conditionTerm) bool {
+	for key, polarity := range t.opaque {
+		if existing, ok := o.opaque[key]; !ok || existing != polarity {
+			return false
+		}
+	}
+	return true
+}
+
+// termsOverlap reports whether two disjunctions share a match. An opaque
+// predicate may contradict any other condition, so only terms with the same
+// opaque predicates are compared.
+func termsOverlap(left, right []conditionTerm) bool {
+	for _, a := range left {
+		for _, b := range right {
+			if a.opaqueWithin(b) && b.opaqueWithin(a) && !a.and(b).never {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// termsWithin reports whether every term of inner is within some term of outer
+func termsWithin(inner, outer []conditionTerm) bool {
+	for _, a := range inner {
+		covered := false
+		for _, b := range outer {
+			if a.within(b) {
+				covered = true
+				break
+			}
+		}
+		if !covered {
+			return false
+		}
+	}
+	return true
+}
+
+func (r *valueRange) intersect(o *valueRange) *valueRange {
+	merged := *r
+	if o.hasLo && (!merged.hasLo || o.lo > merged.lo || (o.lo == merged.lo && o.loOpen)) {
+		merged.hasLo, merged.lo, merged.loOpen = true, o.lo, o.loOpen
+	}
+	if o.hasHi && (!merged.hasHi || o.hi < merged.hi || (o.hi == merged.hi && o.hiOpen)) {
+		merged.hasHi, merged.hi, merged.hiOpen = true, o.hi, o.hiOpen
+	}
+	switch {
+	case r.allowed == nil:
+		merged.allowed = o.allowed
+	case o.allowed != nil:
+		merged.allowed = make(map[string]interface{})
+		for key, v := range r.allowed {
+			if _, ok := o.allowed[key]; ok {
+				merged.allowed[key] = v
+			}
+		}
+	}
+	if len(o.excluded) > 0 {
+		merged.excluded = make(map[string]interface{}, len(r.excluded)+len(o.excluded))
+		for key, v := range r.excluded {
+			merged.excluded[key] = v
+		}
+		for key, v := range o.excluded {
+			merged.excluded[key] = v
+		}
+	}
+	return &merged
+}
+
+// contains reports whether a single value satisfies the range
+func (r *valueRange) contains(v interface{}) bool {
+	key := rangeKey(v)
+	if _, excluded := r.excluded[key]; excluded {
+		return false
+	}
+	if r.allowed != nil {
+		if _, ok := r.allowed[key]; !ok {
+			return false
+		}
+	}
+	if f, numeric := v.(float64); numeric {
+		return r.containsNumber(f)
+	}
+	return !r.hasLo && !r.hasHi
+}
+
+// values returns the finite set of values the range allows, when it is finite
+func (r *valueRange) values() ([]interface{}, bool) {
+	switch {
+	case r.allowed != nil:
+		var values []interface{}
+		for _, v := range r.allowed {
+			if r.contains(v) {
+				values = append(values, v)
+			}
+		}
+		return values, true
+	case r.hasLo && r.hasHi && r.lo == r.hi:
+		if r.contains(r.lo) {
+			return []interface{}{r.lo}, true
+		}
+		return nil, true
+	}
+	return nil, false
+}
+
+func (r *valueRange) satisfiable() bool {
+	if values, finite := r.values(); finite {
+		return len(values) > 0
+	}
+	return !r.hasLo || !r.hasHi || r.lo < r.hi
+}
+
+// within reports whether every value r allows is also allowed by o
+func (r *valueRange) within(o *valueRange) bool {
+	if values, finite := r.values(); finite {
+		for _, v := range values {
+			if !o.contains(v) {
+				return false
+			}
+		}
+		return true
+	}
+
+	if o.allowed != nil {
+		return false
+	}
+	if o.hasLo && (!r.hasLo || r.lo < o.lo || (r.lo == o.lo && o.loOpen && !r.loOpen)) {
+		return false
+	}
+	if o.hasHi && (!r.hasHi || r.hi > o.hi || (r.hi == o.hi && o.hiOpen && !r.hiOpen)) {
+		return false
+	}
+	for key, v := range o.excluded {
+		if _, excluded := r.excluded[key]; excluded {
+			continue
+		}
+		if f, numeric := v.(float64); numeric && !r.containsNumber(f) {
+			continue
+		}
+		return false
+	}
+	return true
+}
+
+// containsNumber reports whether f lies inside the range's interval
+func (r *valueRange) containsNumber(f float64) bool {
+	if r.hasLo && (f < r.lo || (f == r.lo && r.loOpen)) {
+		return false
+	}
+	if r.hasHi && (f > r.hi || (f == r.hi && r.hiOpen)) {
+		return false
+	}
+	return true
+}
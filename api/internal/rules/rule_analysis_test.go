@@ -0,0 +1,137 @@
+package rules
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/sambitmohanty1/payment-watchdog/api/internal/models"
+)
+
+func parseTestDefinitions(t *testing.T, sources ...string) []RuleDefinition {
+	t.Helper()
+	defs := make([]RuleDefinition, len(sources))
+	for i, source := range sources {
+		def, err := ParseRuleDefinition([]byte(source), "json")
+		require.NoError(t, err)
+		require.NoError(t, ValidateRuleDefinition(def, DefaultActionCatalogue()))
+		defs[i] = *def
+	}
+	return defs
+}
+
+func issuesOfKind(analysis *RuleSetAnalysis, kind string) []RuleIssue {
+	var issues []RuleIssue
+	for _, issue := range analysis.Issues {
+		if issue.Kind == kind {
+			issues = append(issues, issue)
+		}
+	}
+	return issues
+}
+
+func TestAnalyzeRuleSetUnreachableBehindTerminalRule(t *testing.T) {
+	defs := parseTestDefinitions(t,
+		`{"name":"block_fraud","priority":100,"when":{"field":"failure_reason","operator":"in","value":["fraudulent","stolen_card"]},"actions":[{"type":"block"}]}`,
+		`{"name":"contact_stolen","priority":10,"when":{"all":[{"field":"failure_reason","operator":"eq","value":"stolen_card"},{"field":"amount","operator":"gt","value":50}]},"actions":[{"type":"customer_contact","params":{"channel":"email"}}]}`,
+		`{"name":"large","priority":5,"when":{"field":"amount_cents","operator":"gte","value":100000},"actions":[{"type":"alert"}]}`,
+	)
+
+	analysis := AnalyzeRuleSet(defs, DefaultActionCatalogue(), nil)
+	unreachable := issuesOfKind(analysis, RuleIssueUnreachable)
+	require.Len(t, unreachable, 1)
+	assert.Equal(t, []string{"block_fraud", "contact_stolen"}, unreachable[0].Rules)
+	assert.Contains(t, unreachable[0].Message, "block action stops evaluation")
+
+	// "large" only partly overlaps block_fraud, so it is not reported as unreachable
+	overlaps := issuesOfKind(analysis, RuleIssueOverlap)
+	require.Len(t, overlaps, 1)
+	assert.Equal(t, []string{"block_fraud", "large"}, overlaps[0].Rules)
+	assert.Equal(t, RuleIssueWarning, overlaps[0].Severity)
+}
+
+func TestAnalyzeRuleSetContradictions(t *testing.T) {
+	defs := parseTestDefinitions(t,
+		`{"name":"retry_declined","priority":50,"when":{"field":"failure_reason","operator":"eq","value":"card_declined"},"actions":[{"type":"retry"}]}`,
+		`{"name":"no_retry_large","priority":40,"when":{"all":[{"field":"failure_reason","operator":"ne","value":"expired_card"},{"field":"amount","operator":"gt","value":1000}]},"actions":[{"type":"no_retry"}]}`,
+		`{"name":"no_retry_small","priority":30,"when":{"all":[{"field":"failure_reason","operator":"eq","value":"insufficient_funds"},{"field":"amount","operator":"lt","value":10}]},"actions":[{"type":"no_retry"}]}`,
+		`{"name":"confused","when":{"expr":"amount > 1"},"actions":[{"type":"retry"},{"type":"no_retry"}]}`,
+	)
+
+	analysis := AnalyzeRuleSet(defs, DefaultActionCatalogue(), nil)
+	contradictions := issuesOfKind(analysis, RuleIssueContradiction)
+	require.Len(t, contradictions, 2)
+	assert.Equal(t, []string{"confused"}, contradictions[0].Rules)
+	assert.Equal(t, []string{"retry_declined", "no_retry_large"}, contradictions[1].Rules)
+	assert.Contains(t, contradictions[1].Message, "no_retry vs retry")
+}
+
+func TestAnalyzeRuleSetOverlaps(t *testing.T) {
+	defs := parseTestDefinitions(t,
+		`{"name":"large","priority":20,"when":{"field":"amount","operator":"gte","value":1000},"actions":[{"type":"alert"}]}`,
+		`{"name":"very_large_aud","priority":10,"when":{"all":[{"field":"amount","operator":"gt","value":5000},{"field":"currency","operator":"eq","value":"AUD"}]},"actions":[{"type":"escalate","params":{"team":"finance"}}]}`,
+		`{"name":"small","priority":5,"when":{"not":{"field":"amount","operator":"gte","value":1000}},"actions":[{"type":"tag","params":{"tags":["small"]}}]}`,
+		`{"name":"expr_a","when":{"expr":"customer.email.endsWith(\"@example.com\")"},"actions":[{"type":"alert"}]}`,
+		`{"name":"expr_b","when":{"expr":"amount > 10"},"actions":[{"type":"alert"}]}`,
+	)
+
+	analysis := AnalyzeRuleSet(defs, DefaultActionCatalogue(), nil)
+	overlaps := issuesOfKind(analysis, RuleIssueOverlap)
+	// "small" is disjoint from "large" and the two unrelated expressions may or may not overlap
+	require.Len(t, overlaps, 1)
+	assert.Equal(t, []string{"large", "very_large_aud"}, overlaps[0].Rules)
+	assert.Equal(t, `Every failure matched by "very_large_aud" is also matched by "large"`, overlaps[0].Message)
+	assert.Equal(t, RuleIssueInfo, overlaps[0].Severity)
+}
+
+func TestAnalyzeRuleSetSelfContradictoryCondition(t *testing.T) {
+	defs := parseTestDefinitions(t,
+		`{"name":"impossible","when":{"all":[{"field":"amount","operator":"gt","value":100},{"field":"amount","operator":"lte","value":50}]},"actions":[{"type":"alert"}]}`,
+		`{"name":"excluded","when":{"all":[{"field":"currency","operator":"in","value":["AUD"]},{"field":"currency","operator":"ne","value":"AUD"}]},"actions":[{"type":"alert"}]}`,
+	)
+
+	analysis := AnalyzeRuleSet(defs, DefaultActionCatalogue(), nil)
+	unreachable := issuesOfKind(analysis, RuleIssueUnreachable)
+	require.Len(t, unreachable, 2)
+	assert.Contains(t, unreachable[0].Message, "can never match")
+	assert.Empty(t, issuesOfKind(analysis, RuleIssueOverlap))
+}
+
+func TestAnalyzeRuleSetUnusedAndIgnoredRules(t *testing.T) {
+	defs := parseTestDefinitions(t,
+		`{"name":"stale","when":{"field":"currency","operator":"eq","value":"XYZ"},"actions":[{"type":"alert"}]}`,
+		`{"name":"fresh","when":{"field":"currency","operator":"eq","value":"AUD"},"actions":[{"type":"alert"}]}`,
+		`{"name":"new","when":{"field":"currency","operator":"eq","value":"NZD"},"actions":[{"type":"alert"}]}`,
+		`{"name":"shadowed","shadow":true,"when":{"field":"currency","operator":"eq","value":"AUD"},"actions":[{"type":"retry"}]}`,
+		`{"name":"disabled","enabled":false,"when":{"field":"currency","operator":"eq","value":"AUD"},"actions":[{"type":"no_retry"}]}`,
+	)
+
+	analysis := AnalyzeRuleSet(defs, DefaultActionCatalogue(), map[string]RuleUsage{
+		"stale": {Evaluated: 500},
+		"fresh": {Evaluated: 500, Triggered: 20},
+		"new":   {Evaluated: 3},
+	})
+	assert.Equal(t, 5, analysis.Rules)
+	require.Len(t, analysis.Issues, 1)
+	assert.Equal(t, RuleIssueUnused, analysis.Issues[0].Kind)
+	assert.Equal(t, []string{"stale"}, analysis.Issues[0].Rules)
+}
+
+func TestTerminalRuleStopsEvaluation(t *testing.T) {
+	defs := parseTestDefinitions(t,
+		`{"name":"block_fraud","priority":100,"when":{"field":"failure_reason","operator":"eq","value":"fraudulent"},"actions":[{"type":"block"}]}`,
+		`{"name":"retry_all","priority":10,"when":{"field":"amount","operator":"gt","value":0},"actions":[{"type":"retry"}]}`,
+	)
+	engine, err := NewRuleEngineFactory(zap.NewNop()).CompileRuleSet(defs, DefaultActionCatalogue())
+	require.NoError(t, err)
+
+	results := engine.ExecuteRules(&models.PaymentFailureEvent{AmountCents: 5000, FailureReason: "fraudulent"})
+	require.Len(t, results, 1)
+	assert.Equal(t, "block_fraud", results[0].RuleName)
+
+	results = engine.ExecuteRules(&models.PaymentFailureEvent{AmountCents: 5000, FailureReason: "card_declined"})
+	require.Len(t, results, 1)
+	assert.Equal(t, "retry_all", results[0].RuleName)
+}
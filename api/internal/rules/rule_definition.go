@@ -0,0 +1,491 @@
+package rules
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/sambitmohanty1/payment-watchdog/api/internal/expr"
+	"github.com/sambitmohanty1/payment-watchdog/api/internal/models"
+)
+
+// ErrInvalidRuleDefinition is returned when a rule definition fails validation
+var ErrInvalidRuleDefinition = errors.New("invalid rule definition")
+
+// RuleDefinition is a rule expressed as data rather than Go code. It is compiled
+// into a Rule for the RuleEngine interface.
+type RuleDefinition struct {
+	Name        string        `json:"name" yaml:"name"`
+	Description string        `json:"description,omitempty" yaml:"description,omitempty"`
+	Priority    int           `json:"priority" yaml:"priority"`
+	Enabled     bool          `json:"enabled" yaml:"enabled"`
+	Shadow      bool          `json:"shadow,omitempty" yaml:"shadow,omitempty"` // log decisions without acting
+	When        ConditionNode `json:"when" yaml:"when"`
+	Actions     []ActionSpec  `json:"actions" yaml:"actions"`
+}
+
+// ConditionNode is either a boolean combination (all, any, not), a single
+// comparison of an event field against a value, or an expression
+type ConditionNode struct {
+	All []ConditionNode `json:"all,omitempty" yaml:"all,omitempty"`
+	Any []ConditionNode `json:"any,omitempty" yaml:"any,omitempty"`
+	Not *ConditionNode  `json:"not,omitempty" yaml:"not,omitempty"`
+
+	// Expr is a boolean expression over the payment failure, e.g.
+	// `amount > 500 && customer.email.endsWith("@example.com")`
+	Expr string `json:"expr,omitempty" yaml:"expr,omitempty"`
+
+	Field    string      `json:"field,omitempty" yaml:"field,omitempty"`
+	Operator string      `json:"operator,omitempty" yaml:"operator,omitempty"`
+	Value    interface{} `json:"value,omitempty" yaml:"value,omitempty"`
+}
+
+// ActionSpec selects an action from the catalogue with its parameters
+type ActionSpec struct {
+	Type   string                 `json:"type" yaml:"type"`
+	Params map[string]interface{} `json:"params,omitempty" yaml:"params,omitempty"`
+}
+
+// ParseRuleDefinition decodes a rule definition from JSON or YAML
+func ParseRuleDefinition(data []byte, format string) (*RuleDefinition, error) {
+	def := &RuleDefinition{Enabled: true}
+	var err error
+	switch strings.ToLower(format) {
+	case "", "json":
+		err = json.Unmarshal(data, def)
+	case "yaml", "yml":
+		err = yaml.Unmarshal(data, def)
+	default:
+		return nil, fmt.Errorf("%w: unsupported format %q", ErrInvalidRuleDefinition, format)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidRuleDefinition, err)
+	}
+	return def, nil
+}
+
+// Field kinds
+const (
+	FieldKindString = "string"
+	FieldKindNumber = "number"
+	FieldKindTime   = "time"
+	FieldKindList   = "list"
+)
+
+// EventField describes a PaymentFailureEvent field that conditions may reference
+type EventField struct {
+	Kind        string
+	Description string
+	Get         func(*models.PaymentFailureEvent) interface{}
+}
+
+// EventFields lists the fields available to rule conditions
+var EventFields = map[string]EventField{
+	"amount": {FieldKindNumber, "Amount in major currency units", func(e *models.PaymentFailureEvent) interface{} {
+		return float64(e.AmountCents) / 100
+	}},
+	"amount_cents":    {FieldKindNumber, "Amount in cents", func(e *models.PaymentFailureEvent) interface{} { return float64(e.AmountCents) }},
+	"currency":        {FieldKindString, "ISO currency code", func(e *models.PaymentFailureEvent) interface{} { return e.Currency }},
+	"provider":        {FieldKindString, "Payment provider", func(e *models.PaymentFailureEvent) interface{} { return e.ProviderID }},
+	"event_type":      {FieldKindString, "Provider event type", func(e *models.PaymentFailureEvent) interface{} { return e.EventType }},
+	"failure_reason":  {FieldKindString, "Normalised failure reason", func(e *models.PaymentFailureEvent) interface{} { return e.FailureReason }},
+	"failure_code":    {FieldKindString, "Provider failure code", func(e *models.PaymentFailureEvent) interface{} { return e.FailureCode }},
+	"failure_message": {FieldKindString, "Provider failure message", func(e *models.PaymentFailureEvent) interface{} { return e.FailureMessage }},
+	"status":          {FieldKindString, "Processing status", func(e *models.PaymentFailureEvent) interface{} { return e.Status }},
+	"customer_id":     {FieldKindString, "Provider customer ID", func(e *models.PaymentFailureEvent) interface{} { return e.CustomerID }},
+	"customer_email":  {FieldKindString, "Customer email", func(e *models.PaymentFailureEvent) interface{} { return e.CustomerEmail }},
+	"customer_name":   {FieldKindString, "Customer name", func(e *models.PaymentFailureEvent) interface{} { return e.CustomerName }},
+	"invoice_number":  {FieldKindString, "Invoice number", func(e *models.PaymentFailureEvent) interface{} { return e.InvoiceNumber }},
+	"retry_count":     {FieldKindNumber, "Retries attempted so far", func(e *models.PaymentFailureEvent) interface{} { return float64(e.RetryCount) }},
+	"tags":            {FieldKindList, "Failure tags", func(e *models.PaymentFailureEvent) interface{} { return e.Tags }},
+	"customer_segments": {FieldKindList, "Customer segments such as vip, recurring, new and hardship", func(e *models.PaymentFailureEvent) interface{} {
+		return e.CustomerSegments
+	}},
+	"due_date": {FieldKindTime, "Invoice due date", func(e *models.PaymentFailureEvent) interface{} {
+		if e.DueDate == nil {
+			return nil
+		}
+		return *e.DueDate
+	}},
+	"days_overdue": {FieldKindNumber, "Whole days past the due date, 0 when not overdue", func(e *models.PaymentFailureEvent) interface{} {
+		if e.DueDate == nil || !time.Now().After(*e.DueDate) {
+			return float64(0)
+		}
+		return math.Floor(time.Since(*e.DueDate).Hours() / 24)
+	}},
+}
+
+// operatorKinds lists the field kinds each comparison operator accepts
+var operatorKinds = map[string][]string{
+	"eq":          {FieldKindString, FieldKindNumber, FieldKindTime},
+	"ne":          {FieldKindString, FieldKindNumber, FieldKindTime},
+	"gt":          {FieldKindNumber, FieldKindTime},
+	"gte":         {FieldKindNumber, FieldKindTime},
+	"lt":          {FieldKindNumber, FieldKindTime},
+	"lte":         {FieldKindNumber, FieldKindTime},
+	"in":          {FieldKindString, FieldKindNumber},
+	"not_in":      {FieldKindString, FieldKindNumber},
+	"contains":    {FieldKindString, FieldKindList},
+	"starts_with": {FieldKindString},
+	"ends_with":   {FieldKindString},
+	"matches":     {FieldKindString},
+	"exists":      {FieldKindString, FieldKindNumber, FieldKindTime, FieldKindList},
+}
+
+// conditionFunc is a compiled condition
+type conditionFunc func(*models.PaymentFailureEvent) bool
+
+// CompileRuleDefinition validates def against the event fields and action catalogue
+// and returns an executable Rule
+func CompileRuleDefinition(def *RuleDefinition, catalogue *ActionCatalogue) (*Rule, error) {
+	if strings.TrimSpace(def.Name) == "" {
+		return nil, fmt.Errorf("%w: name is required", ErrInvalidRuleDefinition)
+	}
+
+	condition, err := compileCondition(&def.When, "when")
+	if err != nil {
+		return nil, err
+	}
+
+	if len(def.Actions) == 0 {
+		return nil, fmt.Errorf("%w: at least one action is required", ErrInvalidRuleDefinition)
+	}
+	actions := make([]boundAction, len(def.Actions))
+	actionTypes := make([]string, len(def.Actions))
+	terminal := false
+	for i, spec := range def.Actions {
+		action, err := catalogue.bind(spec)
+		if err != nil {
+			return nil, fmt.Errorf("%w: actions[%d]: %v", ErrInvalidRuleDefinition, i, err)
+		}
+		actions[i] = action
+		actionTypes[i] = action.Type
+		if actionType, _ := catalogue.Get(action.Type); actionType.Terminal {
+			terminal = true
+		}
+	}
+
+	name := def.Name
+	now := time.Now()
+	return &Rule{
+		ID:          name,
+		Name:        name,
+		Description: def.Description,
+		Priority:    def.Priority,
+		Enabled:     def.Enabled,
+		Shadow:      def.Shadow,
+		Terminal:    terminal,
+		ActionTypes: actionTypes,
+		Condition:   condition,
+		Action: func(event *models.PaymentFailureEvent) (*ActionResult, error) {
+			return runActions(name, actions, event)
+		},
+		CreatedAt: now,
+		UpdatedAt: now,
+	}, nil
+}
+
+// ValidateRuleDefinition reports whether def compiles
+func ValidateRuleDefinition(def *RuleDefinition, catalogue *ActionCatalogue) error {
+	_, err := CompileRuleDefinition(def, catalogue)
+	return err
+}
+
+// CompileRuleSet compiles definitions into a RuleEngine
+func (f *RuleEngineFactory) CompileRuleSet(defs []RuleDefinition, catalogue *ActionCatalogue) (RuleEngine, error) {
+	engine := NewRuleEngineAdapter(NewBasicRuleEngine(f.logger))
+	for i := range defs {
+		rule, err := CompileRuleDefinition(&defs[i], catalogue)
+		if err != nil {
+			return nil, fmt.Errorf("rule %q: %w", defs[i].Name, err)
+		}
+		engine.AddRule(rule)
+	}
+	return engine, nil
+}
+
+func compileCondition(node *ConditionNode, path string) (conditionFunc, error) {
+	groups := 0
+	for _, set := range []bool{node.All != nil, node.Any != nil, node.Not != nil, node.Field != "", node.Expr != ""} {
+		if set {
+			groups++
+		}
+	}
+	if groups != 1 {
+		return nil, fmt.Errorf("%w: %s must have exactly one of all, any, not, field or expr", ErrInvalidRuleDefinition, path)
+	}
+
+	switch {
+	case node.All != nil || node.Any != nil:
+		children, key := node.All, "all"
+		if node.Any != nil {
+			children, key = node.Any, "any"
+		}
+		if len(children) == 0 {
+			return nil, fmt.Errorf("%w: %s.%s is empty", ErrInvalidRuleDefinition, path, key)
+		}
+		compiled := make([]conditionFunc, len(children))
+		for i := range children {
+			c, err := compileCondition(&children[i], fmt.Sprintf("%s.%s[%d]", path, key, i))
+			if err != nil {
+				return nil, err
+			}
+			compiled[i] = c
+		}
+		if key == "all" {
+			return func(e *models.PaymentFailureEvent) bool {
+				for _, c := range compiled {
+					if !c(e) {
+						return false
+					}
+				}
+				return true
+			}, nil
+		}
+		return func(e *models.PaymentFailureEvent) bool {
+			for _, c := range compiled {
+				if c(e) {
+					return true
+				}
+			}
+			return false
+		}, nil
+
+	case node.Not != nil:
+		inner, err := compileCondition(node.Not, path+".not")
+		if err != nil {
+			return nil, err
+		}
+		return func(e *models.PaymentFailureEvent) bool { return !inner(e) }, nil
+
+	case node.Expr != "":
+		return compileExpression(node.Expr, path)
+	}
+
+	return compileComparison(node, path)
+}
+
+// conditionEnv is the expression environment for rule conditions
+var conditionEnv = expr.PaymentFailureEnv()
+
+// compileExpression type checks an expression condition. An expression that fails
+// at runtime, for example on a metadata value of an unexpected type, does not match.
+func compileExpression(src, path string) (conditionFunc, error) {
+	program, err := expr.CompileBool(src, conditionEnv)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s.expr: %v", ErrInvalidRuleDefinition, path, err)
+	}
+	return func(e *models.PaymentFailureEvent) bool {
+		ok, err := program.EvalBool(expr.PaymentFailureActivation(e, time.Now()))
+		return err == nil && ok
+	}, nil
+}
+
+func compileComparison(node *ConditionNode, path string) (conditionFunc, error) {
+	field, ok := EventFields[node.Field]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s: unknown field %q", ErrInvalidRuleDefinition, path, node.Field)
+	}
+	kinds, ok := operatorKinds[node.Operator]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s: unknown operator %q", ErrInvalidRuleDefinition, path, node.Operator)
+	}
+	if !containsString(kinds, field.Kind) {
+		return nil, fmt.Errorf("%w: %s: operator %s does not apply to %s field %s", ErrInvalidRuleDefinition, path, node.Operator, field.Kind, node.Field)
+	}
+
+	get := field.Get
+	fail := func(msg string) error {
+		return fmt.Errorf("%w: %s: %s", ErrInvalidRuleDefinition, path, msg)
+	}
+
+	switch node.Operator {
+	case "exists":
+		want, ok := node.Value.(bool)
+		if node.Value == nil {
+			want, ok = true, true
+		}
+		if !ok {
+			return nil, fail("exists expects a boolean value")
+		}
+		return func(e *models.PaymentFailureEvent) bool { return isPresent(get(e)) == want }, nil
+
+	case "in", "not_in":
+		list, ok := node.Value.([]interface{})
+		if !ok || len(list) == 0 {
+			return nil, fail(node.Operator + " expects a non-empty list")
+		}
+		set := make([]interface{}, len(list))
+		for i, item := range list {
+			v, err := coerceValue(item, field.Kind)
+			if err != nil {
+				return nil, fail(err.Error())
+			}
+			set[i] = v
+		}
+		negate := node.Operator == "not_in"
+		return func(e *models.PaymentFailureEvent) bool {
+			actual := get(e)
+			for _, v := range set {
+				if actual == v {
+					return !negate
+				}
+			}
+			return negate
+		}, nil
+
+	case "matches":
+		pattern, ok := node.Value.(string)
+		if !ok {
+			return nil, fail("matches expects a regular expression string")
+		}
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fail(fmt.Sprintf("invalid regular expression: %v", err))
+		}
+		return func(e *models.PaymentFailureEvent) bool {
+			s, _ := get(e).(string)
+			return re.MatchString(s)
+		}, nil
+
+	case "contains":
+		want, ok := node.Value.(string)
+		if !ok {
+			return nil, fail("contains expects a string value")
+		}
+		if field.Kind == FieldKindList {
+			return func(e *models.PaymentFailureEvent) bool {
+				list, _ := get(e).([]string)
+				return containsString(list, want)
+			}, nil
+		}
+		return func(e *models.PaymentFailureEvent) bool {
+			s, _ := get(e).(string)
+			return strings.Contains(strings.ToLower(s), strings.ToLower(want))
+		}, nil
+
+	case "starts_with", "ends_with":
+		want, ok := node.Value.(string)
+		if !ok {
+			return nil, fail(node.Operator + " expects a string value")
+		}
+		test := strings.HasPrefix
+		if node.Operator == "ends_with" {
+			test = strings.HasSuffix
+		}
+		return func(e *models.PaymentFailureEvent) bool {
+			s, _ := get(e).(string)
+			return test(s, want)
+		}, nil
+	}
+
+	// eq, ne, gt, gte, lt, lte
+	want, err := coerceValue(node.Value, field.Kind)
+	if err != nil {
+		return nil, fail(err.Error())
+	}
+	op := node.Operator
+	return func(e *models.PaymentFailureEvent) bool {
+		actual := get(e)
+		if actual == nil {
+			return op == "ne"
+		}
+		cmp := compareValues(actual, want)
+		switch op {
+		case "eq":
+			return cmp == 0
+		case "ne":
+			return cmp != 0
+		case "gt":
+			return cmp > 0
+		case "gte":
+			return cmp >= 0
+		case "lt":
+			return cmp < 0
+		}
+		return cmp <= 0
+	}, nil
+}
+
+// coerceValue converts a decoded JSON/YAML literal to the Go type used for kind, so
+// that comparisons never depend on whether a number arrived as int or float64
+func coerceValue(v interface{}, kind string) (interface{}, error) {
+	switch kind {
+	case FieldKindNumber:
+		switch n := v.(type) {
+		case float64:
+			return n, nil
+		case int:
+			return float64(n), nil
+		case int64:
+			return float64(n), nil
+		case json.Number:
+			return n.Float64()
+		}
+		return nil, fmt.Errorf("expected a number, got %T", v)
+	case FieldKindTime:
+		switch t := v.(type) {
+		case time.Time:
+			return t, nil
+		case string:
+			for _, layout := range []string{time.RFC3339, "2006-01-02"} {
+				if parsed, err := time.Parse(layout, t); err == nil {
+					return parsed, nil
+				}
+			}
+		}
+		return nil, fmt.Errorf("expected an RFC3339 or YYYY-MM-DD date, got %v", v)
+	}
+	s, ok := v.(string)
+	if !ok {
+		return nil, fmt.Errorf("expected a string, got %T", v)
+	}
+	return s, nil
+}
+
+// compareValues orders two values of the same coerced kind
+func compareValues(a, b interface{}) int {
+	switch x := a.(type) {
+	case float64:
+		y := b.(float64)
+		switch {
+		case x < y:
+			return -1
+		case x > y:
+			return 1
+		}
+		return 0
+	case time.Time:
+		return x.Compare(b.(time.Time))
+	case string:
+		return strings.Compare(x, b.(string))
+	}
+	return 1
+}
+
+func isPresent(v interface{}) bool {
+	switch x := v.(type) {
+	case nil:
+		return false
+	case string:
+		return x != ""
+	case []string:
+		return len(x) > 0
+	}
+	return true
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,213 @@
+package rules
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/sambitmohanty1/payment-watchdog/api/internal/models"
+)
+
+const highValueRuleYAML = `
+name: high_value_card_declined
+priority: 150
+when:
+  all:
+    - field: amount
+      operator: gt
+      value: 5000
+    - any:
+        - field: failure_reason
+          operator: in
+          value: [card_declined, do_not_honor]
+        - field: tags
+          operator: contains
+          value: vip
+    - not:
+        field: currency
+        operator: eq
+        value: USD
+actions:
+  - type: alert
+    params:
+      severity: high
+  - type: customer_contact
+    params:
+      channel: email
+      template: card_declined_high_value
+`
+
+func TestCompileRuleDefinitionFromYAML(t *testing.T) {
+	def, err := ParseRuleDefinition([]byte(highValueRuleYAML), "yaml")
+	require.NoError(t, err)
+	assert.True(t, def.Enabled, "enabled defaults to true")
+
+	rule, err := CompileRuleDefinition(def, DefaultActionCatalogue())
+	require.NoError(t, err)
+
+	event := &models.PaymentFailureEvent{
+		ID:            uuid.New(),
+		AmountCents:   750000,
+		Currency:      "AUD",
+		FailureReason: "card_declined",
+	}
+	assert.True(t, rule.Condition(event))
+
+	event.FailureReason = "insufficient_funds"
+	assert.False(t, rule.Condition(event))
+	event.Tags = []string{"vip"}
+	assert.True(t, rule.Condition(event))
+
+	event.Currency = "USD"
+	assert.False(t, rule.Condition(event))
+
+	event.Currency = "AUD"
+	event.AmountCents = 500000
+	assert.False(t, rule.Condition(event), "gt is strict")
+
+	result, err := rule.Action(event)
+	require.NoError(t, err)
+	assert.Equal(t, "alert", result.Data["action"])
+	actions := result.Data["actions"].([]map[string]interface{})
+	require.Len(t, actions, 2)
+	assert.Equal(t, "high", actions[0]["severity"])
+	assert.Equal(t, "normal", actions[1]["urgency"], "defaults are applied")
+}
+
+func TestCompileRuleDefinitionNumberCoercion(t *testing.T) {
+	// JSON decodes numbers as float64 while YAML produces int; both must compare equal
+	for _, doc := range []struct{ source, format string }{
+		{`{"name":"r","when":{"field":"retry_count","operator":"in","value":[2,3]},"actions":[{"type":"no_retry"}]}`, "json"},
+		{"name: r\nwhen: {field: retry_count, operator: in, value: [2, 3]}\nactions: [{type: no_retry}]", "yaml"},
+	} {
+		def, err := ParseRuleDefinition([]byte(doc.source), doc.format)
+		require.NoError(t, err)
+		rule, err := CompileRuleDefinition(def, DefaultActionCatalogue())
+		require.NoError(t, err)
+		assert.True(t, rule.Condition(&models.PaymentFailureEvent{RetryCount: 3}), doc.format)
+		assert.False(t, rule.Condition(&models.PaymentFailureEvent{RetryCount: 1}), doc.format)
+	}
+}
+
+func TestCompileRuleDefinitionValidation(t *testing.T) {
+	catalogue := DefaultActionCatalogue()
+	tests := map[string]string{
+		"unknown field":      `{"name":"r","when":{"field":"nope","operator":"eq","value":1},"actions":[{"type":"no_retry"}]}`,
+		"unknown operator":   `{"name":"r","when":{"field":"amount","operator":"approx","value":1},"actions":[{"type":"no_retry"}]}`,
+		"operator kind":      `{"name":"r","when":{"field":"amount","operator":"starts_with","value":"1"},"actions":[{"type":"no_retry"}]}`,
+		"value type":         `{"name":"r","when":{"field":"amount","operator":"gt","value":"lots"},"actions":[{"type":"no_retry"}]}`,
+		"bad regex":          `{"name":"r","when":{"field":"customer_email","operator":"matches","value":"("},"actions":[{"type":"no_retry"}]}`,
+		"mixed node":         `{"name":"r","when":{"all":[{"field":"amount","operator":"gt","value":1}],"field":"amount"},"actions":[{"type":"no_retry"}]}`,
+		"empty group":        `{"name":"r","when":{"any":[]},"actions":[{"type":"no_retry"}]}`,
+		"no actions":         `{"name":"r","when":{"field":"amount","operator":"gt","value":1}}`,
+		"unknown action":     `{"name":"r","when":{"field":"amount","operator":"gt","value":1},"actions":[{"type":"launch_rocket"}]}`,
+		"missing param":      `{"name":"r","when":{"field":"amount","operator":"gt","value":1},"actions":[{"type":"escalate"}]}`,
+		"unknown param":      `{"name":"r","when":{"field":"amount","operator":"gt","value":1},"actions":[{"type":"no_retry","params":{"x":1}}]}`,
+		"enum param":         `{"name":"r","when":{"field":"amount","operator":"gt","value":1},"actions":[{"type":"customer_contact","params":{"channel":"fax"}}]}`,
+		"risk score range":   `{"name":"r","when":{"field":"amount","operator":"gt","value":1},"actions":[{"type":"set_risk_score","params":{"score":150}}]}`,
+		"missing name":       `{"when":{"field":"amount","operator":"gt","value":1},"actions":[{"type":"no_retry"}]}`,
+		"malformed document": `{"name":`,
+		"expr type error":    `{"name":"r","when":{"expr":"amount > 'lots'"},"actions":[{"type":"no_retry"}]}`,
+		"expr not bool":      `{"name":"r","when":{"expr":"amount + 1"},"actions":[{"type":"no_retry"}]}`,
+	}
+	for name, source := range tests {
+		t.Run(name, func(t *testing.T) {
+			def, err := ParseRuleDefinition([]byte(source), "json")
+			if err == nil {
+				err = ValidateRuleDefinition(def, catalogue)
+			}
+			assert.ErrorIs(t, err, ErrInvalidRuleDefinition)
+		})
+	}
+}
+
+func TestCompileRuleDefinitionExpression(t *testing.T) {
+	source := `
+name: vip_large_failure
+when:
+  all:
+    - field: currency
+      operator: eq
+      value: AUD
+    - expr: amount >= 1000 && (metadata.tier == "vip" || customer.email.endsWith("@bigco.example"))
+actions:
+  - type: escalate
+    params:
+      team: key_accounts
+`
+	def, err := ParseRuleDefinition([]byte(source), "yaml")
+	require.NoError(t, err)
+	rule, err := CompileRuleDefinition(def, DefaultActionCatalogue())
+	require.NoError(t, err)
+
+	event := &models.PaymentFailureEvent{
+		ID:             uuid.New(),
+		AmountCents:    150000,
+		Currency:       "AUD",
+		CustomerEmail:  "ap@smallco.example",
+		NormalizedData: `{"tier": "vip"}`,
+	}
+	assert.True(t, rule.Condition(event))
+
+	event.NormalizedData = `{"tier": "standard"}`
+	assert.False(t, rule.Condition(event))
+	event.CustomerEmail = "ap@bigco.example"
+	assert.True(t, rule.Condition(event))
+
+	event.AmountCents = 99999
+	assert.False(t, rule.Condition(event))
+}
+
+func TestCompileRuleSet(t *testing.T) {
+	factory := NewRuleEngineFactory(zap.NewNop())
+	due := time.Now().Add(-72 * time.Hour)
+
+	engine, err := factory.CompileRuleSet([]RuleDefinition{
+		{Name: "overdue", Priority: 10, Enabled: true,
+			When:    ConditionNode{Field: "days_overdue", Operator: "gte", Value: float64(2)},
+			Actions: []ActionSpec{{Type: "escalate", Params: map[string]interface{}{"team": "collections"}}}},
+		{Name: "has_due_date", Priority: 20, Enabled: true,
+			When:    ConditionNode{Field: "due_date", Operator: "exists"},
+			Actions: []ActionSpec{{Type: "tag", Params: map[string]interface{}{"tags": []interface{}{"invoiced"}}}}},
+	}, DefaultActionCatalogue())
+	require.NoError(t, err)
+
+	results := engine.ExecuteRules(&models.PaymentFailureEvent{ID: uuid.New(), DueDate: &due})
+	require.Len(t, results, 2)
+	assert.Equal(t, "has_due_date", results[0].RuleName, "higher priority runs first")
+	assert.Equal(t, "overdue", results[1].RuleName)
+
+	assert.Empty(t, engine.ExecuteRules(&models.PaymentFailureEvent{ID: uuid.New()}))
+}
+
+func TestShadowRulesAreEvaluatedButNotExecuted(t *testing.T) {
+	factory := NewRuleEngineFactory(zap.NewNop())
+	engine, err := factory.CompileRuleSet([]RuleDefinition{
+		{Name: "live", Enabled: true,
+			When:    ConditionNode{Expr: "amount > 10"},
+			Actions: []ActionSpec{{Type: "alert"}}},
+		{Name: "candidate", Enabled: true, Shadow: true,
+			When:    ConditionNode{Expr: "amount > 100"},
+			Actions: []ActionSpec{{Type: "escalate", Params: map[string]interface{}{"team": "collections"}}, {Type: "alert"}}},
+	}, DefaultActionCatalogue())
+	require.NoError(t, err)
+
+	event := &models.PaymentFailureEvent{ID: uuid.New(), AmountCents: 50000}
+	results := engine.ExecuteRules(event)
+	require.Len(t, results, 1)
+	assert.Equal(t, "live", results[0].RuleName)
+
+	evaluations := EvaluateShadowRules(engine, event)
+	require.Len(t, evaluations, 1)
+	assert.Equal(t, "candidate", evaluations[0].Rule.Name)
+	assert.True(t, evaluations[0].Matched)
+	assert.Equal(t, []string{"escalate", "alert"}, evaluations[0].Rule.ActionTypes)
+
+	evaluations = EvaluateShadowRules(engine, &models.PaymentFailureEvent{ID: uuid.New(), AmountCents: 2000})
+	require.Len(t, evaluations, 1)
+	assert.False(t, evaluations[0].Matched, "unmatched shadow rules are still reported")
+}
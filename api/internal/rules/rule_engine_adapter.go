@@ -42,6 +42,9 @@ type Rule struct {
 	Action      func(*models.PaymentFailureEvent) (*ActionResult, error)
 	Priority    int
 	Enabled     bool
+	Shadow      bool     // evaluated and logged but never executed
+	Terminal    bool     // stops evaluation of lower-priority rules when it matches
+	ActionTypes []string // actions the rule takes, when known without running it
 	CreatedAt   time.Time
 	UpdatedAt   time.Time
 }
@@ -103,6 +106,9 @@ func (a *RuleEngineAdapter) GetRules() []*Rule {
 			Action:      a.adaptAction(basicRule.Action),
 			Priority:    basicRule.Priority,
 			Enabled:     basicRule.Enabled,
+			Shadow:      basicRule.Shadow,
+			Terminal:    basicRule.Terminal,
+			ActionTypes: basicRule.ActionTypes,
 			CreatedAt:   time.Now(),
 			UpdatedAt:   time.Now(),
 		}
@@ -120,6 +126,9 @@ func (a *RuleEngineAdapter) AddRule(rule *Rule) {
 		Action:      a.adaptActionBack(rule.Action),
 		Priority:    rule.Priority,
 		Enabled:     rule.Enabled,
+		Shadow:      rule.Shadow,
+		Terminal:    rule.Terminal,
+		ActionTypes: rule.ActionTypes,
 	}
 	a.basicEngine.AddRule(basicRule)
 }
@@ -185,6 +194,9 @@ func (a *RuleEngineAdapter) GetRuleByName(name string) *Rule {
 				Action:      a.adaptAction(basicRule.Action),
 				Priority:    basicRule.Priority,
 				Enabled:     basicRule.Enabled,
+				Shadow:      basicRule.Shadow,
+				Terminal:    basicRule.Terminal,
+				ActionTypes: basicRule.ActionTypes,
 				CreatedAt:   time.Now(),
 				UpdatedAt:   time.Now(),
 			}
@@ -0,0 +1,214 @@
+package rules
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/sambitmohanty1/payment-watchdog/api/internal/calendar"
+	"github.com/sambitmohanty1/payment-watchdog/api/internal/script"
+)
+
+// Company scripts define evaluate(failure, ctx), returning whether their rule
+// applies, and optionally actions(failure, ctx), returning a list of
+// action(type, **params) values dispatched like any other rule action.
+const (
+	ScriptEvaluateFunction = "evaluate"
+	ScriptActionsFunction  = "actions"
+)
+
+// NewScriptRule wraps an initialized company script as an enterprise rule. The
+// script's evaluate function is its condition and its actions function, when
+// defined, plans its actions.
+func NewScriptRule(id, name string, priority int, module *script.Module, shadow bool) EnterpriseRule {
+	rule := EnterpriseRule{
+		ID:          id,
+		Name:        name,
+		Description: fmt.Sprintf("Company script %s", name),
+		Priority:    priority,
+		Conditions:  []EnterpriseCondition{&ScriptCondition{name: name, module: module}},
+		Enabled:     true,
+		Shadow:      shadow,
+		Tags:        []string{"script"},
+		Metadata:    map[string]string{"source": "company_script"},
+	}
+	if module.Has(ScriptActionsFunction) {
+		rule.Actions = []EnterpriseAction{&ScriptAction{name: name, module: module}}
+	}
+	return rule
+}
+
+// ScriptCondition is met when a company script's evaluate function returns True
+type ScriptCondition struct {
+	name   string
+	module *script.Module
+}
+
+func (c *ScriptCondition) Evaluate(ctx EnterpriseRuleContext) bool {
+	met, _, err := c.EvaluateChecked(context.Background(), ctx)
+	return met && err == nil
+}
+
+// EvaluateChecked runs the script under its limits. The observed value records
+// the steps used and anything the script printed.
+func (c *ScriptCondition) EvaluateChecked(goCtx context.Context, ctx EnterpriseRuleContext) (bool, interface{}, error) {
+	failure, scriptCtx := scriptArguments(ctx)
+	result, err := c.module.Call(goCtx, ScriptEvaluateFunction, failure, scriptCtx)
+	if err != nil {
+		return false, scriptObservation(result), err
+	}
+	met, err := result.Bool()
+	if err != nil {
+		return false, scriptObservation(result), fmt.Errorf("%s(): %w", ScriptEvaluateFunction, err)
+	}
+	return met, scriptObservation(result), nil
+}
+
+func (c *ScriptCondition) GetType() string {
+	return "script"
+}
+
+func (c *ScriptCondition) GetDescription() string {
+	return fmt.Sprintf("Company script %s", c.name)
+}
+
+// ScriptAction plans actions by calling a company script's actions function.
+// It has no behaviour of its own and is only carried out through a dispatcher.
+type ScriptAction struct {
+	name   string
+	module *script.Module
+}
+
+func (a *ScriptAction) Execute(ctx EnterpriseRuleContext) error {
+	return fmt.Errorf("script %s actions must be run through an action dispatcher", a.name)
+}
+
+// PlanActions calls the script's actions function
+func (a *ScriptAction) PlanActions(goCtx context.Context, ctx EnterpriseRuleContext) ([]EnterprisePlannedAction, error) {
+	failure, scriptCtx := scriptArguments(ctx)
+	result, err := a.module.Call(goCtx, ScriptActionsFunction, failure, scriptCtx)
+	if err != nil {
+		return nil, err
+	}
+	requests, err := script.Actions(result.Value)
+	if err != nil {
+		return nil, err
+	}
+	planned := make([]EnterprisePlannedAction, len(requests))
+	for i, request := range requests {
+		planned[i] = EnterprisePlannedAction{Type: request.Type, Params: request.Params}
+	}
+	return planned, nil
+}
+
+func (a *ScriptAction) GetType() string {
+	return "script_actions"
+}
+
+func (a *ScriptAction) GetDescription() string {
+	return fmt.Sprintf("Actions planned by company script %s", a.name)
+}
+
+func (a *ScriptAction) GetPriority() int {
+	return 0
+}
+
+func scriptObservation(result *script.Result) interface{} {
+	if result == nil {
+		return nil
+	}
+	observed := map[string]interface{}{
+		"result": script.ToGo(result.Value),
+		"steps":  result.Steps,
+	}
+	if result.Output != "" {
+		observed["output"] = result.Output
+	}
+	return observed
+}
+
+// scriptArguments builds the failure and ctx arguments of script functions.
+// Times are Unix seconds; today, weekday and hour are in the company's timezone.
+func scriptArguments(ctx EnterpriseRuleContext) (script.Value, script.Value) {
+	now := ctx.Timestamp
+	if now.IsZero() {
+		now = time.Now()
+	}
+	cal := calendar.Default()
+	company := map[string]script.Value{}
+	if ctx.Company != nil {
+		if ctx.Company.Calendar != nil {
+			cal = ctx.Company.Calendar
+		}
+		settings, _ := script.FromGo(ctx.Company.Settings)
+		company = map[string]script.Value{
+			"id":           ctx.Company.ID,
+			"name":         ctx.Company.Name,
+			"industry":     ctx.Company.Industry,
+			"size":         ctx.Company.Size,
+			"risk_profile": ctx.Company.RiskProfile,
+			"settings":     settings,
+		}
+	}
+	local := now.In(cal.Location())
+	var holiday script.Value
+	if name, ok := cal.Holiday(now); ok {
+		holiday = name
+	}
+	metadata, _ := script.FromGo(ctx.Metadata)
+	scriptCtx := script.NewStruct("context", map[string]script.Value{
+		"company":        script.NewStruct("company", company),
+		"now":            now.Unix(),
+		"today":          local.Format("2006-01-02"),
+		"weekday":        local.Format("Mon"),
+		"hour":           int64(local.Hour()),
+		"timezone":       cal.Location().String(),
+		"business_hours": cal.IsOpen(now),
+		"business_day":   cal.IsBusinessDay(now),
+		"holiday":        holiday,
+		"environment":    ctx.Environment,
+		"metadata":       metadata,
+	})
+
+	failure := ctx.PaymentFailure
+	if failure == nil {
+		return nil, scriptCtx
+	}
+	var dueDate, daysOverdue script.Value
+	if failure.DueDate != nil {
+		dueDate = failure.DueDate.Unix()
+		if overdue := int64(now.Sub(*failure.DueDate).Hours() / 24); overdue > 0 {
+			daysOverdue = overdue
+		} else {
+			daysOverdue = int64(0)
+		}
+	}
+	tags, _ := script.FromGo(failure.Tags)
+	segments, _ := script.FromGo(ctx.CustomerSegments)
+	failureMetadata, _ := script.FromGo(failure.Metadata)
+	return script.NewStruct("failure", map[string]script.Value{
+		"id":                failure.ID.String(),
+		"company_id":        failure.CompanyID,
+		"provider":          failure.ProviderID,
+		"amount":            failure.Amount,
+		"currency":          failure.Currency,
+		"payment_method":    failure.PaymentMethod,
+		"customer_id":       failure.CustomerID,
+		"customer_name":     failure.CustomerName,
+		"customer_email":    failure.CustomerEmail,
+		"reason":            failure.FailureReason,
+		"code":              failure.FailureCode,
+		"message":           failure.FailureMessage,
+		"invoice_id":        failure.InvoiceID,
+		"due_date":          dueDate,
+		"days_overdue":      daysOverdue,
+		"business_category": failure.BusinessCategory,
+		"status":            string(failure.Status),
+		"priority":          string(failure.Priority),
+		"risk_score":        failure.RiskScore,
+		"occurred_at":       failure.OccurredAt.Unix(),
+		"tags":              tags,
+		"segments":          segments,
+		"metadata":          failureMetadata,
+	}), scriptCtx
+}
@@ -0,0 +1,24 @@
+package rules
+
+import (
+	"github.com/sambitmohanty1/payment-watchdog/api/internal/models"
+)
+
+// RuleEvaluation is the outcome of evaluating one rule's condition
+type RuleEvaluation struct {
+	Rule    *Rule
+	Matched bool
+}
+
+// EvaluateShadowRules evaluates the enabled shadow rules of engine against event
+// without running their actions. Every shadow rule is reported, matched or not,
+// so that shadow decisions can be compared with the live ones.
+func EvaluateShadowRules(engine RuleEngine, event *models.PaymentFailureEvent) []RuleEvaluation {
+	var evaluations []RuleEvaluation
+	for _, rule := range engine.GetRules() {
+		if rule.Enabled && rule.Shadow {
+			evaluations = append(evaluations, RuleEvaluation{Rule: rule, Matched: conditionHolds(rule, event)})
+		}
+	}
+	return evaluations
+}
@@ -0,0 +1,1105 @@
+package script
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// The standard library is deterministic: there is no clock, randomness, file
+// or network access. Dicts iterate in insertion order and the current time is
+// only available through the context argument supplied by the host.
+var universe map[string]*Builtin
+
+func init() {
+	universe = make(map[string]*Builtin)
+	for name, fn := range map[string]func(t *thread, b *Builtin, args []Value, kwargs []kwarg) (Value, error){
+		"len":       builtinLen,
+		"str":       builtinStr,
+		"repr":      builtinRepr,
+		"int":       builtinInt,
+		"float":     builtinFloat,
+		"bool":      builtinBool,
+		"abs":       builtinAbs,
+		"min":       builtinMinMax,
+		"max":       builtinMinMax,
+		"sum":       builtinSum,
+		"sorted":    builtinSorted,
+		"range":     builtinRange,
+		"list":      builtinList,
+		"tuple":     builtinTuple,
+		"dict":      builtinDict,
+		"enumerate": builtinEnumerate,
+		"zip":       builtinZip,
+		"any":       builtinAnyAll,
+		"all":       builtinAnyAll,
+		"round":     builtinRound,
+		"type":      builtinType,
+		"hasattr":   builtinHasattr,
+		"getattr":   builtinGetattr,
+		"fail":      builtinFail,
+		"print":     builtinPrint,
+		"struct":    builtinStruct,
+		"action":    builtinAction,
+	} {
+		universe[name] = &Builtin{name: name, fn: fn}
+	}
+}
+
+// ActionRequest is an action returned by a script's actions() function
+type ActionRequest struct {
+	Type   string                 `json:"type"`
+	Params map[string]interface{} `json:"params,omitempty"`
+}
+
+// Actions converts the value returned by a script's actions() function, a list
+// of action(...) values or None, to action requests
+func Actions(v Value) ([]ActionRequest, error) {
+	if v == nil {
+		return nil, nil
+	}
+	elems, err := iterate(v)
+	if err != nil {
+		return nil, fmt.Errorf("actions() must return a list of action(...) values, not %s", typeName(v))
+	}
+	requests := make([]ActionRequest, 0, len(elems))
+	for i, elem := range elems {
+		s, ok := elem.(*Struct)
+		if !ok || s.name != "action" {
+			return nil, fmt.Errorf("actions()[%d] is %s, not action(...)", i, typeName(elem))
+		}
+		request := ActionRequest{Type: s.fields["type"].(string)}
+		params, err := toGo(s.fields["params"])
+		if err != nil {
+			return nil, fmt.Errorf("actions()[%d] params: %w", i, err)
+		}
+		if params, ok := params.(map[string]interface{}); ok && len(params) > 0 {
+			request.Params = params
+		}
+		requests = append(requests, request)
+	}
+	return requests, nil
+}
+
+// bindArgs matches positional and keyword arguments to named parameters, of
+// which the first required must be supplied; missing optional parameters are
+// reported as not set
+func bindArgs(name string, args []Value, kwargs []kwarg, required int, params ...string) ([]Value, []bool, error) {
+	if len(args) > len(params) {
+		return nil, nil, fmt.Errorf("%s() takes at most %d arguments (%d given)", name, len(params), len(args))
+	}
+	values := make([]Value, len(params))
+	set := make([]bool, len(params))
+	for i, arg := range args {
+		values[i], set[i] = arg, true
+	}
+	for _, kw := range kwargs {
+		found := false
+		for i, prm := range params {
+			if prm != kw.name {
+				continue
+			}
+			if set[i] {
+				return nil, nil, fmt.Errorf("%s() got multiple values for argument %q", name, kw.name)
+			}
+			values[i], set[i], found = kw.value, true, true
+		}
+		if !found {
+			return nil, nil, fmt.Errorf("%s() got an unexpected keyword argument %q", name, kw.name)
+		}
+	}
+	for i := 0; i < required; i++ {
+		if !set[i] {
+			return nil, nil, fmt.Errorf("%s() missing argument %q", name, params[i])
+		}
+	}
+	return values, set, nil
+}
+
+func noKwargs(name string, kwargs []kwarg) error {
+	if len(kwargs) > 0 {
+		return fmt.Errorf("%s() got an unexpected keyword argument %q", name, kwargs[0].name)
+	}
+	return nil
+}
+
+func builtinLen(t *thread, b *Builtin, args []Value, kwargs []kwarg) (Value, error) {
+	values, _, err := bindArgs(b.name, args, kwargs, 1, "x")
+	if err != nil {
+		return nil, err
+	}
+	switch x := values[0].(type) {
+	case string:
+		return int64(len(x)), nil
+	case *List:
+		return int64(len(x.elems)), nil
+	case Tuple:
+		return int64(len(x)), nil
+	case *Dict:
+		return int64(len(x.keys)), nil
+	}
+	return nil, fmt.Errorf("len() of %s is not defined", typeName(values[0]))
+}
+
+func builtinStr(t *thread, b *Builtin, args []Value, kwargs []kwarg) (Value, error) {
+	values, _, err := bindArgs(b.name, args, kwargs, 1, "x")
+	if err != nil {
+		return nil, err
+	}
+	return t.str(values[0])
+}
+
+func builtinRepr(t *thread, b *Builtin, args []Value, kwargs []kwarg) (Value, error) {
+	values, _, err := bindArgs(b.name, args, kwargs, 1, "x")
+	if err != nil {
+		return nil, err
+	}
+	return t.repr(values[0])
+}
+
+func builtinInt(t *thread, b *Builtin, args []Value, kwargs []kwarg) (Value, error) {
+	values, _, err := bindArgs(b.name, args, kwargs, 1, "x")
+	if err != nil {
+		return nil, err
+	}
+	switch x := values[0].(type) {
+	case int64:
+		return x, nil
+	case bool:
+		if x {
+			return int64(1), nil
+		}
+		return int64(0), nil
+	case float64:
+		if math.IsNaN(x) || math.IsInf(x, 0) || math.Abs(x) >= 1<<63 {
+			return nil, fmt.Errorf("cannot convert %s to int", formatFloat(x))
+		}
+		return int64(x), nil
+	case string:
+		n, err := strconv.ParseInt(strings.TrimSpace(x), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid literal for int(): %s", repr(x))
+		}
+		return n, nil
+	}
+	return nil, fmt.Errorf("cannot convert %s to int", typeName(values[0]))
+}
+
+func builtinFloat(t *thread, b *Builtin, args []Value, kwargs []kwarg) (Value, error) {
+	values, _, err := bindArgs(b.name, args, kwargs, 1, "x")
+	if err != nil {
+		return nil, err
+	}
+	switch x := values[0].(type) {
+	case int64, float64:
+		return toFloat(x), nil
+	case bool:
+		if x {
+			return 1.0, nil
+		}
+		return 0.0, nil
+	case string:
+		f, err := strconv.ParseFloat(strings.TrimSpace(x), 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid literal for float(): %s", repr(x))
+		}
+		return f, nil
+	}
+	return nil, fmt.Errorf("cannot convert %s to float", typeName(values[0]))
+}
+
+func builtinBool(t *thread, b *Builtin, args []Value, kwargs []kwarg) (Value, error) {
+	values, _, err := bindArgs(b.name, args, kwargs, 0, "x")
+	if err != nil {
+		return nil, err
+	}
+	return truth(values[0]), nil
+}
+
+func builtinAbs(t *thread, b *Builtin, args []Value, kwargs []kwarg) (Value, error) {
+	values, _, err := bindArgs(b.name, args, kwargs, 1, "x")
+	if err != nil {
+		return nil, err
+	}
+	switch x := values[0].(type) {
+	case int64:
+		if x == math.MinInt64 {
+			return nil, fmt.Errorf("integer overflow")
+		}
+		if x < 0 {
+			return -x, nil
+		}
+		return x, nil
+	case float64:
+		return math.Abs(x), nil
+	}
+	return nil, fmt.Errorf("abs() of %s is not defined", typeName(values[0]))
+}
+
+// builtinMinMax implements min and max over an iterable or several arguments,
+// with an optional key function
+func builtinMinMax(t *thread, b *Builtin, args []Value, kwargs []kwarg) (Value, error) {
+	var key Value
+	for _, kw := range kwargs {
+		if kw.name != "key" {
+			return nil, fmt.Errorf("%s() got an unexpected keyword argument %q", b.name, kw.name)
+		}
+		key = kw.value
+	}
+	elems := args
+	if len(args) == 1 {
+		var err error
+		if elems, err = iterate(args[0]); err != nil {
+			return nil, err
+		}
+	}
+	if len(elems) == 0 {
+		return nil, fmt.Errorf("%s() of an empty sequence", b.name)
+	}
+	if err := t.tick(int64(len(elems))); err != nil {
+		return nil, err
+	}
+
+	best, bestKey := elems[0], elems[0]
+	if key != nil {
+		var err error
+		if bestKey, err = t.call(key, []Value{best}, nil); err != nil {
+			return nil, err
+		}
+	}
+	for _, elem := range elems[1:] {
+		elemKey := elem
+		if key != nil {
+			var err error
+			if elemKey, err = t.call(key, []Value{elem}, nil); err != nil {
+				return nil, err
+			}
+		}
+		c, err := t.compare(elemKey, bestKey)
+		if err != nil {
+			return nil, err
+		}
+		if (b.name == "min" && c < 0) || (b.name == "max" && c > 0) {
+			best, bestKey = elem, elemKey
+		}
+	}
+	return best, nil
+}
+
+func builtinSum(t *thread, b *Builtin, args []Value, kwargs []kwarg) (Value, error) {
+	values, set, err := bindArgs(b.name, args, kwargs, 1, "iterable", "start")
+	if err != nil {
+		return nil, err
+	}
+	elems, err := iterate(values[0])
+	if err != nil {
+		return nil, err
+	}
+	if err := t.tick(int64(len(elems))); err != nil {
+		return nil, err
+	}
+	var total Value = int64(0)
+	if set[1] {
+		total = values[1]
+	}
+	for _, elem := range elems {
+		if !isNumber(elem) || !isNumber(total) {
+			return nil, fmt.Errorf("sum() requires numbers, not %s", typeName(elem))
+		}
+		if total, err = arith("+", total, elem); err != nil {
+			return nil, err
+		}
+	}
+	return total, nil
+}
+
+func builtinSorted(t *thread, b *Builtin, args []Value, kwargs []kwarg) (Value, error) {
+	values, _, err := bindArgs(b.name, args, kwargs, 1, "iterable", "key", "reverse")
+	if err != nil {
+		return nil, err
+	}
+	elems, err := iterate(values[0])
+	if err != nil {
+		return nil, err
+	}
+	elems = append([]Value{}, elems...)
+	keys := elems
+	if values[1] != nil {
+		keys = make([]Value, len(elems))
+		for i, elem := range elems {
+			if keys[i], err = t.call(values[1], []Value{elem}, nil); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if err := t.tick(int64(len(elems)) * int64(1+bitLen(len(elems)))); err != nil {
+		return nil, err
+	}
+
+	order := make([]int, len(elems))
+	for i := range order {
+		order[i] = i
+	}
+	reverse := truth(values[2])
+	var compareErr error
+	sort.SliceStable(order, func(i, j int) bool {
+		c, err := t.compare(keys[order[i]], keys[order[j]])
+		if err != nil && compareErr == nil {
+			compareErr = err
+		}
+		if reverse {
+			return c > 0
+		}
+		return c < 0
+	})
+	if compareErr != nil {
+		return nil, compareErr
+	}
+	sorted := make([]Value, len(elems))
+	for i, idx := range order {
+		sorted[i] = elems[idx]
+	}
+	return NewList(sorted), nil
+}
+
+func bitLen(n int) int {
+	bits := 0
+	for ; n > 0; n >>= 1 {
+		bits++
+	}
+	return bits
+}
+
+func builtinRange(t *thread, b *Builtin, args []Value, kwargs []kwarg) (Value, error) {
+	if err := noKwargs(b.name, kwargs); err != nil {
+		return nil, err
+	}
+	bounds := make([]int64, len(args))
+	for i, arg := range args {
+		n, ok := arg.(int64)
+		if !ok {
+			return nil, fmt.Errorf("range() arguments must be ints, not %s", typeName(arg))
+		}
+		bounds[i] = n
+	}
+	start, stop, step := int64(0), int64(0), int64(1)
+	switch len(bounds) {
+	case 1:
+		stop = bounds[0]
+	case 2:
+		start, stop = bounds[0], bounds[1]
+	case 3:
+		start, stop, step = bounds[0], bounds[1], bounds[2]
+	default:
+		return nil, fmt.Errorf("range() takes 1 to 3 arguments (%d given)", len(args))
+	}
+	if step == 0 {
+		return nil, fmt.Errorf("range() step must not be zero")
+	}
+
+	var count int64
+	if step > 0 && stop > start {
+		count = (stop - start + step - 1) / step
+	} else if step < 0 && start > stop {
+		count = (start - stop - step - 1) / -step
+	}
+	if count > math.MaxInt32 {
+		count = math.MaxInt32
+	}
+	if err := t.checkSize(int(count)); err != nil {
+		return nil, err
+	}
+	if err := t.tick(count); err != nil {
+		return nil, err
+	}
+	elems := make([]Value, count)
+	for i := range elems {
+		elems[i] = start + int64(i)*step
+	}
+	return NewList(elems), nil
+}
+
+func builtinList(t *thread, b *Builtin, args []Value, kwargs []kwarg) (Value, error) {
+	values, set, err := bindArgs(b.name, args, kwargs, 0, "iterable")
+	if err != nil {
+		return nil, err
+	}
+	if !set[0] {
+		return NewList(nil), nil
+	}
+	elems, err := iterate(values[0])
+	if err != nil {
+		return nil, err
+	}
+	return NewList(append([]Value{}, elems...)), t.tick(int64(len(elems)))
+}
+
+func builtinTuple(t *thread, b *Builtin, args []Value, kwargs []kwarg) (Value, error) {
+	values, set, err := bindArgs(b.name, args, kwargs, 0, "iterable")
+	if err != nil {
+		return nil, err
+	}
+	if !set[0] {
+		return Tuple{}, nil
+	}
+	elems, err := iterate(values[0])
+	if err != nil {
+		return nil, err
+	}
+	return append(Tuple{}, elems...), t.tick(int64(len(elems)))
+}
+
+func builtinDict(t *thread, b *Builtin, args []Value, kwargs []kwarg) (Value, error) {
+	if len(args) > 1 {
+		return nil, fmt.Errorf("dict() takes at most 1 positional argument (%d given)", len(args))
+	}
+	d := NewDict()
+	if len(args) == 1 {
+		if err := updateDict(t, d, args[0]); err != nil {
+			return nil, err
+		}
+	}
+	for _, kw := range kwargs {
+		if err := d.Set(kw.name, kw.value); err != nil {
+			return nil, err
+		}
+	}
+	return d, t.checkSize(d.Len())
+}
+
+// updateDict copies entries from a dict or an iterable of pairs
+func updateDict(t *thread, d *Dict, from Value) error {
+	if src, ok := from.(*Dict); ok {
+		if err := t.tick(int64(src.Len())); err != nil {
+			return err
+		}
+		for i, key := range src.keys {
+			if err := d.Set(key, src.values[i]); err != nil {
+				return err
+			}
+		}
+		return t.checkSize(d.Len())
+	}
+	pairs, err := iterate(from)
+	if err != nil {
+		return err
+	}
+	if err := t.tick(int64(len(pairs))); err != nil {
+		return err
+	}
+	for i, pair := range pairs {
+		kv, err := iterate(pair)
+		if err != nil || len(kv) != 2 {
+			return fmt.Errorf("dict update element %d is not a key/value pair", i)
+		}
+		if err := d.Set(kv[0], kv[1]); err != nil {
+			return err
+		}
+	}
+	return t.checkSize(d.Len())
+}
+
+func builtinEnumerate(t *thread, b *Builtin, args []Value, kwargs []kwarg) (Value, error) {
+	values, set, err := bindArgs(b.name, args, kwargs, 1, "iterable", "start")
+	if err != nil {
+		return nil, err
+	}
+	elems, err := iterate(values[0])
+	if err != nil {
+		return nil, err
+	}
+	start := int64(0)
+	if set[1] {
+		n, ok := values[1].(int64)
+		if !ok {
+			return nil, fmt.Errorf("enumerate() start must be an int")
+		}
+		start = n
+	}
+	out := make([]Value, len(elems))
+	for i, elem := range elems {
+		out[i] = Tuple{start + int64(i), elem}
+	}
+	return NewList(out), t.tick(int64(len(elems)))
+}
+
+func builtinZip(t *thread, b *Builtin, args []Value, kwargs []kwarg) (Value, error) {
+	if err := noKwargs(b.name, kwargs); err != nil {
+		return nil, err
+	}
+	seqs := make([][]Value, len(args))
+	length := -1
+	for i, arg := range args {
+		elems, err := iterate(arg)
+		if err != nil {
+			return nil, err
+		}
+		seqs[i] = elems
+		if length < 0 || len(elems) < length {
+			length = len(elems)
+		}
+	}
+	if length < 0 {
+		length = 0
+	}
+	out := make([]Value, length)
+	for i := range out {
+		row := make(Tuple, len(seqs))
+		for j, seq := range seqs {
+			row[j] = seq[i]
+		}
+		out[i] = row
+	}
+	return NewList(out), t.tick(int64(length))
+}
+
+func builtinAnyAll(t *thread, b *Builtin, args []Value, kwargs []kwarg) (Value, error) {
+	values, _, err := bindArgs(b.name, args, kwargs, 1, "iterable")
+	if err != nil {
+		return nil, err
+	}
+	elems, err := iterate(values[0])
+	if err != nil {
+		return nil, err
+	}
+	if err := t.tick(int64(len(elems))); err != nil {
+		return nil, err
+	}
+	for _, elem := range elems {
+		if truth(elem) == (b.name == "any") {
+			return b.name == "any", nil
+		}
+	}
+	return b.name == "all", nil
+}
+
+// builtinRound rounds half to even; without ndigits it returns an int
+func builtinRound(t *thread, b *Builtin, args []Value, kwargs []kwarg) (Value, error) {
+	values, set, err := bindArgs(b.name, args, kwargs, 1, "x", "ndigits")
+	if err != nil {
+		return nil, err
+	}
+	if !isNumber(values[0]) {
+		return nil, fmt.Errorf("round() requires a number, not %s", typeName(values[0]))
+	}
+	x := toFloat(values[0])
+	if !set[1] || values[1] == nil {
+		if n, ok := values[0].(int64); ok {
+			return n, nil
+		}
+		r := math.RoundToEven(x)
+		if math.IsNaN(r) || math.IsInf(r, 0) || math.Abs(r) >= 1<<63 {
+			return nil, fmt.Errorf("cannot round %s to int", formatFloat(x))
+		}
+		return int64(r), nil
+	}
+	digits, ok := values[1].(int64)
+	if !ok || digits < 0 || digits > 15 {
+		return nil, fmt.Errorf("round() ndigits must be an int between 0 and 15")
+	}
+	scale := math.Pow(10, float64(digits))
+	return math.RoundToEven(x*scale) / scale, nil
+}
+
+func builtinType(t *thread, b *Builtin, args []Value, kwargs []kwarg) (Value, error) {
+	values, _, err := bindArgs(b.name, args, kwargs, 1, "x")
+	if err != nil {
+		return nil, err
+	}
+	return typeName(values[0]), nil
+}
+
+func builtinHasattr(t *thread, b *Builtin, args []Value, kwargs []kwarg) (Value, error) {
+	values, _, err := bindArgs(b.name, args, kwargs, 2, "x", "name")
+	if err != nil {
+		return nil, err
+	}
+	name, ok := values[1].(string)
+	if !ok {
+		return nil, fmt.Errorf("hasattr() name must be a string")
+	}
+	_, err = attr(values[0], name)
+	return err == nil, nil
+}
+
+func builtinGetattr(t *thread, b *Builtin, args []Value, kwargs []kwarg) (Value, error) {
+	values, set, err := bindArgs(b.name, args, kwargs, 2, "x", "name", "default")
+	if err != nil {
+		return nil, err
+	}
+	name, ok := values[1].(string)
+	if !ok {
+		return nil, fmt.Errorf("getattr() name must be a string")
+	}
+	value, err := attr(values[0], name)
+	if err != nil && set[2] {
+		return values[2], nil
+	}
+	return value, err
+}
+
+func builtinFail(t *thread, b *Builtin, args []Value, kwargs []kwarg) (Value, error) {
+	if err := noKwargs(b.name, kwargs); err != nil {
+		return nil, err
+	}
+	parts := make([]string, len(args))
+	for i, arg := range args {
+		parts[i] = str(arg)
+	}
+	return nil, fmt.Errorf("fail: %s", strings.Join(parts, " "))
+}
+
+func builtinPrint(t *thread, b *Builtin, args []Value, kwargs []kwarg) (Value, error) {
+	if err := noKwargs(b.name, kwargs); err != nil {
+		return nil, err
+	}
+	parts := make([]string, len(args))
+	for i, arg := range args {
+		// print output is capped, so longer values are cut short
+		if s, ok := arg.(string); ok {
+			parts[i] = s
+		} else {
+			parts[i] = reprLimit(arg, maxOutputBytes)
+		}
+	}
+	t.print(strings.Join(parts, " ") + "\n")
+	return nil, nil
+}
+
+func builtinStruct(t *thread, b *Builtin, args []Value, kwargs []kwarg) (Value, error) {
+	if len(args) > 0 {
+		return nil, fmt.Errorf("struct() takes keyword arguments only")
+	}
+	fields := make(map[string]Value, len(kwargs))
+	for _, kw := range kwargs {
+		fields[kw.name] = kw.value
+	}
+	return NewStruct("struct", fields), nil
+}
+
+// builtinAction builds an action request: action("schedule_retry", delay="2h")
+func builtinAction(t *thread, b *Builtin, args []Value, kwargs []kwarg) (Value, error) {
+	if len(args) != 1 {
+		return nil, fmt.Errorf("action() takes the action type as its only positional argument")
+	}
+	actionType, ok := args[0].(string)
+	if !ok || actionType == "" {
+		return nil, fmt.Errorf("action() type must be a non-empty string")
+	}
+	params := NewDict()
+	for _, kw := range kwargs {
+		if err := params.Set(kw.name, kw.value); err != nil {
+			return nil, err
+		}
+	}
+	return NewStruct("action", map[string]Value{"type": actionType, "params": params}), nil
+}
+
+// boundMethod returns the named method of a string, list or dict bound to x
+func boundMethod(x Value, name string) (*Builtin, bool) {
+	var methods map[string]func(t *thread, b *Builtin, args []Value, kwargs []kwarg) (Value, error)
+	switch x.(type) {
+	case string:
+		methods = stringMethods
+	case *List:
+		methods = listMethods
+	case *Dict:
+		methods = dictMethods
+	}
+	fn, ok := methods[name]
+	if !ok {
+		return nil, false
+	}
+	return &Builtin{name: name, recv: x, fn: fn}, true
+}
+
+var stringMethods = map[string]func(t *thread, b *Builtin, args []Value, kwargs []kwarg) (Value, error){
+	"lower": func(t *thread, b *Builtin, args []Value, kwargs []kwarg) (Value, error) {
+		if _, _, err := bindArgs(b.name, args, kwargs, 0); err != nil {
+			return nil, err
+		}
+		return strings.ToLower(b.recv.(string)), nil
+	},
+	"upper": func(t *thread, b *Builtin, args []Value, kwargs []kwarg) (Value, error) {
+		if _, _, err := bindArgs(b.name, args, kwargs, 0); err != nil {
+			return nil, err
+		}
+		return strings.ToUpper(b.recv.(string)), nil
+	},
+	"strip":  stringStrip,
+	"lstrip": stringStrip,
+	"rstrip": stringStrip,
+	"startswith": func(t *thread, b *Builtin, args []Value, kwargs []kwarg) (Value, error) {
+		return stringAffix(b, args, kwargs, strings.HasPrefix)
+	},
+	"endswith": func(t *thread, b *Builtin, args []Value, kwargs []kwarg) (Value, error) {
+		return stringAffix(b, args, kwargs, strings.HasSuffix)
+	},
+	"split": func(t *thread, b *Builtin, args []Value, kwargs []kwarg) (Value, error) {
+		values, _, err := bindArgs(b.name, args, kwargs, 0, "sep", "maxsplit")
+		if err != nil {
+			return nil, err
+		}
+		s := b.recv.(string)
+		limit := -1
+		if n, ok := values[1].(int64); ok && n >= 0 {
+			limit = int(n) + 1
+		}
+		var parts []string
+		switch sep := values[0].(type) {
+		case nil:
+			parts = strings.Fields(s)
+		case string:
+			if sep == "" {
+				return nil, fmt.Errorf("split() separator must not be empty")
+			}
+			parts = strings.SplitN(s, sep, limit)
+		default:
+			return nil, fmt.Errorf("split() separator must be a string")
+		}
+		if err := t.checkSize(len(parts)); err != nil {
+			return nil, err
+		}
+		elems := make([]Value, len(parts))
+		for i, part := range parts {
+			elems[i] = part
+		}
+		return NewList(elems), t.tick(int64(len(parts)))
+	},
+	"replace": func(t *thread, b *Builtin, args []Value, kwargs []kwarg) (Value, error) {
+		values, set, err := bindArgs(b.name, args, kwargs, 2, "old", "new", "count")
+		if err != nil {
+			return nil, err
+		}
+		old, ok1 := values[0].(string)
+		repl, ok2 := values[1].(string)
+		if !ok1 || !ok2 {
+			return nil, fmt.Errorf("replace() arguments must be strings")
+		}
+		n := -1
+		if set[2] {
+			count, ok := values[2].(int64)
+			if !ok {
+				return nil, fmt.Errorf("replace() count must be an int")
+			}
+			n = int(count)
+		}
+		s := b.recv.(string)
+		count := strings.Count(s, old)
+		if n >= 0 && n < count {
+			count = n
+		}
+		if err := t.checkSize(len(s) + repeatedSize(int64(count), len(repl)-len(old))); err != nil {
+			return nil, err
+		}
+		return strings.Replace(s, old, repl, n), nil
+	},
+	"join": func(t *thread, b *Builtin, args []Value, kwargs []kwarg) (Value, error) {
+		values, _, err := bindArgs(b.name, args, kwargs, 1, "iterable")
+		if err != nil {
+			return nil, err
+		}
+		elems, err := iterate(values[0])
+		if err != nil {
+			return nil, err
+		}
+		sep := b.recv.(string)
+		parts := make([]string, len(elems))
+		size := len(sep) * max(len(elems)-1, 0)
+		for i, elem := range elems {
+			s, ok := elem.(string)
+			if !ok {
+				return nil, fmt.Errorf("join() element %d is %s, not string", i, typeName(elem))
+			}
+			parts[i] = s
+			size += len(s)
+		}
+		// Check the size before joining so an oversized result is never built
+		if err := t.checkSize(size); err != nil {
+			return nil, err
+		}
+		out := strings.Join(parts, sep)
+		return out, t.tick(int64(len(elems)))
+	},
+	"find": func(t *thread, b *Builtin, args []Value, kwargs []kwarg) (Value, error) {
+		values, _, err := bindArgs(b.name, args, kwargs, 1, "sub")
+		if err != nil {
+			return nil, err
+		}
+		sub, ok := values[0].(string)
+		if !ok {
+			return nil, fmt.Errorf("find() argument must be a string")
+		}
+		return int64(strings.Index(b.recv.(string), sub)), nil
+	},
+	"count": func(t *thread, b *Builtin, args []Value, kwargs []kwarg) (Value, error) {
+		values, _, err := bindArgs(b.name, args, kwargs, 1, "sub")
+		if err != nil {
+			return nil, err
+		}
+		sub, ok := values[0].(string)
+		if !ok {
+			return nil, fmt.Errorf("count() argument must be a string")
+		}
+		return int64(strings.Count(b.recv.(string), sub)), nil
+	},
+}
+
+func stringStrip(t *thread, b *Builtin, args []Value, kwargs []kwarg) (Value, error) {
+	values, set, err := bindArgs(b.name, args, kwargs, 0, "chars")
+	if err != nil {
+		return nil, err
+	}
+	cutset := " \t\n\r"
+	if set[0] && values[0] != nil {
+		chars, ok := values[0].(string)
+		if !ok {
+			return nil, fmt.Errorf("%s() chars must be a string", b.name)
+		}
+		cutset = chars
+	}
+	s := b.recv.(string)
+	switch b.name {
+	case "lstrip":
+		return strings.TrimLeft(s, cutset), nil
+	case "rstrip":
+		return strings.TrimRight(s, cutset), nil
+	}
+	return strings.Trim(s, cutset), nil
+}
+
+// stringAffix implements startswith and endswith, accepting a string or a tuple
+// of alternatives
+func stringAffix(b *Builtin, args []Value, kwargs []kwarg, match func(s, affix string) bool) (Value, error) {
+	values, _, err := bindArgs(b.name, args, kwargs, 1, "affix")
+	if err != nil {
+		return nil, err
+	}
+	var affixes []Value
+	if tuple, ok := values[0].(Tuple); ok {
+		affixes = tuple
+	} else {
+		affixes = []Value{values[0]}
+	}
+	for _, affix := range affixes {
+		s, ok := affix.(string)
+		if !ok {
+			return nil, fmt.Errorf("%s() argument must be a string or tuple of strings", b.name)
+		}
+		if match(b.recv.(string), s) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func mutableList(b *Builtin) (*List, error) {
+	list := b.recv.(*List)
+	if list.frozen {
+		return nil, fmt.Errorf("cannot modify a frozen list")
+	}
+	return list, nil
+}
+
+var listMethods = map[string]func(t *thread, b *Builtin, args []Value, kwargs []kwarg) (Value, error){
+	"append": func(t *thread, b *Builtin, args []Value, kwargs []kwarg) (Value, error) {
+		values, _, err := bindArgs(b.name, args, kwargs, 1, "x")
+		if err != nil {
+			return nil, err
+		}
+		list, err := mutableList(b)
+		if err != nil {
+			return nil, err
+		}
+		if err := t.checkSize(len(list.elems) + 1); err != nil {
+			return nil, err
+		}
+		list.elems = append(list.elems, values[0])
+		return nil, nil
+	},
+	"extend": func(t *thread, b *Builtin, args []Value, kwargs []kwarg) (Value, error) {
+		values, _, err := bindArgs(b.name, args, kwargs, 1, "iterable")
+		if err != nil {
+			return nil, err
+		}
+		return nil, t.extend(b.recv.(*List), values[0])
+	},
+	"insert": func(t *thread, b *Builtin, args []Value, kwargs []kwarg) (Value, error) {
+		values, _, err := bindArgs(b.name, args, kwargs, 2, "index", "x")
+		if err != nil {
+			return nil, err
+		}
+		list, err := mutableList(b)
+		if err != nil {
+			return nil, err
+		}
+		i, ok := values[0].(int64)
+		if !ok {
+			return nil, fmt.Errorf("insert() index must be an int")
+		}
+		if err := t.checkSize(len(list.elems) + 1); err != nil {
+			return nil, err
+		}
+		n := int64(len(list.elems))
+		if i < 0 {
+			i += n
+		}
+		i = max(0, min(i, n))
+		list.elems = append(list.elems, nil)
+		copy(list.elems[i+1:], list.elems[i:])
+		list.elems[i] = values[1]
+		return nil, t.tick(n)
+	},
+	"index": func(t *thread, b *Builtin, args []Value, kwargs []kwarg) (Value, error) {
+		values, _, err := bindArgs(b.name, args, kwargs, 1, "x")
+		if err != nil {
+			return nil, err
+		}
+		list := b.recv.(*List)
+		if err := t.tick(int64(len(list.elems))); err != nil {
+			return nil, err
+		}
+		for i, elem := range list.elems {
+			eq, err := t.equal(elem, values[0])
+			if err != nil {
+				return nil, err
+			}
+			if eq {
+				return int64(i), nil
+			}
+		}
+		return nil, fmt.Errorf("index(): %s is not in list", repr(values[0]))
+	},
+	"pop": func(t *thread, b *Builtin, args []Value, kwargs []kwarg) (Value, error) {
+		values, set, err := bindArgs(b.name, args, kwargs, 0, "index")
+		if err != nil {
+			return nil, err
+		}
+		list, err := mutableList(b)
+		if err != nil {
+			return nil, err
+		}
+		index := Value(int64(-1))
+		if set[0] {
+			index = values[0]
+		}
+		i, err := sequenceIndex(index, len(list.elems))
+		if err != nil {
+			return nil, fmt.Errorf("pop(): %v", err)
+		}
+		value := list.elems[i]
+		list.elems = append(list.elems[:i], list.elems[i+1:]...)
+		return value, nil
+	},
+}
+
+func mutableDict(b *Builtin) (*Dict, error) {
+	d := b.recv.(*Dict)
+	if d.frozen {
+		return nil, fmt.Errorf("cannot modify a frozen dict")
+	}
+	return d, nil
+}
+
+var dictMethods = map[string]func(t *thread, b *Builtin, args []Value, kwargs []kwarg) (Value, error){
+	"get": func(t *thread, b *Builtin, args []Value, kwargs []kwarg) (Value, error) {
+		values, _, err := bindArgs(b.name, args, kwargs, 1, "key", "default")
+		if err != nil {
+			return nil, err
+		}
+		value, found, err := b.recv.(*Dict).Get(values[0])
+		if err != nil {
+			return nil, err
+		}
+		if !found {
+			return values[1], nil
+		}
+		return value, nil
+	},
+	"keys": func(t *thread, b *Builtin, args []Value, kwargs []kwarg) (Value, error) {
+		if _, _, err := bindArgs(b.name, args, kwargs, 0); err != nil {
+			return nil, err
+		}
+		d := b.recv.(*Dict)
+		return NewList(append([]Value{}, d.keys...)), t.tick(int64(d.Len()))
+	},
+	"values": func(t *thread, b *Builtin, args []Value, kwargs []kwarg) (Value, error) {
+		if _, _, err := bindArgs(b.name, args, kwargs, 0); err != nil {
+			return nil, err
+		}
+		d := b.recv.(*Dict)
+		return NewList(append([]Value{}, d.values...)), t.tick(int64(d.Len()))
+	},
+	"items": func(t *thread, b *Builtin, args []Value, kwargs []kwarg) (Value, error) {
+		if _, _, err := bindArgs(b.name, args, kwargs, 0); err != nil {
+			return nil, err
+		}
+		d := b.recv.(*Dict)
+		items := make([]Value, d.Len())
+		for i, key := range d.keys {
+			items[i] = Tuple{key, d.values[i]}
+		}
+		return NewList(items), t.tick(int64(d.Len()))
+	},
+	"pop": func(t *thread, b *Builtin, args []Value, kwargs []kwarg) (Value, error) {
+		values, set, err := bindArgs(b.name, args, kwargs, 1, "key", "default")
+		if err != nil {
+			return nil, err
+		}
+		d, err := mutableDict(b)
+		if err != nil {
+			return nil, err
+		}
+		value, found, err := d.delete(values[0])
+		if err != nil {
+			return nil, err
+		}
+		if !found {
+			if set[1] {
+				return values[1], nil
+			}
+			return nil, fmt.Errorf("pop(): key %s not found", repr(values[0]))
+		}
+		return value, t.tick(int64(d.Len()))
+	},
+	"update": func(t *thread, b *Builtin, args []Value, kwargs []kwarg) (Value, error) {
+		d, err := mutableDict(b)
+		if err != nil {
+			return nil, err
+		}
+		if len(args) > 1 {
+			return nil, fmt.Errorf("update() takes at most 1 positional argument (%d given)", len(args))
+		}
+		if len(args) == 1 {
+			if err := updateDict(t, d, args[0]); err != nil {
+				return nil, err
+			}
+		}
+		for _, kw := range kwargs {
+			if err := d.Set(kw.name, kw.value); err != nil {
+				return nil, err
+			}
+		}
+		return nil, t.checkSize(d.Len())
+	},
+	"setdefault": func(t *thread, b *Builtin, args []Value, kwargs []kwarg) (Value, error) {
+		values, _, err := bindArgs(b.name, args, kwargs, 1, "key", "default")
+		if err != nil {
+			return nil, err
+		}
+		d := b.recv.(*Dict)
+		value, found, err := d.Get(values[0])
+		if err != nil || found {
+			return value, err
+		}
+		if _, err := mutableDict(b); err != nil {
+			return nil, err
+		}
+		if err := d.Set(values[0], values[1]); err != nil {
+			return nil, err
+		}
+		return values[1], t.checkSize(d.Len())
+	},
+}
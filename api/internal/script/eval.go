@@ -0,0 +1,1201 @@
+// Package script interprets company scripts, written in a subset of Starlark,
+// in a sandbox with no clock, randomness, file or network access.
+//
+// The interpreter is written for this package rather than built on
+// go.starlark.net because every call must be bounded in memory as well as in
+// steps and time: go.starlark.net counts steps but lets a single operation such
+// as a string repetition allocate up to 1GB, so MaxCollectionSize could not be
+// enforced. FuzzScript exercises it with arbitrary scripts.
+package script
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+// ErrLimitExceeded is returned when a script runs out of steps, time, call
+// depth or collection space
+var ErrLimitExceeded = errors.New("script limit exceeded")
+
+// Limits bounds the work a single script call may do
+type Limits struct {
+	// MaxSteps bounds the number of statements, loop iterations, calls and
+	// collection elements processed
+	MaxSteps int64 `json:"max_steps"`
+	// Timeout bounds the wall clock time of a call
+	Timeout time.Duration `json:"timeout"`
+	// MaxDepth bounds nested function calls
+	MaxDepth int `json:"max_depth"`
+	// MaxCollectionSize bounds the length of lists, dicts and strings a script builds
+	MaxCollectionSize int `json:"max_collection_size"`
+}
+
+// DefaultLimits are applied to company scripts unless overridden
+var DefaultLimits = Limits{
+	MaxSteps:          100000,
+	Timeout:           50 * time.Millisecond,
+	MaxDepth:          16,
+	MaxCollectionSize: 10000,
+}
+
+// maxOutputBytes bounds the captured print output of a call
+const maxOutputBytes = 16 << 10
+
+// Program is a parsed script
+type Program struct {
+	name  string
+	stmts []stmt
+}
+
+// Compile parses a script. Compile errors are *Error values with the position
+// of the problem.
+func Compile(name, src string) (_ *Program, err error) {
+	defer recoverPanic(&err)
+	stmts, err := parse(src)
+	if err != nil {
+		return nil, err
+	}
+	return &Program{name: name, stmts: stmts}, nil
+}
+
+// Module is an initialized program whose globals are frozen, so it can be
+// called concurrently
+type Module struct {
+	name        string
+	globals     map[string]Value
+	predeclared map[string]Value
+	limits      Limits
+}
+
+// Result is the outcome of a script call
+type Result struct {
+	Value  Value  `json:"-"`
+	Steps  int64  `json:"steps"`
+	Output string `json:"output,omitempty"`
+}
+
+// Init runs the program's top level statements with the given predeclared
+// globals and returns the frozen module
+func (p *Program) Init(ctx context.Context, predeclared map[string]Value, limits Limits) (_ *Module, err error) {
+	defer recoverPanic(&err)
+	for _, value := range predeclared {
+		freeze(value)
+	}
+	m := &Module{
+		name:        p.name,
+		globals:     make(map[string]Value),
+		predeclared: predeclared,
+		limits:      limits,
+	}
+
+	t, cancel := newThread(ctx, limits)
+	defer cancel()
+	fr := &frame{vars: m.globals}
+	if _, _, err := t.execBlock(m, fr, p.stmts); err != nil {
+		return nil, err
+	}
+	for _, value := range m.globals {
+		freeze(value)
+	}
+	return m, nil
+}
+
+// Has reports whether the module defines a function with the given name
+func (m *Module) Has(name string) bool {
+	_, ok := m.globals[name].(*Function)
+	return ok
+}
+
+// Call calls a function defined by the module under the module's limits
+func (m *Module) Call(ctx context.Context, name string, args ...Value) (_ *Result, err error) {
+	defer recoverPanic(&err)
+	fn, ok := m.globals[name].(*Function)
+	if !ok {
+		return nil, &Error{Msg: fmt.Sprintf("script does not define %s()", name)}
+	}
+	t, cancel := newThread(ctx, m.limits)
+	defer cancel()
+
+	value, err := t.callFunction(fn, args, nil)
+	result := &Result{Value: value, Steps: t.steps, Output: t.output.String()}
+	if err != nil {
+		return result, err
+	}
+	return result, nil
+}
+
+// recoverPanic turns a panic in the interpreter into an error, so a bug a
+// script reaches fails the call rather than the process running it
+func recoverPanic(err *error) {
+	if r := recover(); r != nil {
+		*err = &Error{Msg: fmt.Sprintf("internal error: %v", r)}
+	}
+}
+
+type thread struct {
+	ctx       context.Context
+	limits    Limits
+	steps     int64
+	nextCheck int64
+	depth     int
+	active    map[*defStmt]bool
+	output    strings.Builder
+}
+
+func newThread(ctx context.Context, limits Limits) (*thread, context.CancelFunc) {
+	cancel := context.CancelFunc(func() {})
+	if limits.Timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, limits.Timeout)
+	}
+	return &thread{ctx: ctx, limits: limits, active: make(map[*defStmt]bool)}, cancel
+}
+
+// tick charges n steps and periodically checks for cancellation
+func (t *thread) tick(n int64) error {
+	t.steps += n
+	if t.limits.MaxSteps > 0 && t.steps > t.limits.MaxSteps {
+		return fmt.Errorf("%w: exceeded %d steps", ErrLimitExceeded, t.limits.MaxSteps)
+	}
+	if t.steps >= t.nextCheck {
+		t.nextCheck = t.steps + 256
+		if err := t.ctx.Err(); err != nil {
+			if errors.Is(err, context.DeadlineExceeded) {
+				return fmt.Errorf("%w: exceeded time limit of %s", ErrLimitExceeded, t.limits.Timeout)
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// checkSize rejects collections larger than the collection limit
+func (t *thread) checkSize(n int) error {
+	if t.limits.MaxCollectionSize > 0 && n > t.limits.MaxCollectionSize {
+		return fmt.Errorf("%w: collection of %d elements exceeds the limit of %d", ErrLimitExceeded, n, t.limits.MaxCollectionSize)
+	}
+	return nil
+}
+
+func (t *thread) print(s string) {
+	if t.output.Len()+len(s) > maxOutputBytes {
+		return
+	}
+	t.output.WriteString(s)
+}
+
+// frame holds variables of a function call, the module top level or a
+// comprehension, whose parent is the enclosing frame
+type frame struct {
+	vars   map[string]Value
+	parent *frame
+	def    *defStmt
+}
+
+func (m *Module) lookup(fr *frame, name string) (Value, error) {
+	for f := fr; f != nil; f = f.parent {
+		if v, ok := f.vars[name]; ok {
+			return v, nil
+		}
+		if f.def != nil && f.def.locals[name] {
+			return nil, fmt.Errorf("local variable %q referenced before assignment", name)
+		}
+	}
+	if v, ok := m.globals[name]; ok {
+		return v, nil
+	}
+	if v, ok := m.predeclared[name]; ok {
+		return v, nil
+	}
+	if b, ok := universe[name]; ok {
+		return b, nil
+	}
+	return nil, fmt.Errorf("undefined: %s", name)
+}
+
+func at(pos Pos, err error) error {
+	var scriptErr *Error
+	if errors.As(err, &scriptErr) {
+		return err
+	}
+	return &Error{Pos: pos, Msg: err.Error(), cause: err}
+}
+
+type control int
+
+const (
+	ctrlNone control = iota
+	ctrlBreak
+	ctrlContinue
+	ctrlReturn
+)
+
+func (t *thread) execBlock(m *Module, fr *frame, stmts []stmt) (control, Value, error) {
+	for _, s := range stmts {
+		ctrl, value, err := t.exec(m, fr, s)
+		if err != nil || ctrl != ctrlNone {
+			return ctrl, value, err
+		}
+	}
+	return ctrlNone, nil, nil
+}
+
+func (t *thread) exec(m *Module, fr *frame, s stmt) (control, Value, error) {
+	if err := t.tick(1); err != nil {
+		return ctrlNone, nil, at(s.position(), err)
+	}
+
+	switch s := s.(type) {
+	case *exprStmt:
+		_, err := t.eval(m, fr, s.x)
+		return ctrlNone, nil, err
+
+	case *assignStmt:
+		return ctrlNone, nil, t.assign(m, fr, s)
+
+	case *ifStmt:
+		cond, err := t.eval(m, fr, s.cond)
+		if err != nil {
+			return ctrlNone, nil, err
+		}
+		if truth(cond) {
+			return t.execBlock(m, fr, s.then)
+		}
+		return t.execBlock(m, fr, s.els)
+
+	case *forStmt:
+		iterable, err := t.eval(m, fr, s.iter)
+		if err != nil {
+			return ctrlNone, nil, err
+		}
+		elems, err := iterate(iterable)
+		if err != nil {
+			return ctrlNone, nil, at(s.iter.position(), err)
+		}
+		for _, elem := range elems {
+			if err := t.tick(1); err != nil {
+				return ctrlNone, nil, at(s.pos, err)
+			}
+			if err := t.bind(m, fr, s.vars, elem); err != nil {
+				return ctrlNone, nil, err
+			}
+			ctrl, value, err := t.execBlock(m, fr, s.body)
+			if err != nil {
+				return ctrlNone, nil, err
+			}
+			switch ctrl {
+			case ctrlBreak:
+				return ctrlNone, nil, nil
+			case ctrlReturn:
+				return ctrl, value, nil
+			}
+		}
+		return ctrlNone, nil, nil
+
+	case *defStmt:
+		fn := &Function{def: s, module: m}
+		for _, prm := range s.params {
+			if prm.dflt == nil {
+				fn.dflts = append(fn.dflts, nil)
+				continue
+			}
+			value, err := t.eval(m, fr, prm.dflt)
+			if err != nil {
+				return ctrlNone, nil, err
+			}
+			freeze(value)
+			fn.dflts = append(fn.dflts, value)
+		}
+		fr.vars[s.name] = fn
+		return ctrlNone, nil, nil
+
+	case *returnStmt:
+		if s.x == nil {
+			return ctrlReturn, nil, nil
+		}
+		value, err := t.eval(m, fr, s.x)
+		return ctrlReturn, value, err
+
+	case *branchStmt:
+		switch s.kind {
+		case "break":
+			return ctrlBreak, nil, nil
+		case "continue":
+			return ctrlContinue, nil, nil
+		}
+		return ctrlNone, nil, nil
+	}
+	return ctrlNone, nil, at(s.position(), fmt.Errorf("unsupported statement"))
+}
+
+func (t *thread) assign(m *Module, fr *frame, s *assignStmt) error {
+	rhs, err := t.eval(m, fr, s.rhs)
+	if err != nil {
+		return err
+	}
+	if s.op == "=" {
+		return t.bind(m, fr, s.lhs, rhs)
+	}
+
+	op := strings.TrimSuffix(s.op, "=")
+	switch lhs := s.lhs.(type) {
+	case *identExpr:
+		current, err := m.lookup(fr, lhs.name)
+		if err != nil {
+			return at(lhs.pos, err)
+		}
+		// += on a list extends it in place
+		if list, ok := current.(*List); ok && op == "+" {
+			if err := t.extend(list, rhs); err != nil {
+				return at(s.pos, err)
+			}
+			return nil
+		}
+		value, err := t.binary(op, current, rhs)
+		if err != nil {
+			return at(s.pos, err)
+		}
+		fr.vars[lhs.name] = value
+		return nil
+	case *indexExpr:
+		container, err := t.eval(m, fr, lhs.x)
+		if err != nil {
+			return err
+		}
+		index, err := t.eval(m, fr, lhs.index)
+		if err != nil {
+			return err
+		}
+		current, err := getIndex(container, index)
+		if err != nil {
+			return at(lhs.pos, err)
+		}
+		value, err := t.binary(op, current, rhs)
+		if err != nil {
+			return at(s.pos, err)
+		}
+		return at(lhs.pos, setIndex(t, container, index, value))
+	}
+	return at(s.pos, fmt.Errorf("invalid augmented assignment target"))
+}
+
+// bind assigns a value to an identifier, index or tuple target
+func (t *thread) bind(m *Module, fr *frame, target expr, value Value) error {
+	switch target := target.(type) {
+	case *identExpr:
+		fr.vars[target.name] = value
+		return nil
+	case *indexExpr:
+		container, err := t.eval(m, fr, target.x)
+		if err != nil {
+			return err
+		}
+		index, err := t.eval(m, fr, target.index)
+		if err != nil {
+			return err
+		}
+		if err := setIndex(t, container, index, value); err != nil {
+			return at(target.pos, err)
+		}
+		return nil
+	case *tupleExpr, *listExpr:
+		var targets []expr
+		if tuple, ok := target.(*tupleExpr); ok {
+			targets = tuple.elems
+		} else {
+			targets = target.(*listExpr).elems
+		}
+		elems, err := iterate(value)
+		if err != nil {
+			return at(target.position(), err)
+		}
+		if len(elems) != len(targets) {
+			return at(target.position(), fmt.Errorf("cannot unpack %d values into %d variables", len(elems), len(targets)))
+		}
+		for i, elem := range targets {
+			if err := t.bind(m, fr, elem, elems[i]); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return at(target.position(), fmt.Errorf("invalid assignment target"))
+}
+
+func (t *thread) eval(m *Module, fr *frame, e expr) (Value, error) {
+	value, err := t.evalExpr(m, fr, e)
+	if err != nil {
+		return nil, at(e.position(), err)
+	}
+	return value, nil
+}
+
+func (t *thread) evalExpr(m *Module, fr *frame, e expr) (Value, error) {
+	switch e := e.(type) {
+	case *identExpr:
+		return m.lookup(fr, e.name)
+
+	case *literalExpr:
+		return e.value, nil
+
+	case *listExpr:
+		elems, err := t.evalAll(m, fr, e.elems)
+		if err != nil {
+			return nil, err
+		}
+		return NewList(elems), nil
+
+	case *tupleExpr:
+		elems, err := t.evalAll(m, fr, e.elems)
+		if err != nil {
+			return nil, err
+		}
+		return Tuple(elems), nil
+
+	case *dictExpr:
+		d := NewDict()
+		for i := range e.keys {
+			key, err := t.eval(m, fr, e.keys[i])
+			if err != nil {
+				return nil, err
+			}
+			value, err := t.eval(m, fr, e.values[i])
+			if err != nil {
+				return nil, err
+			}
+			if err := d.Set(key, value); err != nil {
+				return nil, at(e.keys[i].position(), err)
+			}
+		}
+		return d, t.checkSize(d.Len())
+
+	case *unaryExpr:
+		x, err := t.eval(m, fr, e.x)
+		if err != nil {
+			return nil, err
+		}
+		switch e.op {
+		case "not":
+			return !truth(x), nil
+		case "-":
+			switch x := x.(type) {
+			case int64:
+				if x == math.MinInt64 {
+					return nil, fmt.Errorf("integer overflow")
+				}
+				return -x, nil
+			case float64:
+				return -x, nil
+			}
+		case "+":
+			if isNumber(x) {
+				return x, nil
+			}
+		}
+		return nil, fmt.Errorf("unsupported operand for unary %s: %s", e.op, typeName(x))
+
+	case *binaryExpr:
+		x, err := t.eval(m, fr, e.x)
+		if err != nil {
+			return nil, err
+		}
+		switch e.op {
+		case "and":
+			if !truth(x) {
+				return x, nil
+			}
+			return t.eval(m, fr, e.y)
+		case "or":
+			if truth(x) {
+				return x, nil
+			}
+			return t.eval(m, fr, e.y)
+		}
+		y, err := t.eval(m, fr, e.y)
+		if err != nil {
+			return nil, err
+		}
+		return t.binary(e.op, x, y)
+
+	case *condExpr:
+		cond, err := t.eval(m, fr, e.cond)
+		if err != nil {
+			return nil, err
+		}
+		if truth(cond) {
+			return t.eval(m, fr, e.yes)
+		}
+		return t.eval(m, fr, e.orElse)
+
+	case *callExpr:
+		return t.evalCall(m, fr, e)
+
+	case *indexExpr:
+		x, err := t.eval(m, fr, e.x)
+		if err != nil {
+			return nil, err
+		}
+		index, err := t.eval(m, fr, e.index)
+		if err != nil {
+			return nil, err
+		}
+		return getIndex(x, index)
+
+	case *sliceExpr:
+		x, err := t.eval(m, fr, e.x)
+		if err != nil {
+			return nil, err
+		}
+		var lo, hi Value
+		if e.lo != nil {
+			if lo, err = t.eval(m, fr, e.lo); err != nil {
+				return nil, err
+			}
+		}
+		if e.hi != nil {
+			if hi, err = t.eval(m, fr, e.hi); err != nil {
+				return nil, err
+			}
+		}
+		return slice(x, lo, hi)
+
+	case *dotExpr:
+		x, err := t.eval(m, fr, e.x)
+		if err != nil {
+			return nil, err
+		}
+		return attr(x, e.name)
+
+	case *comprehensionExpr:
+		iterable, err := t.eval(m, fr, e.iter)
+		if err != nil {
+			return nil, err
+		}
+		elems, err := iterate(iterable)
+		if err != nil {
+			return nil, at(e.iter.position(), err)
+		}
+		inner := &frame{vars: make(map[string]Value), parent: fr}
+		var out []Value
+		for _, elem := range elems {
+			if err := t.tick(1); err != nil {
+				return nil, err
+			}
+			if err := t.bind(m, inner, e.vars, elem); err != nil {
+				return nil, err
+			}
+			if e.cond != nil {
+				keep, err := t.eval(m, inner, e.cond)
+				if err != nil {
+					return nil, err
+				}
+				if !truth(keep) {
+					continue
+				}
+			}
+			value, err := t.eval(m, inner, e.body)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, value)
+		}
+		return NewList(out), nil
+	}
+	return nil, fmt.Errorf("unsupported expression")
+}
+
+func (t *thread) evalAll(m *Module, fr *frame, exprs []expr) ([]Value, error) {
+	if err := t.checkSize(len(exprs)); err != nil {
+		return nil, err
+	}
+	values := make([]Value, len(exprs))
+	for i, e := range exprs {
+		value, err := t.eval(m, fr, e)
+		if err != nil {
+			return nil, err
+		}
+		values[i] = value
+	}
+	return values, nil
+}
+
+func (t *thread) evalCall(m *Module, fr *frame, e *callExpr) (Value, error) {
+	fn, err := t.eval(m, fr, e.fn)
+	if err != nil {
+		return nil, err
+	}
+	var (
+		args   []Value
+		kwargs []kwarg
+	)
+	for _, arg := range e.args {
+		value, err := t.eval(m, fr, arg.value)
+		if err != nil {
+			return nil, err
+		}
+		if arg.name == "" {
+			args = append(args, value)
+		} else {
+			kwargs = append(kwargs, kwarg{name: arg.name, value: value})
+		}
+	}
+	return t.call(fn, args, kwargs)
+}
+
+func (t *thread) call(fn Value, args []Value, kwargs []kwarg) (Value, error) {
+	if err := t.tick(1); err != nil {
+		return nil, err
+	}
+	switch fn := fn.(type) {
+	case *Function:
+		return t.callFunction(fn, args, kwargs)
+	case *Builtin:
+		return fn.fn(t, fn, args, kwargs)
+	}
+	return nil, fmt.Errorf("%s is not callable", typeName(fn))
+}
+
+func (t *thread) callFunction(fn *Function, args []Value, kwargs []kwarg) (Value, error) {
+	def := fn.def
+	if t.active[def] {
+		return nil, fmt.Errorf("recursive call to %s() is not allowed", def.name)
+	}
+	if t.limits.MaxDepth > 0 && t.depth >= t.limits.MaxDepth {
+		return nil, fmt.Errorf("%w: call depth exceeds %d", ErrLimitExceeded, t.limits.MaxDepth)
+	}
+
+	if len(args) > len(def.params) {
+		return nil, fmt.Errorf("%s() takes %d arguments but %d were given", def.name, len(def.params), len(args))
+	}
+	fr := &frame{vars: make(map[string]Value, len(def.locals)), def: def}
+	for i, arg := range args {
+		fr.vars[def.params[i].name] = arg
+	}
+	for _, kw := range kwargs {
+		found := false
+		for _, prm := range def.params {
+			if prm.name == kw.name {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("%s() got an unexpected keyword argument %q", def.name, kw.name)
+		}
+		if _, dup := fr.vars[kw.name]; dup {
+			return nil, fmt.Errorf("%s() got multiple values for argument %q", def.name, kw.name)
+		}
+		fr.vars[kw.name] = kw.value
+	}
+	for i, prm := range def.params {
+		if _, ok := fr.vars[prm.name]; ok {
+			continue
+		}
+		if prm.dflt == nil {
+			return nil, fmt.Errorf("%s() missing argument %q", def.name, prm.name)
+		}
+		fr.vars[prm.name] = fn.dflts[i]
+	}
+
+	t.active[def] = true
+	t.depth++
+	_, value, err := t.execBlock(fn.module, fr, def.body)
+	t.depth--
+	delete(t.active, def)
+	if err != nil {
+		var scriptErr *Error
+		if errors.As(err, &scriptErr) {
+			scriptErr.Stack = append([]string{def.name}, scriptErr.Stack...)
+		}
+		return nil, err
+	}
+	return value, nil
+}
+
+func (t *thread) binary(op string, x, y Value) (Value, error) {
+	switch op {
+	case "==":
+		return t.equal(x, y)
+	case "!=":
+		eq, err := t.equal(x, y)
+		return !eq, err
+	case "<", "<=", ">", ">=":
+		c, err := t.compare(x, y)
+		if err != nil {
+			return nil, err
+		}
+		switch op {
+		case "<":
+			return c < 0, nil
+		case "<=":
+			return c <= 0, nil
+		case ">":
+			return c > 0, nil
+		}
+		return c >= 0, nil
+	case "in", "not in":
+		found, err := t.contains(y, x)
+		if err != nil {
+			return nil, err
+		}
+		return found == (op == "in"), nil
+	}
+
+	if isNumber(x) && isNumber(y) {
+		return arith(op, x, y)
+	}
+
+	switch op {
+	case "+":
+		switch x := x.(type) {
+		case string:
+			if ys, ok := y.(string); ok {
+				if err := t.checkSize(len(x) + len(ys)); err != nil {
+					return nil, err
+				}
+				return x + ys, nil
+			}
+		case *List:
+			if yl, ok := y.(*List); ok {
+				if err := t.checkSize(len(x.elems) + len(yl.elems)); err != nil {
+					return nil, err
+				}
+				elems := append(append([]Value{}, x.elems...), yl.elems...)
+				return NewList(elems), nil
+			}
+		case Tuple:
+			if yt, ok := y.(Tuple); ok {
+				if err := t.checkSize(len(x) + len(yt)); err != nil {
+					return nil, err
+				}
+				return append(append(Tuple{}, x...), yt...), nil
+			}
+		}
+	case "*":
+		if n, ok := y.(int64); ok {
+			return t.repeat(x, n)
+		}
+		if n, ok := x.(int64); ok {
+			return t.repeat(y, n)
+		}
+	case "%":
+		if format, ok := x.(string); ok {
+			return t.format(format, y)
+		}
+	}
+	return nil, fmt.Errorf("unsupported operands for %s: %s and %s", op, typeName(x), typeName(y))
+}
+
+func arith(op string, x, y Value) (Value, error) {
+	xi, xInt := x.(int64)
+	yi, yInt := y.(int64)
+	if xInt && yInt {
+		switch op {
+		case "+":
+			sum := xi + yi
+			if (sum > xi) != (yi > 0) {
+				return nil, fmt.Errorf("integer overflow")
+			}
+			return sum, nil
+		case "-":
+			diff := xi - yi
+			if (diff < xi) != (yi > 0) {
+				return nil, fmt.Errorf("integer overflow")
+			}
+			return diff, nil
+		case "*":
+			if xi != 0 && yi != 0 {
+				product := xi * yi
+				if product/yi != xi || (xi == -1 && yi == math.MinInt64) || (yi == -1 && xi == math.MinInt64) {
+					return nil, fmt.Errorf("integer overflow")
+				}
+				return product, nil
+			}
+			return int64(0), nil
+		case "/":
+			if yi == 0 {
+				return nil, fmt.Errorf("division by zero")
+			}
+			return float64(xi) / float64(yi), nil
+		case "//":
+			if yi == 0 {
+				return nil, fmt.Errorf("division by zero")
+			}
+			if xi == math.MinInt64 && yi == -1 {
+				return nil, fmt.Errorf("integer overflow")
+			}
+			q := xi / yi
+			if (xi%yi != 0) && ((xi < 0) != (yi < 0)) {
+				q--
+			}
+			return q, nil
+		case "%":
+			if yi == 0 {
+				return nil, fmt.Errorf("division by zero")
+			}
+			if yi == -1 {
+				return int64(0), nil
+			}
+			r := xi % yi
+			if r != 0 && ((r < 0) != (yi < 0)) {
+				r += yi
+			}
+			return r, nil
+		}
+	}
+
+	xf, yf := toFloat(x), toFloat(y)
+	switch op {
+	case "+":
+		return xf + yf, nil
+	case "-":
+		return xf - yf, nil
+	case "*":
+		return xf * yf, nil
+	case "/":
+		if yf == 0 {
+			return nil, fmt.Errorf("division by zero")
+		}
+		return xf / yf, nil
+	case "//":
+		if yf == 0 {
+			return nil, fmt.Errorf("division by zero")
+		}
+		return math.Floor(xf / yf), nil
+	case "%":
+		if yf == 0 {
+			return nil, fmt.Errorf("division by zero")
+		}
+		r := math.Mod(xf, yf)
+		if r != 0 && ((r < 0) != (yf < 0)) {
+			r += yf
+		}
+		return r, nil
+	}
+	return nil, fmt.Errorf("unsupported operands for %s: %s and %s", op, typeName(x), typeName(y))
+}
+
+func (t *thread) repeat(x Value, n int64) (Value, error) {
+	if n < 0 {
+		n = 0
+	}
+	switch x := x.(type) {
+	case string:
+		if err := t.checkSize(repeatedSize(n, len(x))); err != nil {
+			return nil, err
+		}
+		return strings.Repeat(x, int(n)), nil
+	case *List:
+		elems, err := t.repeatSeq(x.elems, n)
+		if err != nil {
+			return nil, err
+		}
+		return NewList(elems), nil
+	case Tuple:
+		elems, err := t.repeatSeq(x, n)
+		if err != nil {
+			return nil, err
+		}
+		return Tuple(elems), nil
+	}
+	return nil, fmt.Errorf("unsupported operands for *: %s and int", typeName(x))
+}
+
+func (t *thread) repeatSeq(elems []Value, n int64) ([]Value, error) {
+	size := repeatedSize(n, len(elems))
+	if err := t.checkSize(size); err != nil {
+		return nil, err
+	}
+	if err := t.tick(int64(size)); err != nil {
+		return nil, err
+	}
+	out := make([]Value, 0, size)
+	for len(out) < size {
+		out = append(out, elems...)
+	}
+	return out, nil
+}
+
+// repeatedSize returns n*size, saturating at math.MaxInt so that an
+// overflowing size fails the size check instead of wrapping around
+func repeatedSize(n int64, size int) int {
+	if n <= 0 || size == 0 {
+		return 0
+	}
+	if size > 0 && n > int64(math.MaxInt/size) {
+		return math.MaxInt
+	}
+	return int(n) * size
+}
+
+// format implements the % operator for %s, %r, %d, %f and %%
+func (t *thread) format(format string, args Value) (Value, error) {
+	var values []Value
+	if tuple, ok := args.(Tuple); ok {
+		values = tuple
+	} else {
+		values = []Value{args}
+	}
+
+	var b strings.Builder
+	next := 0
+	for i := 0; i < len(format); i++ {
+		c := format[i]
+		if c != '%' {
+			b.WriteByte(c)
+			continue
+		}
+		i++
+		if i >= len(format) {
+			return nil, fmt.Errorf("incomplete format")
+		}
+		verb := format[i]
+		if verb == '%' {
+			b.WriteByte('%')
+			continue
+		}
+		if next >= len(values) {
+			return nil, fmt.Errorf("not enough arguments for format string")
+		}
+		value := values[next]
+		next++
+		switch verb {
+		case 's', 'r':
+			var s string
+			var err error
+			if verb == 's' {
+				s, err = t.str(value)
+			} else {
+				s, err = t.repr(value)
+			}
+			if err != nil {
+				return nil, err
+			}
+			b.WriteString(s)
+		case 'd':
+			switch v := value.(type) {
+			case int64:
+				fmt.Fprintf(&b, "%d", v)
+			case float64:
+				fmt.Fprintf(&b, "%d", int64(v))
+			default:
+				return nil, fmt.Errorf("%%d format requires a number, not %s", typeName(value))
+			}
+		case 'f':
+			if !isNumber(value) {
+				return nil, fmt.Errorf("%%f format requires a number, not %s", typeName(value))
+			}
+			fmt.Fprintf(&b, "%f", toFloat(value))
+		default:
+			return nil, fmt.Errorf("unsupported format verb %%%c", verb)
+		}
+		if err := t.checkSize(b.Len()); err != nil {
+			return nil, err
+		}
+	}
+	if next < len(values) {
+		return nil, fmt.Errorf("too many arguments for format string")
+	}
+	return b.String(), nil
+}
+
+func (t *thread) extend(list *List, values Value) error {
+	if list.frozen {
+		return fmt.Errorf("cannot modify a frozen list")
+	}
+	elems, err := iterate(values)
+	if err != nil {
+		return err
+	}
+	if err := t.checkSize(len(list.elems) + len(elems)); err != nil {
+		return err
+	}
+	if err := t.tick(int64(len(elems))); err != nil {
+		return err
+	}
+	list.elems = append(list.elems, elems...)
+	return nil
+}
+
+// iterate returns the elements of a list, tuple or dict (its keys)
+func iterate(v Value) ([]Value, error) {
+	switch v := v.(type) {
+	case *List:
+		return append([]Value{}, v.elems...), nil
+	case Tuple:
+		return v, nil
+	case *Dict:
+		return append([]Value{}, v.keys...), nil
+	}
+	return nil, fmt.Errorf("%s is not iterable", typeName(v))
+}
+
+func (t *thread) contains(container, x Value) (bool, error) {
+	switch c := container.(type) {
+	case string:
+		s, ok := x.(string)
+		if !ok {
+			return false, fmt.Errorf("'in <string>' requires a string, not %s", typeName(x))
+		}
+		return strings.Contains(c, s), nil
+	case *Dict:
+		_, found, err := c.Get(x)
+		return found, err
+	case *List, Tuple:
+		elems, _ := iterate(c)
+		if err := t.tick(int64(len(elems))); err != nil {
+			return false, err
+		}
+		for _, elem := range elems {
+			eq, err := t.equal(elem, x)
+			if err != nil {
+				return false, err
+			}
+			if eq {
+				return true, nil
+			}
+		}
+		return false, nil
+	}
+	return false, fmt.Errorf("'in' requires a list, tuple, dict or string, not %s", typeName(container))
+}
+
+func sequenceIndex(index Value, length int) (int, error) {
+	i, ok := index.(int64)
+	if !ok {
+		return 0, fmt.Errorf("index must be an int, not %s", typeName(index))
+	}
+	if i < 0 {
+		i += int64(length)
+	}
+	if i < 0 || i >= int64(length) {
+		return 0, fmt.Errorf("index %d out of range for length %d", index, length)
+	}
+	return int(i), nil
+}
+
+func getIndex(x, index Value) (Value, error) {
+	switch x := x.(type) {
+	case *List:
+		i, err := sequenceIndex(index, len(x.elems))
+		if err != nil {
+			return nil, err
+		}
+		return x.elems[i], nil
+	case Tuple:
+		i, err := sequenceIndex(index, len(x))
+		if err != nil {
+			return nil, err
+		}
+		return x[i], nil
+	case string:
+		i, err := sequenceIndex(index, len(x))
+		if err != nil {
+			return nil, err
+		}
+		return x[i : i+1], nil
+	case *Dict:
+		value, found, err := x.Get(index)
+		if err != nil {
+			return nil, err
+		}
+		if !found {
+			return nil, fmt.Errorf("key %s not found", repr(index))
+		}
+		return value, nil
+	}
+	return nil, fmt.Errorf("%s is not indexable", typeName(x))
+}
+
+func setIndex(t *thread, x, index, value Value) error {
+	switch x := x.(type) {
+	case *List:
+		if x.frozen {
+			return fmt.Errorf("cannot modify a frozen list")
+		}
+		i, err := sequenceIndex(index, len(x.elems))
+		if err != nil {
+			return err
+		}
+		x.elems[i] = value
+		return nil
+	case *Dict:
+		if err := x.Set(index, value); err != nil {
+			return err
+		}
+		return t.checkSize(x.Len())
+	}
+	return fmt.Errorf("%s does not support item assignment", typeName(x))
+}
+
+func slice(x, lo, hi Value) (Value, error) {
+	var length int
+	switch x := x.(type) {
+	case *List:
+		length = len(x.elems)
+	case Tuple:
+		length = len(x)
+	case string:
+		length = len(x)
+	default:
+		return nil, fmt.Errorf("%s cannot be sliced", typeName(x))
+	}
+
+	bound := func(v Value, dflt int) (int, error) {
+		if v == nil {
+			return dflt, nil
+		}
+		i, ok := v.(int64)
+		if !ok {
+			return 0, fmt.Errorf("slice index must be an int, not %s", typeName(v))
+		}
+		if i < 0 {
+			i += int64(length)
+		}
+		return int(max(0, min(i, int64(length)))), nil
+	}
+	start, err := bound(lo, 0)
+	if err != nil {
+		return nil, err
+	}
+	end, err := bound(hi, length)
+	if err != nil {
+		return nil, err
+	}
+	if end < start {
+		end = start
+	}
+
+	switch x := x.(type) {
+	case *List:
+		return NewList(append([]Value{}, x.elems[start:end]...)), nil
+	case Tuple:
+		return append(Tuple{}, x[start:end]...), nil
+	}
+	return x.(string)[start:end], nil
+}
+
+func attr(x Value, name string) (Value, error) {
+	if s, ok := x.(*Struct); ok {
+		if value, found := s.fields[name]; found {
+			return value, nil
+		}
+		return nil, fmt.Errorf("%s has no field %q", s.name, name)
+	}
+	if method, ok := boundMethod(x, name); ok {
+		return method, nil
+	}
+	return nil, fmt.Errorf("%s has no attribute %q", typeName(x), name)
+}
+
+// Bool returns the result of a condition function, which must be a bool
+func (r *Result) Bool() (bool, error) {
+	b, ok := r.Value.(bool)
+	if !ok {
+		return false, fmt.Errorf("expected a bool result, not %s", typeName(r.Value))
+	}
+	return b, nil
+}
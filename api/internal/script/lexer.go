@@ -0,0 +1,299 @@
+package script
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Pos is a position in a script's source
+type Pos struct {
+	Line int `json:"line"`
+	Col  int `json:"col"`
+}
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokNewline
+	tokIndent
+	tokDedent
+	tokIdent
+	tokKeyword
+	tokInt
+	tokFloat
+	tokString
+	tokOp
+)
+
+type token struct {
+	kind  tokenKind
+	text  string
+	value interface{} // int64, float64 or string for literals
+	pos   Pos
+}
+
+var keywords = map[string]bool{
+	"and": true, "break": true, "continue": true, "def": true, "elif": true,
+	"else": true, "for": true, "if": true, "in": true, "not": true, "or": true,
+	"pass": true, "return": true, "True": true, "False": true, "None": true,
+	// Reserved so that scripts fail clearly instead of misparsing
+	"lambda": true, "load": true, "while": true, "import": true, "class": true,
+	"global": true, "nonlocal": true, "try": true, "except": true, "with": true,
+	"yield": true, "del": true, "assert": true, "from": true, "as": true, "is": true,
+}
+
+// operators are matched longest first
+var operators = []string{
+	"//=", "**",
+	"==", "!=", "<=", ">=", "+=", "-=", "*=", "/=", "%=", "//",
+	"+", "-", "*", "/", "%", "<", ">", "=", "(", ")", "[", "]", "{", "}", ",", ":", ".",
+}
+
+// scan converts source to tokens, emitting INDENT and DEDENT tokens for changes
+// of indentation and NEWLINE at the end of each logical line
+func scan(src string) ([]token, error) {
+	var (
+		tokens  []token
+		indents = []int{0}
+		depth   int // bracket nesting; newlines inside brackets are ignored
+		line    = 1
+		i       int
+	)
+	lineStart := true
+
+	emit := func(kind tokenKind, text string, value interface{}, col int) {
+		tokens = append(tokens, token{kind: kind, text: text, value: value, pos: Pos{Line: line, Col: col}})
+	}
+	col := func() int {
+		start := strings.LastIndexByte(src[:i], '\n')
+		return i - start
+	}
+
+	for i < len(src) {
+		if lineStart && depth == 0 {
+			width := 0
+			j := i
+			for j < len(src) && (src[j] == ' ' || src[j] == '\t') {
+				if src[j] == '\t' {
+					return nil, &Error{Pos: Pos{Line: line, Col: j - i + 1}, Msg: "tabs are not allowed for indentation"}
+				}
+				width++
+				j++
+			}
+			// Blank and comment-only lines do not affect indentation
+			if j >= len(src) || src[j] == '\n' || src[j] == '#' || src[j] == '\r' {
+				for j < len(src) && src[j] != '\n' {
+					j++
+				}
+				i = j
+				if i < len(src) {
+					i++
+					line++
+				}
+				continue
+			}
+			i = j
+			lineStart = false
+			switch current := indents[len(indents)-1]; {
+			case width > current:
+				indents = append(indents, width)
+				emit(tokIndent, "", nil, 1)
+			case width < current:
+				for width < indents[len(indents)-1] {
+					indents = indents[:len(indents)-1]
+					emit(tokDedent, "", nil, 1)
+				}
+				if width != indents[len(indents)-1] {
+					return nil, &Error{Pos: Pos{Line: line, Col: 1}, Msg: "unindent does not match any outer indentation level"}
+				}
+			}
+		}
+
+		c := src[i]
+		switch {
+		case c == '\n':
+			if depth == 0 && len(tokens) > 0 && tokens[len(tokens)-1].kind != tokNewline {
+				emit(tokNewline, "", nil, col())
+			}
+			i++
+			line++
+			lineStart = depth == 0
+		case c == ' ' || c == '\t' || c == '\r':
+			i++
+		case c == '\\' && i+1 < len(src) && src[i+1] == '\n':
+			i += 2
+			line++
+		case c == '#':
+			for i < len(src) && src[i] != '\n' {
+				i++
+			}
+		case isIdentStart(c):
+			start, startCol := i, col()
+			for i < len(src) && isIdentPart(src[i]) {
+				i++
+			}
+			word := src[start:i]
+			if keywords[word] {
+				emit(tokKeyword, word, nil, startCol)
+			} else {
+				emit(tokIdent, word, nil, startCol)
+			}
+		case isDigit(c) || (c == '.' && i+1 < len(src) && isDigit(src[i+1])):
+			startCol := col()
+			tok, err := scanNumber(src, &i)
+			if err != nil {
+				return nil, &Error{Pos: Pos{Line: line, Col: startCol}, Msg: err.Error()}
+			}
+			tok.pos = Pos{Line: line, Col: startCol}
+			tokens = append(tokens, tok)
+		case c == '"' || c == '\'':
+			startLine, startCol := line, col()
+			value, lines, err := scanString(src, &i)
+			if err != nil {
+				return nil, &Error{Pos: Pos{Line: startLine, Col: startCol}, Msg: err.Error()}
+			}
+			tokens = append(tokens, token{kind: tokString, value: value, pos: Pos{Line: startLine, Col: startCol}})
+			line += lines
+		default:
+			matched := ""
+			for _, op := range operators {
+				if strings.HasPrefix(src[i:], op) {
+					matched = op
+					break
+				}
+			}
+			if matched == "" {
+				return nil, &Error{Pos: Pos{Line: line, Col: col()}, Msg: fmt.Sprintf("unexpected character %q", c)}
+			}
+			switch matched {
+			case "(", "[", "{":
+				depth++
+			case ")", "]", "}":
+				if depth > 0 {
+					depth--
+				}
+			}
+			emit(tokOp, matched, nil, col())
+			i += len(matched)
+		}
+	}
+
+	if len(tokens) > 0 && tokens[len(tokens)-1].kind != tokNewline {
+		emit(tokNewline, "", nil, 1)
+	}
+	for len(indents) > 1 {
+		indents = indents[:len(indents)-1]
+		emit(tokDedent, "", nil, 1)
+	}
+	emit(tokEOF, "", nil, 1)
+	return tokens, nil
+}
+
+func scanNumber(src string, i *int) (token, error) {
+	start := *i
+	isFloat := false
+	for *i < len(src) && isDigit(src[*i]) {
+		*i++
+	}
+	if *i < len(src) && src[*i] == '.' {
+		isFloat = true
+		*i++
+		for *i < len(src) && isDigit(src[*i]) {
+			*i++
+		}
+	}
+	if *i < len(src) && (src[*i] == 'e' || src[*i] == 'E') {
+		isFloat = true
+		*i++
+		if *i < len(src) && (src[*i] == '+' || src[*i] == '-') {
+			*i++
+		}
+		for *i < len(src) && isDigit(src[*i]) {
+			*i++
+		}
+	}
+	text := src[start:*i]
+	if *i < len(src) && isIdentStart(src[*i]) {
+		return token{}, fmt.Errorf("invalid number %q", text+string(src[*i]))
+	}
+	if isFloat {
+		f, err := strconv.ParseFloat(text, 64)
+		if err != nil {
+			return token{}, fmt.Errorf("invalid number %q", text)
+		}
+		return token{kind: tokFloat, text: text, value: f}, nil
+	}
+	n, err := strconv.ParseInt(text, 10, 64)
+	if err != nil {
+		return token{}, fmt.Errorf("integer %s is out of range", text)
+	}
+	return token{kind: tokInt, text: text, value: n}, nil
+}
+
+// scanString reads a quoted or triple-quoted string literal and returns its value
+// and the number of newlines it spans
+func scanString(src string, i *int) (string, int, error) {
+	quote := src[*i]
+	triple := strings.HasPrefix(src[*i:], strings.Repeat(string(quote), 3))
+	if triple {
+		*i += 3
+	} else {
+		*i++
+	}
+
+	var b strings.Builder
+	lines := 0
+	for *i < len(src) {
+		c := src[*i]
+		switch {
+		case triple && strings.HasPrefix(src[*i:], strings.Repeat(string(quote), 3)):
+			*i += 3
+			return b.String(), lines, nil
+		case !triple && c == quote:
+			*i++
+			return b.String(), lines, nil
+		case c == '\n':
+			if !triple {
+				return "", lines, fmt.Errorf("unterminated string")
+			}
+			lines++
+			b.WriteByte(c)
+			*i++
+		case c == '\\' && *i+1 < len(src):
+			next := src[*i+1]
+			switch next {
+			case 'n':
+				b.WriteByte('\n')
+			case 't':
+				b.WriteByte('\t')
+			case 'r':
+				b.WriteByte('\r')
+			case '\\', '\'', '"':
+				b.WriteByte(next)
+			case '\n':
+				lines++
+			default:
+				return "", lines, fmt.Errorf("invalid escape sequence \\%c", next)
+			}
+			*i += 2
+		default:
+			b.WriteByte(c)
+			*i++
+		}
+	}
+	return "", lines, fmt.Errorf("unterminated string")
+}
+
+func isIdentStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isIdentPart(c byte) bool {
+	return isIdentStart(c) || isDigit(c)
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
@@ -0,0 +1,973 @@
+package script
+
+import (
+	"fmt"
+)
+
+// Error is a script compile or runtime error at a source position
+type Error struct {
+	Pos   Pos
+	Msg   string
+	Stack []string // function names, innermost last, for runtime errors
+	cause error
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("line %d:%d: %s", e.Pos.Line, e.Pos.Col, e.Msg)
+}
+
+// Unwrap returns the limit error behind a runtime error, if any
+func (e *Error) Unwrap() error {
+	return e.cause
+}
+
+type (
+	stmt interface{ position() Pos }
+	expr interface{ position() Pos }
+
+	exprStmt struct {
+		pos Pos
+		x   expr
+	}
+	assignStmt struct {
+		pos Pos
+		op  string // "=", "+=", "-=", "*=", "/=", "//=", "%="
+		lhs expr
+		rhs expr
+	}
+	ifStmt struct {
+		pos  Pos
+		cond expr
+		then []stmt
+		els  []stmt
+	}
+	forStmt struct {
+		pos  Pos
+		vars expr
+		iter expr
+		body []stmt
+	}
+	defStmt struct {
+		pos    Pos
+		name   string
+		params []param
+		body   []stmt
+		locals map[string]bool
+	}
+	returnStmt struct {
+		pos Pos
+		x   expr
+	}
+	branchStmt struct {
+		pos  Pos
+		kind string // break, continue, pass
+	}
+
+	param struct {
+		name string
+		dflt expr
+	}
+
+	identExpr struct {
+		pos  Pos
+		name string
+	}
+	literalExpr struct {
+		pos   Pos
+		value Value
+	}
+	listExpr struct {
+		pos   Pos
+		elems []expr
+	}
+	tupleExpr struct {
+		pos   Pos
+		elems []expr
+	}
+	dictExpr struct {
+		pos    Pos
+		keys   []expr
+		values []expr
+	}
+	unaryExpr struct {
+		pos Pos
+		op  string
+		x   expr
+	}
+	binaryExpr struct {
+		pos  Pos
+		op   string
+		x, y expr
+	}
+	condExpr struct {
+		pos               Pos
+		cond, yes, orElse expr
+	}
+	callExpr struct {
+		pos  Pos
+		fn   expr
+		args []argument
+	}
+	argument struct {
+		name  string // set for keyword arguments
+		value expr
+	}
+	indexExpr struct {
+		pos      Pos
+		x, index expr
+	}
+	sliceExpr struct {
+		pos       Pos
+		x, lo, hi expr
+	}
+	dotExpr struct {
+		pos  Pos
+		x    expr
+		name string
+	}
+	comprehensionExpr struct {
+		pos  Pos
+		body expr
+		vars expr
+		iter expr
+		cond expr
+	}
+)
+
+func (s *exprStmt) position() Pos          { return s.pos }
+func (s *assignStmt) position() Pos        { return s.pos }
+func (s *ifStmt) position() Pos            { return s.pos }
+func (s *forStmt) position() Pos           { return s.pos }
+func (s *defStmt) position() Pos           { return s.pos }
+func (s *returnStmt) position() Pos        { return s.pos }
+func (s *branchStmt) position() Pos        { return s.pos }
+func (e *identExpr) position() Pos         { return e.pos }
+func (e *literalExpr) position() Pos       { return e.pos }
+func (e *listExpr) position() Pos          { return e.pos }
+func (e *tupleExpr) position() Pos         { return e.pos }
+func (e *dictExpr) position() Pos          { return e.pos }
+func (e *unaryExpr) position() Pos         { return e.pos }
+func (e *binaryExpr) position() Pos        { return e.pos }
+func (e *condExpr) position() Pos          { return e.pos }
+func (e *callExpr) position() Pos          { return e.pos }
+func (e *indexExpr) position() Pos         { return e.pos }
+func (e *sliceExpr) position() Pos         { return e.pos }
+func (e *dotExpr) position() Pos           { return e.pos }
+func (e *comprehensionExpr) position() Pos { return e.pos }
+
+type parser struct {
+	tokens []token
+	i      int
+	// loops counts enclosing for loops, for break and continue
+	loops int
+	// def is the function being parsed, nil at top level
+	def *defStmt
+	// nesting counts enclosing expressions and blocks, bounded by maxNesting
+	nesting int
+}
+
+// maxNesting bounds how deeply expressions and blocks may nest, which keeps
+// parsing and evaluation recursion shallow
+const maxNesting = 100
+
+func parse(src string) ([]stmt, error) {
+	tokens, err := scan(src)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{tokens: tokens}
+	var stmts []stmt
+	for p.peek().kind != tokEOF {
+		s, err := p.parseStmt()
+		if err != nil {
+			return nil, err
+		}
+		stmts = append(stmts, s)
+	}
+	return stmts, nil
+}
+
+func (p *parser) peek() token { return p.tokens[p.i] }
+
+func (p *parser) next() token {
+	t := p.tokens[p.i]
+	if t.kind != tokEOF {
+		p.i++
+	}
+	return t
+}
+
+func (p *parser) is(text string) bool {
+	t := p.peek()
+	return (t.kind == tokOp || t.kind == tokKeyword) && t.text == text
+}
+
+func (p *parser) accept(text string) bool {
+	if p.is(text) {
+		p.i++
+		return true
+	}
+	return false
+}
+
+func (p *parser) expect(text string) (token, error) {
+	if !p.is(text) {
+		return token{}, p.errorf("expected %q, found %s", text, describe(p.peek()))
+	}
+	return p.next(), nil
+}
+
+func (p *parser) errorf(format string, args ...interface{}) error {
+	return &Error{Pos: p.peek().pos, Msg: fmt.Sprintf(format, args...)}
+}
+
+func (p *parser) enter() error {
+	p.nesting++
+	if p.nesting > maxNesting {
+		return p.errorf("code nested more than %d levels deep", maxNesting)
+	}
+	return nil
+}
+
+func (p *parser) leave() { p.nesting-- }
+
+func describe(t token) string {
+	switch t.kind {
+	case tokEOF:
+		return "end of script"
+	case tokNewline:
+		return "end of line"
+	case tokIndent:
+		return "unexpected indentation"
+	case tokDedent:
+		return "end of block"
+	case tokString:
+		return "string"
+	case tokInt, tokFloat:
+		return "number " + t.text
+	}
+	return fmt.Sprintf("%q", t.text)
+}
+
+func (p *parser) parseStmt() (stmt, error) {
+	t := p.peek()
+	if t.kind == tokKeyword {
+		switch t.text {
+		case "def":
+			return p.parseDef()
+		case "if":
+			p.next()
+			return p.parseIf(t.pos)
+		case "for":
+			return p.parseFor()
+		}
+	}
+	if t.kind == tokIndent {
+		return nil, p.errorf("unexpected indentation")
+	}
+	s, err := p.parseSimpleStmt()
+	if err != nil {
+		return nil, err
+	}
+	if p.peek().kind != tokNewline {
+		return nil, p.errorf("expected end of line, found %s", describe(p.peek()))
+	}
+	p.next()
+	return s, nil
+}
+
+func (p *parser) parseSimpleStmt() (stmt, error) {
+	t := p.peek()
+	if t.kind == tokKeyword {
+		switch t.text {
+		case "return":
+			p.next()
+			if p.def == nil {
+				return nil, &Error{Pos: t.pos, Msg: "return outside function"}
+			}
+			if p.peek().kind == tokNewline {
+				return &returnStmt{pos: t.pos}, nil
+			}
+			x, err := p.parseExprList()
+			if err != nil {
+				return nil, err
+			}
+			return &returnStmt{pos: t.pos, x: x}, nil
+		case "break", "continue":
+			p.next()
+			if p.loops == 0 {
+				return nil, &Error{Pos: t.pos, Msg: t.text + " outside loop"}
+			}
+			return &branchStmt{pos: t.pos, kind: t.text}, nil
+		case "pass":
+			p.next()
+			return &branchStmt{pos: t.pos, kind: "pass"}, nil
+		case "while":
+			return nil, &Error{Pos: t.pos, Msg: "while loops are not supported; use for with range()"}
+		case "load", "import", "from":
+			return nil, &Error{Pos: t.pos, Msg: "scripts cannot load other modules"}
+		case "lambda", "class", "global", "nonlocal", "try", "except", "with", "yield", "del", "assert", "as", "is":
+			return nil, &Error{Pos: t.pos, Msg: fmt.Sprintf("%q is not supported", t.text)}
+		}
+	}
+
+	lhs, err := p.parseExprList()
+	if err != nil {
+		return nil, err
+	}
+	for _, op := range []string{"=", "+=", "-=", "*=", "/=", "//=", "%="} {
+		if !p.is(op) {
+			continue
+		}
+		opTok := p.next()
+		if err := p.checkTarget(lhs, op == "="); err != nil {
+			return nil, err
+		}
+		rhs, err := p.parseExprList()
+		if err != nil {
+			return nil, err
+		}
+		return &assignStmt{pos: opTok.pos, op: op, lhs: lhs, rhs: rhs}, nil
+	}
+	return &exprStmt{pos: t.pos, x: lhs}, nil
+}
+
+// checkTarget validates an assignment target and records assigned names as locals
+// of the enclosing function
+func (p *parser) checkTarget(target expr, allowTuple bool) error {
+	switch t := target.(type) {
+	case *identExpr:
+		if p.def != nil {
+			p.def.locals[t.name] = true
+		}
+		return nil
+	case *indexExpr, *dotExpr:
+		if _, ok := t.(*dotExpr); ok {
+			return &Error{Pos: t.position(), Msg: "cannot assign to a field"}
+		}
+		return nil
+	case *tupleExpr:
+		if allowTuple {
+			for _, elem := range t.elems {
+				if err := p.checkTarget(elem, true); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+	case *listExpr:
+		if allowTuple {
+			for _, elem := range t.elems {
+				if err := p.checkTarget(elem, true); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+	}
+	return &Error{Pos: target.position(), Msg: "invalid assignment target"}
+}
+
+func (p *parser) parseDef() (stmt, error) {
+	defTok := p.next()
+	if p.def != nil {
+		return nil, &Error{Pos: defTok.pos, Msg: "nested functions are not supported"}
+	}
+	name := p.next()
+	if name.kind != tokIdent {
+		return nil, &Error{Pos: name.pos, Msg: "expected function name"}
+	}
+	if _, err := p.expect("("); err != nil {
+		return nil, err
+	}
+
+	def := &defStmt{pos: defTok.pos, name: name.text, locals: make(map[string]bool)}
+	seen := make(map[string]bool)
+	for !p.is(")") {
+		paramTok := p.next()
+		if paramTok.kind != tokIdent {
+			if paramTok.text == "*" || paramTok.text == "**" {
+				return nil, &Error{Pos: paramTok.pos, Msg: "variadic parameters are not supported"}
+			}
+			return nil, &Error{Pos: paramTok.pos, Msg: "expected parameter name"}
+		}
+		if seen[paramTok.text] {
+			return nil, &Error{Pos: paramTok.pos, Msg: fmt.Sprintf("duplicate parameter %q", paramTok.text)}
+		}
+		seen[paramTok.text] = true
+		prm := param{name: paramTok.text}
+		if p.accept("=") {
+			dflt, err := p.parseExpr()
+			if err != nil {
+				return nil, err
+			}
+			prm.dflt = dflt
+		} else if len(def.params) > 0 && def.params[len(def.params)-1].dflt != nil {
+			return nil, &Error{Pos: paramTok.pos, Msg: "parameter without a default follows a parameter with a default"}
+		}
+		def.params = append(def.params, prm)
+		def.locals[prm.name] = true
+		if !p.accept(",") {
+			break
+		}
+	}
+	if _, err := p.expect(")"); err != nil {
+		return nil, err
+	}
+
+	p.def = def
+	body, err := p.parseBlock()
+	p.def = nil
+	if err != nil {
+		return nil, err
+	}
+	def.body = body
+	return def, nil
+}
+
+func (p *parser) parseIf(pos Pos) (stmt, error) {
+	cond, err := p.parseExpr()
+	if err != nil {
+		return nil, err
+	}
+	then, err := p.parseBlock()
+	if err != nil {
+		return nil, err
+	}
+	s := &ifStmt{pos: pos, cond: cond, then: then}
+	switch {
+	case p.is("elif"):
+		elifTok := p.next()
+		nested, err := p.parseIf(elifTok.pos)
+		if err != nil {
+			return nil, err
+		}
+		s.els = []stmt{nested}
+	case p.is("else"):
+		p.next()
+		if s.els, err = p.parseBlock(); err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+func (p *parser) parseFor() (stmt, error) {
+	forTok := p.next()
+	if p.def == nil {
+		return nil, &Error{Pos: forTok.pos, Msg: "for loops are only allowed inside functions"}
+	}
+	vars, err := p.parseTargets()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := p.expect("in"); err != nil {
+		return nil, err
+	}
+	iter, err := p.parseExprList()
+	if err != nil {
+		return nil, err
+	}
+	p.loops++
+	body, err := p.parseBlock()
+	p.loops--
+	if err != nil {
+		return nil, err
+	}
+	return &forStmt{pos: forTok.pos, vars: vars, iter: iter, body: body}, nil
+}
+
+// parseTargets parses the loop variables of a for loop or comprehension
+func (p *parser) parseTargets() (expr, error) {
+	start := p.peek().pos
+	var elems []expr
+	for {
+		t := p.next()
+		if t.kind != tokIdent {
+			return nil, &Error{Pos: t.pos, Msg: "expected loop variable"}
+		}
+		elems = append(elems, &identExpr{pos: t.pos, name: t.text})
+		if !p.accept(",") {
+			break
+		}
+	}
+	var target expr = elems[0]
+	if len(elems) > 1 {
+		target = &tupleExpr{pos: start, elems: elems}
+	}
+	if err := p.checkTarget(target, true); err != nil {
+		return nil, err
+	}
+	return target, nil
+}
+
+// parseBlock parses ":" followed by an indented block or a simple statement on
+// the same line
+func (p *parser) parseBlock() ([]stmt, error) {
+	if _, err := p.expect(":"); err != nil {
+		return nil, err
+	}
+	if err := p.enter(); err != nil {
+		return nil, err
+	}
+	defer p.leave()
+	if p.peek().kind != tokNewline {
+		s, err := p.parseSimpleStmt()
+		if err != nil {
+			return nil, err
+		}
+		if p.peek().kind != tokNewline {
+			return nil, p.errorf("expected end of line, found %s", describe(p.peek()))
+		}
+		p.next()
+		return []stmt{s}, nil
+	}
+	p.next()
+	if p.peek().kind != tokIndent {
+		return nil, p.errorf("expected an indented block")
+	}
+	p.next()
+
+	var body []stmt
+	for p.peek().kind != tokDedent && p.peek().kind != tokEOF {
+		s, err := p.parseStmt()
+		if err != nil {
+			return nil, err
+		}
+		body = append(body, s)
+	}
+	p.next()
+	return body, nil
+}
+
+// parseExprList parses one expression or a comma separated tuple
+func (p *parser) parseExprList() (expr, error) {
+	start := p.peek().pos
+	x, err := p.parseExpr()
+	if err != nil {
+		return nil, err
+	}
+	if !p.is(",") {
+		return x, nil
+	}
+	elems := []expr{x}
+	for p.accept(",") {
+		if p.endsExprList() {
+			break
+		}
+		elem, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		elems = append(elems, elem)
+	}
+	return &tupleExpr{pos: start, elems: elems}, nil
+}
+
+func (p *parser) endsExprList() bool {
+	t := p.peek()
+	if t.kind == tokNewline || t.kind == tokEOF {
+		return true
+	}
+	return t.kind == tokOp && (t.text == "=" || t.text == ")" || t.text == "]" || t.text == "}" || t.text == ":")
+}
+
+func (p *parser) parseExpr() (expr, error) {
+	if err := p.enter(); err != nil {
+		return nil, err
+	}
+	defer p.leave()
+
+	x, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if !p.is("if") {
+		return x, nil
+	}
+	ifTok := p.next()
+	cond, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := p.expect("else"); err != nil {
+		return nil, err
+	}
+	orElse, err := p.parseExpr()
+	if err != nil {
+		return nil, err
+	}
+	return &condExpr{pos: ifTok.pos, cond: cond, yes: x, orElse: orElse}, nil
+}
+
+func (p *parser) parseOr() (expr, error) {
+	x, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.is("or") {
+		opTok := p.next()
+		y, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		x = &binaryExpr{pos: opTok.pos, op: "or", x: x, y: y}
+	}
+	return x, nil
+}
+
+func (p *parser) parseAnd() (expr, error) {
+	x, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for p.is("and") {
+		opTok := p.next()
+		y, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		x = &binaryExpr{pos: opTok.pos, op: "and", x: x, y: y}
+	}
+	return x, nil
+}
+
+func (p *parser) parseNot() (expr, error) {
+	if p.is("not") {
+		if err := p.enter(); err != nil {
+			return nil, err
+		}
+		defer p.leave()
+		opTok := p.next()
+		x, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return &unaryExpr{pos: opTok.pos, op: "not", x: x}, nil
+	}
+	return p.parseComparison()
+}
+
+func (p *parser) parseComparison() (expr, error) {
+	x, err := p.parseArith()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		var op string
+		t := p.peek()
+		switch {
+		case t.kind == tokOp && (t.text == "==" || t.text == "!=" || t.text == "<" || t.text == "<=" || t.text == ">" || t.text == ">="):
+			op = t.text
+			p.next()
+		case p.is("in"):
+			op = "in"
+			p.next()
+		case p.is("not") && p.tokens[p.i+1].kind == tokKeyword && p.tokens[p.i+1].text == "in":
+			op = "not in"
+			p.i += 2
+		case p.is("is"):
+			return nil, p.errorf("\"is\" is not supported; use ==")
+		default:
+			return x, nil
+		}
+		y, err := p.parseArith()
+		if err != nil {
+			return nil, err
+		}
+		x = &binaryExpr{pos: t.pos, op: op, x: x, y: y}
+	}
+}
+
+func (p *parser) parseArith() (expr, error) {
+	x, err := p.parseTerm()
+	if err != nil {
+		return nil, err
+	}
+	for p.is("+") || p.is("-") {
+		opTok := p.next()
+		y, err := p.parseTerm()
+		if err != nil {
+			return nil, err
+		}
+		x = &binaryExpr{pos: opTok.pos, op: opTok.text, x: x, y: y}
+	}
+	return x, nil
+}
+
+func (p *parser) parseTerm() (expr, error) {
+	x, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.is("*") || p.is("/") || p.is("//") || p.is("%") {
+		opTok := p.next()
+		y, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		x = &binaryExpr{pos: opTok.pos, op: opTok.text, x: x, y: y}
+	}
+	return x, nil
+}
+
+func (p *parser) parseUnary() (expr, error) {
+	if p.is("-") || p.is("+") {
+		if err := p.enter(); err != nil {
+			return nil, err
+		}
+		defer p.leave()
+		opTok := p.next()
+		x, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &unaryExpr{pos: opTok.pos, op: opTok.text, x: x}, nil
+	}
+	if p.is("**") {
+		return nil, p.errorf("exponentiation is not supported")
+	}
+	return p.parsePostfix()
+}
+
+func (p *parser) parsePostfix() (expr, error) {
+	x, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		t := p.peek()
+		switch {
+		case p.is("."):
+			p.next()
+			name := p.next()
+			if name.kind != tokIdent {
+				return nil, &Error{Pos: name.pos, Msg: "expected field or method name"}
+			}
+			x = &dotExpr{pos: name.pos, x: x, name: name.text}
+		case p.is("("):
+			p.next()
+			args, err := p.parseArgs()
+			if err != nil {
+				return nil, err
+			}
+			x = &callExpr{pos: t.pos, fn: x, args: args}
+		case p.is("["):
+			p.next()
+			var lo, hi expr
+			if !p.is(":") {
+				if lo, err = p.parseExpr(); err != nil {
+					return nil, err
+				}
+			}
+			if p.accept(":") {
+				if !p.is("]") {
+					if hi, err = p.parseExpr(); err != nil {
+						return nil, err
+					}
+				}
+				if _, err := p.expect("]"); err != nil {
+					return nil, err
+				}
+				x = &sliceExpr{pos: t.pos, x: x, lo: lo, hi: hi}
+				continue
+			}
+			if _, err := p.expect("]"); err != nil {
+				return nil, err
+			}
+			x = &indexExpr{pos: t.pos, x: x, index: lo}
+		default:
+			return x, nil
+		}
+	}
+}
+
+func (p *parser) parseArgs() ([]argument, error) {
+	var args []argument
+	keywords := false
+	for !p.is(")") {
+		if p.is("*") || p.is("**") {
+			return nil, p.errorf("argument unpacking is not supported")
+		}
+		var arg argument
+		if p.peek().kind == tokIdent && p.tokens[p.i+1].kind == tokOp && p.tokens[p.i+1].text == "=" {
+			arg.name = p.next().text
+			p.next()
+			keywords = true
+		} else if keywords {
+			return nil, p.errorf("positional argument follows keyword argument")
+		}
+		value, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		arg.value = value
+		args = append(args, arg)
+		if !p.accept(",") {
+			break
+		}
+	}
+	if _, err := p.expect(")"); err != nil {
+		return nil, err
+	}
+	return args, nil
+}
+
+func (p *parser) parsePrimary() (expr, error) {
+	t := p.next()
+	switch t.kind {
+	case tokIdent:
+		return &identExpr{pos: t.pos, name: t.text}, nil
+	case tokInt, tokFloat, tokString:
+		value := t.value
+		// Adjacent string literals are concatenated
+		if s, ok := value.(string); ok {
+			for p.peek().kind == tokString {
+				s += p.next().value.(string)
+			}
+			value = s
+		}
+		return &literalExpr{pos: t.pos, value: value}, nil
+	case tokKeyword:
+		switch t.text {
+		case "True":
+			return &literalExpr{pos: t.pos, value: true}, nil
+		case "False":
+			return &literalExpr{pos: t.pos, value: false}, nil
+		case "None":
+			return &literalExpr{pos: t.pos, value: nil}, nil
+		case "lambda":
+			return nil, &Error{Pos: t.pos, Msg: "lambda is not supported; define a function with def"}
+		}
+	case tokOp:
+		switch t.text {
+		case "(":
+			if p.accept(")") {
+				return &tupleExpr{pos: t.pos}, nil
+			}
+			x, err := p.parseExpr()
+			if err != nil {
+				return nil, err
+			}
+			if p.accept(")") {
+				return x, nil
+			}
+			elems := []expr{x}
+			for p.accept(",") {
+				if p.is(")") {
+					break
+				}
+				elem, err := p.parseExpr()
+				if err != nil {
+					return nil, err
+				}
+				elems = append(elems, elem)
+			}
+			if _, err := p.expect(")"); err != nil {
+				return nil, err
+			}
+			return &tupleExpr{pos: t.pos, elems: elems}, nil
+		case "[":
+			return p.parseList(t.pos)
+		case "{":
+			return p.parseDict(t.pos)
+		}
+	}
+	p.i--
+	return nil, p.errorf("unexpected %s", describe(t))
+}
+
+func (p *parser) parseList(pos Pos) (expr, error) {
+	var elems []expr
+	for !p.is("]") {
+		elem, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		if len(elems) == 0 && p.is("for") {
+			return p.parseComprehension(pos, elem)
+		}
+		elems = append(elems, elem)
+		if !p.accept(",") {
+			break
+		}
+	}
+	if _, err := p.expect("]"); err != nil {
+		return nil, err
+	}
+	return &listExpr{pos: pos, elems: elems}, nil
+}
+
+func (p *parser) parseComprehension(pos Pos, body expr) (expr, error) {
+	forTok := p.next()
+	vars, err := p.parseComprehensionTargets(forTok.pos)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := p.expect("in"); err != nil {
+		return nil, err
+	}
+	iter, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	c := &comprehensionExpr{pos: pos, body: body, vars: vars, iter: iter}
+	if p.accept("if") {
+		if c.cond, err = p.parseOr(); err != nil {
+			return nil, err
+		}
+	}
+	if p.is("for") {
+		return nil, p.errorf("nested comprehensions are not supported")
+	}
+	if _, err := p.expect("]"); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// parseComprehensionTargets parses comprehension variables, which are scoped to
+// the comprehension rather than the enclosing function
+func (p *parser) parseComprehensionTargets(pos Pos) (expr, error) {
+	var elems []expr
+	for {
+		t := p.next()
+		if t.kind != tokIdent {
+			return nil, &Error{Pos: t.pos, Msg: "expected loop variable"}
+		}
+		elems = append(elems, &identExpr{pos: t.pos, name: t.text})
+		if !p.accept(",") {
+			break
+		}
+	}
+	if len(elems) == 1 {
+		return elems[0], nil
+	}
+	return &tupleExpr{pos: pos, elems: elems}, nil
+}
+
+func (p *parser) parseDict(pos Pos) (expr, error) {
+	d := &dictExpr{pos: pos}
+	for !p.is("}") {
+		key, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		if _, err := p.expect(":"); err != nil {
+			return nil, err
+		}
+		value, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		d.keys = append(d.keys, key)
+		d.values = append(d.values, value)
+		if !p.accept(",") {
+			break
+		}
+	}
+	if _, err := p.expect("}"); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
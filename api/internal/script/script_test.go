@@ -0,0 +1,423 @@
+package script
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func load(t *testing.T, src string, predeclared map[string]Value) *Module {
+	t.Helper()
+	prog, err := Compile("test.star", src)
+	require.NoError(t, err)
+	m, err := prog.Init(context.Background(), predeclared, DefaultLimits)
+	require.NoError(t, err)
+	return m
+}
+
+func call(t *testing.T, m *Module, name string, args ...Value) Value {
+	t.Helper()
+	result, err := m.Call(context.Background(), name, args...)
+	require.NoError(t, err)
+	return result.Value
+}
+
+func TestScriptEvaluatesConditions(t *testing.T) {
+	const src = `
+HIGH_RISK = ("card_declined", "fraudulent")
+
+def evaluate(failure, ctx):
+    limit = tables["limits"].get(failure.currency, 1000)
+    if failure.amount > limit and failure.code in HIGH_RISK:
+        return True
+    elif "vip" in failure.tags:
+        return ctx.business_hours
+    return False
+`
+	tables, err := FromGo(map[string]interface{}{"limits": map[string]interface{}{"AUD": 500, "USD": 800.0}})
+	require.NoError(t, err)
+	m := load(t, src, map[string]Value{"tables": tables})
+
+	failure := func(amount float64, currency, code string, tags ...string) Value {
+		tagList, _ := FromGo(tags)
+		return NewStruct("failure", map[string]Value{"amount": amount, "currency": currency, "code": code, "tags": tagList})
+	}
+	ctx := NewStruct("context", map[string]Value{"business_hours": false})
+
+	assert.Equal(t, true, call(t, m, "evaluate", failure(600, "AUD", "card_declined"), ctx))
+	assert.Equal(t, false, call(t, m, "evaluate", failure(600, "USD", "card_declined"), ctx))
+	assert.Equal(t, false, call(t, m, "evaluate", failure(1200, "EUR", "expired_card"), ctx))
+	assert.Equal(t, false, call(t, m, "evaluate", failure(10, "EUR", "expired_card", "vip"), ctx))
+	assert.True(t, m.Has("evaluate"))
+	assert.False(t, m.Has("actions"))
+	assert.False(t, m.Has("HIGH_RISK"))
+}
+
+func TestScriptLanguage(t *testing.T) {
+	const src = `
+def fib(n):
+    a, b = 0, 1
+    for _ in range(n):
+        a, b = b, a + b
+    return a
+
+def describe(items, sep=", "):
+    counts = {}
+    for item in items:
+        counts[item] = counts.get(item, 0) + 1
+    parts = ["%s=%d" % (k, v) for k, v in counts.items() if v > 1]
+    return sep.join(sorted(parts, reverse=True))
+
+def misc():
+    xs = [3, 1, 2]
+    xs.append(4)
+    xs += [5]
+    total = 0
+    for i, x in enumerate(xs):
+        if x == 2:
+            continue
+        if x == 5:
+            break
+        total += i * x
+    return struct(
+        total = total,
+        slice = xs[1:-1],
+        neg = -7 // 2,
+        mod = -7 % 3,
+        div = 7 / 2,
+        cond = "yes" if total > 10 else "no",
+        minmax = (min(xs), max(xs, key = lambda_free_key)),
+        rounded = (round(2.5), round(3.14159, 2)),
+        words = "  A b  C ".strip().lower().split(),
+        zipped = dict(zip(["a", "b"], [1, 2])),
+        nested = {(1, "x"): True}[(1.0, "x")],
+    )
+
+def lambda_free_key(x):
+    return -x
+`
+	m := load(t, src, nil)
+
+	assert.Equal(t, int64(55), call(t, m, "fib", int64(10)))
+	assert.Equal(t, "b=3, a=2", call(t, m, "describe", NewList([]Value{"a", "b", "b", "c", "a", "b"})))
+
+	got := ToGo(call(t, m, "misc")).(map[string]interface{})
+	assert.Equal(t, int64(0*3+1*1+3*4), got["total"])
+	assert.Equal(t, []interface{}{int64(1), int64(2), int64(4)}, got["slice"])
+	assert.Equal(t, int64(-4), got["neg"])
+	assert.Equal(t, int64(2), got["mod"])
+	assert.Equal(t, 3.5, got["div"])
+	assert.Equal(t, "yes", got["cond"])
+	assert.Equal(t, []interface{}{int64(1), int64(1)}, got["minmax"])
+	assert.Equal(t, []interface{}{int64(2), 3.14}, got["rounded"])
+	assert.Equal(t, []interface{}{"a", "b", "c"}, got["words"])
+	assert.Equal(t, map[string]interface{}{"a": int64(1), "b": int64(2)}, got["zipped"])
+	assert.Equal(t, true, got["nested"])
+}
+
+func TestScriptActions(t *testing.T) {
+	const src = `
+def actions(failure, ctx):
+    out = [action("schedule_retry", delay = "2h", business_hours = True)]
+    if failure.amount > 100:
+        out.append(action("manager_escalation", reason = "amount %d" % failure.amount))
+    return out
+`
+	m := load(t, src, nil)
+	value := call(t, m, "actions", NewStruct("failure", map[string]Value{"amount": int64(250)}), nil)
+
+	requests, err := Actions(value)
+	require.NoError(t, err)
+	assert.Equal(t, []ActionRequest{
+		{Type: "schedule_retry", Params: map[string]interface{}{"delay": "2h", "business_hours": true}},
+		{Type: "manager_escalation", Params: map[string]interface{}{"reason": "amount 250"}},
+	}, requests)
+
+	_, err = Actions(NewList([]Value{"schedule_retry"}))
+	assert.Error(t, err)
+	requests, err = Actions(nil)
+	assert.NoError(t, err)
+	assert.Empty(t, requests)
+}
+
+func TestScriptCompileErrors(t *testing.T) {
+	cases := map[string]string{
+		"line 1:1: while loops are not supported":      "while True:\n    pass\n",
+		"line 1:1: scripts cannot load other modules":  "import os\n",
+		"line 2:7: \"is\" is not supported":            "def f(x):\n    x is None\n",
+		"line 1:1: for loops are only allowed":         "for x in []:\n    pass\n",
+		"line 3:1: unindent does not match":            "def f():\n    x = 1\n  return x\n",
+		"line 2:7: return outside function":            "x = 1\nif x: return 1\n",
+		"line 1:14: unterminated string":               "def f(): x = 'abc\n",
+		"line 2:9: lambda is not supported":            "def f():\n    g = lambda x: x\n",
+		"line 2:5: nested functions are not supported": "def f():\n    def g(): pass\n",
+	}
+	for want, src := range cases {
+		_, err := Compile("bad.star", src)
+		require.Error(t, err, src)
+		var scriptErr *Error
+		require.True(t, errors.As(err, &scriptErr), src)
+		assert.Contains(t, err.Error(), want, src)
+	}
+}
+
+func TestScriptRuntimeErrors(t *testing.T) {
+	const src = `
+CONFIG = {"threshold": 10}
+
+def frozen():
+    CONFIG["threshold"] = 0
+
+def recurse(n):
+    return recurse(n - 1)
+
+def unbound():
+    if False:
+        x = 1
+    return x
+
+def failing(failure):
+    fail("unsupported currency", failure)
+
+def outer():
+    return inner()
+
+def inner():
+    return {}["missing"]
+`
+	m := load(t, src, nil)
+
+	cases := map[string]string{
+		"frozen":    "line 5:11: cannot modify a frozen dict",
+		"recurse":   "line 8:19: recursive call to recurse() is not allowed",
+		"unbound":   "line 13:12: local variable \"x\" referenced before assignment",
+		"failing":   "line 16:9: fail: unsupported currency AUD",
+		"outer":     "line 22:14: key \"missing\" not found",
+		"undefined": "script does not define undefined()",
+	}
+	for name, want := range cases {
+		var args []Value
+		if name == "recurse" {
+			args = []Value{int64(3)}
+		}
+		if name == "failing" {
+			args = []Value{"AUD"}
+		}
+		_, err := m.Call(context.Background(), name, args...)
+		require.Error(t, err, name)
+		assert.Contains(t, err.Error(), want, name)
+		assert.False(t, errors.Is(err, ErrLimitExceeded), name)
+	}
+
+	_, err := m.Call(context.Background(), "outer")
+	var scriptErr *Error
+	require.True(t, errors.As(err, &scriptErr))
+	assert.Equal(t, []string{"outer", "inner"}, scriptErr.Stack)
+}
+
+func TestScriptLimits(t *testing.T) {
+	const src = `
+def spin():
+    total = 0
+    for i in range(10000):
+        for j in range(10000):
+            total += 1
+    return total
+
+def grow():
+    xs = ["x"]
+    for _ in range(30):
+        xs = xs + xs
+    return len(xs)
+
+def big_string():
+    return "x" * 100000000
+`
+	prog, err := Compile("limits.star", src)
+	require.NoError(t, err)
+
+	limits := DefaultLimits
+	limits.MaxSteps = 5000
+	m, err := prog.Init(context.Background(), nil, limits)
+	require.NoError(t, err)
+
+	result, err := m.Call(context.Background(), "spin")
+	assert.True(t, errors.Is(err, ErrLimitExceeded), "%v", err)
+	assert.Contains(t, err.Error(), "exceeded 5000 steps")
+	assert.Greater(t, result.Steps, int64(5000))
+
+	for _, name := range []string{"grow", "big_string"} {
+		_, err = m.Call(context.Background(), name)
+		assert.True(t, errors.Is(err, ErrLimitExceeded), name)
+	}
+
+	limits = Limits{Timeout: 20 * time.Millisecond}
+	m, err = prog.Init(context.Background(), nil, limits)
+	require.NoError(t, err)
+	start := time.Now()
+	_, err = m.Call(context.Background(), "spin")
+	assert.True(t, errors.Is(err, ErrLimitExceeded), "%v", err)
+	assert.Contains(t, err.Error(), "time limit")
+	assert.Less(t, time.Since(start), time.Second)
+}
+
+func TestScriptIsDeterministic(t *testing.T) {
+	const src = `
+def evaluate(failure, ctx):
+    seen = {}
+    for key in ["zeta", "alpha", "mid", "alpha"]:
+        seen[key] = len(seen)
+    print("keys", list(seen.keys()))
+    return seen
+`
+	m := load(t, src, nil)
+	for i := 0; i < 20; i++ {
+		result, err := m.Call(context.Background(), "evaluate", nil, nil)
+		require.NoError(t, err)
+		assert.Equal(t, `{"zeta": 0, "alpha": 3, "mid": 2}`, repr(result.Value))
+		assert.Equal(t, "keys [\"zeta\", \"alpha\", \"mid\"]\n", result.Output)
+	}
+
+	for _, name := range []string{"time", "random", "open", "exec", "eval", "getenv"} {
+		prog, err := Compile("io.star", "def f():\n    return "+name+"()\n")
+		require.NoError(t, err)
+		m, err := prog.Init(context.Background(), nil, DefaultLimits)
+		require.NoError(t, err)
+		_, err = m.Call(context.Background(), "f")
+		assert.ErrorContains(t, err, "undefined: "+name)
+	}
+}
+
+func TestScriptSelfReferentialValues(t *testing.T) {
+	const src = `
+def cyclic_dict():
+    x = {}
+    x["a"] = x
+    return x == x
+
+def cyclic_list():
+    x = [1]
+    x.append(x)
+    return x < x
+
+def cyclic_in():
+    x = []
+    x.append(x)
+    return [x] in [[x]]
+
+def shared():
+    x = [1]
+    for _ in range(60):
+        x = [x, x]
+    y = [1]
+    for _ in range(60):
+        y = [y, y]
+    return x == y
+
+def deep_key():
+    t = ()
+    for _ in range(100):
+        t = (t,)
+    return {t: 1}
+
+def cyclic_repr():
+    x = {}
+    x["a"] = x
+    return repr(x)
+
+def shared_repr():
+    x = [1]
+    for _ in range(60):
+        x = [x, x]
+    return str(x)
+
+def cyclic_result():
+    x = []
+    x.append(x)
+    return x
+
+def cyclic_action():
+    x = {}
+    x["a"] = x
+    return [action("tag", value = x)]
+`
+	m := load(t, src, nil)
+
+	for _, name := range []string{"cyclic_dict", "cyclic_list", "cyclic_in", "shared", "deep_key", "shared_repr"} {
+		_, err := m.Call(context.Background(), name)
+		assert.True(t, errors.Is(err, ErrLimitExceeded), "%s: %v", name, err)
+	}
+
+	assert.Equal(t, `{"a": {...}}`, call(t, m, "cyclic_repr"))
+	assert.Equal(t, "[[...]]", ToGo(call(t, m, "cyclic_result")))
+
+	_, err := Actions(call(t, m, "cyclic_action"))
+	assert.True(t, errors.Is(err, ErrLimitExceeded), "%v", err)
+}
+
+func TestScriptChecksSizeBeforeBuilding(t *testing.T) {
+	const src = `
+def join():
+    return ",".join(["x" * 10000] * 9999)
+
+def replace():
+    return ("x" * 10000).replace("x", "yyyyyyyyyy")
+
+def concat():
+    s = "x" * 10000
+    return s + s
+
+def overflow():
+    return "xx" * 4611686018427387904
+
+def empty_repeat():
+    return len([] * 4611686018427387904)
+`
+	m := load(t, src, nil)
+	for _, name := range []string{"join", "replace", "concat", "overflow"} {
+		_, err := m.Call(context.Background(), name)
+		assert.True(t, errors.Is(err, ErrLimitExceeded), "%s: %v", name, err)
+	}
+	assert.Equal(t, int64(0), call(t, m, "empty_repeat"))
+}
+
+// FuzzScript runs arbitrary scripts under tight limits. A script may fail to
+// compile or run, but must not panic, hang or exhaust the stack.
+func FuzzScript(f *testing.F) {
+	for _, src := range []string{
+		"def f():\n    x = {}\n    x[\"a\"] = x\n    return x == x\n",
+		"def f():\n    x = [1]\n    x.append(x)\n    return str(x) + repr(sorted([x, x]))\n",
+		"def f():\n    return \",\".join([\"ab\"] * 50) % ()\n",
+		"def f():\n    t = ()\n    for i in range(80):\n        t = (t, i)\n    return {t: [t] * 3}\n",
+		"X = [1, 2.5, \"s\", None, True, (1,), {\"k\": []}]\ndef f():\n    return [v for v in X if v] + list(X[1:-1])\n",
+		"def f(a = 1, b = \"%d%%\"):\n    return b % a if a else max([a, 2], key = abs)\n",
+	} {
+		f.Add(src)
+	}
+	limits := Limits{MaxSteps: 20000, Timeout: 200 * time.Millisecond, MaxDepth: 8, MaxCollectionSize: 1000}
+	f.Fuzz(func(t *testing.T, src string) {
+		prog, err := Compile("fuzz.star", src)
+		if err != nil {
+			var scriptErr *Error
+			require.True(t, errors.As(err, &scriptErr), "%v", err)
+			require.NotContains(t, err.Error(), "internal error")
+			return
+		}
+		m, err := prog.Init(context.Background(), nil, limits)
+		if err == nil && m.Has("f") {
+			var result *Result
+			result, err = m.Call(context.Background(), "f")
+			if err == nil {
+				ToGo(result.Value)
+				repr(result.Value)
+			}
+		}
+		if err != nil {
+			require.NotContains(t, err.Error(), "internal error")
+		}
+	})
+}
@@ -0,0 +1,832 @@
+package script
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Value is a script value: nil, bool, int64, float64, string, *List, Tuple,
+// *Dict, *Struct, *Function or *Builtin
+type Value = interface{}
+
+// List is a mutable list; lists reachable from module globals are frozen
+type List struct {
+	elems  []Value
+	frozen bool
+}
+
+// NewList returns a list holding elems
+func NewList(elems []Value) *List {
+	return &List{elems: elems}
+}
+
+// Len returns the number of elements
+func (l *List) Len() int { return len(l.elems) }
+
+// Index returns the i'th element
+func (l *List) Index(i int) Value { return l.elems[i] }
+
+// Tuple is an immutable sequence
+type Tuple []Value
+
+// Dict is a mapping that iterates in insertion order
+type Dict struct {
+	keys   []Value
+	index  map[interface{}]int
+	values []Value
+	frozen bool
+}
+
+// NewDict returns an empty dict
+func NewDict() *Dict {
+	return &Dict{index: make(map[interface{}]int)}
+}
+
+// Len returns the number of entries
+func (d *Dict) Len() int { return len(d.keys) }
+
+// Get returns the value for key
+func (d *Dict) Get(key Value) (Value, bool, error) {
+	k, err := hashKey(key)
+	if err != nil {
+		return nil, false, err
+	}
+	i, ok := d.index[k]
+	if !ok {
+		return nil, false, nil
+	}
+	return d.values[i], true, nil
+}
+
+// Set adds or replaces the value for key
+func (d *Dict) Set(key, value Value) error {
+	if d.frozen {
+		return fmt.Errorf("cannot modify a frozen dict")
+	}
+	k, err := hashKey(key)
+	if err != nil {
+		return err
+	}
+	if i, ok := d.index[k]; ok {
+		d.values[i] = value
+		return nil
+	}
+	d.index[k] = len(d.keys)
+	d.keys = append(d.keys, key)
+	d.values = append(d.values, value)
+	return nil
+}
+
+func (d *Dict) delete(key Value) (Value, bool, error) {
+	if d.frozen {
+		return nil, false, fmt.Errorf("cannot modify a frozen dict")
+	}
+	k, err := hashKey(key)
+	if err != nil {
+		return nil, false, err
+	}
+	i, ok := d.index[k]
+	if !ok {
+		return nil, false, nil
+	}
+	value := d.values[i]
+	d.keys = append(d.keys[:i], d.keys[i+1:]...)
+	d.values = append(d.values[:i], d.values[i+1:]...)
+	delete(d.index, k)
+	for j := i; j < len(d.keys); j++ {
+		kj, _ := hashKey(d.keys[j])
+		d.index[kj] = j
+	}
+	return value, true, nil
+}
+
+// Struct is an immutable record with named fields, used for the failure and
+// context arguments and for action requests
+type Struct struct {
+	name   string
+	fields map[string]Value
+}
+
+// NewStruct returns a struct of the given type name
+func NewStruct(name string, fields map[string]Value) *Struct {
+	return &Struct{name: name, fields: fields}
+}
+
+// Field returns a field of the struct
+func (s *Struct) Field(name string) (Value, bool) {
+	v, ok := s.fields[name]
+	return v, ok
+}
+
+// Function is a function defined by a script
+type Function struct {
+	def    *defStmt
+	module *Module
+	dflts  []Value
+}
+
+// Builtin is a function provided by the host, possibly bound to a receiver
+type Builtin struct {
+	name string
+	recv Value
+	fn   func(t *thread, b *Builtin, args []Value, kwargs []kwarg) (Value, error)
+}
+
+type kwarg struct {
+	name  string
+	value Value
+}
+
+// tupleKey is the hashable form of a tuple used as a dict key
+type tupleKey string
+
+const (
+	// maxValueDepth bounds how deeply nested values are walked when they are
+	// compared, hashed, frozen, printed or converted, so that containers that
+	// contain themselves fail with a limit error instead of exhausting the stack
+	maxValueDepth = 64
+	// maxKeyBytes bounds the encoded size of a tuple used as a dict key
+	maxKeyBytes = 64 << 10
+)
+
+func errValueDepth() error {
+	return fmt.Errorf("%w: value nested more than %d levels deep", ErrLimitExceeded, maxValueDepth)
+}
+
+func hashKey(v Value) (interface{}, error) {
+	switch v := v.(type) {
+	case nil, bool, string:
+		return v, nil
+	case int64:
+		return v, nil
+	case float64:
+		// Integral floats hash like ints so 1 and 1.0 are the same key
+		if v == math.Trunc(v) && math.Abs(v) < 1<<63 {
+			return int64(v), nil
+		}
+		return v, nil
+	case Tuple:
+		var b strings.Builder
+		if err := writeKey(&b, v, 0); err != nil {
+			return nil, err
+		}
+		return tupleKey(b.String()), nil
+	}
+	return nil, fmt.Errorf("unhashable type: %s", typeName(v))
+}
+
+// writeKey writes a prefix-free encoding of a hashable value, so that two
+// tuples encode alike exactly when their elements hash alike
+func writeKey(b *strings.Builder, v Value, depth int) error {
+	if depth > maxValueDepth {
+		return errValueDepth()
+	}
+	if b.Len() > maxKeyBytes {
+		return fmt.Errorf("%w: dict key exceeds %d bytes", ErrLimitExceeded, maxKeyBytes)
+	}
+	switch v := v.(type) {
+	case nil:
+		b.WriteByte('N')
+	case bool:
+		if v {
+			b.WriteByte('T')
+		} else {
+			b.WriteByte('F')
+		}
+	case int64:
+		b.WriteString("i" + strconv.FormatInt(v, 10) + ";")
+	case float64:
+		if v == math.Trunc(v) && math.Abs(v) < 1<<63 {
+			b.WriteString("i" + strconv.FormatInt(int64(v), 10) + ";")
+		} else {
+			b.WriteString("f" + strconv.FormatFloat(v, 'g', -1, 64) + ";")
+		}
+	case string:
+		b.WriteString("s" + strconv.Itoa(len(v)) + ":" + v)
+	case Tuple:
+		b.WriteByte('(')
+		for _, elem := range v {
+			if err := writeKey(b, elem, depth+1); err != nil {
+				return err
+			}
+		}
+		b.WriteByte(')')
+	default:
+		return fmt.Errorf("unhashable type: %s", typeName(v))
+	}
+	return nil
+}
+
+func typeName(v Value) string {
+	switch v := v.(type) {
+	case nil:
+		return "NoneType"
+	case bool:
+		return "bool"
+	case int64:
+		return "int"
+	case float64:
+		return "float"
+	case string:
+		return "string"
+	case *List:
+		return "list"
+	case Tuple:
+		return "tuple"
+	case *Dict:
+		return "dict"
+	case *Struct:
+		return v.name
+	case *Function, *Builtin:
+		return "function"
+	}
+	return fmt.Sprintf("%T", v)
+}
+
+func truth(v Value) bool {
+	switch v := v.(type) {
+	case nil:
+		return false
+	case bool:
+		return v
+	case int64:
+		return v != 0
+	case float64:
+		return v != 0
+	case string:
+		return v != ""
+	case *List:
+		return len(v.elems) > 0
+	case Tuple:
+		return len(v) > 0
+	case *Dict:
+		return len(v.keys) > 0
+	}
+	return true
+}
+
+// freeze makes a value and everything reachable from it immutable
+func freeze(v Value) {
+	freezeValue(v, make(map[tupleID]bool))
+}
+
+// tupleID identifies a tuple by its backing array and length
+type tupleID struct {
+	first *Value
+	n     int
+}
+
+// freezeValue freezes v; seen holds the tuples already frozen, which unlike
+// lists and dicts carry no flag and may be shared many times over
+func freezeValue(v Value, seen map[tupleID]bool) {
+	switch v := v.(type) {
+	case *List:
+		if v.frozen {
+			return
+		}
+		v.frozen = true
+		for _, elem := range v.elems {
+			freezeValue(elem, seen)
+		}
+	case Tuple:
+		if len(v) == 0 || seen[tupleID{&v[0], len(v)}] {
+			return
+		}
+		seen[tupleID{&v[0], len(v)}] = true
+		for _, elem := range v {
+			freezeValue(elem, seen)
+		}
+	case *Dict:
+		if v.frozen {
+			return
+		}
+		v.frozen = true
+		for _, value := range v.values {
+			freezeValue(value, seen)
+		}
+	case *Struct:
+		for _, value := range v.fields {
+			freezeValue(value, seen)
+		}
+	}
+}
+
+// equal compares two values. Each container element compared is charged as a
+// step and nesting is bounded, so comparing containers that contain
+// themselves fails with a limit error.
+func (t *thread) equal(x, y Value) (bool, error) {
+	return t.equalAt(x, y, 0)
+}
+
+func (t *thread) equalAt(x, y Value, depth int) (bool, error) {
+	switch x := x.(type) {
+	case int64, float64:
+		if !isNumber(y) {
+			return false, nil
+		}
+		c, err := compareNumbers(x, y)
+		return c == 0, err
+	case *List:
+		yl, ok := y.(*List)
+		if !ok {
+			return false, nil
+		}
+		return t.equalSeq(x.elems, yl.elems, depth)
+	case Tuple:
+		yt, ok := y.(Tuple)
+		if !ok {
+			return false, nil
+		}
+		return t.equalSeq(x, yt, depth)
+	case *Dict:
+		yd, ok := y.(*Dict)
+		if !ok || len(x.keys) != len(yd.keys) {
+			return false, nil
+		}
+		if err := t.descend(depth, len(x.keys)); err != nil {
+			return false, err
+		}
+		for i, key := range x.keys {
+			other, found, err := yd.Get(key)
+			if err != nil || !found {
+				return false, err
+			}
+			if eq, err := t.equalAt(x.values[i], other, depth+1); err != nil || !eq {
+				return false, err
+			}
+		}
+		return true, nil
+	case *Struct:
+		ys, ok := y.(*Struct)
+		if !ok || x.name != ys.name || len(x.fields) != len(ys.fields) {
+			return false, nil
+		}
+		if err := t.descend(depth, len(x.fields)); err != nil {
+			return false, err
+		}
+		for name, value := range x.fields {
+			other, found := ys.fields[name]
+			if !found {
+				return false, nil
+			}
+			if eq, err := t.equalAt(value, other, depth+1); err != nil || !eq {
+				return false, err
+			}
+		}
+		return true, nil
+	case nil, bool, string:
+		return x == y, nil
+	}
+	return x == y, nil
+}
+
+func (t *thread) equalSeq(x, y []Value, depth int) (bool, error) {
+	if len(x) != len(y) {
+		return false, nil
+	}
+	if err := t.descend(depth, len(x)); err != nil {
+		return false, err
+	}
+	for i := range x {
+		if eq, err := t.equalAt(x[i], y[i], depth+1); err != nil || !eq {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// descend charges the n elements of a container about to be walked at the
+// given depth and rejects values nested deeper than maxValueDepth
+func (t *thread) descend(depth, n int) error {
+	if depth >= maxValueDepth {
+		return errValueDepth()
+	}
+	return t.tick(int64(n))
+}
+
+func isNumber(v Value) bool {
+	switch v.(type) {
+	case int64, float64:
+		return true
+	}
+	return false
+}
+
+func toFloat(v Value) float64 {
+	switch v := v.(type) {
+	case int64:
+		return float64(v)
+	case float64:
+		return v
+	}
+	return 0
+}
+
+func compareNumbers(x, y Value) (int, error) {
+	xi, xInt := x.(int64)
+	yi, yInt := y.(int64)
+	if xInt && yInt {
+		switch {
+		case xi < yi:
+			return -1, nil
+		case xi > yi:
+			return 1, nil
+		}
+		return 0, nil
+	}
+	xf, yf := toFloat(x), toFloat(y)
+	if math.IsNaN(xf) || math.IsNaN(yf) {
+		return 0, fmt.Errorf("cannot compare NaN")
+	}
+	switch {
+	case xf < yf:
+		return -1, nil
+	case xf > yf:
+		return 1, nil
+	}
+	return 0, nil
+}
+
+// compare orders two values of the same kind, charging and bounding the
+// elements of sequences walked like equal
+func (t *thread) compare(x, y Value) (int, error) {
+	return t.compareAt(x, y, 0)
+}
+
+func (t *thread) compareAt(x, y Value, depth int) (int, error) {
+	if isNumber(x) && isNumber(y) {
+		return compareNumbers(x, y)
+	}
+	switch x := x.(type) {
+	case string:
+		if ys, ok := y.(string); ok {
+			return strings.Compare(x, ys), nil
+		}
+	case bool:
+		if yb, ok := y.(bool); ok {
+			switch {
+			case x == yb:
+				return 0, nil
+			case !x:
+				return -1, nil
+			}
+			return 1, nil
+		}
+	case *List:
+		if yl, ok := y.(*List); ok {
+			return t.compareSeq(x.elems, yl.elems, depth)
+		}
+	case Tuple:
+		if yt, ok := y.(Tuple); ok {
+			return t.compareSeq(x, yt, depth)
+		}
+	}
+	return 0, fmt.Errorf("cannot compare %s and %s", typeName(x), typeName(y))
+}
+
+func (t *thread) compareSeq(x, y []Value, depth int) (int, error) {
+	if err := t.descend(depth, min(len(x), len(y))); err != nil {
+		return 0, err
+	}
+	for i := 0; i < len(x) && i < len(y); i++ {
+		c, err := t.compareAt(x[i], y[i], depth+1)
+		if err != nil || c != 0 {
+			return c, err
+		}
+	}
+	switch {
+	case len(x) < len(y):
+		return -1, nil
+	case len(x) > len(y):
+		return 1, nil
+	}
+	return 0, nil
+}
+
+// maxReprBytes bounds the repr of a value in an error message
+const maxReprBytes = 4 << 10
+
+// maxStringBytes bounds the strings str(), repr() and % formatting build when
+// the collection size is unlimited
+const maxStringBytes = 1 << 20
+
+// str returns the string form of a value for an error message
+func str(v Value) string {
+	if s, ok := v.(string); ok {
+		return s
+	}
+	return repr(v)
+}
+
+// repr returns the repr of a value for an error message
+func repr(v Value) string {
+	return reprLimit(v, maxReprBytes)
+}
+
+// str returns the string form of a value as used by str() and % formatting,
+// failing when it is longer than the collection limit
+func (t *thread) str(v Value) (string, error) {
+	if s, ok := v.(string); ok {
+		return s, nil
+	}
+	return t.repr(v)
+}
+
+// repr returns the repr of a value, failing when it is longer than the
+// collection limit. Writing stops at the limit, so values that share or contain
+// themselves cannot build an unbounded string first.
+func (t *thread) repr(v Value) (string, error) {
+	limit := t.limits.MaxCollectionSize
+	if limit <= 0 {
+		limit = maxStringBytes
+	}
+	s := reprLimit(v, limit)
+	return s, t.checkSize(len(s))
+}
+
+// reprLimit returns the repr of v, cut short with "..." once it is longer than
+// limit bytes. Lists and dicts that contain themselves are written as [...] and
+// {...}.
+func reprLimit(v Value, limit int) string {
+	w := &reprWriter{limit: limit, active: make(map[interface{}]bool)}
+	w.write(v, 0)
+	if w.Len() > limit {
+		return w.String()[:limit] + "..."
+	}
+	return w.String()
+}
+
+type reprWriter struct {
+	strings.Builder
+	limit int
+	// active holds the lists and dicts being written
+	active map[interface{}]bool
+}
+
+func (w *reprWriter) write(v Value, depth int) {
+	if w.Len() > w.limit {
+		return
+	}
+	if depth > maxValueDepth {
+		w.WriteString("...")
+		return
+	}
+	switch v := v.(type) {
+	case nil:
+		w.WriteString("None")
+	case bool:
+		if v {
+			w.WriteString("True")
+		} else {
+			w.WriteString("False")
+		}
+	case int64:
+		w.WriteString(strconv.FormatInt(v, 10))
+	case float64:
+		w.WriteString(formatFloat(v))
+	case string:
+		w.WriteString(strconv.Quote(v))
+	case *List:
+		if w.active[v] {
+			w.WriteString("[...]")
+			return
+		}
+		w.active[v] = true
+		defer delete(w.active, v)
+		w.WriteByte('[')
+		for i, elem := range v.elems {
+			if i > 0 {
+				w.WriteString(", ")
+			}
+			w.write(elem, depth+1)
+		}
+		w.WriteByte(']')
+	case Tuple:
+		w.WriteByte('(')
+		for i, elem := range v {
+			if i > 0 {
+				w.WriteString(", ")
+			}
+			w.write(elem, depth+1)
+		}
+		if len(v) == 1 {
+			w.WriteByte(',')
+		}
+		w.WriteByte(')')
+	case *Dict:
+		if w.active[v] {
+			w.WriteString("{...}")
+			return
+		}
+		w.active[v] = true
+		defer delete(w.active, v)
+		w.WriteByte('{')
+		for i, key := range v.keys {
+			if i > 0 {
+				w.WriteString(", ")
+			}
+			w.write(key, depth+1)
+			w.WriteString(": ")
+			w.write(v.values[i], depth+1)
+		}
+		w.WriteByte('}')
+	case *Struct:
+		w.WriteString(v.name)
+		w.WriteByte('(')
+		for i, name := range sortedFieldNames(v) {
+			if i > 0 {
+				w.WriteString(", ")
+			}
+			w.WriteString(name)
+			w.WriteByte('=')
+			w.write(v.fields[name], depth+1)
+		}
+		w.WriteByte(')')
+	case *Function:
+		w.WriteString("<function " + v.def.name + ">")
+	case *Builtin:
+		w.WriteString("<built-in " + v.name + ">")
+	default:
+		fmt.Fprintf(w, "%v", v)
+	}
+}
+
+func formatFloat(f float64) string {
+	switch {
+	case math.IsInf(f, 1):
+		return "+inf"
+	case math.IsInf(f, -1):
+		return "-inf"
+	case math.IsNaN(f):
+		return "nan"
+	}
+	s := strconv.FormatFloat(f, 'g', -1, 64)
+	if !strings.ContainsAny(s, ".eE") {
+		s += ".0"
+	}
+	return s
+}
+
+func sortedFieldNames(s *Struct) []string {
+	names := make([]string, 0, len(s.fields))
+	for name := range s.fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// FromGo converts a Go value, typically decoded JSON, to a script value. Maps
+// become dicts with sorted keys and structs are not supported.
+func FromGo(v interface{}) (Value, error) {
+	switch v := v.(type) {
+	case nil:
+		return nil, nil
+	case bool, string, int64, float64:
+		return v, nil
+	case int:
+		return int64(v), nil
+	case int32:
+		return int64(v), nil
+	case float32:
+		return float64(v), nil
+	case []string:
+		elems := make([]Value, len(v))
+		for i, s := range v {
+			elems[i] = s
+		}
+		return NewList(elems), nil
+	case []interface{}:
+		elems := make([]Value, len(v))
+		for i, elem := range v {
+			converted, err := FromGo(elem)
+			if err != nil {
+				return nil, err
+			}
+			elems[i] = converted
+		}
+		return NewList(elems), nil
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		d := NewDict()
+		for _, key := range keys {
+			converted, err := FromGo(v[key])
+			if err != nil {
+				return nil, err
+			}
+			_ = d.Set(key, converted)
+		}
+		return d, nil
+	case map[string]string:
+		converted := make(map[string]interface{}, len(v))
+		for key, value := range v {
+			converted[key] = value
+		}
+		return FromGo(converted)
+	}
+
+	// Numeric types decoded from JSON with UseNumber or typed maps
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return rv.Int(), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return int64(rv.Uint()), nil
+	case reflect.Float32, reflect.Float64:
+		return rv.Float(), nil
+	case reflect.String:
+		return rv.String(), nil
+	}
+	return nil, fmt.Errorf("cannot convert %T to a script value", v)
+}
+
+// maxConvertElements bounds the container elements converted by ToGo
+const maxConvertElements = 100000
+
+// ToGo converts a script value to plain Go values suitable for JSON encoding.
+// A value that contains itself, nests too deeply or holds too many elements to
+// convert is replaced by its repr.
+func ToGo(v Value) interface{} {
+	out, err := toGo(v)
+	if err != nil {
+		return repr(v)
+	}
+	return out
+}
+
+// toGo converts a script value to plain Go values, failing for values that
+// contain themselves, nest more than maxValueDepth levels or hold more than
+// maxConvertElements elements in all
+func toGo(v Value) (interface{}, error) {
+	budget := maxConvertElements
+	return toGoAt(v, 0, &budget)
+}
+
+func toGoAt(v Value, depth int, budget *int) (interface{}, error) {
+	var n int
+	switch v := v.(type) {
+	case *List:
+		n = len(v.elems)
+	case Tuple:
+		n = len(v)
+	case *Dict:
+		n = len(v.keys)
+	case *Struct:
+		n = len(v.fields)
+	}
+	if n > 0 {
+		if depth >= maxValueDepth {
+			return nil, errValueDepth()
+		}
+		if *budget -= n; *budget < 0 {
+			return nil, fmt.Errorf("%w: value has more than %d elements to convert", ErrLimitExceeded, maxConvertElements)
+		}
+	}
+
+	var err error
+	switch v := v.(type) {
+	case *List:
+		out := make([]interface{}, len(v.elems))
+		for i, elem := range v.elems {
+			if out[i], err = toGoAt(elem, depth+1, budget); err != nil {
+				return nil, err
+			}
+		}
+		return out, nil
+	case Tuple:
+		out := make([]interface{}, len(v))
+		for i, elem := range v {
+			if out[i], err = toGoAt(elem, depth+1, budget); err != nil {
+				return nil, err
+			}
+		}
+		return out, nil
+	case *Dict:
+		out := make(map[string]interface{}, len(v.keys))
+		for i, key := range v.keys {
+			if out[str(key)], err = toGoAt(v.values[i], depth+1, budget); err != nil {
+				return nil, err
+			}
+		}
+		return out, nil
+	case *Struct:
+		out := make(map[string]interface{}, len(v.fields))
+		for name, value := range v.fields {
+			if out[name], err = toGoAt(value, depth+1, budget); err != nil {
+				return nil, err
+			}
+		}
+		return out, nil
+	case *Function, *Builtin:
+		return repr(v), nil
+	}
+	return v, nil
+}
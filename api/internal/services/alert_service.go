@@ -59,26 +59,45 @@ func (s *AlertService) ProcessNewPaymentFailures(ctx context.Context) error {
 
 // generateAlert creates and sends an alert for a payment failure
 func (s *AlertService) generateAlert(ctx context.Context, failure *models.PaymentFailureEvent) error {
+	_, err := s.RaiseAlert(ctx, failure, AlertRequest{
+		Channel:    "email", // Start with email for MVP
+		TemplateID: "payment_failure_alert",
+		Subject:    fmt.Sprintf("Payment Failed - %s", failure.FailureReason),
+	})
+	return err
+}
+
+// AlertRequest describes an alert raised for a payment failure
+type AlertRequest struct {
+	Channel    string
+	TemplateID string
+	Subject    string
+	Metadata   map[string]interface{}
+}
+
+// RaiseAlert creates and sends an alert for a payment failure
+func (s *AlertService) RaiseAlert(ctx context.Context, failure *models.PaymentFailureEvent, req AlertRequest) (*models.CustomerCommunication, error) {
 	// Create alert record
 	alert := &models.CustomerCommunication{
-		PaymentFailureID: failure.ID,
+		PaymentFailureID: &failure.ID,
 		CompanyID:        failure.CompanyID,
-		Channel:          "email", // Start with email for MVP
-		TemplateID:       "payment_failure_alert",
-		Subject:          fmt.Sprintf("Payment Failed - %s", failure.FailureReason),
+		Channel:          req.Channel,
+		TemplateID:       req.TemplateID,
+		Subject:          req.Subject,
 		Content:          s.generateAlertContent(failure),
 		Status:           "pending",
+		Metadata:         req.Metadata,
 	}
 
-	if err := s.db.Create(alert).Error; err != nil {
-		return fmt.Errorf("failed to create alert: %w", err)
+	if err := s.db.WithContext(ctx).Create(alert).Error; err != nil {
+		return nil, fmt.Errorf("failed to create alert: %w", err)
 	}
 
 	// Send the alert
 	if err := s.sendAlert(alert); err != nil {
 		// Update status to failed
 		s.db.Model(alert).Update("status", "failed")
-		return fmt.Errorf("failed to send alert: %w", err)
+		return alert, fmt.Errorf("failed to send alert: %w", err)
 	}
 
 	// Update status to sent
@@ -89,12 +108,13 @@ func (s *AlertService) generateAlert(ctx context.Context, failure *models.Paymen
 	}).Error; err != nil {
 		s.logger.Error("Failed to update alert status", zap.Error(err))
 	}
+	alert.Status = "sent"
 
 	s.logger.Info("Alert sent successfully", 
 		zap.String("alert_id", alert.ID.String()),
 		zap.String("customer_email", failure.CustomerEmail))
 
-	return nil
+	return alert, nil
 }
 
 // generateAlertContent generates professional alert content
@@ -19,11 +19,12 @@ type AnalyticsService struct {
 	patternDetector  analytics.PatternDetector
 	trendAnalyzer    analytics.TrendAnalyzer
 	failurePredictor analytics.FailurePredictor
+	identityService  *CustomerIdentityService
 	logger           *zap.Logger
 }
 
 // NewAnalyticsService creates a new analytics service
-func NewAnalyticsService(db *gorm.DB, logger *zap.Logger) *AnalyticsService {
+func NewAnalyticsService(db *gorm.DB, identityService *CustomerIdentityService, logger *zap.Logger) *AnalyticsService {
 
 	// Initialize analytics components
 	patternDetector := analytics.NewDefaultPatternDetector(logger)
@@ -44,6 +45,7 @@ func NewAnalyticsService(db *gorm.DB, logger *zap.Logger) *AnalyticsService {
 		patternDetector:  patternDetector,
 		trendAnalyzer:    trendAnalyzer,
 		failurePredictor: failurePredictor,
+		identityService:  identityService,
 		logger:           logger,
 	}
 }
@@ -90,15 +92,16 @@ func (s *AnalyticsService) AnalyzeCompanyPaymentFailures(ctx context.Context, co
 	return result, nil
 }
 
-// AnalyzeCustomerPaymentFailures performs analysis for a specific customer
-func (s *AnalyticsService) AnalyzeCustomerPaymentFailures(ctx context.Context, companyID, customerID string, timeRange time.Duration) (*analytics.AnalysisResult, error) {
+// AnalyzeCustomerPaymentFailures performs analysis for a specific customer, identified
+// by an internal identity ID or the provider's customer ID
+func (s *AnalyticsService) AnalyzeCustomerPaymentFailures(ctx context.Context, companyID, provider, customerID string, timeRange time.Duration) (*analytics.AnalysisResult, error) {
 	s.logger.Info("Starting customer payment failure analysis",
 		zap.String("company_id", companyID),
 		zap.String("customer_id", customerID),
 		zap.Duration("time_range", timeRange))
 
 	// Fetch customer-specific payment failures
-	failures, err := s.getCustomerPaymentFailures(ctx, companyID, customerID, timeRange)
+	failures, err := s.getCustomerPaymentFailures(ctx, companyID, provider, customerID, timeRange)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch customer payment failures: %w", err)
 	}
@@ -135,15 +138,16 @@ func (s *AnalyticsService) AnalyzeCustomerPaymentFailures(ctx context.Context, c
 	return result, nil
 }
 
-// GetCustomerRiskScore returns the current risk score for a customer
-func (s *AnalyticsService) GetCustomerRiskScore(ctx context.Context, companyID, customerID string) (float64, error) {
+// GetCustomerRiskScore returns the current risk score for a customer, identified by an
+// internal identity ID or the provider's customer ID
+func (s *AnalyticsService) GetCustomerRiskScore(ctx context.Context, companyID, provider, customerID string) (float64, error) {
 	s.logger.Info("Getting customer risk score",
 		zap.String("company_id", companyID),
 		zap.String("customer_id", customerID))
 
 	// Fetch customer payment failures (last 90 days for risk assessment)
 	timeRange := 90 * 24 * time.Hour
-	failures, err := s.getCustomerPaymentFailures(ctx, companyID, customerID, timeRange)
+	failures, err := s.getCustomerPaymentFailures(ctx, companyID, provider, customerID, timeRange)
 	if err != nil {
 		return 0.0, fmt.Errorf("failed to fetch customer payment failures: %w", err)
 	}
@@ -250,12 +254,22 @@ func (s *AnalyticsService) getCompanyPaymentFailures(ctx context.Context, compan
 	return failures, err
 }
 
-func (s *AnalyticsService) getCustomerPaymentFailures(ctx context.Context, companyID, customerID string, timeRange time.Duration) ([]models.PaymentFailureEvent, error) {
+// getCustomerPaymentFailures returns failures for every provider record linked to the
+// customer's resolved identity, so risk is assessed per customer rather than per provider.
+// The provider qualifies customerID; it may be empty for internal identity IDs.
+func (s *AnalyticsService) getCustomerPaymentFailures(ctx context.Context, companyID, provider, customerID string, timeRange time.Duration) ([]models.PaymentFailureEvent, error) {
 	var failures []models.PaymentFailureEvent
 
 	startTime := time.Now().Add(-timeRange)
 
-	err := s.db.Where("company_id = ? AND customer_id = ? AND created_at >= ?", companyID, customerID, startTime).
+	customers, err := s.identityService.GetLinkedCustomers(ctx, companyID, provider, customerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve customer identity: %w", err)
+	}
+
+	condition, args := linkedCustomerCondition("payment_failure_events", customers)
+	err = s.db.WithContext(ctx).Where("company_id = ? AND created_at >= ?", companyID, startTime).
+		Where(condition, args...).
 		Order("created_at DESC").
 		Find(&failures).Error
 
@@ -328,3 +342,76 @@ func (s *AnalyticsService) getTopFailureReasons(ctx context.Context, companyID s
 
 	return reasons
 }
+
+// ReasonBreakdown summarises failures sharing a failure reason
+type ReasonBreakdown struct {
+	Reason      string `json:"reason"`
+	Count       int64  `json:"count"`
+	AmountCents int64  `json:"amount_cents"`
+}
+
+// OverdueCustomer is a customer with unresolved failures past their due date
+type OverdueCustomer struct {
+	CustomerID     string    `json:"customer_id"`
+	CustomerName   string    `json:"customer_name"`
+	CustomerEmail  string    `json:"customer_email"`
+	OverdueCount   int64     `json:"overdue_count"`
+	OverdueCents   int64     `json:"overdue_cents"`
+	OldestDueDate  time.Time `json:"oldest_due_date"`
+	DaysOverdueMax int       `json:"days_overdue_max"`
+}
+
+// GetFailuresByReason groups failures created in [start, end) by failure reason
+func (s *AnalyticsService) GetFailuresByReason(ctx context.Context, companyID string, start, end time.Time) ([]ReasonBreakdown, error) {
+	var reasons []ReasonBreakdown
+	err := s.db.WithContext(ctx).Model(&models.PaymentFailureEvent{}).
+		Select("COALESCE(NULLIF(failure_reason, ''), 'unknown') AS reason, COUNT(*) AS count, COALESCE(SUM(amount_cents), 0) AS amount_cents").
+		Where("company_id = ? AND created_at >= ? AND created_at < ?", companyID, start, end).
+		Group("1").
+		Order("count DESC").
+		Scan(&reasons).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get failures by reason: %w", err)
+	}
+	return reasons, nil
+}
+
+// GetAmountAtRisk returns the total of failures that are not yet resolved or recovered
+func (s *AnalyticsService) GetAmountAtRisk(ctx context.Context, companyID string) (int64, error) {
+	var total int64
+	err := s.db.WithContext(ctx).Model(&models.PaymentFailureEvent{}).
+		Select("COALESCE(SUM(amount_cents), 0)").
+		Where("company_id = ? AND status NOT IN ?", companyID, []string{"resolved", "recovered"}).
+		Scan(&total).Error
+	if err != nil {
+		return 0, fmt.Errorf("failed to get amount at risk: %w", err)
+	}
+	return total, nil
+}
+
+// GetTopOverdueCustomers returns the customers with the largest unresolved amounts past due at asOf
+func (s *AnalyticsService) GetTopOverdueCustomers(ctx context.Context, companyID string, asOf time.Time, limit int) ([]OverdueCustomer, error) {
+	if limit <= 0 {
+		limit = 10
+	}
+
+	var customers []OverdueCustomer
+	err := s.db.WithContext(ctx).Model(&models.PaymentFailureEvent{}).
+		Select(`customer_id,
+			MAX(customer_name) AS customer_name,
+			MAX(customer_email) AS customer_email,
+			COUNT(*) AS overdue_count,
+			COALESCE(SUM(amount_cents), 0) AS overdue_cents,
+			MIN(due_date) AS oldest_due_date,
+			EXTRACT(DAY FROM ?::timestamptz - MIN(due_date))::int AS days_overdue_max`, asOf).
+		Where("company_id = ? AND due_date < ? AND status NOT IN ? AND customer_id <> ''",
+			companyID, asOf, []string{"resolved", "recovered"}).
+		Group("customer_id").
+		Order("overdue_cents DESC").
+		Limit(limit).
+		Scan(&customers).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get overdue customers: %w", err)
+	}
+	return customers, nil
+}
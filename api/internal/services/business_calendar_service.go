@@ -0,0 +1,344 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+
+	"github.com/sambitmohanty1/payment-watchdog/api/internal/calendar"
+	"github.com/sambitmohanty1/payment-watchdog/api/internal/models"
+)
+
+var (
+	// ErrInvalidBusinessCalendar is returned for invalid timezones, opening hours or holiday files
+	ErrInvalidBusinessCalendar = errors.New("invalid business calendar")
+	// ErrCompanyNotFound is returned when the company does not exist
+	ErrCompanyNotFound = errors.New("company not found")
+	// ErrHolidayCalendarNotFound is returned when a holiday calendar does not exist
+	ErrHolidayCalendarNotFound = errors.New("holiday calendar not found")
+)
+
+const (
+	// businessCalendarTTL bounds how long a cached calendar may miss changes made
+	// through another service instance
+	businessCalendarTTL = 5 * time.Minute
+	// holidayImportYears is how far ahead yearly recurring holidays are expanded
+	holidayImportYears = 5
+)
+
+// BusinessCalendarSettings is a company's timezone, opening hours and holiday calendars
+type BusinessCalendarSettings struct {
+	Timezone      string                   `json:"timezone"`
+	BusinessHours []models.BusinessHours   `json:"business_hours"`
+	IsDefault     bool                     `json:"is_default_hours"` // no hours configured, DefaultHours apply
+	Calendars     []models.HolidayCalendar `json:"holiday_calendars"`
+}
+
+type cachedBusinessCalendar struct {
+	calendar *calendar.Calendar
+	loadedAt time.Time
+}
+
+// BusinessCalendarService manages company timezones, business hours and holidays
+// and builds the calendars rules, workflow waits and communications schedule by
+type BusinessCalendarService struct {
+	db     *gorm.DB
+	logger *zap.Logger
+
+	mu    sync.Mutex
+	cache map[string]cachedBusinessCalendar
+}
+
+// NewBusinessCalendarService creates a new business calendar service
+func NewBusinessCalendarService(db *gorm.DB, logger *zap.Logger) *BusinessCalendarService {
+	return &BusinessCalendarService{
+		db:     db,
+		logger: logger,
+		cache:  make(map[string]cachedBusinessCalendar),
+	}
+}
+
+// Calendar returns the company's business calendar. Companies without settings get
+// a UTC calendar with the default opening hours.
+func (s *BusinessCalendarService) Calendar(ctx context.Context, companyID string) (*calendar.Calendar, error) {
+	s.mu.Lock()
+	cached, ok := s.cache[companyID]
+	s.mu.Unlock()
+	if ok && time.Since(cached.loadedAt) < businessCalendarTTL {
+		return cached.calendar, nil
+	}
+
+	var company models.Company
+	err := s.db.WithContext(ctx).Select("id", "timezone", "business_hours").Where("id = ?", companyID).First(&company).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("failed to load company calendar settings: %w", err)
+	}
+
+	location := time.UTC
+	if company.Timezone != "" {
+		if location, err = time.LoadLocation(company.Timezone); err != nil {
+			s.logger.Warn("Unknown company timezone, using UTC",
+				zap.String("company_id", companyID),
+				zap.String("timezone", company.Timezone))
+			location = time.UTC
+		}
+	}
+
+	hours := calendar.DefaultHours()
+	if len(company.BusinessHours) > 0 {
+		if hours, err = businessHoursWindows(company.BusinessHours); err != nil {
+			s.logger.Warn("Invalid company business hours, using defaults",
+				zap.String("company_id", companyID),
+				zap.Error(err))
+			hours = calendar.DefaultHours()
+		}
+	}
+
+	var rows []models.CompanyHoliday
+	if err := s.db.WithContext(ctx).Where("company_id = ?", companyID).Find(&rows).Error; err != nil {
+		return nil, fmt.Errorf("failed to load company holidays: %w", err)
+	}
+	holidays := make([]calendar.Holiday, len(rows))
+	for i, row := range rows {
+		holidays[i] = calendar.Holiday{Date: row.Date, Name: row.Name, UID: row.UID}
+	}
+
+	cal := calendar.New(location, hours, holidays)
+	s.mu.Lock()
+	s.cache[companyID] = cachedBusinessCalendar{calendar: cal, loadedAt: time.Now()}
+	s.mu.Unlock()
+	return cal, nil
+}
+
+// Resolve returns the first time at or after from that satisfies a schedule spec
+// such as "next_business_day 10:00" in the company's calendar
+func (s *BusinessCalendarService) Resolve(ctx context.Context, companyID, spec string, from time.Time) (time.Time, error) {
+	schedule, err := calendar.ParseSchedule(spec)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%w: %v", ErrInvalidBusinessCalendar, err)
+	}
+	cal, err := s.Calendar(ctx, companyID)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return cal.Next(schedule, from), nil
+}
+
+// GetSettings returns the company's calendar settings
+func (s *BusinessCalendarService) GetSettings(ctx context.Context, companyID string) (*BusinessCalendarSettings, error) {
+	var company models.Company
+	err := s.db.WithContext(ctx).Select("id", "timezone", "business_hours").Where("id = ?", companyID).First(&company).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrCompanyNotFound
+		}
+		return nil, fmt.Errorf("failed to load company calendar settings: %w", err)
+	}
+
+	settings := &BusinessCalendarSettings{
+		Timezone:      company.Timezone,
+		BusinessHours: company.BusinessHours,
+	}
+	if settings.Timezone == "" {
+		settings.Timezone = "UTC"
+	}
+	if len(settings.BusinessHours) == 0 {
+		settings.IsDefault = true
+		settings.BusinessHours = defaultBusinessHours()
+	}
+	if err := s.db.WithContext(ctx).Where("company_id = ?", companyID).Order("name").Find(&settings.Calendars).Error; err != nil {
+		return nil, fmt.Errorf("failed to list holiday calendars: %w", err)
+	}
+	return settings, nil
+}
+
+// UpdateSettings replaces the company's timezone and opening hours. Empty hours
+// restore the defaults.
+func (s *BusinessCalendarService) UpdateSettings(ctx context.Context, companyID, timezone string, hours []models.BusinessHours) error {
+	if timezone == "" {
+		timezone = "UTC"
+	}
+	if _, err := time.LoadLocation(timezone); err != nil {
+		return fmt.Errorf("%w: unknown timezone %q", ErrInvalidBusinessCalendar, timezone)
+	}
+	if _, err := businessHoursWindows(hours); err != nil {
+		return err
+	}
+	sort.SliceStable(hours, func(i, j int) bool {
+		if hours[i].Weekday != hours[j].Weekday {
+			return hours[i].Weekday < hours[j].Weekday
+		}
+		return hours[i].Opens < hours[j].Opens
+	})
+
+	company := models.Company{Timezone: timezone, BusinessHours: hours}
+	result := s.db.WithContext(ctx).Model(&models.Company{}).Where("id = ?", companyID).
+		Select("timezone", "business_hours").Updates(&company)
+	if result.Error != nil {
+		return fmt.Errorf("failed to update company calendar settings: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrCompanyNotFound
+	}
+	s.invalidate(companyID)
+	return nil
+}
+
+// ImportHolidays imports the holidays of an iCalendar file into the named calendar,
+// replacing any holidays previously imported under that name
+func (s *BusinessCalendarService) ImportHolidays(ctx context.Context, companyID, name, source string, data io.Reader, importedBy string) (*models.HolidayCalendar, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, fmt.Errorf("%w: calendar name is required", ErrInvalidBusinessCalendar)
+	}
+	holidays, err := calendar.ParseICS(data, time.Now().AddDate(holidayImportYears, 0, 0))
+	if err != nil {
+		if !errors.Is(err, calendar.ErrInvalidICS) {
+			return nil, fmt.Errorf("failed to read holiday calendar: %w", err)
+		}
+		return nil, fmt.Errorf("%w: %v", ErrInvalidBusinessCalendar, err)
+	}
+
+	var imported models.HolidayCalendar
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Where("company_id = ? AND name = ?", companyID, name).First(&imported).Error
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			imported = models.HolidayCalendar{CompanyID: companyID, Name: name}
+		case err != nil:
+			return fmt.Errorf("failed to load holiday calendar: %w", err)
+		}
+		imported.Source = source
+		imported.HolidayCount = len(holidays)
+		imported.ImportedBy = importedBy
+		if err := tx.Save(&imported).Error; err != nil {
+			return fmt.Errorf("failed to save holiday calendar: %w", err)
+		}
+
+		if err := tx.Where("calendar_id = ?", imported.ID).Delete(&models.CompanyHoliday{}).Error; err != nil {
+			return fmt.Errorf("failed to replace holidays: %w", err)
+		}
+		if len(holidays) == 0 {
+			return nil
+		}
+		rows := make([]models.CompanyHoliday, len(holidays))
+		for i, holiday := range holidays {
+			rows[i] = models.CompanyHoliday{
+				CompanyID:  companyID,
+				CalendarID: imported.ID,
+				Date:       holiday.Date,
+				Name:       holiday.Name,
+				UID:        holiday.UID,
+			}
+		}
+		if err := tx.CreateInBatches(rows, 500).Error; err != nil {
+			return fmt.Errorf("failed to save holidays: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.invalidate(companyID)
+	s.logger.Info("Imported holiday calendar",
+		zap.String("company_id", companyID),
+		zap.String("calendar", name),
+		zap.Int("holidays", len(holidays)))
+	return &imported, nil
+}
+
+// ListHolidays returns the company's holidays between from and to inclusive
+func (s *BusinessCalendarService) ListHolidays(ctx context.Context, companyID string, from, to time.Time) ([]models.CompanyHoliday, error) {
+	var holidays []models.CompanyHoliday
+	err := s.db.WithContext(ctx).
+		Where("company_id = ? AND date >= ? AND date <= ?", companyID, from.Format("2006-01-02"), to.Format("2006-01-02")).
+		Order("date, name").
+		Find(&holidays).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list holidays: %w", err)
+	}
+	return holidays, nil
+}
+
+// DeleteHolidayCalendar removes a holiday calendar and its holidays
+func (s *BusinessCalendarService) DeleteHolidayCalendar(ctx context.Context, companyID string, id uuid.UUID) error {
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("company_id = ? AND id = ?", companyID, id).Delete(&models.HolidayCalendar{})
+		if result.Error != nil {
+			return fmt.Errorf("failed to delete holiday calendar: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return ErrHolidayCalendarNotFound
+		}
+		if err := tx.Where("calendar_id = ?", id).Delete(&models.CompanyHoliday{}).Error; err != nil {
+			return fmt.Errorf("failed to delete holidays: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	s.invalidate(companyID)
+	return nil
+}
+
+func (s *BusinessCalendarService) invalidate(companyID string) {
+	s.mu.Lock()
+	delete(s.cache, companyID)
+	s.mu.Unlock()
+}
+
+// businessHoursWindows validates opening hours and converts them to calendar windows
+func businessHoursWindows(hours []models.BusinessHours) (map[time.Weekday][]calendar.Window, error) {
+	windows := make(map[time.Weekday][]calendar.Window)
+	for _, h := range hours {
+		if h.Weekday < 0 || h.Weekday > 6 {
+			return nil, fmt.Errorf("%w: weekday %d must be between 0 (Sunday) and 6 (Saturday)", ErrInvalidBusinessCalendar, h.Weekday)
+		}
+		opens, err := calendar.ParseClock(h.Opens)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidBusinessCalendar, err)
+		}
+		closes, err := calendar.ParseClock(h.Closes)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidBusinessCalendar, err)
+		}
+		if opens >= closes {
+			return nil, fmt.Errorf("%w: %s opens at %s but closes at %s", ErrInvalidBusinessCalendar, time.Weekday(h.Weekday), h.Opens, h.Closes)
+		}
+
+		day := time.Weekday(h.Weekday)
+		for _, existing := range windows[day] {
+			if opens < existing.Close && existing.Open < closes {
+				return nil, fmt.Errorf("%w: overlapping hours on %s", ErrInvalidBusinessCalendar, day)
+			}
+		}
+		windows[day] = append(windows[day], calendar.Window{Open: opens, Close: closes})
+	}
+	return windows, nil
+}
+
+// defaultBusinessHours describes calendar.DefaultHours as settings
+func defaultBusinessHours() []models.BusinessHours {
+	var hours []models.BusinessHours
+	for day := time.Sunday; day <= time.Saturday; day++ {
+		for _, window := range calendar.DefaultHours()[day] {
+			hours = append(hours, models.BusinessHours{
+				Weekday: int(day),
+				Opens:   calendar.FormatClock(window.Open),
+				Closes:  calendar.FormatClock(window.Close),
+			})
+		}
+	}
+	return hours
+}
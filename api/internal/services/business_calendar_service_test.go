@@ -0,0 +1,49 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sambitmohanty1/payment-watchdog/api/internal/calendar"
+	"github.com/sambitmohanty1/payment-watchdog/api/internal/models"
+)
+
+func TestBusinessHoursWindows(t *testing.T) {
+	windows, err := businessHoursWindows([]models.BusinessHours{
+		{Weekday: 1, Opens: "13:00", Closes: "17:30"},
+		{Weekday: 1, Opens: "08:30", Closes: "12:00"},
+		{Weekday: 6, Opens: "10:00", Closes: "24:00"},
+	})
+	require.NoError(t, err)
+	assert.Len(t, windows[time.Monday], 2)
+	assert.Equal(t, []calendar.Window{{Open: 600, Close: 1440}}, windows[time.Saturday])
+	assert.Empty(t, windows[time.Sunday])
+
+	invalid := map[string][]models.BusinessHours{
+		"weekday 7":         {{Weekday: 7, Opens: "09:00", Closes: "17:00"}},
+		"out of range":      {{Weekday: 1, Opens: "09:00", Closes: "24:30"}},
+		"opens at 17:00":    {{Weekday: 2, Opens: "17:00", Closes: "09:00"}},
+		"overlapping hours": {{Weekday: 3, Opens: "09:00", Closes: "13:00"}, {Weekday: 3, Opens: "12:00", Closes: "17:00"}},
+		"must be HH:MM":     {{Weekday: 4, Opens: "9am", Closes: "17:00"}},
+	}
+	for want, hours := range invalid {
+		_, err := businessHoursWindows(hours)
+		require.Error(t, err, want)
+		assert.True(t, errors.Is(err, ErrInvalidBusinessCalendar), want)
+		assert.Contains(t, err.Error(), want)
+	}
+}
+
+func TestDefaultBusinessHoursMatchCalendarDefaults(t *testing.T) {
+	hours := defaultBusinessHours()
+	require.Len(t, hours, 5)
+	assert.Equal(t, models.BusinessHours{Weekday: 1, Opens: "09:00", Closes: "17:00"}, hours[0])
+
+	windows, err := businessHoursWindows(hours)
+	require.NoError(t, err)
+	assert.Equal(t, calendar.DefaultHours(), windows)
+}
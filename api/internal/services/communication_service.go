@@ -34,6 +34,10 @@ type CommunicationRequest struct {
 	Message      string                 `json:"message,omitempty"`
 	Variables    map[string]interface{} `json:"variables,omitempty"`
 	Context      map[string]interface{} `json:"context,omitempty"`
+
+	// IdempotencyKey makes a repeated request return the first send instead of
+	// sending again. Providers receive it in the "idempotency_key" metadata key.
+	IdempotencyKey string `json:"idempotency_key,omitempty"`
 }
 
 // CommunicationResult represents the result of a communication
@@ -62,6 +66,14 @@ type EmailResult struct {
 	Metadata  map[string]string `json:"metadata,omitempty"`
 }
 
+// EmailAttachment is a file attached to an email. Providers read attachments from
+// the "attachments" metadata key as []EmailAttachment.
+type EmailAttachment struct {
+	Filename    string `json:"filename"`
+	ContentType string `json:"content_type"`
+	Content     []byte `json:"-"`
+}
+
 // SMSResult represents SMS sending result
 type SMSResult struct {
 	MessageID string            `json:"message_id"`
@@ -127,6 +139,10 @@ func (c *CommunicationService) SendEmail(ctx context.Context, req *Communication
 		attribute.String("recipient", req.Recipient),
 	)
 
+	if sent, err := c.FindSent(ctx, req.IdempotencyKey); err != nil || sent != nil {
+		return sent, err
+	}
+
 	// Get or create template
 	template, err := c.getEmailTemplate(ctx, req)
 	if err != nil {
@@ -143,9 +159,10 @@ func (c *CommunicationService) SendEmail(ctx context.Context, req *Communication
 
 	// Send email through provider
 	emailResult, err := c.emailService.SendEmail(ctx, req.Recipient, subject, body, map[string]interface{}{
-		"company_id":  req.CompanyID.String(),
-		"template_id": template.ID.String(),
-		"context":     req.Context,
+		"company_id":      req.CompanyID.String(),
+		"template_id":     template.ID.String(),
+		"context":         req.Context,
+		"idempotency_key": req.IdempotencyKey,
 	})
 	if err != nil {
 		span.RecordError(err)
@@ -158,7 +175,9 @@ func (c *CommunicationService) SendEmail(ctx context.Context, req *Communication
 	// Create customer communication record
 	communication := &models.CustomerCommunication{
 		ID:               uuid.New(),
+		PaymentFailureID: paymentFailureIDFromContext(req.Context),
 		CompanyID:        req.CompanyID.String(),
+		Channel:          "email",
 		CommunicationType: "email",
 		Recipient:        req.Recipient,
 		Subject:          subject,
@@ -167,6 +186,7 @@ func (c *CommunicationService) SendEmail(ctx context.Context, req *Communication
 		SentAt:           &time.Time{},
 		TemplateID:       template.ID.String(),
 		ExternalID:       emailResult.MessageID,
+		IdempotencyKey:   req.IdempotencyKey,
 		Metadata:         req.Context,
 	}
 	*communication.SentAt = time.Now()
@@ -197,6 +217,10 @@ func (c *CommunicationService) SendSMS(ctx context.Context, req *CommunicationRe
 		attribute.String("recipient", req.Recipient),
 	)
 
+	if sent, err := c.FindSent(ctx, req.IdempotencyKey); err != nil || sent != nil {
+		return sent, err
+	}
+
 	// Get or create template
 	template, err := c.getSMSTemplate(ctx, req)
 	if err != nil {
@@ -213,9 +237,10 @@ func (c *CommunicationService) SendSMS(ctx context.Context, req *CommunicationRe
 
 	// Send SMS through provider
 	smsResult, err := c.smsService.SendSMS(ctx, req.Recipient, message, map[string]interface{}{
-		"company_id":  req.CompanyID.String(),
-		"template_id": template.ID.String(),
-		"context":     req.Context,
+		"company_id":      req.CompanyID.String(),
+		"template_id":     template.ID.String(),
+		"context":         req.Context,
+		"idempotency_key": req.IdempotencyKey,
 	})
 	if err != nil {
 		span.RecordError(err)
@@ -228,7 +253,9 @@ func (c *CommunicationService) SendSMS(ctx context.Context, req *CommunicationRe
 	// Create customer communication record
 	communication := &models.CustomerCommunication{
 		ID:               uuid.New(),
+		PaymentFailureID: paymentFailureIDFromContext(req.Context),
 		CompanyID:        req.CompanyID.String(),
+		Channel:          "sms",
 		CommunicationType: "sms",
 		Recipient:        req.Recipient,
 		Content:          message,
@@ -236,6 +263,7 @@ func (c *CommunicationService) SendSMS(ctx context.Context, req *CommunicationRe
 		SentAt:           &time.Time{},
 		TemplateID:       template.ID.String(),
 		ExternalID:       smsResult.MessageID,
+		IdempotencyKey:   req.IdempotencyKey,
 		Metadata:         req.Context,
 	}
 	*communication.SentAt = time.Now()
@@ -254,18 +282,77 @@ func (c *CommunicationService) SendSMS(ctx context.Context, req *CommunicationRe
 	}, nil
 }
 
+// FindSent returns the result of the communication already sent with
+// idempotencyKey, or nil when there is none or the key is empty
+func (c *CommunicationService) FindSent(ctx context.Context, idempotencyKey string) (*CommunicationResult, error) {
+	if idempotencyKey == "" {
+		return nil, nil
+	}
+	var sent []models.CustomerCommunication
+	if err := c.db.WithContext(ctx).
+		Where("idempotency_key = ?", idempotencyKey).
+		Limit(1).
+		Find(&sent).Error; err != nil {
+		return nil, fmt.Errorf("failed to check for sent communication: %w", err)
+	}
+	if len(sent) == 0 {
+		return nil, nil
+	}
+	result := &CommunicationResult{
+		MessageID: sent[0].ExternalID,
+		Status:    sent[0].Status,
+		Provider:  sent[0].Channel,
+	}
+	if sent[0].SentAt != nil {
+		result.SentAt = *sent[0].SentAt
+	}
+	return result, nil
+}
+
 // getEmailTemplate retrieves or creates an email template
 func (c *CommunicationService) getEmailTemplate(ctx context.Context, req *CommunicationRequest) (*models.CommunicationTemplate, error) {
+	if template := c.findTemplate(ctx, req, "email"); template != nil {
+		return template, nil
+	}
+
+	// Create a basic template if none found
+	template := defaultEmailTemplate(req.CompanyID)
+	if err := c.db.WithContext(ctx).Create(template).Error; err != nil {
+		return nil, fmt.Errorf("failed to create default email template: %w", err)
+	}
+
+	return template, nil
+}
+
+// getSMSTemplate retrieves or creates an SMS template
+func (c *CommunicationService) getSMSTemplate(ctx context.Context, req *CommunicationRequest) (*models.CommunicationTemplate, error) {
+	if template := c.findTemplate(ctx, req, "sms"); template != nil {
+		return template, nil
+	}
+
+	// Create a basic template if none found
+	template := defaultSMSTemplate(req.CompanyID)
+	if err := c.db.WithContext(ctx).Create(template).Error; err != nil {
+		return nil, fmt.Errorf("failed to create default SMS template: %w", err)
+	}
+
+	return template, nil
+}
+
+// findTemplate finds the active template of templateType a request asks for:
+// by ID, then by name, then the company's default. It returns nil when the
+// company has none of them.
+func (c *CommunicationService) findTemplate(ctx context.Context, req *CommunicationRequest, templateType string) *models.CommunicationTemplate {
 	var template models.CommunicationTemplate
 
 	// Try to find by ID first
 	if req.TemplateID != "" {
 		if id, err := uuid.Parse(req.TemplateID); err == nil {
 			if err := c.db.WithContext(ctx).
-				Where("id = ? AND company_id = ? AND template_type = ? AND is_active = ?", 
-					id, req.CompanyID, "email", true).
+				Where("id = ? AND company_id = ? AND template_type = ? AND is_active = ?",
+					id, req.CompanyID, templateType, true).
 				First(&template).Error; err == nil {
-				return &template, nil
+				return &template
 			}
 		}
 	}
@@ -273,25 +360,29 @@ func (c *CommunicationService) getEmailTemplate(ctx context.Context, req *Commun
 	// Try to find by name
 	if req.TemplateName != "" {
 		if err := c.db.WithContext(ctx).
-			Where("name = ? AND company_id = ? AND template_type = ? AND is_active = ?", 
-				req.TemplateName, req.CompanyID, "email", true).
+			Where("name = ? AND company_id = ? AND template_type = ? AND is_active = ?",
+				req.TemplateName, req.CompanyID, templateType, true).
 			First(&template).Error; err == nil {
-			return &template, nil
+			return &template
 		}
 	}
 
 	// Try to find default template
 	if err := c.db.WithContext(ctx).
-		Where("company_id = ? AND template_type = ? AND is_default = ? AND is_active = ?", 
-			req.CompanyID, "email", true, true).
+		Where("company_id = ? AND template_type = ? AND is_default = ? AND is_active = ?",
+			req.CompanyID, templateType, true, true).
 		First(&template).Error; err == nil {
-		return &template, nil
+		return &template
 	}
 
-	// Create a basic template if none found
-	template = models.CommunicationTemplate{
+	return nil
+}
+
+// defaultEmailTemplate is the email template created for companies without one
+func defaultEmailTemplate(companyID uuid.UUID) *models.CommunicationTemplate {
+	return &models.CommunicationTemplate{
 		ID:           uuid.New(),
-		CompanyID:    req.CompanyID,
+		CompanyID:    companyID,
 		Name:         "Default Payment Failure Email",
 		Description:  "Auto-generated default email template for payment failures",
 		TemplateType: "email",
@@ -309,52 +400,13 @@ Thank you for your attention to this matter.`,
 		IsDefault: true,
 		CreatedBy: "system",
 	}
-
-	if err := c.db.WithContext(ctx).Create(&template).Error; err != nil {
-		return nil, fmt.Errorf("failed to create default email template: %w", err)
-	}
-
-	return &template, nil
 }
 
-// getSMSTemplate retrieves or creates an SMS template
-func (c *CommunicationService) getSMSTemplate(ctx context.Context, req *CommunicationRequest) (*models.CommunicationTemplate, error) {
-	var template models.CommunicationTemplate
-
-	// Try to find by ID first
-	if req.TemplateID != "" {
-		if id, err := uuid.Parse(req.TemplateID); err == nil {
-			if err := c.db.WithContext(ctx).
-				Where("id = ? AND company_id = ? AND template_type = ? AND is_active = ?", 
-					id, req.CompanyID, "sms", true).
-				First(&template).Error; err == nil {
-				return &template, nil
-			}
-		}
-	}
-
-	// Try to find by name
-	if req.TemplateName != "" {
-		if err := c.db.WithContext(ctx).
-			Where("name = ? AND company_id = ? AND template_type = ? AND is_active = ?", 
-				req.TemplateName, req.CompanyID, "sms", true).
-			First(&template).Error; err == nil {
-			return &template, nil
-		}
-	}
-
-	// Try to find default template
-	if err := c.db.WithContext(ctx).
-		Where("company_id = ? AND template_type = ? AND is_default = ? AND is_active = ?", 
-			req.CompanyID, "sms", true, true).
-		First(&template).Error; err == nil {
-		return &template, nil
-	}
-
-	// Create a basic template if none found
-	template = models.CommunicationTemplate{
+// defaultSMSTemplate is the SMS template created for companies without one
+func defaultSMSTemplate(companyID uuid.UUID) *models.CommunicationTemplate {
+	return &models.CommunicationTemplate{
 		ID:           uuid.New(),
-		CompanyID:    req.CompanyID,
+		CompanyID:    companyID,
 		Name:         "Default Payment Failure SMS",
 		Description:  "Auto-generated default SMS template for payment failures",
 		TemplateType: "sms",
@@ -363,12 +415,44 @@ func (c *CommunicationService) getSMSTemplate(ctx context.Context, req *Communic
 		IsDefault:    true,
 		CreatedBy:    "system",
 	}
+}
 
-	if err := c.db.WithContext(ctx).Create(&template).Error; err != nil {
-		return nil, fmt.Errorf("failed to create default SMS template: %w", err)
+// RenderedMessage is a communication as it would be sent
+type RenderedMessage struct {
+	Channel      string `json:"channel"`
+	Recipient    string `json:"recipient"`
+	Subject      string `json:"subject,omitempty"`
+	Body         string `json:"body"`
+	TemplateUsed string `json:"template_used"`
+}
+
+// PreviewEmail renders the email SendEmail would send for req without sending
+// or recording it. A company without a matching template gets the default
+// template rendered, which is not created.
+func (c *CommunicationService) PreviewEmail(ctx context.Context, req *CommunicationRequest) (*RenderedMessage, error) {
+	template := c.findTemplate(ctx, req, "email")
+	if template == nil {
+		template = defaultEmailTemplate(req.CompanyID)
+	}
+	subject, body, err := c.renderEmailTemplate(template, req.Variables)
+	if err != nil {
+		return nil, fmt.Errorf("failed to render email template: %w", err)
 	}
+	return &RenderedMessage{Channel: "email", Recipient: req.Recipient, Subject: subject, Body: body, TemplateUsed: template.Name}, nil
+}
 
-	return &template, nil
+// PreviewSMS renders the SMS SendSMS would send for req without sending or
+// recording it, like PreviewEmail
+func (c *CommunicationService) PreviewSMS(ctx context.Context, req *CommunicationRequest) (*RenderedMessage, error) {
+	template := c.findTemplate(ctx, req, "sms")
+	if template == nil {
+		template = defaultSMSTemplate(req.CompanyID)
+	}
+	message, err := c.renderSMSTemplate(template, req.Variables)
+	if err != nil {
+		return nil, fmt.Errorf("failed to render SMS template: %w", err)
+	}
+	return &RenderedMessage{Channel: "sms", Recipient: req.Recipient, Body: message, TemplateUsed: template.Name}, nil
 }
 
 // renderEmailTemplate renders an email template with variables
@@ -542,3 +626,40 @@ func (c *CommunicationService) GetCommunicationHistory(ctx context.Context, comp
 
 	return communications, total, nil
 }
+
+// SendInternalEmail sends an untemplated email to company staff, such as a scheduled
+// report. It is not recorded as a customer communication.
+func (c *CommunicationService) SendInternalEmail(ctx context.Context, companyID, to, subject, body string, attachments []EmailAttachment) (*EmailResult, error) {
+	ctx, span := c.tracer.Start(ctx, "send_internal_email")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("company_id", companyID),
+		attribute.String("recipient", to),
+		attribute.Int("attachments", len(attachments)),
+	)
+
+	result, err := c.emailService.SendEmail(ctx, to, subject, body, map[string]interface{}{
+		"company_id":  companyID,
+		"internal":    true,
+		"attachments": attachments,
+	})
+	if err != nil {
+		span.RecordError(err)
+		return nil, fmt.Errorf("failed to send email: %w", err)
+	}
+	return result, nil
+}
+
+// paymentFailureIDFromContext extracts the payment failure a communication relates to
+func paymentFailureIDFromContext(reqContext map[string]interface{}) *uuid.UUID {
+	raw, ok := reqContext["payment_failure_id"].(string)
+	if !ok {
+		return nil
+	}
+	id, err := uuid.Parse(raw)
+	if err != nil {
+		return nil
+	}
+	return &id
+}
@@ -0,0 +1,484 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+
+	"github.com/sambitmohanty1/payment-watchdog/api/internal/architecture"
+	"github.com/sambitmohanty1/payment-watchdog/api/internal/models"
+	"github.com/sambitmohanty1/payment-watchdog/api/internal/rules"
+	"github.com/sambitmohanty1/payment-watchdog/api/internal/script"
+)
+
+var (
+	// ErrInvalidScript is returned for scripts that do not compile, fail to
+	// initialize or do not define evaluate
+	ErrInvalidScript = errors.New("invalid script")
+	// ErrScriptNotFound is returned for unknown company scripts
+	ErrScriptNotFound = errors.New("script not found")
+	// ErrScriptExists is returned when a company already has a script with the same name
+	ErrScriptExists = errors.New("script with this name already exists")
+)
+
+const (
+	// maxScriptSourceBytes bounds the size of a script's source
+	maxScriptSourceBytes = 64 << 10
+	// maxScriptSteps and maxScriptTimeout bound the limits a company may configure
+	maxScriptSteps   = 1000000
+	maxScriptTimeout = time.Second
+	// maxScriptTestCases bounds the cases run by one test harness call
+	maxScriptTestCases = 100
+)
+
+// CompanyScriptInput is the editable part of a company script
+type CompanyScriptInput struct {
+	Name        string          `json:"name" binding:"required"`
+	Description string          `json:"description"`
+	Source      string          `json:"source" binding:"required"`
+	Tables      json.RawMessage `json:"tables,omitempty"`
+	Priority    int             `json:"priority"`
+	Enabled     *bool           `json:"enabled,omitempty"` // defaults to true
+	Shadow      bool            `json:"shadow"`
+	MaxSteps    int64           `json:"max_steps,omitempty"`
+	TimeoutMs   int             `json:"timeout_ms,omitempty"`
+}
+
+// ScriptTestRequest runs a script that need not be saved against sample failures
+type ScriptTestRequest struct {
+	Source    string           `json:"source" binding:"required"`
+	Tables    json.RawMessage  `json:"tables,omitempty"`
+	MaxSteps  int64            `json:"max_steps,omitempty"`
+	TimeoutMs int              `json:"timeout_ms,omitempty"`
+	Cases     []ScriptTestCase `json:"cases" binding:"required"`
+}
+
+// ScriptTestCase is one sample failure with optional expectations
+type ScriptTestCase struct {
+	Name        string                      `json:"name"`
+	Failure     architecture.PaymentFailure `json:"failure"`
+	Timestamp   *time.Time                  `json:"timestamp,omitempty"` // defaults to now
+	Environment string                      `json:"environment,omitempty"`
+	ExpectMet   *bool                       `json:"expect_met,omitempty"`
+	// ExpectActions lists the action types actions() should return, in order
+	ExpectActions []string `json:"expect_actions,omitempty"`
+}
+
+// ScriptTestResult is the outcome of one test case
+type ScriptTestResult struct {
+	Name           string                          `json:"name"`
+	Met            bool                            `json:"met"`
+	Actions        []rules.EnterprisePlannedAction `json:"actions,omitempty"`
+	Steps          int64                           `json:"steps"`
+	DurationMicros int64                           `json:"duration_micros"`
+	Output         string                          `json:"output,omitempty"`
+	Error          string                          `json:"error,omitempty"`
+	Passed         bool                            `json:"passed"`
+	Failures       []string                        `json:"failures,omitempty"` // unmet expectations
+}
+
+// ScriptTestReport is the outcome of a test harness run
+type ScriptTestReport struct {
+	Passed     bool               `json:"passed"`
+	HasActions bool               `json:"has_actions"`
+	Cases      []ScriptTestResult `json:"cases"`
+}
+
+type compiledCompanyScript struct {
+	version int
+	module  *script.Module
+}
+
+// CompanyScriptService stores sandboxed company scripts and supplies them to the
+// enterprise rule engine as per-company rules
+type CompanyScriptService struct {
+	db        *gorm.DB
+	calendars *BusinessCalendarService
+	logger    *zap.Logger
+
+	// compiled caches initialized scripts by ID; entries are replaced when the
+	// stored version changes
+	mu       sync.Mutex
+	compiled map[uuid.UUID]compiledCompanyScript
+}
+
+// NewCompanyScriptService creates a new company script service
+func NewCompanyScriptService(db *gorm.DB, calendars *BusinessCalendarService, logger *zap.Logger) *CompanyScriptService {
+	return &CompanyScriptService{
+		db:        db,
+		calendars: calendars,
+		logger:    logger,
+		compiled:  make(map[uuid.UUID]compiledCompanyScript),
+	}
+}
+
+// Create validates and stores a new company script
+func (s *CompanyScriptService) Create(ctx context.Context, companyID string, input CompanyScriptInput, author string) (*models.CompanyScript, error) {
+	if _, err := s.compileInput(ctx, input); err != nil {
+		return nil, err
+	}
+	if err := s.checkName(ctx, companyID, input.Name, uuid.Nil); err != nil {
+		return nil, err
+	}
+
+	record := &models.CompanyScript{
+		ID:        uuid.New(),
+		CompanyID: companyID,
+		Version:   1,
+		CreatedBy: author,
+		UpdatedBy: author,
+	}
+	applyCompanyScriptInput(record, input)
+	if err := s.db.WithContext(ctx).Create(record).Error; err != nil {
+		return nil, fmt.Errorf("failed to create script: %w", err)
+	}
+
+	s.logger.Info("Company script created",
+		zap.String("company_id", companyID),
+		zap.String("script_name", record.Name))
+	return record, nil
+}
+
+// Update validates and replaces a company script, bumping its version
+func (s *CompanyScriptService) Update(ctx context.Context, companyID string, id uuid.UUID, input CompanyScriptInput, author string) (*models.CompanyScript, error) {
+	if _, err := s.compileInput(ctx, input); err != nil {
+		return nil, err
+	}
+	record, err := s.Get(ctx, companyID, id)
+	if err != nil {
+		return nil, err
+	}
+	if input.Name != record.Name {
+		if err := s.checkName(ctx, companyID, input.Name, id); err != nil {
+			return nil, err
+		}
+	}
+
+	applyCompanyScriptInput(record, input)
+	record.Version++
+	record.UpdatedBy = author
+	if err := s.db.WithContext(ctx).Save(record).Error; err != nil {
+		return nil, fmt.Errorf("failed to update script: %w", err)
+	}
+
+	s.logger.Info("Company script updated",
+		zap.String("company_id", companyID),
+		zap.String("script_name", record.Name),
+		zap.Int("version", record.Version))
+	return record, nil
+}
+
+// Get returns a company's script
+func (s *CompanyScriptService) Get(ctx context.Context, companyID string, id uuid.UUID) (*models.CompanyScript, error) {
+	var record models.CompanyScript
+	err := s.db.WithContext(ctx).Where("id = ? AND company_id = ?", id, companyID).First(&record).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrScriptNotFound
+		}
+		return nil, fmt.Errorf("failed to get script: %w", err)
+	}
+	return &record, nil
+}
+
+// List returns a company's scripts, highest priority first
+func (s *CompanyScriptService) List(ctx context.Context, companyID string) ([]models.CompanyScript, error) {
+	var records []models.CompanyScript
+	if err := s.db.WithContext(ctx).
+		Where("company_id = ?", companyID).
+		Order("priority DESC, name").
+		Find(&records).Error; err != nil {
+		return nil, fmt.Errorf("failed to list scripts: %w", err)
+	}
+	return records, nil
+}
+
+// Delete removes a company script
+func (s *CompanyScriptService) Delete(ctx context.Context, companyID string, id uuid.UUID) error {
+	result := s.db.WithContext(ctx).Where("id = ? AND company_id = ?", id, companyID).Delete(&models.CompanyScript{})
+	if result.Error != nil {
+		return fmt.Errorf("failed to delete script: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrScriptNotFound
+	}
+
+	s.mu.Lock()
+	delete(s.compiled, id)
+	s.mu.Unlock()
+	return nil
+}
+
+// CompanyRules returns the company's enabled scripts as enterprise rules. It
+// implements rules.EnterpriseRuleProvider.
+func (s *CompanyScriptService) CompanyRules(ctx context.Context, companyID string) ([]rules.EnterpriseRule, error) {
+	var records []models.CompanyScript
+	if err := s.db.WithContext(ctx).
+		Where("company_id = ? AND enabled = ?", companyID, true).
+		Order("priority DESC, name").
+		Find(&records).Error; err != nil {
+		return nil, fmt.Errorf("failed to load scripts: %w", err)
+	}
+
+	companyRules := make([]rules.EnterpriseRule, 0, len(records))
+	for _, record := range records {
+		module, err := s.module(ctx, record)
+		if err != nil {
+			// Stored scripts were valid when saved; skip any that no longer are
+			s.logger.Error("Failed to load company script",
+				zap.String("company_id", companyID),
+				zap.String("script_id", record.ID.String()),
+				zap.Error(err))
+			continue
+		}
+		rule := rules.NewScriptRule("script:"+record.ID.String(), record.Name, record.Priority, module, record.Shadow)
+		rule.Description = record.Description
+		rule.Metadata["version"] = fmt.Sprintf("%d", record.Version)
+		companyRules = append(companyRules, rule)
+	}
+	return companyRules, nil
+}
+
+// Test compiles source and runs it against each case without saving anything or
+// dispatching actions. Script errors in a case are reported in its result.
+func (s *CompanyScriptService) Test(ctx context.Context, companyID string, req ScriptTestRequest) (*ScriptTestReport, error) {
+	if len(req.Cases) == 0 {
+		return nil, fmt.Errorf("%w: at least one test case is required", ErrInvalidScript)
+	}
+	if len(req.Cases) > maxScriptTestCases {
+		return nil, fmt.Errorf("%w: at most %d test cases are allowed", ErrInvalidScript, maxScriptTestCases)
+	}
+	module, err := s.compileInput(ctx, CompanyScriptInput{
+		Name:      "test",
+		Source:    req.Source,
+		Tables:    req.Tables,
+		MaxSteps:  req.MaxSteps,
+		TimeoutMs: req.TimeoutMs,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	company := &rules.EnterpriseCompany{ID: companyID}
+	if cal, err := s.calendars.Calendar(ctx, companyID); err == nil {
+		company.Calendar = cal
+	} else {
+		s.logger.Warn("Failed to load business calendar for script test, using defaults",
+			zap.String("company_id", companyID),
+			zap.Error(err))
+	}
+
+	rule := rules.NewScriptRule("script:test", "test", 0, module, true)
+	report := &ScriptTestReport{Passed: true, HasActions: module.Has(rules.ScriptActionsFunction)}
+	for i, tc := range req.Cases {
+		result := runScriptTestCase(ctx, rule, company, tc)
+		if result.Name == "" {
+			result.Name = fmt.Sprintf("case %d", i+1)
+		}
+		report.Passed = report.Passed && result.Passed
+		report.Cases = append(report.Cases, result)
+	}
+	return report, nil
+}
+
+func runScriptTestCase(ctx context.Context, rule rules.EnterpriseRule, company *rules.EnterpriseCompany, tc ScriptTestCase) ScriptTestResult {
+	failure := tc.Failure
+	if failure.CompanyID == "" {
+		failure.CompanyID = company.ID
+	}
+	ruleCtx := rules.EnterpriseRuleContext{
+		PaymentFailure: &failure,
+		Company:        company,
+		Timestamp:      time.Now(),
+		Environment:    tc.Environment,
+	}
+	if tc.Timestamp != nil {
+		ruleCtx.Timestamp = *tc.Timestamp
+	}
+	if ruleCtx.Environment == "" {
+		ruleCtx.Environment = "test"
+	}
+
+	result := ScriptTestResult{Name: tc.Name}
+	start := time.Now()
+	condition := rule.Conditions[0].(rules.EnterpriseCheckedCondition)
+	met, observed, err := condition.EvaluateChecked(ctx, ruleCtx)
+	if observation, ok := observed.(map[string]interface{}); ok {
+		result.Steps, _ = observation["steps"].(int64)
+		result.Output, _ = observation["output"].(string)
+	}
+	result.Met = met
+	if err != nil {
+		result.Error = err.Error()
+	}
+	if err == nil && met && len(rule.Actions) > 0 {
+		planned, err := rule.Actions[0].(rules.EnterpriseActionPlanner).PlanActions(ctx, ruleCtx)
+		if err != nil {
+			result.Error = err.Error()
+		}
+		result.Actions = planned
+	}
+	result.DurationMicros = time.Since(start).Microseconds()
+
+	if result.Error != "" {
+		result.Failures = append(result.Failures, "script error: "+result.Error)
+	}
+	if tc.ExpectMet != nil && *tc.ExpectMet != result.Met {
+		result.Failures = append(result.Failures, fmt.Sprintf("expected met=%t, got %t", *tc.ExpectMet, result.Met))
+	}
+	if tc.ExpectActions != nil {
+		got := make([]string, len(result.Actions))
+		for i, action := range result.Actions {
+			got[i] = action.Type
+		}
+		if strings.Join(got, ",") != strings.Join(tc.ExpectActions, ",") {
+			result.Failures = append(result.Failures, fmt.Sprintf("expected actions [%s], got [%s]",
+				strings.Join(tc.ExpectActions, ", "), strings.Join(got, ", ")))
+		}
+	}
+	result.Passed = len(result.Failures) == 0
+	return result
+}
+
+// module returns the initialized script for record, compiling it when the cache
+// holds an older version
+func (s *CompanyScriptService) module(ctx context.Context, record models.CompanyScript) (*script.Module, error) {
+	s.mu.Lock()
+	cached, ok := s.compiled[record.ID]
+	s.mu.Unlock()
+	if ok && cached.version == record.Version {
+		return cached.module, nil
+	}
+
+	module, err := s.compileInput(ctx, CompanyScriptInput{
+		Name:      record.Name,
+		Source:    record.Source,
+		Tables:    json.RawMessage(record.Tables),
+		MaxSteps:  record.MaxSteps,
+		TimeoutMs: record.TimeoutMs,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	s.compiled[record.ID] = compiledCompanyScript{version: record.Version, module: module}
+	s.mu.Unlock()
+	return module, nil
+}
+
+// compileInput validates a script and its settings and returns it initialized
+func (s *CompanyScriptService) compileInput(ctx context.Context, input CompanyScriptInput) (*script.Module, error) {
+	switch {
+	case strings.TrimSpace(input.Name) == "":
+		return nil, fmt.Errorf("%w: name is required", ErrInvalidScript)
+	case len(input.Source) > maxScriptSourceBytes:
+		return nil, fmt.Errorf("%w: source exceeds %d bytes", ErrInvalidScript, maxScriptSourceBytes)
+	case input.MaxSteps < 0 || input.MaxSteps > maxScriptSteps:
+		return nil, fmt.Errorf("%w: max_steps must be between 0 and %d", ErrInvalidScript, maxScriptSteps)
+	case input.TimeoutMs < 0 || time.Duration(input.TimeoutMs)*time.Millisecond > maxScriptTimeout:
+		return nil, fmt.Errorf("%w: timeout_ms must be between 0 and %d", ErrInvalidScript, maxScriptTimeout.Milliseconds())
+	}
+
+	limits := script.DefaultLimits
+	if input.MaxSteps > 0 {
+		limits.MaxSteps = input.MaxSteps
+	}
+	if input.TimeoutMs > 0 {
+		limits.Timeout = time.Duration(input.TimeoutMs) * time.Millisecond
+	}
+
+	tables, err := decodeScriptTables(input.Tables)
+	if err != nil {
+		return nil, err
+	}
+	prog, err := script.Compile(input.Name, input.Source)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidScript, err)
+	}
+	module, err := prog.Init(ctx, map[string]script.Value{"tables": tables}, limits)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidScript, err)
+	}
+	if !module.Has(rules.ScriptEvaluateFunction) {
+		return nil, fmt.Errorf("%w: script must define %s(failure, ctx)", ErrInvalidScript, rules.ScriptEvaluateFunction)
+	}
+	return module, nil
+}
+
+func (s *CompanyScriptService) checkName(ctx context.Context, companyID, name string, exclude uuid.UUID) error {
+	var count int64
+	if err := s.db.WithContext(ctx).Model(&models.CompanyScript{}).
+		Where("company_id = ? AND name = ? AND id <> ?", companyID, name, exclude).
+		Count(&count).Error; err != nil {
+		return fmt.Errorf("failed to check script name: %w", err)
+	}
+	if count > 0 {
+		return ErrScriptExists
+	}
+	return nil
+}
+
+func applyCompanyScriptInput(record *models.CompanyScript, input CompanyScriptInput) {
+	record.Name = strings.TrimSpace(input.Name)
+	record.Description = input.Description
+	record.Source = input.Source
+	record.Tables = nil
+	if len(input.Tables) > 0 {
+		record.Tables = datatypes.JSON(input.Tables)
+	}
+	record.Priority = input.Priority
+	record.Enabled = input.Enabled == nil || *input.Enabled
+	record.Shadow = input.Shadow
+	record.MaxSteps = input.MaxSteps
+	record.TimeoutMs = input.TimeoutMs
+}
+
+// decodeScriptTables converts a script's JSON tables, which must be an object, to
+// a script dict. Integer literals become ints so they compare and index exactly.
+func decodeScriptTables(raw json.RawMessage) (script.Value, error) {
+	if len(bytes.TrimSpace(raw)) == 0 || bytes.Equal(bytes.TrimSpace(raw), []byte("null")) {
+		return script.NewDict(), nil
+	}
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.UseNumber()
+	var tables map[string]interface{}
+	if err := decoder.Decode(&tables); err != nil {
+		return nil, fmt.Errorf("%w: tables must be a JSON object: %v", ErrInvalidScript, err)
+	}
+	value, err := script.FromGo(scriptNumbers(tables))
+	if err != nil {
+		return nil, fmt.Errorf("%w: tables: %v", ErrInvalidScript, err)
+	}
+	return value, nil
+}
+
+func scriptNumbers(v interface{}) interface{} {
+	switch v := v.(type) {
+	case json.Number:
+		if n, err := v.Int64(); err == nil {
+			return n
+		}
+		f, _ := v.Float64()
+		return f
+	case map[string]interface{}:
+		for key, value := range v {
+			v[key] = scriptNumbers(value)
+		}
+	case []interface{}:
+		for i, value := range v {
+			v[i] = scriptNumbers(value)
+		}
+	}
+	return v
+}
@@ -0,0 +1,106 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/sambitmohanty1/payment-watchdog/api/internal/architecture"
+	"github.com/sambitmohanty1/payment-watchdog/api/internal/script"
+)
+
+func TestCompanyScriptValidation(t *testing.T) {
+	service := NewCompanyScriptService(nil, nil, zap.NewNop())
+	ctx := context.Background()
+
+	module, err := service.compileInput(ctx, CompanyScriptInput{
+		Name:   "limits",
+		Source: "def evaluate(failure, ctx):\n    return failure.amount > tables[\"limit\"]\n",
+		Tables: json.RawMessage(`{"limit": 500, "rate": 0.5}`),
+	})
+	require.NoError(t, err)
+	assert.True(t, module.Has("evaluate"))
+
+	invalid := map[string]CompanyScriptInput{
+		"name is required":             {Source: "def evaluate(f, c):\n    return True\n"},
+		"must define evaluate":         {Name: "x", Source: "def check(f, c):\n    return True\n"},
+		"line 1:1: while loops":        {Name: "x", Source: "while True:\n    pass\n"},
+		"tables must be a JSON object": {Name: "x", Source: "def evaluate(f, c):\n    return True\n", Tables: json.RawMessage(`[1]`)},
+		"max_steps must be between":    {Name: "x", Source: "def evaluate(f, c):\n    return True\n", MaxSteps: 5000000},
+		"timeout_ms must be between":   {Name: "x", Source: "def evaluate(f, c):\n    return True\n", TimeoutMs: 5000},
+	}
+	for want, input := range invalid {
+		_, err := service.compileInput(ctx, input)
+		require.Error(t, err, want)
+		assert.True(t, errors.Is(err, ErrInvalidScript), want)
+		assert.Contains(t, err.Error(), want)
+	}
+
+	_, err = service.compileInput(ctx, CompanyScriptInput{Name: "x", Source: "def evaluate(f, c)\n    return True\n"})
+	var scriptErr *script.Error
+	require.True(t, errors.As(err, &scriptErr))
+	assert.Equal(t, 1, scriptErr.Pos.Line)
+}
+
+func TestDecodeScriptTables(t *testing.T) {
+	tables, err := decodeScriptTables(json.RawMessage(`{"limits": {"AUD": 500, "USD": 1e3}, "rate": 0.25, "codes": ["a"]}`))
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"limits": map[string]interface{}{"AUD": int64(500), "USD": 1000.0},
+		"rate":   0.25,
+		"codes":  []interface{}{"a"},
+	}, script.ToGo(tables))
+
+	empty, err := decodeScriptTables(nil)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{}, script.ToGo(empty))
+}
+
+func TestCompanyScriptTestHarness(t *testing.T) {
+	service := scriptService(dryRunDB(t))
+	met, notMet := true, false
+	saturday := time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)
+
+	report, err := service.Test(context.Background(), "company-1", ScriptTestRequest{
+		Source: `
+def evaluate(failure, ctx):
+    print("amount", failure.amount)
+    return failure.amount >= tables["threshold"]
+
+def actions(failure, ctx):
+    if ctx.business_day:
+        return [action("send_email", template = "large_failure")]
+    return [action("schedule_retry", at = "next_business_day 09:00")]
+`,
+		Tables: json.RawMessage(`{"threshold": 1000}`),
+		Cases: []ScriptTestCase{
+			{Name: "large", Failure: architecture.PaymentFailure{Amount: 1500}, Timestamp: &saturday, ExpectMet: &met, ExpectActions: []string{"schedule_retry"}},
+			{Failure: architecture.PaymentFailure{Amount: 10}, ExpectMet: &notMet},
+			{Name: "wrong expectation", Failure: architecture.PaymentFailure{Amount: 10}, ExpectMet: &met},
+		},
+	})
+	require.NoError(t, err)
+	require.Len(t, report.Cases, 3)
+	assert.False(t, report.Passed)
+	assert.True(t, report.HasActions)
+
+	large := report.Cases[0]
+	assert.True(t, large.Passed, "%v", large.Failures)
+	assert.Equal(t, "amount 1500.0\n", large.Output)
+	assert.Positive(t, large.Steps)
+	assert.Equal(t, map[string]interface{}{"at": "next_business_day 09:00"}, large.Actions[0].Params)
+
+	assert.Equal(t, "case 2", report.Cases[1].Name)
+	assert.True(t, report.Cases[1].Passed)
+	assert.Empty(t, report.Cases[1].Actions)
+	assert.Equal(t, []string{"expected met=true, got false"}, report.Cases[2].Failures)
+
+	_, err = service.Test(context.Background(), "company-1", ScriptTestRequest{Source: "def evaluate(f, c):\n    return True\n"})
+	assert.ErrorIs(t, err, ErrInvalidScript)
+}
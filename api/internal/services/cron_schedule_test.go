@@ -0,0 +1,56 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseCronExpressionErrors(t *testing.T) {
+	for _, expr := range []string{"", "* * * *", "60 * * * *", "* 24 * * *", "0 9 * * foo", "0 9 5-1 * *", "*/0 * * * *"} {
+		_, err := ParseCronExpression(expr)
+		assert.ErrorIs(t, err, ErrInvalidCronExpression, expr)
+	}
+}
+
+func TestCronScheduleNext(t *testing.T) {
+	sydney, err := time.LoadLocation("Australia/Sydney")
+	require.NoError(t, err)
+
+	tests := []struct {
+		expr string
+		from time.Time
+		want time.Time
+	}{
+		// Monday 08:00 weekly, from a Wednesday
+		{"0 8 * * MON", time.Date(2025, 3, 5, 12, 0, 0, 0, sydney), time.Date(2025, 3, 10, 8, 0, 0, 0, sydney)},
+		// Strictly after the current activation
+		{"0 8 * * 1", time.Date(2025, 3, 10, 8, 0, 0, 0, sydney), time.Date(2025, 3, 17, 8, 0, 0, 0, sydney)},
+		{"*/15 9-17 * * 1-5", time.Date(2025, 3, 7, 17, 50, 0, 0, sydney), time.Date(2025, 3, 10, 9, 0, 0, 0, sydney)},
+		{"@monthly", time.Date(2025, 1, 31, 0, 0, 0, 0, sydney), time.Date(2025, 2, 1, 0, 0, 0, 0, sydney)},
+		{"0 0 29 2 *", time.Date(2025, 1, 1, 0, 0, 0, 0, sydney), time.Date(2028, 2, 29, 0, 0, 0, 0, sydney)},
+		// Day-of-month and day-of-week are ORed when both are restricted
+		{"0 6 1 * SUN", time.Date(2025, 3, 2, 7, 0, 0, 0, sydney), time.Date(2025, 3, 9, 6, 0, 0, 0, sydney)},
+		// 02:30 does not exist on 2025-10-05 in Sydney (clocks jump 02:00 -> 03:00)
+		{"30 2 * * *", time.Date(2025, 10, 4, 12, 0, 0, 0, sydney), time.Date(2025, 10, 6, 2, 30, 0, 0, sydney)},
+	}
+	for _, tt := range tests {
+		s, err := ParseCronExpression(tt.expr)
+		require.NoError(t, err, tt.expr)
+		assert.True(t, tt.want.Equal(s.Next(tt.from, sydney)), "%s: got %s want %s", tt.expr, s.Next(tt.from, sydney), tt.want)
+	}
+}
+
+func TestCronScheduleNextUsesLocation(t *testing.T) {
+	sydney, err := time.LoadLocation("Australia/Sydney")
+	require.NoError(t, err)
+
+	s, err := ParseCronExpression("0 8 * * *")
+	require.NoError(t, err)
+
+	// 20:00 UTC on 9 March is 07:00 on 10 March in Sydney
+	next := s.Next(time.Date(2025, 3, 9, 20, 0, 0, 0, time.UTC), sydney)
+	assert.Equal(t, time.Date(2025, 3, 9, 21, 0, 0, 0, time.UTC), next.UTC())
+}
@@ -0,0 +1,444 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/sambitmohanty1/payment-watchdog/api/internal/architecture"
+	"github.com/sambitmohanty1/payment-watchdog/api/internal/models"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// timelineSourceLimit caps how many recent records of each kind feed the activity timeline
+const timelineSourceLimit = 200
+
+// ErrCustomerNotFound is returned when a customer has no records for the company
+var ErrCustomerNotFound = errors.New("customer not found")
+
+// Customer360Service aggregates everything known about a customer across providers
+type Customer360Service struct {
+	db               *gorm.DB
+	identityService  *CustomerIdentityService
+	analyticsService *AnalyticsService
+	logger           *zap.Logger
+}
+
+// SectionPage requests one page of a Customer 360 section
+type SectionPage struct {
+	Page  int `json:"page"`
+	Limit int `json:"limit"`
+}
+
+// Customer360Options holds the customer ID's provider and per-section pagination
+type Customer360Options struct {
+	Provider       string // the provider of a provider customer ID, when known
+	Failures       SectionPage
+	Invoices       SectionPage
+	Executions     SectionPage
+	Actions        SectionPage
+	Communications SectionPage
+	Timeline       SectionPage
+}
+
+// SectionPagination describes the page returned for a section
+type SectionPagination struct {
+	Page       int   `json:"page"`
+	Limit      int   `json:"limit"`
+	Total      int64 `json:"total"`
+	TotalPages int64 `json:"total_pages"`
+	// Truncated reports that the total counts only the most recent records of
+	// each kind, so older entries are missing from the last pages
+	Truncated bool `json:"truncated,omitempty"`
+}
+
+// Customer360Section is one paginated section of the Customer 360 view
+type Customer360Section struct {
+	Data       interface{}       `json:"data"`
+	Pagination SectionPagination `json:"pagination"`
+}
+
+// CustomerTotals holds lifetime financial totals for a customer
+type CustomerTotals struct {
+	LifetimeInvoicedCents int64 `json:"lifetime_invoiced_cents"`
+	LifetimePaidCents     int64 `json:"lifetime_paid_cents"`
+	OutstandingCents      int64 `json:"outstanding_cents"`
+	FailedAmountCents     int64 `json:"failed_amount_cents"`
+	FailureCount          int64 `json:"failure_count"`
+}
+
+// TimelineEntry is a single item in a customer's activity timeline
+type TimelineEntry struct {
+	OccurredAt  time.Time `json:"occurred_at"`
+	Type        string    `json:"type"`
+	Description string    `json:"description"`
+	ReferenceID string    `json:"reference_id"`
+	AmountCents int64     `json:"amount_cents,omitempty"`
+	Status      string    `json:"status,omitempty"`
+}
+
+// Customer360 is the aggregated view of a single customer
+type Customer360 struct {
+	CustomerID     string                   `json:"customer_id"`
+	Identity       *models.CustomerIdentity `json:"identity,omitempty"`
+	CustomerIDs    []string                 `json:"customer_ids"`
+	RiskScore      float64                  `json:"risk_score"`
+	Totals         CustomerTotals           `json:"totals"`
+	Failures       Customer360Section       `json:"failures"`
+	Invoices       Customer360Section       `json:"invoices"`
+	Executions     Customer360Section       `json:"executions"`
+	Actions        Customer360Section       `json:"recovery_actions"`
+	Communications Customer360Section       `json:"communications"`
+	Timeline       Customer360Section       `json:"timeline"`
+	GeneratedAt    time.Time                `json:"generated_at"`
+}
+
+// NewCustomer360Service creates a new Customer 360 service
+func NewCustomer360Service(db *gorm.DB, identityService *CustomerIdentityService, analyticsService *AnalyticsService, logger *zap.Logger) *Customer360Service {
+	return &Customer360Service{
+		db:               db,
+		identityService:  identityService,
+		analyticsService: analyticsService,
+		logger:           logger,
+	}
+}
+
+// GetCustomer360 returns the aggregated view for a customer, identified either by
+// an internal identity ID or any linked provider customer ID
+func (s *Customer360Service) GetCustomer360(ctx context.Context, companyID, customerID string, opts Customer360Options) (*Customer360, error) {
+	result := &Customer360{
+		CustomerID:  customerID,
+		GeneratedAt: time.Now(),
+	}
+
+	identity, err := s.identityService.FindIdentity(ctx, companyID, opts.Provider, customerID)
+	if err != nil && !errors.Is(err, ErrCustomerIdentityNotFound) {
+		return nil, fmt.Errorf("failed to resolve customer identity: %w", err)
+	}
+	if identity != nil {
+		result.Identity = identity
+		result.CustomerIDs, err = s.identityService.GetLinkedCustomerIDs(ctx, companyID, "", identity.ID.String())
+		if err != nil {
+			return nil, fmt.Errorf("failed to get linked customer IDs: %w", err)
+		}
+	} else {
+		result.CustomerIDs = []string{customerID}
+	}
+
+	db := s.db.WithContext(ctx)
+	failureIDs := db.Model(&models.PaymentFailureEvent{}).Select("id").
+		Where("company_id = ? AND customer_id IN ?", companyID, result.CustomerIDs)
+
+	// Payment failures
+	var failures []models.PaymentFailureEvent
+	result.Failures, err = paginateSection(db.Model(&models.PaymentFailureEvent{}).
+		Where("company_id = ? AND customer_id IN ?", companyID, result.CustomerIDs).
+		Order("created_at DESC"), opts.Failures, &failures)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get payment failures: %w", err)
+	}
+
+	// Invoices
+	var invoices []architecture.Invoice
+	result.Invoices, err = paginateSection(db.Model(&architecture.Invoice{}).
+		Where("company_id = ? AND customer_id IN ?", companyID, result.CustomerIDs).
+		Order("issue_date DESC"), opts.Invoices, &invoices)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get invoices: %w", err)
+	}
+
+	if result.Identity == nil && result.Failures.Pagination.Total == 0 && result.Invoices.Pagination.Total == 0 {
+		return nil, ErrCustomerNotFound
+	}
+
+	// Workflow executions with step history
+	var executions []models.RecoveryWorkflowExecution
+	result.Executions, err = paginateSection(db.Model(&models.RecoveryWorkflowExecution{}).
+		Where("payment_failure_id IN (?)", failureIDs).
+		Preload("StepExecutions", func(tx *gorm.DB) *gorm.DB { return tx.Order("started_at ASC") }).
+		Preload("StepExecutions.Step").
+		Order("started_at DESC"), opts.Executions, &executions)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get workflow executions: %w", err)
+	}
+
+	// Recovery actions
+	var actions []models.RecoveryAction
+	result.Actions, err = paginateSection(db.Model(&models.RecoveryAction{}).
+		Where("payment_failure_id IN (?)", failureIDs).
+		Order("created_at DESC"), opts.Actions, &actions)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get recovery actions: %w", err)
+	}
+
+	// Communications
+	var communications []models.CustomerCommunication
+	result.Communications, err = paginateSection(db.Model(&models.CustomerCommunication{}).
+		Where("company_id = ? AND payment_failure_id IN (?)", companyID, failureIDs).
+		Order("created_at DESC"), opts.Communications, &communications)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get communications: %w", err)
+	}
+
+	// Totals
+	if err := s.loadTotals(ctx, companyID, result); err != nil {
+		return nil, err
+	}
+
+	// Risk score from the failure predictor, across all linked provider records
+	riskCustomerID := customerID
+	if identity != nil {
+		riskCustomerID = identity.ID.String()
+	}
+	result.RiskScore, err = s.analyticsService.GetCustomerRiskScore(ctx, companyID, opts.Provider, riskCustomerID)
+	if err != nil {
+		s.logger.Warn("Failed to calculate customer risk score",
+			zap.String("company_id", companyID),
+			zap.String("customer_id", customerID),
+			zap.Error(err))
+	}
+
+	// Activity timeline
+	timeline, truncated, err := s.buildTimeline(ctx, companyID, result.CustomerIDs, failureIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build activity timeline: %w", err)
+	}
+	result.Timeline = pageSlice(timeline, opts.Timeline)
+	result.Timeline.Pagination.Truncated = truncated
+
+	return result, nil
+}
+
+// Helper methods
+
+func (s *Customer360Service) loadTotals(ctx context.Context, companyID string, result *Customer360) error {
+	db := s.db.WithContext(ctx)
+
+	// Invoice amounts are DECIMAL(12,2), so summing them in cents is exact
+	var invoiceTotals struct {
+		InvoicedCents    int64
+		PaidCents        int64
+		OutstandingCents int64
+	}
+	if err := db.Model(&architecture.Invoice{}).
+		Select(`CAST(COALESCE(SUM(amount * 100), 0) AS BIGINT) AS invoiced_cents,
+			CAST(COALESCE(SUM(CASE WHEN paid_date IS NOT NULL OR status = 'paid' THEN amount * 100 ELSE 0 END), 0) AS BIGINT) AS paid_cents,
+			CAST(COALESCE(SUM(CASE WHEN paid_date IS NULL AND status NOT IN ('paid', 'voided', 'deleted') THEN amount * 100 ELSE 0 END), 0) AS BIGINT) AS outstanding_cents`).
+		Where("company_id = ? AND customer_id IN ?", companyID, result.CustomerIDs).
+		Scan(&invoiceTotals).Error; err != nil {
+		return fmt.Errorf("failed to calculate invoice totals: %w", err)
+	}
+
+	var failureTotals struct {
+		Count       int64
+		AmountCents int64
+	}
+	if err := db.Model(&models.PaymentFailureEvent{}).
+		Select("COUNT(*) AS count, COALESCE(SUM(amount_cents), 0) AS amount_cents").
+		Where("company_id = ? AND customer_id IN ? AND status NOT IN ?", companyID, result.CustomerIDs, []string{"resolved", "recovered"}).
+		Scan(&failureTotals).Error; err != nil {
+		return fmt.Errorf("failed to calculate failure totals: %w", err)
+	}
+
+	result.Totals = CustomerTotals{
+		LifetimeInvoicedCents: invoiceTotals.InvoicedCents,
+		LifetimePaidCents:     invoiceTotals.PaidCents,
+		OutstandingCents:      invoiceTotals.OutstandingCents,
+		FailedAmountCents:     failureTotals.AmountCents,
+		FailureCount:          failureTotals.Count,
+	}
+	return nil
+}
+
+// buildTimeline merges the most recent records of each kind into a single
+// newest-first activity stream. It reports whether any kind had more than
+// timelineSourceLimit records, in which case older entries are left out.
+func (s *Customer360Service) buildTimeline(ctx context.Context, companyID string, customerIDs []string, failureIDs *gorm.DB) ([]TimelineEntry, bool, error) {
+	db := s.db.WithContext(ctx)
+	var timeline []TimelineEntry
+	truncated := false
+
+	var failures []models.PaymentFailureEvent
+	if err := db.Where("company_id = ? AND customer_id IN ?", companyID, customerIDs).
+		Order("created_at DESC").Limit(timelineSourceLimit + 1).Find(&failures).Error; err != nil {
+		return nil, false, err
+	}
+	if len(failures) > timelineSourceLimit {
+		failures, truncated = failures[:timelineSourceLimit], true
+	}
+	for _, f := range failures {
+		timeline = append(timeline, TimelineEntry{
+			OccurredAt:  f.CreatedAt,
+			Type:        "payment_failed",
+			Description: fmt.Sprintf("Payment failed via %s: %s", f.ProviderID, f.FailureReason),
+			ReferenceID: f.ID.String(),
+			AmountCents: f.AmountCents,
+			Status:      f.Status,
+		})
+	}
+
+	var invoices []architecture.Invoice
+	if err := db.Where("company_id = ? AND customer_id IN ?", companyID, customerIDs).
+		Order("issue_date DESC").Limit(timelineSourceLimit + 1).Find(&invoices).Error; err != nil {
+		return nil, false, err
+	}
+	if len(invoices) > timelineSourceLimit {
+		invoices, truncated = invoices[:timelineSourceLimit], true
+	}
+	for _, inv := range invoices {
+		timeline = append(timeline, TimelineEntry{
+			OccurredAt:  inv.IssueDate,
+			Type:        "invoice_issued",
+			Description: fmt.Sprintf("Invoice %s issued", inv.InvoiceNumber),
+			ReferenceID: inv.ID.String(),
+			AmountCents: int64(math.Round(inv.Amount * 100)),
+			Status:      inv.Status,
+		})
+		if inv.PaidDate != nil {
+			timeline = append(timeline, TimelineEntry{
+				OccurredAt:  *inv.PaidDate,
+				Type:        "invoice_paid",
+				Description: fmt.Sprintf("Invoice %s paid", inv.InvoiceNumber),
+				ReferenceID: inv.ID.String(),
+				AmountCents: int64(math.Round(inv.Amount * 100)),
+			})
+		}
+	}
+
+	var executions []models.RecoveryWorkflowExecution
+	if err := db.Where("payment_failure_id IN (?)", failureIDs).
+		Order("started_at DESC").Limit(timelineSourceLimit + 1).Find(&executions).Error; err != nil {
+		return nil, false, err
+	}
+	if len(executions) > timelineSourceLimit {
+		executions, truncated = executions[:timelineSourceLimit], true
+	}
+	for _, e := range executions {
+		timeline = append(timeline, TimelineEntry{
+			OccurredAt:  e.StartedAt,
+			Type:        "workflow_started",
+			Description: "Recovery workflow started",
+			ReferenceID: e.ID.String(),
+			Status:      e.Status,
+		})
+		if e.CompletedAt != nil {
+			timeline = append(timeline, TimelineEntry{
+				OccurredAt:  *e.CompletedAt,
+				Type:        "workflow_" + e.Status,
+				Description: fmt.Sprintf("Recovery workflow %s", e.Status),
+				ReferenceID: e.ID.String(),
+				Status:      e.Status,
+			})
+		}
+	}
+
+	var actions []models.RecoveryAction
+	if err := db.Where("payment_failure_id IN (?)", failureIDs).
+		Order("created_at DESC").Limit(timelineSourceLimit + 1).Find(&actions).Error; err != nil {
+		return nil, false, err
+	}
+	if len(actions) > timelineSourceLimit {
+		actions, truncated = actions[:timelineSourceLimit], true
+	}
+	for _, a := range actions {
+		occurredAt := a.CreatedAt
+		if a.ExecutedAt != nil {
+			occurredAt = *a.ExecutedAt
+		}
+		timeline = append(timeline, TimelineEntry{
+			OccurredAt:  occurredAt,
+			Type:        a.ActionType,
+			Description: fmt.Sprintf("Recovery action %s (%s)", a.ActionType, a.Provider),
+			ReferenceID: a.ID.String(),
+			Status:      a.Status,
+		})
+	}
+
+	var communications []models.CustomerCommunication
+	if err := db.Where("company_id = ? AND payment_failure_id IN (?)", companyID, failureIDs).
+		Order("created_at DESC").Limit(timelineSourceLimit + 1).Find(&communications).Error; err != nil {
+		return nil, false, err
+	}
+	if len(communications) > timelineSourceLimit {
+		communications, truncated = communications[:timelineSourceLimit], true
+	}
+	for _, c := range communications {
+		occurredAt := c.CreatedAt
+		if c.SentAt != nil {
+			occurredAt = *c.SentAt
+		}
+		timeline = append(timeline, TimelineEntry{
+			OccurredAt:  occurredAt,
+			Type:        "communication_" + c.CommunicationType,
+			Description: fmt.Sprintf("%s sent to %s", c.CommunicationType, c.Recipient),
+			ReferenceID: c.ID.String(),
+			Status:      c.Status,
+		})
+	}
+
+	sort.SliceStable(timeline, func(i, j int) bool {
+		return timeline[i].OccurredAt.After(timeline[j].OccurredAt)
+	})
+
+	return timeline, truncated, nil
+}
+
+// normalizeSectionPage applies the API's default page size and bounds
+func normalizeSectionPage(p SectionPage) SectionPage {
+	if p.Page < 1 {
+		p.Page = 1
+	}
+	if p.Limit < 1 || p.Limit > 100 {
+		p.Limit = 20
+	}
+	return p
+}
+
+func newSectionPagination(p SectionPage, total int64) SectionPagination {
+	return SectionPagination{
+		Page:       p.Page,
+		Limit:      p.Limit,
+		Total:      total,
+		TotalPages: (total + int64(p.Limit) - 1) / int64(p.Limit),
+	}
+}
+
+// paginateSection counts and loads one page of query into dest
+func paginateSection(query *gorm.DB, page SectionPage, dest interface{}) (Customer360Section, error) {
+	page = normalizeSectionPage(page)
+
+	var total int64
+	if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		return Customer360Section{}, err
+	}
+
+	if err := query.Offset((page.Page - 1) * page.Limit).Limit(page.Limit).Find(dest).Error; err != nil {
+		return Customer360Section{}, err
+	}
+
+	return Customer360Section{Data: dest, Pagination: newSectionPagination(page, total)}, nil
+}
+
+// pageSlice returns one page of an in-memory timeline
+func pageSlice(timeline []TimelineEntry, page SectionPage) Customer360Section {
+	page = normalizeSectionPage(page)
+	total := int64(len(timeline))
+
+	start := (page.Page - 1) * page.Limit
+	if start > len(timeline) {
+		start = len(timeline)
+	}
+	end := start + page.Limit
+	if end > len(timeline) {
+		end = len(timeline)
+	}
+
+	return Customer360Section{
+		Data:       timeline[start:end],
+		Pagination: newSectionPagination(page, total),
+	}
+}
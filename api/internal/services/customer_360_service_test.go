@@ -0,0 +1,194 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/sambitmohanty1/payment-watchdog/api/internal/models"
+)
+
+func timelineEntries(n int) []TimelineEntry {
+	entries := make([]TimelineEntry, n)
+	for i := range entries {
+		entries[i] = TimelineEntry{ReferenceID: fmt.Sprint(i)}
+	}
+	return entries
+}
+
+func TestPageSliceBounds(t *testing.T) {
+	timeline := timelineEntries(5)
+
+	page := pageSlice(timeline, SectionPage{Page: 3, Limit: 2})
+	assert.Equal(t, timeline[4:5], page.Data)
+	assert.Equal(t, SectionPagination{Page: 3, Limit: 2, Total: 5, TotalPages: 3}, page.Pagination)
+
+	// Pages past the end are empty rather than out of range
+	page = pageSlice(timeline, SectionPage{Page: 9, Limit: 2})
+	assert.Empty(t, page.Data)
+	assert.Equal(t, int64(5), page.Pagination.Total)
+
+	// Unset and oversized pages fall back to the first page of 20
+	for _, p := range []SectionPage{{}, {Page: -1, Limit: 0}, {Page: 1, Limit: 500}} {
+		page = pageSlice(timeline, p)
+		assert.Equal(t, timeline, page.Data)
+		assert.Equal(t, SectionPagination{Page: 1, Limit: 20, Total: 5, TotalPages: 1}, page.Pagination)
+	}
+
+	assert.Equal(t, SectionPagination{Page: 1, Limit: 20}, pageSlice(nil, SectionPage{}).Pagination)
+}
+
+func TestPaginateSection(t *testing.T) {
+	db, mock := mockDB(t)
+
+	mock.ExpectQuery(`SELECT count\(\*\) FROM "payment_failure_events" WHERE company_id = \$1`).
+		WithArgs("company").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(45))
+	mock.ExpectQuery(`SELECT \* FROM "payment_failure_events" WHERE company_id = \$1 ORDER BY created_at DESC LIMIT \$2 OFFSET \$3`).
+		WithArgs("company", 20, 40).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(uuid.New()))
+
+	var failures []models.PaymentFailureEvent
+	section, err := paginateSection(db.Model(&models.PaymentFailureEvent{}).
+		Where("company_id = ?", "company").
+		Order("created_at DESC"), SectionPage{Page: 3, Limit: 20}, &failures)
+	require.NoError(t, err)
+	assert.Len(t, failures, 1)
+	assert.Equal(t, SectionPagination{Page: 3, Limit: 20, Total: 45, TotalPages: 3}, section.Pagination)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestBuildTimelineOrdering(t *testing.T) {
+	db, mock := mockDB(t)
+	service := NewCustomer360Service(db, nil, nil, zap.NewNop())
+	customerIDs := []string{"cus_1", "xero_1"}
+	at := func(day int) time.Time { return time.Date(2026, 3, day, 9, 0, 0, 0, time.UTC) }
+	failureID, invoiceID, executionID, actionID, messageID := uuid.New(), uuid.New(), uuid.New(), uuid.New(), uuid.New()
+
+	// Every source is scoped to the company, directly or through its failures
+	mock.ExpectQuery(`SELECT \* FROM "payment_failure_events" WHERE company_id = \$1 AND customer_id IN \(\$2,\$3\) ORDER BY created_at DESC LIMIT \$4`).
+		WithArgs("company", "cus_1", "xero_1", timelineSourceLimit+1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "provider_id", "failure_reason", "amount_cents", "status", "created_at"}).
+			AddRow(failureID, "stripe", "card_declined", 12550, "received", at(3)))
+	mock.ExpectQuery(`SELECT \* FROM "invoices" WHERE company_id = \$1 AND customer_id IN \(\$2,\$3\) ORDER BY issue_date DESC LIMIT \$4`).
+		WithArgs("company", "cus_1", "xero_1", timelineSourceLimit+1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "invoice_number", "amount", "status", "issue_date", "paid_date"}).
+			AddRow(invoiceID, "INV-1", 300.0, "paid", at(1), at(6)))
+	mock.ExpectQuery(`SELECT \* FROM "recovery_workflow_executions" WHERE payment_failure_id IN \(SELECT "id" FROM "payment_failure_events" WHERE company_id = \$1 AND customer_id IN \(\$2,\$3\)\)`).
+		WithArgs("company", "cus_1", "xero_1", timelineSourceLimit+1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "status", "started_at", "completed_at"}).
+			AddRow(executionID, "completed", at(4), at(5)))
+	mock.ExpectQuery(`SELECT \* FROM "recovery_actions" WHERE payment_failure_id IN \(SELECT "id" FROM "payment_failure_events" WHERE company_id = \$1`).
+		WithArgs("company", "cus_1", "xero_1", timelineSourceLimit+1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "action_type", "provider", "status", "created_at", "executed_at"}).
+			AddRow(actionID, "payment_retry", "stripe", "completed", at(2), at(4).Add(time.Hour)))
+	mock.ExpectQuery(`SELECT \* FROM "customer_communications" WHERE company_id = \$1 AND payment_failure_id IN \(SELECT "id" FROM "payment_failure_events" WHERE company_id = \$2`).
+		WithArgs("company", "company", "cus_1", "xero_1", timelineSourceLimit+1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "communication_type", "recipient", "status", "created_at", "sent_at"}).
+			AddRow(messageID, "email", "ada@example.com", "sent", at(3).Add(time.Hour), nil))
+
+	failureIDs := db.Model(&models.PaymentFailureEvent{}).Select("id").
+		Where("company_id = ? AND customer_id IN ?", "company", customerIDs)
+	timeline, truncated, err := service.buildTimeline(context.Background(), "company", customerIDs, failureIDs)
+	require.NoError(t, err)
+	assert.False(t, truncated)
+
+	var order []string
+	for _, entry := range timeline {
+		order = append(order, entry.Type)
+	}
+	// Newest first, with paid invoices and finished workflows as their own entries
+	assert.Equal(t, []string{
+		"invoice_paid",
+		"workflow_completed",
+		"payment_retry",
+		"workflow_started",
+		"communication_email",
+		"payment_failed",
+		"invoice_issued",
+	}, order)
+	assert.Equal(t, int64(12550), timeline[5].AmountCents)
+	assert.Equal(t, int64(30000), timeline[0].AmountCents)
+	assert.Equal(t, failureID.String(), timeline[5].ReferenceID)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestBuildTimelineReportsTruncation(t *testing.T) {
+	db, mock := mockDB(t)
+	service := NewCustomer360Service(db, nil, nil, zap.NewNop())
+	customerIDs := []string{"cus_1"}
+
+	// One more failure than the source limit means older activity is left out
+	failures := sqlmock.NewRows([]string{"id", "created_at"})
+	for i := 0; i <= timelineSourceLimit; i++ {
+		failures.AddRow(uuid.New(), time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC).Add(-time.Duration(i)*time.Hour))
+	}
+	mock.ExpectQuery(`SELECT \* FROM "payment_failure_events"`).WillReturnRows(failures)
+	for _, table := range []string{"invoices", "recovery_workflow_executions", "recovery_actions", "customer_communications"} {
+		mock.ExpectQuery(`SELECT \* FROM "` + table + `"`).WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	}
+
+	failureIDs := db.Model(&models.PaymentFailureEvent{}).Select("id").
+		Where("company_id = ? AND customer_id IN ?", "company", customerIDs)
+	timeline, truncated, err := service.buildTimeline(context.Background(), "company", customerIDs, failureIDs)
+	require.NoError(t, err)
+	assert.True(t, truncated)
+	assert.Len(t, timeline, timelineSourceLimit)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestLoadCustomerTotals(t *testing.T) {
+	db, mock := mockDB(t)
+	service := NewCustomer360Service(db, nil, nil, zap.NewNop())
+
+	mock.ExpectQuery(`SELECT CAST\(COALESCE\(SUM\(amount \* 100\), 0\) AS BIGINT\) AS invoiced_cents,.* FROM "invoices" WHERE company_id = \$1 AND customer_id IN \(\$2\)`).
+		WithArgs("company", "cus_1").
+		WillReturnRows(sqlmock.NewRows([]string{"invoiced_cents", "paid_cents", "outstanding_cents"}).AddRow(100010, 65010, 25000))
+	mock.ExpectQuery(`SELECT COUNT\(\*\) AS count, COALESCE\(SUM\(amount_cents\), 0\) AS amount_cents FROM "payment_failure_events" WHERE company_id = \$1 AND customer_id IN \(\$2\) AND status NOT IN \(\$3,\$4\)`).
+		WithArgs("company", "cus_1", "resolved", "recovered").
+		WillReturnRows(sqlmock.NewRows([]string{"count", "amount_cents"}).AddRow(2, 25050))
+
+	result := &Customer360{CustomerIDs: []string{"cus_1"}}
+	require.NoError(t, service.loadTotals(context.Background(), "company", result))
+	assert.Equal(t, CustomerTotals{
+		LifetimeInvoicedCents: 100010,
+		LifetimePaidCents:     65010,
+		OutstandingCents:      25000,
+		FailedAmountCents:     25050,
+		FailureCount:          2,
+	}, result.Totals)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetCustomer360IsCompanyScoped(t *testing.T) {
+	db, mock := mockDB(t)
+	service := NewCustomer360Service(db, NewCustomerIdentityService(db, zap.NewNop()), nil, zap.NewNop())
+
+	// The customer exists for another company only, so this company finds nothing
+	mock.ExpectQuery(`SELECT \* FROM "customer_identity_links" WHERE company_id = \$1 AND provider_customer_id = \$2`).
+		WithArgs("company", "cus_1", 2).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectQuery(`SELECT count\(\*\) FROM "payment_failure_events" WHERE company_id = \$1 AND customer_id IN \(\$2\)`).
+		WithArgs("company", "cus_1").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	mock.ExpectQuery(`SELECT \* FROM "payment_failure_events" WHERE company_id = \$1 AND customer_id IN \(\$2\)`).
+		WithArgs("company", "cus_1", 20).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectQuery(`SELECT count\(\*\) FROM "invoices" WHERE company_id = \$1 AND customer_id IN \(\$2\)`).
+		WithArgs("company", "cus_1").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	mock.ExpectQuery(`SELECT \* FROM "invoices" WHERE company_id = \$1 AND customer_id IN \(\$2\)`).
+		WithArgs("company", "cus_1", 20).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	_, err := service.GetCustomer360(context.Background(), "company", "cus_1", Customer360Options{})
+	assert.ErrorIs(t, err, ErrCustomerNotFound)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,352 @@
+package services
+
+import (
+	"context"
+	"os"
+	"sync"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"github.com/sambitmohanty1/payment-watchdog/api/internal/models"
+)
+
+func TestSuggestByNameOnNonASCIIName(t *testing.T) {
+	db, mock := mockDB(t)
+	service := NewCustomerIdentityService(db, zap.NewNop())
+	identityID := uuid.New()
+
+	// The prefix keeps whole runes so Postgres gets valid UTF-8
+	mock.ExpectQuery(`SELECT \* FROM "customer_identity_links" WHERE company_id = \$1 AND name LIKE \$2`).
+		WithArgs("company", "mül%", 200).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "company_id", "identity_id", "name"}).
+			AddRow(uuid.New(), "company", identityID, "müller bau gmbh"))
+
+	link := &models.CustomerIdentityLink{CompanyID: "company", Name: NormalizeCustomerName("Müller Bau GmbH")}
+	require.True(t, utf8.ValidString(link.Name))
+	suggestion, err := service.suggestByName(db, link)
+	require.NoError(t, err)
+	require.NotNil(t, suggestion)
+	assert.Equal(t, identityID, suggestion.IdentityID)
+	assert.Equal(t, "name", suggestion.Reason)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestResolveCustomerLinksOnEmail(t *testing.T) {
+	db, mock := mockDB(t)
+	service := NewCustomerIdentityService(db, zap.NewNop())
+	identityID := uuid.New()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT \* FROM "customer_identity_links" WHERE company_id = \$1 AND provider = \$2 AND provider_customer_id = \$3`).
+		WithArgs("company", "xero", "contact-1", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectQuery(`SELECT \* FROM "customer_identity_links" WHERE company_id = \$1 AND email = \$2`).
+		WithArgs("company", "ap@acme.com", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "identity_id"}).AddRow(uuid.New(), identityID))
+	mock.ExpectQuery(`INSERT INTO "customer_identity_links"`).
+		WithArgs(identityID, "company", "xero", "contact-1", "ap@acme.com", "", "", "acme", "email", 0.95, false, sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(uuid.New()))
+	mock.ExpectQuery(`SELECT \* FROM "customer_identities" WHERE id = \$1 AND company_id = \$2`).
+		WithArgs(identityID, "company", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "company_id"}).AddRow(identityID, "company"))
+	mock.ExpectCommit()
+
+	identity, err := service.ResolveCustomer(context.Background(), CustomerRecord{
+		CompanyID:          "company",
+		Provider:           "Xero",
+		ProviderCustomerID: "contact-1",
+		Email:              "AP+billing@acme.com",
+		Name:               "Acme Pty Ltd",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, identityID, identity.ID)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestResolveCustomerSuggestsNameOnlyMatches(t *testing.T) {
+	db, mock := mockDB(t)
+	service := NewCustomerIdentityService(db, zap.NewNop())
+	similarID, otherPhoneID, createdID := uuid.New(), uuid.New(), uuid.New()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT \* FROM "customer_identity_links" WHERE company_id = \$1 AND provider = \$2 AND provider_customer_id = \$3`).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectQuery(`SELECT \* FROM "customer_identity_links" WHERE company_id = \$1 AND phone = \$2`).
+		WithArgs("company", "61400000000", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectQuery(`SELECT \* FROM "customer_identity_links" WHERE company_id = \$1 AND name LIKE \$2`).
+		WithArgs("company", "acm%", 200).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "identity_id", "name", "phone"}).
+			// The same name with a different phone is a different customer
+			AddRow(uuid.New(), otherPhoneID, "acme plumbing", "61499999999").
+			AddRow(uuid.New(), similarID, "acme plumbng", ""))
+	mock.ExpectQuery(`INSERT INTO "customer_identities"`).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(createdID))
+	mock.ExpectQuery(`INSERT INTO "customer_identity_links"`).
+		WithArgs(createdID, "company", "stripe", "cus_1", "", "61400000000", "", "acme plumbing", "new", 1.0, false, sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(uuid.New()))
+	mock.ExpectQuery(`INSERT INTO "customer_identity_suggestions"`).
+		WithArgs("company", sqlmock.AnyArg(), similarID, "name", sqlmock.AnyArg(), "pending", sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(uuid.New()))
+	mock.ExpectQuery(`SELECT \* FROM "customer_identities" WHERE id = \$1 AND company_id = \$2`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "company_id"}).AddRow(createdID, "company"))
+	mock.ExpectCommit()
+
+	identity, err := service.ResolveCustomer(context.Background(), CustomerRecord{
+		CompanyID:          "company",
+		Provider:           "stripe",
+		ProviderCustomerID: "cus_1",
+		Phone:              "0400 000 000",
+		Name:               "Acme Plumbing",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, createdID, identity.ID, "a similar name alone is not linked")
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestResolveCustomerRereadsLinkCreatedConcurrently(t *testing.T) {
+	db, mock := mockDB(t)
+	service := NewCustomerIdentityService(db, zap.NewNop())
+	createdID, winnerID := uuid.New(), uuid.New()
+
+	// Another request links cus_1 between the lookup and the insert, so this
+	// attempt rolls back its new identity
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT \* FROM "customer_identity_links" WHERE company_id = \$1 AND provider = \$2 AND provider_customer_id = \$3`).
+		WithArgs("company", "stripe", "cus_1", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectQuery(`SELECT \* FROM "customer_identity_links" WHERE company_id = \$1 AND email = \$2`).
+		WithArgs("company", "ada@example.com", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectQuery(`INSERT INTO "customer_identities"`).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(createdID))
+	mock.ExpectQuery(`INSERT INTO "customer_identity_links" .* ON CONFLICT \("company_id","provider","provider_customer_id"\) DO NOTHING RETURNING "id"`).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectRollback()
+
+	// The retry finds the winner's link
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT \* FROM "customer_identity_links" WHERE company_id = \$1 AND provider = \$2 AND provider_customer_id = \$3`).
+		WithArgs("company", "stripe", "cus_1", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "identity_id"}).AddRow(uuid.New(), winnerID))
+	mock.ExpectQuery(`SELECT \* FROM "customer_identities" WHERE id = \$1 AND company_id = \$2`).
+		WithArgs(winnerID, "company", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "company_id"}).AddRow(winnerID, "company"))
+	mock.ExpectCommit()
+
+	identity, err := service.ResolveCustomer(context.Background(), CustomerRecord{
+		CompanyID:          "company",
+		Provider:           "stripe",
+		ProviderCustomerID: "cus_1",
+		Email:              "ada@example.com",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, winnerID, identity.ID)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestResolveCustomerConcurrently(t *testing.T) {
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_URL not set, skipping test")
+	}
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	require.NoError(t, err)
+	migration, err := os.ReadFile("../../migrations/007_customer_identities.up.sql")
+	require.NoError(t, err)
+	require.NoError(t, db.Exec(string(migration)).Error)
+
+	companyID := uuid.New().String()
+	t.Cleanup(func() {
+		db.Where("company_id = ?", companyID).Delete(&models.CustomerIdentityLink{})
+		db.Where("company_id = ?", companyID).Delete(&models.CustomerIdentity{})
+	})
+
+	service := NewCustomerIdentityService(db, zap.NewNop())
+	record := CustomerRecord{CompanyID: companyID, Provider: "stripe", ProviderCustomerID: "cus_1", Email: "ada@example.com"}
+
+	const workers = 8
+	ids := make([]uuid.UUID, workers)
+	errs := make([]error, workers)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			identity, err := service.ResolveCustomer(context.Background(), record)
+			if err == nil {
+				ids[i] = identity.ID
+			}
+			errs[i] = err
+		}(i)
+	}
+	wg.Wait()
+
+	for i := 0; i < workers; i++ {
+		require.NoError(t, errs[i])
+		assert.Equal(t, ids[0], ids[i], "every request resolves to the same identity")
+	}
+	var links, identities int64
+	require.NoError(t, db.Model(&models.CustomerIdentityLink{}).Where("company_id = ?", companyID).Count(&links).Error)
+	require.NoError(t, db.Model(&models.CustomerIdentity{}).Where("company_id = ?", companyID).Count(&identities).Error)
+	assert.Equal(t, int64(1), links)
+	assert.Equal(t, int64(1), identities, "identities created by losing requests are rolled back")
+}
+
+func TestFindIdentityByProvider(t *testing.T) {
+	db, mock := mockDB(t)
+	service := NewCustomerIdentityService(db, zap.NewNop())
+	stripeID, xeroID := uuid.New(), uuid.New()
+
+	mock.ExpectQuery(`SELECT \* FROM "customer_identity_links" WHERE \(company_id = \$1 AND provider_customer_id = \$2\) AND provider = \$3 ORDER BY created_at ASC LIMIT \$4`).
+		WithArgs("company", "1001", "xero", 2).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "identity_id"}).AddRow(uuid.New(), xeroID))
+	mock.ExpectQuery(`SELECT \* FROM "customer_identities" WHERE id = \$1 AND company_id = \$2`).
+		WithArgs(xeroID, "company", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "company_id"}).AddRow(xeroID, "company"))
+	mock.ExpectQuery(`SELECT \* FROM "customer_identity_links" WHERE identity_id = \$1`).
+		WithArgs(xeroID).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	identity, err := service.FindIdentity(context.Background(), "company", "xero", "1001")
+	require.NoError(t, err)
+	assert.Equal(t, xeroID, identity.ID)
+
+	// Without a provider, the same ID under two providers is ambiguous
+	mock.ExpectQuery(`SELECT \* FROM "customer_identity_links" WHERE company_id = \$1 AND provider_customer_id = \$2 ORDER BY created_at ASC LIMIT \$3`).
+		WithArgs("company", "1001", 2).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "identity_id"}).AddRow(uuid.New(), stripeID).AddRow(uuid.New(), xeroID))
+	mock.ExpectQuery(`SELECT \* FROM "customer_identities" WHERE id = \$1`).
+		WithArgs(stripeID, "company", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "company_id"}).AddRow(stripeID, "company"))
+	mock.ExpectQuery(`SELECT \* FROM "customer_identities" WHERE id = \$1`).
+		WithArgs(xeroID, "company", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "company_id"}).AddRow(xeroID, "company"))
+
+	_, err = service.FindIdentity(context.Background(), "company", "", "1001")
+	assert.ErrorIs(t, err, ErrAmbiguousCustomerID)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCountRecentContactsMatchesProviderAndCustomerID(t *testing.T) {
+	db, mock := mockDB(t)
+	service := NewCustomerIdentityService(db, zap.NewNop())
+	identityID := uuid.New()
+	since := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	mock.ExpectQuery(`SELECT \* FROM "customer_identity_links" WHERE \(company_id = \$1 AND provider_customer_id = \$2\) AND provider = \$3`).
+		WithArgs("company", "1001", "xero", 2).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "identity_id"}).AddRow(uuid.New(), identityID))
+	mock.ExpectQuery(`SELECT \* FROM "customer_identities" WHERE id = \$1 AND company_id = \$2`).
+		WithArgs(identityID, "company", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "company_id"}).AddRow(identityID, "company"))
+	mock.ExpectQuery(`SELECT \* FROM "customer_identity_links" WHERE identity_id = \$1`).
+		WithArgs(identityID).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "provider", "provider_customer_id"}).
+			AddRow(uuid.New(), "xero", "1001").
+			AddRow(uuid.New(), "stripe", "cus_9"))
+	// A Stripe customer that is also called 1001 is someone else and is not counted
+	mock.ExpectQuery(`SELECT count\(\*\) FROM "recovery_actions" JOIN payment_failure_events .* WHERE payment_failure_events.company_id = \$1 AND `+
+		`\(\(\(LOWER\(COALESCE\(NULLIF\(payment_failure_events.provider, ''\), payment_failure_events.provider_id\)\) = \$2 AND payment_failure_events.customer_id = \$3\) OR `+
+		`\(LOWER\(COALESCE\(NULLIF\(payment_failure_events.provider, ''\), payment_failure_events.provider_id\)\) = \$4 AND payment_failure_events.customer_id = \$5\)\)\) AND `+
+		`\(recovery_actions.action_type IN \(\$6,\$7\) AND recovery_actions.created_at >= \$8\)`).
+		WithArgs("company", "xero", "1001", "stripe", "cus_9", "email_sent", "sms_sent", since).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(2))
+
+	count, err := service.CountRecentContacts(context.Background(), "company", "Xero", "1001", since)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), count)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestMergeIdentities(t *testing.T) {
+	db, mock := mockDB(t)
+	service := NewCustomerIdentityService(db, zap.NewNop())
+	targetID, sourceID := uuid.New(), uuid.New()
+	identityRows := func(id uuid.UUID, phone string) *sqlmock.Rows {
+		return sqlmock.NewRows([]string{"id", "company_id", "primary_phone"}).AddRow(id, "company", phone)
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT \* FROM "customer_identities" WHERE id = \$1`).
+		WithArgs(targetID, "company", 1).
+		WillReturnRows(identityRows(targetID, ""))
+	mock.ExpectQuery(`SELECT \* FROM "customer_identities" WHERE id = \$1`).
+		WithArgs(sourceID, "company", 1).
+		WillReturnRows(identityRows(sourceID, "61400000000"))
+	mock.ExpectExec(`UPDATE "customer_identity_links" SET "identity_id"=\$1,"is_manual"=\$2,"match_reason"=\$3,"updated_at"=\$4 WHERE identity_id = \$5`).
+		WithArgs(targetID, true, "manual", sqlmock.AnyArg(), sourceID).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectExec(`UPDATE "customer_identities" SET "merged_into_id"=\$1,"status"=\$2,"updated_at"=\$3 WHERE "id" = \$4`).
+		WithArgs(targetID, "merged", sqlmock.AnyArg(), sourceID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	// The target keeps its own keys and takes the ones it lacks
+	mock.ExpectExec(`UPDATE "customer_identities" SET "primary_phone"=\$1,"updated_at"=\$2 WHERE "id" = \$3`).
+		WithArgs("61400000000", sqlmock.AnyArg(), targetID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	mock.ExpectQuery(`SELECT \* FROM "customer_identities" WHERE id = \$1`).
+		WillReturnRows(identityRows(targetID, "61400000000"))
+	mock.ExpectQuery(`SELECT \* FROM "customer_identity_links" WHERE identity_id = \$1`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "identity_id"}).AddRow(uuid.New(), targetID).AddRow(uuid.New(), targetID))
+
+	identity, err := service.MergeIdentities(context.Background(), "company", targetID, sourceID)
+	require.NoError(t, err)
+	assert.Equal(t, targetID, identity.ID)
+	assert.Len(t, identity.Links, 2)
+	require.NoError(t, mock.ExpectationsWereMet())
+
+	_, err = service.MergeIdentities(context.Background(), "company", targetID, targetID)
+	assert.Error(t, err, "an identity cannot be merged into itself")
+}
+
+func TestSplitLink(t *testing.T) {
+	db, mock := mockDB(t)
+	service := NewCustomerIdentityService(db, zap.NewNop())
+	linkID, identityID, splitID := uuid.New(), uuid.New(), uuid.New()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT \* FROM "customer_identity_links" WHERE id = \$1 AND company_id = \$2`).
+		WithArgs(linkID, "company", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "identity_id", "company_id", "email", "name"}).
+			AddRow(linkID, identityID, "company", "ap@acme.com", "acme"))
+	mock.ExpectQuery(`SELECT count\(\*\) FROM "customer_identity_links" WHERE identity_id = \$1 AND id <> \$2`).
+		WithArgs(identityID, linkID).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	mock.ExpectQuery(`INSERT INTO "customer_identities"`).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(splitID))
+	mock.ExpectExec(`UPDATE "customer_identity_links" SET "confidence"=\$1,"identity_id"=\$2,"is_manual"=\$3,"match_reason"=\$4,"updated_at"=\$5 WHERE "id" = \$6`).
+		WithArgs(1.0, sqlmock.AnyArg(), true, "manual", sqlmock.AnyArg(), linkID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	mock.ExpectQuery(`SELECT \* FROM "customer_identities" WHERE id = \$1`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "company_id"}).AddRow(splitID, "company"))
+	mock.ExpectQuery(`SELECT \* FROM "customer_identity_links" WHERE identity_id = \$1`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "identity_id"}).AddRow(linkID, splitID))
+
+	identity, err := service.SplitLink(context.Background(), "company", linkID)
+	require.NoError(t, err)
+	assert.Equal(t, splitID, identity.ID)
+
+	// The last record of an identity cannot be split off
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT \* FROM "customer_identity_links" WHERE id = \$1 AND company_id = \$2`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "identity_id"}).AddRow(linkID, splitID))
+	mock.ExpectQuery(`SELECT count\(\*\) FROM "customer_identity_links"`).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	mock.ExpectRollback()
+
+	_, err = service.SplitLink(context.Background(), "company", linkID)
+	assert.Error(t, err)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
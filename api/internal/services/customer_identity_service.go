@@ -0,0 +1,786 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/google/uuid"
+	"github.com/sambitmohanty1/payment-watchdog/api/internal/models"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// nameMatchThreshold is the minimum Jaro-Winkler similarity for two
+// normalized customer names to be suggested as the same customer.
+const nameMatchThreshold = 0.93
+
+// ErrCustomerIdentityNotFound is returned when an identity or link does not exist for the company
+var ErrCustomerIdentityNotFound = errors.New("customer identity not found")
+
+// ErrAmbiguousCustomerID is returned when a provider customer ID looked up without
+// its provider is linked to more than one identity
+var ErrAmbiguousCustomerID = errors.New("customer ID is linked under more than one provider")
+
+// errIdentityLinkRaced is returned when a provider customer record was linked by a
+// concurrent resolution between looking it up and linking it
+var errIdentityLinkRaced = errors.New("customer identity link created concurrently")
+
+// CustomerIdentityService resolves provider customer records into stable internal identities
+type CustomerIdentityService struct {
+	db     *gorm.DB
+	logger *zap.Logger
+}
+
+// CustomerRecord is a provider-specific view of a customer used for resolution
+type CustomerRecord struct {
+	CompanyID          string `json:"company_id"`
+	Provider           string `json:"provider"`
+	ProviderCustomerID string `json:"provider_customer_id"`
+	Email              string `json:"email"`
+	Phone              string `json:"phone"`
+	BusinessNumber     string `json:"business_number"`
+	Name               string `json:"name"`
+}
+
+// NewCustomerIdentityService creates a new customer identity service
+func NewCustomerIdentityService(db *gorm.DB, logger *zap.Logger) *CustomerIdentityService {
+	return &CustomerIdentityService{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// ResolveCustomer returns the identity for a provider customer record, linking it
+// to an existing identity by business number, email or phone, or creating a new
+// identity when nothing matches. A similar name alone is never linked; it is
+// recorded as a suggestion for someone to accept or dismiss.
+func (s *CustomerIdentityService) ResolveCustomer(ctx context.Context, record CustomerRecord) (*models.CustomerIdentity, error) {
+	if record.CompanyID == "" || record.ProviderCustomerID == "" {
+		return nil, fmt.Errorf("company_id and provider_customer_id are required")
+	}
+	record.Provider = strings.ToLower(strings.TrimSpace(record.Provider))
+
+	identity, err := s.resolveCustomer(ctx, record)
+	if errors.Is(err, errIdentityLinkRaced) {
+		// Another request linked the same record first; the retry reads its link
+		identity, err = s.resolveCustomer(ctx, record)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.Debug("Customer identity resolved",
+		zap.String("company_id", record.CompanyID),
+		zap.String("provider", record.Provider),
+		zap.String("provider_customer_id", record.ProviderCustomerID),
+		zap.String("identity_id", identity.ID.String()))
+
+	return identity, nil
+}
+
+// resolveCustomer resolves record in one transaction. It returns errIdentityLinkRaced,
+// with everything it created rolled back, when a concurrent request links the same
+// provider customer record first.
+func (s *CustomerIdentityService) resolveCustomer(ctx context.Context, record CustomerRecord) (*models.CustomerIdentity, error) {
+	var identity *models.CustomerIdentity
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Already linked
+		var link models.CustomerIdentityLink
+		err := tx.Where("company_id = ? AND provider = ? AND provider_customer_id = ?",
+			record.CompanyID, record.Provider, record.ProviderCustomerID).First(&link).Error
+		if err == nil {
+			resolved, err := s.followMerges(tx, record.CompanyID, link.IdentityID)
+			if err != nil {
+				return err
+			}
+			identity = resolved
+			return nil
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("failed to lookup identity link: %w", err)
+		}
+
+		newLink := models.CustomerIdentityLink{
+			CompanyID:          record.CompanyID,
+			Provider:           record.Provider,
+			ProviderCustomerID: record.ProviderCustomerID,
+			Email:              NormalizeEmail(record.Email),
+			Phone:              NormalizePhone(record.Phone),
+			BusinessNumber:     NormalizeBusinessNumber(record.BusinessNumber),
+			Name:               NormalizeCustomerName(record.Name),
+		}
+
+		identityID, reason, confidence, err := s.findMatch(tx, &newLink)
+		if err != nil {
+			return err
+		}
+
+		var suggestion *models.CustomerIdentitySuggestion
+		if identityID == uuid.Nil {
+			suggestion, err = s.suggestByName(tx, &newLink)
+			if err != nil {
+				return err
+			}
+
+			created := &models.CustomerIdentity{
+				CompanyID:      record.CompanyID,
+				DisplayName:    strings.TrimSpace(record.Name),
+				PrimaryEmail:   newLink.Email,
+				PrimaryPhone:   newLink.Phone,
+				BusinessNumber: newLink.BusinessNumber,
+				Status:         models.CustomerIdentityStatusActive,
+			}
+			if err := tx.Create(created).Error; err != nil {
+				return fmt.Errorf("failed to create customer identity: %w", err)
+			}
+			identityID, reason, confidence = created.ID, "new", 1.0
+		}
+
+		newLink.IdentityID = identityID
+		newLink.MatchReason = reason
+		newLink.Confidence = confidence
+		linked := tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "company_id"}, {Name: "provider"}, {Name: "provider_customer_id"}},
+			DoNothing: true,
+		}).Create(&newLink)
+		if linked.Error != nil {
+			return fmt.Errorf("failed to create identity link: %w", linked.Error)
+		}
+		if linked.RowsAffected == 0 {
+			return errIdentityLinkRaced
+		}
+
+		if suggestion != nil {
+			suggestion.LinkID = newLink.ID
+			if err := tx.Create(suggestion).Error; err != nil {
+				return fmt.Errorf("failed to create identity suggestion: %w", err)
+			}
+		}
+
+		resolved, err := s.followMerges(tx, record.CompanyID, identityID)
+		if err != nil {
+			return err
+		}
+		identity = resolved
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return identity, nil
+}
+
+// ResolveFromFailure resolves the identity of the customer on a payment failure event
+func (s *CustomerIdentityService) ResolveFromFailure(ctx context.Context, failure *models.PaymentFailureEvent) (*models.CustomerIdentity, error) {
+	return s.ResolveCustomer(ctx, CustomerRecord{
+		CompanyID:          failure.CompanyID,
+		Provider:           failureProvider(failure),
+		ProviderCustomerID: failure.CustomerID,
+		Email:              failure.CustomerEmail,
+		Phone:              failure.CustomerPhone,
+		BusinessNumber:     businessNumberFromNormalizedData(failure.NormalizedData),
+		Name:               failure.CustomerName,
+	})
+}
+
+// GetIdentity returns an identity with its provider links, following merges
+func (s *CustomerIdentityService) GetIdentity(ctx context.Context, companyID string, identityID uuid.UUID) (*models.CustomerIdentity, error) {
+	identity, err := s.followMerges(s.db.WithContext(ctx), companyID, identityID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.db.WithContext(ctx).Where("identity_id = ?", identity.ID).
+		Order("created_at ASC").Find(&identity.Links).Error; err != nil {
+		return nil, fmt.Errorf("failed to load identity links: %w", err)
+	}
+
+	return identity, nil
+}
+
+// FindIdentity returns the identity for either an internal identity ID or any
+// provider customer ID linked to it. Provider customer IDs are only unique per
+// provider, so without a provider an ID linked to two identities is ambiguous.
+func (s *CustomerIdentityService) FindIdentity(ctx context.Context, companyID, provider, customerID string) (*models.CustomerIdentity, error) {
+	if id, err := uuid.Parse(customerID); err == nil {
+		identity, err := s.GetIdentity(ctx, companyID, id)
+		if err == nil {
+			return identity, nil
+		}
+		if !errors.Is(err, ErrCustomerIdentityNotFound) {
+			return nil, err
+		}
+	}
+
+	query := s.db.WithContext(ctx).Where("company_id = ? AND provider_customer_id = ?", companyID, customerID)
+	if provider = strings.ToLower(strings.TrimSpace(provider)); provider != "" {
+		query = query.Where("provider = ?", provider)
+	}
+
+	var links []models.CustomerIdentityLink
+	if err := query.Order("created_at ASC").Limit(2).Find(&links).Error; err != nil {
+		return nil, fmt.Errorf("failed to lookup identity link: %w", err)
+	}
+	if len(links) == 0 {
+		return nil, ErrCustomerIdentityNotFound
+	}
+	if len(links) > 1 {
+		first, err := s.followMerges(s.db.WithContext(ctx), companyID, links[0].IdentityID)
+		if err != nil {
+			return nil, err
+		}
+		second, err := s.followMerges(s.db.WithContext(ctx), companyID, links[1].IdentityID)
+		if err != nil {
+			return nil, err
+		}
+		if first.ID != second.ID {
+			return nil, ErrAmbiguousCustomerID
+		}
+	}
+
+	return s.GetIdentity(ctx, companyID, links[0].IdentityID)
+}
+
+// LinkedCustomer is a provider customer record linked to an identity
+type LinkedCustomer struct {
+	Provider   string `json:"provider"`
+	CustomerID string `json:"customer_id"`
+}
+
+// GetLinkedCustomers returns every provider customer record belonging to the same
+// identity as the provider's customerID. Unresolved customers map to themselves.
+func (s *CustomerIdentityService) GetLinkedCustomers(ctx context.Context, companyID, provider, customerID string) ([]LinkedCustomer, error) {
+	provider = strings.ToLower(strings.TrimSpace(provider))
+	identity, err := s.FindIdentity(ctx, companyID, provider, customerID)
+	if errors.Is(err, ErrCustomerIdentityNotFound) {
+		return []LinkedCustomer{{Provider: provider, CustomerID: customerID}}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	customers := make([]LinkedCustomer, 0, len(identity.Links))
+	for _, link := range identity.Links {
+		customers = append(customers, LinkedCustomer{Provider: link.Provider, CustomerID: link.ProviderCustomerID})
+	}
+	if len(customers) == 0 {
+		customers = append(customers, LinkedCustomer{Provider: provider, CustomerID: customerID})
+	}
+
+	return customers, nil
+}
+
+// GetLinkedCustomerIDs returns every provider customer ID belonging to the same
+// identity as customerID. Unresolved customers map to themselves.
+func (s *CustomerIdentityService) GetLinkedCustomerIDs(ctx context.Context, companyID, provider, customerID string) ([]string, error) {
+	customers, err := s.GetLinkedCustomers(ctx, companyID, provider, customerID)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]string, 0, len(customers))
+	seen := make(map[string]bool)
+	for _, customer := range customers {
+		if !seen[customer.CustomerID] {
+			seen[customer.CustomerID] = true
+			ids = append(ids, customer.CustomerID)
+		}
+	}
+
+	return ids, nil
+}
+
+// MergeIdentities moves all links of sourceID onto targetID and marks the source as merged
+func (s *CustomerIdentityService) MergeIdentities(ctx context.Context, companyID string, targetID, sourceID uuid.UUID) (*models.CustomerIdentity, error) {
+	if targetID == sourceID {
+		return nil, fmt.Errorf("cannot merge an identity into itself")
+	}
+
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		target, err := s.followMerges(tx, companyID, targetID)
+		if err != nil {
+			return err
+		}
+		source, err := s.followMerges(tx, companyID, sourceID)
+		if err != nil {
+			return err
+		}
+		if target.ID == source.ID {
+			return fmt.Errorf("identities are already merged")
+		}
+
+		if err := tx.Model(&models.CustomerIdentityLink{}).
+			Where("identity_id = ?", source.ID).
+			Updates(map[string]interface{}{
+				"identity_id":  target.ID,
+				"match_reason": "manual",
+				"is_manual":    true,
+				"updated_at":   time.Now(),
+			}).Error; err != nil {
+			return fmt.Errorf("failed to move identity links: %w", err)
+		}
+
+		if err := tx.Model(source).Updates(map[string]interface{}{
+			"status":         models.CustomerIdentityStatusMerged,
+			"merged_into_id": target.ID,
+			"updated_at":     time.Now(),
+		}).Error; err != nil {
+			return fmt.Errorf("failed to mark identity as merged: %w", err)
+		}
+
+		updates := map[string]interface{}{"updated_at": time.Now()}
+		if target.PrimaryEmail == "" && source.PrimaryEmail != "" {
+			updates["primary_email"] = source.PrimaryEmail
+		}
+		if target.PrimaryPhone == "" && source.PrimaryPhone != "" {
+			updates["primary_phone"] = source.PrimaryPhone
+		}
+		if target.BusinessNumber == "" && source.BusinessNumber != "" {
+			updates["business_number"] = source.BusinessNumber
+		}
+		return tx.Model(target).Updates(updates).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("Customer identities merged",
+		zap.String("company_id", companyID),
+		zap.String("target_id", targetID.String()),
+		zap.String("source_id", sourceID.String()))
+
+	return s.GetIdentity(ctx, companyID, targetID)
+}
+
+// SplitLink detaches a provider customer record into its own identity. The link is
+// marked manual so automatic resolution will not re-attach it.
+func (s *CustomerIdentityService) SplitLink(ctx context.Context, companyID string, linkID uuid.UUID) (*models.CustomerIdentity, error) {
+	var identityID uuid.UUID
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var link models.CustomerIdentityLink
+		if err := tx.Where("id = ? AND company_id = ?", linkID, companyID).First(&link).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrCustomerIdentityNotFound
+			}
+			return fmt.Errorf("failed to lookup identity link: %w", err)
+		}
+
+		var remaining int64
+		if err := tx.Model(&models.CustomerIdentityLink{}).
+			Where("identity_id = ? AND id <> ?", link.IdentityID, link.ID).
+			Count(&remaining).Error; err != nil {
+			return fmt.Errorf("failed to count identity links: %w", err)
+		}
+		if remaining == 0 {
+			return fmt.Errorf("link is the only record of its identity")
+		}
+
+		identity := &models.CustomerIdentity{
+			CompanyID:      companyID,
+			DisplayName:    link.Name,
+			PrimaryEmail:   link.Email,
+			PrimaryPhone:   link.Phone,
+			BusinessNumber: link.BusinessNumber,
+			Status:         models.CustomerIdentityStatusActive,
+		}
+		if err := tx.Create(identity).Error; err != nil {
+			return fmt.Errorf("failed to create customer identity: %w", err)
+		}
+
+		if err := tx.Model(&link).Updates(map[string]interface{}{
+			"identity_id":  identity.ID,
+			"match_reason": "manual",
+			"is_manual":    true,
+			"confidence":   1.0,
+			"updated_at":   time.Now(),
+		}).Error; err != nil {
+			return fmt.Errorf("failed to split identity link: %w", err)
+		}
+
+		identityID = identity.ID
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("Customer identity link split",
+		zap.String("company_id", companyID),
+		zap.String("link_id", linkID.String()),
+		zap.String("identity_id", identityID.String()))
+
+	return s.GetIdentity(ctx, companyID, identityID)
+}
+
+// ListSuggestions returns the company's pending identity suggestions, closest names first
+func (s *CustomerIdentityService) ListSuggestions(ctx context.Context, companyID string) ([]models.CustomerIdentitySuggestion, error) {
+	var suggestions []models.CustomerIdentitySuggestion
+	if err := s.db.WithContext(ctx).
+		Where("company_id = ? AND status = ?", companyID, models.CustomerIdentitySuggestionPending).
+		Order("score DESC, created_at ASC").Find(&suggestions).Error; err != nil {
+		return nil, fmt.Errorf("failed to list identity suggestions: %w", err)
+	}
+	return suggestions, nil
+}
+
+// AcceptSuggestion merges the suggested record's identity into the suggested identity
+func (s *CustomerIdentityService) AcceptSuggestion(ctx context.Context, companyID string, suggestionID uuid.UUID) (*models.CustomerIdentity, error) {
+	suggestion, err := s.pendingSuggestion(ctx, companyID, suggestionID)
+	if err != nil {
+		return nil, err
+	}
+
+	var link models.CustomerIdentityLink
+	if err := s.db.WithContext(ctx).Where("id = ? AND company_id = ?", suggestion.LinkID, companyID).
+		First(&link).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrCustomerIdentityNotFound
+		}
+		return nil, fmt.Errorf("failed to lookup identity link: %w", err)
+	}
+
+	identity, err := s.MergeIdentities(ctx, companyID, suggestion.IdentityID, link.IdentityID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.reviewSuggestion(ctx, suggestion, models.CustomerIdentitySuggestionAccepted); err != nil {
+		return nil, err
+	}
+
+	return identity, nil
+}
+
+// DismissSuggestion records that the suggested records are different customers
+func (s *CustomerIdentityService) DismissSuggestion(ctx context.Context, companyID string, suggestionID uuid.UUID) error {
+	suggestion, err := s.pendingSuggestion(ctx, companyID, suggestionID)
+	if err != nil {
+		return err
+	}
+	return s.reviewSuggestion(ctx, suggestion, models.CustomerIdentitySuggestionDismissed)
+}
+
+// CountRecentContacts counts email and SMS recovery actions sent since the given time to
+// any provider record linked to the same identity as the provider's customerID
+func (s *CustomerIdentityService) CountRecentContacts(ctx context.Context, companyID, provider, customerID string, since time.Time) (int64, error) {
+	customers, err := s.GetLinkedCustomers(ctx, companyID, provider, customerID)
+	if err != nil {
+		return 0, err
+	}
+
+	var count int64
+	condition, args := linkedCustomerCondition("payment_failure_events", customers)
+	if err := s.db.WithContext(ctx).Table("recovery_actions").
+		Joins("JOIN payment_failure_events ON payment_failure_events.id = recovery_actions.payment_failure_id").
+		Where("payment_failure_events.company_id = ?", companyID).
+		Where(condition, args...).
+		Where("recovery_actions.action_type IN ? AND recovery_actions.created_at >= ?", []string{"email_sent", "sms_sent"}, since).
+		Count(&count).Error; err != nil {
+		return 0, fmt.Errorf("failed to count recent contacts: %w", err)
+	}
+
+	return count, nil
+}
+
+// Helper methods
+
+// findMatch looks for an existing identity sharing a strong key with link.
+// Returns uuid.Nil when nothing matches.
+func (s *CustomerIdentityService) findMatch(tx *gorm.DB, link *models.CustomerIdentityLink) (uuid.UUID, string, float64, error) {
+	keys := []struct {
+		column     string
+		value      string
+		confidence float64
+	}{
+		{"business_number", link.BusinessNumber, 1.0},
+		{"email", link.Email, 0.95},
+		{"phone", link.Phone, 0.9},
+	}
+
+	for _, key := range keys {
+		if key.value == "" {
+			continue
+		}
+		var existing models.CustomerIdentityLink
+		err := tx.Where("company_id = ? AND "+key.column+" = ?", link.CompanyID, key.value).
+			Order("created_at ASC").First(&existing).Error
+		if err == nil {
+			return existing.IdentityID, key.column, key.confidence, nil
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return uuid.Nil, "", 0, fmt.Errorf("failed to match on %s: %w", key.column, err)
+		}
+	}
+
+	return uuid.Nil, "", 0, nil
+}
+
+// suggestByName looks for an existing identity with a similar name and no
+// conflicting strong key. Returns nil when no name is close enough.
+func (s *CustomerIdentityService) suggestByName(tx *gorm.DB, link *models.CustomerIdentityLink) (*models.CustomerIdentitySuggestion, error) {
+	// Names keep non-ASCII letters, so they are measured and cut in runes
+	name := []rune(link.Name)
+	if len(name) < 4 {
+		return nil, nil
+	}
+
+	var candidates []models.CustomerIdentityLink
+	if err := tx.Where("company_id = ? AND name LIKE ?", link.CompanyID, string(name[:3])+"%").
+		Limit(200).Find(&candidates).Error; err != nil {
+		return nil, fmt.Errorf("failed to match on name: %w", err)
+	}
+
+	bestID, bestScore := uuid.Nil, 0.0
+	for _, candidate := range candidates {
+		// Different strong keys mean different customers, whatever the name
+		if conflicts(link.Email, candidate.Email) || conflicts(link.Phone, candidate.Phone) ||
+			conflicts(link.BusinessNumber, candidate.BusinessNumber) {
+			continue
+		}
+		if score := NameSimilarity(link.Name, candidate.Name); score >= nameMatchThreshold && score > bestScore {
+			bestID, bestScore = candidate.IdentityID, score
+		}
+	}
+	if bestID == uuid.Nil {
+		return nil, nil
+	}
+
+	return &models.CustomerIdentitySuggestion{
+		CompanyID:  link.CompanyID,
+		IdentityID: bestID,
+		Reason:     "name",
+		Score:      bestScore,
+		Status:     models.CustomerIdentitySuggestionPending,
+	}, nil
+}
+
+func (s *CustomerIdentityService) pendingSuggestion(ctx context.Context, companyID string, suggestionID uuid.UUID) (*models.CustomerIdentitySuggestion, error) {
+	var suggestion models.CustomerIdentitySuggestion
+	err := s.db.WithContext(ctx).Where("id = ? AND company_id = ? AND status = ?",
+		suggestionID, companyID, models.CustomerIdentitySuggestionPending).First(&suggestion).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrCustomerIdentityNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load identity suggestion: %w", err)
+	}
+	return &suggestion, nil
+}
+
+func (s *CustomerIdentityService) reviewSuggestion(ctx context.Context, suggestion *models.CustomerIdentitySuggestion, status string) error {
+	if err := s.db.WithContext(ctx).Model(suggestion).Updates(map[string]interface{}{
+		"status":     status,
+		"updated_at": time.Now(),
+	}).Error; err != nil {
+		return fmt.Errorf("failed to update identity suggestion: %w", err)
+	}
+
+	s.logger.Info("Customer identity suggestion reviewed",
+		zap.String("company_id", suggestion.CompanyID),
+		zap.String("suggestion_id", suggestion.ID.String()),
+		zap.String("status", status))
+	return nil
+}
+
+// followMerges returns the active identity at the end of a merge chain
+func (s *CustomerIdentityService) followMerges(tx *gorm.DB, companyID string, identityID uuid.UUID) (*models.CustomerIdentity, error) {
+	for i := 0; i < 10; i++ {
+		var identity models.CustomerIdentity
+		if err := tx.Where("id = ? AND company_id = ?", identityID, companyID).First(&identity).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, ErrCustomerIdentityNotFound
+			}
+			return nil, fmt.Errorf("failed to load customer identity: %w", err)
+		}
+		if identity.MergedIntoID == nil {
+			return &identity, nil
+		}
+		identityID = *identity.MergedIntoID
+	}
+
+	return nil, fmt.Errorf("customer identity merge chain too deep")
+}
+
+// failureProvider returns the provider that issued a payment failure's customer ID
+func failureProvider(failure *models.PaymentFailureEvent) string {
+	if failure.Provider != "" {
+		return failure.Provider
+	}
+	return failure.ProviderID
+}
+
+// linkedCustomerCondition matches the payment failure events in table that belong
+// to any of customers. Provider customer IDs are only unique per provider, so each
+// ID is matched together with the provider that issued it when that is known.
+func linkedCustomerCondition(table string, customers []LinkedCustomer) (string, []interface{}) {
+	clauses := make([]string, 0, len(customers))
+	args := make([]interface{}, 0, 2*len(customers))
+	for _, customer := range customers {
+		if customer.Provider == "" {
+			clauses = append(clauses, table+".customer_id = ?")
+			args = append(args, customer.CustomerID)
+			continue
+		}
+		clauses = append(clauses, fmt.Sprintf("(LOWER(COALESCE(NULLIF(%[1]s.provider, ''), %[1]s.provider_id)) = ? AND %[1]s.customer_id = ?)", table))
+		args = append(args, customer.Provider, customer.CustomerID)
+	}
+	if len(clauses) == 0 {
+		return "1 = 0", nil
+	}
+
+	return "(" + strings.Join(clauses, " OR ") + ")", args
+}
+
+func conflicts(a, b string) bool {
+	return a != "" && b != "" && a != b
+}
+
+// businessNumberFromNormalizedData extracts an ABN/tax number from provider normalized data
+func businessNumberFromNormalizedData(data string) string {
+	if data == "" {
+		return ""
+	}
+	var fields map[string]interface{}
+	if err := json.Unmarshal([]byte(data), &fields); err != nil {
+		return ""
+	}
+	for _, key := range []string{"business_number", "abn", "tax_number", "tax_id", "nzbn"} {
+		if v, ok := fields[key].(string); ok && v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// NormalizeEmail lowercases an email address and strips any +tag from the local part
+func NormalizeEmail(email string) string {
+	email = strings.ToLower(strings.TrimSpace(email))
+	at := strings.LastIndex(email, "@")
+	if at <= 0 || at == len(email)-1 {
+		return ""
+	}
+	local, domain := email[:at], email[at+1:]
+	if plus := strings.Index(local, "+"); plus > 0 {
+		local = local[:plus]
+	}
+	return local + "@" + domain
+}
+
+// NormalizePhone reduces a phone number to its digits in international form.
+// Australian national numbers (leading 0, 10 digits) are rewritten with the 61 prefix.
+func NormalizePhone(phone string) string {
+	digits := digitsOnly(phone)
+	digits = strings.TrimPrefix(digits, "00")
+	if len(digits) == 10 && strings.HasPrefix(digits, "0") {
+		digits = "61" + digits[1:]
+	}
+	if len(digits) < 8 {
+		return ""
+	}
+	return digits
+}
+
+// NormalizeBusinessNumber reduces an ABN/NZBN/EIN to its digits
+func NormalizeBusinessNumber(number string) string {
+	digits := digitsOnly(number)
+	if len(digits) < 8 {
+		return ""
+	}
+	return digits
+}
+
+var companySuffixes = map[string]bool{
+	"pty": true, "ltd": true, "limited": true, "inc": true, "llc": true,
+	"co": true, "corp": true, "corporation": true, "company": true, "the": true,
+}
+
+// NormalizeCustomerName lowercases a name, drops punctuation and common company suffixes
+func NormalizeCustomerName(name string) string {
+	cleaned := strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return unicode.ToLower(r)
+		}
+		return ' '
+	}, name)
+
+	tokens := make([]string, 0)
+	for _, token := range strings.Fields(cleaned) {
+		if !companySuffixes[token] {
+			tokens = append(tokens, token)
+		}
+	}
+	return strings.Join(tokens, " ")
+}
+
+// NameSimilarity returns the Jaro-Winkler similarity of two normalized names in [0, 1]
+func NameSimilarity(a, b string) float64 {
+	if a == "" || b == "" {
+		return 0
+	}
+	if a == b {
+		return 1
+	}
+
+	ra, rb := []rune(a), []rune(b)
+	window := max(len(ra), len(rb))/2 - 1
+	if window < 0 {
+		window = 0
+	}
+
+	matchedA := make([]bool, len(ra))
+	matchedB := make([]bool, len(rb))
+	matches := 0
+	for i := range ra {
+		lo, hi := max(0, i-window), min(len(rb), i+window+1)
+		for j := lo; j < hi; j++ {
+			if !matchedB[j] && ra[i] == rb[j] {
+				matchedA[i], matchedB[j] = true, true
+				matches++
+				break
+			}
+		}
+	}
+	if matches == 0 {
+		return 0
+	}
+
+	transpositions, j := 0, 0
+	for i := range ra {
+		if !matchedA[i] {
+			continue
+		}
+		for !matchedB[j] {
+			j++
+		}
+		if ra[i] != rb[j] {
+			transpositions++
+		}
+		j++
+	}
+
+	m := float64(matches)
+	jaro := (m/float64(len(ra)) + m/float64(len(rb)) + (m-float64(transpositions)/2)/m) / 3
+
+	prefix := 0
+	for prefix < 4 && prefix < len(ra) && prefix < len(rb) && ra[prefix] == rb[prefix] {
+		prefix++
+	}
+
+	return jaro + float64(prefix)*0.1*(1-jaro)
+}
+
+func digitsOnly(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if r >= '0' && r <= '9' {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,41 @@
+package services_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	svc "github.com/sambitmohanty1/payment-watchdog/api/internal/services"
+)
+
+func TestNormalizeEmail(t *testing.T) {
+	assert.Equal(t, "jane@example.com", svc.NormalizeEmail("  Jane@Example.COM "))
+	assert.Equal(t, "jane@example.com", svc.NormalizeEmail("jane+billing@example.com"))
+	assert.Equal(t, "", svc.NormalizeEmail("not-an-email"))
+	assert.Equal(t, "", svc.NormalizeEmail("@example.com"))
+}
+
+func TestNormalizePhone(t *testing.T) {
+	// Australian national and international forms resolve to the same key
+	assert.Equal(t, "61412345678", svc.NormalizePhone("0412 345 678"))
+	assert.Equal(t, "61412345678", svc.NormalizePhone("+61 412 345 678"))
+	assert.Equal(t, "61412345678", svc.NormalizePhone("0061412345678"))
+	assert.Equal(t, "", svc.NormalizePhone("12345"))
+}
+
+func TestNormalizeBusinessNumber(t *testing.T) {
+	assert.Equal(t, "51824753556", svc.NormalizeBusinessNumber("51 824 753 556"))
+	assert.Equal(t, "", svc.NormalizeBusinessNumber("123"))
+}
+
+func TestNormalizeCustomerName(t *testing.T) {
+	assert.Equal(t, "acme widgets", svc.NormalizeCustomerName("ACME Widgets Pty. Ltd."))
+	assert.Equal(t, "acme widgets", svc.NormalizeCustomerName("The Acme Widgets Company"))
+}
+
+func TestNameSimilarity(t *testing.T) {
+	assert.Equal(t, 1.0, svc.NameSimilarity("acme widgets", "acme widgets"))
+	assert.GreaterOrEqual(t, svc.NameSimilarity("acme widgets", "acme widget"), 0.93)
+	assert.Less(t, svc.NameSimilarity("acme widgets", "zenith plumbing"), 0.7)
+	assert.Equal(t, 0.0, svc.NameSimilarity("", "acme"))
+}
@@ -0,0 +1,769 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/sambitmohanty1/payment-watchdog/api/internal/models"
+)
+
+var (
+	// ErrInvalidCustomerSegment is returned for invalid segment keys or criteria
+	ErrInvalidCustomerSegment = errors.New("invalid customer segment")
+	// ErrCustomerSegmentNotFound is returned for unknown segments
+	ErrCustomerSegmentNotFound = errors.New("customer segment not found")
+	// ErrCustomerSegmentProfileNotFound is returned for customers with no recorded events or tags
+	ErrCustomerSegmentProfileNotFound = errors.New("customer has no segment profile")
+)
+
+const (
+	// customerSegmentTTL bounds how long cached segment definitions may miss
+	// changes made through another service instance
+	customerSegmentTTL = time.Minute
+	// maxSegmentProfileEvents bounds the recent events kept per customer
+	maxSegmentProfileEvents = 100
+	// segmentRecomputeBatch is the number of profiles recomputed per batch
+	segmentRecomputeBatch = 500
+)
+
+var segmentKeyPattern = regexp.MustCompile(`^[a-z][a-z0-9_]{0,49}$`)
+
+// SegmentCriteria decides segment membership. A customer carrying any of Tags as
+// a manual tag is always a member; otherwise every history criterion that is set
+// must hold, and a segment with no history criteria only has tagged members.
+type SegmentCriteria struct {
+	MinLifetimeValueCents *int64 `json:"min_lifetime_value_cents,omitempty"`
+	MinTenureDays         *int   `json:"min_tenure_days,omitempty"`
+	MaxTenureDays         *int   `json:"max_tenure_days,omitempty"`
+
+	// MinFailures and MaxFailures count failures in the last FailureWindowDays,
+	// or all failures when the window is 0, optionally only those with one of
+	// FailureReasons
+	MinFailures       *int     `json:"min_failures,omitempty"`
+	MaxFailures       *int     `json:"max_failures,omitempty"`
+	FailureWindowDays int      `json:"failure_window_days,omitempty"`
+	FailureReasons    []string `json:"failure_reasons,omitempty"`
+
+	// Subscribed requires the customer to have, or not have, a subscription;
+	// SubscriptionStatuses additionally restricts the provider's subscription status
+	Subscribed           *bool    `json:"subscribed,omitempty"`
+	SubscriptionStatuses []string `json:"subscription_statuses,omitempty"`
+
+	Tags []string `json:"tags,omitempty"`
+}
+
+// SegmentDefinition is a segment as applied to a company: a built-in segment,
+// possibly redefined, or one of the company's own
+type SegmentDefinition struct {
+	Key         string          `json:"key"`
+	Name        string          `json:"name"`
+	Description string          `json:"description,omitempty"`
+	Criteria    SegmentCriteria `json:"criteria"`
+	Enabled     bool            `json:"enabled"`
+	BuiltIn     bool            `json:"built_in"`
+	Customized  bool            `json:"customized"` // a company definition replaces the built-in one
+}
+
+// CustomerSegmentInput is the editable part of a segment definition
+type CustomerSegmentInput struct {
+	Name        string          `json:"name" binding:"required"`
+	Description string          `json:"description"`
+	Criteria    SegmentCriteria `json:"criteria"`
+	Enabled     *bool           `json:"enabled,omitempty"` // defaults to true
+}
+
+// SegmentCount is the number of customers currently in a segment
+type SegmentCount struct {
+	Segment   string `json:"segment"`
+	Customers int64  `json:"customers"`
+}
+
+func intPtr(v int) *int       { return &v }
+func int64Ptr(v int64) *int64 { return &v }
+func boolPtr(v bool) *bool    { return &v }
+
+// DefaultCustomerSegments returns the built-in segments
+func DefaultCustomerSegments() []SegmentDefinition {
+	return []SegmentDefinition{
+		{
+			Key:         models.CustomerSegmentVIP,
+			Name:        "VIP",
+			Description: "Customers with at least $10,000 collected over six months or more and few recent failures",
+			Criteria: SegmentCriteria{
+				MinLifetimeValueCents: int64Ptr(1000000),
+				MinTenureDays:         intPtr(180),
+				MaxFailures:           intPtr(2),
+				FailureWindowDays:     90,
+				Tags:                  []string{models.CustomerSegmentVIP},
+			},
+		},
+		{
+			Key:         models.CustomerSegmentRecurring,
+			Name:        "Recurring",
+			Description: "Customers billed through a subscription",
+			Criteria: SegmentCriteria{
+				Subscribed: boolPtr(true),
+				Tags:       []string{models.CustomerSegmentRecurring},
+			},
+		},
+		{
+			Key:         models.CustomerSegmentNew,
+			Name:        "New",
+			Description: "Customers first seen in the last 30 days",
+			Criteria: SegmentCriteria{
+				MaxTenureDays: intPtr(30),
+				Tags:          []string{models.CustomerSegmentNew},
+			},
+		},
+		{
+			Key:         models.CustomerSegmentHardship,
+			Name:        "Hardship",
+			Description: "Customers with three or more insufficient funds failures in 60 days, or tagged as in hardship",
+			Criteria: SegmentCriteria{
+				MinFailures:       intPtr(3),
+				FailureWindowDays: 60,
+				FailureReasons:    []string{"insufficient_funds"},
+				Tags:              []string{models.CustomerSegmentHardship},
+			},
+		},
+	}
+}
+
+type cachedCustomerSegments struct {
+	definitions []SegmentDefinition
+	loadedAt    time.Time
+}
+
+// CustomerSegmentService assigns customers to segments from their payment history
+// and manual tags. Profiles are updated incrementally as each event arrives, and
+// segments are exposed to rules, workflow triggers and payment failure filters.
+type CustomerSegmentService struct {
+	db     *gorm.DB
+	logger *zap.Logger
+
+	mu    sync.Mutex
+	cache map[string]cachedCustomerSegments
+}
+
+// NewCustomerSegmentService creates a new customer segment service
+func NewCustomerSegmentService(db *gorm.DB, logger *zap.Logger) *CustomerSegmentService {
+	return &CustomerSegmentService{
+		db:     db,
+		logger: logger,
+		cache:  make(map[string]cachedCustomerSegments),
+	}
+}
+
+// ListSegments returns the company's segment definitions: the built-in segments,
+// as redefined by the company, followed by its own segments
+func (s *CustomerSegmentService) ListSegments(ctx context.Context, companyID string) ([]SegmentDefinition, error) {
+	s.mu.Lock()
+	cached, ok := s.cache[companyID]
+	s.mu.Unlock()
+	if ok && time.Since(cached.loadedAt) < customerSegmentTTL {
+		return cached.definitions, nil
+	}
+
+	var records []models.CustomerSegment
+	if err := s.db.WithContext(ctx).Where("company_id = ?", companyID).Order("key").Find(&records).Error; err != nil {
+		return nil, fmt.Errorf("failed to load customer segments: %w", err)
+	}
+	definitions, err := mergeSegmentDefinitions(records)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	s.cache[companyID] = cachedCustomerSegments{definitions: definitions, loadedAt: time.Now()}
+	s.mu.Unlock()
+	return definitions, nil
+}
+
+// SaveSegment creates or replaces the company's definition of the segment key.
+// Existing profiles pick up the change on their next event or recompute.
+func (s *CustomerSegmentService) SaveSegment(ctx context.Context, companyID, key string, input CustomerSegmentInput, author string) (*SegmentDefinition, error) {
+	if !segmentKeyPattern.MatchString(key) {
+		return nil, fmt.Errorf("%w: key must be lowercase letters, digits and underscores", ErrInvalidCustomerSegment)
+	}
+	if strings.TrimSpace(input.Name) == "" {
+		return nil, fmt.Errorf("%w: name is required", ErrInvalidCustomerSegment)
+	}
+	if err := input.Criteria.validate(); err != nil {
+		return nil, err
+	}
+	criteria, err := json.Marshal(input.Criteria)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode segment criteria: %w", err)
+	}
+
+	record := models.CustomerSegment{
+		CompanyID:   companyID,
+		Key:         key,
+		Name:        strings.TrimSpace(input.Name),
+		Description: input.Description,
+		Criteria:    datatypes.JSON(criteria),
+		Enabled:     input.Enabled == nil || *input.Enabled,
+		CreatedBy:   author,
+		UpdatedBy:   author,
+	}
+	err = s.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "company_id"}, {Name: "key"}},
+		DoUpdates: clause.AssignmentColumns([]string{"name", "description", "criteria", "enabled", "updated_by", "updated_at"}),
+	}).Create(&record).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to save customer segment: %w", err)
+	}
+	s.invalidate(companyID)
+
+	s.logger.Info("Customer segment saved",
+		zap.String("company_id", companyID),
+		zap.String("segment", key))
+
+	definitions, err := s.ListSegments(ctx, companyID)
+	if err != nil {
+		return nil, err
+	}
+	for _, definition := range definitions {
+		if definition.Key == key {
+			return &definition, nil
+		}
+	}
+	return nil, ErrCustomerSegmentNotFound
+}
+
+// DeleteSegment removes the company's definition of a segment. Built-in
+// segments revert to their default criteria.
+func (s *CustomerSegmentService) DeleteSegment(ctx context.Context, companyID, key string) error {
+	result := s.db.WithContext(ctx).Where("company_id = ? AND key = ?", companyID, key).Delete(&models.CustomerSegment{})
+	if result.Error != nil {
+		return fmt.Errorf("failed to delete customer segment: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrCustomerSegmentNotFound
+	}
+	s.invalidate(companyID)
+	return nil
+}
+
+// ApplyEvent records a payment event in its customer's profile, recomputes the
+// customer's segments and sets them on the event. Events already recorded are
+// not counted again, so redelivered events are harmless.
+func (s *CustomerSegmentService) ApplyEvent(ctx context.Context, event *models.PaymentFailureEvent) ([]string, error) {
+	if event.CustomerID == "" {
+		return nil, nil
+	}
+	definitions, err := s.ListSegments(ctx, event.CompanyID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	var profile models.CustomerSegmentProfile
+	var previous []string
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := lockSegmentProfile(tx, event.CompanyID, event.CustomerID, &profile); err != nil {
+			return err
+		}
+		previous = profile.Segments
+		applySegmentEvent(&profile, event, now)
+		profile.Segments = computeSegments(definitions, &profile, now)
+		if !equalStrings(previous, profile.Segments) {
+			profile.SegmentsChangedAt = &now
+		}
+		return tx.Save(&profile).Error
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to update customer segment profile: %w", err)
+	}
+
+	s.logSegmentChange(&profile, previous)
+	event.CustomerSegments = profile.Segments
+	return profile.Segments, nil
+}
+
+// CustomerSegments returns the customer's current segments, re-evaluated at the
+// current time so tenure-based segments such as new expire without new events.
+// Customers without a profile belong to no segments.
+func (s *CustomerSegmentService) CustomerSegments(ctx context.Context, companyID, customerID string) ([]string, error) {
+	if customerID == "" {
+		return nil, nil
+	}
+	profile, err := s.GetProfile(ctx, companyID, customerID)
+	if errors.Is(err, ErrCustomerSegmentProfileNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return profile.Segments, nil
+}
+
+// GetProfile returns a customer's segment profile with up to date segments
+func (s *CustomerSegmentService) GetProfile(ctx context.Context, companyID, customerID string) (*models.CustomerSegmentProfile, error) {
+	var profile models.CustomerSegmentProfile
+	err := s.db.WithContext(ctx).Where("company_id = ? AND customer_id = ?", companyID, customerID).First(&profile).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrCustomerSegmentProfileNotFound
+		}
+		return nil, fmt.Errorf("failed to get customer segment profile: %w", err)
+	}
+	if _, err := s.refresh(ctx, &profile, time.Now()); err != nil {
+		return nil, err
+	}
+	return &profile, nil
+}
+
+// UpdateManualTags adds and removes a customer's manual tags and recomputes its
+// segments. Customers with no events yet get a profile holding only the tags.
+func (s *CustomerSegmentService) UpdateManualTags(ctx context.Context, companyID, customerID string, add, remove []string) (*models.CustomerSegmentProfile, error) {
+	for _, tag := range append(append([]string(nil), add...), remove...) {
+		if !segmentKeyPattern.MatchString(tag) {
+			return nil, fmt.Errorf("%w: tag %q must be lowercase letters, digits and underscores", ErrInvalidCustomerSegment, tag)
+		}
+	}
+	definitions, err := s.ListSegments(ctx, companyID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	var profile models.CustomerSegmentProfile
+	var previous []string
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := lockSegmentProfile(tx, companyID, customerID, &profile); err != nil {
+			return err
+		}
+		previous = profile.Segments
+		tags := make(map[string]bool)
+		for _, tag := range profile.ManualTags {
+			tags[tag] = true
+		}
+		for _, tag := range add {
+			tags[tag] = true
+		}
+		for _, tag := range remove {
+			delete(tags, tag)
+		}
+		profile.ManualTags = sortedKeys(tags)
+		profile.Segments = computeSegments(definitions, &profile, now)
+		if !equalStrings(previous, profile.Segments) {
+			profile.SegmentsChangedAt = &now
+		}
+		return tx.Save(&profile).Error
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to update customer tags: %w", err)
+	}
+
+	s.logSegmentChange(&profile, previous)
+	return &profile, nil
+}
+
+// Recompute re-evaluates the segments of all the company's customers, for
+// example after a segment definition changed. It returns how many changed.
+func (s *CustomerSegmentService) Recompute(ctx context.Context, companyID string) (int, error) {
+	s.invalidate(companyID)
+	definitions, err := s.ListSegments(ctx, companyID)
+	if err != nil {
+		return 0, err
+	}
+
+	now := time.Now()
+	changed := 0
+	var profiles []models.CustomerSegmentProfile
+	result := s.db.WithContext(ctx).Where("company_id = ?", companyID).
+		FindInBatches(&profiles, segmentRecomputeBatch, func(tx *gorm.DB, batch int) error {
+			for i := range profiles {
+				profile := &profiles[i]
+				previous := profile.Segments
+				segments := computeSegments(definitions, profile, now)
+				if equalStrings(previous, segments) {
+					continue
+				}
+				if err := s.saveSegments(ctx, profile, segments, now); err != nil {
+					return err
+				}
+				s.logSegmentChange(profile, previous)
+				changed++
+			}
+			return nil
+		})
+	if result.Error != nil {
+		return changed, fmt.Errorf("failed to recompute customer segments: %w", result.Error)
+	}
+	return changed, nil
+}
+
+// CountBySegment returns how many of the company's customers are in each of its segments
+func (s *CustomerSegmentService) CountBySegment(ctx context.Context, companyID string) ([]SegmentCount, error) {
+	definitions, err := s.ListSegments(ctx, companyID)
+	if err != nil {
+		return nil, err
+	}
+	counts := make([]SegmentCount, 0, len(definitions))
+	for _, definition := range definitions {
+		if !definition.Enabled {
+			continue
+		}
+		count := SegmentCount{Segment: definition.Key}
+		if err := s.db.WithContext(ctx).Model(&models.CustomerSegmentProfile{}).
+			Where("company_id = ? AND segments @> ?::jsonb", companyID, segmentJSON(definition.Key)).
+			Count(&count.Customers).Error; err != nil {
+			return nil, fmt.Errorf("failed to count customers in segment %s: %w", definition.Key, err)
+		}
+		counts = append(counts, count)
+	}
+	return counts, nil
+}
+
+// refresh re-evaluates profile's segments at now, saving them when they changed
+func (s *CustomerSegmentService) refresh(ctx context.Context, profile *models.CustomerSegmentProfile, now time.Time) (bool, error) {
+	definitions, err := s.ListSegments(ctx, profile.CompanyID)
+	if err != nil {
+		return false, err
+	}
+	previous := profile.Segments
+	segments := computeSegments(definitions, profile, now)
+	if equalStrings(previous, segments) {
+		return false, nil
+	}
+	if err := s.saveSegments(ctx, profile, segments, now); err != nil {
+		return false, err
+	}
+	s.logSegmentChange(profile, previous)
+	return true, nil
+}
+
+func (s *CustomerSegmentService) saveSegments(ctx context.Context, profile *models.CustomerSegmentProfile, segments []string, now time.Time) error {
+	profile.Segments = segments
+	profile.SegmentsChangedAt = &now
+	raw, _ := json.Marshal(segments)
+	if err := s.db.WithContext(ctx).Model(&models.CustomerSegmentProfile{}).
+		Where("id = ?", profile.ID).
+		Updates(map[string]interface{}{"segments": string(raw), "segments_changed_at": now}).Error; err != nil {
+		return fmt.Errorf("failed to save customer segments: %w", err)
+	}
+	return nil
+}
+
+func (s *CustomerSegmentService) logSegmentChange(profile *models.CustomerSegmentProfile, previous []string) {
+	if equalStrings(previous, profile.Segments) {
+		return
+	}
+	s.logger.Info("Customer segments changed",
+		zap.String("company_id", profile.CompanyID),
+		zap.String("customer_id", profile.CustomerID),
+		zap.Strings("from", previous),
+		zap.Strings("to", profile.Segments))
+}
+
+func (s *CustomerSegmentService) invalidate(companyID string) {
+	s.mu.Lock()
+	delete(s.cache, companyID)
+	s.mu.Unlock()
+}
+
+// lockSegmentProfile loads the customer's profile for update, creating it first
+// when the customer has none
+func lockSegmentProfile(tx *gorm.DB, companyID, customerID string, profile *models.CustomerSegmentProfile) error {
+	if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&models.CustomerSegmentProfile{
+		CompanyID:  companyID,
+		CustomerID: customerID,
+		Segments:   []string{},
+	}).Error; err != nil {
+		return err
+	}
+	return tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("company_id = ? AND customer_id = ?", companyID, customerID).
+		First(profile).Error
+}
+
+// mergeSegmentDefinitions applies a company's segment records over the built-in segments
+func mergeSegmentDefinitions(records []models.CustomerSegment) ([]SegmentDefinition, error) {
+	definitions := DefaultCustomerSegments()
+	builtIn := make(map[string]int, len(definitions))
+	for i := range definitions {
+		definitions[i].Enabled = true
+		definitions[i].BuiltIn = true
+		builtIn[definitions[i].Key] = i
+	}
+
+	for _, record := range records {
+		definition := SegmentDefinition{
+			Key:         record.Key,
+			Name:        record.Name,
+			Description: record.Description,
+			Enabled:     record.Enabled,
+		}
+		if err := json.Unmarshal(record.Criteria, &definition.Criteria); err != nil {
+			return nil, fmt.Errorf("%w: segment %s: %v", ErrInvalidCustomerSegment, record.Key, err)
+		}
+		if i, ok := builtIn[record.Key]; ok {
+			definition.BuiltIn = true
+			definition.Customized = true
+			definitions[i] = definition
+			continue
+		}
+		definitions = append(definitions, definition)
+	}
+	return definitions, nil
+}
+
+// applySegmentEvent records event in profile. A recovered or resolved event adds
+// to lifetime value; any other event counts as a failure. Each event is counted
+// once as a failure and once as recovered however often it is applied.
+func applySegmentEvent(profile *models.CustomerSegmentProfile, event *models.PaymentFailureEvent, now time.Time) {
+	at := event.CreatedAt
+	if at.IsZero() {
+		at = event.WebhookReceivedAt
+	}
+	if at.IsZero() {
+		at = now
+	}
+	if profile.FirstSeenAt.IsZero() || at.Before(profile.FirstSeenAt) {
+		profile.FirstSeenAt = at
+	}
+	if at.After(profile.LastEventAt) {
+		profile.LastEventAt = at
+	}
+
+	details := segmentEventDetails(event.NormalizedData)
+	if details.customerSince != nil && details.customerSince.Before(profile.FirstSeenAt) {
+		profile.FirstSeenAt = *details.customerSince
+	}
+	if details.subscriptionID != "" {
+		profile.SubscriptionID = details.subscriptionID
+	}
+	if details.subscriptionStatus != "" {
+		profile.SubscriptionStatus = details.subscriptionStatus
+	}
+
+	eventID := event.ID.String()
+	recovered := event.Status == "recovered" || event.Status == "resolved"
+	index := -1
+	for i := range profile.RecentEvents {
+		if profile.RecentEvents[i].EventID == eventID {
+			index = i
+			break
+		}
+	}
+
+	switch {
+	case index >= 0 && recovered && !profile.RecentEvents[index].Recovered:
+		profile.RecentEvents[index].Recovered = true
+		profile.LifetimeValueCents += event.AmountCents
+	case index >= 0:
+		// Already recorded
+	default:
+		if recovered {
+			profile.LifetimeValueCents += event.AmountCents
+		} else {
+			profile.FailureCount++
+			profile.FailedAmountCents += event.AmountCents
+		}
+		profile.RecentEvents = append(profile.RecentEvents, models.CustomerSegmentEvent{
+			EventID:     eventID,
+			At:          at,
+			Reason:      event.FailureReason,
+			AmountCents: event.AmountCents,
+			Failure:     !recovered,
+			Recovered:   recovered,
+		})
+		if excess := len(profile.RecentEvents) - maxSegmentProfileEvents; excess > 0 {
+			profile.RecentEvents = append([]models.CustomerSegmentEvent(nil), profile.RecentEvents[excess:]...)
+		}
+	}
+
+	if details.lifetimeValueCents > profile.LifetimeValueCents {
+		profile.LifetimeValueCents = details.lifetimeValueCents
+	}
+}
+
+type segmentEventData struct {
+	subscriptionID     string
+	subscriptionStatus string
+	lifetimeValueCents int64
+	customerSince      *time.Time
+}
+
+// segmentEventDetails extracts subscription, lifetime value and customer creation
+// details from provider normalized data
+func segmentEventDetails(data string) segmentEventData {
+	var details segmentEventData
+	if data == "" {
+		return details
+	}
+	var fields map[string]interface{}
+	if err := json.Unmarshal([]byte(data), &fields); err != nil {
+		return details
+	}
+	for _, key := range []string{"subscription_id", "subscription"} {
+		if v, ok := fields[key].(string); ok && v != "" {
+			details.subscriptionID = v
+			break
+		}
+	}
+	if v, ok := fields["subscription_status"].(string); ok {
+		details.subscriptionStatus = v
+	}
+	if v, ok := fields["lifetime_value_cents"].(float64); ok {
+		details.lifetimeValueCents = int64(v)
+	}
+	switch v := fields["customer_created"].(type) {
+	case float64:
+		since := time.Unix(int64(v), 0)
+		details.customerSince = &since
+	case string:
+		if since, err := time.Parse(time.RFC3339, v); err == nil {
+			details.customerSince = &since
+		}
+	}
+	return details
+}
+
+// computeSegments returns the keys of the enabled segments profile belongs to at now, sorted
+func computeSegments(definitions []SegmentDefinition, profile *models.CustomerSegmentProfile, now time.Time) []string {
+	segments := []string{}
+	for _, definition := range definitions {
+		if definition.Enabled && definition.Criteria.matches(profile, now) {
+			segments = append(segments, definition.Key)
+		}
+	}
+	sort.Strings(segments)
+	return segments
+}
+
+// matches reports whether profile meets the criteria at now
+func (c SegmentCriteria) matches(profile *models.CustomerSegmentProfile, now time.Time) bool {
+	for _, tag := range c.Tags {
+		if containsSegment(profile.ManualTags, tag) {
+			return true
+		}
+	}
+	// Profiles holding only manual tags have no history to judge
+	if !c.hasHistoryCriteria() || profile.FirstSeenAt.IsZero() {
+		return false
+	}
+
+	if c.MinLifetimeValueCents != nil && profile.LifetimeValueCents < *c.MinLifetimeValueCents {
+		return false
+	}
+	tenureDays := int(now.Sub(profile.FirstSeenAt).Hours() / 24)
+	if c.MinTenureDays != nil && tenureDays < *c.MinTenureDays {
+		return false
+	}
+	if c.MaxTenureDays != nil && tenureDays > *c.MaxTenureDays {
+		return false
+	}
+	if c.MinFailures != nil || c.MaxFailures != nil {
+		failures := c.countFailures(profile, now)
+		if c.MinFailures != nil && failures < *c.MinFailures {
+			return false
+		}
+		if c.MaxFailures != nil && failures > *c.MaxFailures {
+			return false
+		}
+	}
+	if c.Subscribed != nil && (profile.SubscriptionID != "") != *c.Subscribed {
+		return false
+	}
+	if len(c.SubscriptionStatuses) > 0 && !containsSegment(c.SubscriptionStatuses, profile.SubscriptionStatus) {
+		return false
+	}
+	return true
+}
+
+func (c SegmentCriteria) countFailures(profile *models.CustomerSegmentProfile, now time.Time) int {
+	if c.FailureWindowDays == 0 && len(c.FailureReasons) == 0 {
+		return int(profile.FailureCount)
+	}
+	since := now.AddDate(0, 0, -c.FailureWindowDays)
+	count := 0
+	for _, event := range profile.RecentEvents {
+		if !event.Failure {
+			continue
+		}
+		if c.FailureWindowDays > 0 && event.At.Before(since) {
+			continue
+		}
+		if len(c.FailureReasons) > 0 && !containsSegment(c.FailureReasons, event.Reason) {
+			continue
+		}
+		count++
+	}
+	return count
+}
+
+func (c SegmentCriteria) hasHistoryCriteria() bool {
+	return c.MinLifetimeValueCents != nil || c.MinTenureDays != nil || c.MaxTenureDays != nil ||
+		c.MinFailures != nil || c.MaxFailures != nil || c.Subscribed != nil || len(c.SubscriptionStatuses) > 0
+}
+
+func (c SegmentCriteria) validate() error {
+	if !c.hasHistoryCriteria() && len(c.Tags) == 0 {
+		return fmt.Errorf("%w: criteria need at least one history criterion or tag", ErrInvalidCustomerSegment)
+	}
+	for name, value := range map[string]*int{"min_tenure_days": c.MinTenureDays, "max_tenure_days": c.MaxTenureDays, "min_failures": c.MinFailures, "max_failures": c.MaxFailures} {
+		if value != nil && *value < 0 {
+			return fmt.Errorf("%w: %s must not be negative", ErrInvalidCustomerSegment, name)
+		}
+	}
+	if c.MinLifetimeValueCents != nil && *c.MinLifetimeValueCents < 0 {
+		return fmt.Errorf("%w: min_lifetime_value_cents must not be negative", ErrInvalidCustomerSegment)
+	}
+	if c.FailureWindowDays < 0 || c.FailureWindowDays > 365 {
+		return fmt.Errorf("%w: failure_window_days must be between 0 and 365", ErrInvalidCustomerSegment)
+	}
+	if c.MinTenureDays != nil && c.MaxTenureDays != nil && *c.MinTenureDays > *c.MaxTenureDays {
+		return fmt.Errorf("%w: min_tenure_days exceeds max_tenure_days", ErrInvalidCustomerSegment)
+	}
+	if c.MinFailures != nil && c.MaxFailures != nil && *c.MinFailures > *c.MaxFailures {
+		return fmt.Errorf("%w: min_failures exceeds max_failures", ErrInvalidCustomerSegment)
+	}
+	for _, tag := range c.Tags {
+		if !segmentKeyPattern.MatchString(tag) {
+			return fmt.Errorf("%w: tag %q must be lowercase letters, digits and underscores", ErrInvalidCustomerSegment, tag)
+		}
+	}
+	return nil
+}
+
+// segmentJSON returns the JSON array containing only segment, for jsonb containment
+func segmentJSON(segment string) string {
+	raw, _ := json.Marshal([]string{segment})
+	return string(raw)
+}
+
+func containsSegment(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
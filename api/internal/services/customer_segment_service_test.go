@@ -0,0 +1,155 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sambitmohanty1/payment-watchdog/api/internal/models"
+)
+
+func segmentEvent(at time.Time, reason string, cents int64) *models.PaymentFailureEvent {
+	return &models.PaymentFailureEvent{
+		ID:            uuid.New(),
+		CompanyID:     "company-1",
+		CustomerID:    "cus_1",
+		AmountCents:   cents,
+		FailureReason: reason,
+		Status:        "received",
+		CreatedAt:     at,
+	}
+}
+
+func TestApplySegmentEventIsIncrementalAndIdempotent(t *testing.T) {
+	now := time.Date(2026, 6, 1, 12, 0, 0, 0, time.UTC)
+	profile := &models.CustomerSegmentProfile{CompanyID: "company-1", CustomerID: "cus_1"}
+
+	failure := segmentEvent(now.AddDate(0, 0, -10), "card_declined", 5000)
+	failure.NormalizedData = `{"subscription": "sub_1", "subscription_status": "past_due", "customer_created": "2025-01-15T00:00:00Z"}`
+	applySegmentEvent(profile, failure, now)
+	applySegmentEvent(profile, failure, now)
+
+	assert.Equal(t, int64(1), profile.FailureCount)
+	assert.Equal(t, int64(5000), profile.FailedAmountCents)
+	assert.Equal(t, "sub_1", profile.SubscriptionID)
+	assert.Equal(t, "past_due", profile.SubscriptionStatus)
+	assert.Equal(t, time.Date(2025, 1, 15, 0, 0, 0, 0, time.UTC), profile.FirstSeenAt.UTC())
+	assert.Equal(t, failure.CreatedAt, profile.LastEventAt)
+
+	// The same failure recovered adds to lifetime value once
+	recovered := *failure
+	recovered.Status = "recovered"
+	applySegmentEvent(profile, &recovered, now)
+	applySegmentEvent(profile, &recovered, now)
+	assert.Equal(t, int64(1), profile.FailureCount)
+	assert.Equal(t, int64(5000), profile.LifetimeValueCents)
+	require.Len(t, profile.RecentEvents, 1)
+	assert.True(t, profile.RecentEvents[0].Failure)
+	assert.True(t, profile.RecentEvents[0].Recovered)
+
+	// A provider-reported lifetime value only ever raises the figure
+	payment := segmentEvent(now, "", 1000)
+	payment.Status = "resolved"
+	payment.NormalizedData = `{"lifetime_value_cents": 250000}`
+	applySegmentEvent(profile, payment, now)
+	assert.Equal(t, int64(250000), profile.LifetimeValueCents)
+	assert.Equal(t, int64(1), profile.FailureCount)
+
+	for i := 0; i < maxSegmentProfileEvents+5; i++ {
+		applySegmentEvent(profile, segmentEvent(now, "insufficient_funds", 100), now)
+	}
+	assert.Len(t, profile.RecentEvents, maxSegmentProfileEvents)
+	assert.Equal(t, int64(maxSegmentProfileEvents+6), profile.FailureCount)
+}
+
+func TestComputeSegments(t *testing.T) {
+	now := time.Date(2026, 6, 1, 12, 0, 0, 0, time.UTC)
+	definitions, err := mergeSegmentDefinitions(nil)
+	require.NoError(t, err)
+
+	profile := func(firstSeen time.Time, events ...*models.PaymentFailureEvent) *models.CustomerSegmentProfile {
+		p := &models.CustomerSegmentProfile{}
+		for _, event := range events {
+			applySegmentEvent(p, event, now)
+		}
+		p.FirstSeenAt = firstSeen
+		return p
+	}
+	collected := func(cents int64) *models.PaymentFailureEvent {
+		event := segmentEvent(now.AddDate(0, -1, 0), "", cents)
+		event.Status = "recovered"
+		return event
+	}
+
+	vip := profile(now.AddDate(-1, 0, 0), collected(1500000), segmentEvent(now.AddDate(0, 0, -5), "card_declined", 100))
+	assert.Equal(t, []string{"vip"}, computeSegments(definitions, vip, now))
+
+	// Three insufficient funds failures in 60 days mark hardship and disqualify VIP
+	for i := 1; i <= 3; i++ {
+		applySegmentEvent(vip, segmentEvent(now.AddDate(0, 0, -i*10), "insufficient_funds", 100), now)
+	}
+	assert.Equal(t, []string{"hardship"}, computeSegments(definitions, vip, now))
+
+	newCustomer := profile(now.AddDate(0, 0, -3), segmentEvent(now.AddDate(0, 0, -3), "card_declined", 100))
+	assert.Equal(t, []string{"new"}, computeSegments(definitions, newCustomer, now))
+	assert.Empty(t, computeSegments(definitions, newCustomer, now.AddDate(0, 0, 40)), "new expires with tenure")
+
+	subscriber := segmentEvent(now.AddDate(0, -2, 0), "card_declined", 100)
+	subscriber.NormalizedData = `{"subscription_id": "sub_9"}`
+	assert.Equal(t, []string{"recurring"}, computeSegments(definitions, profile(now.AddDate(0, -2, 0), subscriber), now))
+
+	// Manual tags qualify without history; a tags-only profile matches no history criteria
+	tagged := &models.CustomerSegmentProfile{ManualTags: []string{"hardship", "vip"}}
+	assert.Equal(t, []string{"hardship", "vip"}, computeSegments(definitions, tagged, now))
+}
+
+func TestMergeSegmentDefinitions(t *testing.T) {
+	vipCriteria, _ := json.Marshal(SegmentCriteria{MinLifetimeValueCents: int64Ptr(100), Tags: []string{"vip"}})
+	latePayer, _ := json.Marshal(SegmentCriteria{MinFailures: intPtr(5)})
+	definitions, err := mergeSegmentDefinitions([]models.CustomerSegment{
+		{Key: "late_payer", Name: "Late payer", Criteria: latePayer, Enabled: true},
+		{Key: "new", Name: "New", Criteria: []byte(`{"max_tenure_days": 7}`), Enabled: false},
+		{Key: "vip", Name: "Key accounts", Criteria: vipCriteria, Enabled: true},
+	})
+	require.NoError(t, err)
+	require.Len(t, definitions, 5)
+
+	keys := make([]string, len(definitions))
+	for i, definition := range definitions {
+		keys[i] = definition.Key
+	}
+	assert.Equal(t, []string{"vip", "recurring", "new", "hardship", "late_payer"}, keys)
+	assert.Equal(t, "Key accounts", definitions[0].Name)
+	assert.True(t, definitions[0].BuiltIn && definitions[0].Customized)
+	assert.False(t, definitions[2].Enabled)
+	assert.False(t, definitions[4].BuiltIn)
+
+	p := &models.CustomerSegmentProfile{FirstSeenAt: time.Now().AddDate(0, 0, -1), FailureCount: 6, LifetimeValueCents: 500}
+	assert.Equal(t, []string{"late_payer", "vip"}, computeSegments(definitions, p, time.Now()))
+}
+
+func TestSegmentCriteriaValidation(t *testing.T) {
+	assert.NoError(t, SegmentCriteria{Tags: []string{"key_account"}}.validate())
+	for _, definition := range DefaultCustomerSegments() {
+		assert.NoError(t, definition.Criteria.validate(), definition.Key)
+	}
+
+	invalid := map[string]SegmentCriteria{
+		"at least one history criterion or tag": {},
+		"min_failures must not be negative":     {MinFailures: intPtr(-1)},
+		"failure_window_days must be between":   {MinFailures: intPtr(1), FailureWindowDays: 400},
+		"min_tenure_days exceeds max_tenure":    {MinTenureDays: intPtr(30), MaxTenureDays: intPtr(7)},
+		"tag \"VIP\" must be lowercase":         {Tags: []string{"VIP"}},
+	}
+	for want, criteria := range invalid {
+		err := criteria.validate()
+		require.Error(t, err, want)
+		assert.True(t, errors.Is(err, ErrInvalidCustomerSegment), want)
+		assert.Contains(t, err.Error(), want)
+	}
+}
@@ -0,0 +1,330 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+
+	"github.com/sambitmohanty1/payment-watchdog/api/internal/models"
+)
+
+// ErrPaymentFailureNotFound is returned when a payment failure does not exist for the company
+var ErrPaymentFailureNotFound = errors.New("payment failure not found")
+
+// ErrInvalidShadowReport is returned for shadow report requests with an unusable range or subject
+var ErrInvalidShadowReport = errors.New("invalid shadow report request")
+
+const (
+	shadowReportSampleSize = 10
+	maxShadowReportRange   = 92 * 24 * time.Hour
+	shadowReportBatchSize  = 500
+)
+
+// maxShadowReportDecisions bounds the shadow decisions a single report reads
+var maxShadowReportDecisions = 50000
+
+// ShadowDisagreement is a payment failure on which a shadow subject and the live
+// automation decided differently
+type ShadowDisagreement struct {
+	PaymentFailureID string    `json:"payment_failure_id"`
+	CreatedAt        time.Time `json:"created_at"`
+	ShadowActions    []string  `json:"shadow_actions"`
+	LiveActions      []string  `json:"live_actions"`
+}
+
+// ShadowSubjectComparison compares one shadow rule or workflow with live decisions
+type ShadowSubjectComparison struct {
+	SubjectID      string               `json:"subject_id,omitempty"`
+	SubjectName    string               `json:"subject_name"`
+	Evaluated      int                  `json:"evaluated"`
+	Matched        int                  `json:"matched"`
+	Agreed         int                  `json:"agreed"`
+	AgreementRate  float64              `json:"agreement_rate"`
+	ExtraActions   map[string]int       `json:"extra_actions"`   // actions shadow would take that live did not
+	MissingActions map[string]int       `json:"missing_actions"` // actions live took that shadow would not
+	Disagreements  []ShadowDisagreement `json:"disagreements"`
+}
+
+// ShadowComparisonReport compares shadow decisions with the live decisions taken
+// for the same payment failures
+type ShadowComparisonReport struct {
+	SubjectType string                    `json:"subject_type"`
+	Start       time.Time                 `json:"start"`
+	End         time.Time                 `json:"end"`
+	Truncated   bool                      `json:"truncated"`
+	Subjects    []ShadowSubjectComparison `json:"subjects"`
+}
+
+// FailureDecisionLog explains the automation applied to one payment failure: the
+// rules that were considered with their condition and action traces, and the
+// live and shadow decisions taken
+type FailureDecisionLog struct {
+	PaymentFailureID string                       `json:"payment_failure_id"`
+	Traces           []models.RuleEvaluationTrace `json:"traces"`
+	Decisions        []models.AutomationDecision  `json:"decisions"`
+}
+
+// DecisionLogService stores live and shadow automation decisions and rule
+// evaluation traces, and compares shadow decisions with live ones
+type DecisionLogService struct {
+	db     *gorm.DB
+	logger *zap.Logger
+}
+
+// NewDecisionLogService creates a new decision log service
+func NewDecisionLogService(db *gorm.DB, logger *zap.Logger) *DecisionLogService {
+	return &DecisionLogService{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// Record stores decisions taken for a payment failure
+func (s *DecisionLogService) Record(ctx context.Context, decisions ...models.AutomationDecision) error {
+	if len(decisions) == 0 {
+		return nil
+	}
+	if err := s.db.WithContext(ctx).Create(&decisions).Error; err != nil {
+		return fmt.Errorf("failed to record automation decisions: %w", err)
+	}
+	return nil
+}
+
+// FailureDecisions returns the rule traces and decisions recorded for a payment
+// failure, oldest first
+func (s *DecisionLogService) FailureDecisions(ctx context.Context, companyID string, paymentFailureID uuid.UUID) (*FailureDecisionLog, error) {
+	var count int64
+	if err := s.db.WithContext(ctx).Model(&models.PaymentFailureEvent{}).
+		Where("id = ? AND company_id = ?", paymentFailureID, companyID).
+		Count(&count).Error; err != nil {
+		return nil, fmt.Errorf("failed to load payment failure: %w", err)
+	}
+	if count == 0 {
+		return nil, ErrPaymentFailureNotFound
+	}
+
+	decisionLog := &FailureDecisionLog{
+		PaymentFailureID: paymentFailureID.String(),
+		Traces:           []models.RuleEvaluationTrace{},
+		Decisions:        []models.AutomationDecision{},
+	}
+	if err := s.db.WithContext(ctx).
+		Where("company_id = ? AND payment_failure_id = ?", companyID, paymentFailureID).
+		Order("evaluated_at, rule_name").
+		Find(&decisionLog.Traces).Error; err != nil {
+		return nil, fmt.Errorf("failed to load rule evaluation traces: %w", err)
+	}
+	if err := s.db.WithContext(ctx).
+		Where("company_id = ? AND payment_failure_id = ?", companyID, paymentFailureID).
+		Order("created_at, subject_name").
+		Find(&decisionLog.Decisions).Error; err != nil {
+		return nil, fmt.Errorf("failed to load automation decisions: %w", err)
+	}
+	return decisionLog, nil
+}
+
+// ShadowReport compares the shadow decisions of subjectType recorded in [start, end]
+// with the live decisions of the same subject type for the same payment failures.
+// A shadow subject is compared on the action types it declares: it agrees with
+// live when it would have taken exactly the actions of those types that the
+// live rules or workflows took, including taking none. Live actions of other
+// types, such as those of built-in rules that fire on every failure, are left
+// out of its comparison.
+func (s *DecisionLogService) ShadowReport(ctx context.Context, companyID, subjectType string, start, end time.Time) (*ShadowComparisonReport, error) {
+	if subjectType != models.DecisionSubjectRule && subjectType != models.DecisionSubjectWorkflow {
+		return nil, fmt.Errorf("%w: subject_type must be rule or workflow", ErrInvalidShadowReport)
+	}
+	if end.IsZero() {
+		end = time.Now()
+	}
+	if start.IsZero() {
+		start = end.AddDate(0, 0, -7)
+	}
+	if !end.After(start) {
+		return nil, fmt.Errorf("%w: end must be after start", ErrInvalidShadowReport)
+	}
+	if end.Sub(start) > maxShadowReportRange {
+		return nil, fmt.Errorf("%w: range may not exceed 92 days", ErrInvalidShadowReport)
+	}
+
+	var shadow []models.AutomationDecision
+	err := s.db.WithContext(ctx).
+		Where("company_id = ? AND subject_type = ? AND mode = ? AND created_at BETWEEN ? AND ?",
+			companyID, subjectType, models.DecisionModeShadow, start, end).
+		Order("created_at").
+		Limit(maxShadowReportDecisions + 1).
+		Find(&shadow).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to load shadow decisions: %w", err)
+	}
+	truncated := len(shadow) > maxShadowReportDecisions
+	if truncated {
+		shadow = shadow[:maxShadowReportDecisions]
+	}
+
+	seen := make(map[uuid.UUID]bool)
+	var failureIDs []uuid.UUID
+	for _, decision := range shadow {
+		if !seen[decision.PaymentFailureID] {
+			seen[decision.PaymentFailureID] = true
+			failureIDs = append(failureIDs, decision.PaymentFailureID)
+		}
+	}
+
+	var live []models.AutomationDecision
+	for i := 0; i < len(failureIDs); i += shadowReportBatchSize {
+		batch := failureIDs[i:min(i+shadowReportBatchSize, len(failureIDs))]
+		var decisions []models.AutomationDecision
+		err := s.db.WithContext(ctx).
+			Where("company_id = ? AND subject_type = ? AND mode = ? AND payment_failure_id IN ?",
+				companyID, subjectType, models.DecisionModeLive, batch).
+			Find(&decisions).Error
+		if err != nil {
+			return nil, fmt.Errorf("failed to load live decisions: %w", err)
+		}
+		live = append(live, decisions...)
+	}
+
+	report := compareShadowDecisions(shadow, live, shadowReportSampleSize)
+	report.SubjectType = subjectType
+	report.Start = start
+	report.End = end
+	report.Truncated = truncated
+	return report, nil
+}
+
+// compareShadowDecisions builds the per-subject comparison of shadow decisions
+// against the live actions taken for each payment failure, restricted to the
+// action types each shadow subject declares
+func compareShadowDecisions(shadow, live []models.AutomationDecision, sampleSize int) *ShadowComparisonReport {
+	liveActions := make(map[uuid.UUID]map[string]bool)
+	for _, decision := range live {
+		if !decision.Matched {
+			continue
+		}
+		actions := liveActions[decision.PaymentFailureID]
+		if actions == nil {
+			actions = make(map[string]bool)
+			liveActions[decision.PaymentFailureID] = actions
+		}
+		for _, action := range decisionActions(decision) {
+			actions[action] = true
+		}
+	}
+
+	report := &ShadowComparisonReport{Subjects: []ShadowSubjectComparison{}}
+	index := make(map[string]int)
+	for _, decision := range shadow {
+		key := decision.SubjectID + "/" + decision.SubjectName
+		i, ok := index[key]
+		if !ok {
+			i = len(report.Subjects)
+			index[key] = i
+			report.Subjects = append(report.Subjects, ShadowSubjectComparison{
+				SubjectID:      decision.SubjectID,
+				SubjectName:    decision.SubjectName,
+				ExtraActions:   map[string]int{},
+				MissingActions: map[string]int{},
+				Disagreements:  []ShadowDisagreement{},
+			})
+		}
+		subject := &report.Subjects[i]
+		subject.Evaluated++
+
+		// Shadow decisions record the actions their subject declares whether
+		// or not it matched
+		declared := make(map[string]bool)
+		for _, action := range decisionActions(decision) {
+			declared[action] = true
+		}
+		shadowActions := make(map[string]bool)
+		if decision.Matched {
+			subject.Matched++
+			shadowActions = declared
+		}
+		taken := make(map[string]bool)
+		for action := range liveActions[decision.PaymentFailureID] {
+			if declared[action] {
+				taken[action] = true
+			}
+		}
+
+		agreed := true
+		for action := range shadowActions {
+			if !taken[action] {
+				subject.ExtraActions[action]++
+				agreed = false
+			}
+		}
+		for action := range taken {
+			if !shadowActions[action] {
+				subject.MissingActions[action]++
+				agreed = false
+			}
+		}
+		if agreed {
+			subject.Agreed++
+		} else if len(subject.Disagreements) < sampleSize {
+			subject.Disagreements = append(subject.Disagreements, ShadowDisagreement{
+				PaymentFailureID: decision.PaymentFailureID.String(),
+				CreatedAt:        decision.CreatedAt,
+				ShadowActions:    sortedKeys(shadowActions),
+				LiveActions:      sortedKeys(taken),
+			})
+		}
+	}
+
+	for i := range report.Subjects {
+		subject := &report.Subjects[i]
+		subject.AgreementRate = float64(subject.Agreed) / float64(subject.Evaluated)
+	}
+	sort.Slice(report.Subjects, func(i, j int) bool {
+		return report.Subjects[i].SubjectName < report.Subjects[j].SubjectName
+	})
+	return report
+}
+
+// newAutomationDecision builds a decision record for a payment failure
+func newAutomationDecision(event *models.PaymentFailureEvent, subjectType, subjectID, subjectName, mode string, matched bool, actions []string, details map[string]interface{}) models.AutomationDecision {
+	decision := models.AutomationDecision{
+		CompanyID:        event.CompanyID,
+		PaymentFailureID: event.ID,
+		SubjectType:      subjectType,
+		SubjectID:        subjectID,
+		SubjectName:      subjectName,
+		Mode:             mode,
+		Matched:          matched,
+	}
+	if actions == nil {
+		actions = []string{}
+	}
+	decision.Actions, _ = json.Marshal(actions)
+	if len(details) > 0 {
+		decision.Details, _ = json.Marshal(details)
+	}
+	return decision
+}
+
+func decisionActions(decision models.AutomationDecision) []string {
+	var actions []string
+	if len(decision.Actions) > 0 {
+		_ = json.Unmarshal(decision.Actions, &actions)
+	}
+	return actions
+}
+
+func sortedKeys(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,95 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/sambitmohanty1/payment-watchdog/api/internal/models"
+)
+
+func TestCompareShadowDecisions(t *testing.T) {
+	agreeing := &models.PaymentFailureEvent{ID: uuid.New(), CompanyID: "company-1"}
+	extra := &models.PaymentFailureEvent{ID: uuid.New(), CompanyID: "company-1"}
+	missing := &models.PaymentFailureEvent{ID: uuid.New(), CompanyID: "company-1"}
+	quiet := &models.PaymentFailureEvent{ID: uuid.New(), CompanyID: "company-1"}
+
+	live := []models.AutomationDecision{
+		newAutomationDecision(agreeing, models.DecisionSubjectRule, "1", "alert_rule", models.DecisionModeLive, true, []string{"alert"}, nil),
+		newAutomationDecision(agreeing, models.DecisionSubjectRule, "2", "retry_rule", models.DecisionModeLive, true, []string{"retry"}, nil),
+		newAutomationDecision(extra, models.DecisionSubjectRule, "1", "alert_rule", models.DecisionModeLive, true, []string{"alert"}, nil),
+		newAutomationDecision(missing, models.DecisionSubjectRule, "2", "retry_rule", models.DecisionModeLive, true, []string{"retry"}, nil),
+	}
+	shadow := []models.AutomationDecision{
+		newAutomationDecision(agreeing, models.DecisionSubjectRule, "9", "candidate", models.DecisionModeShadow, true, []string{"retry", "alert"}, nil),
+		newAutomationDecision(extra, models.DecisionSubjectRule, "9", "candidate", models.DecisionModeShadow, true, []string{"retry", "alert"}, nil),
+		newAutomationDecision(missing, models.DecisionSubjectRule, "9", "candidate", models.DecisionModeShadow, false, []string{"retry", "alert"}, nil),
+		newAutomationDecision(quiet, models.DecisionSubjectRule, "9", "candidate", models.DecisionModeShadow, false, []string{"retry", "alert"}, nil),
+	}
+
+	report := compareShadowDecisions(shadow, live, 1)
+	require.Len(t, report.Subjects, 1)
+	subject := report.Subjects[0]
+	assert.Equal(t, "candidate", subject.SubjectName)
+	assert.Equal(t, 4, subject.Evaluated)
+	assert.Equal(t, 2, subject.Matched)
+	assert.Equal(t, 2, subject.Agreed, "matching actions and matching inaction both agree")
+	assert.Equal(t, 0.5, subject.AgreementRate)
+	assert.Equal(t, map[string]int{"retry": 1}, subject.ExtraActions)
+	assert.Equal(t, map[string]int{"retry": 1}, subject.MissingActions)
+	require.Len(t, subject.Disagreements, 1, "samples are capped")
+	assert.Equal(t, extra.ID.String(), subject.Disagreements[0].PaymentFailureID)
+	assert.Equal(t, []string{"alert", "retry"}, subject.Disagreements[0].ShadowActions)
+	assert.Equal(t, []string{"alert"}, subject.Disagreements[0].LiveActions)
+}
+
+func TestCompareShadowDecisionsIgnoresUnrelatedLiveActions(t *testing.T) {
+	failure := &models.PaymentFailureEvent{ID: uuid.New(), CompanyID: "company-1"}
+	quiet := &models.PaymentFailureEvent{ID: uuid.New(), CompanyID: "company-1"}
+
+	// Risk scoring fires on every failure and has nothing to do with the candidate
+	live := []models.AutomationDecision{
+		newAutomationDecision(failure, models.DecisionSubjectRule, "", "risk_scoring", models.DecisionModeLive, true, []string{"risk_scoring"}, nil),
+		newAutomationDecision(failure, models.DecisionSubjectRule, "1", "alert_rule", models.DecisionModeLive, true, []string{"alert"}, nil),
+		newAutomationDecision(quiet, models.DecisionSubjectRule, "", "risk_scoring", models.DecisionModeLive, true, []string{"risk_scoring"}, nil),
+	}
+	shadow := []models.AutomationDecision{
+		newAutomationDecision(failure, models.DecisionSubjectRule, "9", "candidate", models.DecisionModeShadow, true, []string{"alert"}, nil),
+		newAutomationDecision(quiet, models.DecisionSubjectRule, "9", "candidate", models.DecisionModeShadow, false, []string{"alert"}, nil),
+	}
+
+	report := compareShadowDecisions(shadow, live, 10)
+	require.Len(t, report.Subjects, 1)
+	subject := report.Subjects[0]
+	assert.Equal(t, 2, subject.Agreed)
+	assert.Equal(t, 1.0, subject.AgreementRate)
+	assert.Empty(t, subject.MissingActions)
+	assert.Empty(t, subject.ExtraActions)
+	assert.Empty(t, subject.Disagreements)
+}
+
+func TestShadowReportRejectsInvalidRequests(t *testing.T) {
+	service := NewDecisionLogService(nil, zap.NewNop())
+	now := time.Now()
+
+	tests := map[string]struct {
+		subjectType string
+		start, end  time.Time
+	}{
+		"subject type":     {subjectType: "alert", start: now.Add(-time.Hour), end: now},
+		"end before start": {subjectType: models.DecisionSubjectRule, start: now, end: now.Add(-time.Hour)},
+		"range too long":   {subjectType: models.DecisionSubjectWorkflow, start: now.AddDate(-1, 0, 0), end: now},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := service.ShadowReport(context.Background(), "company-1", tc.subjectType, tc.start, tc.end)
+			assert.True(t, errors.Is(err, ErrInvalidShadowReport), "got %v", err)
+		})
+	}
+}
@@ -0,0 +1,432 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/sambitmohanty1/payment-watchdog/api/internal/architecture"
+	"github.com/sambitmohanty1/payment-watchdog/api/internal/calendar"
+	"github.com/sambitmohanty1/payment-watchdog/api/internal/models"
+	"github.com/sambitmohanty1/payment-watchdog/api/internal/rules"
+)
+
+// EnterpriseActionService performs enterprise rule actions through the alert,
+// retry, communication and recovery orchestration services and records each
+// action as a RecoveryAction. It implements rules.EnterpriseActionDispatcher.
+type EnterpriseActionService struct {
+	db                   *gorm.DB
+	alertService         *AlertService
+	retryService         *RetryService
+	communicationService *CommunicationService
+	recoveryService      *RecoveryOrchestrationService
+	logger               *zap.Logger
+}
+
+// NewEnterpriseActionService creates a new enterprise action service
+func NewEnterpriseActionService(
+	db *gorm.DB,
+	alertService *AlertService,
+	retryService *RetryService,
+	communicationService *CommunicationService,
+	recoveryService *RecoveryOrchestrationService,
+	logger *zap.Logger,
+) *EnterpriseActionService {
+	return &EnterpriseActionService{
+		db:                   db,
+		alertService:         alertService,
+		retryService:         retryService,
+		communicationService: communicationService,
+		recoveryService:      recoveryService,
+		logger:               logger,
+	}
+}
+
+// Dispatch performs one action of a triggered enterprise rule
+func (s *EnterpriseActionService) Dispatch(ctx context.Context, ruleCtx rules.EnterpriseRuleContext, request rules.EnterpriseActionRequest) (map[string]interface{}, error) {
+	if ruleCtx.PaymentFailure == nil {
+		return nil, fmt.Errorf("enterprise action %s requires a payment failure", request.Type)
+	}
+	cal := calendar.Default()
+	if ruleCtx.Company != nil && ruleCtx.Company.Calendar != nil {
+		cal = ruleCtx.Company.Calendar
+	}
+	return s.PerformAction(ctx, paymentFailureEventFromArchitecture(ruleCtx.PaymentFailure), cal, request)
+}
+
+// PerformAction performs one action for a payment failure and records it as a
+// RecoveryAction. Each action type is performed at most once per failure, since
+// the enterprise rules and the company rules can both call for it and an event
+// can be delivered more than once. Scheduled actions are timed by cal.
+func (s *EnterpriseActionService) PerformAction(ctx context.Context, failure *models.PaymentFailureEvent, cal *calendar.Calendar, request rules.EnterpriseActionRequest) (map[string]interface{}, error) {
+	action, claimed, err := s.claimAction(ctx, failure, request)
+	if err != nil {
+		return nil, err
+	}
+	if !claimed {
+		s.logger.Info("Rule action already performed for payment failure",
+			zap.String("payment_failure_id", failure.ID.String()),
+			zap.String("action_type", request.Type),
+			zap.String("rule_id", request.RuleID))
+		return map[string]interface{}{"duplicate": true, "recovery_action_id": action.ID.String()}, nil
+	}
+
+	output, externalID, status, err := s.perform(ctx, failure, cal, request)
+	s.finishAction(ctx, failure, request, action, output, externalID, status, err)
+	if err != nil {
+		return output, fmt.Errorf("failed to perform %s: %w", request.Type, err)
+	}
+	return output, nil
+}
+
+// perform routes an action to the service that carries it out. It returns the
+// action's output, the ID of the record the service created and the status of
+// the resulting RecoveryAction. Scheduled actions are timed by the company calendar.
+func (s *EnterpriseActionService) perform(ctx context.Context, failure *models.PaymentFailureEvent, cal *calendar.Calendar, request rules.EnterpriseActionRequest) (map[string]interface{}, string, string, error) {
+	params := request.Params
+	switch request.Type {
+	case "immediate_alert", "security_alert", "business_hours_notification", "manager_escalation", "fraud_investigation":
+		channels := paramStrings(params, "channels")
+		if len(channels) == 0 {
+			channels = []string{paramString(params, "channel", "email")}
+		}
+		var alertIDs []string
+		for _, channel := range channels {
+			alert, err := s.alertService.RaiseAlert(ctx, failure, AlertRequest{
+				Channel:    channel,
+				TemplateID: request.Type,
+				Subject:    fmt.Sprintf("%s: %s", request.RuleName, failure.FailureReason),
+				Metadata: map[string]interface{}{
+					"rule_id": request.RuleID,
+					"action":  request.Type,
+					"params":  params,
+				},
+			})
+			if err != nil {
+				return map[string]interface{}{"alert_ids": alertIDs}, "", "failed", fmt.Errorf("alert on %s: %w", channel, err)
+			}
+			alertIDs = append(alertIDs, alert.ID.String())
+		}
+		return map[string]interface{}{"alert_id": alertIDs[0], "alert_ids": alertIDs, "channels": channels}, alertIDs[0], "completed", nil
+
+	case "schedule_retry":
+		delay, err := time.ParseDuration(paramString(params, "delay", "0s"))
+		if err != nil {
+			return nil, "", "failed", fmt.Errorf("invalid retry delay: %w", err)
+		}
+		notBefore := time.Now().Add(delay)
+		if spec := paramString(params, "schedule", ""); spec != "" {
+			if notBefore, err = cal.Resolve(spec, notBefore); err != nil {
+				return nil, "", "failed", fmt.Errorf("invalid retry schedule: %w", err)
+			}
+		}
+		if business, _ := params["business_hours"].(bool); business {
+			notBefore = cal.NextOpen(notBefore)
+		}
+		job, err := s.retryService.SubmitJob(ctx, "payment_retry", failure.CompanyID, map[string]interface{}{
+			"payment_failure_id": failure.ID.String(),
+			"provider":           failure.ProviderID,
+			"amount_cents":       failure.AmountCents,
+			"retry_reason":       "enterprise_rule",
+			"rule_id":            request.RuleID,
+			"not_before":         notBefore,
+		})
+		if err != nil {
+			return nil, "", "failed", err
+		}
+		return map[string]interface{}{
+			"retry_job_id": job.ID,
+			"delay":        delay.String(),
+			"not_before":   notBefore.In(cal.Location()).Format(time.RFC3339),
+		}, job.ID, "pending", nil
+
+	case "customer_contact", "customer_education", "alternative_payment":
+		return s.contactCustomer(ctx, failure, request)
+
+	case "collection_agency":
+		if err := s.recoveryService.TriggerWorkflowsForFailure(ctx, failure); err != nil {
+			return nil, "", "failed", err
+		}
+		return map[string]interface{}{"workflows_triggered": true, "trigger": params["trigger"]}, "", "completed", nil
+
+	case "start_workflow":
+		return s.startWorkflow(ctx, failure, params)
+
+	case "block_transaction":
+		err := s.db.WithContext(ctx).Model(&models.PaymentFailureEvent{}).
+			Where("id = ?", failure.ID).
+			Update("status", "blocked").Error
+		if err != nil {
+			return nil, "", "failed", err
+		}
+		return map[string]interface{}{"status": "blocked", "reason": params["reason"]}, "", "completed", nil
+	}
+	return nil, "", "failed", fmt.Errorf("unsupported enterprise action %q", request.Type)
+}
+
+// startWorkflow starts the company workflow named by the workflow_id param for
+// a payment failure. Inactive and shadow workflows are never started.
+func (s *EnterpriseActionService) startWorkflow(ctx context.Context, failure *models.PaymentFailureEvent, params map[string]interface{}) (map[string]interface{}, string, string, error) {
+	workflowID, err := uuid.Parse(paramString(params, "workflow_id", ""))
+	if err != nil {
+		return nil, "", "failed", fmt.Errorf("invalid workflow_id: %w", err)
+	}
+	companyID, err := uuid.Parse(failure.CompanyID)
+	if err != nil {
+		return nil, "", "failed", fmt.Errorf("invalid company ID %q: %w", failure.CompanyID, err)
+	}
+	workflow, err := s.recoveryService.GetWorkflow(ctx, workflowID, companyID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, "", "failed", ErrWorkflowNotFound
+	}
+	if err != nil {
+		return nil, "", "failed", err
+	}
+	if !workflow.IsActive || workflow.Shadow {
+		return nil, "", "failed", fmt.Errorf("workflow %s is not active", workflowID)
+	}
+	if err := s.recoveryService.StartWorkflowExecution(ctx, workflow, failure); err != nil {
+		return nil, "", "failed", err
+	}
+	return map[string]interface{}{"workflow_id": workflowID.String()}, workflowID.String(), "completed", nil
+}
+
+// contactCustomer sends an SMS for phone and sms contact methods and an email
+// otherwise, using the template param or else the template named after the action
+func (s *EnterpriseActionService) contactCustomer(ctx context.Context, failure *models.PaymentFailureEvent, request rules.EnterpriseActionRequest) (map[string]interface{}, string, string, error) {
+	companyID, err := uuid.Parse(failure.CompanyID)
+	if err != nil {
+		return nil, "", "failed", fmt.Errorf("invalid company ID %q: %w", failure.CompanyID, err)
+	}
+
+	variables := map[string]interface{}{
+		"customer_name":  failure.CustomerName,
+		"customer_email": failure.CustomerEmail,
+		"amount":         float64(failure.AmountCents) / 100,
+		"currency":       failure.Currency,
+		"failure_reason": failure.FailureReason,
+	}
+	for k, v := range request.Params {
+		variables[k] = v
+	}
+	req := &CommunicationRequest{
+		CompanyID:    companyID,
+		TemplateName: paramString(request.Params, "template", request.Type),
+		Variables:    variables,
+		Context: map[string]interface{}{
+			"payment_failure_id": failure.ID.String(),
+			"rule_id":            request.RuleID,
+		},
+	}
+
+	var result *CommunicationResult
+	switch method := paramString(request.Params, "method", "email"); method {
+	case "phone", "sms":
+		req.Recipient = failure.CustomerPhone
+		result, err = s.communicationService.SendSMS(ctx, req)
+	default:
+		req.Recipient = failure.CustomerEmail
+		result, err = s.communicationService.SendEmail(ctx, req)
+	}
+	if err != nil {
+		return nil, "", "failed", err
+	}
+	return map[string]interface{}{
+		"message_id": result.MessageID,
+		"provider":   result.Provider,
+		"status":     result.Status,
+	}, result.MessageID, "completed", nil
+}
+
+// ruleActionKey is the idempotency key of a rule action for a payment failure
+func ruleActionKey(failureID uuid.UUID, actionType string) string {
+	return "rule:" + failureID.String() + ":" + actionType
+}
+
+// claimAction records a rule action as pending before it is performed and
+// reports whether this call claimed it. The unique idempotency key lets one
+// claim win; an action whose earlier attempt failed can be claimed again. The
+// action is nil, and always claimed, for companies whose actions are not
+// recorded.
+func (s *EnterpriseActionService) claimAction(ctx context.Context, failure *models.PaymentFailureEvent, request rules.EnterpriseActionRequest) (*models.RecoveryAction, bool, error) {
+	companyID, err := uuid.Parse(failure.CompanyID)
+	if err != nil {
+		s.logger.Warn("Skipping recovery action for non-UUID company",
+			zap.String("company_id", failure.CompanyID),
+			zap.String("action_type", request.Type))
+		return nil, true, nil
+	}
+
+	now := time.Now()
+	action := &models.RecoveryAction{
+		ID:               uuid.New(),
+		CompanyID:        companyID,
+		PaymentFailureID: failure.ID,
+		ActionType:       request.Type,
+		Status:           "pending",
+		Provider:         failure.ProviderID,
+		IdempotencyKey:   ruleActionKey(failure.ID, request.Type),
+		ExecutedAt:       &now,
+	}
+	action.ActionData, _ = json.Marshal(map[string]interface{}{
+		"source":    "enterprise_rule",
+		"rule_id":   request.RuleID,
+		"rule_name": request.RuleName,
+		"params":    request.Params,
+	})
+
+	claim := s.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:     []clause.Column{{Name: "idempotency_key"}},
+		TargetWhere: clause.Where{Exprs: []clause.Expression{clause.Expr{SQL: "idempotency_key IS NOT NULL AND idempotency_key <> ''"}}},
+		DoNothing:   true,
+	}).Create(action)
+	if claim.Error != nil {
+		return nil, false, fmt.Errorf("failed to claim %s: %w", request.Type, claim.Error)
+	}
+	if claim.RowsAffected > 0 {
+		return action, true, nil
+	}
+
+	var existing models.RecoveryAction
+	if err := s.db.WithContext(ctx).
+		Where("idempotency_key = ?", action.IdempotencyKey).
+		First(&existing).Error; err != nil {
+		return nil, false, fmt.Errorf("failed to load claimed %s: %w", request.Type, err)
+	}
+	if existing.Status != "failed" {
+		return &existing, false, nil
+	}
+	retry := s.db.WithContext(ctx).Model(&models.RecoveryAction{}).
+		Where("id = ? AND status = ?", existing.ID, "failed").
+		Updates(map[string]interface{}{"status": "pending", "action_data": action.ActionData, "executed_at": now})
+	if retry.Error != nil {
+		return nil, false, fmt.Errorf("failed to claim %s: %w", request.Type, retry.Error)
+	}
+	return &existing, retry.RowsAffected > 0, nil
+}
+
+// finishAction stores the outcome of a claimed action. Recording errors are
+// logged so that they never mask the action's own result.
+func (s *EnterpriseActionService) finishAction(ctx context.Context, failure *models.PaymentFailureEvent, request rules.EnterpriseActionRequest, action *models.RecoveryAction, output map[string]interface{}, externalID, status string, actionErr error) {
+	if action == nil {
+		return
+	}
+
+	updates := map[string]interface{}{
+		"status":        status,
+		"external_id":   externalID,
+		"error_message": "",
+	}
+	if output != nil {
+		result, _ := json.Marshal(output)
+		updates["result"] = datatypes.JSON(result)
+	}
+	if actionErr != nil {
+		updates["error_message"] = actionErr.Error()
+	}
+	if status == "completed" {
+		updates["completed_at"] = time.Now()
+	}
+
+	if err := s.db.WithContext(ctx).Model(&models.RecoveryAction{}).
+		Where("id = ?", action.ID).
+		Updates(updates).Error; err != nil {
+		s.logger.Error("Failed to record recovery action",
+			zap.String("payment_failure_id", failure.ID.String()),
+			zap.String("action_type", request.Type),
+			zap.Error(err))
+	}
+}
+
+// paymentFailureEventFromArchitecture converts the worker pipeline's payment
+// failure into the model used by the alert, retry and communication services
+func paymentFailureEventFromArchitecture(failure *architecture.PaymentFailure) *models.PaymentFailureEvent {
+	return &models.PaymentFailureEvent{
+		ID:             failure.ID,
+		CompanyID:      failure.CompanyID,
+		ProviderID:     failure.ProviderID,
+		Provider:       failure.ProviderID,
+		EventID:        failure.ProviderEventID,
+		EventType:      failure.ProviderEventType,
+		AmountCents:    int64(math.Round(failure.Amount * 100)),
+		Currency:       failure.Currency,
+		CustomerID:     failure.CustomerID,
+		CustomerEmail:  failure.CustomerEmail,
+		CustomerName:   failure.CustomerName,
+		CustomerPhone:  failure.CustomerPhone,
+		DueDate:        failure.DueDate,
+		InvoiceNumber:  failure.InvoiceNumber,
+		Tags:           failure.Tags,
+		FailureReason:  failure.FailureReason,
+		FailureCode:    failure.FailureCode,
+		FailureMessage: failure.FailureMessage,
+		Status:         string(failure.Status),
+		CreatedAt:      failure.CreatedAt,
+	}
+}
+
+// paymentFailureFromEvent converts a recorded payment failure for the event
+// processor, which works with architecture.PaymentFailure
+func paymentFailureFromEvent(event *models.PaymentFailureEvent) *architecture.PaymentFailure {
+	failure := &architecture.PaymentFailure{
+		ID:                event.ID,
+		CompanyID:         event.CompanyID,
+		ProviderID:        event.ProviderID,
+		ProviderEventID:   event.EventID,
+		ProviderEventType: event.EventType,
+		Amount:            float64(event.AmountCents) / 100,
+		Currency:          event.Currency,
+		CustomerID:        event.CustomerID,
+		CustomerName:      event.CustomerName,
+		CustomerEmail:     event.CustomerEmail,
+		CustomerPhone:     event.CustomerPhone,
+		FailureReason:     event.FailureReason,
+		FailureCode:       event.FailureCode,
+		FailureMessage:    event.FailureMessage,
+		InvoiceNumber:     event.InvoiceNumber,
+		DueDate:           event.DueDate,
+		Status:            architecture.PaymentFailureStatus(event.Status),
+		OccurredAt:        event.CreatedAt,
+		DetectedAt:        event.WebhookReceivedAt,
+		Tags:              event.Tags,
+		CreatedAt:         event.CreatedAt,
+		UpdatedAt:         event.UpdatedAt,
+	}
+	if event.NormalizedData != "" {
+		failure.NormalizedData = json.RawMessage(event.NormalizedData)
+	}
+	return failure
+}
+
+func paramString(params map[string]interface{}, key, fallback string) string {
+	if value, ok := params[key].(string); ok && value != "" {
+		return value
+	}
+	return fallback
+}
+
+// paramStrings reads a list of strings, as built in Go or decoded from JSON
+func paramStrings(params map[string]interface{}, key string) []string {
+	switch values := params[key].(type) {
+	case []string:
+		return values
+	case []interface{}:
+		var list []string
+		for _, value := range values {
+			if s, ok := value.(string); ok && s != "" {
+				list = append(list, s)
+			}
+		}
+		return list
+	}
+	return nil
+}
@@ -0,0 +1,228 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/sambitmohanty1/payment-watchdog/api/internal/architecture"
+	"github.com/sambitmohanty1/payment-watchdog/api/internal/models"
+	"github.com/sambitmohanty1/payment-watchdog/api/internal/rules"
+)
+
+func TestPaymentFailureEventFromArchitecture(t *testing.T) {
+	due := time.Now().AddDate(0, 0, -5)
+	failure := &architecture.PaymentFailure{
+		ID:            uuid.New(),
+		CompanyID:     uuid.New().String(),
+		ProviderID:    "stripe",
+		Amount:        1234.565,
+		Currency:      "AUD",
+		CustomerEmail: "ap@example.com",
+		CustomerPhone: "+61400000000",
+		FailureReason: "card_declined",
+		DueDate:       &due,
+		Tags:          []string{"high_value"},
+	}
+
+	event := paymentFailureEventFromArchitecture(failure)
+	assert.Equal(t, failure.ID, event.ID)
+	assert.Equal(t, int64(123457), event.AmountCents, "amounts are rounded to whole cents")
+	assert.Equal(t, "stripe", event.ProviderID)
+	assert.Equal(t, "+61400000000", event.CustomerPhone)
+	assert.Equal(t, &due, event.DueDate)
+	assert.Equal(t, []string{"high_value"}, event.Tags)
+}
+
+func TestEnterpriseActionDispatchErrors(t *testing.T) {
+	service := NewEnterpriseActionService(nil, nil, nil, nil, nil, zap.NewNop())
+
+	_, err := service.Dispatch(context.Background(), rules.EnterpriseRuleContext{}, rules.EnterpriseActionRequest{Type: "immediate_alert"})
+	assert.Error(t, err, "a payment failure is required")
+
+	// Non-UUID companies skip recovery action recording, so no database is needed
+	ruleCtx := rules.EnterpriseRuleContext{PaymentFailure: &architecture.PaymentFailure{ID: uuid.New(), CompanyID: "default_company"}}
+	_, err = service.Dispatch(context.Background(), ruleCtx, rules.EnterpriseActionRequest{Type: "teleport"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `unsupported enterprise action "teleport"`)
+
+	_, err = service.Dispatch(context.Background(), ruleCtx, rules.EnterpriseActionRequest{
+		Type:   "schedule_retry",
+		Params: map[string]interface{}{"delay": "soon"},
+	})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid retry delay")
+}
+
+func TestPerformActionSkipsClaimedActions(t *testing.T) {
+	db, mock := mockDB(t)
+	failure := &models.PaymentFailureEvent{ID: uuid.New(), CompanyID: uuid.New().String(), ProviderID: "stripe"}
+	existing := uuid.New()
+
+	// The company rules already sent this alert for the failure
+	mock.ExpectQuery(`INSERT INTO "recovery_actions" .* ON CONFLICT \("idempotency_key"\) +WHERE .* DO NOTHING`).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectQuery(`SELECT \* FROM "recovery_actions" WHERE idempotency_key = \$1`).
+		WithArgs(ruleActionKey(failure.ID, "immediate_alert"), 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "status"}).AddRow(existing, "completed"))
+
+	result, err := actionService(db).PerformAction(context.Background(), failure, nil, rules.EnterpriseActionRequest{
+		Type:   "immediate_alert",
+		Params: map[string]interface{}{"channel": "email"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, true, result["duplicate"])
+	assert.Equal(t, existing.String(), result["recovery_action_id"])
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPerformActionRetriesFailedActions(t *testing.T) {
+	db, mock := mockDB(t)
+	failure := &models.PaymentFailureEvent{ID: uuid.New(), CompanyID: uuid.New().String(), ProviderID: "stripe"}
+	existing := uuid.New()
+
+	mock.ExpectQuery(`INSERT INTO "recovery_actions" .* ON CONFLICT \("idempotency_key"\) +WHERE .* DO NOTHING`).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectQuery(`SELECT \* FROM "recovery_actions" WHERE idempotency_key = \$1`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "status"}).AddRow(existing, "failed"))
+	mock.ExpectExec(`UPDATE "recovery_actions" SET .* WHERE id = \$\d+ AND status = \$\d+`).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(`INSERT INTO "customer_communications"`).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(uuid.New()))
+	mock.ExpectExec(`UPDATE "customer_communications" SET .*"status"=`).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	expectRecoveryAction(mock, "completed")
+
+	result, err := actionService(db).PerformAction(context.Background(), failure, nil, rules.EnterpriseActionRequest{
+		Type:   "immediate_alert",
+		Params: map[string]interface{}{"channel": "email"},
+	})
+	require.NoError(t, err)
+	assert.Nil(t, result["duplicate"])
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAlertActionsRaiseOnEveryChannel(t *testing.T) {
+	db, mock := mockDB(t)
+	failure := &models.PaymentFailureEvent{ID: uuid.New(), CompanyID: uuid.New().String(), ProviderID: "stripe"}
+
+	expectClaimedAction(mock, failure.ID, "immediate_alert")
+	for range []string{"email", "slack"} {
+		mock.ExpectQuery(`INSERT INTO "customer_communications"`).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(uuid.New()))
+		mock.ExpectExec(`UPDATE "customer_communications" SET .*"status"=`).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+	}
+	expectRecoveryAction(mock, "completed")
+
+	result, err := actionService(db).PerformAction(context.Background(), failure, nil, rules.EnterpriseActionRequest{
+		Type:   "immediate_alert",
+		Params: map[string]interface{}{"channels": []interface{}{"email", "slack"}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"email", "slack"}, result["channels"])
+	assert.Len(t, result["alert_ids"], 2)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// matchAll is an enterprise condition every payment failure meets
+type matchAll struct{}
+
+func (matchAll) Evaluate(rules.EnterpriseRuleContext) bool { return true }
+func (matchAll) GetType() string                           { return "match_all" }
+func (matchAll) GetDescription() string                    { return "every payment failure" }
+
+// dispatchedAction is an enterprise action performed by the engine's dispatcher
+type dispatchedAction struct {
+	actionType string
+	params     map[string]interface{}
+}
+
+func (a dispatchedAction) Execute(rules.EnterpriseRuleContext) error { return nil }
+func (a dispatchedAction) GetType() string                           { return a.actionType }
+func (a dispatchedAction) GetDescription() string                    { return a.actionType }
+func (a dispatchedAction) GetPriority() int                          { return 1 }
+func (a dispatchedAction) ActionParams() map[string]interface{}      { return a.params }
+
+// publishedTopics records the topics the event processor publishes to
+type publishedTopics struct {
+	architecture.EventBus
+	topics []string
+}
+
+func (p *publishedTopics) Publish(ctx context.Context, topic string, event interface{}) error {
+	p.topics = append(p.topics, topic)
+	return nil
+}
+
+func TestEventProcessorPerformsEnterpriseActions(t *testing.T) {
+	db, mock := mockDB(t)
+	companyID := uuid.New()
+	recorded := &models.PaymentFailureEvent{
+		ID:            uuid.New(),
+		CompanyID:     companyID.String(),
+		ProviderID:    "stripe",
+		EventID:       "evt_1",
+		EventType:     "payment_intent.payment_failed",
+		AmountCents:   4250,
+		Currency:      "AUD",
+		CustomerName:  "Ada",
+		CustomerEmail: "ada@example.com",
+		FailureReason: "card_declined",
+		Status:        "received",
+	}
+
+	engine := rules.NewEnterpriseRuleEngine(zap.NewNop())
+	engine.SetActionDispatcher(actionService(db))
+	require.NoError(t, engine.AddRule(rules.EnterpriseRule{
+		ID:         "declined",
+		Name:       "Declined payments",
+		Enabled:    true,
+		Conditions: []rules.EnterpriseCondition{matchAll{}},
+		Actions: []rules.EnterpriseAction{
+			dispatchedAction{"immediate_alert", map[string]interface{}{"channel": "email", "severity": "high"}},
+			dispatchedAction{"schedule_retry", map[string]interface{}{"delay": "1h"}},
+			dispatchedAction{"customer_contact", map[string]interface{}{"method": "email", "template": "declined"}},
+		},
+	}))
+	bus := &publishedTopics{}
+	processor := eventProcessor(db, bus)
+	processor.SetEnterpriseRuleEngine(engine, scriptService(db))
+
+	mock.ExpectQuery(`SELECT \* FROM "payment_failure_events" WHERE id = \$1`).
+		WithArgs(recorded.ID, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "company_id", "provider_id", "amount_cents", "customer_email", "failure_reason", "status"}).
+			AddRow(recorded.ID, recorded.CompanyID, "stripe", 4250, "ada@example.com", "card_declined", "received"))
+	mock.ExpectQuery(`SELECT "id","timezone","business_hours" FROM "companies"`).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectQuery(`SELECT \* FROM "company_holidays"`).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectQuery(`SELECT \* FROM "company_scripts"`).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	expectPerformedActions(mock, companyID, recorded.ID)
+	mock.ExpectQuery(`INSERT INTO "rule_evaluation_traces"`).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(uuid.New()))
+	mock.ExpectExec(`UPDATE "payment_failure_events" SET "processed_at"=\$1,"status"=\$2,"updated_at"=\$3 WHERE id = \$4 AND status = \$5`).
+		WithArgs(sqlmock.AnyArg(), "analyzed", sqlmock.AnyArg(), recorded.ID, "received").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	// The event arrives from the bus as the JSON the API published
+	raw, err := json.Marshal(map[string]interface{}{
+		"event_type":      "payment.failure.detected",
+		"payment_failure": paymentFailureFromEvent(recorded),
+	})
+	require.NoError(t, err)
+	var event map[string]interface{}
+	require.NoError(t, json.Unmarshal(raw, &event))
+
+	require.NoError(t, processor.handlePaymentFailureEvent(context.Background(), event))
+	require.NoError(t, mock.ExpectationsWereMet())
+	assert.Equal(t, []string{"payment.failure.processed"}, bus.topics)
+}
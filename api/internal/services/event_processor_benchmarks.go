@@ -22,7 +22,7 @@ func BenchmarkEventProcessorService(b *testing.B) {
 	}
 
 	// Create event processor service
-	service := NewEventProcessorService(nil, nil, testEventBus, logger)
+	service := NewEventProcessorService(nil, nil, testEventBus, nil, nil, nil, nil, logger)
 
 	// Benchmark data preparation
 	benchmarkData := prepareBenchmarkData()
@@ -64,7 +64,7 @@ func BenchmarkEventProcessorLatency(b *testing.B) {
 	logger, _ := zap.NewDevelopment()
 	defer logger.Sync()
 
-	service := NewEventProcessorService(nil, nil, nil, logger)
+	service := NewEventProcessorService(nil, nil, nil, nil, nil, nil, nil, logger)
 
 	b.Run("LowRiskEventLatency", func(b *testing.B) {
 		b.ResetTimer()
@@ -105,7 +105,7 @@ func BenchmarkEventProcessorThroughput(b *testing.B) {
 	logger, _ := zap.NewDevelopment()
 	defer logger.Sync()
 
-	service := NewEventProcessorService(nil, nil, nil, logger)
+	service := NewEventProcessorService(nil, nil, nil, nil, nil, nil, nil, logger)
 	benchmarkData := prepareBenchmarkData()
 
 	b.Run("Throughput100Events", func(b *testing.B) {
@@ -142,7 +142,7 @@ func BenchmarkEventProcessorMemory(b *testing.B) {
 	logger, _ := zap.NewDevelopment()
 	defer logger.Sync()
 
-	service := NewEventProcessorService(nil, nil, nil, logger)
+	service := NewEventProcessorService(nil, nil, nil, nil, nil, nil, nil, logger)
 	benchmarkData := prepareBenchmarkData()
 
 	b.Run("MemoryAllocation", func(b *testing.B) {
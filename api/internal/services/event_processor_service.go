@@ -2,6 +2,7 @@ package services
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"time"
 
@@ -10,15 +11,22 @@ import (
 	"gorm.io/gorm"
 
 	"github.com/sambitmohanty1/payment-watchdog/api/internal/architecture"
+	"github.com/sambitmohanty1/payment-watchdog/api/internal/models"
 	"github.com/sambitmohanty1/payment-watchdog/api/internal/rules"
 )
 
 // EventProcessorService processes payment failure events and applies business intelligence
 type EventProcessorService struct {
-	db         *gorm.DB
-	ruleEngine *rules.RuleEngine
-	eventBus   architecture.EventBus
-	logger     *zap.Logger
+	db               *gorm.DB
+	ruleEngine       *rules.RuleEngine
+	enterpriseEngine *rules.EnterpriseRuleEngine
+	ruleService      *RuleEngineService
+	decisions        *DecisionLogService
+	calendars        *BusinessCalendarService
+	segments         *CustomerSegmentService
+	identities       *CustomerIdentityService
+	eventBus         architecture.EventBus
+	logger           *zap.Logger
 
 	// Processing metrics
 	metrics *EventProcessorMetrics
@@ -52,7 +60,16 @@ type ProcessingError struct {
 }
 
 // NewEventProcessorService creates a new event processor service
-func NewEventProcessorService(db *gorm.DB, ruleEngine *rules.RuleEngine, eventBus architecture.EventBus, logger *zap.Logger) *EventProcessorService {
+func NewEventProcessorService(
+	db *gorm.DB,
+	ruleEngine *rules.RuleEngine,
+	eventBus architecture.EventBus,
+	identities *CustomerIdentityService,
+	decisions *DecisionLogService,
+	calendars *BusinessCalendarService,
+	segments *CustomerSegmentService,
+	logger *zap.Logger,
+) *EventProcessorService {
 	metrics := &EventProcessorMetrics{
 		EventsByProvider: make(map[string]int64),
 		EventsByStatus:   make(map[string]int64),
@@ -62,6 +79,10 @@ func NewEventProcessorService(db *gorm.DB, ruleEngine *rules.RuleEngine, eventBu
 	return &EventProcessorService{
 		db:         db,
 		ruleEngine: ruleEngine,
+		decisions:  decisions,
+		calendars:  calendars,
+		segments:   segments,
+		identities: identities,
 		eventBus:   eventBus,
 		logger:     logger,
 		metrics:    metrics,
@@ -70,6 +91,23 @@ func NewEventProcessorService(db *gorm.DB, ruleEngine *rules.RuleEngine, eventBu
 	}
 }
 
+// SetEnterpriseRuleEngine sets the enterprise rule engine run for every event.
+// Its actions are performed by whatever dispatcher the engine was given, usually
+// an EnterpriseActionService. The company's scripts are added to the engine's
+// rules when scripts is not nil.
+func (e *EventProcessorService) SetEnterpriseRuleEngine(engine *rules.EnterpriseRuleEngine, scripts *CompanyScriptService) {
+	if engine != nil && scripts != nil {
+		engine.SetRuleProvider(scripts)
+	}
+	e.enterpriseEngine = engine
+}
+
+// SetRuleEngineService runs the built-in rules and the company's published rule
+// definitions, including shadow rules, on every event the API recorded
+func (e *EventProcessorService) SetRuleEngineService(ruleService *RuleEngineService) {
+	e.ruleService = ruleService
+}
+
 // ProcessPaymentFailureEvent processes a payment failure event through the complete pipeline
 func (e *EventProcessorService) ProcessPaymentFailureEvent(ctx context.Context, event map[string]interface{}) error {
 	startTime := time.Now()
@@ -81,9 +119,9 @@ func (e *EventProcessorService) ProcessPaymentFailureEvent(ctx context.Context,
 	}
 
 	// Convert to PaymentFailure struct
-	paymentFailure, ok := paymentFailureData.(*architecture.PaymentFailure)
-	if !ok {
-		return fmt.Errorf("invalid payment failure data type")
+	paymentFailure, err := decodePaymentFailure(paymentFailureData)
+	if err != nil {
+		return err
 	}
 
 	e.logger.Info("Processing payment failure event",
@@ -128,6 +166,26 @@ func (e *EventProcessorService) ProcessPaymentFailureEvent(ctx context.Context,
 	return nil
 }
 
+// decodePaymentFailure returns the payment failure of an event. Events read from
+// the event bus carry it decoded from JSON as a map.
+func decodePaymentFailure(data interface{}) (*architecture.PaymentFailure, error) {
+	switch failure := data.(type) {
+	case *architecture.PaymentFailure:
+		return failure, nil
+	case map[string]interface{}:
+		raw, err := json.Marshal(failure)
+		if err != nil {
+			return nil, fmt.Errorf("invalid payment failure data: %w", err)
+		}
+		var decoded architecture.PaymentFailure
+		if err := json.Unmarshal(raw, &decoded); err != nil {
+			return nil, fmt.Errorf("invalid payment failure data: %w", err)
+		}
+		return &decoded, nil
+	}
+	return nil, fmt.Errorf("invalid payment failure data type %T", data)
+}
+
 // processEventPipeline processes the event through all pipeline stages
 func (e *EventProcessorService) processEventPipeline(ctx context.Context, failure *architecture.PaymentFailure) error {
 	// Stage 1: Enrich with additional data
@@ -273,102 +331,170 @@ func (e *EventProcessorService) mapRiskScoreToPriority(riskScore float64) archit
 	}
 }
 
-// executeBusinessRules executes business rules on the payment failure
-func (e *EventProcessorService) executeBusinessRules(ctx context.Context, failure *architecture.PaymentFailure) error {
-	e.logger.Debug("Executing business rules",
-		zap.String("event_id", failure.ID.String()))
-
-	// TODO: Convert architecture.PaymentFailure to models.PaymentFailureEvent
-	// For now, we'll skip rule execution until the models are aligned
-	// results := e.ruleEngine.ExecuteRules(ruleContext)
+// enterpriseCompany returns the company context for enterprise rules, carrying the
+// company's business calendar so time-based rules use its timezone and holidays
+func (e *EventProcessorService) enterpriseCompany(ctx context.Context, companyID string) *rules.EnterpriseCompany {
+	company := &rules.EnterpriseCompany{ID: companyID}
+	if e.db == nil {
+		return company
+	}
+	cal, err := e.calendars.Calendar(ctx, companyID)
+	if err != nil {
+		e.logger.Warn("Failed to load business calendar, using defaults",
+			zap.String("company_id", companyID),
+			zap.Error(err))
+		return company
+	}
+	company.Calendar = cal
+	return company
+}
 
-	// Placeholder for rule execution results
-	var results []*rules.ActionResult
+// recordedPaymentFailure loads the payment failure the API recorded, or nil
+// when it was never recorded
+func (e *EventProcessorService) recordedPaymentFailure(ctx context.Context, id uuid.UUID) (*models.PaymentFailureEvent, error) {
+	var event models.PaymentFailureEvent
+	if err := e.db.WithContext(ctx).Where("id = ?", id).Limit(1).Find(&event).Error; err != nil {
+		return nil, fmt.Errorf("failed to load recorded payment failure: %w", err)
+	}
+	if event.ID == uuid.Nil {
+		return nil, nil
+	}
+	return &event, nil
+}
 
-	// Process rule results
-	for _, result := range results {
-		if !result.Success {
-			e.logger.Warn("Rule execution failed",
-				zap.String("rule_name", result.RuleName),
-				zap.Error(result.Error))
-			continue
+// paymentFailureEvent returns the failure as the API recorded it, and whether
+// it was recorded. Failures that were not are converted instead.
+func (e *EventProcessorService) paymentFailureEvent(ctx context.Context, failure *architecture.PaymentFailure) (*models.PaymentFailureEvent, bool, error) {
+	if e.db != nil {
+		event, err := e.recordedPaymentFailure(ctx, failure.ID)
+		if err != nil {
+			return nil, false, err
 		}
-
-		e.logger.Info("Rule executed successfully",
-			zap.String("rule_name", result.RuleName),
-			zap.String("message", result.Message))
-
-		// Handle specific rule results
-		if err := e.handleRuleResult(ctx, failure, result); err != nil {
-			e.logger.Error("Failed to handle rule result",
-				zap.String("rule_name", result.RuleName),
-				zap.Error(err))
+		if event != nil {
+			return event, true, nil
 		}
 	}
 
-	return nil
+	// Segments date the event by when the failure occurred
+	event := paymentFailureEventFromArchitecture(failure)
+	event.NormalizedData = string(failure.NormalizedData)
+	event.CreatedAt = failure.OccurredAt
+	return event, false, nil
 }
 
-// handleRuleResult processes the result of a specific rule execution
-func (e *EventProcessorService) handleRuleResult(ctx context.Context, failure *architecture.PaymentFailure, result *rules.ActionResult) error {
-	switch result.RuleName {
-	case "high_risk_alert":
-		return e.createHighRiskAlert(ctx, failure)
-	case "retry_payment":
-		return e.scheduleRetry(ctx, failure)
-	case "customer_communication":
-		return e.scheduleCustomerCommunication(ctx, failure)
-	case "escalate_to_manager":
-		return e.escalateToManager(ctx, failure)
-	default:
-		e.logger.Warn("Unknown rule result", zap.String("rule_name", result.RuleName))
+// customerSegments records the event in its customer's segment profile and
+// returns the customer's segments. Errors are logged and yield no segments.
+func (e *EventProcessorService) customerSegments(ctx context.Context, event *models.PaymentFailureEvent) []string {
+	if e.db == nil || event.CustomerID == "" {
+		return nil
+	}
+	segments, err := e.segments.ApplyEvent(ctx, event)
+	if err != nil {
+		e.logger.Warn("Failed to update customer segments",
+			zap.String("event_id", event.ID.String()),
+			zap.String("customer_id", event.CustomerID),
+			zap.Error(err))
 		return nil
 	}
+	return segments
 }
 
-// createHighRiskAlert creates a high-risk alert for critical payment failures
-func (e *EventProcessorService) createHighRiskAlert(ctx context.Context, failure *architecture.PaymentFailure) error {
-	e.logger.Info("Creating high-risk alert",
-		zap.String("event_id", failure.ID.String()),
-		zap.Float64("amount", failure.Amount),
-		zap.Float64("risk_score", failure.RiskScore))
-
-	// TODO: Implement alert creation logic
-	// This would typically create an alert record in the database
-	// and potentially trigger notifications
-
-	return nil
+// resolveIdentity links the customer of a recorded failure to its cross-provider
+// identity, so failures that start no workflow are resolved as well
+func (e *EventProcessorService) resolveIdentity(ctx context.Context, event *models.PaymentFailureEvent, recorded bool) {
+	if !recorded || event.CustomerID == "" {
+		return
+	}
+	if _, err := e.identities.ResolveFromFailure(ctx, event); err != nil {
+		e.logger.Warn("Failed to resolve customer identity",
+			zap.String("event_id", event.ID.String()),
+			zap.String("customer_id", event.CustomerID),
+			zap.Error(err))
+	}
 }
 
-// scheduleRetry schedules a payment retry for the failure
-func (e *EventProcessorService) scheduleRetry(ctx context.Context, failure *architecture.PaymentFailure) error {
-	e.logger.Info("Scheduling payment retry",
+// executeBusinessRules executes business rules on the payment failure. The
+// customer's identity is resolved and the event is applied to the customer's
+// segments once, before any rule runs, and
+// every rule sees the resulting segments. Both engines act through the
+// enterprise action service, so an action both call for is performed once.
+func (e *EventProcessorService) executeBusinessRules(ctx context.Context, failure *architecture.PaymentFailure) error {
+	e.logger.Debug("Executing business rules",
 		zap.String("event_id", failure.ID.String()))
 
-	// TODO: Implement retry scheduling logic
-	// This would typically create a retry record and schedule it
-
-	return nil
-}
+	if e.enterpriseEngine == nil && e.ruleService == nil {
+		e.logger.Debug("No rule engine configured, skipping rule execution")
+		return nil
+	}
 
-// scheduleCustomerCommunication schedules customer communication
-func (e *EventProcessorService) scheduleCustomerCommunication(ctx context.Context, failure *architecture.PaymentFailure) error {
-	e.logger.Info("Scheduling customer communication",
-		zap.String("event_id", failure.ID.String()))
+	event, recorded, err := e.paymentFailureEvent(ctx, failure)
+	if err != nil {
+		return err
+	}
+	e.resolveIdentity(ctx, event, recorded)
+	segments := e.customerSegments(ctx, event)
 
-	// TODO: Implement customer communication scheduling
-	// This would typically create a communication record
+	if e.enterpriseEngine != nil {
+		if err := e.executeEnterpriseRules(ctx, failure, segments); err != nil {
+			return err
+		}
+	}
 
+	// Company rules update the recorded failure, so failures the API never
+	// recorded are left to the enterprise rules
+	if e.ruleService != nil {
+		if !recorded {
+			e.logger.Debug("Payment failure not recorded, skipping company rules",
+				zap.String("event_id", failure.ID.String()))
+			return nil
+		}
+		if err := e.ruleService.evaluate(ctx, event); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
-// escalateToManager escalates the failure to a manager
-func (e *EventProcessorService) escalateToManager(ctx context.Context, failure *architecture.PaymentFailure) error {
-	e.logger.Info("Escalating to manager",
-		zap.String("event_id", failure.ID.String()))
+// executeEnterpriseRules runs the enterprise engine, whose dispatcher performs
+// the actions of triggered rules, and records how each rule was evaluated
+func (e *EventProcessorService) executeEnterpriseRules(ctx context.Context, failure *architecture.PaymentFailure, segments []string) error {
+	results, err := e.enterpriseEngine.ExecuteRulesContext(ctx, rules.EnterpriseRuleContext{
+		PaymentFailure:   failure,
+		Company:          e.enterpriseCompany(ctx, failure.CompanyID),
+		CustomerSegments: segments,
+		Timestamp:        time.Now(),
+		Environment:      "worker",
+	})
+	if err != nil {
+		return err
+	}
 
-	// TODO: Implement escalation logic
-	// This would typically create an escalation record
+	// Shadow results carry no executed actions; they are only traced
+	for _, result := range results {
+		if !result.Triggered || result.Shadow {
+			continue
+		}
+		for _, action := range result.Actions {
+			if action.Error != nil {
+				e.logger.Warn("Enterprise rule action failed",
+					zap.String("rule_id", result.RuleID),
+					zap.String("action_type", action.ActionType),
+					zap.Error(action.Error))
+				continue
+			}
+			e.logger.Info("Enterprise rule action executed",
+				zap.String("rule_id", result.RuleID),
+				zap.String("action_type", action.ActionType))
+		}
+	}
+
+	if e.db != nil {
+		if err := e.decisions.RecordEnterpriseTraces(ctx, failure.CompanyID, failure.ID, results); err != nil {
+			e.logger.Error("Failed to record enterprise rule traces",
+				zap.String("event_id", failure.ID.String()),
+				zap.Error(err))
+		}
+	}
 
 	return nil
 }
@@ -384,9 +510,14 @@ func (e *EventProcessorService) updateEventStatus(ctx context.Context, failure *
 	*failure.ProcessedAt = time.Now()
 	failure.UpdatedAt = time.Now()
 
-	// Save to database
+	// Mark the recorded failure analyzed, unless company rules already moved it on
 	if e.db != nil {
-		if err := e.db.Save(failure).Error; err != nil {
+		if err := e.db.WithContext(ctx).Model(&models.PaymentFailureEvent{}).
+			Where("id = ? AND status = ?", failure.ID, architecture.PaymentFailureStatusReceived).
+			Updates(map[string]interface{}{
+				"status":       string(failure.Status),
+				"processed_at": *failure.ProcessedAt,
+			}).Error; err != nil {
 			return fmt.Errorf("failed to save failure status: %w", err)
 		}
 		e.logger.Debug("Payment failure status saved to database",
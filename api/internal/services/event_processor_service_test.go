@@ -85,7 +85,7 @@ func TestEventProcessorService(t *testing.T) {
 	ruleEngine := rules.NewRuleEngine(logger)
 
 	// Create event processor service
-	service := NewEventProcessorService(nil, ruleEngine, testEventBus, logger)
+	service := NewEventProcessorService(nil, ruleEngine, testEventBus, nil, nil, nil, nil, logger)
 
 	t.Run("Service Creation", func(t *testing.T) {
 		assert.NotNil(t, service)
@@ -380,7 +380,7 @@ func TestEventProcessorPipelineStages(t *testing.T) {
 	logger, _ := zap.NewDevelopment()
 	defer logger.Sync()
 
-	service := NewEventProcessorService(nil, nil, nil, logger)
+	service := NewEventProcessorService(nil, nil, nil, nil, nil, nil, nil, logger)
 
 	t.Run("Data Enrichment", func(t *testing.T) {
 		failure := &architecture.PaymentFailure{
@@ -0,0 +1,198 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/sambitmohanty1/payment-watchdog/api/internal/models"
+)
+
+const (
+	// defaultCompanyExecutionLimit caps how many executions one company runs at
+	// once across all workers, unless the company sets its own limit
+	defaultCompanyExecutionLimit = 3
+	// executionHeartbeatInterval is how often a running execution renews its lease
+	executionHeartbeatInterval = executionLeaseTTL / 4
+)
+
+// errCompanyAtLimit means the company already runs as many executions as it may
+var errCompanyAtLimit = errors.New("company is at its concurrent execution limit")
+
+// runnableStatuses are the statuses of executions waiting for, or held by, a worker
+var runnableStatuses = []string{"pending", "running"}
+
+// StartExecutionWorkers runs queued workflow executions on up to workers
+// goroutines until ctx is cancelled. Executions are shared through Postgres, so
+// every replica running workers, API or worker binary alike, takes from the
+// same queue; expired leases are taken over. companyLimit is the default cap on
+// a single company's share of all workers, 0 keeping the current default. A
+// replica that starts no workers only queues executions.
+func (r *RecoveryOrchestrationService) StartExecutionWorkers(ctx context.Context, workers, companyLimit int, pollInterval time.Duration) {
+	r.mu.Lock()
+	r.executionWorkers = workers
+	r.workerPool = make(chan struct{}, workers)
+	if companyLimit > 0 {
+		r.companyLimit = companyLimit
+	}
+	r.mu.Unlock()
+
+	go func() {
+		ticker := time.NewTicker(pollInterval)
+		defer ticker.Stop()
+
+		for {
+			r.dispatch(ctx)
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			case <-r.wake:
+			}
+		}
+	}()
+}
+
+// wakeWorkers tells this replica's workers that an execution was queued,
+// without waiting for their next poll
+func (r *RecoveryOrchestrationService) wakeWorkers() {
+	select {
+	case r.wake <- struct{}{}:
+	default:
+	}
+}
+
+// dispatch claims queued executions while worker slots are free and runs each
+// on its own goroutine. It returns how many it started.
+func (r *RecoveryOrchestrationService) dispatch(ctx context.Context) int {
+	var saturated []uuid.UUID
+	started := 0
+	for ctx.Err() == nil {
+		select {
+		case r.workerPool <- struct{}{}:
+		default:
+			return started
+		}
+
+		executionID, err := r.claimExecution(ctx, &saturated)
+		if err != nil || executionID == uuid.Nil {
+			<-r.workerPool
+			if err != nil {
+				r.logger.Error("Failed to claim workflow execution", zap.Error(err))
+			}
+			return started
+		}
+
+		started++
+		go func(executionID uuid.UUID) {
+			defer func() { <-r.workerPool }()
+			if err := r.runClaimed(ctx, executionID); err != nil {
+				r.logger.Error("Failed to run workflow execution",
+					zap.String("execution_id", executionID.String()),
+					zap.Error(err))
+			}
+		}(executionID)
+	}
+	return started
+}
+
+// claimExecution leases the oldest runnable execution no other worker holds,
+// skipping companies at their concurrency limit. Companies found saturated are
+// added to saturated so later claims in the same pass skip them outright. It
+// returns uuid.Nil when nothing can be claimed.
+func (r *RecoveryOrchestrationService) claimExecution(ctx context.Context, saturated *[]uuid.UUID) (uuid.UUID, error) {
+	for {
+		var claimed uuid.UUID
+		err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			now := time.Now()
+
+			query := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+				Select("id", "company_id").
+				Where("status IN ? AND (lease_expires_at IS NULL OR lease_expires_at < ?)", runnableStatuses, now)
+			if len(*saturated) > 0 {
+				query = query.Where("company_id NOT IN ?", *saturated)
+			}
+			var candidates []models.RecoveryWorkflowExecution
+			if err := query.Order("created_at").Limit(1).Find(&candidates).Error; err != nil {
+				return fmt.Errorf("failed to find queued executions: %w", err)
+			}
+			if len(candidates) == 0 {
+				return nil
+			}
+			candidate := candidates[0]
+
+			// Serialize claims per company so the limit holds across replicas
+			if err := tx.Exec("SELECT pg_advisory_xact_lock(hashtext(?))", candidate.CompanyID.String()).Error; err != nil {
+				return fmt.Errorf("failed to lock company executions: %w", err)
+			}
+			var running int64
+			if err := tx.Model(&models.RecoveryWorkflowExecution{}).
+				Where("company_id = ? AND status IN ? AND lease_expires_at >= ?", candidate.CompanyID, runnableStatuses, now).
+				Count(&running).Error; err != nil {
+				return fmt.Errorf("failed to count running executions: %w", err)
+			}
+			if running >= int64(r.companyExecutionLimit(tx, candidate.CompanyID)) {
+				*saturated = append(*saturated, candidate.CompanyID)
+				return errCompanyAtLimit
+			}
+
+			if err := tx.Model(&models.RecoveryWorkflowExecution{}).
+				Where("id = ?", candidate.ID).
+				Updates(r.newLease(now)).Error; err != nil {
+				return fmt.Errorf("failed to lease execution: %w", err)
+			}
+			claimed = candidate.ID
+			return nil
+		})
+		if errors.Is(err, errCompanyAtLimit) {
+			continue
+		}
+		return claimed, err
+	}
+}
+
+// companyExecutionLimit is how many executions the company may run at once
+func (r *RecoveryOrchestrationService) companyExecutionLimit(tx *gorm.DB, companyID uuid.UUID) int {
+	var limits []int
+	if err := tx.Model(&models.Company{}).
+		Where("id = ?", companyID).
+		Pluck("max_concurrent_executions", &limits).Error; err != nil || len(limits) == 0 || limits[0] <= 0 {
+		return r.companyLimit
+	}
+	return limits[0]
+}
+
+// heartbeat renews the execution's lease until ctx is done. Losing the lease
+// stops the run, since another worker has taken the execution over.
+func (r *RecoveryOrchestrationService) heartbeat(ctx context.Context, execution *WorkflowExecution) {
+	ticker := time.NewTicker(executionHeartbeatInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			err := r.updateLeased(ctx, execution, map[string]interface{}{})
+			if errors.Is(err, errExecutionLeaseLost) {
+				r.logger.Warn("Workflow execution lease lost, stopping",
+					zap.String("execution_id", execution.ID.String()))
+				if execution.CancelFunc != nil {
+					execution.CancelFunc()
+				}
+				return
+			}
+			if err != nil && ctx.Err() == nil {
+				r.logger.Error("Failed to renew workflow execution lease",
+					zap.String("execution_id", execution.ID.String()),
+					zap.Error(err))
+			}
+		}
+	}
+}
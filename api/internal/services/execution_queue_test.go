@@ -0,0 +1,87 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestClaimExecutionSkipsCompaniesAtTheirLimit(t *testing.T) {
+	db, mock := mockDB(t)
+	service := &RecoveryOrchestrationService{db: db, logger: zap.NewNop(), companyLimit: 3, instanceID: "worker-a"}
+	noisy, quiet := uuid.New(), uuid.New()
+	flooded, waiting := uuid.New(), uuid.New()
+
+	queued := `SELECT "id","company_id" FROM "recovery_workflow_executions" WHERE status IN \(\$1,\$2\) AND \(lease_expires_at IS NULL OR lease_expires_at < \$3\)`
+	count := `SELECT count\(\*\) FROM "recovery_workflow_executions" WHERE company_id = \$1`
+	limit := `SELECT "max_concurrent_executions" FROM "companies" WHERE id = \$1`
+
+	// The oldest execution belongs to a company already running its default three
+	mock.ExpectBegin()
+	mock.ExpectQuery(queued + ` ORDER BY created_at LIMIT \$4 FOR UPDATE SKIP LOCKED`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "company_id"}).AddRow(flooded, noisy))
+	mock.ExpectExec(`SELECT pg_advisory_xact_lock\(hashtext\(\$1\)\)`).
+		WithArgs(noisy.String()).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(count).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(3))
+	mock.ExpectQuery(limit).
+		WithArgs(noisy).
+		WillReturnRows(sqlmock.NewRows([]string{"max_concurrent_executions"}).AddRow(0))
+	mock.ExpectRollback()
+
+	// The next claim leaves that company out
+	mock.ExpectBegin()
+	mock.ExpectQuery(`WHERE \(status IN .*\) AND company_id NOT IN \(\$4\) ORDER BY created_at LIMIT \$5 FOR UPDATE SKIP LOCKED`).
+		WithArgs("pending", "running", sqlmock.AnyArg(), noisy, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "company_id"}).AddRow(waiting, quiet))
+	mock.ExpectExec(`SELECT pg_advisory_xact_lock`).
+		WithArgs(quiet.String()).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(count).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(4))
+	mock.ExpectQuery(limit).
+		WithArgs(quiet).
+		WillReturnRows(sqlmock.NewRows([]string{"max_concurrent_executions"}).AddRow(10))
+	mock.ExpectExec(`UPDATE "recovery_workflow_executions" SET "heartbeat_at"=\$1,"lease_expires_at"=\$2,"lease_owner"=\$3,"updated_at"=\$4 WHERE id = \$5`).
+		WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), waiting).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	var saturated []uuid.UUID
+	claimed, err := service.claimExecution(context.Background(), &saturated)
+	require.NoError(t, err)
+	assert.Equal(t, waiting, claimed)
+	assert.Equal(t, []uuid.UUID{noisy}, saturated)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestClaimExecutionWithEmptyQueue(t *testing.T) {
+	db, mock := mockDB(t)
+	service := &RecoveryOrchestrationService{db: db, logger: zap.NewNop(), companyLimit: 3}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`FOR UPDATE SKIP LOCKED`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "company_id"}))
+	mock.ExpectCommit()
+
+	var saturated []uuid.UUID
+	claimed, err := service.claimExecution(context.Background(), &saturated)
+	require.NoError(t, err)
+	assert.Equal(t, uuid.Nil, claimed)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestDispatchLeavesQueueAloneWhenWorkersAreBusy(t *testing.T) {
+	db, mock := mockDB(t)
+	service := &RecoveryOrchestrationService{db: db, logger: zap.NewNop(), workerPool: make(chan struct{}, 1)}
+	service.workerPool <- struct{}{}
+
+	assert.Equal(t, 0, service.dispatch(context.Background()))
+	assert.NoError(t, mock.ExpectationsWereMet(), "no claim is attempted without a free worker")
+}
@@ -0,0 +1,660 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sambitmohanty1/payment-watchdog/api/internal/models"
+	"go.uber.org/zap"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+const (
+	// SyncExportRowLimit is the largest export streamed directly in the request
+	SyncExportRowLimit = 50000
+
+	exportFetchSize        = 1000
+	exportProgressInterval = 5000
+	exportTokenTTL         = 24 * time.Hour
+
+	// exportJobBatch caps how many due jobs one poll claims
+	exportJobBatch = 10
+	// exportClaimLease is how long a claimed job stays with its worker. Progress
+	// extends it; a job whose worker stopped is claimed again once it runs out.
+	exportClaimLease = 5 * time.Minute
+	// maxExportAttempts is how often a job is run before it is marked failed
+	maxExportAttempts = 3
+	// exportCleanupInterval is how often expired export files are deleted
+	exportCleanupInterval = time.Hour
+)
+
+// exportChunkSize is the size of the pieces an export file is stored in
+var exportChunkSize = 1 << 20
+
+// ErrExportNotFound is returned for unknown export jobs or expired download tokens
+var ErrExportNotFound = errors.New("export not found")
+
+// ExportRequest describes what to export
+type ExportRequest struct {
+	CompanyID string                `json:"company_id"`
+	Dataset   string                `json:"dataset"`
+	Format    string                `json:"format"`
+	Filter    *PaymentFailureFilter `json:"filter,omitempty"`
+}
+
+// ExportService streams datasets to CSV, JSONL or XLSX and runs large exports as
+// jobs. Jobs are queued in Postgres and run by the workers, which store each
+// file in Postgres too, so any replica can report on and serve any export.
+type ExportService struct {
+	db     *gorm.DB
+	logger *zap.Logger
+}
+
+// exportDataset defines the query and row layout of an exportable dataset
+type exportDataset struct {
+	columns []string
+	query   func(db *gorm.DB, companyID string, filter *PaymentFailureFilter) *gorm.DB
+	dest    func() interface{}
+	scan    func(db *gorm.DB, rows *sql.Rows) ([]interface{}, error)
+}
+
+// NewExportService creates a new export service
+func NewExportService(db *gorm.DB, logger *zap.Logger) *ExportService {
+	return &ExportService{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// ValidateRequest checks the dataset and format of an export request
+func (s *ExportService) ValidateRequest(req *ExportRequest) error {
+	if _, ok := exportDatasets[req.Dataset]; !ok {
+		return fmt.Errorf("unsupported export dataset: %s", req.Dataset)
+	}
+	switch req.Format {
+	case ExportFormatCSV, ExportFormatJSONL, ExportFormatXLSX:
+	default:
+		return fmt.Errorf("unsupported export format: %s", req.Format)
+	}
+	if req.Filter == nil {
+		req.Filter = &PaymentFailureFilter{}
+	}
+	for _, f := range req.Filter.Sort {
+		if _, ok := paymentFailureSortColumns[f.Field]; !ok {
+			return fmt.Errorf("%w: unknown sort field %q", ErrInvalidPaymentFailureQuery, f.Field)
+		}
+	}
+	return nil
+}
+
+// CountRows returns how many rows an export would contain
+func (s *ExportService) CountRows(ctx context.Context, req *ExportRequest) (int64, error) {
+	if err := s.ValidateRequest(req); err != nil {
+		return 0, err
+	}
+
+	var count int64
+	ds := exportDatasets[req.Dataset]
+	if err := ds.query(s.db.WithContext(ctx), req.CompanyID, req.Filter).Count(&count).Error; err != nil {
+		return 0, fmt.Errorf("failed to count export rows: %w", err)
+	}
+	return count, nil
+}
+
+// Stream writes the export to w, reading from a server-side Postgres cursor so
+// memory use does not grow with the export size
+func (s *ExportService) Stream(ctx context.Context, req *ExportRequest, w io.Writer) (int64, error) {
+	return s.stream(ctx, req, w, nil)
+}
+
+// StartExportJob queues an export job for the export workers
+func (s *ExportService) StartExportJob(ctx context.Context, req *ExportRequest) (*models.ExportJob, error) {
+	total, err := s.CountRows(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	filter, _ := json.Marshal(req.Filter)
+	job := &models.ExportJob{
+		ID:          uuid.New(),
+		CompanyID:   req.CompanyID,
+		Dataset:     req.Dataset,
+		Format:      req.Format,
+		Filter:      datatypes.JSON(filter),
+		Status:      "pending",
+		TotalRows:   total,
+		AvailableAt: time.Now(),
+	}
+	if err := s.db.WithContext(ctx).Create(job).Error; err != nil {
+		return nil, fmt.Errorf("failed to create export job: %w", err)
+	}
+	return job, nil
+}
+
+// GetExportJob returns an export job for a company
+func (s *ExportService) GetExportJob(ctx context.Context, companyID string, jobID uuid.UUID) (*models.ExportJob, error) {
+	var job models.ExportJob
+	if err := s.db.WithContext(ctx).Where("id = ? AND company_id = ?", jobID, companyID).First(&job).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrExportNotFound
+		}
+		return nil, fmt.Errorf("failed to get export job: %w", err)
+	}
+	return &job, nil
+}
+
+// OpenDownload returns a completed job and a reader of its file for a valid
+// download token. The caller must close the reader.
+func (s *ExportService) OpenDownload(ctx context.Context, token string) (*models.ExportJob, io.ReadCloser, error) {
+	var job models.ExportJob
+	err := s.db.WithContext(ctx).
+		Where("download_token = ? AND status = ? AND token_expires_at > ?", token, "completed", time.Now()).
+		First(&job).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil, ErrExportNotFound
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to lookup export download: %w", err)
+	}
+
+	return &job, &exportChunkReader{ctx: ctx, db: s.db, jobID: job.ID}, nil
+}
+
+// RunDue claims and runs the export jobs that are due: queued jobs, jobs
+// waiting to be retried and jobs whose worker stopped. Jobs are claimed by
+// moving available_at past the claim lease, so each runs on one worker at a time.
+func (s *ExportService) RunDue(ctx context.Context) (int, error) {
+	now := time.Now()
+	active := []string{"pending", "running"}
+
+	var due []models.ExportJob
+	if err := s.db.WithContext(ctx).
+		Where("status IN ? AND available_at <= ?", active, now).
+		Order("available_at").
+		Limit(exportJobBatch).
+		Find(&due).Error; err != nil {
+		return 0, fmt.Errorf("failed to find due export jobs: %w", err)
+	}
+
+	ran := 0
+	for i := range due {
+		job := &due[i]
+		claim := s.db.WithContext(ctx).Model(&models.ExportJob{}).
+			Where("id = ? AND status IN ? AND available_at = ?", job.ID, active, job.AvailableAt).
+			Updates(map[string]interface{}{
+				"status":       "running",
+				"attempts":     job.Attempts + 1,
+				"available_at": now.Add(exportClaimLease),
+				"started_at":   now,
+			})
+		if claim.Error != nil {
+			s.logger.Error("Failed to claim export job",
+				zap.String("export_job_id", job.ID.String()),
+				zap.Error(claim.Error))
+			continue
+		}
+		if claim.RowsAffected == 0 {
+			continue
+		}
+		job.Attempts++
+		s.runExportJob(ctx, job)
+		ran++
+	}
+	return ran, nil
+}
+
+// CleanupExpired deletes the files of exports whose download links expired and
+// marks their jobs expired
+func (s *ExportService) CleanupExpired(ctx context.Context) (int64, error) {
+	expired := s.db.Model(&models.ExportJob{}).Select("id").
+		Where("status = ? AND token_expires_at <= ?", "completed", time.Now())
+
+	var jobs int64
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("export_job_id IN (?)", expired).Delete(&models.ExportFileChunk{}).Error; err != nil {
+			return fmt.Errorf("failed to delete expired export files: %w", err)
+		}
+		result := tx.Model(&models.ExportJob{}).Where("id IN (?)", expired).
+			Updates(map[string]interface{}{"status": "expired", "download_token": ""})
+		if result.Error != nil {
+			return fmt.Errorf("failed to expire export jobs: %w", result.Error)
+		}
+		jobs = result.RowsAffected
+		return nil
+	})
+	return jobs, err
+}
+
+// Start runs due export jobs every interval and deletes expired export files
+// every hour, until ctx is cancelled
+func (s *ExportService) Start(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		cleanup := time.NewTicker(exportCleanupInterval)
+		defer cleanup.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if _, err := s.RunDue(ctx); err != nil {
+					s.logger.Error("Export job run failed", zap.Error(err))
+				}
+			case <-cleanup.C:
+				if expired, err := s.CleanupExpired(ctx); err != nil {
+					s.logger.Error("Export cleanup failed", zap.Error(err))
+				} else if expired > 0 {
+					s.logger.Info("Expired export files deleted", zap.Int64("jobs", expired))
+				}
+			}
+		}
+	}()
+}
+
+// ExportFileName returns a download file name for an export
+func ExportFileName(dataset, format string, at time.Time) string {
+	return fmt.Sprintf("%s_%s.%s", dataset, at.UTC().Format("20060102_150405"), format)
+}
+
+// Helper methods
+
+// runExportJob runs a claimed job, storing its file in chunks. A job that fails
+// or panics is retried with backoff until its attempts are used up.
+func (s *ExportService) runExportJob(ctx context.Context, job *models.ExportJob) {
+	logger := s.logger.With(zap.String("export_job_id", job.ID.String()), zap.String("company_id", job.CompanyID))
+	startedAt := time.Now()
+
+	defer func() {
+		if r := recover(); r != nil {
+			s.retryExportJob(ctx, job, fmt.Errorf("export job panicked: %v", r))
+		}
+	}()
+
+	req := ExportRequest{
+		CompanyID: job.CompanyID,
+		Dataset:   job.Dataset,
+		Format:    job.Format,
+		Filter:    &PaymentFailureFilter{},
+	}
+	if len(job.Filter) > 0 {
+		if err := json.Unmarshal(job.Filter, req.Filter); err != nil {
+			s.retryExportJob(ctx, job, fmt.Errorf("invalid export filter: %w", err))
+			return
+		}
+	}
+
+	// An earlier attempt may have stored part of the file
+	if err := s.db.WithContext(ctx).Where("export_job_id = ?", job.ID).Delete(&models.ExportFileChunk{}).Error; err != nil {
+		s.retryExportJob(ctx, job, fmt.Errorf("failed to clear export file: %w", err))
+		return
+	}
+
+	file := &exportChunkWriter{ctx: ctx, db: s.db, jobID: job.ID}
+	rows, err := s.stream(ctx, &req, file, func(processed int64) {
+		s.db.WithContext(ctx).Model(&models.ExportJob{}).
+			Where("id = ? AND attempts = ?", job.ID, job.Attempts).
+			Updates(map[string]interface{}{"processed_rows": processed, "available_at": time.Now().Add(exportClaimLease)})
+	})
+	if err == nil {
+		err = file.Close()
+	}
+	if err != nil {
+		s.retryExportJob(ctx, job, err)
+		return
+	}
+
+	token, err := newDownloadToken()
+	if err != nil {
+		s.retryExportJob(ctx, job, err)
+		return
+	}
+	completedAt := time.Now()
+	if err := s.db.WithContext(ctx).Model(&models.ExportJob{}).
+		Where("id = ? AND attempts = ?", job.ID, job.Attempts).
+		Updates(map[string]interface{}{
+			"status":           "completed",
+			"processed_rows":   rows,
+			"file_size":        file.size,
+			"download_token":   token,
+			"token_expires_at": completedAt.Add(exportTokenTTL),
+			"error_message":    "",
+			"completed_at":     completedAt,
+		}).Error; err != nil {
+		// The claim lease runs out and the job is run again
+		logger.Error("Failed to complete export job", zap.Error(err))
+		return
+	}
+
+	logger.Info("Export job completed",
+		zap.Int64("rows", rows),
+		zap.Int64("bytes", file.size),
+		zap.Duration("duration", completedAt.Sub(startedAt)))
+}
+
+// retryExportJob queues a failed job again with backoff, or marks it failed and
+// deletes its partial file once its attempts are used up
+func (s *ExportService) retryExportJob(ctx context.Context, job *models.ExportJob, cause error) {
+	updates := map[string]interface{}{"error_message": cause.Error()}
+	if job.Attempts >= maxExportAttempts {
+		updates["status"] = "failed"
+		updates["completed_at"] = time.Now()
+		s.logger.Error("Export job failed",
+			zap.String("export_job_id", job.ID.String()),
+			zap.String("company_id", job.CompanyID),
+			zap.Int("attempts", job.Attempts),
+			zap.Error(cause))
+	} else {
+		updates["status"] = "pending"
+		updates["available_at"] = time.Now().Add(workflowTimerBackoff(job.Attempts))
+		s.logger.Warn("Export job failed, retrying",
+			zap.String("export_job_id", job.ID.String()),
+			zap.Int("attempts", job.Attempts),
+			zap.Error(cause))
+	}
+
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if updates["status"] == "failed" {
+			if err := tx.Where("export_job_id = ?", job.ID).Delete(&models.ExportFileChunk{}).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Model(&models.ExportJob{}).
+			Where("id = ? AND attempts = ?", job.ID, job.Attempts).
+			Updates(updates).Error
+	})
+	if err != nil {
+		s.logger.Error("Failed to reschedule export job",
+			zap.String("export_job_id", job.ID.String()),
+			zap.Error(err))
+	}
+}
+
+// exportChunkWriter stores an export file as ExportFileChunks of exportChunkSize
+// bytes. Close stores the final, shorter chunk.
+type exportChunkWriter struct {
+	ctx   context.Context
+	db    *gorm.DB
+	jobID uuid.UUID
+	buf   []byte
+	seq   int
+	size  int64
+}
+
+func (w *exportChunkWriter) Write(p []byte) (int, error) {
+	written := 0
+	for written < len(p) {
+		n := min(exportChunkSize-len(w.buf), len(p)-written)
+		w.buf = append(w.buf, p[written:written+n]...)
+		written += n
+		if len(w.buf) == exportChunkSize {
+			if err := w.flush(); err != nil {
+				return written, err
+			}
+		}
+	}
+	return written, nil
+}
+
+func (w *exportChunkWriter) Close() error {
+	return w.flush()
+}
+
+func (w *exportChunkWriter) flush() error {
+	if len(w.buf) == 0 {
+		return nil
+	}
+	chunk := &models.ExportFileChunk{ExportJobID: w.jobID, Seq: w.seq, Data: w.buf}
+	if err := w.db.WithContext(w.ctx).Create(chunk).Error; err != nil {
+		return fmt.Errorf("failed to store export file: %w", err)
+	}
+	w.seq++
+	w.size += int64(len(w.buf))
+	w.buf = w.buf[:0]
+	return nil
+}
+
+// exportChunkReader reads an export file back one ExportFileChunk at a time
+type exportChunkReader struct {
+	ctx   context.Context
+	db    *gorm.DB
+	jobID uuid.UUID
+	seq   int
+	buf   []byte
+	done  bool
+}
+
+func (r *exportChunkReader) Read(p []byte) (int, error) {
+	for len(r.buf) == 0 {
+		if r.done {
+			return 0, io.EOF
+		}
+		var chunk models.ExportFileChunk
+		result := r.db.WithContext(r.ctx).
+			Where("export_job_id = ? AND seq = ?", r.jobID, r.seq).
+			Limit(1).
+			Find(&chunk)
+		if result.Error != nil {
+			return 0, fmt.Errorf("failed to read export file: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			r.done = true
+			continue
+		}
+		r.buf = chunk.Data
+		r.seq++
+	}
+	n := copy(p, r.buf)
+	r.buf = r.buf[n:]
+	return n, nil
+}
+
+func (r *exportChunkReader) Close() error {
+	return nil
+}
+
+// stream runs the dataset query through a read-only cursor, fetching in batches
+func (s *ExportService) stream(ctx context.Context, req *ExportRequest, w io.Writer, progress func(int64)) (int64, error) {
+	if err := s.ValidateRequest(req); err != nil {
+		return 0, err
+	}
+	ds := exportDatasets[req.Dataset]
+
+	writer, err := newExportWriter(req.Format, w)
+	if err != nil {
+		return 0, err
+	}
+	if err := writer.WriteHeader(ds.columns); err != nil {
+		return 0, err
+	}
+
+	stmt := ds.query(s.db.WithContext(ctx), req.CompanyID, req.Filter).
+		Session(&gorm.Session{DryRun: true}).Find(ds.dest()).Statement
+
+	sqlDB, err := s.db.DB()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get database handle: %w", err)
+	}
+	tx, err := sqlDB.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin export transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, "DECLARE export_cursor NO SCROLL CURSOR FOR "+stmt.SQL.String(), stmt.Vars...); err != nil {
+		return 0, fmt.Errorf("failed to declare export cursor: %w", err)
+	}
+
+	var written int64
+	for {
+		rows, err := tx.QueryContext(ctx, fmt.Sprintf("FETCH %d FROM export_cursor", exportFetchSize))
+		if err != nil {
+			return written, fmt.Errorf("failed to fetch export rows: %w", err)
+		}
+
+		fetched := 0
+		for rows.Next() {
+			fetched++
+			values, err := ds.scan(s.db, rows)
+			if err != nil {
+				rows.Close()
+				return written, fmt.Errorf("failed to scan export row: %w", err)
+			}
+			if err := writer.WriteRow(values); err != nil {
+				rows.Close()
+				return written, fmt.Errorf("failed to write export row: %w", err)
+			}
+			written++
+			if progress != nil && written%exportProgressInterval == 0 {
+				progress(written)
+			}
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return written, fmt.Errorf("failed to read export rows: %w", err)
+		}
+		if fetched < exportFetchSize {
+			break
+		}
+	}
+
+	if err := writer.Close(); err != nil {
+		return written, fmt.Errorf("failed to finalize export: %w", err)
+	}
+	return written, nil
+}
+
+func newDownloadToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate download token: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// relatedFailureIDs selects the failures matching the failure-level parts of filter.
+// Status and date filters are applied to the related dataset itself instead.
+func relatedFailureIDs(db *gorm.DB, companyID string, filter *PaymentFailureFilter) *gorm.DB {
+	failureFilter := *filter
+	failureFilter.Statuses = nil
+	failureFilter.StartDate = nil
+	failureFilter.EndDate = nil
+	return applyPaymentFailureFilter(
+		db.Model(&models.PaymentFailureEvent{}).Select("id").Where("company_id = ?", companyID),
+		&failureFilter,
+	)
+}
+
+func applyOwnFilters(query *gorm.DB, table, dateColumn string, filter *PaymentFailureFilter) *gorm.DB {
+	if len(filter.Statuses) > 0 {
+		query = query.Where(table+".status IN ?", filter.Statuses)
+	}
+	if filter.StartDate != nil {
+		query = query.Where(table+"."+dateColumn+" >= ?", *filter.StartDate)
+	}
+	if filter.EndDate != nil {
+		query = query.Where(table+"."+dateColumn+" <= ?", *filter.EndDate)
+	}
+	return query
+}
+
+var exportDatasets = map[string]exportDataset{
+	"payment_failures": {
+		columns: []string{"id", "created_at", "provider_id", "event_id", "customer_id", "customer_name", "customer_email",
+			"invoice_number", "amount", "currency", "status", "failure_reason", "failure_code", "failure_message",
+			"retry_count", "due_date", "tags"},
+		query: func(db *gorm.DB, companyID string, filter *PaymentFailureFilter) *gorm.DB {
+			sort := filter.Sort
+			if len(sort) == 0 {
+				sort = []SortField{{Field: "created_at", Desc: true}}
+			}
+			return applyPaymentFailureFilter(
+				db.Model(&models.PaymentFailureEvent{}).Where("company_id = ?", companyID), filter,
+			).Order(orderClause(sort))
+		},
+		dest: func() interface{} { return &[]models.PaymentFailureEvent{} },
+		scan: func(db *gorm.DB, rows *sql.Rows) ([]interface{}, error) {
+			var f models.PaymentFailureEvent
+			if err := db.ScanRows(rows, &f); err != nil {
+				return nil, err
+			}
+			tags, _ := json.Marshal(f.Tags)
+			return []interface{}{f.ID, f.CreatedAt, f.ProviderID, f.EventID, f.CustomerID, f.CustomerName, f.CustomerEmail,
+				f.InvoiceNumber, float64(f.AmountCents) / 100, f.Currency, f.Status, f.FailureReason, f.FailureCode, f.FailureMessage,
+				f.RetryCount, f.DueDate, string(tags)}, nil
+		},
+	},
+	"recovery_actions": {
+		columns: []string{"id", "created_at", "payment_failure_id", "workflow_execution_id", "action_type", "status",
+			"provider", "external_id", "scheduled_at", "executed_at", "completed_at", "error_message"},
+		query: func(db *gorm.DB, companyID string, filter *PaymentFailureFilter) *gorm.DB {
+			return applyOwnFilters(db.Model(&models.RecoveryAction{}).
+				Where("payment_failure_id IN (?)", relatedFailureIDs(db, companyID, filter)), "recovery_actions", "created_at", filter).
+				Order("created_at DESC, id DESC")
+		},
+		dest: func() interface{} { return &[]models.RecoveryAction{} },
+		scan: func(db *gorm.DB, rows *sql.Rows) ([]interface{}, error) {
+			var a models.RecoveryAction
+			if err := db.ScanRows(rows, &a); err != nil {
+				return nil, err
+			}
+			var executionID string
+			if a.WorkflowExecutionID != nil {
+				executionID = a.WorkflowExecutionID.String()
+			}
+			return []interface{}{a.ID, a.CreatedAt, a.PaymentFailureID, executionID, a.ActionType, a.Status,
+				a.Provider, a.ExternalID, a.ScheduledAt, a.ExecutedAt, a.CompletedAt, a.ErrorMessage}, nil
+		},
+	},
+	"workflow_executions": {
+		columns: []string{"id", "workflow_id", "payment_failure_id", "status", "started_at", "completed_at",
+			"total_steps", "completed_steps", "failed_steps", "retry_count", "last_error"},
+		query: func(db *gorm.DB, companyID string, filter *PaymentFailureFilter) *gorm.DB {
+			return applyOwnFilters(db.Model(&models.RecoveryWorkflowExecution{}).
+				Where("payment_failure_id IN (?)", relatedFailureIDs(db, companyID, filter)), "recovery_workflow_executions", "started_at", filter).
+				Order("started_at DESC, id DESC")
+		},
+		dest: func() interface{} { return &[]models.RecoveryWorkflowExecution{} },
+		scan: func(db *gorm.DB, rows *sql.Rows) ([]interface{}, error) {
+			var e models.RecoveryWorkflowExecution
+			if err := db.ScanRows(rows, &e); err != nil {
+				return nil, err
+			}
+			return []interface{}{e.ID, e.WorkflowID, e.PaymentFailureID, e.Status, e.StartedAt, e.CompletedAt,
+				e.TotalSteps, e.CompletedSteps, e.FailedSteps, e.RetryCount, e.LastError}, nil
+		},
+	},
+	"communications": {
+		columns: []string{"id", "created_at", "payment_failure_id", "communication_type", "recipient", "subject",
+			"status", "template_id", "external_id", "sent_at"},
+		query: func(db *gorm.DB, companyID string, filter *PaymentFailureFilter) *gorm.DB {
+			return applyOwnFilters(db.Model(&models.CustomerCommunication{}).
+				Where("company_id = ? AND payment_failure_id IN (?)", companyID, relatedFailureIDs(db, companyID, filter)),
+				"customer_communications", "created_at", filter).
+				Order("created_at DESC, id DESC")
+		},
+		dest: func() interface{} { return &[]models.CustomerCommunication{} },
+		scan: func(db *gorm.DB, rows *sql.Rows) ([]interface{}, error) {
+			var c models.CustomerCommunication
+			if err := db.ScanRows(rows, &c); err != nil {
+				return nil, err
+			}
+			var failureID string
+			if c.PaymentFailureID != nil {
+				failureID = c.PaymentFailureID.String()
+			}
+			return []interface{}{c.ID, c.CreatedAt, failureID, c.CommunicationType, c.Recipient, c.Subject,
+				c.Status, c.TemplateID, c.ExternalID, c.SentAt}, nil
+		},
+	},
+}
@@ -0,0 +1,142 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/sambitmohanty1/payment-watchdog/api/internal/models"
+)
+
+func TestRunDueExportJobs(t *testing.T) {
+	db, mock := mockDB(t)
+	won, lost := uuid.New(), uuid.New()
+	availableAt := time.Now().Add(-time.Second)
+
+	rows := sqlmock.NewRows([]string{"id", "company_id", "dataset", "format", "status", "available_at", "attempts"}).
+		AddRow(lost, "company-1", "payment_failures", ExportFormatCSV, "pending", availableAt, 0).
+		AddRow(won, "company-1", "payment_failures", ExportFormatCSV, "running", availableAt, 1)
+	mock.ExpectQuery(`SELECT \* FROM "export_jobs" WHERE status IN \(\$1,\$2\) AND available_at <= \$3 ORDER BY available_at LIMIT \$4`).
+		WithArgs("pending", "running", sqlmock.AnyArg(), exportJobBatch).
+		WillReturnRows(rows)
+
+	claim := `UPDATE "export_jobs" SET "attempts"=\$1,"available_at"=\$2,"started_at"=\$3,"status"=\$4,"updated_at"=\$5 WHERE id = \$6 AND status IN \(\$7,\$8\) AND available_at = \$9`
+	// Another worker claimed this one first
+	mock.ExpectExec(claim).
+		WithArgs(1, sqlmock.AnyArg(), sqlmock.AnyArg(), "running", sqlmock.AnyArg(), lost, "pending", "running", availableAt).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	// A job whose worker stopped is taken over
+	mock.ExpectExec(claim).
+		WithArgs(2, sqlmock.AnyArg(), sqlmock.AnyArg(), "running", sqlmock.AnyArg(), won, "pending", "running", availableAt).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`DELETE FROM "export_file_chunks" WHERE export_job_id = \$1`).
+		WithArgs(won).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectBegin()
+	mock.ExpectExec(`DECLARE export_cursor NO SCROLL CURSOR FOR SELECT \* FROM "payment_failure_events" WHERE company_id = \$1`).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(`FETCH 1000 FROM export_cursor`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "customer_name", "amount_cents"}).AddRow(uuid.New(), "=Ada", 4250))
+	mock.ExpectRollback()
+	mock.ExpectExec(`INSERT INTO "export_file_chunks" \("export_job_id","seq","data"\)`).
+		WithArgs(won, 0, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`UPDATE "export_jobs" SET "completed_at"=\$1,"download_token"=\$2,"error_message"=\$3,"file_size"=\$4,"processed_rows"=\$5,"status"=\$6,"token_expires_at"=\$7,"updated_at"=\$8 WHERE id = \$9 AND attempts = \$10`).
+		WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), "", sqlmock.AnyArg(), int64(1), "completed", sqlmock.AnyArg(), sqlmock.AnyArg(), won, 2).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	ran, err := NewExportService(db, zap.NewNop()).RunDue(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 1, ran)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestExportFileChunks(t *testing.T) {
+	db, mock := mockDB(t)
+	jobID := uuid.New()
+	chunkSize := exportChunkSize
+	exportChunkSize = 4
+	t.Cleanup(func() { exportChunkSize = chunkSize })
+
+	for seq, data := range []string{"id,n", "ame\n", "1,A"} {
+		mock.ExpectExec(`INSERT INTO "export_file_chunks"`).
+			WithArgs(jobID, seq, []byte(data)).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+	}
+	w := &exportChunkWriter{ctx: context.Background(), db: db, jobID: jobID}
+	_, err := io.WriteString(w, "id,name\n")
+	require.NoError(t, err)
+	_, err = io.WriteString(w, "1,A")
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+	assert.Equal(t, int64(11), w.size)
+
+	// Downloads read the chunks back in order from any replica
+	read := `SELECT \* FROM "export_file_chunks" WHERE export_job_id = \$1 AND seq = \$2 LIMIT \$3`
+	for seq, data := range []string{"id,n", "ame\n", "1,A"} {
+		mock.ExpectQuery(read).
+			WithArgs(jobID, seq, 1).
+			WillReturnRows(sqlmock.NewRows([]string{"export_job_id", "seq", "data"}).AddRow(jobID, seq, []byte(data)))
+	}
+	mock.ExpectQuery(read).
+		WithArgs(jobID, 3, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"export_job_id", "seq", "data"}))
+
+	body, err := io.ReadAll(&exportChunkReader{ctx: context.Background(), db: db, jobID: jobID})
+	require.NoError(t, err)
+	assert.Equal(t, "id,name\n1,A", string(body))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestExportJobRetriesThenFails(t *testing.T) {
+	db, mock := mockDB(t)
+	service := NewExportService(db, zap.NewNop())
+	id := uuid.New()
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`UPDATE "export_jobs" SET "available_at"=\$1,"error_message"=\$2,"status"=\$3,"updated_at"=\$4 WHERE id = \$5 AND attempts = \$6`).
+		WithArgs(sqlmock.AnyArg(), "database unavailable", "pending", sqlmock.AnyArg(), id, 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	service.retryExportJob(context.Background(), &models.ExportJob{ID: id, Attempts: 1}, errors.New("database unavailable"))
+
+	// The last attempt fails the job and deletes its partial file
+	mock.ExpectBegin()
+	mock.ExpectExec(`DELETE FROM "export_file_chunks" WHERE export_job_id = \$1`).
+		WithArgs(id).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectExec(`UPDATE "export_jobs" SET "completed_at"=\$1,"error_message"=\$2,"status"=\$3,"updated_at"=\$4 WHERE id = \$5 AND attempts = \$6`).
+		WithArgs(sqlmock.AnyArg(), "database unavailable", "failed", sqlmock.AnyArg(), id, maxExportAttempts).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	service.retryExportJob(context.Background(), &models.ExportJob{ID: id, Attempts: maxExportAttempts}, errors.New("database unavailable"))
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCleanupExpiredExports(t *testing.T) {
+	db, mock := mockDB(t)
+	expired := `\(SELECT "id" FROM "export_jobs" WHERE status = \$\d AND token_expires_at <= \$\d\)`
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`DELETE FROM "export_file_chunks" WHERE export_job_id IN `+expired).
+		WithArgs("completed", sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 12))
+	mock.ExpectExec(`UPDATE "export_jobs" SET "download_token"=\$1,"status"=\$2,"updated_at"=\$3 WHERE id IN `+expired).
+		WithArgs("", "expired", sqlmock.AnyArg(), "completed", sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 3))
+	mock.ExpectCommit()
+
+	jobs, err := NewExportService(db, zap.NewNop()).CleanupExpired(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), jobs)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
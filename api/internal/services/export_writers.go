@@ -0,0 +1,243 @@
+package services
+
+import (
+	"archive/zip"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Export formats
+const (
+	ExportFormatCSV   = "csv"
+	ExportFormatJSONL = "jsonl"
+	ExportFormatXLSX  = "xlsx"
+)
+
+// exportWriter writes a header and rows in a specific file format
+type exportWriter interface {
+	WriteHeader(columns []string) error
+	WriteRow(values []interface{}) error
+	Close() error
+}
+
+// newExportWriter returns a streaming writer for format
+func newExportWriter(format string, w io.Writer) (exportWriter, error) {
+	switch format {
+	case ExportFormatCSV:
+		return &csvExportWriter{w: csv.NewWriter(w)}, nil
+	case ExportFormatJSONL:
+		return &jsonlExportWriter{enc: json.NewEncoder(w)}, nil
+	case ExportFormatXLSX:
+		return newXLSXExportWriter(w)
+	}
+	return nil, fmt.Errorf("unsupported export format: %s", format)
+}
+
+// ExportContentType returns the MIME type for an export format
+func ExportContentType(format string) string {
+	switch format {
+	case ExportFormatCSV:
+		return "text/csv"
+	case ExportFormatJSONL:
+		return "application/x-ndjson"
+	case ExportFormatXLSX:
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	}
+	return "application/octet-stream"
+}
+
+func formatExportValue(v interface{}) string {
+	switch val := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return val
+	case time.Time:
+		if val.IsZero() {
+			return ""
+		}
+		return val.UTC().Format(time.RFC3339)
+	case *time.Time:
+		if val == nil {
+			return ""
+		}
+		return formatExportValue(*val)
+	case float64:
+		return strconv.FormatFloat(val, 'f', -1, 64)
+	case fmt.Stringer:
+		return val.String()
+	}
+	return fmt.Sprint(v)
+}
+
+// CSV
+
+type csvExportWriter struct {
+	w *csv.Writer
+}
+
+func (c *csvExportWriter) WriteHeader(columns []string) error {
+	return c.w.Write(columns)
+}
+
+// WriteRow writes a record. Text that a spreadsheet would run as a formula is
+// prefixed with a quote, since exported values come from customers and providers.
+func (c *csvExportWriter) WriteRow(values []interface{}) error {
+	record := make([]string, len(values))
+	for i, v := range values {
+		record[i] = formatExportValue(v)
+		if _, text := v.(string); text {
+			record[i] = escapeCSVFormula(record[i])
+		}
+	}
+	return c.w.Write(record)
+}
+
+// escapeCSVFormula defuses a cell that starts like a formula
+func escapeCSVFormula(cell string) string {
+	if cell != "" && strings.ContainsRune("=+-@\t\r", rune(cell[0])) {
+		return "'" + cell
+	}
+	return cell
+}
+
+func (c *csvExportWriter) Close() error {
+	c.w.Flush()
+	return c.w.Error()
+}
+
+// JSON Lines
+
+type jsonlExportWriter struct {
+	enc     *json.Encoder
+	columns []string
+}
+
+func (j *jsonlExportWriter) WriteHeader(columns []string) error {
+	j.columns = columns
+	return nil
+}
+
+func (j *jsonlExportWriter) WriteRow(values []interface{}) error {
+	row := make(map[string]interface{}, len(values))
+	for i, v := range values {
+		if t, ok := v.(*time.Time); ok && t == nil {
+			v = nil
+		}
+		row[j.columns[i]] = v
+	}
+	return j.enc.Encode(row)
+}
+
+func (j *jsonlExportWriter) Close() error {
+	return nil
+}
+
+// XLSX
+
+// xlsxExportWriter streams a single-sheet SpreadsheetML workbook. Rows are written
+// straight into the zipped sheet part; the remaining package parts are added on Close.
+type xlsxExportWriter struct {
+	zw    *zip.Writer
+	sheet io.Writer
+	row   int
+}
+
+func newXLSXExportWriter(w io.Writer) (*xlsxExportWriter, error) {
+	zw := zip.NewWriter(w)
+	sheet, err := zw.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		return nil, err
+	}
+	if _, err := io.WriteString(sheet, xml.Header+
+		`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`); err != nil {
+		return nil, err
+	}
+	return &xlsxExportWriter{zw: zw, sheet: sheet}, nil
+}
+
+func (x *xlsxExportWriter) WriteHeader(columns []string) error {
+	values := make([]interface{}, len(columns))
+	for i, c := range columns {
+		values[i] = c
+	}
+	return x.WriteRow(values)
+}
+
+func (x *xlsxExportWriter) WriteRow(values []interface{}) error {
+	x.row++
+	if _, err := fmt.Fprintf(x.sheet, `<row r="%d">`, x.row); err != nil {
+		return err
+	}
+	for _, v := range values {
+		if err := x.writeCell(v); err != nil {
+			return err
+		}
+	}
+	_, err := io.WriteString(x.sheet, `</row>`)
+	return err
+}
+
+func (x *xlsxExportWriter) writeCell(v interface{}) error {
+	switch val := v.(type) {
+	case int, int32, int64, float64:
+		_, err := fmt.Fprintf(x.sheet, `<c t="n"><v>%s</v></c>`, formatExportValue(val))
+		return err
+	case bool:
+		b := 0
+		if val {
+			b = 1
+		}
+		_, err := fmt.Fprintf(x.sheet, `<c t="b"><v>%d</v></c>`, b)
+		return err
+	}
+
+	if _, err := io.WriteString(x.sheet, `<c t="inlineStr"><is><t xml:space="preserve">`); err != nil {
+		return err
+	}
+	if err := xml.EscapeText(x.sheet, []byte(formatExportValue(v))); err != nil {
+		return err
+	}
+	_, err := io.WriteString(x.sheet, `</t></is></c>`)
+	return err
+}
+
+func (x *xlsxExportWriter) Close() error {
+	if _, err := io.WriteString(x.sheet, `</sheetData></worksheet>`); err != nil {
+		return err
+	}
+
+	parts := []struct{ name, body string }{
+		{"[Content_Types].xml", `<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+			`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+			`<Default Extension="xml" ContentType="application/xml"/>` +
+			`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+			`<Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>` +
+			`</Types>`},
+		{"_rels/.rels", `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+			`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+			`</Relationships>`},
+		{"xl/workbook.xml", `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">` +
+			`<sheets><sheet name="Export" sheetId="1" r:id="rId1"/></sheets></workbook>`},
+		{"xl/_rels/workbook.xml.rels", `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+			`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>` +
+			`</Relationships>`},
+	}
+	for _, part := range parts {
+		w, err := x.zw.Create(part.name)
+		if err != nil {
+			return err
+		}
+		if _, err := io.WriteString(w, xml.Header+part.body); err != nil {
+			return err
+		}
+	}
+
+	return x.zw.Close()
+}
@@ -0,0 +1,87 @@
+package services
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeExport(t *testing.T, format string) []byte {
+	var buf bytes.Buffer
+	w, err := newExportWriter(format, &buf)
+	require.NoError(t, err)
+
+	require.NoError(t, w.WriteHeader([]string{"id", "amount", "created_at", "note"}))
+	require.NoError(t, w.WriteRow([]interface{}{"pf-1", 12.5, time.Date(2025, 1, 31, 0, 0, 0, 0, time.UTC), `a "quoted" <note>`}))
+	require.NoError(t, w.WriteRow([]interface{}{"pf-2", int64(3), (*time.Time)(nil), ""}))
+	require.NoError(t, w.Close())
+	return buf.Bytes()
+}
+
+func TestCSVExportWriter(t *testing.T) {
+	out := string(writeExport(t, ExportFormatCSV))
+	assert.Equal(t, "id,amount,created_at,note\n"+
+		"pf-1,12.5,2025-01-31T00:00:00Z,\"a \"\"quoted\"\" <note>\"\n"+
+		"pf-2,3,,\n", out)
+}
+
+func TestCSVExportWriterEscapesFormulas(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := newExportWriter(ExportFormatCSV, &buf)
+	require.NoError(t, err)
+
+	require.NoError(t, w.WriteRow([]interface{}{"=HYPERLINK(\"http://x\")", "+1", "-2", "@SUM(A1)", "\tcmd", "plain", -12.5}))
+	require.NoError(t, w.Close())
+	assert.Equal(t, "\"'=HYPERLINK(\"\"http://x\"\")\",'+1,'-2,'@SUM(A1),'\tcmd,plain,-12.5\n", buf.String(),
+		"numbers are written as they are")
+}
+
+func TestJSONLExportWriter(t *testing.T) {
+	lines := strings.Split(strings.TrimSpace(string(writeExport(t, ExportFormatJSONL))), "\n")
+	require.Len(t, lines, 2)
+	assert.JSONEq(t, `{"id":"pf-1","amount":12.5,"created_at":"2025-01-31T00:00:00Z","note":"a \"quoted\" <note>"}`, lines[0])
+	assert.JSONEq(t, `{"id":"pf-2","amount":3,"created_at":null,"note":""}`, lines[1])
+}
+
+func TestXLSXExportWriter(t *testing.T) {
+	out := writeExport(t, ExportFormatXLSX)
+
+	zr, err := zip.NewReader(bytes.NewReader(out), int64(len(out)))
+	require.NoError(t, err)
+
+	parts := make(map[string]string)
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		require.NoError(t, err)
+		body, err := io.ReadAll(rc)
+		require.NoError(t, err)
+		rc.Close()
+		parts[f.Name] = string(body)
+	}
+	for _, name := range []string{"[Content_Types].xml", "_rels/.rels", "xl/workbook.xml", "xl/_rels/workbook.xml.rels", "xl/worksheets/sheet1.xml"} {
+		require.Contains(t, parts, name)
+	}
+
+	var sheet struct {
+		Rows []struct {
+			Cells []struct {
+				Type   string `xml:"t,attr"`
+				Value  string `xml:"v"`
+				Inline string `xml:"is>t"`
+			} `xml:"c"`
+		} `xml:"sheetData>row"`
+	}
+	require.NoError(t, xml.Unmarshal([]byte(parts["xl/worksheets/sheet1.xml"]), &sheet))
+	require.Len(t, sheet.Rows, 3)
+	assert.Equal(t, "id", sheet.Rows[0].Cells[0].Inline)
+	assert.Equal(t, "n", sheet.Rows[1].Cells[1].Type)
+	assert.Equal(t, "12.5", sheet.Rows[1].Cells[1].Value)
+	assert.Equal(t, `a "quoted" <note>`, sheet.Rows[1].Cells[3].Inline)
+}
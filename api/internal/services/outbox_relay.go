@@ -0,0 +1,164 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+
+	"github.com/sambitmohanty1/payment-watchdog/api/internal/architecture"
+	"github.com/sambitmohanty1/payment-watchdog/api/internal/models"
+)
+
+const (
+	// outboxBatch caps how many due events one poll publishes
+	outboxBatch = 100
+	// outboxClaimLease is how long a claimed event stays with its relay; an
+	// event whose relay stopped before publishing it is claimed again after it
+	outboxClaimLease = time.Minute
+	// maxOutboxAttempts is how often publishing an event is tried before it is
+	// marked failed
+	maxOutboxAttempts = 10
+)
+
+// EnqueueOutboxEvent writes event for topic to the outbox in tx, so it commits
+// together with the change it announces. The event's event_id is the outbox
+// event's ID, which stays the same however often the event is published.
+func EnqueueOutboxEvent(tx *gorm.DB, companyID, topic string, event map[string]interface{}) (*models.OutboxEvent, error) {
+	outboxEvent := &models.OutboxEvent{
+		ID:          uuid.New(),
+		CompanyID:   companyID,
+		Topic:       topic,
+		Status:      models.OutboxEventPending,
+		AvailableAt: time.Now(),
+	}
+	event["event_id"] = outboxEvent.ID.String()
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode outbox event: %w", err)
+	}
+	outboxEvent.Payload = string(payload)
+	if err := tx.Create(outboxEvent).Error; err != nil {
+		return nil, fmt.Errorf("failed to write outbox event: %w", err)
+	}
+	return outboxEvent, nil
+}
+
+// OutboxRelay publishes the events committed to the outbox to the event bus.
+// Every event is published at least once; consumers dedupe on its event_id.
+type OutboxRelay struct {
+	db       *gorm.DB
+	eventBus architecture.EventBus
+	logger   *zap.Logger
+}
+
+// NewOutboxRelay creates a new outbox relay
+func NewOutboxRelay(db *gorm.DB, eventBus architecture.EventBus, logger *zap.Logger) *OutboxRelay {
+	return &OutboxRelay{
+		db:       db,
+		eventBus: eventBus,
+		logger:   logger,
+	}
+}
+
+// PublishDue publishes every pending event that is available. Each event is
+// claimed by moving its available_at past the claim lease first, so concurrent
+// relays never publish the same event at once and an event whose relay crashed
+// is published by another.
+func (r *OutboxRelay) PublishDue(ctx context.Context) (int, error) {
+	now := time.Now()
+
+	var due []models.OutboxEvent
+	if err := r.db.WithContext(ctx).
+		Where("status = ? AND available_at <= ?", models.OutboxEventPending, now).
+		Order("available_at").
+		Limit(outboxBatch).
+		Find(&due).Error; err != nil {
+		return 0, fmt.Errorf("failed to find due outbox events: %w", err)
+	}
+
+	published := 0
+	for i := range due {
+		event := &due[i]
+		claim := r.db.WithContext(ctx).Model(&models.OutboxEvent{}).
+			Where("id = ? AND status = ? AND available_at = ?", event.ID, models.OutboxEventPending, event.AvailableAt).
+			Updates(map[string]interface{}{"available_at": now.Add(outboxClaimLease), "attempts": event.Attempts + 1})
+		if claim.Error != nil {
+			r.logger.Error("Failed to claim outbox event",
+				zap.String("outbox_event_id", event.ID.String()),
+				zap.Error(claim.Error))
+			continue
+		}
+		if claim.RowsAffected == 0 {
+			continue
+		}
+		event.Attempts++
+
+		if err := r.eventBus.Publish(ctx, event.Topic, json.RawMessage(event.Payload)); err != nil {
+			r.retry(ctx, event, err, now)
+			continue
+		}
+		if err := r.db.WithContext(ctx).Model(&models.OutboxEvent{}).
+			Where("id = ?", event.ID).
+			Updates(map[string]interface{}{"status": models.OutboxEventPublished, "published_at": now}).Error; err != nil {
+			// The claim lease runs out and the event is published again
+			r.logger.Error("Failed to mark outbox event published",
+				zap.String("outbox_event_id", event.ID.String()),
+				zap.Error(err))
+			continue
+		}
+		published++
+	}
+	return published, nil
+}
+
+// retry makes an event that failed to publish available again with backoff,
+// or marks it failed once its attempts are used up
+func (r *OutboxRelay) retry(ctx context.Context, event *models.OutboxEvent, cause error, now time.Time) {
+	updates := map[string]interface{}{"last_error": cause.Error()}
+	if event.Attempts >= maxOutboxAttempts {
+		updates["status"] = models.OutboxEventFailed
+		r.logger.Error("Outbox event failed",
+			zap.String("outbox_event_id", event.ID.String()),
+			zap.String("topic", event.Topic),
+			zap.String("company_id", event.CompanyID),
+			zap.Int("attempts", event.Attempts),
+			zap.Error(cause))
+	} else {
+		updates["available_at"] = now.Add(workflowTimerBackoff(event.Attempts))
+		r.logger.Warn("Failed to publish outbox event, retrying",
+			zap.String("outbox_event_id", event.ID.String()),
+			zap.Int("attempts", event.Attempts),
+			zap.Error(cause))
+	}
+	if err := r.db.WithContext(ctx).Model(&models.OutboxEvent{}).
+		Where("id = ?", event.ID).
+		Updates(updates).Error; err != nil {
+		r.logger.Error("Failed to reschedule outbox event",
+			zap.String("outbox_event_id", event.ID.String()),
+			zap.Error(err))
+	}
+}
+
+// Start publishes due events every interval until ctx is cancelled
+func (r *OutboxRelay) Start(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if _, err := r.PublishDue(ctx); err != nil {
+					r.logger.Error("Outbox relay run failed", zap.Error(err))
+				}
+			}
+		}
+	}()
+}
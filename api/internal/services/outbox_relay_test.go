@@ -0,0 +1,88 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/sambitmohanty1/payment-watchdog/api/internal/architecture"
+	"github.com/sambitmohanty1/payment-watchdog/api/internal/models"
+)
+
+// outboxBus records the events the outbox relay publishes and fails to
+// publish the ones on failTopic
+type outboxBus struct {
+	architecture.EventBus
+	failTopic string
+	published []json.RawMessage
+}
+
+func (b *outboxBus) Publish(ctx context.Context, topic string, event interface{}) error {
+	if topic == b.failTopic {
+		return errors.New("event bus unavailable")
+	}
+	b.published = append(b.published, event.(json.RawMessage))
+	return nil
+}
+
+func TestPublishDueOutboxEvents(t *testing.T) {
+	db, mock := mockDB(t)
+	won, lost, failing := uuid.New(), uuid.New(), uuid.New()
+	availableAt := time.Now().Add(-time.Second)
+	bus := &outboxBus{failTopic: "payment.failure.unroutable"}
+
+	rows := sqlmock.NewRows([]string{"id", "topic", "payload", "status", "available_at", "attempts"}).
+		AddRow(won, "payment.failure.detected", `{"event_id":"`+won.String()+`"}`, models.OutboxEventPending, availableAt, 0).
+		AddRow(lost, "payment.failure.detected", `{}`, models.OutboxEventPending, availableAt, 0).
+		AddRow(failing, "payment.failure.unroutable", `{}`, models.OutboxEventPending, availableAt, 2)
+	mock.ExpectQuery(`SELECT \* FROM "outbox_events" WHERE status = \$1 AND available_at <= \$2 ORDER BY available_at LIMIT \$3`).
+		WithArgs(models.OutboxEventPending, sqlmock.AnyArg(), outboxBatch).
+		WillReturnRows(rows)
+
+	claim := `UPDATE "outbox_events" SET "attempts"=\$1,"available_at"=\$2,"updated_at"=\$3 WHERE id = \$4 AND status = \$5 AND available_at = \$6`
+	mock.ExpectExec(claim).
+		WithArgs(1, sqlmock.AnyArg(), sqlmock.AnyArg(), won, models.OutboxEventPending, availableAt).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`UPDATE "outbox_events" SET "published_at"=\$1,"status"=\$2,"updated_at"=\$3 WHERE id = \$4`).
+		WithArgs(sqlmock.AnyArg(), models.OutboxEventPublished, sqlmock.AnyArg(), won).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	// Another relay claimed this one first
+	mock.ExpectExec(claim).
+		WithArgs(1, sqlmock.AnyArg(), sqlmock.AnyArg(), lost, models.OutboxEventPending, availableAt).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(claim).
+		WithArgs(3, sqlmock.AnyArg(), sqlmock.AnyArg(), failing, models.OutboxEventPending, availableAt).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	// A failed publish stays pending with backoff
+	mock.ExpectExec(`UPDATE "outbox_events" SET "available_at"=\$1,"last_error"=\$2,"updated_at"=\$3 WHERE id = \$4`).
+		WithArgs(sqlmock.AnyArg(), "event bus unavailable", sqlmock.AnyArg(), failing).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	published, err := NewOutboxRelay(db, bus, zap.NewNop()).PublishDue(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 1, published)
+	require.Len(t, bus.published, 1)
+	assert.JSONEq(t, `{"event_id":"`+won.String()+`"}`, string(bus.published[0]))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestOutboxEventFailsAfterMaxAttempts(t *testing.T) {
+	db, mock := mockDB(t)
+	id := uuid.New()
+
+	mock.ExpectExec(`UPDATE "outbox_events" SET "last_error"=\$1,"status"=\$2,"updated_at"=\$3 WHERE id = \$4`).
+		WithArgs("event bus unavailable", models.OutboxEventFailed, sqlmock.AnyArg(), id).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	relay := NewOutboxRelay(db, &outboxBus{}, zap.NewNop())
+	relay.retry(context.Background(), &models.OutboxEvent{ID: id, Attempts: maxOutboxAttempts}, errors.New("event bus unavailable"), time.Now())
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,344 @@
+package services
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/google/uuid"
+	"github.com/sambitmohanty1/payment-watchdog/api/internal/models"
+	"gorm.io/gorm"
+)
+
+// ErrInvalidPaymentFailureQuery is returned for unknown sort fields or malformed cursors
+var ErrInvalidPaymentFailureQuery = errors.New("invalid payment failure query")
+
+// PaymentFailureFilter describes a payment failure search. Empty fields are not applied.
+type PaymentFailureFilter struct {
+	Statuses     []string `json:"statuses,omitempty"`
+	Providers    []string `json:"providers,omitempty"`
+	Reasons      []string `json:"reasons,omitempty"`
+	Tags         []string `json:"tags,omitempty"`
+	MatchAllTags bool     `json:"match_all_tags,omitempty"`
+	// Segments matches failures of customers currently in any of the segments
+	Segments       []string   `json:"segments,omitempty"`
+	MinAmountCents *int64     `json:"min_amount_cents,omitempty"`
+	MaxAmountCents *int64     `json:"max_amount_cents,omitempty"`
+	Currency       string     `json:"currency,omitempty"`
+	CustomerID     string     `json:"customer_id,omitempty"`
+	CustomerEmail  string     `json:"customer_email,omitempty"`
+	StartDate      *time.Time `json:"start_date,omitempty"`
+	EndDate        *time.Time `json:"end_date,omitempty"`
+	DueFrom        *time.Time `json:"due_from,omitempty"`
+	DueTo          *time.Time `json:"due_to,omitempty"`
+
+	// Query is matched against customer name, email, invoice number and failure message
+	Query string `json:"query,omitempty"`
+
+	// Sort is applied in order; id is always appended as a tiebreaker
+	Sort []SortField `json:"sort,omitempty"`
+
+	// Cursor switches to keyset pagination; Page is ignored when set
+	Cursor       string `json:"cursor,omitempty"`
+	Page         int    `json:"page,omitempty"`
+	Limit        int    `json:"limit,omitempty"`
+	IncludeTotal bool   `json:"include_total,omitempty"`
+}
+
+// SortField is a single sort column
+type SortField struct {
+	Field string `json:"field"`
+	Desc  bool   `json:"desc"`
+}
+
+// PaymentFailurePage is one page of payment failure search results
+type PaymentFailurePage struct {
+	Failures   []models.PaymentFailureEvent `json:"failures"`
+	Total      int64                        `json:"total"`
+	Page       int                          `json:"page,omitempty"`
+	Limit      int                          `json:"limit"`
+	NextCursor string                       `json:"next_cursor,omitempty"`
+	HasMore    bool                         `json:"has_more"`
+}
+
+// sortColumn maps an API sort field to its SQL expression and cursor value kind.
+// Nullable columns are coalesced so that ORDER BY and the keyset predicate
+// agree on where NULL rows fall.
+type sortColumn struct {
+	expr string
+	kind string // time, int, string
+}
+
+var paymentFailureSortColumns = map[string]sortColumn{
+	"created_at":     {"created_at", "time"},
+	"updated_at":     {"updated_at", "time"},
+	"due_date":       {"COALESCE(due_date, 'infinity'::timestamptz)", "time"},
+	"amount":         {"COALESCE(amount_cents, 0)", "int"},
+	"retry_count":    {"COALESCE(retry_count, 0)", "int"},
+	"customer_name":  {"COALESCE(customer_name, '')", "string"},
+	"status":         {"COALESCE(status, '')", "string"},
+	"provider":       {"provider_id", "string"},
+	"failure_reason": {"COALESCE(failure_reason, '')", "string"},
+}
+
+// ParseSortFields parses a sort expression such as "-amount,created_at"
+// where a leading '-' means descending
+func ParseSortFields(expr string) ([]SortField, error) {
+	var fields []SortField
+	for _, part := range strings.Split(expr, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		field := SortField{Field: strings.TrimPrefix(part, "-"), Desc: strings.HasPrefix(part, "-")}
+		if _, ok := paymentFailureSortColumns[field.Field]; !ok {
+			return nil, fmt.Errorf("%w: unknown sort field %q", ErrInvalidPaymentFailureQuery, field.Field)
+		}
+		fields = append(fields, field)
+	}
+	return fields, nil
+}
+
+// applyPaymentFailureFilter adds the filter's WHERE clauses to query
+func applyPaymentFailureFilter(query *gorm.DB, filter *PaymentFailureFilter) *gorm.DB {
+	if len(filter.Statuses) > 0 {
+		query = query.Where("status IN ?", filter.Statuses)
+	}
+	if len(filter.Providers) > 0 {
+		query = query.Where("provider_id IN ?", filter.Providers)
+	}
+	if len(filter.Reasons) > 0 {
+		query = query.Where("failure_reason IN ?", filter.Reasons)
+	}
+	if len(filter.Tags) > 0 {
+		// Containment keeps the GIN index on tags usable
+		if filter.MatchAllTags {
+			raw, _ := json.Marshal(filter.Tags)
+			query = query.Where("tags @> ?::jsonb", string(raw))
+		} else {
+			clauses := make([]string, len(filter.Tags))
+			args := make([]interface{}, len(filter.Tags))
+			for i, tag := range filter.Tags {
+				raw, _ := json.Marshal([]string{tag})
+				clauses[i] = "tags @> ?::jsonb"
+				args[i] = string(raw)
+			}
+			query = query.Where("("+strings.Join(clauses, " OR ")+")", args...)
+		}
+	}
+	if len(filter.Segments) > 0 {
+		clauses := make([]string, len(filter.Segments))
+		args := make([]interface{}, len(filter.Segments))
+		for i, segment := range filter.Segments {
+			clauses[i] = "p.segments @> ?::jsonb"
+			args[i] = segmentJSON(segment)
+		}
+		query = query.Where("customer_id IN (SELECT p.customer_id FROM customer_segment_profiles p "+
+			"WHERE p.company_id = payment_failure_events.company_id AND ("+strings.Join(clauses, " OR ")+"))", args...)
+	}
+	if filter.MinAmountCents != nil {
+		query = query.Where("amount_cents >= ?", *filter.MinAmountCents)
+	}
+	if filter.MaxAmountCents != nil {
+		query = query.Where("amount_cents <= ?", *filter.MaxAmountCents)
+	}
+	if filter.Currency != "" {
+		query = query.Where("currency = ?", strings.ToUpper(filter.Currency))
+	}
+	if filter.CustomerID != "" {
+		query = query.Where("customer_id = ?", filter.CustomerID)
+	}
+	if filter.CustomerEmail != "" {
+		query = query.Where("customer_email ILIKE ?", "%"+filter.CustomerEmail+"%")
+	}
+	if filter.StartDate != nil {
+		query = query.Where("created_at >= ?", *filter.StartDate)
+	}
+	if filter.EndDate != nil {
+		query = query.Where("created_at <= ?", *filter.EndDate)
+	}
+	if filter.DueFrom != nil {
+		query = query.Where("due_date >= ?", *filter.DueFrom)
+	}
+	if filter.DueTo != nil {
+		query = query.Where("due_date <= ?", *filter.DueTo)
+	}
+	if tsQuery := buildPrefixTSQuery(filter.Query); tsQuery != "" {
+		query = query.Where("search_vector @@ to_tsquery('simple', ?)", tsQuery)
+	}
+	return query
+}
+
+// buildPrefixTSQuery turns free text into a prefix-matching tsquery, e.g.
+// "acme inv-10" becomes "acme:* & inv-10:*". Operator characters are dropped.
+func buildPrefixTSQuery(text string) string {
+	var terms []string
+	for _, word := range strings.Fields(strings.ToLower(text)) {
+		cleaned := strings.Map(func(r rune) rune {
+			if unicode.IsLetter(r) || unicode.IsDigit(r) || strings.ContainsRune("@.-_", r) {
+				return r
+			}
+			return -1
+		}, word)
+		if cleaned != "" {
+			terms = append(terms, cleaned+":*")
+		}
+	}
+	return strings.Join(terms, " & ")
+}
+
+// orderClause renders the ORDER BY for sort, with id as the final tiebreaker
+func orderClause(sort []SortField) string {
+	parts := make([]string, 0, len(sort)+1)
+	for _, f := range sort {
+		dir := "ASC"
+		if f.Desc {
+			dir = "DESC"
+		}
+		parts = append(parts, paymentFailureSortColumns[f.Field].expr+" "+dir)
+	}
+	dir := "ASC"
+	if len(sort) > 0 && sort[len(sort)-1].Desc {
+		dir = "DESC"
+	}
+	return strings.Join(append(parts, "id "+dir), ", ")
+}
+
+// paymentFailureCursor holds the sort key of the last row on a page. Decoded
+// cursors hold the values cast to their sort columns' types.
+type paymentFailureCursor struct {
+	Values []interface{} `json:"v"`
+	ID     string        `json:"id"`
+}
+
+func encodeCursor(sort []SortField, f *models.PaymentFailureEvent) string {
+	cursor := paymentFailureCursor{ID: f.ID.String()}
+	for _, s := range sort {
+		cursor.Values = append(cursor.Values, sortValue(s.Field, f))
+	}
+	raw, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+func decodeCursor(sort []SortField, encoded string) (*paymentFailureCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("%w: malformed cursor", ErrInvalidPaymentFailureQuery)
+	}
+	var cursor paymentFailureCursor
+	if err := json.Unmarshal(raw, &cursor); err != nil || len(cursor.Values) != len(sort) || cursor.ID == "" {
+		return nil, fmt.Errorf("%w: cursor does not match sort order", ErrInvalidPaymentFailureQuery)
+	}
+	if _, err := uuid.Parse(cursor.ID); err != nil {
+		return nil, fmt.Errorf("%w: malformed cursor", ErrInvalidPaymentFailureQuery)
+	}
+	for i, s := range sort {
+		value, err := castCursorValue(paymentFailureSortColumns[s.Field].kind, cursor.Values[i])
+		if err != nil {
+			return nil, fmt.Errorf("%w: cursor value for %s: %v", ErrInvalidPaymentFailureQuery, s.Field, err)
+		}
+		cursor.Values[i] = value
+	}
+	return &cursor, nil
+}
+
+func sortValue(field string, f *models.PaymentFailureEvent) interface{} {
+	switch field {
+	case "created_at":
+		return f.CreatedAt.Format(time.RFC3339Nano)
+	case "updated_at":
+		return f.UpdatedAt.Format(time.RFC3339Nano)
+	case "due_date":
+		if f.DueDate == nil {
+			return "infinity"
+		}
+		return f.DueDate.Format(time.RFC3339Nano)
+	case "amount":
+		return f.AmountCents
+	case "retry_count":
+		return f.RetryCount
+	case "customer_name":
+		return f.CustomerName
+	case "status":
+		return f.Status
+	case "provider":
+		return f.ProviderID
+	case "failure_reason":
+		return f.FailureReason
+	}
+	return nil
+}
+
+// applyKeyset restricts query to rows strictly after cursor in the given sort order.
+// For sort (a DESC, b ASC, id ASC) this renders
+// (a < ?) OR (a = ? AND b > ?) OR (a = ? AND b = ? AND id > ?).
+func applyKeyset(query *gorm.DB, sort []SortField, cursor *paymentFailureCursor) *gorm.DB {
+	type key struct {
+		expr  string
+		desc  bool
+		value interface{}
+	}
+	keys := make([]key, 0, len(sort)+1)
+	for i, s := range sort {
+		col := paymentFailureSortColumns[s.Field]
+		keys = append(keys, key{expr: col.expr, desc: s.Desc, value: cursor.Values[i]})
+	}
+	idDesc := len(sort) > 0 && sort[len(sort)-1].Desc
+	keys = append(keys, key{expr: "id", desc: idDesc, value: cursor.ID})
+
+	var clauses []string
+	var args []interface{}
+	for i := range keys {
+		var parts []string
+		for j := 0; j < i; j++ {
+			parts = append(parts, keys[j].expr+" = ?")
+			args = append(args, keys[j].value)
+		}
+		op := ">"
+		if keys[i].desc {
+			op = "<"
+		}
+		parts = append(parts, keys[i].expr+" "+op+" ?")
+		args = append(args, keys[i].value)
+		clauses = append(clauses, "("+strings.Join(parts, " AND ")+")")
+	}
+
+	return query.Where(strings.Join(clauses, " OR "), args...)
+}
+
+// castCursorValue converts a value decoded from a cursor to the type of its sort
+// column, so a tampered cursor is rejected rather than sent to the database
+func castCursorValue(kind string, v interface{}) (interface{}, error) {
+	switch kind {
+	case "time":
+		s, ok := v.(string)
+		if !ok {
+			return nil, fmt.Errorf("%v is not a time", v)
+		}
+		if s == "infinity" {
+			return gorm.Expr("'infinity'::timestamptz"), nil
+		}
+		t, err := time.Parse(time.RFC3339Nano, s)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not a time", s)
+		}
+		return t, nil
+	case "int":
+		n, ok := v.(float64)
+		if !ok || n != math.Trunc(n) || math.Abs(n) > 1<<53 {
+			return nil, fmt.Errorf("%v is not an integer", v)
+		}
+		return int64(n), nil
+	default:
+		s, ok := v.(string)
+		if !ok {
+			return nil, fmt.Errorf("%v is not a string", v)
+		}
+		return s, nil
+	}
+}
@@ -0,0 +1,114 @@
+package services
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+
+	"github.com/sambitmohanty1/payment-watchdog/api/internal/models"
+)
+
+func dryRunDB(t *testing.T) *gorm.DB {
+	sqlDB, _, err := sqlmock.New()
+	require.NoError(t, err)
+	t.Cleanup(func() { sqlDB.Close() })
+
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: sqlDB}), &gorm.Config{DryRun: true})
+	require.NoError(t, err)
+	return db
+}
+
+func TestParseSortFields(t *testing.T) {
+	fields, err := ParseSortFields("-amount, created_at")
+	require.NoError(t, err)
+	assert.Equal(t, []SortField{{Field: "amount", Desc: true}, {Field: "created_at"}}, fields)
+
+	_, err = ParseSortFields("amount;drop table")
+	assert.ErrorIs(t, err, ErrInvalidPaymentFailureQuery)
+}
+
+func TestBuildPrefixTSQuery(t *testing.T) {
+	assert.Equal(t, "acme:* & inv-10:*", buildPrefixTSQuery("Acme INV-10"))
+	assert.Equal(t, "jane@example.com:*", buildPrefixTSQuery("jane@example.com"))
+	assert.Equal(t, "ab:*", buildPrefixTSQuery("a&b | !:*"))
+	assert.Equal(t, "", buildPrefixTSQuery("  "))
+}
+
+func TestCursorRoundTrip(t *testing.T) {
+	sort := []SortField{{Field: "amount", Desc: true}, {Field: "created_at"}}
+	failure := &models.PaymentFailureEvent{
+		ID:          uuid.New(),
+		AmountCents: 125000,
+		CreatedAt:   time.Date(2025, 3, 1, 10, 0, 0, 0, time.UTC),
+	}
+
+	cursor, err := decodeCursor(sort, encodeCursor(sort, failure))
+	require.NoError(t, err)
+	assert.Equal(t, failure.ID.String(), cursor.ID)
+	assert.Equal(t, int64(125000), cursor.Values[0])
+	assert.Equal(t, failure.CreatedAt, cursor.Values[1])
+
+	_, err = decodeCursor(sort[:1], encodeCursor(sort, failure))
+	assert.ErrorIs(t, err, ErrInvalidPaymentFailureQuery)
+
+	_, err = decodeCursor(sort, "not base64!")
+	assert.ErrorIs(t, err, ErrInvalidPaymentFailureQuery)
+}
+
+func TestDecodeCursorRejectsMistypedValues(t *testing.T) {
+	sort := []SortField{{Field: "amount", Desc: true}, {Field: "created_at"}}
+	encode := func(cursor paymentFailureCursor) string {
+		raw, err := json.Marshal(cursor)
+		require.NoError(t, err)
+		return base64.RawURLEncoding.EncodeToString(raw)
+	}
+	id := uuid.NewString()
+
+	for name, cursor := range map[string]paymentFailureCursor{
+		"string amount":     {Values: []interface{}{"500", "2025-03-01T10:00:00Z"}, ID: id},
+		"fractional amount": {Values: []interface{}{500.5, "2025-03-01T10:00:00Z"}, ID: id},
+		"numeric time":      {Values: []interface{}{500, 1740823200}, ID: id},
+		"malformed time":    {Values: []interface{}{500, "yesterday"}, ID: id},
+		"malformed id":      {Values: []interface{}{500, "2025-03-01T10:00:00Z"}, ID: "last"},
+	} {
+		_, err := decodeCursor(sort, encode(cursor))
+		assert.ErrorIs(t, err, ErrInvalidPaymentFailureQuery, name)
+	}
+
+	_, err := decodeCursor([]SortField{{Field: "customer_name"}}, encode(paymentFailureCursor{Values: []interface{}{42}, ID: id}))
+	assert.ErrorIs(t, err, ErrInvalidPaymentFailureQuery)
+}
+
+func TestApplyKeysetMixedDirections(t *testing.T) {
+	db := dryRunDB(t)
+	sort := []SortField{{Field: "amount", Desc: true}, {Field: "created_at"}}
+	cursor := &paymentFailureCursor{Values: []interface{}{int64(500), time.Date(2025, 3, 1, 10, 0, 0, 0, time.UTC)}, ID: uuid.NewString()}
+
+	var failures []models.PaymentFailureEvent
+	stmt := applyKeyset(db.Model(&models.PaymentFailureEvent{}), sort, cursor).
+		Order(orderClause(sort)).Find(&failures).Statement
+
+	sql := stmt.SQL.String()
+	assert.Contains(t, sql, "(COALESCE(amount_cents, 0) < $1) OR (COALESCE(amount_cents, 0) = $2 AND created_at > $3) OR (COALESCE(amount_cents, 0) = $4 AND created_at = $5 AND id > $6)")
+	assert.Contains(t, sql, "ORDER BY COALESCE(amount_cents, 0) DESC, created_at ASC, id ASC")
+}
+
+func TestApplySegmentFilter(t *testing.T) {
+	db := dryRunDB(t)
+	filter := &PaymentFailureFilter{Segments: []string{"vip", "hardship"}}
+
+	var failures []models.PaymentFailureEvent
+	stmt := applyPaymentFailureFilter(db.Model(&models.PaymentFailureEvent{}), filter).Find(&failures).Statement
+
+	assert.Contains(t, stmt.SQL.String(), "customer_id IN (SELECT p.customer_id FROM customer_segment_profiles p "+
+		"WHERE p.company_id = payment_failure_events.company_id AND (p.segments @> $1::jsonb OR p.segments @> $2::jsonb))")
+	assert.Equal(t, []interface{}{`["vip"]`, `["hardship"]`}, stmt.Vars)
+}
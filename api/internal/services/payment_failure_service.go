@@ -13,7 +13,6 @@ package services
 import (
 	"context"
 	"fmt"
-	"time"
 
 	"github.com/sambitmohanty1/payment-watchdog/api/internal/models"
 	"github.com/google/uuid"
@@ -35,62 +34,79 @@ func NewPaymentFailureService(db *gorm.DB, logger *zap.Logger) *PaymentFailureSe
 	}
 }
 
-// GetPaymentFailures returns payment failures with filtering and pagination
-func (s *PaymentFailureService) GetPaymentFailures(ctx context.Context, companyID string, filters map[string]interface{}, page, limit int) ([]models.PaymentFailureEvent, int64, error) {
-	s.logger.Info("GetPaymentFailures called",
-		zap.String("company_id", companyID),
-		zap.Int("page", page),
-		zap.Int("limit", limit))
-
-	var failures []models.PaymentFailureEvent
-	var total int64
-
-	// Build query
-	query := s.db.Model(&models.PaymentFailureEvent{}).Where("company_id = ?", companyID)
-	s.logger.Info("Database query built", zap.String("company_id", companyID))
-
-	// Apply filters
-	if status, ok := filters["status"].(string); ok && status != "" {
-		query = query.Where("status = ?", status)
+// GetPaymentFailures returns payment failures matching filter. Pages are offset-based
+// unless filter.Cursor is set, in which case keyset pagination over the sort order is
+// used and the total is only counted when requested.
+func (s *PaymentFailureService) GetPaymentFailures(ctx context.Context, companyID string, filter *PaymentFailureFilter) (*PaymentFailurePage, error) {
+	if filter == nil {
+		filter = &PaymentFailureFilter{}
 	}
-	if provider, ok := filters["provider_id"].(string); ok && provider != "" {
-		query = query.Where("provider_id = ?", provider)
+	if filter.Page < 1 {
+		filter.Page = 1
 	}
-	if customerEmail, ok := filters["customer_email"].(string); ok && customerEmail != "" {
-		query = query.Where("customer_email ILIKE ?", "%"+customerEmail+"%")
+	if filter.Limit < 1 || filter.Limit > 100 {
+		filter.Limit = 20
 	}
-	if failureReason, ok := filters["failure_reason"].(string); ok && failureReason != "" {
-		query = query.Where("failure_reason = ?", failureReason)
+	sort := filter.Sort
+	if len(sort) == 0 {
+		sort = []SortField{{Field: "created_at", Desc: true}}
 	}
-	if startDate, ok := filters["start_date"].(time.Time); ok {
-		query = query.Where("created_at >= ?", startDate)
-	}
-	if endDate, ok := filters["end_date"].(time.Time); ok {
-		query = query.Where("created_at <= ?", endDate)
+	for _, f := range sort {
+		if _, ok := paymentFailureSortColumns[f.Field]; !ok {
+			return nil, fmt.Errorf("%w: unknown sort field %q", ErrInvalidPaymentFailureQuery, f.Field)
+		}
 	}
 
+	s.logger.Info("GetPaymentFailures called",
+		zap.String("company_id", companyID),
+		zap.Int("page", filter.Page),
+		zap.Int("limit", filter.Limit),
+		zap.Bool("cursor", filter.Cursor != ""))
+
+	query := applyPaymentFailureFilter(
+		s.db.WithContext(ctx).Model(&models.PaymentFailureEvent{}).Where("company_id = ?", companyID),
+		filter,
+	)
+
+	result := &PaymentFailurePage{Limit: filter.Limit}
+
 	// Get total count
-	s.logger.Info("Executing count query")
-	if err := query.Count(&total).Error; err != nil {
-		s.logger.Error("Count query failed", zap.Error(err))
-		return nil, 0, fmt.Errorf("failed to count payment failures: %w", err)
+	if filter.Cursor == "" || filter.IncludeTotal {
+		if err := query.Session(&gorm.Session{}).Count(&result.Total).Error; err != nil {
+			s.logger.Error("Count query failed", zap.Error(err))
+			return nil, fmt.Errorf("failed to count payment failures: %w", err)
+		}
+	}
+
+	if filter.Cursor != "" {
+		cursor, err := decodeCursor(sort, filter.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		query = applyKeyset(query, sort, cursor)
+	} else {
+		result.Page = filter.Page
+		query = query.Offset((filter.Page - 1) * filter.Limit)
 	}
-	s.logger.Info("Count query successful", zap.Int64("total", total))
-
-	// Apply pagination and ordering
-	offset := (page - 1) * limit
-	s.logger.Info("Executing find query", zap.Int("offset", offset), zap.Int("limit", limit))
-	if err := query.Order("created_at DESC").
-		Offset(offset).
-		Limit(limit).
+
+	// Fetch one extra row to know whether another page exists
+	var failures []models.PaymentFailureEvent
+	if err := query.Order(orderClause(sort)).
+		Limit(filter.Limit + 1).
 		Find(&failures).Error; err != nil {
 		s.logger.Error("Find query failed", zap.Error(err))
-		return nil, 0, fmt.Errorf("failed to fetch payment failures: %w", err)
+		return nil, fmt.Errorf("failed to fetch payment failures: %w", err)
+	}
+
+	if len(failures) > filter.Limit {
+		failures = failures[:filter.Limit]
+		result.HasMore = true
+		result.NextCursor = encodeCursor(sort, &failures[len(failures)-1])
 	}
-	s.logger.Info("Find query successful", zap.Int("failures_count", len(failures)))
+	result.Failures = failures
 
-	s.logger.Info("GetPaymentFailures completed", zap.Int("failures_count", len(failures)), zap.Int64("total", total))
-	return failures, total, nil
+	s.logger.Info("GetPaymentFailures completed", zap.Int("failures_count", len(failures)), zap.Int64("total", result.Total))
+	return result, nil
 }
 
 // GetPaymentFailure returns a specific payment failure
@@ -3,8 +3,11 @@ package services
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
+	"math/rand"
+	"sort"
 	"sync"
 	"time"
 
@@ -13,6 +16,7 @@ import (
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
+	"gorm.io/datatypes"
 	"gorm.io/gorm"
 
 	"github.com/sambitmohanty1/payment-watchdog/api/internal/models"
@@ -24,6 +28,11 @@ type RecoveryOrchestrationService struct {
 	retryService          *RetryService
 	communicationService  *CommunicationService
 	analyticsService      *AnalyticsService
+	identityService       *CustomerIdentityService
+	decisions             *DecisionLogService
+	calendars             *BusinessCalendarService
+	segments              *CustomerSegmentService
+	timers                *WorkflowTimerService
 	stepExecutors         map[string]StepExecutor
 	tracer                trace.Tracer
 	logger                *zap.Logger
@@ -31,6 +40,9 @@ type RecoveryOrchestrationService struct {
 	activeExecutions      map[uuid.UUID]*WorkflowExecution
 	executionWorkers      int
 	workerPool            chan struct{}
+	companyLimit          int           // default per-company cap on executions running at once
+	wake                  chan struct{} // signals the execution workers that work was queued
+	instanceID            string        // prefix of the lease owner tokens this instance claims with
 }
 
 // WorkflowExecution represents an active workflow execution
@@ -45,6 +57,37 @@ type WorkflowExecution struct {
 	StartedAt         time.Time
 	CancelFunc        context.CancelFunc
 	mu                sync.RWMutex
+
+	// stepResults holds finished steps' results by step ID for the checkpoint
+	stepResults map[string]*StepResult
+	// outcomes holds the outcome of every settled step by step ID
+	outcomes map[string]string
+	// waits holds the branches parked on a timer by step ID
+	waits map[string]*checkpointWait
+	// served holds the timer kind this run resumed each step's branch from
+	served map[string]string
+	// path is the steps run so far and their outcomes, in order
+	path []models.ExecutionPathEntry
+	// lastError is why a failed execution failed
+	lastError string
+	// leaseOwner is the token of the claim this run holds the execution under
+	leaseOwner string
+	// effects carries out the steps' side effects; nil runs them live
+	effects stepEffects
+}
+
+// waitServed reports whether the execution resumed from a timer of the given
+// kind for step. A step's delay comes before its send time and its retries, so
+// resuming for either means the delay has passed too.
+func (e *WorkflowExecution) waitServed(stepID uuid.UUID, kind string) bool {
+	e.mu.RLock()
+	served, ok := e.served[stepID.String()]
+	e.mu.RUnlock()
+	if !ok {
+		return false
+	}
+	return served == kind ||
+		(kind == models.WorkflowTimerStepDelay && (served == models.WorkflowTimerSendTime || served == models.WorkflowTimerStepRetry))
 }
 
 // StepExecutor interface for different types of workflow steps
@@ -61,6 +104,17 @@ type StepResult struct {
 	NextDelay    time.Duration          `json:"next_delay,omitempty"`
 	ShouldRetry  bool                   `json:"should_retry,omitempty"`
 	ExternalID   string                 `json:"external_id,omitempty"`
+
+	// WaitUntil parks the step's branch until the given time on a durable
+	// timer; with nothing else to run, the execution suspends and releases its
+	// worker. The branch resumes with the step's successors, or with this step
+	// again when RerunStep is set.
+	WaitUntil *time.Time `json:"wait_until,omitempty"`
+	RerunStep bool       `json:"rerun_step,omitempty"`
+
+	// Outcome selects the successors the step hands over to, e.g. true or
+	// false for a conditional step. Empty means success or failure.
+	Outcome string `json:"outcome,omitempty"`
 }
 
 // TriggerCondition represents conditions for workflow triggering
@@ -74,6 +128,14 @@ type TriggerCondition struct {
 type WorkflowTriggerConditions struct {
 	Conditions []TriggerCondition `json:"conditions"`
 	Logic      string             `json:"logic"` // "AND" or "OR"
+
+	// Expression is an optional typed expression that must also hold, e.g.
+	// `amount > 500 && metadata.plan in ["gold", "platinum"]`
+	Expression string `json:"expression,omitempty"`
+}
+
+func (w WorkflowTriggerConditions) conditionSet() conditionSet {
+	return conditionSet{Conditions: w.Conditions, Logic: w.Logic, Expression: w.Expression}
 }
 
 // NewRecoveryOrchestrationService creates a new recovery orchestration service
@@ -82,6 +144,10 @@ func NewRecoveryOrchestrationService(
 	retryService *RetryService,
 	communicationService *CommunicationService,
 	analyticsService *AnalyticsService,
+	identityService *CustomerIdentityService,
+	decisions *DecisionLogService,
+	calendars *BusinessCalendarService,
+	segments *CustomerSegmentService,
 	logger *zap.Logger,
 ) *RecoveryOrchestrationService {
 	service := &RecoveryOrchestrationService{
@@ -89,18 +155,28 @@ func NewRecoveryOrchestrationService(
 		retryService:         retryService,
 		communicationService: communicationService,
 		analyticsService:     analyticsService,
+		identityService:      identityService,
+		decisions:            decisions,
+		calendars:            calendars,
+		segments:             segments,
 		stepExecutors:        make(map[string]StepExecutor),
 		tracer:               otel.Tracer("recovery-orchestration"),
+		logger:               logger,
 		activeExecutions:     make(map[uuid.UUID]*WorkflowExecution),
 		executionWorkers:     10, // Configurable
 		workerPool:           make(chan struct{}, 10),
+		companyLimit:         defaultCompanyExecutionLimit,
+		wake:                 make(chan struct{}, 1),
+		instanceID:           newInstanceID(),
 	}
+	service.timers = NewWorkflowTimerService(db, logger, service.resumeFromTimer)
 
 	// Register default step executors
 	service.RegisterStepExecutor(&PaymentRetryExecutor{service: service})
 	service.RegisterStepExecutor(&EmailExecutor{service: service})
 	service.RegisterStepExecutor(&SMSExecutor{service: service})
 	service.RegisterStepExecutor(&WaitExecutor{service: service})
+	service.RegisterStepExecutor(&ConditionalExecutor{service: service})
 
 	return service
 }
@@ -122,24 +198,48 @@ func (r *RecoveryOrchestrationService) TriggerWorkflowsForFailure(ctx context.Co
 		attribute.String("company_id", paymentFailure.CompanyID),
 	)
 
+	// Link the customer to its cross-provider identity before any contact is made
+	if paymentFailure.CustomerID != "" {
+		if identity, err := r.identityService.ResolveFromFailure(ctx, paymentFailure); err != nil {
+			span.RecordError(err)
+			log.Printf("Failed to resolve customer identity for failure %s: %v", paymentFailure.ID, err)
+		} else {
+			span.SetAttributes(attribute.String("customer_identity_id", identity.ID.String()))
+		}
+	}
+	r.resolveCustomerSegments(ctx, paymentFailure)
+
 	// Get active workflows for the company
 	var workflows []models.RecoveryWorkflow
 	if err := r.db.WithContext(ctx).
 		Where("company_id = ? AND is_active = ?", paymentFailure.CompanyID, true).
 		Order("priority DESC").
-		Preload("Steps", "is_active = ?", true).
+		Preload("Steps", currentVersionSteps, true).
 		Find(&workflows).Error; err != nil {
 		span.RecordError(err)
 		return fmt.Errorf("failed to get workflows: %w", err)
 	}
 
-	// Find matching workflows based on trigger conditions
+	// Find matching workflows based on trigger conditions. Shadow workflows are
+	// only logged with the steps they would have run; they are never started.
 	var matchingWorkflows []models.RecoveryWorkflow
+	var decisions []models.AutomationDecision
 	for _, workflow := range workflows {
-		if r.evaluateTriggerConditions(paymentFailure, workflow.TriggerConditions) {
+		matched := r.evaluateTriggerConditions(paymentFailure, workflow.TriggerConditions)
+		switch {
+		case workflow.Shadow:
+			decisions = append(decisions, newAutomationDecision(paymentFailure, models.DecisionSubjectWorkflow,
+				workflow.ID.String(), workflow.Name, models.DecisionModeShadow, matched, workflowStepTypes(workflow), nil))
+		case matched:
 			matchingWorkflows = append(matchingWorkflows, workflow)
+			decisions = append(decisions, newAutomationDecision(paymentFailure, models.DecisionSubjectWorkflow,
+				workflow.ID.String(), workflow.Name, models.DecisionModeLive, true, workflowStepTypes(workflow), nil))
 		}
 	}
+	if err := r.decisions.Record(ctx, decisions...); err != nil {
+		span.RecordError(err)
+		log.Printf("Failed to record workflow decisions for failure %s: %v", paymentFailure.ID, err)
+	}
 
 	span.SetAttributes(attribute.Int("matching_workflows", len(matchingWorkflows)))
 
@@ -154,19 +254,42 @@ func (r *RecoveryOrchestrationService) TriggerWorkflowsForFailure(ctx context.Co
 	return nil
 }
 
+// workflowStepTypes lists the types of a workflow's active steps in execution order
+func workflowStepTypes(workflow models.RecoveryWorkflow) []string {
+	steps := orderedSteps(&workflow)
+	types := make([]string, 0, len(steps))
+	for _, step := range steps {
+		types = append(types, step.StepType)
+	}
+	return types
+}
+
+// orderedSteps returns a workflow's active steps in execution order
+func orderedSteps(workflow *models.RecoveryWorkflow) []models.RecoveryWorkflowStep {
+	steps := make([]models.RecoveryWorkflowStep, 0, len(workflow.Steps))
+	for _, step := range workflow.Steps {
+		if step.IsActive {
+			steps = append(steps, step)
+		}
+	}
+	sort.SliceStable(steps, func(i, j int) bool { return steps[i].StepOrder < steps[j].StepOrder })
+	return steps
+}
+
 // StartWorkflowExecution starts a new workflow execution
 func (r *RecoveryOrchestrationService) StartWorkflowExecution(ctx context.Context, workflow *models.RecoveryWorkflow, paymentFailure *models.PaymentFailureEvent) error {
 	ctx, span := r.tracer.Start(ctx, "start_workflow_execution")
 	defer span.End()
 
-	// Create workflow execution record
+	// Queue the execution; whichever execution worker claims it first runs it
 	execution := &models.RecoveryWorkflowExecution{
 		ID:               uuid.New(),
 		WorkflowID:       workflow.ID,
 		PaymentFailureID: paymentFailure.ID,
 		CompanyID:        workflow.CompanyID,
+		WorkflowVersion:  workflow.CurrentVersion,
 		Status:           "pending",
-		TotalSteps:       len(workflow.Steps),
+		TotalSteps:       len(orderedSteps(workflow)),
 		StartedAt:        time.Now(),
 	}
 
@@ -175,30 +298,7 @@ func (r *RecoveryOrchestrationService) StartWorkflowExecution(ctx context.Contex
 		return fmt.Errorf("failed to create workflow execution: %w", err)
 	}
 
-	// Create active execution context
-	execCtx, cancel := context.WithCancel(ctx)
-	activeExecution := &WorkflowExecution{
-		ID:               execution.ID,
-		WorkflowID:       workflow.ID,
-		PaymentFailureID: paymentFailure.ID,
-		CompanyID:        workflow.CompanyID,
-		Status:           "running",
-		CurrentStepIndex: 0,
-		Context: map[string]interface{}{
-			"payment_failure": paymentFailure,
-			"workflow":        workflow,
-		},
-		StartedAt:  time.Now(),
-		CancelFunc: cancel,
-	}
-
-	// Store active execution
-	r.mu.Lock()
-	r.activeExecutions[execution.ID] = activeExecution
-	r.mu.Unlock()
-
-	// Start execution in goroutine
-	go r.executeWorkflow(execCtx, activeExecution, workflow)
+	r.wakeWorkers()
 
 	span.SetAttributes(
 		attribute.String("execution_id", execution.ID.String()),
@@ -208,11 +308,16 @@ func (r *RecoveryOrchestrationService) StartWorkflowExecution(ctx context.Contex
 	return nil
 }
 
-// executeWorkflow executes a workflow asynchronously
+// executeWorkflow runs the workflow's steps along its graph from the execution's
+// checkpoint. Each round runs every step whose predecessors have settled in
+// parallel, then checkpoints. A step that has to wait parks its branch on a
+// durable timer; once no other branch can run, the execution suspends until the
+// earliest one and returns, releasing its worker slot. Every write requires the
+// run's lease, which a heartbeat keeps alive; once another worker has taken the
+// execution over, the run stops silently.
 func (r *RecoveryOrchestrationService) executeWorkflow(ctx context.Context, execution *WorkflowExecution, workflow *models.RecoveryWorkflow) {
-	// Acquire a worker from the pool
-	r.workerPool <- struct{}{}
-	defer func() { <-r.workerPool }()
+	ctx, span := r.tracer.Start(ctx, "execute_workflow")
+	defer span.End()
 
 	// Set up logging and tracing
 	logger := r.logger.With(
@@ -222,158 +327,366 @@ func (r *RecoveryOrchestrationService) executeWorkflow(ctx context.Context, exec
 	)
 
 	// Update execution status to running
-	if err := r.updateExecutionStatus(ctx, execution.ID, "running"); err != nil {
+	if err := r.updateLeased(ctx, execution, map[string]interface{}{"status": "running"}); err != nil {
 		logger.Error("Failed to update execution status to running", zap.Error(err))
+		r.mu.Lock()
+		delete(r.activeExecutions, execution.ID)
+		r.mu.Unlock()
 		return
 	}
 
-	// Ensure the execution is cleaned up when done
+	// Record the outcome when the execution finishes or starts waiting
+	leaseLost := false
 	defer func() {
 		r.mu.Lock()
 		delete(r.activeExecutions, execution.ID)
 		r.mu.Unlock()
 
-		// Update execution status to completed
-		status := "completed"
-		if execution.Status == "failed" {
-			status = "failed"
+		if leaseLost {
+			logger.Warn("Workflow execution taken over by another instance",
+				zap.Int("step_index", execution.CurrentStepIndex))
+			return
 		}
-
-		if err := r.updateExecutionStatus(ctx, execution.ID, status); err != nil {
-			logger.Error("Failed to update execution status", 
-				zap.String("status", status), 
-				zap.Error(err))
+		if ctx.Err() != nil && execution.Status != "waiting" {
+			// Paused or cancelled; whoever stopped it recorded the status
+			execution.Status = "cancelled"
+		}
+		span.SetAttributes(
+			attribute.String("execution_id", execution.ID.String()),
+			attribute.String("final_status", execution.Status),
+		)
+
+		switch execution.Status {
+		case "waiting":
+			logger.Info("Workflow execution waiting",
+				zap.Int("step_index", execution.CurrentStepIndex))
+			return
+		case "cancelled":
+			logger.Info("Workflow execution stopped",
+				zap.Int("step_index", execution.CurrentStepIndex))
+			return
 		}
 
-		// Update completed at timestamp
-		if err := r.updateExecutionCompletedAt(ctx, execution.ID, time.Now()); err != nil {
-			logger.Error("Failed to update execution completed at", zap.Error(err))
+		if err := r.finishExecution(ctx, execution); err != nil {
+			logger.Error("Failed to update execution status",
+				zap.String("status", execution.Status),
+				zap.Error(err))
 		}
 
-		// Log workflow completion
 		duration := time.Since(execution.StartedAt)
 		if execution.Status == "completed" {
-			logger.Info("Workflow execution completed successfully", 
+			logger.Info("Workflow execution completed successfully",
 				zap.Duration("duration", duration),
-				zap.Int("steps_completed", execution.CurrentStepIndex))
-		} else if execution.Status == "failed" {
-			logger.Error("Workflow execution failed", 
+				zap.Int("steps_run", len(execution.path)))
+		} else {
+			logger.Error("Workflow execution failed",
 				zap.Duration("duration", duration),
-				zap.Int("steps_completed", execution.CurrentStepIndex))
+				zap.Int("steps_run", len(execution.path)))
 		}
 	}()
 
-	// Execute each step in sequence
-	for i := execution.CurrentStepIndex; i < len(workflow.Steps); i++ {
-		step := workflow.Steps[i]
-		
-		// Update current step
-		execution.CurrentStepIndex = i
-		if err := r.updateCurrentStep(ctx, execution.ID, &step.ID); err != nil {
-			logger.Error("Failed to update current step", 
-				zap.String("step_id", step.ID.String()),
+	// Keep the lease alive through long steps; stopped before the outcome is recorded
+	heartbeatCtx, stopHeartbeat := context.WithCancel(ctx)
+	defer stopHeartbeat()
+	go r.heartbeat(heartbeatCtx, execution)
+
+	graph, err := buildWorkflowGraph(workflow)
+	if err != nil {
+		logger.Error("Workflow graph is invalid", zap.Error(err))
+		execution.Status = "failed"
+		return
+	}
+	if len(execution.outcomes) == 0 && execution.CurrentStepIndex > 0 {
+		graph.adoptLinearCheckpoint(execution, execution.CurrentStepIndex)
+	}
+	graph.releaseDueWaits(execution, time.Now())
+
+	for {
+		if ctx.Err() != nil {
+			return
+		}
+		ready := graph.ready(execution.outcomes, execution.waits)
+		if len(ready) == 0 {
+			break
+		}
+
+		execution.mu.Lock()
+		execution.CurrentStepIndex = ready[0]
+		execution.mu.Unlock()
+
+		// Renew the lease before each round
+		if err := r.updateLeased(ctx, execution, map[string]interface{}{"current_step_id": graph.steps[ready[0]].ID}); err != nil {
+			logger.Error("Failed to update current step",
+				zap.String("step_id", graph.steps[ready[0]].ID.String()),
 				zap.Error(err))
+			leaseLost = errors.Is(err, errExecutionLeaseLost)
 			execution.Status = "failed"
 			return
 		}
 
-		// Execute the step
-		if err := r.executeStep(ctx, execution, &step); err != nil {
-			logger.Error("Step execution failed", 
-				zap.String("step_id", step.ID.String()),
-				zap.String("step_type", step.StepType),
-				zap.Error(err))
+		results := make([]roundResult, len(ready))
+		var wg sync.WaitGroup
+		for n, i := range ready {
+			wg.Add(1)
+			go func(n, i int) {
+				defer wg.Done()
+				results[n] = r.runGraphStep(ctx, execution, &graph.steps[i])
+			}(n, i)
+		}
+		wg.Wait()
+		if ctx.Err() != nil {
+			// Paused or cancelled mid-round; the checkpoint still points here
+			return
+		}
 
-			// Check if the step is marked as critical
-			if step.IsCritical {
-				execution.Status = "failed"
-				return
+		failed := false
+		for n, i := range ready {
+			step := &graph.steps[i]
+			res := results[n]
+			if res.parkKind != "" {
+				if res.parkKind == models.WorkflowTimerStepRetry {
+					logger.Warn("Step attempt failed, retrying",
+						zap.String("step_id", step.ID.String()),
+						zap.String("step_type", step.StepType),
+						zap.Time("retry_at", res.parkUntil))
+				}
+				execution.park(step, res.parkKind, res.parkUntil)
+				continue
+			}
+
+			execution.recordStepResult(step.ID, res.result)
+			outcome := stepOutcome(res.result, res.err)
+			if res.err != nil {
+				logger.Error("Step execution failed",
+					zap.String("step_id", step.ID.String()),
+					zap.String("step_type", step.StepType),
+					zap.String("outcome", outcome),
+					zap.Error(res.err))
+			}
+			execution.recordOutcome(step, outcome, time.Now())
+			if graph.failsExecution(i, outcome) {
+				failed = true
+				execution.lastError = fmt.Sprintf("critical step %s: %v", stepKey(step), stepFailure(res.result, res.err))
 			}
-			// For non-critical steps, continue to the next step
-			continue
+		}
+		if failed {
+			execution.Status = "failed"
+			return
 		}
 
-		// Update successful step count
-		if err := r.incrementExecutionCounter(ctx, execution.ID, "steps_completed"); err != nil {
-			logger.Warn("Failed to increment steps_completed counter", zap.Error(err))
+		if err := r.saveCheckpoint(ctx, execution); err != nil {
+			logger.Error("Failed to checkpoint execution", zap.Error(err))
+			leaseLost = errors.Is(err, errExecutionLeaseLost)
+			execution.Status = "failed"
+			return
 		}
 	}
 
-	// If we've reached here, all steps completed successfully
+	// Nothing can run until a parked branch's timer fires
+	if wait := execution.nextWait(); wait != nil {
+		if err := r.suspend(ctx, execution, wait); err != nil {
+			logger.Error("Failed to schedule workflow timer", zap.Error(err))
+			leaseLost = errors.Is(err, errExecutionLeaseLost)
+			execution.Status = "failed"
+		}
+		return
+	}
+
+	// Every branch has run to its end
 	execution.Status = "completed"
-	ctx, span := r.tracer.Start(ctx, "execute_workflow")
-	defer span.End()
+}
 
-	// Acquire worker slot
-	r.workerPool <- struct{}{}
-	defer func() { <-r.workerPool }()
+// roundResult is how one step of a round ended: run with a result, or parked
+// on a timer
+type roundResult struct {
+	result    *StepResult
+	err       error
+	parkKind  string
+	parkUntil time.Time
+}
 
-	defer func() {
-		// Clean up active execution
-		r.mu.Lock()
-		delete(r.activeExecutions, execution.ID)
-		r.mu.Unlock()
-	}()
+// runGraphStep runs one ready step, or parks it for its delay or for the wait
+// it asked for
+func (r *RecoveryOrchestrationService) runGraphStep(ctx context.Context, execution *WorkflowExecution, step *models.RecoveryWorkflowStep) roundResult {
+	// Hold the step for its delay on a durable timer
+	if step.DelayMinutes > 0 && !execution.waitServed(step.ID, models.WorkflowTimerStepDelay) {
+		return roundResult{
+			parkKind:  models.WorkflowTimerStepDelay,
+			parkUntil: time.Now().Add(time.Duration(step.DelayMinutes) * time.Minute),
+		}
+	}
 
-	// Update execution status
-	if err := r.updateExecutionStatus(ctx, execution.ID, "running"); err != nil {
-		log.Printf("Failed to update execution status: %v", err)
-		return
+	result, retryAt, err := r.executeStep(ctx, execution, step)
+	if retryAt != nil {
+		// The next attempt waits out its backoff on a timer
+		return roundResult{result: result, parkKind: models.WorkflowTimerStepRetry, parkUntil: *retryAt}
 	}
 
-	// Execute workflow steps
-	for i, step := range workflow.Steps {
-		if !step.IsActive {
-			continue
+	// Waits and deferred sends resume from a timer instead of holding the worker
+	if err == nil && result != nil && result.WaitUntil != nil && result.WaitUntil.After(time.Now()) {
+		kind := models.WorkflowTimerWait
+		if result.RerunStep {
+			kind = models.WorkflowTimerSendTime
+		} else {
+			execution.recordStepResult(step.ID, result)
 		}
+		return roundResult{parkKind: kind, parkUntil: *result.WaitUntil}
+	}
+	return roundResult{result: result, err: err}
+}
 
-		execution.mu.Lock()
-		execution.CurrentStepIndex = i
-		execution.mu.Unlock()
-
-		// Update current step in database
-		if err := r.updateCurrentStep(ctx, execution.ID, &step.ID); err != nil {
-			log.Printf("Failed to update current step: %v", err)
+// suspend marks the execution waiting, checkpointed with its parked branches,
+// and schedules the timer of the branch due first. Both commit together and
+// release the lease. The caller returns afterwards, releasing its worker slot.
+func (r *RecoveryOrchestrationService) suspend(ctx context.Context, execution *WorkflowExecution, wait *checkpointWait) error {
+	updates, err := execution.checkpointUpdates()
+	if err != nil {
+		return err
+	}
+	updates["status"] = "waiting"
+	updates["waiting_until"] = wait.Until
+	updates["lease_owner"] = nil
+	updates["lease_expires_at"] = nil
+
+	err = r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.RecoveryWorkflowExecution{}).
+			Where("id = ? AND lease_owner = ?", execution.ID, execution.leaseOwner).
+			Updates(updates)
+		if result.Error != nil {
+			return fmt.Errorf("failed to mark execution waiting: %w", result.Error)
 		}
-
-		// Execute step with delay if specified
-		if step.DelayMinutes > 0 {
-			select {
-			case <-time.After(time.Duration(step.DelayMinutes) * time.Minute):
-			case <-ctx.Done():
-				r.updateExecutionStatus(ctx, execution.ID, "cancelled")
-				return
-			}
+		if result.RowsAffected == 0 {
+			return errExecutionLeaseLost
 		}
+		return r.timers.ScheduleTx(tx, &models.WorkflowTimer{
+			CompanyID:           execution.CompanyID,
+			WorkflowExecutionID: execution.ID,
+			StepID:              wait.StepID,
+			Kind:                wait.Kind,
+			FireAt:              wait.Until,
+		})
+	})
+	if err != nil {
+		return err
+	}
+	execution.Status = "waiting"
+	return nil
+}
 
-		// Execute the step
-		if err := r.executeStep(ctx, execution, &step); err != nil {
-			log.Printf("Step execution failed: %v", err)
-			r.updateExecutionStatus(ctx, execution.ID, "failed")
-			return
+// resumeFromTimer queues a waiting execution again when its timer fires. It
+// continues from the checkpoint written when it suspended, releasing the
+// branches whose timers are due: after the step for a wait, or with the step
+// itself for a step delay or send time.
+func (r *RecoveryOrchestrationService) resumeFromTimer(ctx context.Context, timer *models.WorkflowTimer) error {
+	record, err := r.loadExecution(ctx, timer.WorkflowExecutionID)
+	if err != nil {
+		return fmt.Errorf("failed to load waiting execution: %w", err)
+	}
+	if record.Status != "waiting" {
+		// Paused, cancelled or already resumed
+		return nil
+	}
+
+	updates := map[string]interface{}{"status": "running", "waiting_until": nil}
+	cp, err := decodeCheckpoint(record.Checkpoint)
+	if err != nil {
+		return err
+	}
+	if cp == nil {
+		// Suspended before checkpoints were written; locate the step instead
+		cp, err = legacyTimerCheckpoint(&record.Workflow, timer)
+		if err != nil {
+			now := time.Now()
+			return r.db.WithContext(ctx).Model(&models.RecoveryWorkflowExecution{}).
+				Where("id = ? AND status = ?", record.ID, "waiting").
+				Updates(map[string]interface{}{
+					"status":        "failed",
+					"last_error":    err.Error(),
+					"waiting_until": nil,
+					"completed_at":  now,
+				}).Error
 		}
+		data, err := json.Marshal(cp)
+		if err != nil {
+			return fmt.Errorf("failed to encode execution checkpoint: %w", err)
+		}
+		updates["checkpoint"] = datatypes.JSON(data)
+	} else if !cp.parkedOn(timer) {
+		// A stale timer from an earlier wait
+		return nil
+	}
 
-		// Check if execution was cancelled
-		select {
-		case <-ctx.Done():
-			r.updateExecutionStatus(ctx, execution.ID, "cancelled")
-			return
-		default:
+	// Move it out of waiting so a duplicate timer cannot queue it twice
+	claim := r.db.WithContext(ctx).Model(&models.RecoveryWorkflowExecution{}).
+		Where("id = ? AND status = ?", record.ID, "waiting").
+		Updates(updates)
+	if claim.Error != nil {
+		return fmt.Errorf("failed to resume execution: %w", claim.Error)
+	}
+	if claim.RowsAffected > 0 {
+		r.wakeWorkers()
+	}
+	return nil
+}
+
+// legacyTimerCheckpoint builds the checkpoint of an execution that suspended on
+// timer before checkpoints existed
+func legacyTimerCheckpoint(workflow *models.RecoveryWorkflow, timer *models.WorkflowTimer) (*executionCheckpoint, error) {
+	steps := orderedSteps(workflow)
+	for i := range steps {
+		if steps[i].ID != timer.StepID {
+			continue
+		}
+		if timer.Kind == models.WorkflowTimerWait {
+			i++
 		}
+		return &executionCheckpoint{
+			NextStepIndex: i,
+			Wait:          &checkpointWait{StepID: timer.StepID, Kind: timer.Kind, Until: timer.FireAt},
+		}, nil
 	}
+	return nil, errors.New("waiting step is no longer part of the workflow")
+}
 
-	// Mark execution as completed
-	r.updateExecutionStatus(ctx, execution.ID, "completed")
-	r.updateExecutionCompletedAt(ctx, execution.ID, time.Now())
+// StartScheduler polls for due workflow timers every interval, and refires
+// timers stranded by stopped instances now and once per lease period, until
+// ctx is cancelled. Executions abandoned mid-run are taken over by the
+// execution workers once their lease expires.
+func (r *RecoveryOrchestrationService) StartScheduler(ctx context.Context, interval time.Duration) {
+	r.timers.Start(ctx, interval)
+
+	go func() {
+		reclaim := func() {
+			if resumed := r.refireTimers(ctx); resumed > 0 {
+				r.logger.Info("Refired stranded workflow timers", zap.Int("count", resumed))
+			}
+		}
+		reclaim()
 
-	span.SetAttributes(
-		attribute.String("execution_id", execution.ID.String()),
-		attribute.String("final_status", "completed"),
-	)
+		ticker := time.NewTicker(executionLeaseTTL)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				reclaim()
+			}
+		}
+	}()
 }
 
-// executeStep executes a single workflow step
-func (r *RecoveryOrchestrationService) executeStep(ctx context.Context, execution *WorkflowExecution, step *models.RecoveryWorkflowStep) error {
+// executeStep executes a single workflow step. A step deferring its send is
+// recorded as deferred and not counted; it runs again when its timer fires. An
+// attempt running past the step's TimeoutSeconds is cancelled as a timeout.
+//
+// Every attempt has its own step execution record, keyed by execution, step
+// and attempt, so a step that already finished before a crash replays its
+// recorded outcome instead of running again, and one interrupted mid-run
+// reuses its record. A failed attempt the step's retry policy covers returns
+// when the next attempt is due instead of its outcome; once attempts run out
+// the last one is recorded as exhausted.
+func (r *RecoveryOrchestrationService) executeStep(ctx context.Context, execution *WorkflowExecution, step *models.RecoveryWorkflowStep) (*StepResult, *time.Time, error) {
 	ctx, span := r.tracer.Start(ctx, "execute_step")
 	defer span.End()
 
@@ -383,19 +696,63 @@ func (r *RecoveryOrchestrationService) executeStep(ctx context.Context, executio
 		attribute.String("step_name", step.StepName),
 	)
 
-	// Create step execution record
-	stepExecution := &models.RecoveryStepExecution{
-		ID:                  uuid.New(),
-		WorkflowExecutionID: execution.ID,
-		StepID:              step.ID,
-		Status:              "running",
-		StartedAt:           time.Now(),
+	var previous []models.RecoveryStepExecution
+	if err := r.db.WithContext(ctx).
+		Where("workflow_execution_id = ? AND step_id = ?", execution.ID, step.ID).
+		Order("attempt DESC").
+		Limit(1).
+		Find(&previous).Error; err != nil {
+		span.RecordError(err)
+		return nil, nil, fmt.Errorf("failed to look up step execution: %w", err)
 	}
 
-	if err := r.db.WithContext(ctx).Create(stepExecution).Error; err != nil {
-		span.RecordError(err)
-		return fmt.Errorf("failed to create step execution: %w", err)
+	attempt := 1
+	var stepExecution *models.RecoveryStepExecution
+	if len(previous) > 0 {
+		last := &previous[0]
+		switch {
+		case stepFinished(last.Status):
+			span.SetAttributes(attribute.Bool("replayed", true))
+			result, err := replayedStepResult(last)
+			return result, nil, err
+		case last.Status == "retrying":
+			if last.NextAttemptAt != nil && last.NextAttemptAt.After(time.Now()) &&
+				!execution.waitServed(step.ID, models.WorkflowTimerStepRetry) {
+				// Resumed before the backoff ran out
+				return nil, last.NextAttemptAt, nil
+			}
+			attempt = last.Attempt + 1
+		default:
+			stepExecution = last
+			attempt = last.Attempt
+			stepExecution.Status = "running"
+			stepExecution.StartedAt = time.Now()
+			if err := r.db.WithContext(ctx).Model(stepExecution).
+				Updates(map[string]interface{}{"status": "running", "started_at": stepExecution.StartedAt}).Error; err != nil {
+				span.RecordError(err)
+				return nil, nil, fmt.Errorf("failed to restart step execution: %w", err)
+			}
+		}
 	}
+	if stepExecution == nil {
+		// Create step execution record
+		stepExecution = &models.RecoveryStepExecution{
+			ID:                  uuid.New(),
+			WorkflowExecutionID: execution.ID,
+			StepID:              step.ID,
+			Status:              "running",
+			StartedAt:           time.Now(),
+			Attempt:             attempt,
+			RetryCount:          attempt - 1,
+			IdempotencyKey:      attemptKey(execution.stepKey(step.ID), attempt),
+		}
+
+		if err := r.db.WithContext(ctx).Create(stepExecution).Error; err != nil {
+			span.RecordError(err)
+			return nil, nil, fmt.Errorf("failed to create step execution: %w", err)
+		}
+	}
+	span.SetAttributes(attribute.Int("attempt", attempt))
 
 	// Get step executor
 	r.mu.RLock()
@@ -406,13 +763,24 @@ func (r *RecoveryOrchestrationService) executeStep(ctx context.Context, executio
 		err := fmt.Errorf("no executor found for step type: %s", step.StepType)
 		r.updateStepExecutionStatus(ctx, stepExecution.ID, "failed", err.Error())
 		span.RecordError(err)
-		return err
+		return nil, nil, err
 	}
 
-	// Execute the step
+	// Execute the step, within its timeout if it has one
+	execCtx := ctx
+	if step.TimeoutSeconds > 0 {
+		var cancel context.CancelFunc
+		execCtx, cancel = context.WithTimeout(ctx, time.Duration(step.TimeoutSeconds)*time.Second)
+		defer cancel()
+	}
 	startTime := time.Now()
-	result, err := executor.Execute(ctx, execution, step)
+	result, err := executor.Execute(execCtx, execution, step)
 	duration := time.Since(startTime)
+	timedOut := errors.Is(execCtx.Err(), context.DeadlineExceeded) && ctx.Err() == nil
+	if timedOut && (err != nil || result == nil || !result.Success) {
+		err = fmt.Errorf("step timed out after %ds: %w", step.TimeoutSeconds, context.DeadlineExceeded)
+		result = &StepResult{Outcome: models.StepOutcomeTimeout, ErrorMessage: err.Error()}
+	}
 
 	// Update step execution with results
 	updateData := map[string]interface{}{
@@ -420,35 +788,91 @@ func (r *RecoveryOrchestrationService) executeStep(ctx context.Context, executio
 		"duration_ms":  duration.Milliseconds(),
 	}
 
-	if err != nil {
-		updateData["status"] = "failed"
-		updateData["error_message"] = err.Error()
-		span.RecordError(err)
-	} else {
+	var retryAt *time.Time
+	class := stepErrorClass(result, err)
+	policy := stepRetryPolicy(step)
+	switch {
+	case class == "":
 		updateData["status"] = "completed"
+		if result != nil && result.RerunStep && result.WaitUntil != nil {
+			updateData["status"] = "deferred"
+		}
+	case retriesClass(policy, class) && attempt < policy.MaxAttempts:
+		var requested time.Duration
 		if result != nil {
-			if resultJSON, jsonErr := json.Marshal(result); jsonErr == nil {
-				updateData["result"] = resultJSON
-			}
-			if result.ExternalID != "" {
-				updateData["external_id"] = result.ExternalID
-			}
+			requested = result.NextDelay
+		}
+		next := time.Now().Add(retryDelay(policy, attempt, requested, rand.Float64()))
+		retryAt = &next
+		updateData["status"] = "retrying"
+		updateData["next_attempt_at"] = next
+	case retriesClass(policy, class) && policy.MaxAttempts > 1:
+		updateData["status"] = "exhausted"
+		err = fmt.Errorf("step failed after %d attempts: %w", attempt, stepFailure(result, err))
+	default:
+		updateData["status"] = "failed"
+	}
+	if class != "" {
+		updateData["error_class"] = class
+		updateData["error_message"] = stepFailure(result, err).Error()
+		span.RecordError(stepFailure(result, err))
+	}
+	if result != nil && (class == "" || result.Outcome != "") {
+		// Failed results are kept when replays have to route the step the same way
+		if resultJSON, jsonErr := json.Marshal(result); jsonErr == nil {
+			updateData["result"] = resultJSON
 		}
 	}
+	if result != nil && class == "" && result.ExternalID != "" {
+		updateData["external_id"] = result.ExternalID
+	}
 
 	if updateErr := r.db.WithContext(ctx).Model(stepExecution).Updates(updateData).Error; updateErr != nil {
 		log.Printf("Failed to update step execution: %v", updateErr)
 	}
+	switch updateData["status"] {
+	case "deferred":
+		return result, nil, nil
+	case "retrying":
+		r.incrementExecutionCounter(ctx, execution.ID, "retry_count")
+		return result, retryAt, nil
+	}
 
 	// Update execution counters
-	if err != nil {
+	if class != "" {
 		r.incrementExecutionCounter(ctx, execution.ID, "failed_steps")
 	} else {
 		r.incrementExecutionCounter(ctx, execution.ID, "successful_steps")
 	}
 	r.incrementExecutionCounter(ctx, execution.ID, "completed_steps")
 
-	return err
+	return result, nil, err
+}
+
+// stepFailure is the error a failed attempt ended with
+func stepFailure(result *StepResult, err error) error {
+	if err != nil {
+		return err
+	}
+	if result != nil && result.ErrorMessage != "" {
+		return errors.New(result.ErrorMessage)
+	}
+	return errors.New("step was unsuccessful")
+}
+
+// resolveCustomerSegments sets the customer's segments on a payment failure that
+// does not carry them yet, so trigger conditions and conditional steps can use
+// them. Failures to load segments are logged and leave the failure unsegmented.
+func (r *RecoveryOrchestrationService) resolveCustomerSegments(ctx context.Context, paymentFailure *models.PaymentFailureEvent) {
+	if paymentFailure.CustomerID == "" || paymentFailure.CustomerSegments != nil {
+		return
+	}
+	segments, err := r.segments.CustomerSegments(ctx, paymentFailure.CompanyID, paymentFailure.CustomerID)
+	if err != nil {
+		log.Printf("Failed to load customer segments for failure %s: %v", paymentFailure.ID, err)
+		return
+	}
+	paymentFailure.CustomerSegments = segments
 }
 
 // evaluateTriggerConditions evaluates if a payment failure matches workflow trigger conditions
@@ -463,148 +887,15 @@ func (r *RecoveryOrchestrationService) evaluateTriggerConditions(paymentFailure
 		return false
 	}
 
-	results := make([]bool, len(conditions.Conditions))
-	for i, condition := range conditions.Conditions {
-		results[i] = r.evaluateCondition(paymentFailure, condition)
-	}
-
-	// Apply logic (AND/OR)
-	if conditions.Logic == "OR" {
-		for _, result := range results {
-			if result {
-				return true
-			}
-		}
-		return false
-	} else { // Default to AND
-		for _, result := range results {
-			if !result {
-				return false
-			}
-		}
-		return true
-	}
-}
-
-// evaluateCondition evaluates a single trigger condition
-func (r *RecoveryOrchestrationService) evaluateCondition(paymentFailure *models.PaymentFailureEvent, condition TriggerCondition) bool {
-	var fieldValue interface{}
-
-	// Extract field value from payment failure
-	switch condition.Field {
-	case "amount":
-		fieldValue = paymentFailure.Amount
-	case "currency":
-		fieldValue = paymentFailure.Currency
-	case "failure_reason":
-		fieldValue = paymentFailure.FailureReason
-	case "provider":
-		fieldValue = paymentFailure.Provider
-	case "customer_email":
-		fieldValue = paymentFailure.CustomerEmail
-	case "days_overdue":
-		if paymentFailure.DueDate != nil {
-			fieldValue = int(time.Since(*paymentFailure.DueDate).Hours() / 24)
-		}
-	default:
+	matched, err := conditions.conditionSet().evaluate(paymentFailure, time.Now())
+	if err != nil {
+		log.Printf("Failed to evaluate trigger conditions: %v", err)
 		return false
 	}
-
-	// Evaluate condition based on operator
-	switch condition.Operator {
-	case "eq", "equals":
-		return fieldValue == condition.Value
-	case "ne", "not_equals":
-		return fieldValue != condition.Value
-	case "gt", "greater_than":
-		return compareNumbers(fieldValue, condition.Value) > 0
-	case "gte", "greater_than_or_equal":
-		return compareNumbers(fieldValue, condition.Value) >= 0
-	case "lt", "less_than":
-		return compareNumbers(fieldValue, condition.Value) < 0
-	case "lte", "less_than_or_equal":
-		return compareNumbers(fieldValue, condition.Value) <= 0
-	case "contains":
-		if str, ok := fieldValue.(string); ok {
-			if substr, ok := condition.Value.(string); ok {
-				return contains(str, substr)
-			}
-		}
-	case "in":
-		if values, ok := condition.Value.([]interface{}); ok {
-			for _, v := range values {
-				if fieldValue == v {
-					return true
-				}
-			}
-		}
-	}
-
-	return false
-}
-
-// Helper functions
-func compareNumbers(a, b interface{}) int {
-	aFloat := toFloat64(a)
-	bFloat := toFloat64(b)
-	if aFloat < bFloat {
-		return -1
-	} else if aFloat > bFloat {
-		return 1
-	}
-	return 0
-}
-
-func toFloat64(v interface{}) float64 {
-	switch val := v.(type) {
-	case float64:
-		return val
-	case float32:
-		return float64(val)
-	case int:
-		return float64(val)
-	case int64:
-		return float64(val)
-	case int32:
-		return float64(val)
-	default:
-		return 0
-	}
-}
-
-func contains(str, substr string) bool {
-	return len(str) >= len(substr) && (str == substr || len(substr) == 0 || 
-		(len(substr) > 0 && findSubstring(str, substr)))
-}
-
-func findSubstring(str, substr string) bool {
-	for i := 0; i <= len(str)-len(substr); i++ {
-		if str[i:i+len(substr)] == substr {
-			return true
-		}
-	}
-	return false
+	return matched
 }
 
 // Database helper methods
-func (r *RecoveryOrchestrationService) updateExecutionStatus(ctx context.Context, executionID uuid.UUID, status string) error {
-	return r.db.WithContext(ctx).Model(&models.RecoveryWorkflowExecution{}).
-		Where("id = ?", executionID).
-		Update("status", status).Error
-}
-
-func (r *RecoveryOrchestrationService) updateCurrentStep(ctx context.Context, executionID uuid.UUID, stepID *uuid.UUID) error {
-	return r.db.WithContext(ctx).Model(&models.RecoveryWorkflowExecution{}).
-		Where("id = ?", executionID).
-		Update("current_step_id", stepID).Error
-}
-
-func (r *RecoveryOrchestrationService) updateExecutionCompletedAt(ctx context.Context, executionID uuid.UUID, completedAt time.Time) error {
-	return r.db.WithContext(ctx).Model(&models.RecoveryWorkflowExecution{}).
-		Where("id = ?", executionID).
-		Update("completed_at", completedAt).Error
-}
-
 func (r *RecoveryOrchestrationService) incrementExecutionCounter(ctx context.Context, executionID uuid.UUID, field string) error {
 	return r.db.WithContext(ctx).Model(&models.RecoveryWorkflowExecution{}).
 		Where("id = ?", executionID).
@@ -631,19 +922,52 @@ func (r *RecoveryOrchestrationService) CreateWorkflow(ctx context.Context, workf
 	ctx, span := r.tracer.Start(ctx, "create_workflow")
 	defer span.End()
 
+	if err := ValidateWorkflowConditions(workflow); err != nil {
+		span.RecordError(err)
+		return err
+	}
+	if err := ValidateWorkflowGraph(workflow); err != nil {
+		span.RecordError(err)
+		return err
+	}
+	if err := ValidateStepRetryPolicies(workflow); err != nil {
+		span.RecordError(err)
+		return err
+	}
+
 	workflow.ID = uuid.New()
+	workflow.CurrentVersion = 1
 	workflow.CreatedAt = time.Now()
 	workflow.UpdatedAt = time.Now()
 
-	// Set step workflow IDs
+	// Set step workflow IDs, and keys for steps not referenced by one
 	for i := range workflow.Steps {
+		workflow.Steps[i].Key = stepKey(&workflow.Steps[i])
 		workflow.Steps[i].ID = uuid.New()
 		workflow.Steps[i].WorkflowID = workflow.ID
+		workflow.Steps[i].Version = workflow.CurrentVersion
 		workflow.Steps[i].CreatedAt = time.Now()
 		workflow.Steps[i].UpdatedAt = time.Now()
 	}
 
-	if err := r.db.WithContext(ctx).Create(workflow).Error; err != nil {
+	// The workflow as created is its first version
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(workflow).Error; err != nil {
+			return err
+		}
+		return tx.Create(&models.RecoveryWorkflowVersion{
+			ID:                uuid.New(),
+			WorkflowID:        workflow.ID,
+			CompanyID:         workflow.CompanyID,
+			Version:           workflow.CurrentVersion,
+			Name:              workflow.Name,
+			Description:       workflow.Description,
+			TriggerConditions: workflow.TriggerConditions,
+			CreatedBy:         workflow.CreatedBy,
+			CreatedAt:         workflow.CreatedAt,
+		}).Error
+	})
+	if err != nil {
 		span.RecordError(err)
 		return fmt.Errorf("failed to create workflow: %w", err)
 	}
@@ -663,6 +987,9 @@ func (r *RecoveryOrchestrationService) GetWorkflows(ctx context.Context, company
 	if isActive, ok := filters["is_active"].(bool); ok {
 		query = query.Where("is_active = ?", isActive)
 	}
+	if shadow, ok := filters["shadow"].(bool); ok {
+		query = query.Where("shadow = ?", shadow)
+	}
 
 	// Get total count
 	if err := query.Count(&total).Error; err != nil {
@@ -672,7 +999,7 @@ func (r *RecoveryOrchestrationService) GetWorkflows(ctx context.Context, company
 	// Get paginated results
 	offset := (page - 1) * limit
 	if err := query.Offset(offset).Limit(limit).
-		Preload("Steps", "is_active = ?", true).
+		Preload("Steps", currentVersionSteps, true).
 		Order("priority DESC, created_at DESC").
 		Find(&workflows).Error; err != nil {
 		return nil, 0, err
@@ -686,19 +1013,40 @@ func (r *RecoveryOrchestrationService) GetWorkflow(ctx context.Context, workflow
 	var workflow models.RecoveryWorkflow
 	if err := r.db.WithContext(ctx).
 		Where("id = ? AND company_id = ?", workflowID, companyID).
-		Preload("Steps", "is_active = ?", true).
+		Preload("Steps", currentVersionSteps, true).
 		First(&workflow).Error; err != nil {
 		return nil, err
 	}
 	return &workflow, nil
 }
 
-// UpdateWorkflow updates an existing workflow
+// UpdateWorkflow updates an existing workflow's settings in place. A change to
+// its trigger conditions publishes a new version instead, so executions that
+// already started keep the version they matched.
 func (r *RecoveryOrchestrationService) UpdateWorkflow(ctx context.Context, workflowID, companyID uuid.UUID, updates map[string]interface{}) error {
+	conditionsJSON, changesConditions := updates["trigger_conditions"].([]byte)
+	if changesConditions {
+		if err := validateTriggerConditions(conditionsJSON); err != nil {
+			return err
+		}
+		delete(updates, "trigger_conditions")
+	}
 	updates["updated_at"] = time.Now()
-	return r.db.WithContext(ctx).Model(&models.RecoveryWorkflow{}).
-		Where("id = ? AND company_id = ?", workflowID, companyID).
-		Updates(updates).Error
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.RecoveryWorkflow{}).
+			Where("id = ? AND company_id = ?", workflowID, companyID).
+			Updates(updates).Error; err != nil {
+			return err
+		}
+		if !changesConditions {
+			return nil
+		}
+		_, err := publishVersion(tx, workflowID, companyID, WorkflowVersionChange{
+			TriggerConditions: conditionsJSON,
+			ChangeNote:        "Trigger conditions updated",
+		})
+		return err
+	})
 }
 
 // DeleteWorkflow soft deletes a workflow
@@ -723,6 +1071,7 @@ func (r *RecoveryOrchestrationService) TriggerWorkflowManually(ctx context.Conte
 	}
 
 	// Start workflow execution
+	r.resolveCustomerSegments(ctx, &paymentFailure)
 	return r.StartWorkflowExecution(ctx, workflow, &paymentFailure)
 }
 
@@ -810,6 +1159,26 @@ func (r *RecoveryOrchestrationService) GetWorkflowExecutions(ctx context.Context
 	return executions, total, nil
 }
 
+// ErrWorkflowExecutionNotFound is returned for executions the company does not have
+var ErrWorkflowExecutionNotFound = errors.New("workflow execution not found")
+
+// GetWorkflowExecution retrieves an execution with the path it has taken
+// through its workflow graph
+func (r *RecoveryOrchestrationService) GetWorkflowExecution(ctx context.Context, executionID, companyID uuid.UUID) (*models.RecoveryWorkflowExecution, error) {
+	var execution models.RecoveryWorkflowExecution
+	err := r.db.WithContext(ctx).
+		Where("id = ? AND company_id = ?", executionID, companyID).
+		Preload("Workflow").
+		First(&execution).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrWorkflowExecutionNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load workflow execution: %w", err)
+	}
+	return &execution, nil
+}
+
 func (r *RecoveryOrchestrationService) PauseWorkflowExecution(ctx context.Context, executionID uuid.UUID) error {
 	// Cancel active execution
 	r.mu.Lock()
@@ -819,22 +1188,27 @@ func (r *RecoveryOrchestrationService) PauseWorkflowExecution(ctx context.Contex
 	}
 	r.mu.Unlock()
 
+	// The checkpoint keeps any wait, which resuming schedules again
+	if err := r.timers.CancelExecution(ctx, executionID); err != nil {
+		return err
+	}
+
 	// Update database status
 	return r.db.WithContext(ctx).Model(&models.RecoveryWorkflowExecution{}).
 		Where("id = ?", executionID).
 		Updates(map[string]interface{}{
-			"status":    "paused",
-			"paused_at": time.Now(),
+			"status":           "paused",
+			"paused_at":        time.Now(),
+			"waiting_until":    nil,
+			"lease_owner":      nil,
+			"lease_expires_at": nil,
 		}).Error
 }
 
 func (r *RecoveryOrchestrationService) ResumeWorkflowExecution(ctx context.Context, executionID uuid.UUID) error {
 	// Get execution from database
-	var execution models.RecoveryWorkflowExecution
-	if err := r.db.WithContext(ctx).
-		Preload("Workflow.Steps", "is_active = ?", true).
-		Preload("PaymentFailure").
-		First(&execution, "id = ?", executionID).Error; err != nil {
+	execution, err := r.loadExecution(ctx, executionID)
+	if err != nil {
 		return fmt.Errorf("failed to get workflow execution: %w", err)
 	}
 
@@ -842,17 +1216,23 @@ func (r *RecoveryOrchestrationService) ResumeWorkflowExecution(ctx context.Conte
 		return fmt.Errorf("execution is not paused")
 	}
 
-	// Update status to running
-	if err := r.db.WithContext(ctx).Model(&execution).
+	// Queue it again; the worker that claims it continues from the step it was
+	// paused at
+	claim := r.db.WithContext(ctx).Model(&models.RecoveryWorkflowExecution{}).
+		Where("id = ? AND status = ?", executionID, "paused").
 		Updates(map[string]interface{}{
 			"status":    "running",
 			"paused_at": nil,
-		}).Error; err != nil {
-		return fmt.Errorf("failed to update execution status: %w", err)
+		})
+	if claim.Error != nil {
+		return fmt.Errorf("failed to update execution status: %w", claim.Error)
+	}
+	if claim.RowsAffected == 0 {
+		return fmt.Errorf("execution is not paused")
 	}
 
-	// Restart execution
-	return r.StartWorkflowExecution(ctx, &execution.Workflow, &execution.PaymentFailure)
+	r.wakeWorkers()
+	return nil
 }
 
 func (r *RecoveryOrchestrationService) CancelWorkflowExecution(ctx context.Context, executionID uuid.UUID) error {
@@ -864,6 +1244,16 @@ func (r *RecoveryOrchestrationService) CancelWorkflowExecution(ctx context.Conte
 	}
 	r.mu.Unlock()
 
+	if err := r.timers.CancelExecution(ctx, executionID); err != nil {
+		return err
+	}
+
 	// Update database status
-	return r.updateExecutionStatus(ctx, executionID, "cancelled")
+	return r.db.WithContext(ctx).Model(&models.RecoveryWorkflowExecution{}).
+		Where("id = ?", executionID).
+		Updates(map[string]interface{}{
+			"status":           "cancelled",
+			"lease_owner":      nil,
+			"lease_expires_at": nil,
+		}).Error
 }
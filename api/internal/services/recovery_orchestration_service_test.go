@@ -39,6 +39,10 @@ func MockRecoveryOrchestrationService(t *testing.T) (*svc.RecoveryOrchestrationS
 		retrySvc,
 		commSvc,
 		analyticsSvc,
+		svc.NewCustomerIdentityService(gormDB, logger),
+		svc.NewDecisionLogService(gormDB, logger),
+		svc.NewBusinessCalendarService(gormDB, logger),
+		svc.NewCustomerSegmentService(gormDB, logger),
 		logger,
 	)
 
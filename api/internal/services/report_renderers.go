@@ -0,0 +1,224 @@
+package services
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Report formats
+const (
+	ReportFormatPDF = "pdf"
+	ReportFormatCSV = "csv"
+)
+
+// renderReport renders report in format and returns the content and MIME type
+func renderReport(report *ScheduledReport, format string) ([]byte, string, error) {
+	switch format {
+	case ReportFormatPDF:
+		return renderReportPDF(reportLines(report)), "application/pdf", nil
+	case ReportFormatCSV:
+		content, err := renderReportCSV(report)
+		return content, "text/csv", err
+	}
+	return nil, "", fmt.Errorf("unsupported report format: %s", format)
+}
+
+// reportLines lays the report out as fixed-width text for the PDF and email body
+func reportLines(r *ScheduledReport) []string {
+	lines := []string{
+		"Payment Watchdog - " + r.ScheduleName,
+		fmt.Sprintf("Period: %s to %s (%s)", r.PeriodStart.Format("2 Jan 2006"), r.PeriodEnd.Format("2 Jan 2006"), r.Timezone),
+		"",
+		"SUMMARY",
+		fmt.Sprintf("  %-28s %16d", "Payment failures", r.TotalFailures),
+		fmt.Sprintf("  %-28s %16s", "Failed amount", formatCents(r.TotalFailedCents)),
+		fmt.Sprintf("  %-28s %16s", "Amount at risk", formatCents(r.AmountAtRiskCents)),
+		fmt.Sprintf("  %-28s %16s", "Recovered amount", formatAmount(r.RecoveredAmount)),
+		fmt.Sprintf("  %-28s %15.1f%%", "Recovery rate", r.RecoveryRate),
+		"",
+		"FAILURES BY REASON",
+		fmt.Sprintf("  %-36s %8s %16s", "Reason", "Count", "Amount"),
+	}
+	if len(r.FailuresByReason) == 0 {
+		lines = append(lines, "  No failures in this period")
+	}
+	for _, reason := range r.FailuresByReason {
+		lines = append(lines, fmt.Sprintf("  %-36s %8d %16s", truncateText(reason.Reason, 36), reason.Count, formatCents(reason.AmountCents)))
+	}
+
+	lines = append(lines, "",
+		"TOP OVERDUE CUSTOMERS",
+		fmt.Sprintf("  %-30s %7s %16s %12s", "Customer", "Overdue", "Amount", "Days overdue"),
+	)
+	if len(r.TopOverdueCustomers) == 0 {
+		lines = append(lines, "  No overdue customers")
+	}
+	for _, customer := range r.TopOverdueCustomers {
+		name := customer.CustomerName
+		if name == "" {
+			name = customer.CustomerID
+		}
+		lines = append(lines, fmt.Sprintf("  %-30s %7d %16s %12d", truncateText(name, 30), customer.OverdueCount, formatCents(customer.OverdueCents), customer.DaysOverdueMax))
+	}
+
+	return append(lines, "", "Generated "+r.GeneratedAt.Format(time.RFC1123))
+}
+
+// renderReportCSV writes the report as CSV. Text cells hold names and reasons
+// that come from customers and providers, so formulas in them are defused.
+func renderReportCSV(r *ScheduledReport) ([]byte, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+
+	records := [][]string{
+		{"report", escapeCSVFormula(r.ScheduleName)},
+		{"period_start", r.PeriodStart.Format(time.RFC3339)},
+		{"period_end", r.PeriodEnd.Format(time.RFC3339)},
+		{"timezone", escapeCSVFormula(r.Timezone)},
+		{},
+		{"metric", "value"},
+		{"total_failures", strconv.FormatInt(r.TotalFailures, 10)},
+		{"failed_amount", centsToDecimal(r.TotalFailedCents)},
+		{"amount_at_risk", centsToDecimal(r.AmountAtRiskCents)},
+		{"recovered_amount", strconv.FormatFloat(r.RecoveredAmount, 'f', 2, 64)},
+		{"recovery_rate", strconv.FormatFloat(r.RecoveryRate, 'f', 2, 64)},
+		{},
+		{"failure_reason", "count", "amount"},
+	}
+	for _, reason := range r.FailuresByReason {
+		records = append(records, []string{escapeCSVFormula(reason.Reason), strconv.FormatInt(reason.Count, 10), centsToDecimal(reason.AmountCents)})
+	}
+	records = append(records, []string{},
+		[]string{"customer_id", "customer_name", "customer_email", "overdue_count", "overdue_amount", "oldest_due_date", "days_overdue"})
+	for _, c := range r.TopOverdueCustomers {
+		records = append(records, []string{
+			escapeCSVFormula(c.CustomerID), escapeCSVFormula(c.CustomerName), escapeCSVFormula(c.CustomerEmail),
+			strconv.FormatInt(c.OverdueCount, 10), centsToDecimal(c.OverdueCents),
+			c.OldestDueDate.Format("2006-01-02"), strconv.Itoa(c.DaysOverdueMax),
+		})
+	}
+
+	if err := w.WriteAll(records); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+const (
+	pdfPageWidth    = 612 // US Letter in points
+	pdfPageHeight   = 792
+	pdfMargin       = 50
+	pdfFontSize     = 9
+	pdfLineHeight   = 12
+	pdfLinesPerPage = (pdfPageHeight - 2*pdfMargin) / pdfLineHeight
+)
+
+// renderReportPDF writes lines to a minimal single-font PDF, paginating as needed.
+// Courier keeps the columns from reportLines aligned.
+func renderReportPDF(lines []string) []byte {
+	var pages [][]string
+	for len(lines) > pdfLinesPerPage {
+		pages = append(pages, lines[:pdfLinesPerPage])
+		lines = lines[pdfLinesPerPage:]
+	}
+	pages = append(pages, lines)
+
+	var buf bytes.Buffer
+	var offsets []int
+	writeObj := func(body string) {
+		offsets = append(offsets, buf.Len())
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+
+	buf.WriteString("%PDF-1.4\n")
+
+	// Objects 1-3 are the catalog, page tree and font; each page then takes a
+	// page object followed by its content stream
+	kids := make([]string, len(pages))
+	for i := range pages {
+		kids[i] = fmt.Sprintf("%d 0 R", 4+2*i)
+	}
+	writeObj("<< /Type /Catalog /Pages 2 0 R >>")
+	writeObj(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(pages)))
+	writeObj("<< /Type /Font /Subtype /Type1 /BaseFont /Courier /Encoding /WinAnsiEncoding >>")
+
+	for i, page := range pages {
+		writeObj(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %d %d] /Resources << /Font << /F1 3 0 R >> >> /Contents %d 0 R >>",
+			pdfPageWidth, pdfPageHeight, 5+2*i))
+
+		var content bytes.Buffer
+		fmt.Fprintf(&content, "BT\n/F1 %d Tf\n%d TL\n%d %d Td\n", pdfFontSize, pdfLineHeight, pdfMargin, pdfPageHeight-pdfMargin)
+		for _, line := range page {
+			fmt.Fprintf(&content, "(%s) '\n", escapePDFText(line))
+		}
+		content.WriteString("ET")
+		writeObj(fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", content.Len(), content.String()))
+	}
+
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, off := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", off)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+	return buf.Bytes()
+}
+
+// escapePDFText escapes a PDF string literal, replacing characters outside
+// printable ASCII since the standard font is not embedded
+func escapePDFText(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r < 0x20 || r > 0x7e:
+			b.WriteByte('?')
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+func truncateText(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	return string(r[:n-1]) + "~"
+}
+
+func centsToDecimal(cents int64) string {
+	sign := ""
+	if cents < 0 {
+		sign, cents = "-", -cents
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, cents/100, cents%100)
+}
+
+// formatCents renders cents with thousands separators, e.g. 123456 -> "1,234.56"
+func formatCents(cents int64) string {
+	dec := centsToDecimal(cents)
+	sign := ""
+	if strings.HasPrefix(dec, "-") {
+		sign, dec = "-", dec[1:]
+	}
+	whole, frac := dec[:len(dec)-3], dec[len(dec)-3:]
+	for i := len(whole) - 3; i > 0; i -= 3 {
+		whole = whole[:i] + "," + whole[i:]
+	}
+	return sign + whole + frac
+}
+
+func formatAmount(amount float64) string {
+	if amount < 0 {
+		return "-" + formatCents(int64(-amount*100+0.5))
+	}
+	return formatCents(int64(amount*100 + 0.5))
+}
@@ -0,0 +1,100 @@
+package services
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"regexp"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func sampleScheduledReport() *ScheduledReport {
+	end := time.Date(2025, 3, 10, 8, 0, 0, 0, time.UTC)
+	return &ScheduledReport{
+		ScheduleName:      "Weekly summary",
+		Timezone:          "UTC",
+		PeriodStart:       end.AddDate(0, 0, -7),
+		PeriodEnd:         end,
+		GeneratedAt:       end,
+		TotalFailures:     3,
+		TotalFailedCents:  1234567,
+		AmountAtRiskCents: 99900,
+		RecoveredAmount:   250.5,
+		RecoveryRate:      33.3,
+		FailuresByReason: []ReasonBreakdown{
+			{Reason: "card_declined", Count: 2, AmountCents: 1000000},
+			{Reason: "insufficient_funds (retry)", Count: 1, AmountCents: 234567},
+		},
+		TopOverdueCustomers: []OverdueCustomer{
+			{CustomerID: "cus_1", CustomerName: "Acme Pty Ltd", OverdueCount: 2, OverdueCents: 99900,
+				OldestDueDate: end.AddDate(0, 0, -12), DaysOverdueMax: 12},
+		},
+	}
+}
+
+func TestFormatCents(t *testing.T) {
+	assert.Equal(t, "0.05", formatCents(5))
+	assert.Equal(t, "12,345.67", formatCents(1234567))
+	assert.Equal(t, "-1,000.00", formatCents(-100000))
+	assert.Equal(t, "250.50", formatAmount(250.5))
+}
+
+func TestRenderReportCSV(t *testing.T) {
+	content, contentType, err := renderReport(sampleScheduledReport(), ReportFormatCSV)
+	require.NoError(t, err)
+	assert.Equal(t, "text/csv", contentType)
+
+	r := csv.NewReader(bytes.NewReader(content))
+	r.FieldsPerRecord = -1
+	records, err := r.ReadAll()
+	require.NoError(t, err)
+
+	assert.Contains(t, records, []string{"amount_at_risk", "999.00"})
+	assert.Contains(t, records, []string{"card_declined", "2", "10000.00"})
+	assert.Contains(t, records, []string{"cus_1", "Acme Pty Ltd", "", "2", "999.00", "2025-02-26", "12"})
+}
+
+func TestRenderReportCSVEscapesFormulas(t *testing.T) {
+	report := sampleScheduledReport()
+	report.FailuresByReason[0].Reason = "@SUM(A1:A9)"
+	report.TopOverdueCustomers[0].CustomerName = `=HYPERLINK("https://evil.example","Click")`
+	report.TopOverdueCustomers[0].CustomerEmail = "+61@example.com"
+	report.TopOverdueCustomers[0].OverdueCents = -500
+
+	content, _, err := renderReport(report, ReportFormatCSV)
+	require.NoError(t, err)
+	r := csv.NewReader(bytes.NewReader(content))
+	r.FieldsPerRecord = -1
+	records, err := r.ReadAll()
+	require.NoError(t, err)
+
+	assert.Contains(t, records, []string{"'@SUM(A1:A9)", "2", "10000.00"})
+	assert.Contains(t, records, []string{"cus_1", `'=HYPERLINK("https://evil.example","Click")`, "'+61@example.com", "2", "-5.00", "2025-02-26", "12"})
+}
+
+func TestRenderReportPDF(t *testing.T) {
+	report := sampleScheduledReport()
+	for i := 0; i < 80; i++ {
+		report.FailuresByReason = append(report.FailuresByReason, ReasonBreakdown{Reason: fmt.Sprintf("reason_%d", i), Count: 1})
+	}
+
+	content, contentType, err := renderReport(report, ReportFormatPDF)
+	require.NoError(t, err)
+	assert.Equal(t, "application/pdf", contentType)
+	assert.True(t, bytes.HasPrefix(content, []byte("%PDF-1.4")))
+	assert.True(t, bytes.HasSuffix(content, []byte("%%EOF\n")))
+	assert.Contains(t, string(content), `(  insufficient_funds \(retry\)`)
+	assert.Contains(t, string(content), "/Count 2")
+
+	// startxref must point at the xref table
+	m := regexp.MustCompile(`startxref\n(\d+)\n`).FindSubmatch(content)
+	require.NotNil(t, m)
+	offset, err := strconv.Atoi(string(m[1]))
+	require.NoError(t, err)
+	assert.True(t, bytes.HasPrefix(content[offset:], []byte("xref\n")))
+}
@@ -0,0 +1,436 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/mail"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+
+	"github.com/sambitmohanty1/payment-watchdog/api/internal/models"
+)
+
+var (
+	// ErrReportScheduleNotFound is returned for unknown report schedules
+	ErrReportScheduleNotFound = errors.New("report schedule not found")
+	// ErrInvalidReportSchedule is returned when a schedule fails validation
+	ErrInvalidReportSchedule = errors.New("invalid report schedule")
+)
+
+// ScheduledReport is the content of a periodic summary report
+type ScheduledReport struct {
+	CompanyID    string    `json:"company_id"`
+	ScheduleID   uuid.UUID `json:"schedule_id"`
+	ScheduleName string    `json:"schedule_name"`
+	Timezone     string    `json:"timezone"`
+	PeriodStart  time.Time `json:"period_start"`
+	PeriodEnd    time.Time `json:"period_end"`
+	GeneratedAt  time.Time `json:"generated_at"`
+
+	TotalFailures       int64             `json:"total_failures"`
+	TotalFailedCents    int64             `json:"total_failed_cents"`
+	FailuresByReason    []ReasonBreakdown `json:"failures_by_reason"`
+	AmountAtRiskCents   int64             `json:"amount_at_risk_cents"`
+	RecoveredAmount     float64           `json:"recovered_amount"`
+	RecoveryRate        float64           `json:"recovery_rate"`
+	TopOverdueCustomers []OverdueCustomer `json:"top_overdue_customers"`
+}
+
+// ReportService builds summary reports and emails them on per-company schedules
+type ReportService struct {
+	db                *gorm.DB
+	recoveryAnalytics *RecoveryAnalyticsService
+	analytics         *AnalyticsService
+	communications    *CommunicationService
+	logger            *zap.Logger
+}
+
+// NewReportService creates a new report service
+func NewReportService(
+	db *gorm.DB,
+	recoveryAnalytics *RecoveryAnalyticsService,
+	analytics *AnalyticsService,
+	communications *CommunicationService,
+	logger *zap.Logger,
+) *ReportService {
+	return &ReportService{
+		db:                db,
+		recoveryAnalytics: recoveryAnalytics,
+		analytics:         analytics,
+		communications:    communications,
+		logger:            logger,
+	}
+}
+
+// CreateSchedule validates and stores a report schedule and computes its first run
+func (s *ReportService) CreateSchedule(ctx context.Context, schedule *models.ReportSchedule) error {
+	if err := s.prepareSchedule(ctx, schedule, time.Now()); err != nil {
+		return err
+	}
+	if err := s.db.WithContext(ctx).Create(schedule).Error; err != nil {
+		return fmt.Errorf("failed to create report schedule: %w", err)
+	}
+	return nil
+}
+
+// UpdateSchedule replaces the editable fields of a schedule and recomputes its next run
+func (s *ReportService) UpdateSchedule(ctx context.Context, companyID string, id uuid.UUID, update *models.ReportSchedule) (*models.ReportSchedule, error) {
+	schedule, err := s.GetSchedule(ctx, companyID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	schedule.Name = update.Name
+	schedule.CronExpression = update.CronExpression
+	schedule.Timezone = update.Timezone
+	schedule.Format = update.Format
+	schedule.Recipients = update.Recipients
+	schedule.PeriodDays = update.PeriodDays
+	schedule.TopCustomers = update.TopCustomers
+	schedule.IsActive = update.IsActive
+
+	if err := s.prepareSchedule(ctx, schedule, time.Now()); err != nil {
+		return nil, err
+	}
+	if err := s.db.WithContext(ctx).Save(schedule).Error; err != nil {
+		return nil, fmt.Errorf("failed to update report schedule: %w", err)
+	}
+	return schedule, nil
+}
+
+// GetSchedule returns a company's report schedule
+func (s *ReportService) GetSchedule(ctx context.Context, companyID string, id uuid.UUID) (*models.ReportSchedule, error) {
+	var schedule models.ReportSchedule
+	err := s.db.WithContext(ctx).Where("id = ? AND company_id = ?", id, companyID).First(&schedule).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrReportScheduleNotFound
+		}
+		return nil, fmt.Errorf("failed to get report schedule: %w", err)
+	}
+	return &schedule, nil
+}
+
+// ListSchedules returns a company's report schedules
+func (s *ReportService) ListSchedules(ctx context.Context, companyID string) ([]models.ReportSchedule, error) {
+	var schedules []models.ReportSchedule
+	if err := s.db.WithContext(ctx).Where("company_id = ?", companyID).Order("created_at").Find(&schedules).Error; err != nil {
+		return nil, fmt.Errorf("failed to list report schedules: %w", err)
+	}
+	return schedules, nil
+}
+
+// DeleteSchedule removes a schedule together with its delivery history
+func (s *ReportService) DeleteSchedule(ctx context.Context, companyID string, id uuid.UUID) error {
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("id = ? AND company_id = ?", id, companyID).Delete(&models.ReportSchedule{})
+		if result.Error != nil {
+			return fmt.Errorf("failed to delete report schedule: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return ErrReportScheduleNotFound
+		}
+		if err := tx.Where("schedule_id = ?", id).Delete(&models.ReportDelivery{}).Error; err != nil {
+			return fmt.Errorf("failed to delete report deliveries: %w", err)
+		}
+		return nil
+	})
+}
+
+// ListDeliveries returns the delivery history of a schedule, newest first
+func (s *ReportService) ListDeliveries(ctx context.Context, companyID string, scheduleID uuid.UUID, page, limit int) ([]models.ReportDelivery, int64, error) {
+	if _, err := s.GetSchedule(ctx, companyID, scheduleID); err != nil {
+		return nil, 0, err
+	}
+
+	var deliveries []models.ReportDelivery
+	var total int64
+	query := s.db.WithContext(ctx).Model(&models.ReportDelivery{}).Where("schedule_id = ?", scheduleID)
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count report deliveries: %w", err)
+	}
+	if err := query.Order("created_at DESC").Offset((page - 1) * limit).Limit(limit).Find(&deliveries).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to list report deliveries: %w", err)
+	}
+	return deliveries, total, nil
+}
+
+// prepareSchedule validates a schedule, applies defaults and sets NextRunAt after now
+func (s *ReportService) prepareSchedule(ctx context.Context, schedule *models.ReportSchedule, now time.Time) error {
+	if strings.TrimSpace(schedule.Name) == "" {
+		return fmt.Errorf("%w: name is required", ErrInvalidReportSchedule)
+	}
+
+	cron, err := ParseCronExpression(schedule.CronExpression)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidReportSchedule, err)
+	}
+
+	if schedule.Format == "" {
+		schedule.Format = ReportFormatPDF
+	}
+	if schedule.Format != ReportFormatPDF && schedule.Format != ReportFormatCSV {
+		return fmt.Errorf("%w: format must be pdf or csv", ErrInvalidReportSchedule)
+	}
+
+	if len(schedule.Recipients) == 0 {
+		return fmt.Errorf("%w: at least one recipient is required", ErrInvalidReportSchedule)
+	}
+	for i, recipient := range schedule.Recipients {
+		addr, err := mail.ParseAddress(recipient)
+		if err != nil {
+			return fmt.Errorf("%w: invalid recipient %q", ErrInvalidReportSchedule, recipient)
+		}
+		schedule.Recipients[i] = addr.Address
+	}
+
+	if schedule.Timezone != "" {
+		if _, err := time.LoadLocation(schedule.Timezone); err != nil {
+			return fmt.Errorf("%w: unknown timezone %q", ErrInvalidReportSchedule, schedule.Timezone)
+		}
+	}
+
+	if schedule.PeriodDays <= 0 {
+		schedule.PeriodDays = 7
+	}
+	if schedule.TopCustomers <= 0 {
+		schedule.TopCustomers = 10
+	}
+
+	next := cron.Next(now, s.scheduleLocation(ctx, schedule))
+	schedule.NextRunAt = &next
+	return nil
+}
+
+// scheduleLocation resolves the schedule's timezone, falling back to the company's
+// timezone and then UTC
+func (s *ReportService) scheduleLocation(ctx context.Context, schedule *models.ReportSchedule) *time.Location {
+	name := schedule.Timezone
+	if name == "" {
+		var company models.Company
+		if err := s.db.WithContext(ctx).Select("timezone").Where("id = ?", schedule.CompanyID).First(&company).Error; err == nil {
+			name = company.Timezone
+		}
+	}
+	if name == "" {
+		return time.UTC
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		s.logger.Warn("Unknown timezone for report schedule, using UTC",
+			zap.String("schedule_id", schedule.ID.String()),
+			zap.String("timezone", name))
+		return time.UTC
+	}
+	return loc
+}
+
+// BuildReport assembles the report for the PeriodDays calendar days ending at periodEnd
+func (s *ReportService) BuildReport(ctx context.Context, schedule *models.ReportSchedule, periodEnd time.Time) (*ScheduledReport, error) {
+	loc := s.scheduleLocation(ctx, schedule)
+	periodEnd = periodEnd.In(loc)
+	periodStart := periodEnd.AddDate(0, 0, -schedule.PeriodDays)
+
+	report := &ScheduledReport{
+		CompanyID:    schedule.CompanyID,
+		ScheduleID:   schedule.ID,
+		ScheduleName: schedule.Name,
+		Timezone:     loc.String(),
+		PeriodStart:  periodStart,
+		PeriodEnd:    periodEnd,
+		GeneratedAt:  time.Now().In(loc),
+	}
+
+	reasons, err := s.analytics.GetFailuresByReason(ctx, schedule.CompanyID, periodStart, periodEnd)
+	if err != nil {
+		return nil, err
+	}
+	report.FailuresByReason = reasons
+	for _, r := range reasons {
+		report.TotalFailures += r.Count
+		report.TotalFailedCents += r.AmountCents
+	}
+
+	if report.AmountAtRiskCents, err = s.analytics.GetAmountAtRisk(ctx, schedule.CompanyID); err != nil {
+		return nil, err
+	}
+
+	if report.TopOverdueCustomers, err = s.analytics.GetTopOverdueCustomers(ctx, schedule.CompanyID, periodEnd, schedule.TopCustomers); err != nil {
+		return nil, err
+	}
+
+	metrics, err := s.recoveryAnalytics.GetRecoveryMetrics(ctx, schedule.CompanyID, periodStart, periodEnd)
+	if err != nil {
+		// Failure data is still worth sending without recovery figures
+		s.logger.Warn("Failed to get recovery metrics for report",
+			zap.String("schedule_id", schedule.ID.String()),
+			zap.Error(err))
+	} else {
+		report.RecoveredAmount = metrics.RecoveryAmounts.TotalRecovered
+		report.RecoveryRate = metrics.RecoveryRate
+	}
+
+	return report, nil
+}
+
+// RunSchedule builds the report, emails it to every recipient and records the delivery.
+// scheduledFor is nil for manual runs.
+func (s *ReportService) RunSchedule(ctx context.Context, schedule *models.ReportSchedule, scheduledFor *time.Time) (*models.ReportDelivery, error) {
+	periodEnd := time.Now()
+	if scheduledFor != nil {
+		periodEnd = *scheduledFor
+	}
+
+	delivery := &models.ReportDelivery{
+		ID:           uuid.New(),
+		ScheduleID:   schedule.ID,
+		CompanyID:    schedule.CompanyID,
+		Format:       schedule.Format,
+		Recipients:   schedule.Recipients,
+		ScheduledFor: scheduledFor,
+	}
+
+	if err := s.deliver(ctx, schedule, periodEnd, delivery); err != nil {
+		delivery.Status = models.ReportDeliveryStatusFailed
+		delivery.ErrorMessage = err.Error()
+	}
+
+	if err := s.db.WithContext(ctx).Create(delivery).Error; err != nil {
+		return nil, fmt.Errorf("failed to record report delivery: %w", err)
+	}
+
+	s.logger.Info("Report delivery completed",
+		zap.String("schedule_id", schedule.ID.String()),
+		zap.String("status", delivery.Status),
+		zap.Int("recipients", len(delivery.Recipients)),
+		zap.Int("failed_recipients", len(delivery.FailedRecipients)))
+
+	return delivery, nil
+}
+
+func (s *ReportService) deliver(ctx context.Context, schedule *models.ReportSchedule, periodEnd time.Time, delivery *models.ReportDelivery) error {
+	report, err := s.BuildReport(ctx, schedule, periodEnd)
+	if err != nil {
+		return err
+	}
+	delivery.PeriodStart = report.PeriodStart
+	delivery.PeriodEnd = report.PeriodEnd
+	if summary, err := json.Marshal(report); err == nil {
+		delivery.Summary = summary
+	}
+
+	content, contentType, err := renderReport(report, schedule.Format)
+	if err != nil {
+		return err
+	}
+
+	attachment := EmailAttachment{
+		Filename:    fmt.Sprintf("payment-report-%s.%s", report.PeriodEnd.Format("2006-01-02"), schedule.Format),
+		ContentType: contentType,
+		Content:     content,
+	}
+	subject := fmt.Sprintf("%s: %s to %s", schedule.Name, report.PeriodStart.Format("2 Jan"), report.PeriodEnd.Format("2 Jan 2006"))
+	body := strings.Join(reportLines(report), "\n")
+
+	var errs []string
+	for _, recipient := range schedule.Recipients {
+		result, err := s.communications.SendInternalEmail(ctx, schedule.CompanyID, recipient, subject, body, []EmailAttachment{attachment})
+		if err != nil {
+			delivery.FailedRecipients = append(delivery.FailedRecipients, recipient)
+			errs = append(errs, fmt.Sprintf("%s: %v", recipient, err))
+			continue
+		}
+		delivery.MessageIDs = append(delivery.MessageIDs, result.MessageID)
+	}
+
+	switch {
+	case len(errs) == 0:
+		delivery.Status = models.ReportDeliveryStatusSent
+	case len(errs) < len(schedule.Recipients):
+		delivery.Status = models.ReportDeliveryStatusPartial
+		delivery.ErrorMessage = strings.Join(errs, "; ")
+	default:
+		return errors.New(strings.Join(errs, "; "))
+	}
+	now := time.Now()
+	delivery.SentAt = &now
+	return nil
+}
+
+// RunDueSchedules delivers every active schedule whose next run has passed. Each
+// schedule is claimed by advancing next_run_at first, so concurrent instances
+// never send the same run twice.
+func (s *ReportService) RunDueSchedules(ctx context.Context) (int, error) {
+	now := time.Now()
+
+	var due []models.ReportSchedule
+	if err := s.db.WithContext(ctx).
+		Where("is_active = ? AND next_run_at <= ?", true, now).
+		Order("next_run_at").
+		Find(&due).Error; err != nil {
+		return 0, fmt.Errorf("failed to find due report schedules: %w", err)
+	}
+
+	ran := 0
+	for i := range due {
+		schedule := &due[i]
+		scheduledFor := *schedule.NextRunAt
+
+		cron, err := ParseCronExpression(schedule.CronExpression)
+		if err != nil {
+			s.logger.Error("Skipping report schedule with invalid cron expression",
+				zap.String("schedule_id", schedule.ID.String()),
+				zap.Error(err))
+			continue
+		}
+		// Missed runs collapse into one; the next run is computed from now
+		next := cron.Next(now, s.scheduleLocation(ctx, schedule))
+
+		claim := s.db.WithContext(ctx).Model(&models.ReportSchedule{}).
+			Where("id = ? AND next_run_at = ?", schedule.ID, scheduledFor).
+			Updates(map[string]interface{}{"next_run_at": next, "last_run_at": now})
+		if claim.Error != nil {
+			s.logger.Error("Failed to claim report schedule",
+				zap.String("schedule_id", schedule.ID.String()),
+				zap.Error(claim.Error))
+			continue
+		}
+		if claim.RowsAffected == 0 {
+			continue
+		}
+
+		if _, err := s.RunSchedule(ctx, schedule, &scheduledFor); err != nil {
+			s.logger.Error("Failed to run report schedule",
+				zap.String("schedule_id", schedule.ID.String()),
+				zap.Error(err))
+			continue
+		}
+		ran++
+	}
+	return ran, nil
+}
+
+// Start polls for due schedules every interval until ctx is cancelled
+func (s *ReportService) Start(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if _, err := s.RunDueSchedules(ctx); err != nil {
+					s.logger.Error("Report scheduler run failed", zap.Error(err))
+				}
+			}
+		}
+	}()
+}
@@ -36,6 +36,9 @@ type RetryJob struct {
 	CreatedAt   time.Time              `json:"created_at" gorm:"column:created_at;autoCreateTime"`
 	UpdatedAt   time.Time              `json:"updated_at" gorm:"column:updated_at;autoUpdateTime"`
 	CompletedAt *time.Time             `json:"completed_at,omitempty" gorm:"column:completed_at"`
+
+	// IdempotencyKey makes submitting the same job twice return the first one
+	IdempotencyKey string `json:"idempotency_key,omitempty" gorm:"column:idempotency_key"`
 }
 
 // TableName specifies the table name for GORM
@@ -80,6 +83,12 @@ func NewRetryService(db *gorm.DB, maxRetries int, baseDelay, maxDelay time.Durat
 
 // SubmitJob submits a new job for retry processing
 func (r *RetryService) SubmitJob(ctx context.Context, jobType, companyID string, data map[string]interface{}) (*RetryJob, error) {
+	return r.SubmitJobOnce(ctx, "", jobType, companyID, data)
+}
+
+// SubmitJobOnce submits a job unless one was already submitted with the same
+// idempotency key, in which case that job is returned. An empty key always submits.
+func (r *RetryService) SubmitJobOnce(ctx context.Context, idempotencyKey, jobType, companyID string, data map[string]interface{}) (*RetryJob, error) {
 	// Safety check: ensure we have a valid database connection
 	if r.db == nil {
 		return nil, fmt.Errorf("database connection not available")
@@ -116,16 +125,32 @@ func (r *RetryService) SubmitJob(ctx context.Context, jobType, companyID string,
 		return job, nil
 	}
 
+	if idempotencyKey != "" {
+		var existing RetryJob
+		err := r.db.WithContext(ctx).
+			Select("id", "job_type", "company_id", "retry_count", "max_retries", "status", "error", "next_retry_at", "created_at", "updated_at", "completed_at", "idempotency_key").
+			Where("idempotency_key = ?", idempotencyKey).
+			Limit(1).
+			Find(&existing).Error
+		if err != nil {
+			return nil, fmt.Errorf("failed to check for submitted retry job: %w", err)
+		}
+		if existing.ID != "" {
+			return &existing, nil
+		}
+	}
+
 	job := &RetryJob{
-		ID:         uuid.New().String(),
-		JobType:    jobType,
-		CompanyID:  companyID,
-		Data:       data,
-		RetryCount: 0,
-		MaxRetries: r.maxRetries,
-		Status:     "pending",
-		CreatedAt:  time.Now(),
-		UpdatedAt:  time.Now(),
+		ID:             uuid.New().String(),
+		JobType:        jobType,
+		CompanyID:      companyID,
+		Data:           data,
+		RetryCount:     0,
+		MaxRetries:     r.maxRetries,
+		Status:         "pending",
+		CreatedAt:      time.Now(),
+		UpdatedAt:      time.Now(),
+		IdempotencyKey: idempotencyKey,
 	}
 
 	// Save to database
@@ -214,7 +239,8 @@ func (r *RetryService) executeAlertNotificationJob(ctx context.Context, job *Ret
 	}
 }
 
-// scheduleRetry schedules a job for retry with exponential backoff
+// scheduleRetry schedules a job for retry with exponential backoff. The retry
+// time is persisted and picked up by the workers' poll, so it survives restarts.
 func (r *RetryService) scheduleRetry(job *RetryJob, err error) {
 	job.RetryCount++
 	job.Status = "scheduled"
@@ -228,13 +254,7 @@ func (r *RetryService) scheduleRetry(job *RetryJob, err error) {
 	// Update database
 	if updateErr := r.db.Save(job).Error; updateErr != nil {
 		fmt.Printf("Failed to update retry job: %v\n", updateErr)
-		return
 	}
-
-	// Schedule retry
-	time.AfterFunc(delay, func() {
-		r.processJob(job)
-	})
 }
 
 // calculateDelay calculates the delay for the next retry using exponential backoff
@@ -288,11 +308,13 @@ func (r *RetryService) sendToDeadLetterQueue(job *RetryJob, err error) {
 	r.mu.Unlock()
 }
 
-// worker processes jobs from the active jobs map
+// worker polls the database for jobs whose retry is due
 func (r *RetryService) worker() {
-	// This worker processes jobs that are scheduled for retry
-	// In a real implementation, this would poll the database for scheduled jobs
-	ticker := time.NewTicker(10 * time.Second)
+	interval := 10 * time.Second
+	if r.baseDelay > 0 && r.baseDelay < interval {
+		interval = r.baseDelay
+	}
+	ticker := time.NewTicker(interval)
 	defer ticker.Stop()
 
 	for range ticker.C {
@@ -325,19 +347,28 @@ func (r *RetryService) processScheduledJobs() {
 		return
 	}
 
-	for _, job := range scheduledJobs {
-		// Check if job is still active
-		r.mu.RLock()
-		_, exists := r.activeJobs[job.ID]
-		r.mu.RUnlock()
+	for i := range scheduledJobs {
+		job := &scheduledJobs[i]
 
-		if !exists {
-			// Job was removed, skip
+		// Claim the job so other workers and instances skip it
+		claim := r.db.Model(&RetryJob{}).
+			Where("id = ? AND status = ?", job.ID, "scheduled").
+			Update("status", "running")
+		if claim.Error != nil {
+			fmt.Printf("Failed to claim retry job %s: %v\n", job.ID, claim.Error)
 			continue
 		}
+		if claim.RowsAffected == 0 {
+			continue
+		}
+		job.Status = "running"
 
-		// Process the job
-		go r.processJob(&job)
+		// Jobs scheduled before a restart are tracked again from here
+		r.mu.Lock()
+		r.activeJobs[job.ID] = job
+		r.mu.Unlock()
+
+		go r.processJob(job)
 	}
 }
 
@@ -0,0 +1,83 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/sambitmohanty1/payment-watchdog/api/internal/models"
+	"github.com/sambitmohanty1/payment-watchdog/api/internal/rules"
+)
+
+// ruleUsageWindow is how far back evaluation traces are counted when looking for
+// rules that never trigger
+const ruleUsageWindow = 30 * 24 * time.Hour
+
+// Analyze checks a company's rule set for overlapping, contradictory, unreachable
+// and unused rules. Version zero analyses the current, possibly unpublished,
+// definitions; any other version analyses that snapshot.
+func (s *RuleDefinitionService) Analyze(ctx context.Context, companyID string, version int) (*rules.RuleSetAnalysis, error) {
+	var records []models.RuleDefinitionRecord
+	if version > 0 {
+		snapshot, err := s.GetVersion(ctx, companyID, version)
+		if err != nil {
+			return nil, err
+		}
+		if records, err = ruleSetVersionRecords(snapshot); err != nil {
+			return nil, err
+		}
+	} else {
+		var err error
+		if records, err = s.List(ctx, companyID); err != nil {
+			return nil, err
+		}
+	}
+
+	usage, err := s.ruleUsage(ctx, companyID, time.Now().Add(-ruleUsageWindow))
+	if err != nil {
+		return nil, err
+	}
+	return rules.AnalyzeRuleSet(s.recordDefinitions(records), s.catalogue, usage), nil
+}
+
+// ruleUsage counts how often each company rule was evaluated and triggered since
+func (s *RuleDefinitionService) ruleUsage(ctx context.Context, companyID string, since time.Time) (map[string]rules.RuleUsage, error) {
+	var rows []struct {
+		RuleName  string
+		Evaluated int64
+		Triggered int64
+	}
+	if err := s.db.WithContext(ctx).Model(&models.RuleEvaluationTrace{}).
+		Select("rule_name, COUNT(*) AS evaluated, SUM(CASE WHEN triggered THEN 1 ELSE 0 END) AS triggered").
+		Where("company_id = ? AND engine = ? AND rule_set_version IS NOT NULL AND evaluated_at >= ?",
+			companyID, models.RuleTraceEngineBasic, since).
+		Group("rule_name").
+		Scan(&rows).Error; err != nil {
+		return nil, fmt.Errorf("failed to load rule usage: %w", err)
+	}
+
+	usage := make(map[string]rules.RuleUsage, len(rows))
+	for _, row := range rows {
+		usage[row.RuleName] = rules.RuleUsage{Evaluated: row.Evaluated, Triggered: row.Triggered}
+	}
+	return usage, nil
+}
+
+// recordDefinitions decodes stored definitions, skipping any that no longer decode
+func (s *RuleDefinitionService) recordDefinitions(records []models.RuleDefinitionRecord) []rules.RuleDefinition {
+	defs := make([]rules.RuleDefinition, 0, len(records))
+	for _, record := range records {
+		var def rules.RuleDefinition
+		if err := json.Unmarshal(record.Definition, &def); err != nil {
+			s.logger.Error("Skipping undecodable rule definition",
+				zap.String("rule_id", record.ID.String()),
+				zap.Error(err))
+			continue
+		}
+		defs = append(defs, def)
+	}
+	return defs
+}
@@ -0,0 +1,349 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+
+	"github.com/sambitmohanty1/payment-watchdog/api/internal/models"
+	"github.com/sambitmohanty1/payment-watchdog/api/internal/rules"
+)
+
+// ErrInvalidBacktest is returned for backtest requests with an unusable range or options
+var ErrInvalidBacktest = errors.New("invalid backtest request")
+
+const (
+	defaultBacktestSampleSize = 5
+	maxBacktestSampleSize     = 50
+	maxBacktestRange          = 366 * 24 * time.Hour
+	backtestBatchSize         = 500
+)
+
+// maxBacktestEvents bounds the number of events a single backtest replays
+var maxBacktestEvents = 100000
+
+// errBacktestLimit stops batch iteration once maxBacktestEvents is reached
+var errBacktestLimit = errors.New("backtest event limit reached")
+
+// RuleBacktestRequest asks what a rule set would have matched in a date range
+type RuleBacktestRequest struct {
+	Start      time.Time
+	End        time.Time
+	Selection  RuleSetSelection
+	SampleSize int
+}
+
+// BacktestEventSample is a matched event included in backtest results
+type BacktestEventSample struct {
+	EventID       string    `json:"event_id"`
+	CreatedAt     time.Time `json:"created_at"`
+	AmountCents   int64     `json:"amount_cents"`
+	Currency      string    `json:"currency"`
+	CustomerEmail string    `json:"customer_email"`
+	FailureReason string    `json:"failure_reason"`
+	MatchedRules  []string  `json:"matched_rules"`
+}
+
+// RuleBacktestRuleStats summarises the matches of one candidate rule
+type RuleBacktestRuleStats struct {
+	RuleName    string                `json:"rule_name"`
+	Priority    int                   `json:"priority"`
+	Matches     int                   `json:"matches"`
+	AmountCents int64                 `json:"amount_cents"`
+	Samples     []BacktestEventSample `json:"samples"`
+}
+
+// RuleBacktestDiff compares the candidate rule set with the active one
+type RuleBacktestDiff struct {
+	AddedRules                 []string              `json:"added_rules"`
+	RemovedRules               []string              `json:"removed_rules"`
+	ActiveMatchedEvents        int                   `json:"active_matched_events"`
+	ActiveAmountCents          int64                 `json:"active_amount_cents"`
+	NewlyMatchedEvents         int                   `json:"newly_matched_events"`
+	NewlyMatchedAmountCents    int64                 `json:"newly_matched_amount_cents"`
+	NoLongerMatchedEvents      int                   `json:"no_longer_matched_events"`
+	NoLongerMatchedAmountCents int64                 `json:"no_longer_matched_amount_cents"`
+	ChangedEvents              int                   `json:"changed_events"`
+	NewlyMatchedSamples        []BacktestEventSample `json:"newly_matched_samples"`
+	NoLongerMatchedSamples     []BacktestEventSample `json:"no_longer_matched_samples"`
+}
+
+// RuleBacktestResult is the outcome of replaying historical failures through a rule set
+type RuleBacktestResult struct {
+	Start           time.Time               `json:"start"`
+	End             time.Time               `json:"end"`
+	EventsEvaluated int                     `json:"events_evaluated"`
+	MatchedEvents   int                     `json:"matched_events"`
+	AmountCents     int64                   `json:"amount_cents"`
+	Truncated       bool                    `json:"truncated"`
+	Rules           []RuleBacktestRuleStats `json:"rules"`
+	Diff            RuleBacktestDiff        `json:"diff"`
+}
+
+// RuleBacktestService replays historical payment failures through rule sets
+// without executing any rule actions
+type RuleBacktestService struct {
+	db          *gorm.DB
+	definitions *RuleDefinitionService
+	logger      *zap.Logger
+}
+
+// NewRuleBacktestService creates a new rule backtest service
+func NewRuleBacktestService(db *gorm.DB, definitions *RuleDefinitionService, logger *zap.Logger) *RuleBacktestService {
+	return &RuleBacktestService{
+		db:          db,
+		definitions: definitions,
+		logger:      logger,
+	}
+}
+
+// Run replays the company's failures created in [Start, End] through the selected
+// rule set and the active rule set. Conditions on time-relative fields such as
+// days_overdue are evaluated as of now, not as of the original failure, and
+// customer segments are the customer's stored segments, not those it had then.
+func (s *RuleBacktestService) Run(ctx context.Context, companyID string, req RuleBacktestRequest) (*RuleBacktestResult, error) {
+	if req.End.IsZero() {
+		req.End = time.Now()
+	}
+	if req.Start.IsZero() {
+		req.Start = req.End.AddDate(0, 0, -30)
+	}
+	if !req.End.After(req.Start) {
+		return nil, fmt.Errorf("%w: end must be after start", ErrInvalidBacktest)
+	}
+	if req.End.Sub(req.Start) > maxBacktestRange {
+		return nil, fmt.Errorf("%w: range may not exceed 366 days", ErrInvalidBacktest)
+	}
+	switch {
+	case req.SampleSize == 0:
+		req.SampleSize = defaultBacktestSampleSize
+	case req.SampleSize < 0 || req.SampleSize > maxBacktestSampleSize:
+		return nil, fmt.Errorf("%w: sample_size must be between 1 and %d", ErrInvalidBacktest, maxBacktestSampleSize)
+	}
+
+	active, err := s.definitions.LoadRuleEngine(ctx, companyID)
+	if err != nil {
+		return nil, err
+	}
+	candidate := active
+	sel := req.Selection
+	if len(sel.RuleIDs) > 0 || len(sel.Drafts) > 0 {
+		if candidate, err = s.definitions.ComposeRuleEngine(ctx, companyID, sel); err != nil {
+			return nil, err
+		}
+	}
+
+	bt := newBacktest(req, candidate, active)
+	err = s.db.WithContext(ctx).
+		Where("company_id = ? AND created_at BETWEEN ? AND ?", companyID, req.Start, req.End).
+		FindInBatches(&[]models.PaymentFailureEvent{}, backtestBatchSize, func(tx *gorm.DB, batch int) error {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			events := *tx.Statement.Dest.(*[]models.PaymentFailureEvent)
+			if err := s.loadSegments(ctx, companyID, events); err != nil {
+				return err
+			}
+			for i := range events {
+				if bt.result.EventsEvaluated >= maxBacktestEvents {
+					bt.result.Truncated = true
+					return errBacktestLimit
+				}
+				bt.replay(&events[i])
+			}
+			return nil
+		}).Error
+	if err != nil && !errors.Is(err, errBacktestLimit) {
+		return nil, fmt.Errorf("failed to replay payment failures: %w", err)
+	}
+
+	s.logger.Info("Rule backtest completed",
+		zap.String("company_id", companyID),
+		zap.Int("events_evaluated", bt.result.EventsEvaluated),
+		zap.Int("matched_events", bt.result.MatchedEvents),
+		zap.Bool("truncated", bt.result.Truncated))
+	return bt.finish(), nil
+}
+
+// loadSegments sets the customer segments of events from the stored segment
+// profiles of their customers. Customers without a profile belong to no segments.
+func (s *RuleBacktestService) loadSegments(ctx context.Context, companyID string, events []models.PaymentFailureEvent) error {
+	var customerIDs []string
+	seen := make(map[string]bool)
+	for i := range events {
+		if id := events[i].CustomerID; id != "" && !seen[id] {
+			seen[id] = true
+			customerIDs = append(customerIDs, id)
+		}
+	}
+	if len(customerIDs) == 0 {
+		return nil
+	}
+
+	var profiles []models.CustomerSegmentProfile
+	if err := s.db.WithContext(ctx).Select("customer_id", "segments").
+		Where("company_id = ? AND customer_id IN ?", companyID, customerIDs).
+		Find(&profiles).Error; err != nil {
+		return fmt.Errorf("failed to load customer segments: %w", err)
+	}
+	segments := make(map[string][]string, len(profiles))
+	for _, profile := range profiles {
+		segments[profile.CustomerID] = profile.Segments
+	}
+	for i := range events {
+		events[i].CustomerSegments = segments[events[i].CustomerID]
+	}
+	return nil
+}
+
+// backtest accumulates replay results
+type backtest struct {
+	result     *RuleBacktestResult
+	candidate  rules.RuleEngine
+	active     rules.RuleEngine
+	stats      map[string]*RuleBacktestRuleStats
+	sampleSize int
+}
+
+func newBacktest(req RuleBacktestRequest, candidate, active rules.RuleEngine) *backtest {
+	bt := &backtest{
+		result:     &RuleBacktestResult{Start: req.Start, End: req.End},
+		candidate:  candidate,
+		active:     active,
+		stats:      make(map[string]*RuleBacktestRuleStats),
+		sampleSize: req.SampleSize,
+	}
+
+	candidateNames := make(map[string]bool)
+	for _, rule := range candidate.GetRules() {
+		if !rule.Enabled {
+			continue
+		}
+		candidateNames[rule.Name] = true
+		bt.result.Rules = append(bt.result.Rules, RuleBacktestRuleStats{
+			RuleName: rule.Name,
+			Priority: rule.Priority,
+			Samples:  []BacktestEventSample{},
+		})
+		if active.GetRuleByName(rule.Name) == nil {
+			bt.result.Diff.AddedRules = append(bt.result.Diff.AddedRules, rule.Name)
+		}
+	}
+	for i := range bt.result.Rules {
+		bt.stats[bt.result.Rules[i].RuleName] = &bt.result.Rules[i]
+	}
+	for _, rule := range active.GetRules() {
+		if rule.Enabled && !candidateNames[rule.Name] {
+			bt.result.Diff.RemovedRules = append(bt.result.Diff.RemovedRules, rule.Name)
+		}
+	}
+	return bt
+}
+
+func (bt *backtest) replay(event *models.PaymentFailureEvent) {
+	bt.result.EventsEvaluated++
+	diff := &bt.result.Diff
+
+	matched := ruleNames(rules.MatchRules(bt.candidate, event))
+	activeMatched := matched
+	if bt.active != bt.candidate {
+		activeMatched = ruleNames(rules.MatchRules(bt.active, event))
+	}
+
+	if len(matched) > 0 {
+		bt.result.MatchedEvents++
+		bt.result.AmountCents += event.AmountCents
+		for _, name := range matched {
+			stats := bt.stats[name]
+			stats.Matches++
+			stats.AmountCents += event.AmountCents
+			if len(stats.Samples) < bt.sampleSize {
+				stats.Samples = append(stats.Samples, backtestSample(event, matched))
+			}
+		}
+	}
+
+	if len(activeMatched) > 0 {
+		diff.ActiveMatchedEvents++
+		diff.ActiveAmountCents += event.AmountCents
+	}
+	switch {
+	case len(matched) > 0 && len(activeMatched) == 0:
+		diff.NewlyMatchedEvents++
+		diff.NewlyMatchedAmountCents += event.AmountCents
+		if len(diff.NewlyMatchedSamples) < bt.sampleSize {
+			diff.NewlyMatchedSamples = append(diff.NewlyMatchedSamples, backtestSample(event, matched))
+		}
+	case len(matched) == 0 && len(activeMatched) > 0:
+		diff.NoLongerMatchedEvents++
+		diff.NoLongerMatchedAmountCents += event.AmountCents
+		if len(diff.NoLongerMatchedSamples) < bt.sampleSize {
+			diff.NoLongerMatchedSamples = append(diff.NoLongerMatchedSamples, backtestSample(event, activeMatched))
+		}
+	case !sameStrings(matched, activeMatched):
+		diff.ChangedEvents++
+	}
+}
+
+func (bt *backtest) finish() *RuleBacktestResult {
+	diff := &bt.result.Diff
+	if diff.AddedRules == nil {
+		diff.AddedRules = []string{}
+	}
+	if diff.RemovedRules == nil {
+		diff.RemovedRules = []string{}
+	}
+	if diff.NewlyMatchedSamples == nil {
+		diff.NewlyMatchedSamples = []BacktestEventSample{}
+	}
+	if diff.NoLongerMatchedSamples == nil {
+		diff.NoLongerMatchedSamples = []BacktestEventSample{}
+	}
+	if bt.result.Rules == nil {
+		bt.result.Rules = []RuleBacktestRuleStats{}
+	}
+	return bt.result
+}
+
+func backtestSample(event *models.PaymentFailureEvent, matched []string) BacktestEventSample {
+	return BacktestEventSample{
+		EventID:       event.ID.String(),
+		CreatedAt:     event.CreatedAt,
+		AmountCents:   event.AmountCents,
+		Currency:      event.Currency,
+		CustomerEmail: event.CustomerEmail,
+		FailureReason: event.FailureReason,
+		MatchedRules:  matched,
+	}
+}
+
+func ruleNames(matched []*rules.Rule) []string {
+	names := make([]string, len(matched))
+	for i, rule := range matched {
+		names[i] = rule.Name
+	}
+	return names
+}
+
+// sameStrings reports whether two rule name lists hold the same names. Rules of
+// equal priority may be ordered differently by the two engines.
+func sameStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	sortedA := append([]string(nil), a...)
+	sortedB := append([]string(nil), b...)
+	sort.Strings(sortedA)
+	sort.Strings(sortedB)
+	for i := range sortedA {
+		if sortedA[i] != sortedB[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,129 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/sambitmohanty1/payment-watchdog/api/internal/models"
+	"github.com/sambitmohanty1/payment-watchdog/api/internal/rules"
+)
+
+func compileTestRuleSet(t *testing.T, sources ...string) rules.RuleEngine {
+	t.Helper()
+	defs := make([]rules.RuleDefinition, len(sources))
+	for i, src := range sources {
+		def, err := rules.ParseRuleDefinition([]byte(src), "json")
+		require.NoError(t, err)
+		defs[i] = *def
+	}
+	engine, err := rules.NewRuleEngineFactory(zap.NewNop()).CompileRuleSet(defs, rules.DefaultActionCatalogue())
+	require.NoError(t, err)
+	return engine
+}
+
+func TestBacktestReplayAndDiff(t *testing.T) {
+	active := compileTestRuleSet(t,
+		`{"name":"large","when":{"expr":"amount >= 1000"},"actions":[{"type":"alert"}]}`,
+		`{"name":"declined","when":{"field":"failure_reason","operator":"eq","value":"card_declined"},"actions":[{"type":"retry"}]}`,
+	)
+	candidate := compileTestRuleSet(t,
+		`{"name":"large","when":{"expr":"amount >= 500"},"actions":[{"type":"alert"}]}`,
+		`{"name":"vip","when":{"expr":"'vip' in tags"},"actions":[{"type":"escalate","params":{"team":"key_accounts"}}]}`,
+	)
+
+	bt := newBacktest(RuleBacktestRequest{SampleSize: 1}, candidate, active)
+	events := []*models.PaymentFailureEvent{
+		{ID: uuid.New(), AmountCents: 60000, FailureReason: "insufficient_funds"},           // newly matched by large
+		{ID: uuid.New(), AmountCents: 2000, FailureReason: "card_declined"},                 // no longer matched
+		{ID: uuid.New(), AmountCents: 150000, FailureReason: "card_declined"},               // changed: large+declined -> large
+		{ID: uuid.New(), AmountCents: 100, FailureReason: "expired", Tags: []string{"vip"}}, // newly matched by vip
+		{ID: uuid.New(), AmountCents: 100, FailureReason: "expired"},                        // matched by neither
+	}
+	for _, event := range events {
+		bt.replay(event)
+	}
+	result := bt.finish()
+
+	assert.Equal(t, 5, result.EventsEvaluated)
+	assert.Equal(t, 3, result.MatchedEvents)
+	assert.Equal(t, int64(60000+150000+100), result.AmountCents)
+
+	stats := map[string]RuleBacktestRuleStats{}
+	for _, s := range result.Rules {
+		stats[s.RuleName] = s
+	}
+	require.Len(t, stats, 2)
+	assert.Equal(t, 2, stats["large"].Matches)
+	assert.Equal(t, int64(210000), stats["large"].AmountCents)
+	assert.Len(t, stats["large"].Samples, 1, "samples are capped at sample_size")
+	assert.Equal(t, 1, stats["vip"].Matches)
+
+	diff := result.Diff
+	assert.Equal(t, []string{"vip"}, diff.AddedRules)
+	assert.Equal(t, []string{"declined"}, diff.RemovedRules)
+	assert.Equal(t, 2, diff.ActiveMatchedEvents)
+	assert.Equal(t, 2, diff.NewlyMatchedEvents)
+	assert.Equal(t, int64(60100), diff.NewlyMatchedAmountCents)
+	assert.Equal(t, 1, diff.NoLongerMatchedEvents)
+	assert.Equal(t, int64(2000), diff.NoLongerMatchedAmountCents)
+	assert.Equal(t, 1, diff.ChangedEvents)
+	require.Len(t, diff.NoLongerMatchedSamples, 1)
+	assert.Equal(t, []string{"declined"}, diff.NoLongerMatchedSamples[0].MatchedRules)
+}
+
+func TestBacktestStopsAtTerminalRules(t *testing.T) {
+	candidate := compileTestRuleSet(t,
+		`{"name":"large","priority":10,"when":{"expr":"amount >= 500"},"actions":[{"type":"alert"}]}`,
+		`{"name":"hardship","priority":100,"when":{"field":"customer_segments","operator":"contains","value":"hardship"},"actions":[{"type":"block"}]}`,
+		`{"name":"audit","priority":1,"shadow":true,"when":{"expr":"amount >= 500"},"actions":[{"type":"alert"}]}`,
+	)
+
+	hardship := &models.PaymentFailureEvent{ID: uuid.New(), AmountCents: 60000, CustomerSegments: []string{"hardship"}}
+	other := &models.PaymentFailureEvent{ID: uuid.New(), AmountCents: 60000}
+
+	// As live, the terminal rule stops lower-priority rules but not shadow rules
+	assert.Equal(t, []string{"hardship", "audit"}, ruleNames(rules.MatchRules(candidate, hardship)))
+	assert.Equal(t, []string{"large", "audit"}, ruleNames(rules.MatchRules(candidate, other)))
+}
+
+func TestBacktestLoadsStoredSegments(t *testing.T) {
+	db, mock := mockDB(t)
+	service := NewRuleBacktestService(db, nil, zap.NewNop())
+
+	mock.ExpectQuery(`SELECT "customer_id","segments" FROM "customer_segment_profiles" WHERE company_id = \$1 AND customer_id IN \(\$2,\$3\)`).
+		WithArgs("company", "cus_1", "cus_2").
+		WillReturnRows(sqlmock.NewRows([]string{"customer_id", "segments"}).AddRow("cus_1", `["vip"]`))
+
+	events := []models.PaymentFailureEvent{{CustomerID: "cus_1"}, {CustomerID: "cus_2"}, {CustomerID: "cus_1"}, {}}
+	require.NoError(t, service.loadSegments(context.Background(), "company", events))
+	assert.Equal(t, []string{"vip"}, events[0].CustomerSegments)
+	assert.Empty(t, events[1].CustomerSegments, "customers without a profile belong to no segments")
+	assert.Equal(t, []string{"vip"}, events[2].CustomerSegments)
+	assert.Empty(t, events[3].CustomerSegments)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestBacktestRejectsInvalidRanges(t *testing.T) {
+	service := NewRuleBacktestService(nil, nil, zap.NewNop())
+	now := time.Now()
+
+	tests := map[string]RuleBacktestRequest{
+		"end before start": {Start: now, End: now.Add(-time.Hour)},
+		"range too long":   {Start: now.AddDate(-2, 0, 0), End: now},
+		"sample size":      {Start: now.Add(-time.Hour), End: now, SampleSize: 500},
+	}
+	for name, req := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := service.Run(context.Background(), "company-1", req)
+			assert.True(t, errors.Is(err, ErrInvalidBacktest), "got %v", err)
+		})
+	}
+}
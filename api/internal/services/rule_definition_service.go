@@ -0,0 +1,310 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+
+	"github.com/sambitmohanty1/payment-watchdog/api/internal/models"
+	"github.com/sambitmohanty1/payment-watchdog/api/internal/rules"
+)
+
+var (
+	// ErrRuleDefinitionNotFound is returned for unknown rule definitions
+	ErrRuleDefinitionNotFound = errors.New("rule definition not found")
+	// ErrRuleDefinitionExists is returned when a company already has a rule with the same name
+	ErrRuleDefinitionExists = errors.New("rule definition with this name already exists")
+)
+
+// RuleDefinitionService stores declarative rules per company and compiles them into rule engines.
+// Every change records an immutable draft rule set version; only published versions are live.
+type RuleDefinitionService struct {
+	db        *gorm.DB
+	catalogue *rules.ActionCatalogue
+	factory   *rules.RuleEngineFactory
+	logger    *zap.Logger
+
+	// compiled caches each company's published rule set by version
+	compiledMu sync.Mutex
+	compiled   map[string]*ActiveRuleSet
+}
+
+// NewRuleDefinitionService creates a new rule definition service using the default action catalogue
+func NewRuleDefinitionService(db *gorm.DB, logger *zap.Logger) *RuleDefinitionService {
+	return &RuleDefinitionService{
+		db:        db,
+		catalogue: rules.DefaultActionCatalogue(),
+		factory:   rules.NewRuleEngineFactory(logger),
+		logger:    logger,
+		compiled:  make(map[string]*ActiveRuleSet),
+	}
+}
+
+// Catalogue returns the actions rule definitions may use
+func (s *RuleDefinitionService) Catalogue() *rules.ActionCatalogue {
+	return s.catalogue
+}
+
+// Validate parses and compiles a rule document without saving it
+func (s *RuleDefinitionService) Validate(source []byte, format string) (*rules.RuleDefinition, error) {
+	def, err := rules.ParseRuleDefinition(source, format)
+	if err != nil {
+		return nil, err
+	}
+	if err := rules.ValidateRuleDefinition(def, s.catalogue); err != nil {
+		return nil, err
+	}
+	return def, nil
+}
+
+// Create validates and stores a new rule definition and records a draft version
+func (s *RuleDefinitionService) Create(ctx context.Context, companyID string, source []byte, format, author string) (*models.RuleDefinitionRecord, error) {
+	def, err := s.Validate(source, format)
+	if err != nil {
+		return nil, err
+	}
+
+	var count int64
+	if err := s.db.WithContext(ctx).Model(&models.RuleDefinitionRecord{}).
+		Where("company_id = ? AND name = ?", companyID, def.Name).
+		Count(&count).Error; err != nil {
+		return nil, fmt.Errorf("failed to check rule definition name: %w", err)
+	}
+	if count > 0 {
+		return nil, ErrRuleDefinitionExists
+	}
+
+	record := &models.RuleDefinitionRecord{
+		ID:        uuid.New(),
+		CompanyID: companyID,
+		CreatedBy: author,
+		UpdatedBy: author,
+	}
+	if err := applyRuleDefinition(record, def, source, format); err != nil {
+		return nil, err
+	}
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(record).Error; err != nil {
+			return fmt.Errorf("failed to create rule definition: %w", err)
+		}
+		_, err := s.recordVersion(tx, companyID, author, "Create rule "+record.Name, nil)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("Rule definition created",
+		zap.String("company_id", companyID),
+		zap.String("rule_name", record.Name))
+	return record, nil
+}
+
+// Update validates and replaces an existing rule definition and records a draft version
+func (s *RuleDefinitionService) Update(ctx context.Context, companyID string, id uuid.UUID, source []byte, format, author string) (*models.RuleDefinitionRecord, error) {
+	def, err := s.Validate(source, format)
+	if err != nil {
+		return nil, err
+	}
+
+	record, err := s.Get(ctx, companyID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if def.Name != record.Name {
+		var count int64
+		if err := s.db.WithContext(ctx).Model(&models.RuleDefinitionRecord{}).
+			Where("company_id = ? AND name = ? AND id <> ?", companyID, def.Name, id).
+			Count(&count).Error; err != nil {
+			return nil, fmt.Errorf("failed to check rule definition name: %w", err)
+		}
+		if count > 0 {
+			return nil, ErrRuleDefinitionExists
+		}
+	}
+
+	record.UpdatedBy = author
+	if err := applyRuleDefinition(record, def, source, format); err != nil {
+		return nil, err
+	}
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(record).Error; err != nil {
+			return fmt.Errorf("failed to update rule definition: %w", err)
+		}
+		_, err := s.recordVersion(tx, companyID, author, "Update rule "+record.Name, nil)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return record, nil
+}
+
+// Get returns a company's rule definition
+func (s *RuleDefinitionService) Get(ctx context.Context, companyID string, id uuid.UUID) (*models.RuleDefinitionRecord, error) {
+	var record models.RuleDefinitionRecord
+	err := s.db.WithContext(ctx).Where("id = ? AND company_id = ?", id, companyID).First(&record).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrRuleDefinitionNotFound
+		}
+		return nil, fmt.Errorf("failed to get rule definition: %w", err)
+	}
+	return &record, nil
+}
+
+// List returns a company's rule definitions, highest priority first
+func (s *RuleDefinitionService) List(ctx context.Context, companyID string) ([]models.RuleDefinitionRecord, error) {
+	var records []models.RuleDefinitionRecord
+	if err := s.db.WithContext(ctx).
+		Where("company_id = ?", companyID).
+		Order("priority DESC, name").
+		Find(&records).Error; err != nil {
+		return nil, fmt.Errorf("failed to list rule definitions: %w", err)
+	}
+	return records, nil
+}
+
+// Delete removes a rule definition and records a draft version
+func (s *RuleDefinitionService) Delete(ctx context.Context, companyID string, id uuid.UUID, author string) error {
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var record models.RuleDefinitionRecord
+		if err := tx.Where("id = ? AND company_id = ?", id, companyID).First(&record).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrRuleDefinitionNotFound
+			}
+			return fmt.Errorf("failed to get rule definition: %w", err)
+		}
+		if err := tx.Delete(&record).Error; err != nil {
+			return fmt.Errorf("failed to delete rule definition: %w", err)
+		}
+		_, err := s.recordVersion(tx, companyID, author, "Delete rule "+record.Name, nil)
+		return err
+	})
+}
+
+// LoadRuleEngine compiles a company's published rule set into a RuleEngine.
+// Drafts are never live.
+func (s *RuleDefinitionService) LoadRuleEngine(ctx context.Context, companyID string) (rules.RuleEngine, error) {
+	ruleSet, err := s.LoadActiveRuleSet(ctx, companyID)
+	if err != nil {
+		return nil, err
+	}
+	return ruleSet.Engine, nil
+}
+
+// RuleSetSelection describes a rule set other than the active one: optionally the
+// published rules, plus stored definitions picked by ID whether or not they are
+// enabled or published, plus unsaved drafts. A stored definition or draft replaces
+// a rule of the same name.
+type RuleSetSelection struct {
+	IncludeActive bool
+	RuleIDs       []uuid.UUID
+	Drafts        []rules.RuleDefinition
+}
+
+// ComposeRuleEngine compiles a selected rule set into a RuleEngine
+func (s *RuleDefinitionService) ComposeRuleEngine(ctx context.Context, companyID string, selection RuleSetSelection) (rules.RuleEngine, error) {
+	engine := s.factory.CreateEmptyBasicRuleEngine()
+
+	if selection.IncludeActive {
+		published, _, err := s.publishedRecords(ctx, companyID)
+		if err != nil {
+			return nil, err
+		}
+		for _, record := range published {
+			if !record.Enabled {
+				continue
+			}
+			if rule := s.compileRecord(record); rule != nil {
+				engine.AddRule(rule)
+			}
+		}
+	}
+
+	if len(selection.RuleIDs) > 0 {
+		var records []models.RuleDefinitionRecord
+		if err := s.db.WithContext(ctx).
+			Where("company_id = ? AND id IN ?", companyID, selection.RuleIDs).
+			Find(&records).Error; err != nil {
+			return nil, fmt.Errorf("failed to load rule definitions: %w", err)
+		}
+
+		found := make(map[uuid.UUID]bool, len(records))
+		for _, record := range records {
+			found[record.ID] = true
+			if rule := s.compileRecord(record); rule != nil {
+				// Selected rules take part even while disabled
+				rule.Enabled = true
+				engine.RemoveRule(rule.ID)
+				engine.AddRule(rule)
+			}
+		}
+		for _, id := range selection.RuleIDs {
+			if !found[id] {
+				return nil, fmt.Errorf("%w: %s", ErrRuleDefinitionNotFound, id)
+			}
+		}
+	}
+
+	for i := range selection.Drafts {
+		rule, err := rules.CompileRuleDefinition(&selection.Drafts[i], s.catalogue)
+		if err != nil {
+			return nil, fmt.Errorf("drafts[%d]: %w", i, err)
+		}
+		engine.RemoveRule(rule.ID)
+		engine.AddRule(rule)
+	}
+	return engine, nil
+}
+
+// compileRecord compiles a stored definition. A stored definition that no longer
+// compiles is skipped rather than disabling every rule for the company.
+func (s *RuleDefinitionService) compileRecord(record models.RuleDefinitionRecord) *rules.Rule {
+	var def rules.RuleDefinition
+	if err := json.Unmarshal(record.Definition, &def); err != nil {
+		s.logger.Error("Skipping undecodable rule definition",
+			zap.String("rule_id", record.ID.String()),
+			zap.Error(err))
+		return nil
+	}
+	rule, err := rules.CompileRuleDefinition(&def, s.catalogue)
+	if err != nil {
+		s.logger.Error("Skipping rule definition that no longer compiles",
+			zap.String("rule_id", record.ID.String()),
+			zap.Error(err))
+		return nil
+	}
+	return rule
+}
+
+// applyRuleDefinition copies a parsed definition onto its record
+func applyRuleDefinition(record *models.RuleDefinitionRecord, def *rules.RuleDefinition, source []byte, format string) error {
+	normalised, err := json.Marshal(def)
+	if err != nil {
+		return fmt.Errorf("failed to encode rule definition: %w", err)
+	}
+	switch format {
+	case "":
+		format = "json"
+	case "yml":
+		format = "yaml"
+	}
+
+	record.Name = def.Name
+	record.Description = def.Description
+	record.Priority = def.Priority
+	record.Enabled = def.Enabled
+	record.Shadow = def.Shadow
+	record.SourceFormat = format
+	record.Source = string(source)
+	record.Definition = normalised
+	return nil
+}
@@ -0,0 +1,131 @@
+package services
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"github.com/sambitmohanty1/payment-watchdog/api/internal/models"
+	"github.com/sambitmohanty1/payment-watchdog/api/internal/rules"
+)
+
+var (
+	benchmarkProviders  = []string{"stripe", "xero", "paypal", "adyen"}
+	benchmarkReasons    = []string{"card_declined", "insufficient_funds", "expired_card", "do_not_honor", "fraudulent", "processing_error"}
+	benchmarkCurrencies = []string{"AUD", "USD", "EUR", "GBP", "NZD"}
+	benchmarkThresholds = []int{100, 500, 1000, 5000}
+)
+
+// BenchmarkRuleEvaluation compares the linear rule engine with the indexed
+// compiled engine on company rule sets of increasing size
+func BenchmarkRuleEvaluation(b *testing.B) {
+	logger := zap.NewNop()
+	catalogue := rules.DefaultActionCatalogue()
+	events := prepareRuleBenchmarkEvents(256)
+
+	for _, size := range []int{100, 1000, 5000} {
+		defs := prepareRuleBenchmarkDefinitions(b, size)
+
+		linear, err := rules.NewRuleEngineFactory(logger).CompileRuleSet(defs, catalogue)
+		if err != nil {
+			b.Fatal(err)
+		}
+		compiled, err := rules.NewCompiledRuleEngine(defs, catalogue, logger)
+		if err != nil {
+			b.Fatal(err)
+		}
+
+		b.Run(fmt.Sprintf("Linear%dRules", size), func(b *testing.B) {
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				_ = linear.ExecuteRules(events[i%len(events)])
+			}
+		})
+
+		b.Run(fmt.Sprintf("Compiled%dRules", size), func(b *testing.B) {
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				_ = compiled.ExecuteRules(events[i%len(events)])
+			}
+		})
+
+		b.Run(fmt.Sprintf("CompiledConcurrent%dRules", size), func(b *testing.B) {
+			b.ReportAllocs()
+			b.ResetTimer()
+			b.RunParallel(func(pb *testing.PB) {
+				i := 0
+				for pb.Next() {
+					_ = compiled.ExecuteRules(events[i%len(events)])
+					i++
+				}
+			})
+		})
+	}
+}
+
+// BenchmarkRuleSetCompilation measures the cost of a cache miss
+func BenchmarkRuleSetCompilation(b *testing.B) {
+	logger := zap.NewNop()
+	catalogue := rules.DefaultActionCatalogue()
+	defs := prepareRuleBenchmarkDefinitions(b, 1000)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := rules.NewCompiledRuleEngine(defs, catalogue, logger); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// prepareRuleBenchmarkDefinitions builds a rule set shaped like a large company's:
+// most rules pin a provider, failure reason and currency and share a small set
+// of amount thresholds, and one in ten is an unindexed expression rule
+func prepareRuleBenchmarkDefinitions(b *testing.B, size int) []rules.RuleDefinition {
+	defs := make([]rules.RuleDefinition, 0, size)
+	for i := 0; i < size; i++ {
+		var source string
+		if i%10 == 9 {
+			source = fmt.Sprintf(`{"name":"expr_%d","priority":%d,"when":{"expr":"amount > %d && retry_count < 3"},"actions":[{"type":"tag","params":{"tags":["large"]}}]}`,
+				i, i%50, benchmarkThresholds[i%len(benchmarkThresholds)])
+		} else {
+			source = fmt.Sprintf(`{"name":"rule_%d","priority":%d,"when":{"all":[`+
+				`{"field":"provider","operator":"eq","value":%q},`+
+				`{"field":"failure_reason","operator":"in","value":[%q]},`+
+				`{"field":"currency","operator":"eq","value":%q},`+
+				`{"field":"amount","operator":"gt","value":%d}]},`+
+				`"actions":[{"type":"alert","params":{"severity":"high"}}]}`,
+				i, i%50,
+				benchmarkProviders[i%len(benchmarkProviders)],
+				benchmarkReasons[(i/len(benchmarkProviders))%len(benchmarkReasons)],
+				benchmarkCurrencies[(i/7)%len(benchmarkCurrencies)],
+				benchmarkThresholds[i%len(benchmarkThresholds)])
+		}
+		def, err := rules.ParseRuleDefinition([]byte(source), "json")
+		if err != nil {
+			b.Fatal(err)
+		}
+		defs = append(defs, *def)
+	}
+	return defs
+}
+
+func prepareRuleBenchmarkEvents(count int) []*models.PaymentFailureEvent {
+	events := make([]*models.PaymentFailureEvent, count)
+	for i := range events {
+		events[i] = &models.PaymentFailureEvent{
+			ID:            uuid.New(),
+			CompanyID:     "benchmark-company",
+			ProviderID:    benchmarkProviders[i%len(benchmarkProviders)],
+			FailureReason: benchmarkReasons[i%len(benchmarkReasons)],
+			Currency:      benchmarkCurrencies[i%len(benchmarkCurrencies)],
+			AmountCents:   int64((i%20)*50000 + 999),
+			RetryCount:    i % 5,
+		}
+	}
+	return events
+}
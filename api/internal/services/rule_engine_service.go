@@ -2,31 +2,67 @@ package services
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"slices"
 	"time"
 
+	"github.com/sambitmohanty1/payment-watchdog/api/internal/calendar"
 	"github.com/sambitmohanty1/payment-watchdog/api/internal/models"
 	"github.com/sambitmohanty1/payment-watchdog/api/internal/rules"
 	"go.uber.org/zap"
 	"gorm.io/gorm"
 )
 
+// defaultRetryDelayMinutes is the delay of a catalogue retry that names none,
+// matching the retry action's catalogue default
+const defaultRetryDelayMinutes = 60
+
+// NoRetryTag marks a payment failure that is not retried automatically. Company
+// rules add it with the no_retry action.
+const NoRetryTag = "no_retry"
+
 // RuleEngineService integrates the rule engine with business operations
 type RuleEngineService struct {
-	db         *gorm.DB
-	ruleEngine rules.RuleEngine
-	logger     *zap.Logger
+	db          *gorm.DB
+	ruleEngine  rules.RuleEngine
+	definitions *RuleDefinitionService
+	decisions   *DecisionLogService
+	segments    *CustomerSegmentService
+	calendars   *BusinessCalendarService
+	actions     *EnterpriseActionService
+	logger      *zap.Logger
 }
 
-// NewRuleEngineService creates a new rule engine service
-func NewRuleEngineService(db *gorm.DB, ruleEngine rules.RuleEngine, logger *zap.Logger) *RuleEngineService {
+// NewRuleEngineService creates a new rule engine service. Company rule definitions
+// are evaluated after the built-in rules when definitions is not nil.
+func NewRuleEngineService(
+	db *gorm.DB,
+	ruleEngine rules.RuleEngine,
+	definitions *RuleDefinitionService,
+	decisions *DecisionLogService,
+	segments *CustomerSegmentService,
+	calendars *BusinessCalendarService,
+	logger *zap.Logger,
+) *RuleEngineService {
 	return &RuleEngineService{
-		db:         db,
-		ruleEngine: ruleEngine,
-		logger:     logger,
+		db:          db,
+		ruleEngine:  ruleEngine,
+		definitions: definitions,
+		decisions:   decisions,
+		segments:    segments,
+		calendars:   calendars,
+		logger:      logger,
 	}
 }
 
+// SetActionService performs the actions of matched company rule definitions
+// through actions. Without it declarative actions are only logged.
+func (s *RuleEngineService) SetActionService(actions *EnterpriseActionService) {
+	s.actions = actions
+}
+
 // ProcessPaymentFailureEvent processes a payment failure event through the rule engine
 func (s *RuleEngineService) ProcessPaymentFailureEvent(ctx context.Context, event *models.PaymentFailureEvent) error {
 	s.logger.Info("Processing payment failure event through rule engine",
@@ -34,8 +70,54 @@ func (s *RuleEngineService) ProcessPaymentFailureEvent(ctx context.Context, even
 		zap.String("provider", event.ProviderID),
 		zap.String("failure_reason", event.FailureReason))
 
+	// Record the event in the customer's segment profile first, so rules see
+	// segments that include it
+	if _, err := s.segments.ApplyEvent(ctx, event); err != nil {
+		s.logger.Error("Failed to update customer segments",
+			zap.String("event_id", event.ID.String()),
+			zap.String("customer_id", event.CustomerID),
+			zap.Error(err))
+	}
+
+	return s.evaluate(ctx, event)
+}
+
+// evaluate runs the built-in and company rules, shadow rules included, on an
+// event whose customer segments are already up to date. Decisions and traces
+// are recorded and the actions of matched rules performed.
+func (s *RuleEngineService) evaluate(ctx context.Context, event *models.PaymentFailureEvent) error {
 	// Execute all applicable rules
 	results := s.ruleEngine.ExecuteRules(event)
+	decisions := ruleDecisions(s.ruleEngine, event, results)
+	s.recordTraces(ctx, s.ruleEngine, 0, event, results)
+
+	if s.definitions != nil {
+		ruleSet, err := s.definitions.LoadActiveRuleSet(ctx, event.CompanyID)
+		if err != nil {
+			s.logger.Error("Failed to load company rule definitions",
+				zap.String("company_id", event.CompanyID),
+				zap.Error(err))
+		} else {
+			companyResults := ruleSet.Engine.ExecuteRules(event)
+			results = append(results, companyResults...)
+			companyDecisions := ruleDecisions(ruleSet.Engine, event, companyResults)
+			for i := range companyDecisions {
+				companyDecisions[i].RuleSetVersion = ruleSetVersionRef(ruleSet.Version)
+			}
+			decisions = append(decisions, companyDecisions...)
+			s.recordTraces(ctx, ruleSet.Engine, ruleSet.Version, event, companyResults)
+		}
+	}
+
+	if err := s.decisions.Record(ctx, decisions...); err != nil {
+		s.logger.Error("Failed to record rule decisions",
+			zap.String("event_id", event.ID.String()),
+			zap.Error(err))
+	}
+
+	// Tags, risk scores and no_retry change the event itself, so they apply
+	// before any action is performed and are saved with the event's status
+	applyEventActions(event, results)
 
 	// Process rule execution results
 	for _, result := range results {
@@ -70,8 +152,60 @@ func (s *RuleEngineService) ProcessPaymentFailureEvent(ctx context.Context, even
 	return nil
 }
 
+// recordTraces persists how each rule of engine was evaluated for event. Trace
+// failures are logged but never fail event processing.
+func (s *RuleEngineService) recordTraces(ctx context.Context, engine rules.RuleEngine, ruleSetVersion int, event *models.PaymentFailureEvent, results []*rules.ActionResult) {
+	if err := s.decisions.RecordRuleTraces(ctx, engine, ruleSetVersion, event, results); err != nil {
+		s.logger.Error("Failed to record rule evaluation traces",
+			zap.String("event_id", event.ID.String()),
+			zap.Error(err))
+	}
+}
+
+// ruleDecisions records the live decisions behind results and evaluates the
+// engine's shadow rules, whose actions are logged but never run
+func ruleDecisions(engine rules.RuleEngine, event *models.PaymentFailureEvent, results []*rules.ActionResult) []models.AutomationDecision {
+	var decisions []models.AutomationDecision
+	for _, result := range results {
+		if !result.Success {
+			continue
+		}
+		var ruleID string
+		var actions []string
+		if rule := engine.GetRuleByName(result.RuleName); rule != nil {
+			ruleID = rule.ID
+			actions = rule.ActionTypes
+		}
+		if len(actions) == 0 {
+			actions = []string{inferredRuleAction(result)}
+		}
+		decisions = append(decisions, newAutomationDecision(event, models.DecisionSubjectRule, ruleID, result.RuleName,
+			models.DecisionModeLive, true, actions, map[string]interface{}{"message": result.Message}))
+	}
+
+	for _, evaluation := range rules.EvaluateShadowRules(engine, event) {
+		decisions = append(decisions, newAutomationDecision(event, models.DecisionSubjectRule, evaluation.Rule.ID, evaluation.Rule.Name,
+			models.DecisionModeShadow, evaluation.Matched, evaluation.Rule.ActionTypes, nil))
+	}
+	return decisions
+}
+
+// inferredRuleAction names the action of a built-in rule from its result data
+func inferredRuleAction(result *rules.ActionResult) string {
+	for _, key := range []string{"action", "action_required"} {
+		if action, ok := result.Data[key].(string); ok && action != "" {
+			return action
+		}
+	}
+	return result.RuleName
+}
+
 // handleRuleResult processes the result of a specific rule execution
 func (s *RuleEngineService) handleRuleResult(ctx context.Context, event *models.PaymentFailureEvent, result *rules.ActionResult) error {
+	if declarative, _ := result.Data["declarative"].(bool); declarative {
+		return s.handleDeclarativeRule(ctx, event, result)
+	}
+
 	switch result.RuleName {
 	case "high_value_immediate_alert", "low_value_alert":
 		return s.handleAlertRule(ctx, event, result)
@@ -87,8 +221,114 @@ func (s *RuleEngineService) handleRuleResult(ctx context.Context, event *models.
 	}
 }
 
-// handleAlertRule processes alert-related rule results
+// handleDeclarativeRule routes each catalogue action of a matched rule definition
+// to its handler; actions that change the event are applied by
+// applyEventActions. Every action is attempted even when an earlier one fails.
+func (s *RuleEngineService) handleDeclarativeRule(ctx context.Context, event *models.PaymentFailureEvent, result *rules.ActionResult) error {
+	entries, _ := result.Data["actions"].([]map[string]interface{})
+
+	var errs []error
+	for _, entry := range entries {
+		action := &rules.ActionResult{
+			RuleID:     result.RuleID,
+			RuleName:   result.RuleName,
+			Success:    true,
+			ExecutedAt: result.ExecutedAt,
+			Data:       entry,
+		}
+
+		var err error
+		switch actionType, _ := entry["type"].(string); actionType {
+		case "alert", "escalate":
+			err = s.handleAlertRule(ctx, event, action)
+		case "retry":
+			err = s.handleRetryRule(ctx, event, action)
+		case "customer_contact":
+			err = s.handleCommunicationRule(ctx, event, action)
+		case "start_workflow":
+			err = s.performAction(ctx, event, action, rules.EnterpriseActionRequest{
+				Type:   "start_workflow",
+				Params: map[string]interface{}{"workflow_id": entry["workflow_id"]},
+			})
+		case "block":
+			err = s.performAction(ctx, event, action, rules.EnterpriseActionRequest{
+				Type:   "block_transaction",
+				Params: map[string]interface{}{"reason": entry["reason"]},
+			})
+			if err == nil && s.actions != nil {
+				event.Status = "blocked"
+			}
+		case "tag", "set_risk_score", "no_retry":
+			// Already applied to the event by applyEventActions
+		default:
+			err = fmt.Errorf("rule %s: unsupported action %q", result.RuleName, actionType)
+		}
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// applyEventActions applies the tag, set_risk_score and no_retry actions of
+// matched company rules to the event
+func applyEventActions(event *models.PaymentFailureEvent, results []*rules.ActionResult) {
+	for _, result := range results {
+		if declarative, _ := result.Data["declarative"].(bool); !result.Success || !declarative {
+			continue
+		}
+		entries, _ := result.Data["actions"].([]map[string]interface{})
+		for _, entry := range entries {
+			switch entry["type"] {
+			case "tag":
+				tags, _ := entry["tags"].([]string)
+				for _, tag := range tags {
+					addTag(event, tag)
+				}
+			case "set_risk_score":
+				if score, err := numberParam(entry, "score", 0); err == nil {
+					event.RiskScore = &score
+				}
+			case "no_retry":
+				addTag(event, NoRetryTag)
+			}
+		}
+	}
+}
+
+func addTag(event *models.PaymentFailureEvent, tag string) {
+	if tag != "" && !slices.Contains(event.Tags, tag) {
+		event.Tags = append(event.Tags, tag)
+	}
+}
+
+// handleAlertRule processes alert-related rule results. Catalogue alerts are
+// raised through the action service on every channel they list, by email when
+// they list none; escalations alert the named team.
 func (s *RuleEngineService) handleAlertRule(ctx context.Context, event *models.PaymentFailureEvent, result *rules.ActionResult) error {
+	if actionType, ok := result.Data["type"].(string); ok {
+		request := rules.EnterpriseActionRequest{
+			Type: "immediate_alert",
+			Params: map[string]interface{}{
+				"channel":  "email",
+				"severity": result.Data["severity"],
+				"message":  result.Data["message"],
+			},
+		}
+		if channels, _ := result.Data["channels"].([]string); len(channels) > 0 {
+			request.Params["channels"] = channels
+		}
+		if actionType == "escalate" {
+			request.Type = "manager_escalation"
+			request.Params = map[string]interface{}{
+				"channel": "email",
+				"team":    result.Data["team"],
+				"reason":  result.Data["reason"],
+			}
+		}
+		return s.performAction(ctx, event, result, request)
+	}
+
 	// Extract alert information from result data
 	alertID, ok := result.Data["alert_id"].(string)
 	if !ok {
@@ -103,8 +343,33 @@ func (s *RuleEngineService) handleAlertRule(ctx context.Context, event *models.P
 	return nil
 }
 
-// handleRetryRule processes retry-related rule results
+// handleRetryRule processes retry-related rule results. Catalogue retries are
+// scheduled through the action service after their delay, which defaults to
+// defaultRetryDelayMinutes, unless the event is blocked or tagged NoRetryTag.
 func (s *RuleEngineService) handleRetryRule(ctx context.Context, event *models.PaymentFailureEvent, result *rules.ActionResult) error {
+	if _, ok := result.Data["type"].(string); ok {
+		if event.Status == "blocked" || slices.Contains(event.Tags, NoRetryTag) {
+			s.logger.Info("Retry not scheduled for a payment failure that is blocked or not to be retried",
+				zap.String("rule_name", result.RuleName),
+				zap.String("event_id", event.ID.String()))
+			return nil
+		}
+		minutes, err := numberParam(result.Data, "delay_minutes", defaultRetryDelayMinutes)
+		if err != nil {
+			return fmt.Errorf("rule %s: %w", result.RuleName, err)
+		}
+		if minutes < 0 {
+			return fmt.Errorf("rule %s: delay_minutes must not be negative, got %v", result.RuleName, minutes)
+		}
+		return s.performAction(ctx, event, result, rules.EnterpriseActionRequest{
+			Type: "schedule_retry",
+			Params: map[string]interface{}{
+				"delay":        time.Duration(minutes * float64(time.Minute)).String(),
+				"max_attempts": result.Data["max_attempts"],
+			},
+		})
+	}
+
 	// Extract retry information from result data
 	retryID, ok := result.Data["retry_id"].(string)
 	if !ok {
@@ -119,8 +384,23 @@ func (s *RuleEngineService) handleRetryRule(ctx context.Context, event *models.P
 	return nil
 }
 
-// handleCommunicationRule processes communication-related rule results
+// handleCommunicationRule processes communication-related rule results.
+// Catalogue contacts are sent through the action service on their channel.
 func (s *RuleEngineService) handleCommunicationRule(ctx context.Context, event *models.PaymentFailureEvent, result *rules.ActionResult) error {
+	if _, ok := result.Data["type"].(string); ok {
+		params := map[string]interface{}{
+			"method":  result.Data["channel"],
+			"urgency": result.Data["urgency"],
+		}
+		if template, ok := result.Data["template"]; ok {
+			params["template"] = template
+		}
+		return s.performAction(ctx, event, result, rules.EnterpriseActionRequest{
+			Type:   "customer_contact",
+			Params: params,
+		})
+	}
+
 	// Extract communication information from result data
 	commID, ok := result.Data["communication_id"].(string)
 	if !ok {
@@ -135,6 +415,52 @@ func (s *RuleEngineService) handleCommunicationRule(ctx context.Context, event *
 	return nil
 }
 
+// performAction carries out a declarative action through the action service,
+// scheduling it by the company's business calendar
+func (s *RuleEngineService) performAction(ctx context.Context, event *models.PaymentFailureEvent, result *rules.ActionResult, request rules.EnterpriseActionRequest) error {
+	if s.actions == nil {
+		s.logger.Info("Declarative rule action not performed without an action service",
+			zap.String("rule_name", result.RuleName),
+			zap.String("action", request.Type))
+		return nil
+	}
+
+	cal, err := s.calendars.Calendar(ctx, event.CompanyID)
+	if err != nil {
+		s.logger.Warn("Failed to load business calendar, using defaults",
+			zap.String("company_id", event.CompanyID),
+			zap.Error(err))
+		cal = calendar.Default()
+	}
+
+	request.RuleID = result.RuleID
+	request.RuleName = result.RuleName
+	_, err = s.actions.PerformAction(ctx, event, cal, request)
+	return err
+}
+
+// numberParam reads a numeric rule parameter however it was decoded, returning
+// fallback when it is missing
+func numberParam(data map[string]interface{}, key string, fallback float64) (float64, error) {
+	switch n := data[key].(type) {
+	case nil:
+		return fallback, nil
+	case float64:
+		return n, nil
+	case int:
+		return float64(n), nil
+	case int64:
+		return float64(n), nil
+	case json.Number:
+		f, err := n.Float64()
+		if err != nil {
+			return 0, fmt.Errorf("%s must be a number: %w", key, err)
+		}
+		return f, nil
+	}
+	return 0, fmt.Errorf("%s must be a number, got %T", key, data[key])
+}
+
 // handleRiskScoringRule processes risk scoring rule results
 func (s *RuleEngineService) handleRiskScoringRule(ctx context.Context, event *models.PaymentFailureEvent, result *rules.ActionResult) error {
 	// Extract risk score information from result data
@@ -153,7 +479,7 @@ func (s *RuleEngineService) handleRiskScoringRule(ctx context.Context, event *mo
 
 // updateEventStatus updates the event status based on rule execution results
 func (s *RuleEngineService) updateEventStatus(ctx context.Context, event *models.PaymentFailureEvent, results []*rules.ActionResult) error {
-	// Determine new status based on rule results
+	// Determine new status based on rule results. A blocked event stays blocked.
 	newStatus := "processed"
 
 	for _, result := range results {
@@ -171,6 +497,10 @@ func (s *RuleEngineService) updateEventStatus(ctx context.Context, event *models
 		}
 	}
 
+	if event.Status == "blocked" {
+		newStatus = "blocked"
+	}
+
 	// Update event status
 	event.Status = newStatus
 	event.ProcessedAt = &[]time.Time{time.Now()}[0]
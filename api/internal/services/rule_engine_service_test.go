@@ -0,0 +1,408 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+
+	"github.com/sambitmohanty1/payment-watchdog/api/internal/architecture"
+	"github.com/sambitmohanty1/payment-watchdog/api/internal/models"
+	"github.com/sambitmohanty1/payment-watchdog/api/internal/rules"
+)
+
+// actionService performs actions through the real alert, retry and
+// communication services. Retry jobs are not processed in the background.
+func actionService(db *gorm.DB) *EnterpriseActionService {
+	logger := zap.NewNop()
+	return NewEnterpriseActionService(db,
+		NewAlertService(db, logger),
+		NewRetryService(db, 3, time.Second, time.Minute, 0),
+		NewCommunicationService(db, nil, nil),
+		nil, logger)
+}
+
+// ruleEngineService builds a rule engine service whose collaborators use db
+func ruleEngineService(db *gorm.DB, ruleEngine rules.RuleEngine, definitions *RuleDefinitionService) *RuleEngineService {
+	logger := zap.NewNop()
+	return NewRuleEngineService(db, ruleEngine, definitions,
+		NewDecisionLogService(db, logger),
+		NewCustomerSegmentService(db, logger),
+		NewBusinessCalendarService(db, logger),
+		logger)
+}
+
+// eventProcessor builds an event processor whose collaborators use db
+func eventProcessor(db *gorm.DB, eventBus architecture.EventBus) *EventProcessorService {
+	logger := zap.NewNop()
+	return NewEventProcessorService(db, nil, eventBus,
+		NewCustomerIdentityService(db, logger),
+		NewDecisionLogService(db, logger),
+		NewBusinessCalendarService(db, logger),
+		NewCustomerSegmentService(db, logger),
+		logger)
+}
+
+// scriptService builds a company script service whose calendars use db
+func scriptService(db *gorm.DB) *CompanyScriptService {
+	return NewCompanyScriptService(db, NewBusinessCalendarService(db, zap.NewNop()), zap.NewNop())
+}
+
+// expectClaimedAction expects an action to be claimed for a payment failure
+// before it is performed
+func expectClaimedAction(mock sqlmock.Sqlmock, failureID uuid.UUID, actionType string) {
+	anyArg := sqlmock.AnyArg()
+	mock.ExpectQuery(`INSERT INTO "recovery_actions" .* ON CONFLICT \("idempotency_key"\) +WHERE idempotency_key IS NOT NULL AND idempotency_key <> '' DO NOTHING`).
+		WithArgs(anyArg, failureID, nil, nil, actionType, anyArg, "pending", "stripe", "", ruleActionKey(failureID, actionType), "", nil, anyArg, nil, anyArg, anyArg, anyArg).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(uuid.New()))
+}
+
+// expectRecoveryAction expects the outcome of a performed action to be recorded
+func expectRecoveryAction(mock sqlmock.Sqlmock, status string) {
+	anyArg := sqlmock.AnyArg()
+	if status == "completed" {
+		mock.ExpectExec(`UPDATE "recovery_actions" SET "completed_at"=\$1,"error_message"=\$2,"external_id"=\$3,"result"=\$4,"status"=\$5`).
+			WithArgs(anyArg, "", anyArg, anyArg, status, anyArg, anyArg).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		return
+	}
+	mock.ExpectExec(`UPDATE "recovery_actions" SET "error_message"=\$1,"external_id"=\$2,"result"=\$3,"status"=\$4`).
+		WithArgs("", anyArg, anyArg, status, anyArg, anyArg).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+}
+
+// expectPerformedActions expects an alert, a retry job and a customer email to
+// be stored for a payment failure, each claimed first and its outcome recorded after
+func expectPerformedActions(mock sqlmock.Sqlmock, companyID, failureID uuid.UUID) {
+	expectClaimedAction(mock, failureID, "immediate_alert")
+	mock.ExpectQuery(`INSERT INTO "customer_communications"`).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(uuid.New()))
+	mock.ExpectExec(`UPDATE "customer_communications" SET .*"status"=`).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	expectRecoveryAction(mock, "completed")
+
+	expectClaimedAction(mock, failureID, "schedule_retry")
+	mock.ExpectQuery(`SELECT EXISTS \(SELECT FROM information_schema.tables WHERE table_name = 'retry_jobs'\)`).
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+	expectRecoveryAction(mock, "pending")
+
+	expectClaimedAction(mock, failureID, "customer_contact")
+	mock.ExpectQuery(`SELECT \* FROM "communication_templates" WHERE name = \$1 AND company_id = \$2`).
+		WithArgs("declined", companyID, "email", true, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "company_id", "name", "template_type", "subject", "content", "is_active"}).
+			AddRow(uuid.New(), companyID, "declined", "email", "Payment declined", "Hi {{.customer_name}}", true))
+	mock.ExpectExec(`UPDATE "communication_templates"`).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(`INSERT INTO "customer_communications"`).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(uuid.New()))
+	expectRecoveryAction(mock, "completed")
+}
+
+func TestDeclarativeRuleActionsArePerformed(t *testing.T) {
+	db, mock := mockDB(t)
+	definitions := NewRuleDefinitionService(db, zap.NewNop())
+	engine := definitions.compileRuleSet([]models.RuleDefinitionRecord{testRuleRecord(t, `{
+		"name": "declined",
+		"enabled": true,
+		"when": {"field": "failure_reason", "operator": "eq", "value": "card_declined"},
+		"actions": [
+			{"type": "alert", "params": {"severity": "high", "channels": ["slack"]}},
+			{"type": "retry", "params": {"delay_minutes": 30}},
+			{"type": "customer_contact", "params": {"channel": "email", "template": "declined"}},
+			{"type": "tag", "params": {"tags": ["declined"]}}
+		]
+	}`)})
+
+	companyID := uuid.New()
+	event := &models.PaymentFailureEvent{
+		ID:            uuid.New(),
+		CompanyID:     companyID.String(),
+		ProviderID:    "stripe",
+		FailureReason: "card_declined",
+		CustomerName:  "Ada",
+		CustomerEmail: "ada@example.com",
+		AmountCents:   4250,
+	}
+	results := engine.ExecuteRules(event)
+	require.Len(t, results, 1)
+
+	service := ruleEngineService(db, nil, definitions)
+	service.SetActionService(actionService(db))
+	// Actions are scheduled by the company's calendar
+	mock.ExpectQuery(`SELECT "id","timezone","business_hours" FROM "companies"`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "timezone"}).AddRow(companyID, "Australia/Sydney"))
+	mock.ExpectQuery(`SELECT \* FROM "company_holidays"`).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	expectPerformedActions(mock, companyID, event.ID)
+
+	require.NoError(t, service.handleRuleResult(context.Background(), event, results[0]))
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestDeclarativeRuleActionsWithoutActionService(t *testing.T) {
+	db, mock := mockDB(t)
+	definitions := NewRuleDefinitionService(db, zap.NewNop())
+	engine := definitions.compileRuleSet([]models.RuleDefinitionRecord{testRuleRecord(t,
+		`{"name":"large","enabled":true,"when":{"expr":"amount >= 10"},"actions":[{"type":"alert"},{"type":"retry"}]}`)})
+
+	event := &models.PaymentFailureEvent{ID: uuid.New(), CompanyID: uuid.New().String(), AmountCents: 5000}
+	results := engine.ExecuteRules(event)
+	require.Len(t, results, 1)
+
+	// Actions are only logged, so nothing touches the database
+	service := ruleEngineService(db, nil, definitions)
+	assert.NoError(t, service.handleRuleResult(context.Background(), event, results[0]))
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestDeclarativeRuleEventActions(t *testing.T) {
+	db, mock := mockDB(t)
+	definitions := NewRuleDefinitionService(db, zap.NewNop())
+	engine := definitions.compileRuleSet([]models.RuleDefinitionRecord{
+		testRuleRecord(t, `{"name":"risky","enabled":true,"when":{"expr":"amount >= 10"},"actions":[
+			{"type":"tag","params":{"tags":["review","declined"]}},
+			{"type":"set_risk_score","params":{"score":85}},
+			{"type":"no_retry","params":{"reason":"disputed"}}]}`),
+		testRuleRecord(t, `{"name":"retry","enabled":true,"when":{"expr":"amount >= 10"},"actions":[{"type":"retry"}]}`),
+	})
+
+	event := &models.PaymentFailureEvent{ID: uuid.New(), CompanyID: uuid.New().String(), AmountCents: 5000, Tags: []string{"declined"}}
+	results := engine.ExecuteRules(event)
+	require.Len(t, results, 2)
+
+	applyEventActions(event, results)
+	assert.Equal(t, []string{"declined", "review", NoRetryTag}, event.Tags)
+	require.NotNil(t, event.RiskScore)
+	assert.Equal(t, 85.0, *event.RiskScore)
+
+	// The retry is not scheduled, so nothing touches the database
+	service := ruleEngineService(db, nil, definitions)
+	service.SetActionService(actionService(db))
+	for _, result := range results {
+		require.NoError(t, service.handleRuleResult(context.Background(), event, result))
+	}
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestDeclarativeRuleBlocksPayment(t *testing.T) {
+	db, mock := mockDB(t)
+	definitions := NewRuleDefinitionService(db, zap.NewNop())
+	engine := definitions.compileRuleSet([]models.RuleDefinitionRecord{testRuleRecord(t,
+		`{"name":"fraud","enabled":true,"when":{"expr":"amount >= 10"},"actions":[{"type":"block","params":{"reason":"fraud"}}]}`)})
+
+	companyID := uuid.New()
+	event := &models.PaymentFailureEvent{ID: uuid.New(), CompanyID: companyID.String(), ProviderID: "stripe", AmountCents: 5000, Status: "received"}
+	results := engine.ExecuteRules(event)
+	require.Len(t, results, 1)
+
+	service := ruleEngineService(db, nil, definitions)
+	service.SetActionService(actionService(db))
+	mock.ExpectQuery(`SELECT "id","timezone","business_hours" FROM "companies"`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "timezone"}).AddRow(companyID, "UTC"))
+	mock.ExpectQuery(`SELECT \* FROM "company_holidays"`).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	expectClaimedAction(mock, event.ID, "block_transaction")
+	mock.ExpectExec(`UPDATE "payment_failure_events" SET "status"=\$1,"updated_at"=\$2 WHERE id = \$3`).
+		WithArgs("blocked", sqlmock.AnyArg(), event.ID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	expectRecoveryAction(mock, "completed")
+
+	require.NoError(t, service.handleRuleResult(context.Background(), event, results[0]))
+	assert.Equal(t, "blocked", event.Status)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestEventProcessorRecordsCompanyRuleDecisions(t *testing.T) {
+	db, mock := mockDB(t)
+	companyID := uuid.New().String()
+	eventID := uuid.New()
+
+	// A published company rule set with a live and a shadow rule
+	definitions := NewRuleDefinitionService(db, zap.NewNop())
+	definitions.compiled[companyID] = &ActiveRuleSet{Version: 2, Engine: definitions.compileRuleSet([]models.RuleDefinitionRecord{
+		testRuleRecord(t, `{"name":"declined","enabled":true,"when":{"field":"failure_reason","operator":"eq","value":"card_declined"},"actions":[{"type":"tag","params":{"tags":["declined"]}}]}`),
+		testRuleRecord(t, `{"name":"candidate","enabled":true,"shadow":true,"when":{"expr":"amount >= 10"},"actions":[{"type":"alert"}]}`),
+	})}
+	ruleService := ruleEngineService(db, rules.NewRuleEngineFactory(zap.NewNop()).CreateEmptyBasicRuleEngine(), definitions)
+	processor := eventProcessor(db, &publishedTopics{})
+	processor.SetRuleEngineService(ruleService)
+
+	mock.ExpectQuery(`SELECT \* FROM "payment_failure_events" WHERE id = \$1`).
+		WithArgs(eventID, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "company_id", "provider_id", "amount_cents", "failure_reason", "status"}).
+			AddRow(eventID, companyID, "stripe", 4250, "card_declined", "received"))
+	mock.ExpectQuery(`SELECT "version" FROM "rule_set_versions" WHERE company_id = \$1 AND status = \$2`).
+		WithArgs(companyID, "published", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(2))
+	mock.ExpectQuery(`INSERT INTO "rule_evaluation_traces"`).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(uuid.New()))
+
+	// Both rules are decided: the live one as acted on, the shadow one only logged
+	anyArg := sqlmock.AnyArg()
+	mock.ExpectQuery(`INSERT INTO "automation_decisions"`).
+		WithArgs(
+			companyID, eventID, "rule", "declined", "declined", "live", true, anyArg, anyArg, 2, anyArg,
+			companyID, eventID, "rule", "candidate", "candidate", "shadow", true, anyArg, 2, anyArg).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(uuid.New()).AddRow(uuid.New()))
+	mock.ExpectExec(`UPDATE "payment_failure_events" SET .*"status"=\$`).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`UPDATE "payment_failure_events" SET "processed_at"=\$1,"status"=\$2,"updated_at"=\$3 WHERE id = \$4 AND status = \$5`).
+		WithArgs(anyArg, "analyzed", anyArg, eventID, "received").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	raw, err := json.Marshal(map[string]interface{}{
+		"payment_failure": &architecture.PaymentFailure{ID: eventID, CompanyID: companyID, ProviderID: "stripe", Amount: 42.5},
+	})
+	require.NoError(t, err)
+	var event map[string]interface{}
+	require.NoError(t, json.Unmarshal(raw, &event))
+
+	require.NoError(t, processor.handlePaymentFailureEvent(context.Background(), event))
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+// segmentsSeen is an enterprise condition that records the customer segments
+// it is evaluated with
+type segmentsSeen struct {
+	segments *[]string
+}
+
+func (c segmentsSeen) Evaluate(ctx rules.EnterpriseRuleContext) bool {
+	*c.segments = ctx.CustomerSegments
+	return true
+}
+func (c segmentsSeen) GetType() string        { return "segments_seen" }
+func (c segmentsSeen) GetDescription() string { return "records customer segments" }
+
+func TestEventProcessorAppliesSegmentsOnceForAllRules(t *testing.T) {
+	db, mock := mockDB(t)
+	companyID := uuid.New().String()
+	eventID := uuid.New()
+
+	var enterpriseSegments []string
+	engine := rules.NewEnterpriseRuleEngine(zap.NewNop())
+	require.NoError(t, engine.AddRule(rules.EnterpriseRule{
+		ID:         "segments",
+		Name:       "Segments",
+		Enabled:    true,
+		Conditions: []rules.EnterpriseCondition{segmentsSeen{&enterpriseSegments}},
+		Actions:    []rules.EnterpriseAction{dispatchedAction{actionType: "log"}},
+	}))
+	definitions := NewRuleDefinitionService(db, zap.NewNop())
+	definitions.compiled[companyID] = &ActiveRuleSet{Version: 1, Engine: definitions.compileRuleSet([]models.RuleDefinitionRecord{
+		testRuleRecord(t, `{"name":"new customer","enabled":true,"when":{"field":"customer_segments","operator":"contains","value":"new"},"actions":[{"type":"tag","params":{"tags":["new"]}}]}`),
+	})}
+	processor := eventProcessor(db, &publishedTopics{})
+	processor.SetEnterpriseRuleEngine(engine, scriptService(db))
+	processor.SetRuleEngineService(ruleEngineService(db, rules.NewRuleEngineFactory(zap.NewNop()).CreateEmptyBasicRuleEngine(), definitions))
+
+	mock.ExpectQuery(`SELECT \* FROM "payment_failure_events" WHERE id = \$1`).
+		WithArgs(eventID, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "company_id", "provider_id", "customer_id", "amount_cents", "status", "created_at"}).
+			AddRow(eventID, companyID, "stripe", "cus_1", 4250, "received", time.Now()))
+
+	// The customer is already linked to an identity
+	identityID := uuid.New()
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT \* FROM "customer_identity_links" WHERE company_id = \$1 AND provider = \$2 AND provider_customer_id = \$3`).
+		WithArgs(companyID, "stripe", "cus_1", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "identity_id"}).AddRow(uuid.New(), identityID))
+	mock.ExpectQuery(`SELECT \* FROM "customer_identities" WHERE id = \$1 AND company_id = \$2`).
+		WithArgs(identityID, companyID, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "company_id"}).AddRow(identityID, companyID))
+	mock.ExpectCommit()
+
+	// The customer's profile is updated once, before either engine runs
+	mock.ExpectQuery(`SELECT \* FROM "customer_segments" WHERE company_id = \$1`).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectBegin()
+	mock.ExpectQuery(`INSERT INTO "customer_segment_profiles" .* ON CONFLICT DO NOTHING`).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(uuid.New()))
+	mock.ExpectQuery(`SELECT \* FROM "customer_segment_profiles" WHERE company_id = \$1 AND customer_id = \$2 .*FOR UPDATE`).
+		WithArgs(companyID, "cus_1", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "company_id", "customer_id"}).AddRow(uuid.New(), companyID, "cus_1"))
+	mock.ExpectExec(`UPDATE "customer_segment_profiles"`).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	mock.ExpectQuery(`SELECT "id","timezone","business_hours" FROM "companies"`).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectQuery(`SELECT \* FROM "company_holidays"`).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectQuery(`SELECT \* FROM "company_scripts"`).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectQuery(`INSERT INTO "rule_evaluation_traces"`).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(uuid.New()))
+
+	// The company rule matches on the segment the event just earned
+	mock.ExpectQuery(`SELECT "version" FROM "rule_set_versions"`).
+		WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(1))
+	mock.ExpectQuery(`INSERT INTO "rule_evaluation_traces"`).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(uuid.New()))
+	anyArg := sqlmock.AnyArg()
+	mock.ExpectQuery(`INSERT INTO "automation_decisions"`).
+		WithArgs(companyID, eventID, "rule", "new customer", "new customer", "live", true, anyArg, anyArg, 1, anyArg).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(uuid.New()))
+	mock.ExpectExec(`UPDATE "payment_failure_events" SET .*"status"=\$`).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`UPDATE "payment_failure_events" SET "processed_at"=`).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	event := map[string]interface{}{
+		"payment_failure": &architecture.PaymentFailure{ID: eventID, CompanyID: companyID, ProviderID: "stripe", CustomerID: "cus_1", Amount: 42.5},
+	}
+	require.NoError(t, processor.handlePaymentFailureEvent(context.Background(), event))
+	require.NoError(t, mock.ExpectationsWereMet())
+	assert.Equal(t, []string{"new"}, enterpriseSegments)
+}
+
+func TestNumberParam(t *testing.T) {
+	tests := map[string]struct {
+		value   interface{}
+		want    float64
+		wantErr bool
+	}{
+		"missing":     {value: nil, want: 60},
+		"float":       {value: 30.5, want: 30.5},
+		"int":         {value: 30, want: 30},
+		"int64":       {value: int64(45), want: 45},
+		"json number": {value: json.Number("15"), want: 15},
+		"string":      {value: "30", wantErr: true},
+		"bad number":  {value: json.Number("soon"), wantErr: true},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			data := map[string]interface{}{}
+			if tc.value != nil {
+				data["delay_minutes"] = tc.value
+			}
+			got, err := numberParam(data, "delay_minutes", defaultRetryDelayMinutes)
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func TestRetryRuleRejectsInvalidDelays(t *testing.T) {
+	service := ruleEngineService(nil, nil, nil)
+	event := &models.PaymentFailureEvent{ID: uuid.New()}
+
+	for _, delay := range []interface{}{"thirty", -5} {
+		err := service.handleRetryRule(context.Background(), event, &rules.ActionResult{
+			RuleName: "declined",
+			Data:     map[string]interface{}{"type": "retry", "delay_minutes": delay},
+		})
+		assert.Error(t, err, "delay %v", delay)
+	}
+}
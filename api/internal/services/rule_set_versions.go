@@ -0,0 +1,443 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+
+	"github.com/sambitmohanty1/payment-watchdog/api/internal/models"
+	"github.com/sambitmohanty1/payment-watchdog/api/internal/rules"
+)
+
+var (
+	// ErrRuleSetVersionNotFound is returned for unknown rule set versions
+	ErrRuleSetVersionNotFound = errors.New("rule set version not found")
+	// ErrRuleSetVersionState is returned when a version cannot be published or restored in its current status
+	ErrRuleSetVersionState = errors.New("rule set version is in the wrong state")
+)
+
+// ActiveRuleSet is a company's published rule set compiled into a RuleEngine.
+// Version is zero while the company has never published its rules. Active rule
+// sets are cached and shared, so their engines must not be modified.
+type ActiveRuleSet struct {
+	Engine  rules.RuleEngine
+	Version int
+}
+
+// LoadActiveRuleSet returns the compiled enabled rules of a company's published
+// rule set. Compiled rule sets are cached per company and recompiled when a
+// different version is published, by this process or any other.
+func (s *RuleDefinitionService) LoadActiveRuleSet(ctx context.Context, companyID string) (*ActiveRuleSet, error) {
+	var published []int
+	if err := s.db.WithContext(ctx).Model(&models.RuleSetVersion{}).
+		Where("company_id = ? AND status = ?", companyID, models.RuleSetVersionPublished).
+		Order("version DESC").
+		Limit(1).
+		Pluck("version", &published).Error; err != nil {
+		return nil, fmt.Errorf("failed to load published rule set version: %w", err)
+	}
+	current := 0
+	if len(published) > 0 {
+		current = published[0]
+	}
+
+	s.compiledMu.Lock()
+	cached := s.compiled[companyID]
+	s.compiledMu.Unlock()
+	if cached != nil && cached.Version == current {
+		return cached, nil
+	}
+
+	records, version, err := s.publishedRecords(ctx, companyID)
+	if err != nil {
+		return nil, err
+	}
+	engine := s.compileRuleSet(records)
+	ruleSet := &ActiveRuleSet{Engine: engine, Version: version}
+
+	s.compiledMu.Lock()
+	s.compiled[companyID] = ruleSet
+	s.compiledMu.Unlock()
+
+	s.logger.Debug("Compiled published rule set",
+		zap.String("company_id", companyID),
+		zap.Int("version", version),
+		zap.Any("stats", engine.GetStats()))
+	return ruleSet, nil
+}
+
+// compileRuleSet compiles the enabled records into an indexed engine. A stored
+// definition that no longer compiles is skipped rather than disabling every rule
+// for the company.
+func (s *RuleDefinitionService) compileRuleSet(records []models.RuleDefinitionRecord) *rules.CompiledRuleEngine {
+	engine, _ := rules.NewCompiledRuleEngine(nil, s.catalogue, s.logger)
+	for _, record := range records {
+		if !record.Enabled {
+			continue
+		}
+		var def rules.RuleDefinition
+		if err := json.Unmarshal(record.Definition, &def); err != nil {
+			s.logger.Error("Skipping undecodable rule definition",
+				zap.String("rule_id", record.ID.String()),
+				zap.Error(err))
+			continue
+		}
+		if err := engine.AddDefinition(&def, s.catalogue); err != nil {
+			s.logger.Error("Skipping rule definition that no longer compiles",
+				zap.String("rule_id", record.ID.String()),
+				zap.Error(err))
+		}
+	}
+	return engine
+}
+
+// invalidateRuleSet drops a company's cached rule set after its published version changed
+func (s *RuleDefinitionService) invalidateRuleSet(companyID string) {
+	s.compiledMu.Lock()
+	delete(s.compiled, companyID)
+	s.compiledMu.Unlock()
+}
+
+// ListVersions returns a company's rule set history, newest first. Snapshots are
+// left out; fetch a single version to see its rules.
+func (s *RuleDefinitionService) ListVersions(ctx context.Context, companyID string) ([]models.RuleSetVersion, error) {
+	var versions []models.RuleSetVersion
+	if err := s.db.WithContext(ctx).
+		Omit("rules").
+		Where("company_id = ?", companyID).
+		Order("version DESC").
+		Find(&versions).Error; err != nil {
+		return nil, fmt.Errorf("failed to list rule set versions: %w", err)
+	}
+	return versions, nil
+}
+
+// GetVersion returns a rule set version including its snapshot
+func (s *RuleDefinitionService) GetVersion(ctx context.Context, companyID string, version int) (*models.RuleSetVersion, error) {
+	return findRuleSetVersion(s.db.WithContext(ctx), companyID, version)
+}
+
+// DiffVersions compares a version with another one, or with the published
+// version when against is zero
+func (s *RuleDefinitionService) DiffVersions(ctx context.Context, companyID string, version, against int) (*models.RuleSetDiff, error) {
+	target, err := s.GetVersion(ctx, companyID, version)
+	if err != nil {
+		return nil, err
+	}
+	next, err := ruleSetVersionRecords(target)
+	if err != nil {
+		return nil, err
+	}
+
+	var base []models.RuleDefinitionRecord
+	if against > 0 {
+		baseVersion, err := s.GetVersion(ctx, companyID, against)
+		if err != nil {
+			return nil, err
+		}
+		if base, err = ruleSetVersionRecords(baseVersion); err != nil {
+			return nil, err
+		}
+	} else if base, _, err = s.publishedRecords(ctx, companyID); err != nil {
+		return nil, err
+	}
+
+	diff := diffRuleSets(base, next)
+	return &diff, nil
+}
+
+// Publish makes a draft version the live rule set. The previously published
+// version is marked superseded.
+func (s *RuleDefinitionService) Publish(ctx context.Context, companyID string, version int, author string) (*models.RuleSetVersion, error) {
+	var published *models.RuleSetVersion
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		target, err := findRuleSetVersion(tx, companyID, version)
+		if err != nil {
+			return err
+		}
+		if target.Status != models.RuleSetVersionDraft {
+			return fmt.Errorf("%w: version %d is %s, only drafts can be published", ErrRuleSetVersionState, version, target.Status)
+		}
+
+		if err := supersedePublishedVersion(tx, companyID); err != nil {
+			return err
+		}
+		now := time.Now()
+		result := tx.Model(&models.RuleSetVersion{}).
+			Where("id = ? AND status = ?", target.ID, models.RuleSetVersionDraft).
+			Updates(map[string]interface{}{
+				"status":       models.RuleSetVersionPublished,
+				"published_by": author,
+				"published_at": now,
+			})
+		if result.Error != nil {
+			return fmt.Errorf("failed to publish rule set version: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return fmt.Errorf("%w: version %d was published concurrently", ErrRuleSetVersionState, version)
+		}
+
+		target.Status = models.RuleSetVersionPublished
+		target.PublishedBy = author
+		target.PublishedAt = &now
+		published = target
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.invalidateRuleSet(companyID)
+	s.logger.Info("Rule set version published",
+		zap.String("company_id", companyID),
+		zap.Int("version", version))
+	return published, nil
+}
+
+// Rollback restores the rules of a previously published version. It records and
+// publishes a new version with the old snapshot, so history is never rewritten,
+// and resets the editable definitions to match.
+func (s *RuleDefinitionService) Rollback(ctx context.Context, companyID string, version int, author string) (*models.RuleSetVersion, error) {
+	var restored *models.RuleSetVersion
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		target, err := findRuleSetVersion(tx, companyID, version)
+		if err != nil {
+			return err
+		}
+		if target.PublishedAt == nil {
+			return fmt.Errorf("%w: version %d was never published", ErrRuleSetVersionState, version)
+		}
+		records, err := ruleSetVersionRecords(target)
+		if err != nil {
+			return err
+		}
+
+		if err := tx.Where("company_id = ?", companyID).Delete(&models.RuleDefinitionRecord{}).Error; err != nil {
+			return fmt.Errorf("failed to clear rule definitions: %w", err)
+		}
+		for i := range records {
+			records[i].CompanyID = companyID
+			records[i].UpdatedBy = author
+		}
+		if len(records) > 0 {
+			if err := tx.Create(&records).Error; err != nil {
+				return fmt.Errorf("failed to restore rule definitions: %w", err)
+			}
+		}
+
+		if err := supersedePublishedVersion(tx, companyID); err != nil {
+			return err
+		}
+		restored, err = s.recordVersion(tx, companyID, author, fmt.Sprintf("Rollback to version %d", version), &target.Version)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.invalidateRuleSet(companyID)
+	s.logger.Info("Rule set rolled back",
+		zap.String("company_id", companyID),
+		zap.Int("restored_version", version),
+		zap.Int("version", restored.Version))
+	return restored, nil
+}
+
+// recordVersion snapshots a company's rule definitions as a new version. Edits
+// record drafts; a rollback (restoredFrom set) records a published version.
+func (s *RuleDefinitionService) recordVersion(tx *gorm.DB, companyID, author, message string, restoredFrom *int) (*models.RuleSetVersion, error) {
+	var records []models.RuleDefinitionRecord
+	if err := tx.Where("company_id = ?", companyID).Order("priority DESC, name").Find(&records).Error; err != nil {
+		return nil, fmt.Errorf("failed to load rule definitions: %w", err)
+	}
+
+	version := &models.RuleSetVersion{
+		CompanyID:    companyID,
+		Version:      1,
+		Status:       models.RuleSetVersionDraft,
+		Author:       author,
+		Message:      message,
+		RestoredFrom: restoredFrom,
+	}
+
+	var base []models.RuleDefinitionRecord
+	var latest models.RuleSetVersion
+	err := tx.Where("company_id = ?", companyID).Order("version DESC").First(&latest).Error
+	switch {
+	case err == nil:
+		version.Version = latest.Version + 1
+		version.BaseVersion = &latest.Version
+		if base, err = ruleSetVersionRecords(&latest); err != nil {
+			return nil, err
+		}
+	case !errors.Is(err, gorm.ErrRecordNotFound):
+		return nil, fmt.Errorf("failed to load latest rule set version: %w", err)
+	}
+
+	if restoredFrom != nil {
+		now := time.Now()
+		version.Status = models.RuleSetVersionPublished
+		version.PublishedBy = author
+		version.PublishedAt = &now
+	}
+
+	if version.Rules, err = json.Marshal(records); err != nil {
+		return nil, fmt.Errorf("failed to encode rule set snapshot: %w", err)
+	}
+	if version.Diff, err = json.Marshal(diffRuleSets(base, records)); err != nil {
+		return nil, fmt.Errorf("failed to encode rule set diff: %w", err)
+	}
+	// Static analysis runs on every save so reviewers see problems before publishing
+	analysis := rules.AnalyzeRuleSet(s.recordDefinitions(records), s.catalogue, nil)
+	if version.Analysis, err = json.Marshal(analysis); err != nil {
+		return nil, fmt.Errorf("failed to encode rule set analysis: %w", err)
+	}
+	if err := tx.Create(version).Error; err != nil {
+		return nil, fmt.Errorf("failed to record rule set version: %w", err)
+	}
+	return version, nil
+}
+
+// publishedRecords returns the snapshot of a company's published rule set and its
+// version number, or no records and zero before anything is published
+func (s *RuleDefinitionService) publishedRecords(ctx context.Context, companyID string) ([]models.RuleDefinitionRecord, int, error) {
+	var version models.RuleSetVersion
+	err := s.db.WithContext(ctx).
+		Where("company_id = ? AND status = ?", companyID, models.RuleSetVersionPublished).
+		Order("version DESC").
+		First(&version).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, 0, nil
+		}
+		return nil, 0, fmt.Errorf("failed to load published rule set: %w", err)
+	}
+
+	records, err := ruleSetVersionRecords(&version)
+	if err != nil {
+		return nil, 0, err
+	}
+	return records, version.Version, nil
+}
+
+func findRuleSetVersion(db *gorm.DB, companyID string, version int) (*models.RuleSetVersion, error) {
+	var record models.RuleSetVersion
+	err := db.Where("company_id = ? AND version = ?", companyID, version).First(&record).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrRuleSetVersionNotFound
+		}
+		return nil, fmt.Errorf("failed to get rule set version: %w", err)
+	}
+	return &record, nil
+}
+
+func supersedePublishedVersion(tx *gorm.DB, companyID string) error {
+	if err := tx.Model(&models.RuleSetVersion{}).
+		Where("company_id = ? AND status = ?", companyID, models.RuleSetVersionPublished).
+		Update("status", models.RuleSetVersionSuperseded).Error; err != nil {
+		return fmt.Errorf("failed to supersede published rule set version: %w", err)
+	}
+	return nil
+}
+
+func ruleSetVersionRecords(version *models.RuleSetVersion) ([]models.RuleDefinitionRecord, error) {
+	var records []models.RuleDefinitionRecord
+	if len(version.Rules) == 0 {
+		return records, nil
+	}
+	if err := json.Unmarshal(version.Rules, &records); err != nil {
+		return nil, fmt.Errorf("failed to decode rule set version %d: %w", version.Version, err)
+	}
+	return records, nil
+}
+
+// diffRuleSets compares two snapshots by rule name. A renamed rule shows up as
+// one removal and one addition.
+func diffRuleSets(base, next []models.RuleDefinitionRecord) models.RuleSetDiff {
+	diff := models.RuleSetDiff{
+		Added:   []string{},
+		Removed: []string{},
+		Changed: []models.RuleSetChange{},
+	}
+
+	previous := make(map[string]models.RuleDefinitionRecord, len(base))
+	for _, record := range base {
+		previous[record.Name] = record
+	}
+	seen := make(map[string]bool, len(next))
+	for _, record := range next {
+		seen[record.Name] = true
+		old, ok := previous[record.Name]
+		if !ok {
+			diff.Added = append(diff.Added, record.Name)
+			continue
+		}
+		if fields := changedRuleFields(old, record); len(fields) > 0 {
+			diff.Changed = append(diff.Changed, models.RuleSetChange{Name: record.Name, Fields: fields})
+		}
+	}
+	for _, record := range base {
+		if !seen[record.Name] {
+			diff.Removed = append(diff.Removed, record.Name)
+		}
+	}
+
+	sort.Strings(diff.Added)
+	sort.Strings(diff.Removed)
+	sort.Slice(diff.Changed, func(i, j int) bool { return diff.Changed[i].Name < diff.Changed[j].Name })
+	return diff
+}
+
+func changedRuleFields(old, record models.RuleDefinitionRecord) []string {
+	var fields []string
+	if old.Description != record.Description {
+		fields = append(fields, "description")
+	}
+	if old.Priority != record.Priority {
+		fields = append(fields, "priority")
+	}
+	if old.Enabled != record.Enabled {
+		fields = append(fields, "enabled")
+	}
+	if old.Shadow != record.Shadow {
+		fields = append(fields, "shadow")
+	}
+
+	var oldDef, newDef rules.RuleDefinition
+	if json.Unmarshal(old.Definition, &oldDef) != nil || json.Unmarshal(record.Definition, &newDef) != nil {
+		if !bytes.Equal(old.Definition, record.Definition) {
+			fields = append(fields, "definition")
+		}
+		return fields
+	}
+	if !sameJSON(oldDef.When, newDef.When) {
+		fields = append(fields, "when")
+	}
+	if !sameJSON(oldDef.Actions, newDef.Actions) {
+		fields = append(fields, "actions")
+	}
+	return fields
+}
+
+func sameJSON(a, b interface{}) bool {
+	left, errA := json.Marshal(a)
+	right, errB := json.Marshal(b)
+	return errA == nil && errB == nil && bytes.Equal(left, right)
+}
+
+// ruleSetVersionRef returns a reference to a published version, or nil for rules
+// that are not versioned
+func ruleSetVersionRef(version int) *int {
+	if version == 0 {
+		return nil
+	}
+	return &version
+}
@@ -0,0 +1,72 @@
+package services
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sambitmohanty1/payment-watchdog/api/internal/models"
+	"github.com/sambitmohanty1/payment-watchdog/api/internal/rules"
+)
+
+func testRuleRecord(t *testing.T, source string) models.RuleDefinitionRecord {
+	t.Helper()
+	def, err := rules.ParseRuleDefinition([]byte(source), "json")
+	require.NoError(t, err)
+
+	var record models.RuleDefinitionRecord
+	require.NoError(t, applyRuleDefinition(&record, def, []byte(source), "json"))
+	return record
+}
+
+func TestDiffRuleSets(t *testing.T) {
+	base := []models.RuleDefinitionRecord{
+		testRuleRecord(t, `{"name":"large","priority":10,"enabled":true,"when":{"expr":"amount >= 1000"},"actions":[{"type":"alert"}]}`),
+		testRuleRecord(t, `{"name":"declined","priority":5,"enabled":true,"when":{"field":"failure_reason","operator":"eq","value":"card_declined"},"actions":[{"type":"retry"}]}`),
+		testRuleRecord(t, `{"name":"legacy","enabled":true,"when":{"expr":"amount > 0"},"actions":[{"type":"alert"}]}`),
+	}
+	next := []models.RuleDefinitionRecord{
+		testRuleRecord(t, `{"name":"large","priority":20,"enabled":false,"when":{"expr":"amount >= 5000"},"actions":[{"type":"alert"}]}`),
+		testRuleRecord(t, `{"name":"declined","priority":5,"enabled":true,"when":{"field":"failure_reason","operator":"eq","value":"card_declined"},"actions":[{"type":"retry"}]}`),
+		testRuleRecord(t, `{"name":"expired","enabled":true,"when":{"field":"failure_reason","operator":"eq","value":"expired_card"},"actions":[{"type":"alert"}]}`),
+	}
+
+	diff := diffRuleSets(base, next)
+	assert.Equal(t, []string{"expired"}, diff.Added)
+	assert.Equal(t, []string{"legacy"}, diff.Removed)
+	require.Len(t, diff.Changed, 1)
+	assert.Equal(t, "large", diff.Changed[0].Name)
+	assert.Equal(t, []string{"priority", "enabled", "when"}, diff.Changed[0].Fields)
+}
+
+func TestDiffRuleSetsFromNothing(t *testing.T) {
+	diff := diffRuleSets(nil, []models.RuleDefinitionRecord{
+		testRuleRecord(t, `{"name":"large","enabled":true,"when":{"expr":"amount >= 1000"},"actions":[{"type":"alert"}]}`),
+	})
+	assert.Equal(t, []string{"large"}, diff.Added)
+
+	// Empty lists are encoded as arrays so clients can iterate without null checks
+	encoded, err := json.Marshal(diffRuleSets(nil, nil))
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"added":[],"removed":[],"changed":[]}`, string(encoded))
+}
+
+func TestRuleSetVersionRecordsRoundTrip(t *testing.T) {
+	records := []models.RuleDefinitionRecord{
+		testRuleRecord(t, `{"name":"large","enabled":true,"shadow":true,"when":{"expr":"amount >= 1000"},"actions":[{"type":"alert"}]}`),
+	}
+	snapshot, err := json.Marshal(records)
+	require.NoError(t, err)
+
+	restored, err := ruleSetVersionRecords(&models.RuleSetVersion{Version: 3, Rules: snapshot})
+	require.NoError(t, err)
+	require.Len(t, restored, 1)
+	assert.Equal(t, "large", restored[0].Name)
+	assert.True(t, restored[0].Shadow)
+	assert.Empty(t, diffRuleSets(records, restored).Changed)
+
+	assert.Nil(t, ruleSetVersionRef(0))
+	assert.Equal(t, 3, *ruleSetVersionRef(3))
+}
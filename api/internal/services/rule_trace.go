@@ -0,0 +1,157 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/sambitmohanty1/payment-watchdog/api/internal/models"
+	"github.com/sambitmohanty1/payment-watchdog/api/internal/rules"
+)
+
+// RecordEnterpriseTraces persists the evaluation of every rule the enterprise
+// rule engine considered for a payment failure
+func (s *DecisionLogService) RecordEnterpriseTraces(ctx context.Context, companyID string, paymentFailureID uuid.UUID, results []*rules.EnterpriseRuleResult) error {
+	return s.recordTraces(ctx, enterpriseRuleTraces(companyID, paymentFailureID, results))
+}
+
+// RecordRuleTraces persists the evaluation of the live rules of engine for event.
+// Results only cover rules whose condition held, so every other enabled rule is
+// traced as considered but not triggered. ruleSetVersion is the published company
+// rule set engine was compiled from, or zero for built-in rules.
+func (s *DecisionLogService) RecordRuleTraces(ctx context.Context, engine rules.RuleEngine, ruleSetVersion int, event *models.PaymentFailureEvent, results []*rules.ActionResult) error {
+	traces := basicRuleTraces(engine, event, results)
+	for i := range traces {
+		traces[i].RuleSetVersion = ruleSetVersionRef(ruleSetVersion)
+	}
+	return s.recordTraces(ctx, traces)
+}
+
+func (s *DecisionLogService) recordTraces(ctx context.Context, traces []models.RuleEvaluationTrace) error {
+	if len(traces) == 0 {
+		return nil
+	}
+	if err := s.db.WithContext(ctx).Create(&traces).Error; err != nil {
+		return fmt.Errorf("failed to record rule evaluation traces: %w", err)
+	}
+	return nil
+}
+
+func enterpriseRuleTraces(companyID string, paymentFailureID uuid.UUID, results []*rules.EnterpriseRuleResult) []models.RuleEvaluationTrace {
+	traces := make([]models.RuleEvaluationTrace, 0, len(results))
+	for _, result := range results {
+		if result == nil {
+			continue
+		}
+		conditions := make([]models.RuleTraceCondition, len(result.Conditions))
+		for i, condition := range result.Conditions {
+			conditions[i] = models.RuleTraceCondition{
+				Type:           condition.ConditionType,
+				Description:    condition.ConditionName,
+				Met:            condition.Met,
+				Value:          condition.Value,
+				Error:          condition.Error,
+				DurationMicros: condition.Duration.Microseconds(),
+			}
+		}
+		actions := make([]models.RuleTraceAction, len(result.Actions))
+		for i, action := range result.Actions {
+			actions[i] = models.RuleTraceAction{
+				Type:           action.ActionType,
+				Description:    action.ActionName,
+				Executed:       action.Executed,
+				Error:          errorString(action.Error),
+				Output:         action.Output,
+				DurationMicros: action.Duration.Microseconds(),
+			}
+		}
+
+		trace := models.RuleEvaluationTrace{
+			CompanyID:        companyID,
+			PaymentFailureID: paymentFailureID,
+			Engine:           models.RuleTraceEngineEnterprise,
+			RuleID:           result.RuleID,
+			RuleName:         result.RuleName,
+			Triggered:        result.Triggered,
+			Shadow:           result.Shadow,
+			ErrorMessage:     errorString(result.Error),
+			DurationMicros:   result.Duration.Microseconds(),
+			EvaluatedAt:      result.ExecutedAt,
+		}
+		trace.Conditions, _ = json.Marshal(conditions)
+		trace.Actions, _ = json.Marshal(actions)
+		traces = append(traces, trace)
+	}
+	return traces
+}
+
+func basicRuleTraces(engine rules.RuleEngine, event *models.PaymentFailureEvent, results []*rules.ActionResult) []models.RuleEvaluationTrace {
+	byName := make(map[string]*rules.ActionResult, len(results))
+	for _, result := range results {
+		byName[result.RuleName] = result
+	}
+
+	now := time.Now()
+	var traces []models.RuleEvaluationTrace
+	for _, rule := range engine.GetRules() {
+		if !rule.Enabled || rule.Shadow {
+			continue
+		}
+		trace := models.RuleEvaluationTrace{
+			CompanyID:        event.CompanyID,
+			PaymentFailureID: event.ID,
+			Engine:           models.RuleTraceEngineBasic,
+			RuleID:           rule.ID,
+			RuleName:         rule.Name,
+			EvaluatedAt:      now,
+		}
+		actions := []models.RuleTraceAction{}
+		if result, ok := byName[rule.Name]; ok {
+			trace.Triggered = true
+			trace.ErrorMessage = errorString(result.Error)
+			if !result.ExecutedAt.IsZero() {
+				trace.EvaluatedAt = result.ExecutedAt
+			}
+			actions = ruleResultActions(rule, result)
+		}
+		trace.Actions, _ = json.Marshal(actions)
+		traces = append(traces, trace)
+	}
+	return traces
+}
+
+// ruleResultActions splits a rule's single ActionResult into one traced action
+// per action type. Declarative rules report the parameters of each action.
+func ruleResultActions(rule *rules.Rule, result *rules.ActionResult) []models.RuleTraceAction {
+	types := rule.ActionTypes
+	if len(types) == 0 {
+		types = []string{inferredRuleAction(result)}
+	}
+	entries, _ := result.Data["actions"].([]map[string]interface{})
+
+	actions := make([]models.RuleTraceAction, len(types))
+	for i, actionType := range types {
+		actions[i] = models.RuleTraceAction{
+			Type:     actionType,
+			Executed: result.Success,
+			Error:    errorString(result.Error),
+		}
+		switch {
+		case len(entries) == len(types):
+			actions[i].Output = entries[i]
+		case len(types) == 1:
+			actions[i].Output = result.Data
+		}
+	}
+	return actions
+}
+
+func errorString(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
@@ -0,0 +1,83 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sambitmohanty1/payment-watchdog/api/internal/models"
+	"github.com/sambitmohanty1/payment-watchdog/api/internal/rules"
+)
+
+func TestEnterpriseRuleTraces(t *testing.T) {
+	failureID := uuid.New()
+	executedAt := time.Now()
+	traces := enterpriseRuleTraces("company-1", failureID, []*rules.EnterpriseRuleResult{{
+		RuleID:     "high_value",
+		RuleName:   "High Value",
+		Triggered:  true,
+		ExecutedAt: executedAt,
+		Duration:   3 * time.Millisecond,
+		Conditions: []*rules.EnterpriseConditionResult{
+			{ConditionType: "high_value", ConditionName: "Payment amount >= $10000.00", Met: true, Value: 15000.0},
+		},
+		Actions: []*rules.EnterpriseActionResult{
+			{ActionType: "customer_contact", ActionName: "Send SMS", Executed: true, Output: map[string]interface{}{"channel": "sms"}},
+			{ActionType: "alert", Executed: true, Error: errors.New("alert service unavailable")},
+		},
+	}})
+
+	require.Len(t, traces, 1)
+	trace := traces[0]
+	assert.Equal(t, models.RuleTraceEngineEnterprise, trace.Engine)
+	assert.Equal(t, failureID, trace.PaymentFailureID)
+	assert.True(t, trace.Triggered)
+	assert.Equal(t, int64(3000), trace.DurationMicros)
+	assert.Equal(t, executedAt, trace.EvaluatedAt)
+
+	var conditions []models.RuleTraceCondition
+	require.NoError(t, json.Unmarshal(trace.Conditions, &conditions))
+	require.Len(t, conditions, 1)
+	assert.True(t, conditions[0].Met)
+	assert.Equal(t, 15000.0, conditions[0].Value)
+
+	var actions []models.RuleTraceAction
+	require.NoError(t, json.Unmarshal(trace.Actions, &actions))
+	require.Len(t, actions, 2)
+	assert.Equal(t, "sms", actions[0].Output["channel"])
+	assert.Equal(t, "alert service unavailable", actions[1].Error)
+}
+
+func TestBasicRuleTracesIncludeUntriggeredRules(t *testing.T) {
+	engine := compileTestRuleSet(t,
+		`{"name":"large","when":{"expr":"amount >= 1000"},"actions":[{"type":"alert"},{"type":"escalate","params":{"team":"collections"}}]}`,
+		`{"name":"declined","when":{"field":"failure_reason","operator":"eq","value":"card_declined"},"actions":[{"type":"retry"}]}`,
+	)
+	event := &models.PaymentFailureEvent{ID: uuid.New(), CompanyID: "company-1", AmountCents: 250000}
+	results := engine.ExecuteRules(event)
+	require.Len(t, results, 1)
+
+	traces := basicRuleTraces(engine, event, results)
+	require.Len(t, traces, 2)
+	byName := map[string]models.RuleEvaluationTrace{}
+	for _, trace := range traces {
+		byName[trace.RuleName] = trace
+	}
+
+	assert.False(t, byName["declined"].Triggered)
+	assert.JSONEq(t, `[]`, string(byName["declined"].Actions))
+
+	assert.True(t, byName["large"].Triggered)
+	var actions []models.RuleTraceAction
+	require.NoError(t, json.Unmarshal(byName["large"].Actions, &actions))
+	require.Len(t, actions, 2)
+	assert.Equal(t, "alert", actions[0].Type)
+	assert.Equal(t, "escalate", actions[1].Type)
+	assert.Equal(t, "collections", actions[1].Output["team"])
+	assert.True(t, actions[1].Executed)
+}
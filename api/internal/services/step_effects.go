@@ -0,0 +1,65 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"github.com/sambitmohanty1/payment-watchdog/api/internal/models"
+)
+
+// stepEffects is what step executors do outside the workflow engine: reading
+// the clock, submitting payment retries, sending messages, counting a
+// customer's recent contacts and recording recovery actions. Executions run
+// them live through the service; simulations swap in a dry run on a virtual
+// clock, so the same executors run without side effects.
+type stepEffects interface {
+	Now() time.Time
+	SubmitRetry(ctx context.Context, idempotencyKey, companyID string, data map[string]interface{}) (*RetryJob, error)
+	SendEmail(ctx context.Context, req *CommunicationRequest) (*CommunicationResult, error)
+	SendSMS(ctx context.Context, req *CommunicationRequest) (*CommunicationResult, error)
+	FindSent(ctx context.Context, idempotencyKey string) (*CommunicationResult, error)
+	CountRecentContacts(ctx context.Context, companyID, provider, customerID string, since time.Time) (int64, error)
+	RecordRecoveryAction(ctx context.Context, action *models.RecoveryAction) error
+}
+
+// effects returns the step effects of an execution, live unless it carries its own
+func (r *RecoveryOrchestrationService) effects(execution *WorkflowExecution) stepEffects {
+	if execution.effects != nil {
+		return execution.effects
+	}
+	return liveEffects{service: r}
+}
+
+// liveEffects carries step effects out through the service's retry,
+// communication and identity services
+type liveEffects struct {
+	service *RecoveryOrchestrationService
+}
+
+func (l liveEffects) Now() time.Time {
+	return time.Now()
+}
+
+func (l liveEffects) SubmitRetry(ctx context.Context, idempotencyKey, companyID string, data map[string]interface{}) (*RetryJob, error) {
+	return l.service.retryService.SubmitJobOnce(ctx, idempotencyKey, "payment_retry", companyID, data)
+}
+
+func (l liveEffects) SendEmail(ctx context.Context, req *CommunicationRequest) (*CommunicationResult, error) {
+	return l.service.communicationService.SendEmail(ctx, req)
+}
+
+func (l liveEffects) SendSMS(ctx context.Context, req *CommunicationRequest) (*CommunicationResult, error) {
+	return l.service.communicationService.SendSMS(ctx, req)
+}
+
+func (l liveEffects) FindSent(ctx context.Context, idempotencyKey string) (*CommunicationResult, error) {
+	return l.service.communicationService.FindSent(ctx, idempotencyKey)
+}
+
+func (l liveEffects) CountRecentContacts(ctx context.Context, companyID, provider, customerID string, since time.Time) (int64, error) {
+	return l.service.identityService.CountRecentContacts(ctx, companyID, provider, customerID, since)
+}
+
+func (l liveEffects) RecordRecoveryAction(ctx context.Context, action *models.RecoveryAction) error {
+	return l.service.recordRecoveryAction(ctx, action)
+}
@@ -23,6 +23,17 @@ func (e *PaymentRetryExecutor) GetStepType() string {
 	return "retry_payment"
 }
 
+// ConfigSchema describes the config of retry_payment steps
+func (e *PaymentRetryExecutor) ConfigSchema() StepConfigSchema {
+	return StepConfigSchema{
+		"provider":            {Type: ConfigFieldString, Description: "Payment provider to retry through"},
+		"max_retries":         {Type: ConfigFieldInteger, Description: "Maximum number of retries"},
+		"retry_delay_minutes": {Type: ConfigFieldInteger, Description: "Minutes between retries"},
+		"update_amount":       {Type: ConfigFieldBool, Description: "Retry with new_amount instead of the original amount"},
+		"new_amount":          {Type: ConfigFieldNumber, Description: "Amount to retry with"},
+	}
+}
+
 func (e *PaymentRetryExecutor) Execute(ctx context.Context, execution *WorkflowExecution, step *models.RecoveryWorkflowStep) (*StepResult, error) {
 	if e.tracer == nil {
 		e.tracer = otel.Tracer("payment-retry-executor")
@@ -75,7 +86,8 @@ func (e *PaymentRetryExecutor) Execute(ctx context.Context, execution *WorkflowE
 	}
 
 	// Submit to retry service
-	retryJob, err := e.service.retryService.SubmitJob(ctx, "payment_retry", execution.CompanyID.String(), retryData)
+	effects := e.service.effects(execution)
+	retryJob, err := effects.SubmitRetry(ctx, execution.stepKey(step.ID), execution.CompanyID.String(), retryData)
 	if err != nil {
 		span.RecordError(err)
 		return &StepResult{
@@ -95,11 +107,12 @@ func (e *PaymentRetryExecutor) Execute(ctx context.Context, execution *WorkflowE
 		Status:              "pending",
 		Provider:            config.Provider,
 		ExternalID:          retryJob.ID,
+		IdempotencyKey:      execution.stepKey(step.ID),
 		ScheduledAt:         &time.Time{},
 	}
-	*recoveryAction.ScheduledAt = time.Now()
+	*recoveryAction.ScheduledAt = effects.Now()
 
-	if err := e.service.db.WithContext(ctx).Create(recoveryAction).Error; err != nil {
+	if err := effects.RecordRecoveryAction(ctx, recoveryAction); err != nil {
 		// Log error but don't fail the step
 		span.RecordError(err)
 	}
@@ -110,7 +123,7 @@ func (e *PaymentRetryExecutor) Execute(ctx context.Context, execution *WorkflowE
 		Data: map[string]interface{}{
 			"retry_job_id":     retryJob.ID,
 			"provider":         config.Provider,
-			"scheduled_at":     time.Now(),
+			"scheduled_at":     *recoveryAction.ScheduledAt,
 			"recovery_action_id": recoveryAction.ID.String(),
 		},
 	}, nil
@@ -126,6 +139,21 @@ func (e *EmailExecutor) GetStepType() string {
 	return "send_email"
 }
 
+// ConfigSchema describes the config of send_email steps
+func (e *EmailExecutor) ConfigSchema() StepConfigSchema {
+	return StepConfigSchema{
+		"template_id":          {Type: ConfigFieldString, Template: "email", Description: "Email template to send, by ID"},
+		"template_name":        {Type: ConfigFieldString, Template: "email", Description: "Email template to send, by name"},
+		"to_email":             {Type: ConfigFieldString, Description: "Recipient; defaults to the customer's email"},
+		"subject":              {Type: ConfigFieldString, Description: "Subject overriding the template's"},
+		"variables":            {Type: ConfigFieldObject, Description: "Extra template variables"},
+		"max_contacts":         {Type: ConfigFieldInteger, Description: "Contacts allowed per customer within the window; unlimited when unset"},
+		"contact_window_hours": {Type: ConfigFieldInteger, Description: "Window max_contacts applies to"},
+		"send_at":              {Type: ConfigFieldSchedule, Description: "Schedule in the company calendar, e.g. \"next_business_day 10:00\""},
+		"business_hours_only":  {Type: ConfigFieldBool, Description: "Hold the message until the company is open"},
+	}
+}
+
 func (e *EmailExecutor) Execute(ctx context.Context, execution *WorkflowExecution, step *models.RecoveryWorkflowStep) (*StepResult, error) {
 	if e.tracer == nil {
 		e.tracer = otel.Tracer("email-executor")
@@ -141,6 +169,10 @@ func (e *EmailExecutor) Execute(ctx context.Context, execution *WorkflowExecutio
 		ToEmail      string            `json:"to_email"`
 		Subject      string            `json:"subject"`
 		Variables    map[string]string `json:"variables"`
+		MaxContacts  int               `json:"max_contacts"`
+		ContactWindowHours int         `json:"contact_window_hours"`
+		SendAt       string            `json:"send_at"`             // schedule in the company calendar, e.g. "next_business_day 10:00"
+		BusinessHoursOnly bool         `json:"business_hours_only"` // hold the message until the company is open
 	}
 
 	if err := json.Unmarshal(step.Config, &config); err != nil {
@@ -156,6 +188,19 @@ func (e *EmailExecutor) Execute(ctx context.Context, execution *WorkflowExecutio
 		return nil, err
 	}
 
+	// Hold the email until its send time in the company's business calendar
+	effects := e.service.effects(execution)
+	if held := e.service.waitForSendTime(ctx, execution, step, config.SendAt, config.BusinessHoursOnly); held != nil {
+		return held, nil
+	}
+
+	// Respect contact frequency limits across all of the customer's provider
+	// records, unless this step's email already went out before a restart
+	if skipped := checkContactLimit(ctx, effects, execution.stepKey(step.ID), paymentFailure, config.MaxContacts, config.ContactWindowHours); skipped != nil {
+		span.SetAttributes(attribute.Bool("contact_limit_reached", true))
+		return skipped, nil
+	}
+
 	// Determine recipient email
 	recipientEmail := config.ToEmail
 	if recipientEmail == "" {
@@ -183,7 +228,7 @@ func (e *EmailExecutor) Execute(ctx context.Context, execution *WorkflowExecutio
 	templateVars["transaction_id"] = paymentFailure.TransactionID
 
 	// Send email through communication service
-	emailResult, err := e.service.communicationService.SendEmail(ctx, &CommunicationRequest{
+	emailResult, err := effects.SendEmail(ctx, &CommunicationRequest{
 		CompanyID:    execution.CompanyID,
 		TemplateID:   config.TemplateID,
 		TemplateName: config.TemplateName,
@@ -195,6 +240,7 @@ func (e *EmailExecutor) Execute(ctx context.Context, execution *WorkflowExecutio
 			"workflow_execution_id": execution.ID.String(),
 			"step_id":              step.ID.String(),
 		},
+		IdempotencyKey: execution.stepKey(step.ID),
 	})
 
 	if err != nil {
@@ -216,14 +262,15 @@ func (e *EmailExecutor) Execute(ctx context.Context, execution *WorkflowExecutio
 		Status:              "completed",
 		Provider:            "email",
 		ExternalID:          emailResult.MessageID,
+		IdempotencyKey:      execution.stepKey(step.ID),
 		ExecutedAt:          &time.Time{},
 		CompletedAt:         &time.Time{},
 	}
-	now := time.Now()
+	now := effects.Now()
 	*recoveryAction.ExecutedAt = now
 	*recoveryAction.CompletedAt = now
 
-	if err := e.service.db.WithContext(ctx).Create(recoveryAction).Error; err != nil {
+	if err := effects.RecordRecoveryAction(ctx, recoveryAction); err != nil {
 		span.RecordError(err)
 	}
 
@@ -250,6 +297,21 @@ func (e *SMSExecutor) GetStepType() string {
 	return "send_sms"
 }
 
+// ConfigSchema describes the config of send_sms steps
+func (e *SMSExecutor) ConfigSchema() StepConfigSchema {
+	return StepConfigSchema{
+		"template_id":          {Type: ConfigFieldString, Template: "sms", Description: "SMS template to send, by ID"},
+		"template_name":        {Type: ConfigFieldString, Template: "sms", Description: "SMS template to send, by name"},
+		"to_phone":             {Type: ConfigFieldString, Description: "Recipient; defaults to the customer's phone"},
+		"message":              {Type: ConfigFieldString, Description: "Message overriding the template's"},
+		"variables":            {Type: ConfigFieldObject, Description: "Extra template variables"},
+		"max_contacts":         {Type: ConfigFieldInteger, Description: "Contacts allowed per customer within the window; unlimited when unset"},
+		"contact_window_hours": {Type: ConfigFieldInteger, Description: "Window max_contacts applies to"},
+		"send_at":              {Type: ConfigFieldSchedule, Description: "Schedule in the company calendar, e.g. \"next_business_day 10:00\""},
+		"business_hours_only":  {Type: ConfigFieldBool, Description: "Hold the message until the company is open"},
+	}
+}
+
 func (e *SMSExecutor) Execute(ctx context.Context, execution *WorkflowExecution, step *models.RecoveryWorkflowStep) (*StepResult, error) {
 	if e.tracer == nil {
 		e.tracer = otel.Tracer("sms-executor")
@@ -265,6 +327,10 @@ func (e *SMSExecutor) Execute(ctx context.Context, execution *WorkflowExecution,
 		ToPhone      string            `json:"to_phone"`
 		Message      string            `json:"message"`
 		Variables    map[string]string `json:"variables"`
+		MaxContacts  int               `json:"max_contacts"`
+		ContactWindowHours int         `json:"contact_window_hours"`
+		SendAt       string            `json:"send_at"`             // schedule in the company calendar, e.g. "next_business_day 10:00"
+		BusinessHoursOnly bool         `json:"business_hours_only"` // hold the message until the company is open
 	}
 
 	if err := json.Unmarshal(step.Config, &config); err != nil {
@@ -280,6 +346,12 @@ func (e *SMSExecutor) Execute(ctx context.Context, execution *WorkflowExecution,
 		return nil, err
 	}
 
+	// Hold the SMS until its send time in the company's business calendar
+	effects := e.service.effects(execution)
+	if held := e.service.waitForSendTime(ctx, execution, step, config.SendAt, config.BusinessHoursOnly); held != nil {
+		return held, nil
+	}
+
 	// Determine recipient phone
 	recipientPhone := config.ToPhone
 	if recipientPhone == "" {
@@ -294,6 +366,13 @@ func (e *SMSExecutor) Execute(ctx context.Context, execution *WorkflowExecution,
 		}, nil
 	}
 
+	// Respect contact frequency limits across all of the customer's provider
+	// records, unless this step's SMS already went out before a restart
+	if skipped := checkContactLimit(ctx, effects, execution.stepKey(step.ID), paymentFailure, config.MaxContacts, config.ContactWindowHours); skipped != nil {
+		span.SetAttributes(attribute.Bool("contact_limit_reached", true))
+		return skipped, nil
+	}
+
 	span.SetAttributes(
 		attribute.String("template_id", config.TemplateID),
 		attribute.String("template_name", config.TemplateName),
@@ -312,7 +391,7 @@ func (e *SMSExecutor) Execute(ctx context.Context, execution *WorkflowExecution,
 	templateVars["currency"] = paymentFailure.Currency
 
 	// Send SMS through communication service
-	smsResult, err := e.service.communicationService.SendSMS(ctx, &CommunicationRequest{
+	smsResult, err := effects.SendSMS(ctx, &CommunicationRequest{
 		CompanyID:    execution.CompanyID,
 		TemplateID:   config.TemplateID,
 		TemplateName: config.TemplateName,
@@ -324,6 +403,7 @@ func (e *SMSExecutor) Execute(ctx context.Context, execution *WorkflowExecution,
 			"workflow_execution_id": execution.ID.String(),
 			"step_id":              step.ID.String(),
 		},
+		IdempotencyKey: execution.stepKey(step.ID),
 	})
 
 	if err != nil {
@@ -345,14 +425,15 @@ func (e *SMSExecutor) Execute(ctx context.Context, execution *WorkflowExecution,
 		Status:              "completed",
 		Provider:            "sms",
 		ExternalID:          smsResult.MessageID,
+		IdempotencyKey:      execution.stepKey(step.ID),
 		ExecutedAt:          &time.Time{},
 		CompletedAt:         &time.Time{},
 	}
-	now := time.Now()
+	now := effects.Now()
 	*recoveryAction.ExecutedAt = now
 	*recoveryAction.CompletedAt = now
 
-	if err := e.service.db.WithContext(ctx).Create(recoveryAction).Error; err != nil {
+	if err := effects.RecordRecoveryAction(ctx, recoveryAction); err != nil {
 		span.RecordError(err)
 	}
 
@@ -369,6 +450,43 @@ func (e *SMSExecutor) Execute(ctx context.Context, execution *WorkflowExecution,
 	}, nil
 }
 
+// Default window a step's contact frequency limit applies to
+const defaultContactWindowHours = 72
+
+// checkContactLimit returns a skipped step result when the customer identity behind
+// paymentFailure has already been contacted maxContacts times in the window. Steps
+// without a positive maxContacts are not limited. Lookup errors never block a
+// contact. A message already sent under idempotencyKey is never skipped, since it
+// counts towards the limit itself.
+func checkContactLimit(ctx context.Context, effects stepEffects, idempotencyKey string, paymentFailure *models.PaymentFailureEvent, maxContacts, windowHours int) *StepResult {
+	if maxContacts <= 0 || paymentFailure.CustomerID == "" {
+		return nil
+	}
+	if sent, err := effects.FindSent(ctx, idempotencyKey); err == nil && sent != nil {
+		return nil
+	}
+	if windowHours <= 0 {
+		windowHours = defaultContactWindowHours
+	}
+
+	since := effects.Now().Add(-time.Duration(windowHours) * time.Hour)
+	count, err := effects.CountRecentContacts(ctx, paymentFailure.CompanyID, failureProvider(paymentFailure), paymentFailure.CustomerID, since)
+	if err != nil || count < int64(maxContacts) {
+		return nil
+	}
+
+	return &StepResult{
+		Success: true,
+		Data: map[string]interface{}{
+			"skipped":         true,
+			"reason":          "contact_frequency_limit",
+			"recent_contacts": count,
+			"max_contacts":    maxContacts,
+			"window_hours":    windowHours,
+		},
+	}
+}
+
 // WaitExecutor handles wait/delay steps
 type WaitExecutor struct {
 	service *RecoveryOrchestrationService
@@ -379,6 +497,19 @@ func (e *WaitExecutor) GetStepType() string {
 	return "wait"
 }
 
+// ConfigSchema describes the config of wait steps
+func (e *WaitExecutor) ConfigSchema() StepConfigSchema {
+	return StepConfigSchema{
+		"wait_minutes":        {Type: ConfigFieldInteger, Description: "Minutes to wait"},
+		"wait_hours":          {Type: ConfigFieldInteger, Description: "Hours to wait"},
+		"wait_days":           {Type: ConfigFieldInteger, Description: "Days to wait"},
+		"wait_business_days":  {Type: ConfigFieldInteger, Description: "Business days to wait in the company calendar"},
+		"until":               {Type: ConfigFieldSchedule, Description: "Wait until a schedule in the company calendar"},
+		"business_hours_only": {Type: ConfigFieldBool, Description: "Extend the wait to the company's next opening time"},
+		"reason":              {Type: ConfigFieldString, Description: "Why the workflow waits"},
+	}
+}
+
 func (e *WaitExecutor) Execute(ctx context.Context, execution *WorkflowExecution, step *models.RecoveryWorkflowStep) (*StepResult, error) {
 	if e.tracer == nil {
 		e.tracer = otel.Tracer("wait-executor")
@@ -389,10 +520,13 @@ func (e *WaitExecutor) Execute(ctx context.Context, execution *WorkflowExecution
 
 	// Parse step configuration
 	var config struct {
-		WaitMinutes int    `json:"wait_minutes"`
-		WaitHours   int    `json:"wait_hours"`
-		WaitDays    int    `json:"wait_days"`
-		Reason      string `json:"reason"`
+		WaitMinutes       int    `json:"wait_minutes"`
+		WaitHours         int    `json:"wait_hours"`
+		WaitDays          int    `json:"wait_days"`
+		WaitBusinessDays  int    `json:"wait_business_days"`
+		Until             string `json:"until"`               // schedule in the company calendar, e.g. "next_business_day 10:00"
+		BusinessHoursOnly bool   `json:"business_hours_only"` // extend the wait to the company's next opening time
+		Reason            string `json:"reason"`
 	}
 
 	if err := json.Unmarshal(step.Config, &config); err != nil {
@@ -402,36 +536,104 @@ func (e *WaitExecutor) Execute(ctx context.Context, execution *WorkflowExecution
 
 	// Calculate total wait duration
 	totalMinutes := config.WaitMinutes + (config.WaitHours * 60) + (config.WaitDays * 24 * 60)
-	if totalMinutes <= 0 {
+	calendarWait := config.WaitBusinessDays > 0 || config.Until != "" || config.BusinessHoursOnly
+	if totalMinutes <= 0 && !calendarWait {
 		totalMinutes = 1 // Minimum 1 minute wait
 	}
 
-	waitDuration := time.Duration(totalMinutes) * time.Minute
+	resumeAt := e.service.effects(execution).Now().Add(time.Duration(totalMinutes) * time.Minute)
+	if calendarWait {
+		cal, err := e.service.calendars.Calendar(ctx, execution.CompanyID.String())
+		if err != nil {
+			span.RecordError(err)
+			return &StepResult{
+				Success:      false,
+				ErrorMessage: fmt.Sprintf("Failed to load business calendar: %v", err),
+				ShouldRetry:  true,
+			}, nil
+		}
+		if config.WaitBusinessDays > 0 {
+			resumeAt = cal.AddBusinessDays(resumeAt, config.WaitBusinessDays)
+		}
+		if config.Until != "" {
+			if resumeAt, err = cal.Resolve(config.Until, resumeAt); err != nil {
+				span.RecordError(err)
+				return &StepResult{
+					Success:      false,
+					ErrorMessage: fmt.Sprintf("Invalid wait schedule: %v", err),
+					ShouldRetry:  false,
+				}, nil
+			}
+		}
+		if config.BusinessHoursOnly {
+			resumeAt = cal.NextOpen(resumeAt)
+		}
+	}
 
 	span.SetAttributes(
 		attribute.Int("wait_minutes", totalMinutes),
+		attribute.String("resume_at", resumeAt.Format(time.RFC3339)),
 		attribute.String("reason", config.Reason),
 	)
 
-	// Perform the wait
-	select {
-	case <-time.After(waitDuration):
-		// Wait completed successfully
-		return &StepResult{
-			Success: true,
-			Data: map[string]interface{}{
-				"wait_duration_minutes": totalMinutes,
-				"reason":               config.Reason,
-				"completed_at":         time.Now(),
-			},
-		}, nil
-	case <-ctx.Done():
-		// Context cancelled
+	// The execution waits on a durable timer and resumes with the next step
+	return &StepResult{
+		Success:   true,
+		WaitUntil: &resumeAt,
+		Data: map[string]interface{}{
+			"wait_duration_minutes": totalMinutes,
+			"resume_at":             resumeAt,
+			"reason":                config.Reason,
+		},
+	}, nil
+}
+
+// waitForSendTime holds a communication step until the time given by its send_at
+// schedule and business_hours_only setting in the company's business calendar. It
+// returns nil once the step may send, or the result to end the step with: a
+// deferral that runs the step again at its send time.
+func (r *RecoveryOrchestrationService) waitForSendTime(ctx context.Context, execution *WorkflowExecution, step *models.RecoveryWorkflowStep, sendAt string, businessHoursOnly bool) *StepResult {
+	if sendAt == "" && !businessHoursOnly {
+		return nil
+	}
+	if execution.waitServed(step.ID, models.WorkflowTimerSendTime) {
+		return nil
+	}
+
+	cal, err := r.calendars.Calendar(ctx, execution.CompanyID.String())
+	if err != nil {
 		return &StepResult{
 			Success:      false,
-			ErrorMessage: "Wait cancelled due to context cancellation",
-			ShouldRetry:  false,
-		}, nil
+			ErrorMessage: fmt.Sprintf("Failed to load business calendar: %v", err),
+			ShouldRetry:  true,
+		}
+	}
+
+	now := r.effects(execution).Now()
+	sendTime := now
+	if sendAt != "" {
+		if sendTime, err = cal.Resolve(sendAt, sendTime); err != nil {
+			return &StepResult{
+				Success:      false,
+				ErrorMessage: fmt.Sprintf("Invalid send_at schedule: %v", err),
+				ShouldRetry:  false,
+			}
+		}
+	}
+	if businessHoursOnly {
+		sendTime = cal.NextOpen(sendTime)
+	}
+
+	if !sendTime.After(now) {
+		return nil
+	}
+	return &StepResult{
+		Success:   true,
+		WaitUntil: &sendTime,
+		RerunStep: true,
+		Data: map[string]interface{}{
+			"deferred_until": sendTime,
+		},
 	}
 }
 
@@ -445,6 +647,18 @@ func (e *ConditionalExecutor) GetStepType() string {
 	return "conditional"
 }
 
+// ConfigSchema describes the config of conditional steps
+func (e *ConditionalExecutor) ConfigSchema() StepConfigSchema {
+	return StepConfigSchema{
+		"conditions": {Type: ConfigFieldList, Description: "Field comparisons"},
+		"logic":      {Type: ConfigFieldString, Enum: []string{"AND", "OR"}, Description: "How conditions combine"},
+		"expression": {Type: ConfigFieldString, Description: "Expression that must also hold"},
+		"on_true":    {Type: ConfigFieldString, Enum: []string{"continue", "skip", "stop"}, Description: "Action if the condition holds"},
+		"on_false":   {Type: ConfigFieldString, Enum: []string{"continue", "skip", "stop"}, Description: "Action if it does not"},
+		"skip_steps": {Type: ConfigFieldInteger, Description: "Number of steps to skip"},
+	}
+}
+
 func (e *ConditionalExecutor) Execute(ctx context.Context, execution *WorkflowExecution, step *models.RecoveryWorkflowStep) (*StepResult, error) {
 	if e.tracer == nil {
 		e.tracer = otel.Tracer("conditional-executor")
@@ -455,12 +669,7 @@ func (e *ConditionalExecutor) Execute(ctx context.Context, execution *WorkflowEx
 
 	// Parse step configuration
 	var config struct {
-		Conditions []struct {
-			Field    string      `json:"field"`
-			Operator string      `json:"operator"`
-			Value    interface{} `json:"value"`
-		} `json:"conditions"`
-		Logic      string `json:"logic"` // "AND" or "OR"
+		conditionSet        // conditions, logic ("AND" or "OR") and expression
 		OnTrue     string `json:"on_true"`   // Action if condition is true
 		OnFalse    string `json:"on_false"`  // Action if condition is false
 		SkipSteps  int    `json:"skip_steps"` // Number of steps to skip
@@ -480,29 +689,10 @@ func (e *ConditionalExecutor) Execute(ctx context.Context, execution *WorkflowEx
 	}
 
 	// Evaluate conditions
-	conditionResults := make([]bool, len(config.Conditions))
-	for i, condition := range config.Conditions {
-		conditionResults[i] = e.evaluateCondition(paymentFailure, condition.Field, condition.Operator, condition.Value)
-	}
-
-	// Apply logic
-	var finalResult bool
-	if config.Logic == "OR" {
-		finalResult = false
-		for _, result := range conditionResults {
-			if result {
-				finalResult = true
-				break
-			}
-		}
-	} else { // Default to AND
-		finalResult = true
-		for _, result := range conditionResults {
-			if !result {
-				finalResult = false
-				break
-			}
-		}
+	finalResult, err := config.evaluate(paymentFailure, e.service.effects(execution).Now())
+	if err != nil {
+		span.RecordError(err)
+		return nil, fmt.Errorf("failed to evaluate conditions: %w", err)
 	}
 
 	span.SetAttributes(
@@ -511,10 +701,12 @@ func (e *ConditionalExecutor) Execute(ctx context.Context, execution *WorkflowEx
 		attribute.Int("conditions_count", len(config.Conditions)),
 	)
 
-	// Determine action based on result
+	// Determine action based on result; the outcome picks the successors
 	var action string
+	outcome := models.StepOutcomeFalse
 	if finalResult {
 		action = config.OnTrue
+		outcome = models.StepOutcomeTrue
 	} else {
 		action = config.OnFalse
 	}
@@ -525,6 +717,9 @@ func (e *ConditionalExecutor) Execute(ctx context.Context, execution *WorkflowEx
 		"conditions_evaluated": len(config.Conditions),
 		"logic_used":         config.Logic,
 	}
+	if config.Expression != "" {
+		resultData["expression"] = config.Expression
+	}
 
 	// Handle specific actions
 	switch action {
@@ -533,6 +728,7 @@ func (e *ConditionalExecutor) Execute(ctx context.Context, execution *WorkflowEx
 		return &StepResult{
 			Success: true,
 			Data:    resultData,
+			Outcome: outcome,
 		}, nil
 	case "skip":
 		// Skip specified number of steps
@@ -540,6 +736,7 @@ func (e *ConditionalExecutor) Execute(ctx context.Context, execution *WorkflowEx
 		return &StepResult{
 			Success: true,
 			Data:    resultData,
+			Outcome: outcome,
 		}, nil
 	case "stop":
 		// Stop workflow execution
@@ -547,70 +744,14 @@ func (e *ConditionalExecutor) Execute(ctx context.Context, execution *WorkflowEx
 			Success:      false,
 			ErrorMessage: "Workflow stopped due to conditional logic",
 			Data:         resultData,
+			Outcome:      outcome,
 		}, nil
 	default:
 		// Unknown action, continue normally
 		return &StepResult{
 			Success: true,
 			Data:    resultData,
+			Outcome: outcome,
 		}, nil
 	}
 }
-
-func (e *ConditionalExecutor) evaluateCondition(paymentFailure *models.PaymentFailureEvent, field, operator string, value interface{}) bool {
-	var fieldValue interface{}
-
-	// Extract field value from payment failure
-	switch field {
-	case "amount":
-		fieldValue = paymentFailure.Amount
-	case "currency":
-		fieldValue = paymentFailure.Currency
-	case "failure_reason":
-		fieldValue = paymentFailure.FailureReason
-	case "provider":
-		fieldValue = paymentFailure.Provider
-	case "customer_email":
-		fieldValue = paymentFailure.CustomerEmail
-	case "retry_count":
-		fieldValue = paymentFailure.RetryCount
-	case "days_overdue":
-		if paymentFailure.DueDate != nil {
-			fieldValue = int(time.Since(*paymentFailure.DueDate).Hours() / 24)
-		}
-	default:
-		return false
-	}
-
-	// Evaluate condition based on operator
-	switch operator {
-	case "eq", "equals":
-		return fieldValue == value
-	case "ne", "not_equals":
-		return fieldValue != value
-	case "gt", "greater_than":
-		return compareNumbers(fieldValue, value) > 0
-	case "gte", "greater_than_or_equal":
-		return compareNumbers(fieldValue, value) >= 0
-	case "lt", "less_than":
-		return compareNumbers(fieldValue, value) < 0
-	case "lte", "less_than_or_equal":
-		return compareNumbers(fieldValue, value) <= 0
-	case "contains":
-		if str, ok := fieldValue.(string); ok {
-			if substr, ok := value.(string); ok {
-				return contains(str, substr)
-			}
-		}
-	case "in":
-		if values, ok := value.([]interface{}); ok {
-			for _, v := range values {
-				if fieldValue == v {
-					return true
-				}
-			}
-		}
-	}
-
-	return false
-}
@@ -0,0 +1,37 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/sambitmohanty1/payment-watchdog/api/internal/models"
+)
+
+func TestCheckContactLimitOnlyWhenConfigured(t *testing.T) {
+	now := time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+	effects := &dryRun{now: now, sent: make(map[string]*CommunicationResult)}
+	for i := 1; i <= 5; i++ {
+		effects.contacts = append(effects.contacts, now.Add(-time.Duration(i)*time.Hour))
+	}
+	failure := &models.PaymentFailureEvent{CompanyID: "company", CustomerID: "cus_1"}
+
+	// Steps without max_contacts are never capped
+	assert.Nil(t, checkContactLimit(context.Background(), effects, "key", failure, 0, 0))
+
+	assert.Nil(t, checkContactLimit(context.Background(), effects, "key", failure, 6, 0))
+	skipped := checkContactLimit(context.Background(), effects, "key", failure, 5, 0)
+	if assert.NotNil(t, skipped) {
+		assert.Equal(t, "contact_frequency_limit", skipped.Data["reason"])
+		assert.Equal(t, int64(5), skipped.Data["recent_contacts"])
+	}
+
+	// Only contacts within the window count
+	assert.Nil(t, checkContactLimit(context.Background(), effects, "key", failure, 3, 2))
+
+	// A message already sent under the key is not skipped
+	effects.sent["key"] = &CommunicationResult{MessageID: "msg"}
+	assert.Nil(t, checkContactLimit(context.Background(), effects, "key", failure, 1, 0))
+}
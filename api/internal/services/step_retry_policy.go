@@ -0,0 +1,165 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/sambitmohanty1/payment-watchdog/api/internal/models"
+)
+
+// ErrInvalidRetryPolicy is returned when a step's retry policy cannot be used
+var ErrInvalidRetryPolicy = errors.New("invalid retry policy")
+
+// maxStepAttempts caps how often a single step may be attempted
+const maxStepAttempts = 20
+
+// defaultStepRetryPolicy applies to steps without a policy of their own, and
+// fills in the fields a policy leaves zero. Only transient failures and
+// timeouts are retried.
+var defaultStepRetryPolicy = models.StepRetryPolicy{
+	MaxAttempts:         3,
+	InitialDelaySeconds: 60,
+	MaxDelaySeconds:     3600,
+	BackoffMultiplier:   2,
+	Jitter:              0.2,
+	RetryOn:             []string{models.StepErrorRetryable, models.StepErrorTimeout},
+}
+
+var stepErrorClasses = map[string]bool{
+	models.StepErrorRetryable:    true,
+	models.StepErrorTimeout:      true,
+	models.StepErrorError:        true,
+	models.StepErrorUnsuccessful: true,
+}
+
+// stepRetryPolicy returns the step's retry policy with defaults applied
+func stepRetryPolicy(step *models.RecoveryWorkflowStep) models.StepRetryPolicy {
+	if step.RetryPolicy == nil {
+		return defaultStepRetryPolicy
+	}
+	policy := *step.RetryPolicy
+	if policy.MaxAttempts == 0 {
+		policy.MaxAttempts = defaultStepRetryPolicy.MaxAttempts
+	}
+	if policy.InitialDelaySeconds == 0 {
+		policy.InitialDelaySeconds = defaultStepRetryPolicy.InitialDelaySeconds
+	}
+	if policy.MaxDelaySeconds == 0 {
+		policy.MaxDelaySeconds = defaultStepRetryPolicy.MaxDelaySeconds
+	}
+	if policy.BackoffMultiplier == 0 {
+		policy.BackoffMultiplier = defaultStepRetryPolicy.BackoffMultiplier
+	}
+	if len(policy.RetryOn) == 0 {
+		policy.RetryOn = defaultStepRetryPolicy.RetryOn
+	}
+	return policy
+}
+
+// retriesClass reports whether the policy retries failures of the error class
+func retriesClass(policy models.StepRetryPolicy, class string) bool {
+	for _, retryOn := range policy.RetryOn {
+		if retryOn == class {
+			return true
+		}
+	}
+	return false
+}
+
+// retryDelay is how long to wait after the given failed attempt. A delay the
+// step asked for replaces the backoff; jitter then moves it by up to its
+// fraction either way, with random in [0, 1).
+func retryDelay(policy models.StepRetryPolicy, attempt int, requested time.Duration, random float64) time.Duration {
+	delay := requested
+	if delay <= 0 {
+		seconds := float64(policy.InitialDelaySeconds) * math.Pow(policy.BackoffMultiplier, float64(attempt-1))
+		delay = time.Duration(math.Min(seconds, float64(policy.MaxDelaySeconds)) * float64(time.Second))
+	}
+	if policy.Jitter > 0 {
+		delay = time.Duration(float64(delay) * (1 + policy.Jitter*(2*random-1)))
+	}
+	if delay < time.Second {
+		delay = time.Second
+	}
+	return delay
+}
+
+// stepErrorClass classifies a failed attempt, returning "" when it succeeded
+func stepErrorClass(result *StepResult, err error) string {
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return models.StepErrorTimeout
+	case result != nil && result.ShouldRetry && (err != nil || !result.Success):
+		return models.StepErrorRetryable
+	case err != nil:
+		return models.StepErrorError
+	case result != nil && !result.Success && result.Outcome == "":
+		return models.StepErrorUnsuccessful
+	}
+	return ""
+}
+
+// attemptKey is the idempotency key of one attempt at a step. The first keeps
+// the step's key so records from before retries still match.
+func attemptKey(stepKey string, attempt int) string {
+	if attempt <= 1 {
+		return stepKey
+	}
+	return fmt.Sprintf("%s#%d", stepKey, attempt)
+}
+
+// ValidateStepRetryPolicies checks the retry policies of a workflow's steps
+func ValidateStepRetryPolicies(workflow *models.RecoveryWorkflow) error {
+	for i, step := range workflow.Steps {
+		policy := step.RetryPolicy
+		if policy == nil {
+			continue
+		}
+		switch {
+		case policy.MaxAttempts < 0 || policy.MaxAttempts > maxStepAttempts:
+			return fmt.Errorf("%w: steps[%d].retry_policy.max_attempts: must be between 1 and %d", ErrInvalidRetryPolicy, i, maxStepAttempts)
+		case policy.InitialDelaySeconds < 0:
+			return fmt.Errorf("%w: steps[%d].retry_policy.initial_delay_seconds: must not be negative", ErrInvalidRetryPolicy, i)
+		case policy.MaxDelaySeconds < 0:
+			return fmt.Errorf("%w: steps[%d].retry_policy.max_delay_seconds: must not be negative", ErrInvalidRetryPolicy, i)
+		case policy.BackoffMultiplier != 0 && policy.BackoffMultiplier < 1:
+			return fmt.Errorf("%w: steps[%d].retry_policy.backoff_multiplier: must be at least 1", ErrInvalidRetryPolicy, i)
+		case policy.Jitter < 0 || policy.Jitter > 1:
+			return fmt.Errorf("%w: steps[%d].retry_policy.jitter: must be between 0 and 1", ErrInvalidRetryPolicy, i)
+		}
+		for _, class := range policy.RetryOn {
+			if !stepErrorClasses[class] {
+				return fmt.Errorf("%w: steps[%d].retry_policy.retry_on: unknown error class %q", ErrInvalidRetryPolicy, i, class)
+			}
+		}
+	}
+	return nil
+}
+
+// GetStepAttempts returns every attempt record of an execution's steps, in the
+// order they were made, including those still retrying and those exhausted
+func (r *RecoveryOrchestrationService) GetStepAttempts(ctx context.Context, executionID, companyID uuid.UUID) ([]models.RecoveryStepExecution, error) {
+	var executions int64
+	if err := r.db.WithContext(ctx).Model(&models.RecoveryWorkflowExecution{}).
+		Where("id = ? AND company_id = ?", executionID, companyID).
+		Count(&executions).Error; err != nil {
+		return nil, fmt.Errorf("failed to load workflow execution: %w", err)
+	}
+	if executions == 0 {
+		return nil, ErrWorkflowExecutionNotFound
+	}
+
+	var attempts []models.RecoveryStepExecution
+	if err := r.db.WithContext(ctx).
+		Where("workflow_execution_id = ?", executionID).
+		Order("started_at ASC, attempt ASC").
+		Find(&attempts).Error; err != nil {
+		return nil, fmt.Errorf("failed to load step attempts: %w", err)
+	}
+	return attempts, nil
+}
@@ -0,0 +1,192 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.uber.org/zap"
+
+	"github.com/sambitmohanty1/payment-watchdog/api/internal/models"
+)
+
+// flakyExecutor fails every attempt with a transient error
+type flakyExecutor struct{ calls int }
+
+func (e *flakyExecutor) GetStepType() string { return "send_email" }
+
+func (e *flakyExecutor) Execute(ctx context.Context, execution *WorkflowExecution, step *models.RecoveryWorkflowStep) (*StepResult, error) {
+	e.calls++
+	return &StepResult{Success: false, ShouldRetry: true, ErrorMessage: "provider down"}, nil
+}
+
+func TestStepRetryPolicyDefaults(t *testing.T) {
+	assert.Equal(t, defaultStepRetryPolicy, stepRetryPolicy(&models.RecoveryWorkflowStep{}))
+
+	policy := stepRetryPolicy(&models.RecoveryWorkflowStep{RetryPolicy: &models.StepRetryPolicy{MaxAttempts: 5, RetryOn: []string{models.StepErrorError}}})
+	assert.Equal(t, 5, policy.MaxAttempts)
+	assert.Equal(t, defaultStepRetryPolicy.InitialDelaySeconds, policy.InitialDelaySeconds)
+	assert.Zero(t, policy.Jitter, "jitter is only added when asked for")
+	assert.True(t, retriesClass(policy, models.StepErrorError))
+	assert.False(t, retriesClass(policy, models.StepErrorRetryable))
+}
+
+func TestRetryDelay(t *testing.T) {
+	policy := models.StepRetryPolicy{InitialDelaySeconds: 10, MaxDelaySeconds: 60, BackoffMultiplier: 3}
+	assert.Equal(t, 10*time.Second, retryDelay(policy, 1, 0, 0))
+	assert.Equal(t, 30*time.Second, retryDelay(policy, 2, 0, 0))
+	assert.Equal(t, 60*time.Second, retryDelay(policy, 3, 0, 0), "capped at the maximum delay")
+	assert.Equal(t, 5*time.Minute, retryDelay(policy, 1, 5*time.Minute, 0), "the step's own NextDelay wins")
+
+	policy.Jitter = 0.5
+	assert.Equal(t, 5*time.Second, retryDelay(policy, 1, 0, 0))
+	assert.Equal(t, 10*time.Second, retryDelay(policy, 1, 0, 0.5))
+	assert.InDelta(t, float64(15*time.Second), float64(retryDelay(policy, 1, 0, 0.999999)), float64(time.Millisecond))
+}
+
+func TestStepErrorClass(t *testing.T) {
+	assert.Equal(t, "", stepErrorClass(&StepResult{Success: true}, nil))
+	assert.Equal(t, "", stepErrorClass(&StepResult{Outcome: models.StepOutcomeFalse}, nil), "a condition outcome is not a failure")
+	assert.Equal(t, models.StepErrorRetryable, stepErrorClass(&StepResult{ShouldRetry: true}, nil))
+	assert.Equal(t, models.StepErrorUnsuccessful, stepErrorClass(&StepResult{ErrorMessage: "No phone number available for SMS"}, nil))
+	assert.Equal(t, models.StepErrorError, stepErrorClass(nil, errors.New("payment failure not found")))
+	assert.Equal(t, models.StepErrorTimeout, stepErrorClass(nil, fmt.Errorf("step timed out: %w", context.DeadlineExceeded)))
+}
+
+func TestValidateStepRetryPolicies(t *testing.T) {
+	valid := &models.RecoveryWorkflow{Steps: []models.RecoveryWorkflowStep{
+		{},
+		{RetryPolicy: &models.StepRetryPolicy{MaxAttempts: 4, Jitter: 0.3, RetryOn: []string{models.StepErrorTimeout}}},
+	}}
+	assert.NoError(t, ValidateStepRetryPolicies(valid))
+
+	for name, policy := range map[string]models.StepRetryPolicy{
+		"too many attempts":  {MaxAttempts: 50},
+		"shrinking backoff":  {BackoffMultiplier: 0.5},
+		"jitter over one":    {Jitter: 1.5},
+		"unknown error type": {RetryOn: []string{"network"}},
+	} {
+		policy := policy
+		err := ValidateStepRetryPolicies(&models.RecoveryWorkflow{Steps: []models.RecoveryWorkflowStep{{RetryPolicy: &policy}}})
+		assert.ErrorIs(t, err, ErrInvalidRetryPolicy, name)
+	}
+}
+
+func TestExecuteStepRetriesUntilExhausted(t *testing.T) {
+	db, mock := mockDB(t)
+	executor := &flakyExecutor{}
+	service := &RecoveryOrchestrationService{
+		db:            db,
+		logger:        zap.NewNop(),
+		tracer:        otel.Tracer("test"),
+		stepExecutors: map[string]StepExecutor{"send_email": executor},
+	}
+	execution := &WorkflowExecution{ID: uuid.New()}
+	step := &models.RecoveryWorkflowStep{
+		ID:          uuid.New(),
+		StepType:    "send_email",
+		RetryPolicy: &models.StepRetryPolicy{MaxAttempts: 2, InitialDelaySeconds: 30},
+	}
+	attempts := `SELECT \* FROM "recovery_step_executions" WHERE workflow_execution_id = \$1 AND step_id = \$2 ORDER BY attempt DESC LIMIT \$3`
+	update := `UPDATE "recovery_step_executions" SET "completed_at"=\$1,"duration_ms"=\$2,"error_class"=\$3,"error_message"=\$4,`
+	counter := `UPDATE "recovery_workflow_executions" SET "%s"=%s \+ \$1`
+
+	// The first attempt fails and schedules the second
+	mock.ExpectQuery(attempts).
+		WithArgs(execution.ID, step.ID, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectQuery(`INSERT INTO "recovery_step_executions"`).
+		WillReturnRows(sqlmock.NewRows([]string{"retry_count", "attempt"}).AddRow(0, 1))
+	mock.ExpectExec(update+`"next_attempt_at"=\$5,"status"=\$6`).
+		WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), models.StepErrorRetryable, "provider down", sqlmock.AnyArg(), "retrying", sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(fmt.Sprintf(counter, "retry_count", "retry_count")).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	result, retryAt, err := service.executeStep(context.Background(), execution, step)
+	require.NoError(t, err)
+	require.NotNil(t, retryAt)
+	assert.False(t, result.Success)
+	assert.WithinDuration(t, time.Now().Add(30*time.Second), *retryAt, 10*time.Second)
+
+	// The second and last attempt fails too
+	due := time.Now().Add(-time.Second)
+	mock.ExpectQuery(attempts).
+		WithArgs(execution.ID, step.ID, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "status", "attempt", "next_attempt_at"}).
+			AddRow(uuid.New(), "retrying", 1, due))
+	mock.ExpectQuery(`INSERT INTO "recovery_step_executions"`).
+		WillReturnRows(sqlmock.NewRows([]string{"retry_count", "attempt"}).AddRow(1, 2))
+	mock.ExpectExec(update+`"status"=\$5`).
+		WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), models.StepErrorRetryable, "step failed after 2 attempts: provider down", "exhausted", sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(fmt.Sprintf(counter, "failed_steps", "failed_steps")).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(fmt.Sprintf(counter, "completed_steps", "completed_steps")).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	result, retryAt, err = service.executeStep(context.Background(), execution, step)
+	assert.EqualError(t, err, "step failed after 2 attempts: provider down")
+	assert.Nil(t, retryAt)
+	assert.Equal(t, models.StepOutcomeFailure, stepOutcome(result, err))
+	assert.Equal(t, 2, executor.calls)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestExecuteStepWaitsOutBackoffAfterResume(t *testing.T) {
+	db, mock := mockDB(t)
+	executor := &flakyExecutor{}
+	service := &RecoveryOrchestrationService{
+		db:            db,
+		tracer:        otel.Tracer("test"),
+		stepExecutors: map[string]StepExecutor{"send_email": executor},
+	}
+	execution := &WorkflowExecution{ID: uuid.New()}
+	step := &models.RecoveryWorkflowStep{ID: uuid.New(), StepType: "send_email"}
+
+	next := time.Now().Add(time.Hour)
+	mock.ExpectQuery(`SELECT \* FROM "recovery_step_executions"`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "status", "attempt", "next_attempt_at"}).
+			AddRow(uuid.New(), "retrying", 1, next))
+
+	_, retryAt, err := service.executeStep(context.Background(), execution, step)
+	require.NoError(t, err)
+	require.NotNil(t, retryAt)
+	assert.WithinDuration(t, next, *retryAt, time.Second)
+	assert.Zero(t, executor.calls, "the next attempt is not due yet")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetStepAttempts(t *testing.T) {
+	db, mock := mockDB(t)
+	service := &RecoveryOrchestrationService{db: db}
+	executionID, companyID, stepID := uuid.New(), uuid.New(), uuid.New()
+
+	mock.ExpectQuery(`SELECT count\(\*\) FROM "recovery_workflow_executions" WHERE id = \$1 AND company_id = \$2`).
+		WithArgs(executionID, companyID).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	mock.ExpectQuery(`SELECT \* FROM "recovery_step_executions" WHERE workflow_execution_id = \$1 ORDER BY started_at ASC, attempt ASC`).
+		WithArgs(executionID).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "step_id", "status", "attempt"}).
+			AddRow(uuid.New(), stepID, "retrying", 1).
+			AddRow(uuid.New(), stepID, "exhausted", 2))
+
+	attempts, err := service.GetStepAttempts(context.Background(), executionID, companyID)
+	require.NoError(t, err)
+	require.Len(t, attempts, 2)
+	assert.Equal(t, "exhausted", attempts[1].Status)
+
+	// Another company's execution is not found
+	mock.ExpectQuery(`SELECT count\(\*\) FROM "recovery_workflow_executions"`).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	_, err = service.GetStepAttempts(context.Background(), executionID, uuid.New())
+	assert.ErrorIs(t, err, ErrWorkflowExecutionNotFound)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
@@ -11,6 +11,7 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/go-redis/redis/v8"
+	"github.com/google/uuid"
 	"github.com/stripe/stripe-go/v74"
 	"github.com/stripe/stripe-go/v74/webhook"
 	"gorm.io/gorm"
@@ -27,6 +28,10 @@ type WebhookService struct {
 	webhookSecret string
 }
 
+// errUnknownStripeAccount rejects events from Stripe accounts no company is
+// linked to, whose failures no company's rules could run on
+var errUnknownStripeAccount = errors.New("no company is linked to the Stripe account")
+
 func NewWebhookService(db *gorm.DB, rc *redis.Client, ruleEngine rules.RuleEngine, webhookSecret string) *WebhookService {
 	// Ensure DLQ table exists
 	_ = db.AutoMigrate(&models.DeadLetterEntry{})
@@ -92,9 +97,15 @@ func (s *WebhookService) processEvent(ctx context.Context, event *stripe.Event,
 			return err
 		}
 
+		companyID, err := s.companyForStripeAccount(ctx, event.Account)
+		if err != nil {
+			return err
+		}
+
 		// Use db transaction for atomicity
 		return s.db.Transaction(func(tx *gorm.DB) error {
 			failure := models.PaymentFailureEvent{
+				CompanyID:      companyID,
 				EventID:        event.ID,
 				ProviderID:     "stripe",
 				EventType:      event.Type,
@@ -105,12 +116,49 @@ func (s *WebhookService) processEvent(ctx context.Context, event *stripe.Event,
 				RawEventData:   string(rawBody),
 				WebhookReceivedAt: time.Now(),
 			}
-			return tx.Create(&failure).Error
+			if err := tx.Create(&failure).Error; err != nil {
+				return err
+			}
+			return enqueueDetected(tx, &failure)
 		})
 	}
 	return nil
 }
 
+// companyForStripeAccount returns the ID of the company linked to the Stripe
+// account an event came from
+func (s *WebhookService) companyForStripeAccount(ctx context.Context, account string) (string, error) {
+	if account == "" {
+		return "", fmt.Errorf("%w: event has no account", errUnknownStripeAccount)
+	}
+	var company models.Company
+	if err := s.db.WithContext(ctx).Select("id").
+		Where("stripe_account_id = ?", account).
+		Limit(1).
+		Find(&company).Error; err != nil {
+		return "", fmt.Errorf("failed to resolve company for Stripe account %s: %w", account, err)
+	}
+	if company.ID == uuid.Nil {
+		return "", fmt.Errorf("%w %s", errUnknownStripeAccount, account)
+	}
+	return company.ID.String(), nil
+}
+
+// enqueueDetected writes the payment.failure.detected event for a failure to
+// the outbox in the transaction that records it. The outbox relay publishes it
+// to the worker's event processor, so a failure is never recorded without its
+// rules running, even when the event bus is down.
+func enqueueDetected(tx *gorm.DB, failure *models.PaymentFailureEvent) error {
+	_, err := EnqueueOutboxEvent(tx, failure.CompanyID, "payment.failure.detected", map[string]interface{}{
+		"event_type":      "payment.failure.detected",
+		"provider":        failure.ProviderID,
+		"company_id":      failure.CompanyID,
+		"payment_failure": paymentFailureFromEvent(failure),
+		"timestamp":       time.Now(),
+	})
+	return err
+}
+
 func (s *WebhookService) logToDLQ(eventID string, payload []byte, err error) {
 	entry := models.DeadLetterEntry{
 		EventID:   eventID,
@@ -0,0 +1,82 @@
+package services
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stripe/stripe-go/v74"
+
+	"github.com/sambitmohanty1/payment-watchdog/api/internal/models"
+)
+
+// payloadCompany matches an outbox payload whose event and payment failure
+// both belong to the company
+type payloadCompany string
+
+func (c payloadCompany) Match(v driver.Value) bool {
+	var event struct {
+		CompanyID      string `json:"company_id"`
+		PaymentFailure struct {
+			CompanyID string `json:"company_id"`
+		} `json:"payment_failure"`
+	}
+	payload, ok := v.(string)
+	if !ok || json.Unmarshal([]byte(payload), &event) != nil {
+		return false
+	}
+	return event.CompanyID == string(c) && event.PaymentFailure.CompanyID == string(c)
+}
+
+func paymentFailedEvent(account string) *stripe.Event {
+	return &stripe.Event{
+		ID:      "evt_1",
+		Type:    "payment_intent.payment_failed",
+		Account: account,
+		Data:    &stripe.EventData{Raw: json.RawMessage(`{"id":"pi_1","amount":4250,"currency":"aud"}`)},
+	}
+}
+
+func TestWebhookRecordsFailureWithOutboxEvent(t *testing.T) {
+	db, mock := mockDB(t)
+	companyID := uuid.New()
+	service := &WebhookService{db: db}
+
+	mock.ExpectQuery(`SELECT "id" FROM "companies" WHERE stripe_account_id = \$1 LIMIT \$2`).
+		WithArgs("acct_1", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(companyID))
+	// The failure and its event commit together
+	mock.ExpectBegin()
+	mock.ExpectQuery(`INSERT INTO "payment_failure_events"`).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(uuid.New()))
+	mock.ExpectQuery(`INSERT INTO "outbox_events"`).
+		WithArgs(companyID.String(), "payment.failure.detected", payloadCompany(companyID.String()),
+			models.OutboxEventPending, sqlmock.AnyArg(), 0, "", nil, sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(uuid.New()))
+	mock.ExpectCommit()
+
+	require.NoError(t, service.processEvent(context.Background(), paymentFailedEvent("acct_1"), []byte(`{}`)))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestWebhookRejectsEventsWithoutCompany(t *testing.T) {
+	db, mock := mockDB(t)
+	service := &WebhookService{db: db}
+
+	mock.ExpectQuery(`SELECT "id" FROM "companies" WHERE stripe_account_id = \$1 LIMIT \$2`).
+		WithArgs("acct_unknown", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	err := service.processEvent(context.Background(), paymentFailedEvent("acct_unknown"), []byte(`{}`))
+	assert.True(t, errors.Is(err, errUnknownStripeAccount))
+
+	err = service.processEvent(context.Background(), paymentFailedEvent(""), []byte(`{}`))
+	assert.True(t, errors.Is(err, errUnknownStripeAccount))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,368 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"gorm.io/datatypes"
+
+	"github.com/sambitmohanty1/payment-watchdog/api/internal/models"
+)
+
+const (
+	// executionLeaseTTL is how long a worker owns a running execution without
+	// renewing it. Leases are renewed by heartbeats and around every step; once
+	// one expires, any worker may claim the execution and resume it.
+	executionLeaseTTL = 2 * time.Minute
+	// refireBatch caps how many stranded timers one pass refires
+	refireBatch = 50
+)
+
+// errExecutionLeaseLost means another instance has taken over the execution.
+// The run stops without writing anything further.
+var errExecutionLeaseLost = errors.New("execution lease lost")
+
+// executionCheckpoint is the progress of an execution, written after each round
+// of steps so any instance can continue it from where it stopped
+type executionCheckpoint struct {
+	Outcomes    map[string]string          `json:"outcomes,omitempty"`
+	Waits       map[string]*checkpointWait `json:"waits,omitempty"`
+	StepResults map[string]*StepResult     `json:"step_results,omitempty"`
+	Variables   map[string]interface{}     `json:"variables,omitempty"`
+
+	// Checkpoints written before workflow graphs record the step index the
+	// execution continues with and the one timer it waited on
+	NextStepIndex int             `json:"next_step_index,omitempty"`
+	Wait          *checkpointWait `json:"wait,omitempty"`
+}
+
+// checkpointWait is a timer a branch of a suspended execution is parked on
+type checkpointWait struct {
+	StepID uuid.UUID `json:"step_id"`
+	Kind   string    `json:"kind"`
+	Until  time.Time `json:"until"`
+}
+
+// newInstanceID identifies this process as a lease owner
+func newInstanceID() string {
+	host, err := os.Hostname()
+	if err != nil {
+		host = "unknown"
+	}
+	return fmt.Sprintf("%s-%d-%s", host, os.Getpid(), uuid.New().String()[:8])
+}
+
+// stepKey is the idempotency key of step within the execution. The step
+// execution record, and any job, message or action the step creates, carry it
+// so a resumed step never repeats a side effect.
+func (e *WorkflowExecution) stepKey(stepID uuid.UUID) string {
+	return e.ID.String() + ":" + stepID.String()
+}
+
+// recordStepResult keeps a finished step's result for the checkpoint
+func (e *WorkflowExecution) recordStepResult(stepID uuid.UUID, result *StepResult) {
+	if result == nil {
+		return
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.stepResults == nil {
+		e.stepResults = make(map[string]*StepResult)
+	}
+	e.stepResults[stepID.String()] = result
+}
+
+// checkpoint captures the execution's progress. The payment failure and
+// workflow are reloaded on resume, so only the remaining context is kept.
+func (e *WorkflowExecution) checkpoint() executionCheckpoint {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	cp := executionCheckpoint{}
+	if len(e.outcomes) > 0 {
+		cp.Outcomes = make(map[string]string, len(e.outcomes))
+		for stepID, outcome := range e.outcomes {
+			cp.Outcomes[stepID] = outcome
+		}
+	}
+	if len(e.waits) > 0 {
+		cp.Waits = make(map[string]*checkpointWait, len(e.waits))
+		for stepID, wait := range e.waits {
+			cp.Waits[stepID] = wait
+		}
+	}
+	for key, value := range e.Context {
+		if key == "payment_failure" || key == "workflow" {
+			continue
+		}
+		if cp.Variables == nil {
+			cp.Variables = make(map[string]interface{})
+		}
+		cp.Variables[key] = value
+	}
+	if len(e.stepResults) > 0 {
+		cp.StepResults = make(map[string]*StepResult, len(e.stepResults))
+		for stepID, result := range e.stepResults {
+			cp.StepResults[stepID] = result
+		}
+	}
+	return cp
+}
+
+// decodeCheckpoint returns nil for executions that have not checkpointed yet
+func decodeCheckpoint(data datatypes.JSON) (*executionCheckpoint, error) {
+	if len(data) == 0 || string(data) == "null" {
+		return nil, nil
+	}
+	var cp executionCheckpoint
+	if err := json.Unmarshal(data, &cp); err != nil {
+		return nil, fmt.Errorf("failed to decode execution checkpoint: %w", err)
+	}
+	return &cp, nil
+}
+
+// parkedOn reports whether the checkpoint has a branch parked on timer
+func (cp *executionCheckpoint) parkedOn(timer *models.WorkflowTimer) bool {
+	wait := cp.Waits[timer.StepID.String()]
+	if wait == nil && cp.Wait != nil && cp.Wait.StepID == timer.StepID {
+		wait = cp.Wait
+	}
+	return wait != nil && wait.Kind == timer.Kind
+}
+
+// restoreExecution rebuilds an in-memory execution from its record and
+// checkpoint. Parked branches whose timers are due are released when the
+// execution runs.
+func restoreExecution(record *models.RecoveryWorkflowExecution, cp *executionCheckpoint) *WorkflowExecution {
+	execution := &WorkflowExecution{
+		ID:               record.ID,
+		WorkflowID:       record.WorkflowID,
+		PaymentFailureID: record.PaymentFailureID,
+		CompanyID:        record.CompanyID,
+		Status:           "running",
+		CurrentStepIndex: cp.NextStepIndex,
+		Context: map[string]interface{}{
+			"payment_failure": &record.PaymentFailure,
+			"workflow":        &record.Workflow,
+		},
+		StartedAt:   record.StartedAt,
+		stepResults: cp.StepResults,
+		outcomes:    cp.Outcomes,
+		waits:       cp.Waits,
+		path:        record.Path,
+	}
+	if execution.outcomes == nil {
+		execution.outcomes = make(map[string]string)
+	}
+	if execution.waits == nil {
+		execution.waits = make(map[string]*checkpointWait)
+	}
+	if record.LeaseOwner != nil {
+		execution.leaseOwner = *record.LeaseOwner
+	}
+	for key, value := range cp.Variables {
+		execution.Context[key] = value
+	}
+	if cp.Wait != nil {
+		execution.waits[cp.Wait.StepID.String()] = cp.Wait
+	}
+	return execution
+}
+
+// newLease grants a fresh lease from now. Each claim gets its own owner token,
+// so a run that lost its lease can never write over the run that took over,
+// even on the same instance.
+func (r *RecoveryOrchestrationService) newLease(now time.Time) map[string]interface{} {
+	return map[string]interface{}{
+		"lease_owner":      r.instanceID + "/" + uuid.New().String()[:8],
+		"lease_expires_at": now.Add(executionLeaseTTL),
+		"heartbeat_at":     now,
+	}
+}
+
+// updateLeased applies updates to the execution and renews its lease, provided
+// the run still holds it
+func (r *RecoveryOrchestrationService) updateLeased(ctx context.Context, execution *WorkflowExecution, updates map[string]interface{}) error {
+	now := time.Now()
+	if _, releasing := updates["lease_expires_at"]; !releasing {
+		updates["lease_expires_at"] = now.Add(executionLeaseTTL)
+		updates["heartbeat_at"] = now
+	}
+	result := r.db.WithContext(ctx).Model(&models.RecoveryWorkflowExecution{}).
+		Where("id = ? AND lease_owner = ?", execution.ID, execution.leaseOwner).
+		Updates(updates)
+	if result.Error != nil {
+		return fmt.Errorf("failed to update execution: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return errExecutionLeaseLost
+	}
+	return nil
+}
+
+// checkpointUpdates are the column updates recording the execution's progress
+func (e *WorkflowExecution) checkpointUpdates() (map[string]interface{}, error) {
+	data, err := json.Marshal(e.checkpoint())
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode execution checkpoint: %w", err)
+	}
+	e.mu.RLock()
+	path, err := json.Marshal(e.path)
+	e.mu.RUnlock()
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode execution path: %w", err)
+	}
+	return map[string]interface{}{
+		"checkpoint":      datatypes.JSON(data),
+		"checkpointed_at": time.Now(),
+		"path":            datatypes.JSON(path),
+	}, nil
+}
+
+// saveCheckpoint records the execution's progress so far
+func (r *RecoveryOrchestrationService) saveCheckpoint(ctx context.Context, execution *WorkflowExecution) error {
+	updates, err := execution.checkpointUpdates()
+	if err != nil {
+		return err
+	}
+	return r.updateLeased(ctx, execution, updates)
+}
+
+// finishExecution records the execution's final status, path and the error it
+// failed with, and releases its lease
+func (r *RecoveryOrchestrationService) finishExecution(ctx context.Context, execution *WorkflowExecution) error {
+	execution.mu.RLock()
+	path, err := json.Marshal(execution.path)
+	execution.mu.RUnlock()
+	if err != nil {
+		return fmt.Errorf("failed to encode execution path: %w", err)
+	}
+	updates := map[string]interface{}{
+		"status":           execution.Status,
+		"completed_at":     time.Now(),
+		"path":             datatypes.JSON(path),
+		"lease_owner":      nil,
+		"lease_expires_at": nil,
+	}
+	if execution.lastError != "" {
+		updates["last_error"] = execution.lastError
+	}
+	return r.updateLeased(ctx, execution, updates)
+}
+
+// loadExecution loads an execution with the workflow steps and payment failure
+// needed to run it. The steps are those of the version the execution is pinned
+// to, whatever the workflow's current version.
+func (r *RecoveryOrchestrationService) loadExecution(ctx context.Context, executionID uuid.UUID) (*models.RecoveryWorkflowExecution, error) {
+	var record models.RecoveryWorkflowExecution
+	if err := r.db.WithContext(ctx).
+		Preload("Workflow").
+		Preload("PaymentFailure").
+		First(&record, "id = ?", executionID).Error; err != nil {
+		return nil, err
+	}
+	steps, err := versionSteps(r.db.WithContext(ctx), record.WorkflowID, record.WorkflowVersion)
+	if err != nil {
+		return nil, err
+	}
+	record.Workflow.Steps = steps
+	return &record, nil
+}
+
+// runClaimed runs an execution a worker has just leased from its checkpoint
+// until it finishes, suspends or loses its lease. One that stopped before its
+// first checkpoint starts over; the steps it finished replay from their
+// idempotency keys. One whose branches are all still parked suspends again.
+func (r *RecoveryOrchestrationService) runClaimed(ctx context.Context, executionID uuid.UUID) error {
+	record, err := r.loadExecution(ctx, executionID)
+	if err != nil {
+		return fmt.Errorf("failed to load execution: %w", err)
+	}
+	cp, err := decodeCheckpoint(record.Checkpoint)
+	if err != nil {
+		return err
+	}
+	if cp == nil {
+		cp = &executionCheckpoint{}
+	}
+
+	r.resolveCustomerSegments(ctx, &record.PaymentFailure)
+	execution := restoreExecution(record, cp)
+
+	execCtx, cancel := context.WithCancel(ctx)
+	execution.CancelFunc = cancel
+
+	r.mu.Lock()
+	r.activeExecutions[execution.ID] = execution
+	r.mu.Unlock()
+
+	r.executeWorkflow(execCtx, execution, &record.Workflow)
+	return nil
+}
+
+// refireTimers hands timers that fired more than a lease ago to their waiting
+// execution again, covering an instance that stopped between firing a timer and
+// resuming the execution
+func (r *RecoveryOrchestrationService) refireTimers(ctx context.Context) int {
+	var timers []models.WorkflowTimer
+	if err := r.db.WithContext(ctx).
+		Joins("JOIN recovery_workflow_executions ON recovery_workflow_executions.id = workflow_timers.workflow_execution_id").
+		Where("workflow_timers.status = ? AND workflow_timers.fired_at < ?", models.WorkflowTimerFired, time.Now().Add(-executionLeaseTTL)).
+		Where("recovery_workflow_executions.status = ?", "waiting").
+		Where("NOT EXISTS (SELECT 1 FROM workflow_timers pending WHERE pending.workflow_execution_id = workflow_timers.workflow_execution_id AND pending.status = ?)", models.WorkflowTimerPending).
+		Limit(refireBatch).
+		Find(&timers).Error; err != nil {
+		r.logger.Error("Failed to find stranded workflow timers", zap.Error(err))
+		return 0
+	}
+
+	resumed := 0
+	for i := range timers {
+		if err := r.resumeFromTimer(ctx, &timers[i]); err != nil {
+			r.logger.Error("Failed to resume stranded workflow timer",
+				zap.String("timer_id", timers[i].ID.String()),
+				zap.Error(err))
+			continue
+		}
+		resumed++
+	}
+	return resumed
+}
+
+// replayedStepResult returns the outcome recorded for a step that already
+// finished, so resuming an execution does not run the step again
+func replayedStepResult(stepExecution *models.RecoveryStepExecution) (*StepResult, error) {
+	var result *StepResult
+	if len(stepExecution.Result) > 0 {
+		result = &StepResult{}
+		if err := json.Unmarshal(stepExecution.Result, result); err != nil {
+			return nil, fmt.Errorf("failed to decode step result: %w", err)
+		}
+	}
+	if stepExecution.Status == "failed" || stepExecution.Status == "exhausted" {
+		return result, errors.New(stepExecution.ErrorMessage)
+	}
+	return result, nil
+}
+
+// stepFinished reports whether a step execution status is terminal
+func stepFinished(status string) bool {
+	return status == "completed" || status == "failed" || status == "exhausted" || status == "skipped"
+}
+
+// recordRecoveryAction records the action a step took once; a resumed step gets
+// the action recorded the first time
+func (r *RecoveryOrchestrationService) recordRecoveryAction(ctx context.Context, action *models.RecoveryAction) error {
+	if action.IdempotencyKey == "" {
+		return r.db.WithContext(ctx).Create(action).Error
+	}
+	return r.db.WithContext(ctx).
+		Where(&models.RecoveryAction{IdempotencyKey: action.IdempotencyKey}).
+		FirstOrCreate(action).Error
+}
@@ -0,0 +1,119 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sambitmohanty1/payment-watchdog/api/internal/models"
+)
+
+func TestCheckpointRoundTrip(t *testing.T) {
+	waitStep, emailStep := uuid.New(), uuid.New()
+	until := time.Now().Add(time.Hour).UTC().Truncate(time.Second)
+
+	execution := &WorkflowExecution{
+		ID: uuid.New(),
+		Context: map[string]interface{}{
+			"payment_failure": &models.PaymentFailureEvent{},
+			"workflow":        &models.RecoveryWorkflow{},
+			"offer":           "discount",
+		},
+	}
+	execution.recordStepResult(emailStep, &StepResult{Success: true, ExternalID: "msg_1"})
+	execution.recordStepResult(uuid.New(), nil)
+	execution.recordOutcome(&models.RecoveryWorkflowStep{ID: emailStep, StepOrder: 1}, models.StepOutcomeSuccess, time.Now())
+	execution.park(&models.RecoveryWorkflowStep{ID: waitStep}, models.WorkflowTimerWait, until)
+
+	data, err := json.Marshal(execution.checkpoint())
+	require.NoError(t, err)
+
+	cp, err := decodeCheckpoint(data)
+	require.NoError(t, err)
+	require.NotNil(t, cp)
+	assert.Equal(t, map[string]string{emailStep.String(): models.StepOutcomeSuccess}, cp.Outcomes)
+	assert.Equal(t, map[string]interface{}{"offer": "discount"}, cp.Variables, "the payment failure and workflow are reloaded, not checkpointed")
+	require.Len(t, cp.StepResults, 1)
+	assert.True(t, cp.parkedOn(&models.WorkflowTimer{StepID: waitStep, Kind: models.WorkflowTimerWait}))
+	assert.False(t, cp.parkedOn(&models.WorkflowTimer{StepID: waitStep, Kind: models.WorkflowTimerStepDelay}))
+
+	record := &models.RecoveryWorkflowExecution{ID: execution.ID, CompanyID: uuid.New(), Path: execution.path}
+	restored := restoreExecution(record, cp)
+	assert.Equal(t, "discount", restored.Context["offer"])
+	assert.Same(t, &record.PaymentFailure, restored.Context["payment_failure"])
+	assert.Equal(t, "msg_1", restored.stepResults[emailStep.String()].ExternalID)
+	assert.Equal(t, until, restored.waits[waitStep.String()].Until)
+	require.Len(t, restored.path, 1)
+	assert.Equal(t, "step_1", restored.path[0].StepKey)
+
+	empty, err := decodeCheckpoint(nil)
+	require.NoError(t, err)
+	assert.Nil(t, empty)
+}
+
+func TestStepKeyIsStablePerExecutionAndStep(t *testing.T) {
+	step := uuid.New()
+	execution := &WorkflowExecution{ID: uuid.New()}
+	assert.Equal(t, execution.stepKey(step), (&WorkflowExecution{ID: execution.ID}).stepKey(step))
+	assert.NotEqual(t, execution.stepKey(step), (&WorkflowExecution{ID: uuid.New()}).stepKey(step))
+	assert.NotEqual(t, execution.stepKey(step), execution.stepKey(uuid.New()))
+}
+
+func TestReplayedStepResult(t *testing.T) {
+	stored, _ := json.Marshal(&StepResult{Success: true, ExternalID: "job_1"})
+
+	result, err := replayedStepResult(&models.RecoveryStepExecution{Status: "completed", Result: stored})
+	require.NoError(t, err)
+	assert.Equal(t, "job_1", result.ExternalID)
+
+	_, err = replayedStepResult(&models.RecoveryStepExecution{Status: "failed", ErrorMessage: "provider down"})
+	assert.EqualError(t, err, "provider down")
+
+	assert.True(t, stepFinished("skipped"))
+	assert.False(t, stepFinished("running"), "a step interrupted mid-run runs again")
+	assert.False(t, stepFinished("deferred"))
+}
+
+func TestSaveCheckpointStopsWhenLeaseLost(t *testing.T) {
+	db, mock := mockDB(t)
+	service := &RecoveryOrchestrationService{db: db, instanceID: "worker-a"}
+	execution := &WorkflowExecution{ID: uuid.New(), leaseOwner: "worker-a/1f2e3d4c"}
+
+	update := `UPDATE "recovery_workflow_executions" SET "checkpoint"=\$1,"checkpointed_at"=\$2,"heartbeat_at"=\$3,"lease_expires_at"=\$4,"path"=\$5,"updated_at"=\$6 WHERE id = \$7 AND lease_owner = \$8`
+	mock.ExpectExec(update).
+		WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), execution.ID, "worker-a/1f2e3d4c").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	// Another worker took the execution over
+	mock.ExpectExec(update).
+		WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), execution.ID, "worker-a/1f2e3d4c").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	require.NoError(t, service.saveCheckpoint(context.Background(), execution))
+	assert.ErrorIs(t, service.saveCheckpoint(context.Background(), execution), errExecutionLeaseLost)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestLegacyTimerCheckpoint(t *testing.T) {
+	first, wait := uuid.New(), uuid.New()
+	workflow := &models.RecoveryWorkflow{Steps: []models.RecoveryWorkflowStep{
+		{ID: wait, StepOrder: 2, IsActive: true},
+		{ID: first, StepOrder: 1, IsActive: true},
+	}}
+
+	cp, err := legacyTimerCheckpoint(workflow, &models.WorkflowTimer{StepID: wait, Kind: models.WorkflowTimerWait})
+	require.NoError(t, err)
+	assert.Equal(t, 2, cp.NextStepIndex, "a served wait continues after its step")
+
+	cp, err = legacyTimerCheckpoint(workflow, &models.WorkflowTimer{StepID: first, Kind: models.WorkflowTimerStepDelay})
+	require.NoError(t, err)
+	assert.Equal(t, 0, cp.NextStepIndex, "a served delay runs its step")
+
+	_, err = legacyTimerCheckpoint(workflow, &models.WorkflowTimer{StepID: uuid.New(), Kind: models.WorkflowTimerWait})
+	assert.Error(t, err)
+}
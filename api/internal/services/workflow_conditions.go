@@ -0,0 +1,206 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/sambitmohanty1/payment-watchdog/api/internal/expr"
+	"github.com/sambitmohanty1/payment-watchdog/api/internal/models"
+)
+
+// ErrInvalidWorkflowCondition is returned when a workflow's trigger or conditional
+// step conditions do not type check
+var ErrInvalidWorkflowCondition = errors.New("invalid workflow condition")
+
+// workflowConditionEnv is the expression environment shared by trigger
+// conditions and conditional steps
+var workflowConditionEnv = expr.PaymentFailureEnv()
+
+// conditionFieldPaths maps the legacy field names of TriggerCondition onto the
+// expression activation
+var conditionFieldPaths = map[string][]string{
+	"amount":            {"amount"},
+	"amount_cents":      {"amount_cents"},
+	"currency":          {"currency"},
+	"failure_reason":    {"failure_reason"},
+	"provider":          {"provider"},
+	"customer_email":    {"customer", "email"},
+	"customer_segments": {"customer", "segments"},
+	"retry_count":       {"retry_count"},
+	"days_overdue":      {"days_overdue"},
+}
+
+// conditionOperators lists the legacy comparison operators and their aliases
+var conditionOperators = map[string]string{
+	"eq": "eq", "equals": "eq",
+	"ne": "ne", "not_equals": "ne",
+	"gt": "gt", "greater_than": "gt",
+	"gte": "gte", "greater_than_or_equal": "gte",
+	"lt": "lt", "less_than": "lt",
+	"lte": "lte", "less_than_or_equal": "lte",
+	"contains": "contains",
+	"in":       "in",
+}
+
+// conditionSet is the condition part of trigger conditions and conditional step
+// configs: field comparisons combined with Logic, and an optional expression
+// that must also hold
+type conditionSet struct {
+	Conditions []TriggerCondition `json:"conditions"`
+	Logic      string             `json:"logic"`
+	Expression string             `json:"expression,omitempty"`
+}
+
+// validate type checks the expression and rejects unknown fields and operators
+func (s conditionSet) validate() error {
+	for i, condition := range s.Conditions {
+		if _, ok := conditionFieldPaths[condition.Field]; !ok {
+			return fmt.Errorf("conditions[%d]: unknown field %q", i, condition.Field)
+		}
+		if _, ok := conditionOperators[condition.Operator]; !ok {
+			return fmt.Errorf("conditions[%d]: unknown operator %q", i, condition.Operator)
+		}
+	}
+	if s.Expression != "" {
+		if _, err := expr.CompileBool(s.Expression, workflowConditionEnv); err != nil {
+			return fmt.Errorf("expression: %w", err)
+		}
+	}
+	return nil
+}
+
+// evaluate reports whether paymentFailure satisfies the set at time now. An
+// empty set always matches.
+func (s conditionSet) evaluate(paymentFailure *models.PaymentFailureEvent, now time.Time) (bool, error) {
+	activation := expr.PaymentFailureActivation(paymentFailure, now)
+
+	if len(s.Conditions) > 0 {
+		matched := s.Logic != "OR"
+		for _, condition := range s.Conditions {
+			result := evaluateFieldCondition(activation, condition)
+			if s.Logic == "OR" && result {
+				matched = true
+				break
+			}
+			if s.Logic != "OR" && !result {
+				matched = false
+				break
+			}
+		}
+		if !matched {
+			return false, nil
+		}
+	}
+
+	if s.Expression == "" {
+		return true, nil
+	}
+	program, err := expr.CompileBool(s.Expression, workflowConditionEnv)
+	if err != nil {
+		return false, err
+	}
+	return program.EvalBool(activation)
+}
+
+// evaluateFieldCondition compares a legacy field condition using the expression
+// language's value semantics, so a JSON 100 equals an integer 100
+func evaluateFieldCondition(activation map[string]interface{}, condition TriggerCondition) bool {
+	path, ok := conditionFieldPaths[condition.Field]
+	if !ok {
+		return false
+	}
+	var fieldValue interface{} = activation
+	for _, key := range path {
+		m, _ := fieldValue.(map[string]interface{})
+		fieldValue = m[key]
+	}
+
+	switch conditionOperators[condition.Operator] {
+	case "eq":
+		return expr.Equal(fieldValue, condition.Value)
+	case "ne":
+		return !expr.Equal(fieldValue, condition.Value)
+	case "gt", "gte", "lt", "lte":
+		cmp, ok := expr.Compare(fieldValue, condition.Value)
+		if !ok {
+			return false
+		}
+		switch conditionOperators[condition.Operator] {
+		case "gt":
+			return cmp > 0
+		case "gte":
+			return cmp >= 0
+		case "lt":
+			return cmp < 0
+		}
+		return cmp <= 0
+	case "contains":
+		if list, ok := fieldValue.([]interface{}); ok {
+			for _, v := range list {
+				if expr.Equal(v, condition.Value) {
+					return true
+				}
+			}
+			return false
+		}
+		str, ok1 := fieldValue.(string)
+		substr, ok2 := condition.Value.(string)
+		return ok1 && ok2 && strings.Contains(str, substr)
+	case "in":
+		values, _ := condition.Value.([]interface{})
+		for _, v := range values {
+			if expr.Equal(fieldValue, v) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// ValidateWorkflowConditions type checks a workflow's trigger conditions and the
+// conditions of its conditional steps so that mistakes surface when the
+// workflow is saved rather than when it first runs
+func ValidateWorkflowConditions(workflow *models.RecoveryWorkflow) error {
+	if err := validateTriggerConditions(workflow.TriggerConditions); err != nil {
+		return err
+	}
+	for i, step := range workflow.Steps {
+		if step.StepType != "conditional" || len(step.Config) == 0 {
+			continue
+		}
+		var config conditionSet
+		if err := json.Unmarshal(step.Config, &config); err != nil {
+			return fmt.Errorf("%w: steps[%d].config: %v", ErrInvalidWorkflowCondition, i, err)
+		}
+		if err := config.validate(); err != nil {
+			return fmt.Errorf("%w: steps[%d].config.%v", ErrInvalidWorkflowCondition, i, err)
+		}
+	}
+	return nil
+}
+
+// ValidateConditions type checks a condition set on its own, so a trigger or
+// conditional step condition can be checked while it is being written
+func ValidateConditions(conditions WorkflowTriggerConditions) error {
+	if err := conditions.conditionSet().validate(); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidWorkflowCondition, err)
+	}
+	return nil
+}
+
+func validateTriggerConditions(conditionsJSON []byte) error {
+	if len(conditionsJSON) == 0 || string(conditionsJSON) == "null" {
+		return nil
+	}
+	var conditions WorkflowTriggerConditions
+	if err := json.Unmarshal(conditionsJSON, &conditions); err != nil {
+		return fmt.Errorf("%w: trigger_conditions: %v", ErrInvalidWorkflowCondition, err)
+	}
+	if err := conditions.conditionSet().validate(); err != nil {
+		return fmt.Errorf("%w: trigger_conditions.%v", ErrInvalidWorkflowCondition, err)
+	}
+	return nil
+}
@@ -0,0 +1,118 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sambitmohanty1/payment-watchdog/api/internal/models"
+)
+
+func TestTriggerConditionsCompareDecodedNumbers(t *testing.T) {
+	// Values decoded from JSON are float64 while event fields are integers; the
+	// comparison must not depend on the Go type
+	var conditions WorkflowTriggerConditions
+	require.NoError(t, json.Unmarshal([]byte(`{
+		"conditions": [
+			{"field": "amount", "operator": "eq", "value": 100},
+			{"field": "retry_count", "operator": "in", "value": [1, 2]}
+		],
+		"logic": "AND"
+	}`), &conditions))
+
+	event := &models.PaymentFailureEvent{AmountCents: 10000, RetryCount: 2, Currency: "AUD"}
+	matched, err := conditions.conditionSet().evaluate(event, time.Now())
+	require.NoError(t, err)
+	assert.True(t, matched)
+
+	event.RetryCount = 3
+	matched, err = conditions.conditionSet().evaluate(event, time.Now())
+	require.NoError(t, err)
+	assert.False(t, matched)
+}
+
+func TestTriggerConditionsExpression(t *testing.T) {
+	conditions := WorkflowTriggerConditions{
+		Conditions: []TriggerCondition{{Field: "currency", Operator: "eq", Value: "AUD"}},
+		Expression: `amount >= 50 && customer.email.endsWith("@example.com")`,
+	}
+	event := &models.PaymentFailureEvent{AmountCents: 7500, Currency: "AUD", CustomerEmail: "ap@example.com"}
+
+	matched, err := conditions.conditionSet().evaluate(event, time.Now())
+	require.NoError(t, err)
+	assert.True(t, matched)
+
+	event.Currency = "USD"
+	matched, err = conditions.conditionSet().evaluate(event, time.Now())
+	require.NoError(t, err)
+	assert.False(t, matched, "field conditions and expression must both hold")
+}
+
+func TestTriggerConditionsOnCustomerSegments(t *testing.T) {
+	legacy := WorkflowTriggerConditions{
+		Conditions: []TriggerCondition{{Field: "customer_segments", Operator: "contains", Value: "vip"}},
+	}
+	expression := WorkflowTriggerConditions{Expression: `"hardship" in customer.segments`}
+	event := &models.PaymentFailureEvent{CustomerSegments: []string{"recurring", "vip"}}
+
+	matched, err := legacy.conditionSet().evaluate(event, time.Now())
+	require.NoError(t, err)
+	assert.True(t, matched)
+	matched, err = expression.conditionSet().evaluate(event, time.Now())
+	require.NoError(t, err)
+	assert.False(t, matched)
+
+	event.CustomerSegments = []string{"hardship"}
+	matched, err = legacy.conditionSet().evaluate(event, time.Now())
+	require.NoError(t, err)
+	assert.False(t, matched)
+	matched, err = expression.conditionSet().evaluate(event, time.Now())
+	require.NoError(t, err)
+	assert.True(t, matched)
+}
+
+func TestValidateWorkflowConditions(t *testing.T) {
+	valid := &models.RecoveryWorkflow{
+		TriggerConditions: []byte(`{"conditions":[{"field":"amount","operator":"gt","value":10}],"expression":"failure_reason in ['card_declined']"}`),
+		Steps: []models.RecoveryWorkflowStep{
+			{StepType: "conditional", Config: []byte(`{"expression":"retry_count < 3","on_true":"continue","on_false":"stop"}`)},
+			{StepType: "wait", Config: []byte(`{"expression":"not checked for other step types +"}`)},
+		},
+	}
+	assert.NoError(t, ValidateWorkflowConditions(valid))
+
+	tests := map[string]*models.RecoveryWorkflow{
+		"trigger type error":   {TriggerConditions: []byte(`{"expression":"amount == 'ten'"}`)},
+		"trigger syntax error": {TriggerConditions: []byte(`{"expression":"amount >"}`)},
+		"unknown field":        {TriggerConditions: []byte(`{"conditions":[{"field":"colour","operator":"eq","value":"red"}]}`)},
+		"unknown operator":     {TriggerConditions: []byte(`{"conditions":[{"field":"amount","operator":"approx","value":1}]}`)},
+		"conditional step": {Steps: []models.RecoveryWorkflowStep{
+			{StepType: "conditional", Config: []byte(`{"expression":"amount + 1"}`)},
+		}},
+	}
+	for name, workflow := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := ValidateWorkflowConditions(workflow)
+			assert.True(t, errors.Is(err, ErrInvalidWorkflowCondition), "got %v", err)
+		})
+	}
+}
+
+func TestValidateConditions(t *testing.T) {
+	assert.NoError(t, ValidateConditions(WorkflowTriggerConditions{
+		Conditions: []TriggerCondition{{Field: "currency", Operator: "eq", Value: "AUD"}},
+		Expression: `amount > 500 && customer.email.endsWith("@example.com")`,
+	}))
+
+	err := ValidateConditions(WorkflowTriggerConditions{Expression: `amount > "500"`})
+	assert.True(t, errors.Is(err, ErrInvalidWorkflowCondition))
+
+	err = ValidateConditions(WorkflowTriggerConditions{
+		Conditions: []TriggerCondition{{Field: "plan", Operator: "eq", Value: "gold"}},
+	})
+	assert.True(t, errors.Is(err, ErrInvalidWorkflowCondition))
+}
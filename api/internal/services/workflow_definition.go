@@ -0,0 +1,820 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"math"
+	"reflect"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gopkg.in/yaml.v3"
+	"gorm.io/gorm"
+
+	"github.com/sambitmohanty1/payment-watchdog/api/internal/calendar"
+	"github.com/sambitmohanty1/payment-watchdog/api/internal/models"
+)
+
+// ErrInvalidWorkflowDefinition is returned when a workflow definition fails validation
+var ErrInvalidWorkflowDefinition = errors.New("invalid workflow definition")
+
+// Step config field types
+const (
+	ConfigFieldString   = "string"
+	ConfigFieldNumber   = "number"
+	ConfigFieldInteger  = "integer"
+	ConfigFieldBool     = "bool"
+	ConfigFieldObject   = "object"
+	ConfigFieldList     = "list"
+	ConfigFieldSchedule = "schedule" // schedule in the company calendar, e.g. "next_business_day 10:00"
+)
+
+// StepConfigField describes one field of a step config
+type StepConfigField struct {
+	Type        string   `json:"type"`
+	Required    bool     `json:"required,omitempty"`
+	Enum        []string `json:"enum,omitempty"`
+	Template    string   `json:"template,omitempty"` // type of the template the field refers to by name or ID
+	Description string   `json:"description,omitempty"`
+}
+
+// StepConfigSchema lists the config fields a step type accepts
+type StepConfigSchema map[string]StepConfigField
+
+// StepConfigDescriber is implemented by step executors that declare the config
+// their steps accept, so definitions are checked before they run. Configs of
+// other step types are not checked.
+type StepConfigDescriber interface {
+	ConfigSchema() StepConfigSchema
+}
+
+// WorkflowDefinition is a workflow as kept in source control: its settings,
+// trigger conditions and the steps of one version, without IDs, so it can be
+// promoted between environments. Templates are referenced by name.
+type WorkflowDefinition struct {
+	Name              string                   `json:"name" yaml:"name"`
+	Description       string                   `json:"description,omitempty" yaml:"description,omitempty"`
+	Priority          int                      `json:"priority" yaml:"priority"`
+	Shadow            bool                     `json:"shadow,omitempty" yaml:"shadow,omitempty"`
+	TriggerConditions map[string]interface{}   `json:"trigger_conditions,omitempty" yaml:"trigger_conditions,omitempty"`
+	Steps             []WorkflowStepDefinition `json:"steps" yaml:"steps"`
+}
+
+// WorkflowStepDefinition is a step of a WorkflowDefinition. Steps without a
+// step_order are ordered as listed.
+type WorkflowStepDefinition struct {
+	Key            string                  `json:"key,omitempty" yaml:"key,omitempty"`
+	StepOrder      int                     `json:"step_order" yaml:"step_order"`
+	StepType       string                  `json:"step_type" yaml:"step_type"`
+	StepName       string                  `json:"step_name" yaml:"step_name"`
+	Description    string                  `json:"description,omitempty" yaml:"description,omitempty"`
+	Config         map[string]interface{}  `json:"config,omitempty" yaml:"config,omitempty"`
+	Conditions     interface{}             `json:"conditions,omitempty" yaml:"conditions,omitempty"`
+	DelayMinutes   int                     `json:"delay_minutes,omitempty" yaml:"delay_minutes,omitempty"`
+	IsParallel     bool                    `json:"is_parallel,omitempty" yaml:"is_parallel,omitempty"`
+	IsCritical     bool                    `json:"is_critical,omitempty" yaml:"is_critical,omitempty"`
+	Next           *models.StepTransitions `json:"next,omitempty" yaml:"next,omitempty"`
+	Join           string                  `json:"join,omitempty" yaml:"join,omitempty"`
+	TimeoutSeconds int                     `json:"timeout_seconds,omitempty" yaml:"timeout_seconds,omitempty"`
+	RetryPolicy    *models.StepRetryPolicy `json:"retry_policy,omitempty" yaml:"retry_policy,omitempty"`
+}
+
+// WorkflowDefinitionError is a problem found in a workflow definition, with the
+// line of the source it is on when known
+type WorkflowDefinitionError struct {
+	Line    int    `json:"line,omitempty"`
+	Column  int    `json:"column,omitempty"`
+	Path    string `json:"path,omitempty"`
+	Message string `json:"message"`
+}
+
+func (e WorkflowDefinitionError) Error() string {
+	message := e.Message
+	if e.Path != "" {
+		message = e.Path + ": " + message
+	}
+	if e.Line > 0 {
+		message = fmt.Sprintf("line %d: %s", e.Line, message)
+	}
+	return message
+}
+
+// WorkflowDefinitionErrors lists every problem found in a workflow definition
+type WorkflowDefinitionErrors []WorkflowDefinitionError
+
+func (e WorkflowDefinitionErrors) Error() string {
+	messages := make([]string, len(e))
+	for i, err := range e {
+		messages[i] = err.Error()
+	}
+	return fmt.Sprintf("%v: %s", ErrInvalidWorkflowDefinition, strings.Join(messages, "; "))
+}
+
+func (e WorkflowDefinitionErrors) Unwrap() error {
+	return ErrInvalidWorkflowDefinition
+}
+
+// ValidateWorkflowDefinition parses a workflow definition from JSON or YAML and
+// checks it as an import would: step types and configs against the executors'
+// schemas, the templates steps refer to, trigger and step conditions, retry
+// policies and the step graph. Problems are returned together as
+// WorkflowDefinitionErrors.
+func (r *RecoveryOrchestrationService) ValidateWorkflowDefinition(ctx context.Context, companyID uuid.UUID, source []byte, format string) (*WorkflowDefinition, error) {
+	def, doc, err := parseWorkflowDefinition(source, format)
+	if err != nil {
+		return nil, err
+	}
+	if def != nil {
+		if err := r.checkWorkflowDefinition(ctx, companyID, def, doc); err != nil {
+			return nil, err
+		}
+	}
+	if len(doc.errors) > 0 {
+		return nil, doc.errors
+	}
+	return def, nil
+}
+
+// ExportWorkflow renders the current version of a workflow as a definition in
+// JSON or YAML. Steps refer to templates by name, so the definition imports into
+// environments where the templates have other IDs.
+func (r *RecoveryOrchestrationService) ExportWorkflow(ctx context.Context, workflowID, companyID uuid.UUID, format string) ([]byte, error) {
+	var workflow models.RecoveryWorkflow
+	err := r.db.WithContext(ctx).
+		Where("id = ? AND company_id = ?", workflowID, companyID).
+		First(&workflow).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrWorkflowNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load workflow: %w", err)
+	}
+	steps, err := versionSteps(r.db.WithContext(ctx), workflow.ID, workflow.CurrentVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	var templates templateIndex
+	for _, step := range steps {
+		if bytes.Contains(step.Config, []byte(`"template_id"`)) {
+			if templates, err = r.companyTemplates(ctx, companyID); err != nil {
+				return nil, err
+			}
+			break
+		}
+	}
+
+	def, err := newWorkflowDefinition(&workflow, steps, templates)
+	if err != nil {
+		return nil, err
+	}
+	return def.Marshal(format)
+}
+
+// ImportWorkflow validates a definition and creates the workflow it describes.
+// When the company already has a workflow of that name, the definition is
+// published as its next version instead, so promoting a definition updates the
+// workflow in place and leaves running executions on their version. It reports
+// whether the workflow was created.
+func (r *RecoveryOrchestrationService) ImportWorkflow(ctx context.Context, companyID uuid.UUID, source []byte, format, importedBy string) (*models.RecoveryWorkflow, bool, error) {
+	ctx, span := r.tracer.Start(ctx, "import_workflow")
+	defer span.End()
+
+	def, err := r.ValidateWorkflowDefinition(ctx, companyID, source, format)
+	if err != nil {
+		span.RecordError(err)
+		return nil, false, err
+	}
+	workflow, err := def.workflow(companyID, importedBy)
+	if err != nil {
+		span.RecordError(err)
+		return nil, false, err
+	}
+
+	var existing models.RecoveryWorkflow
+	err = r.db.WithContext(ctx).
+		Where("company_id = ? AND name = ?", companyID, def.Name).
+		Order("created_at").
+		First(&existing).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		if err := r.CreateWorkflow(ctx, workflow); err != nil {
+			return nil, false, err
+		}
+		return workflow, true, nil
+	}
+	if err != nil {
+		span.RecordError(err)
+		return nil, false, fmt.Errorf("failed to look up workflow: %w", err)
+	}
+
+	err = r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.RecoveryWorkflow{}).
+			Where("id = ?", existing.ID).
+			Updates(map[string]interface{}{
+				"description": workflow.Description,
+				"priority":    workflow.Priority,
+				"shadow":      workflow.Shadow,
+				"updated_at":  time.Now(),
+			}).Error; err != nil {
+			return fmt.Errorf("failed to update workflow: %w", err)
+		}
+		_, err := publishVersion(tx, existing.ID, companyID, WorkflowVersionChange{
+			Steps:             workflow.Steps,
+			TriggerConditions: workflow.TriggerConditions,
+			ChangeNote:        "Imported",
+			CreatedBy:         importedBy,
+		})
+		return err
+	})
+	if err != nil {
+		span.RecordError(err)
+		return nil, false, err
+	}
+
+	updated, err := r.GetWorkflow(ctx, existing.ID, companyID)
+	if err != nil {
+		return nil, false, err
+	}
+	return updated, false, nil
+}
+
+// Marshal renders the definition as JSON or YAML
+func (def *WorkflowDefinition) Marshal(format string) ([]byte, error) {
+	switch strings.ToLower(format) {
+	case "", "json":
+		return json.MarshalIndent(def, "", "  ")
+	case "yaml", "yml":
+		return yaml.Marshal(def)
+	}
+	return nil, fmt.Errorf("%w: unsupported format %q", ErrInvalidWorkflowDefinition, format)
+}
+
+// newWorkflowDefinition describes a workflow with the steps of one of its
+// versions. Template IDs are replaced by the names of the templates.
+func newWorkflowDefinition(workflow *models.RecoveryWorkflow, steps []models.RecoveryWorkflowStep, templates templateIndex) (*WorkflowDefinition, error) {
+	def := &WorkflowDefinition{
+		Name:        workflow.Name,
+		Description: workflow.Description,
+		Priority:    workflow.Priority,
+		Shadow:      workflow.Shadow,
+		Steps:       make([]WorkflowStepDefinition, 0, len(steps)),
+	}
+	if len(workflow.TriggerConditions) > 0 {
+		if err := json.Unmarshal(workflow.TriggerConditions, &def.TriggerConditions); err != nil {
+			return nil, fmt.Errorf("failed to parse trigger conditions: %w", err)
+		}
+	}
+
+	for i := range steps {
+		step := &steps[i]
+		stepDef := WorkflowStepDefinition{
+			Key:            stepKey(step),
+			StepOrder:      step.StepOrder,
+			StepType:       step.StepType,
+			StepName:       step.StepName,
+			Description:    step.Description,
+			DelayMinutes:   step.DelayMinutes,
+			IsParallel:     step.IsParallel,
+			IsCritical:     step.IsCritical,
+			Join:           step.Join,
+			TimeoutSeconds: step.TimeoutSeconds,
+			RetryPolicy:    step.RetryPolicy,
+		}
+		if !step.Next.IsEmpty() {
+			next := step.Next
+			stepDef.Next = &next
+		}
+		if len(step.Config) > 0 {
+			if err := json.Unmarshal(step.Config, &stepDef.Config); err != nil {
+				return nil, fmt.Errorf("failed to parse config of step %q: %w", stepDef.Key, err)
+			}
+		}
+		if id, ok := stepDef.Config["template_id"].(string); ok {
+			if template := templates.byID(id); template != nil {
+				delete(stepDef.Config, "template_id")
+				stepDef.Config["template_name"] = template.Name
+			}
+		}
+		if len(step.Conditions) > 0 {
+			if err := json.Unmarshal(step.Conditions, &stepDef.Conditions); err != nil {
+				return nil, fmt.Errorf("failed to parse conditions of step %q: %w", stepDef.Key, err)
+			}
+		}
+		def.Steps = append(def.Steps, stepDef)
+	}
+	return def, nil
+}
+
+// workflow converts the definition to an active workflow with active steps
+func (def *WorkflowDefinition) workflow(companyID uuid.UUID, createdBy string) (*models.RecoveryWorkflow, error) {
+	triggerJSON, err := json.Marshal(def.TriggerConditions)
+	if err != nil {
+		return nil, fmt.Errorf("%w: trigger_conditions: %v", ErrInvalidWorkflowDefinition, err)
+	}
+	workflow := &models.RecoveryWorkflow{
+		CompanyID:         companyID,
+		Name:              def.Name,
+		Description:       def.Description,
+		IsActive:          true,
+		Priority:          def.Priority,
+		Shadow:            def.Shadow,
+		TriggerConditions: triggerJSON,
+		CreatedBy:         createdBy,
+		Steps:             make([]models.RecoveryWorkflowStep, 0, len(def.Steps)),
+	}
+
+	for i, stepDef := range def.Steps {
+		step := models.RecoveryWorkflowStep{
+			StepOrder:      stepDef.StepOrder,
+			StepType:       stepDef.StepType,
+			StepName:       stepDef.StepName,
+			Description:    stepDef.Description,
+			DelayMinutes:   stepDef.DelayMinutes,
+			IsParallel:     stepDef.IsParallel,
+			IsCritical:     stepDef.IsCritical,
+			IsActive:       true,
+			Key:            stepDef.Key,
+			Join:           stepDef.Join,
+			TimeoutSeconds: stepDef.TimeoutSeconds,
+			RetryPolicy:    stepDef.RetryPolicy,
+		}
+		if stepDef.Next != nil {
+			step.Next = *stepDef.Next
+		}
+		if stepDef.Config != nil {
+			if step.Config, err = json.Marshal(stepDef.Config); err != nil {
+				return nil, fmt.Errorf("%w: steps[%d].config: %v", ErrInvalidWorkflowDefinition, i, err)
+			}
+		}
+		if stepDef.Conditions != nil {
+			if step.Conditions, err = json.Marshal(stepDef.Conditions); err != nil {
+				return nil, fmt.Errorf("%w: steps[%d].conditions: %v", ErrInvalidWorkflowDefinition, i, err)
+			}
+		}
+		workflow.Steps = append(workflow.Steps, step)
+	}
+	return workflow, nil
+}
+
+// checkWorkflowDefinition records the problems of a parsed definition in doc.
+// Only a failure to look up templates is returned.
+func (r *RecoveryOrchestrationService) checkWorkflowDefinition(ctx context.Context, companyID uuid.UUID, def *WorkflowDefinition, doc *workflowDocument) error {
+	if strings.TrimSpace(def.Name) == "" {
+		doc.addf("name", "is required")
+	}
+	if def.TriggerConditions != nil {
+		conditionsJSON, _ := json.Marshal(def.TriggerConditions)
+		if err := validateTriggerConditions(conditionsJSON); err != nil {
+			doc.addError("", err, ErrInvalidWorkflowCondition)
+		}
+	}
+
+	var templates templateIndex
+	if def.referencesTemplates() {
+		var err error
+		if templates, err = r.companyTemplates(ctx, companyID); err != nil {
+			return err
+		}
+	}
+
+	r.mu.RLock()
+	executors := make(map[string]StepExecutor, len(r.stepExecutors))
+	for stepType, executor := range r.stepExecutors {
+		executors[stepType] = executor
+	}
+	r.mu.RUnlock()
+
+	for i, step := range def.Steps {
+		path := fmt.Sprintf("steps[%d]", i)
+		if strings.TrimSpace(step.StepName) == "" {
+			doc.addf(path+".step_name", "is required")
+		}
+		if step.StepType == "" {
+			doc.addf(path+".step_type", "is required")
+			continue
+		}
+		executor, ok := executors[step.StepType]
+		if !ok {
+			doc.addf(path+".step_type", "unknown step type %q, expected one of %s", step.StepType, strings.Join(slices.Sorted(maps.Keys(executors)), ", "))
+			continue
+		}
+		if describer, ok := executor.(StepConfigDescriber); ok {
+			doc.checkConfig(path+".config", step.StepType, step.Config, describer.ConfigSchema(), templates)
+		}
+		if step.StepType == "conditional" && step.Config != nil {
+			var set conditionSet
+			configJSON, _ := json.Marshal(step.Config)
+			if err := json.Unmarshal(configJSON, &set); err != nil {
+				doc.addf(path+".config", "%v", err)
+			} else if err := set.validate(); err != nil {
+				doc.addError(path+".config", err, nil)
+			}
+		}
+	}
+
+	// The retry policy and graph checks stop at the first problem
+	workflow, err := def.workflow(companyID, "")
+	if err != nil {
+		doc.addError("", err, ErrInvalidWorkflowDefinition)
+		return nil
+	}
+	if err := ValidateStepRetryPolicies(workflow); err != nil {
+		doc.addError("", err, ErrInvalidRetryPolicy)
+	}
+	if err := ValidateWorkflowGraph(workflow); err != nil {
+		doc.addError("steps", err, ErrInvalidWorkflowGraph)
+	}
+	return nil
+}
+
+// referencesTemplates reports whether any step config sets a template field
+func (def *WorkflowDefinition) referencesTemplates() bool {
+	for _, step := range def.Steps {
+		for _, field := range []string{"template_id", "template_name"} {
+			if value, _ := step.Config[field].(string); value != "" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// checkConfig checks a step config against its executor's schema
+func (d *workflowDocument) checkConfig(path, stepType string, config map[string]interface{}, schema StepConfigSchema, templates templateIndex) {
+	for _, name := range slices.Sorted(maps.Keys(config)) {
+		value, fieldPath := config[name], path+"."+name
+		field, ok := schema[name]
+		if !ok {
+			d.addf(fieldPath, "unknown field for %s steps", stepType)
+			continue
+		}
+		if value == nil {
+			continue
+		}
+		if !field.accepts(value) {
+			d.addf(fieldPath, "expected %s", field.Type)
+			continue
+		}
+		text, _ := value.(string)
+		if len(field.Enum) > 0 && !slices.Contains(field.Enum, text) {
+			d.addf(fieldPath, "must be one of %s", strings.Join(field.Enum, ", "))
+		}
+		if field.Type == ConfigFieldSchedule {
+			if _, err := calendar.ParseSchedule(text); err != nil {
+				d.addf(fieldPath, "%v", err)
+			}
+		}
+		if field.Template != "" && text != "" {
+			if problem := templates.check(text, field.Template); problem != "" {
+				d.addf(fieldPath, "%s", problem)
+			}
+		}
+	}
+	for _, name := range slices.Sorted(maps.Keys(schema)) {
+		if _, ok := config[name]; schema[name].Required && !ok {
+			d.addf(path+"."+name, "is required")
+		}
+	}
+}
+
+// accepts reports whether a decoded JSON or YAML value has the field's type
+func (f StepConfigField) accepts(value interface{}) bool {
+	switch f.Type {
+	case ConfigFieldString, ConfigFieldSchedule:
+		_, ok := value.(string)
+		return ok
+	case ConfigFieldNumber, ConfigFieldInteger:
+		var number float64
+		switch v := value.(type) {
+		case int:
+			number = float64(v)
+		case int64:
+			number = float64(v)
+		case uint64:
+			number = float64(v)
+		case float64:
+			number = v
+		default:
+			return false
+		}
+		return f.Type == ConfigFieldNumber || number == math.Trunc(number)
+	case ConfigFieldBool:
+		_, ok := value.(bool)
+		return ok
+	case ConfigFieldObject:
+		_, ok := value.(map[string]interface{})
+		return ok
+	case ConfigFieldList:
+		_, ok := value.([]interface{})
+		return ok
+	}
+	return true
+}
+
+// templateIndex holds a company's communication templates for resolving the
+// references step configs make by ID or name
+type templateIndex []models.CommunicationTemplate
+
+func (r *RecoveryOrchestrationService) companyTemplates(ctx context.Context, companyID uuid.UUID) (templateIndex, error) {
+	var templates []models.CommunicationTemplate
+	if err := r.db.WithContext(ctx).
+		Where("company_id = ?", companyID).
+		Find(&templates).Error; err != nil {
+		return nil, fmt.Errorf("failed to load communication templates: %w", err)
+	}
+	return templates, nil
+}
+
+func (t templateIndex) byID(id string) *models.CommunicationTemplate {
+	for i := range t {
+		if t[i].ID.String() == id {
+			return &t[i]
+		}
+	}
+	return nil
+}
+
+// check describes what is wrong with a reference to a template of
+// templateType, or returns "" when it resolves to an active template
+func (t templateIndex) check(ref, templateType string) string {
+	var found *models.CommunicationTemplate
+	if _, err := uuid.Parse(ref); err == nil {
+		found = t.byID(ref)
+	} else {
+		for i := range t {
+			if t[i].Name == ref && (found == nil || t[i].TemplateType == templateType && found.TemplateType != templateType) {
+				found = &t[i]
+			}
+		}
+	}
+	switch {
+	case found == nil:
+		return fmt.Sprintf("template %q not found", ref)
+	case found.TemplateType != templateType:
+		return fmt.Sprintf("template %q is for %s, not %s", ref, found.TemplateType, templateType)
+	case !found.IsActive:
+		return fmt.Sprintf("template %q is inactive", ref)
+	}
+	return ""
+}
+
+// workflowDocument is the source of a definition being checked. Problems are
+// recorded against paths such as steps[1].config.template_name and located in
+// the source through its YAML node tree; JSON sources parse as YAML too.
+type workflowDocument struct {
+	root   *yaml.Node
+	errors WorkflowDefinitionErrors
+}
+
+// parseWorkflowDefinition decodes a definition from JSON or YAML. The
+// definition is nil when the source does not decode; doc then holds the syntax
+// and type errors.
+func parseWorkflowDefinition(source []byte, format string) (*WorkflowDefinition, *workflowDocument, error) {
+	def := &WorkflowDefinition{Priority: 1}
+	doc := &workflowDocument{}
+	switch strings.ToLower(format) {
+	case "", "json":
+		// JSON is YAML once tabs, which YAML does not indent with, are spaces
+		var root yaml.Node
+		if yaml.Unmarshal(bytes.ReplaceAll(source, []byte("\t"), []byte(" ")), &root) == nil {
+			doc.root = &root
+		}
+		if err := json.Unmarshal(source, def); err != nil {
+			doc.addJSONError(source, err)
+		}
+	case "yaml", "yml":
+		var root yaml.Node
+		if err := yaml.Unmarshal(source, &root); err != nil {
+			doc.addYAMLError(err)
+		} else {
+			doc.root = &root
+			if err := root.Decode(def); err != nil {
+				doc.addYAMLError(err)
+			}
+		}
+	default:
+		return nil, nil, fmt.Errorf("%w: unsupported format %q", ErrInvalidWorkflowDefinition, format)
+	}
+	if len(doc.errors) > 0 {
+		return nil, doc, nil
+	}
+
+	doc.checkKeys()
+	for i := range def.Steps {
+		if def.Steps[i].StepOrder == 0 {
+			def.Steps[i].StepOrder = i + 1
+		}
+	}
+	return def, doc, nil
+}
+
+// addf records a problem at path, on the line of the source path leads to
+func (d *workflowDocument) addf(path, format string, args ...interface{}) {
+	node := d.locate(path)
+	d.errors = append(d.errors, WorkflowDefinitionError{
+		Line:    node.Line,
+		Column:  node.Column,
+		Path:    path,
+		Message: fmt.Sprintf(format, args...),
+	})
+}
+
+// addError records an error of the workflow validators, which start their
+// messages with the path they apply to after the sentinel. The path is relative
+// to base; errors without one are recorded at base.
+func (d *workflowDocument) addError(base string, err error, sentinel error) {
+	message := err.Error()
+	if sentinel != nil {
+		message = strings.TrimPrefix(message, sentinel.Error()+": ")
+	}
+	path := base
+	if prefix, rest, ok := strings.Cut(message, ": "); ok && !strings.ContainsAny(prefix, ` "`) {
+		path, message = joinDefinitionPath(base, prefix), rest
+	}
+	d.addf(path, "%s", message)
+}
+
+// addJSONError records a JSON decoding error at the offset it reports
+func (d *workflowDocument) addJSONError(source []byte, err error) {
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case errors.As(err, &syntaxErr):
+		line, column := sourcePosition(source, syntaxErr.Offset)
+		d.errors = append(d.errors, WorkflowDefinitionError{Line: line, Column: column, Message: syntaxErr.Error()})
+	case errors.As(err, &typeErr):
+		line, column := sourcePosition(source, typeErr.Offset)
+		d.errors = append(d.errors, WorkflowDefinitionError{
+			Line:    line,
+			Column:  column,
+			Path:    typeErr.Field,
+			Message: fmt.Sprintf("expected %s, got %s", jsonTypeName(typeErr.Type), typeErr.Value),
+		})
+	default:
+		d.errors = append(d.errors, WorkflowDefinitionError{Message: err.Error()})
+	}
+}
+
+var yamlErrorLine = regexp.MustCompile(`^(?:yaml: )?line (\d+): (.*)$`)
+
+// addYAMLError records a YAML parsing or decoding error, one entry per line
+// the decoder complained about
+func (d *workflowDocument) addYAMLError(err error) {
+	messages := []string{err.Error()}
+	var typeErr *yaml.TypeError
+	if errors.As(err, &typeErr) {
+		messages = typeErr.Errors
+	}
+	for _, message := range messages {
+		entry := WorkflowDefinitionError{Message: strings.TrimPrefix(message, "yaml: ")}
+		if match := yamlErrorLine.FindStringSubmatch(message); match != nil {
+			entry.Line, _ = strconv.Atoi(match[1])
+			entry.Message = match[2]
+		}
+		d.errors = append(d.errors, entry)
+	}
+}
+
+var (
+	definitionKeys  = jsonFieldNames(WorkflowDefinition{})
+	stepKeys        = jsonFieldNames(WorkflowStepDefinition{})
+	transitionKeys  = jsonFieldNames(models.StepTransitions{})
+	retryPolicyKeys = jsonFieldNames(models.StepRetryPolicy{})
+)
+
+// checkKeys records keys the definition format does not have, which decoding
+// would otherwise drop without a word
+func (d *workflowDocument) checkKeys() {
+	root := d.locate("")
+	d.checkMappingKeys("", root, definitionKeys)
+	steps := childNode(root, "steps")
+	if steps == nil || steps.Kind != yaml.SequenceNode {
+		return
+	}
+	for i, step := range steps.Content {
+		path := fmt.Sprintf("steps[%d]", i)
+		d.checkMappingKeys(path, step, stepKeys)
+		d.checkMappingKeys(path+".next", childNode(step, "next"), transitionKeys)
+		d.checkMappingKeys(path+".retry_policy", childNode(step, "retry_policy"), retryPolicyKeys)
+	}
+}
+
+func (d *workflowDocument) checkMappingKeys(path string, node *yaml.Node, known map[string]bool) {
+	if node == nil || node.Kind != yaml.MappingNode {
+		return
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key := node.Content[i]
+		if !known[key.Value] {
+			d.errors = append(d.errors, WorkflowDefinitionError{
+				Line:    key.Line,
+				Column:  key.Column,
+				Path:    joinDefinitionPath(path, key.Value),
+				Message: "unknown field",
+			})
+		}
+	}
+}
+
+var definitionPathSegment = regexp.MustCompile(`[^.\[\]]+|\[\d+\]`)
+
+// locate returns the node path leads to, or the deepest node on the way there
+// that the source has. An empty node is returned when the source did not parse.
+func (d *workflowDocument) locate(path string) *yaml.Node {
+	node := d.root
+	if node == nil {
+		return &yaml.Node{}
+	}
+	if node.Kind == yaml.DocumentNode && len(node.Content) > 0 {
+		node = node.Content[0]
+	}
+	for _, segment := range definitionPathSegment.FindAllString(path, -1) {
+		next := childNode(node, segment)
+		if next == nil {
+			break
+		}
+		node = next
+	}
+	return node
+}
+
+// childNode returns the value of a mapping key, or a sequence item for an
+// [index] segment
+func childNode(node *yaml.Node, segment string) *yaml.Node {
+	if node == nil {
+		return nil
+	}
+	if strings.HasPrefix(segment, "[") {
+		index, err := strconv.Atoi(strings.Trim(segment, "[]"))
+		if err != nil || node.Kind != yaml.SequenceNode || index >= len(node.Content) {
+			return nil
+		}
+		return node.Content[index]
+	}
+	if node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == segment {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
+
+func joinDefinitionPath(base, path string) string {
+	if base == "" || strings.HasPrefix(path, "[") {
+		return base + path
+	}
+	return base + "." + path
+}
+
+// sourcePosition converts a byte offset into a line and column, both from 1
+func sourcePosition(source []byte, offset int64) (int, int) {
+	if offset > int64(len(source)) {
+		offset = int64(len(source))
+	}
+	before := source[:offset]
+	line := bytes.Count(before, []byte("\n")) + 1
+	column := len(before) - bytes.LastIndexByte(before, '\n')
+	return line, column
+}
+
+// jsonTypeName names a Go type the way definition errors describe values
+func jsonTypeName(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return ConfigFieldInteger
+	case reflect.Float32, reflect.Float64:
+		return ConfigFieldNumber
+	case reflect.Bool:
+		return ConfigFieldBool
+	case reflect.Map, reflect.Struct, reflect.Ptr:
+		return ConfigFieldObject
+	case reflect.Slice, reflect.Array:
+		return ConfigFieldList
+	}
+	return t.Kind().String()
+}
+
+// jsonFieldNames returns the JSON names of a struct's fields
+func jsonFieldNames(v interface{}) map[string]bool {
+	t := reflect.TypeOf(v)
+	names := make(map[string]bool, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		if name != "" && name != "-" {
+			names[name] = true
+		}
+	}
+	return names
+}
@@ -0,0 +1,164 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+
+	"github.com/sambitmohanty1/payment-watchdog/api/internal/models"
+)
+
+const templatesQuery = `SELECT \* FROM "communication_templates" WHERE company_id = \$1`
+
+func definitionService(db *gorm.DB) *RecoveryOrchestrationService {
+	service := &RecoveryOrchestrationService{
+		db:            db,
+		logger:        zap.NewNop(),
+		tracer:        otel.Tracer("test"),
+		stepExecutors: make(map[string]StepExecutor),
+	}
+	service.RegisterStepExecutor(&PaymentRetryExecutor{service: service})
+	service.RegisterStepExecutor(&EmailExecutor{service: service})
+	service.RegisterStepExecutor(&SMSExecutor{service: service})
+	service.RegisterStepExecutor(&WaitExecutor{service: service})
+	service.RegisterStepExecutor(&ConditionalExecutor{service: service})
+	return service
+}
+
+// definitionErrorLines maps the paths of definition errors to their lines
+func definitionErrorLines(t *testing.T, err error) map[string]int {
+	var errs WorkflowDefinitionErrors
+	require.ErrorAs(t, err, &errs)
+	assert.ErrorIs(t, err, ErrInvalidWorkflowDefinition)
+	lines := make(map[string]int, len(errs))
+	for _, e := range errs {
+		lines[e.Path] = e.Line
+	}
+	return lines
+}
+
+func TestValidateWorkflowDefinitionReportsLines(t *testing.T) {
+	db, mock := mockDB(t)
+	companyID := uuid.New()
+	mock.ExpectQuery(templatesQuery).
+		WithArgs(companyID).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "company_id", "name", "template_type", "is_active"}).
+			AddRow(uuid.New(), companyID, "reminder", "sms", true))
+
+	source := `name: Dunning
+priority: 2
+trigger_conditions:
+  expression: "amount >"
+steps:
+  - key: email
+    step_type: send_email
+    step_name: First reminder
+    config:
+      template_name: reminder
+      send_at: whenever
+      max_contacts: two
+  - key: fax
+    step_type: send_fax
+    step_name: Fax
+    colour: red
+`
+	_, err := definitionService(db).ValidateWorkflowDefinition(context.Background(), companyID, []byte(source), "yaml")
+
+	assert.Equal(t, map[string]int{
+		"trigger_conditions.expression": 4,
+		"steps[0].config.template_name": 10,
+		"steps[0].config.send_at":       11,
+		"steps[0].config.max_contacts":  12,
+		"steps[1].step_type":            14,
+		"steps[1].colour":               16,
+	}, definitionErrorLines(t, err))
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestValidateWorkflowDefinitionJSON(t *testing.T) {
+	db, _ := mockDB(t)
+	service := definitionService(db)
+
+	syntax := "{\n  \"name\": \"Dunning\",\n  \"steps\": [\n    {\"step_type\": \"wait\",}\n  ]\n}"
+	_, err := service.ValidateWorkflowDefinition(context.Background(), uuid.New(), []byte(syntax), "json")
+	assert.Equal(t, map[string]int{"": 4}, definitionErrorLines(t, err))
+
+	mistyped := "{\n  \"name\": \"Dunning\",\n  \"priority\": \"high\",\n  \"steps\": []\n}"
+	_, err = service.ValidateWorkflowDefinition(context.Background(), uuid.New(), []byte(mistyped), "json")
+	assert.Equal(t, map[string]int{"priority": 3}, definitionErrorLines(t, err))
+
+	// A step conditional config and a successor are checked after decoding
+	semantic := `{
+	"name": "Dunning",
+	"steps": [
+		{"key": "check", "step_type": "conditional", "step_name": "Check",
+		 "config": {"conditions": [{"field": "colour", "operator": "eq", "value": "red"}], "on_true": "jump"},
+		 "next": {"on_true": ["missing"]}}
+	]
+}`
+	_, err = service.ValidateWorkflowDefinition(context.Background(), uuid.New(), []byte(semantic), "json")
+	assert.Equal(t, map[string]int{
+		"steps[0].config.conditions[0]": 5,
+		"steps[0].config.on_true":       5,
+		"steps":                         3,
+	}, definitionErrorLines(t, err))
+
+	_, err = service.ValidateWorkflowDefinition(context.Background(), uuid.New(), []byte(syntax), "toml")
+	assert.ErrorIs(t, err, ErrInvalidWorkflowDefinition)
+}
+
+func TestWorkflowDefinitionRoundTrip(t *testing.T) {
+	db, mock := mockDB(t)
+	companyID, templateID := uuid.New(), uuid.New()
+	workflow := &models.RecoveryWorkflow{
+		Name:              "Dunning",
+		Priority:          3,
+		TriggerConditions: []byte(`{"conditions":[{"field":"amount","operator":"gt","value":100}],"logic":"AND"}`),
+	}
+	steps := []models.RecoveryWorkflowStep{
+		{ID: uuid.New(), StepOrder: 1, Key: "email", StepType: "send_email", StepName: "Reminder",
+			Config: []byte(`{"template_id":"` + templateID.String() + `","max_contacts":2}`),
+			Next:   models.StepTransitions{OnSuccess: []string{"wait"}}},
+		{ID: uuid.New(), StepOrder: 2, Key: "wait", StepType: "wait", StepName: "Wait",
+			Config:      []byte(`{"wait_business_days":3,"until":"next_business_day 10:00"}`),
+			RetryPolicy: &models.StepRetryPolicy{MaxAttempts: 2}},
+	}
+	templates := templateIndex{{ID: templateID, CompanyID: companyID, Name: "reminder", TemplateType: "email", IsActive: true}}
+
+	def, err := newWorkflowDefinition(workflow, steps, templates)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"template_name": "reminder", "max_contacts": float64(2)}, def.Steps[0].Config)
+
+	source, err := def.Marshal("yaml")
+	require.NoError(t, err)
+
+	mock.ExpectQuery(templatesQuery).
+		WithArgs(companyID).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "company_id", "name", "template_type", "is_active"}).
+			AddRow(templateID, companyID, "reminder", "email", true))
+	imported, err := definitionService(db).ValidateWorkflowDefinition(context.Background(), companyID, source, "yaml")
+	require.NoError(t, err, string(source))
+
+	restored, err := imported.workflow(companyID, "importer")
+	require.NoError(t, err)
+	assert.Equal(t, 3, restored.Priority)
+	assert.JSONEq(t, string(workflow.TriggerConditions), string(restored.TriggerConditions))
+	require.Len(t, restored.Steps, 2)
+	assert.Equal(t, "email", restored.Steps[0].Key)
+	assert.Equal(t, []string{"wait"}, restored.Steps[0].Next.OnSuccess)
+	assert.JSONEq(t, `{"template_name":"reminder","max_contacts":2}`, string(restored.Steps[0].Config))
+	assert.Equal(t, 2, restored.Steps[1].RetryPolicy.MaxAttempts)
+
+	var config map[string]interface{}
+	require.NoError(t, json.Unmarshal(restored.Steps[1].Config, &config))
+	assert.Equal(t, "next_business_day 10:00", config["until"])
+	require.NoError(t, mock.ExpectationsWereMet())
+}
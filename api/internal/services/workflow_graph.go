@@ -0,0 +1,434 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/sambitmohanty1/payment-watchdog/api/internal/models"
+)
+
+// ErrInvalidWorkflowGraph is returned when a workflow's steps do not form a
+// valid graph: unknown or duplicate step keys, bad join modes or cycles
+var ErrInvalidWorkflowGraph = errors.New("invalid workflow graph")
+
+// workflowGraph is a workflow's active steps and the edges between them. A
+// workflow that declares successors on none of its steps runs linearly in
+// StepOrder, with consecutive IsParallel steps running side by side and
+// conditional steps' skip and stop actions mapped onto edges.
+type workflowGraph struct {
+	steps []models.RecoveryWorkflowStep
+	// next holds each step's successors per outcome, by step index
+	next []map[string][]int
+	// preds holds the steps with an edge into each step, by step index
+	preds [][]int
+}
+
+// stepKey is the key a step is referenced by, derived from its order when the
+// step does not set one
+func stepKey(step *models.RecoveryWorkflowStep) string {
+	if step.Key != "" {
+		return step.Key
+	}
+	return fmt.Sprintf("step_%d", step.StepOrder)
+}
+
+// declaresGraph reports whether any step declares successors or a join mode
+func declaresGraph(steps []models.RecoveryWorkflowStep) bool {
+	for _, step := range steps {
+		if !step.Next.IsEmpty() || step.Join != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// buildWorkflowGraph builds the graph of the workflow's active steps
+func buildWorkflowGraph(workflow *models.RecoveryWorkflow) (*workflowGraph, error) {
+	steps := orderedSteps(workflow)
+	g := &workflowGraph{
+		steps: steps,
+		next:  make([]map[string][]int, len(steps)),
+		preds: make([][]int, len(steps)),
+	}
+	for i := range steps {
+		g.next[i] = make(map[string][]int)
+	}
+
+	if declaresGraph(steps) {
+		if err := g.linkDeclared(); err != nil {
+			return nil, err
+		}
+	} else {
+		g.linkLinear()
+	}
+
+	for i := range g.next {
+		seen := make(map[int]bool)
+		for _, successors := range g.next[i] {
+			for _, j := range successors {
+				if !seen[j] {
+					seen[j] = true
+					g.preds[j] = append(g.preds[j], i)
+				}
+			}
+		}
+	}
+	if cycle := g.cycle(); cycle != nil {
+		return nil, fmt.Errorf("%w: cycle %s", ErrInvalidWorkflowGraph, strings.Join(cycle, " -> "))
+	}
+	return g, nil
+}
+
+// linkDeclared resolves the successor keys the steps declare
+func (g *workflowGraph) linkDeclared() error {
+	index := make(map[string]int, len(g.steps))
+	for i := range g.steps {
+		key := stepKey(&g.steps[i])
+		if _, exists := index[key]; exists {
+			return fmt.Errorf("%w: duplicate step key %q", ErrInvalidWorkflowGraph, key)
+		}
+		index[key] = i
+	}
+
+	for i := range g.steps {
+		step := &g.steps[i]
+		for _, outcome := range []struct {
+			name string
+			keys []string
+		}{
+			{models.StepOutcomeSuccess, step.Next.OnSuccess},
+			{models.StepOutcomeFailure, step.Next.OnFailure},
+			{models.StepOutcomeTrue, step.Next.OnTrue},
+			{models.StepOutcomeFalse, step.Next.OnFalse},
+			{models.StepOutcomeTimeout, step.Next.OnTimeout},
+		} {
+			for _, key := range outcome.keys {
+				j, ok := index[key]
+				if !ok {
+					return fmt.Errorf("%w: step %q: on_%s refers to unknown step %q", ErrInvalidWorkflowGraph, stepKey(step), outcome.name, key)
+				}
+				g.next[i][outcome.name] = append(g.next[i][outcome.name], j)
+			}
+		}
+	}
+	return nil
+}
+
+// linkLinear links steps in order. Consecutive IsParallel steps form a group
+// that starts together and is joined by the group after it.
+func (g *workflowGraph) linkLinear() {
+	var groups [][]int
+	groupOf := make([]int, len(g.steps))
+	for i := range g.steps {
+		if i == 0 || !g.steps[i-1].IsParallel {
+			groups = append(groups, nil)
+		}
+		groupOf[i] = len(groups) - 1
+		groups[len(groups)-1] = append(groups[len(groups)-1], i)
+	}
+
+	for i := range g.steps {
+		var next []int
+		if group := groupOf[i] + 1; group < len(groups) {
+			next = groups[group]
+		}
+		g.next[i][models.StepOutcomeSuccess] = next
+
+		if g.steps[i].StepType != "conditional" {
+			continue
+		}
+		// Conditional steps' actions: skip jumps over the next skip_steps
+		// steps, stop ends the branch
+		var config struct {
+			OnTrue    string `json:"on_true"`
+			OnFalse   string `json:"on_false"`
+			SkipSteps int    `json:"skip_steps"`
+		}
+		if len(g.steps[i].Config) == 0 || json.Unmarshal(g.steps[i].Config, &config) != nil {
+			continue
+		}
+		for outcome, action := range map[string]string{
+			models.StepOutcomeTrue:  config.OnTrue,
+			models.StepOutcomeFalse: config.OnFalse,
+		} {
+			switch action {
+			case "skip":
+				var skipTo []int
+				if target := i + 1 + config.SkipSteps; target < len(g.steps) {
+					skipTo = groups[groupOf[target]]
+				}
+				g.next[i][outcome] = skipTo
+			case "stop":
+				g.next[i][outcome] = []int{}
+			}
+		}
+	}
+}
+
+// cycle returns the keys along a cycle in the graph, or nil if it has none
+func (g *workflowGraph) cycle() []string {
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	state := make([]int, len(g.steps))
+	var stack []int
+
+	var visit func(i int) []string
+	visit = func(i int) []string {
+		state[i] = visiting
+		stack = append(stack, i)
+		for _, successors := range g.next[i] {
+			for _, j := range successors {
+				switch state[j] {
+				case visiting:
+					var keys []string
+					for k := len(stack) - 1; k >= 0; k-- {
+						if stack[k] == j {
+							for _, s := range stack[k:] {
+								keys = append(keys, stepKey(&g.steps[s]))
+							}
+							break
+						}
+					}
+					return append(keys, stepKey(&g.steps[j]))
+				case unvisited:
+					if cycle := visit(j); cycle != nil {
+						return cycle
+					}
+				}
+			}
+		}
+		stack = stack[:len(stack)-1]
+		state[i] = visited
+		return nil
+	}
+
+	for i := range g.steps {
+		if state[i] == unvisited {
+			if cycle := visit(i); cycle != nil {
+				return cycle
+			}
+		}
+	}
+	return nil
+}
+
+// successors returns the steps a step's outcome leads to. Condition outcomes
+// without their own successors follow the success ones, a timeout without
+// them counts as a failure, and a failure without them follows the success
+// successors unless the step is critical.
+func (g *workflowGraph) successors(i int, outcome string) []int {
+	next, declared := g.next[i][outcome]
+	switch outcome {
+	case models.StepOutcomeSkipped:
+		return nil
+	case models.StepOutcomeTrue, models.StepOutcomeFalse:
+		if declared {
+			return next
+		}
+	case models.StepOutcomeTimeout:
+		if declared {
+			return next
+		}
+		return g.successors(i, models.StepOutcomeFailure)
+	case models.StepOutcomeFailure:
+		if declared {
+			return next
+		}
+		if g.steps[i].IsCritical {
+			return nil
+		}
+	}
+	return g.next[i][models.StepOutcomeSuccess]
+}
+
+// failsExecution reports whether a step's outcome fails the whole execution: a
+// critical step failing or timing out with no successors declared for it
+func (g *workflowGraph) failsExecution(i int, outcome string) bool {
+	if !g.steps[i].IsCritical {
+		return false
+	}
+	_, onFailure := g.next[i][models.StepOutcomeFailure]
+	_, onTimeout := g.next[i][models.StepOutcomeTimeout]
+	switch outcome {
+	case models.StepOutcomeFailure:
+		return !onFailure
+	case models.StepOutcomeTimeout:
+		return !onTimeout && !onFailure
+	}
+	return false
+}
+
+// takes reports whether the edge from step i into step j was taken
+func (g *workflowGraph) takes(i int, outcome string, j int) bool {
+	for _, s := range g.successors(i, outcome) {
+		if s == j {
+			return true
+		}
+	}
+	return false
+}
+
+// ready returns the steps that can run now given the outcomes so far. Steps no
+// branch can reach any more are marked skipped, which may in turn settle the
+// joins after them.
+func (g *workflowGraph) ready(outcomes map[string]string, waiting map[string]*checkpointWait) []int {
+	for {
+		var ready []int
+		skipped := false
+		for i := range g.steps {
+			id := g.steps[i].ID.String()
+			if _, done := outcomes[id]; done {
+				continue
+			}
+			if _, parked := waiting[id]; parked {
+				continue
+			}
+			preds := g.preds[i]
+			if len(preds) == 0 {
+				ready = append(ready, i)
+				continue
+			}
+
+			resolved, taken := 0, 0
+			for _, p := range preds {
+				outcome, done := outcomes[g.steps[p].ID.String()]
+				if !done {
+					continue
+				}
+				resolved++
+				if g.takes(p, outcome, i) {
+					taken++
+				}
+			}
+			switch {
+			case taken > 0 && (g.steps[i].Join == models.StepJoinAny || resolved == len(preds)):
+				ready = append(ready, i)
+			case taken == 0 && resolved == len(preds):
+				outcomes[id] = models.StepOutcomeSkipped
+				skipped = true
+			}
+		}
+		if !skipped {
+			return ready
+		}
+	}
+}
+
+// stepOutcome classifies how a step ended
+func stepOutcome(result *StepResult, err error) string {
+	switch {
+	case result != nil && result.Outcome != "":
+		return result.Outcome
+	case errors.Is(err, context.DeadlineExceeded):
+		return models.StepOutcomeTimeout
+	case err != nil, result != nil && !result.Success:
+		return models.StepOutcomeFailure
+	default:
+		return models.StepOutcomeSuccess
+	}
+}
+
+// ValidateWorkflowGraph checks that a workflow's steps form a valid graph so
+// that broken successor lists and cycles are rejected when the workflow is
+// saved
+func ValidateWorkflowGraph(workflow *models.RecoveryWorkflow) error {
+	for i, step := range workflow.Steps {
+		switch step.Join {
+		case "", models.StepJoinAll, models.StepJoinAny:
+		default:
+			return fmt.Errorf("%w: steps[%d].join: must be %q or %q", ErrInvalidWorkflowGraph, i, models.StepJoinAll, models.StepJoinAny)
+		}
+		if step.TimeoutSeconds < 0 {
+			return fmt.Errorf("%w: steps[%d].timeout_seconds: must not be negative", ErrInvalidWorkflowGraph, i)
+		}
+	}
+	_, err := buildWorkflowGraph(workflow)
+	return err
+}
+
+// recordOutcome settles a step and appends it to the execution's path
+func (e *WorkflowExecution) recordOutcome(step *models.RecoveryWorkflowStep, outcome string, at time.Time) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.outcomes == nil {
+		e.outcomes = make(map[string]string)
+	}
+	e.outcomes[step.ID.String()] = outcome
+	e.path = append(e.path, models.ExecutionPathEntry{
+		StepID:  step.ID,
+		StepKey: stepKey(step),
+		Outcome: outcome,
+		At:      at,
+	})
+}
+
+// park holds a step's branch on a timer until the execution resumes for it
+func (e *WorkflowExecution) park(step *models.RecoveryWorkflowStep, kind string, until time.Time) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.waits == nil {
+		e.waits = make(map[string]*checkpointWait)
+	}
+	e.waits[step.ID.String()] = &checkpointWait{StepID: step.ID, Kind: kind, Until: until}
+}
+
+// nextWait returns the parked branch due first
+func (e *WorkflowExecution) nextWait() *checkpointWait {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	var next *checkpointWait
+	for _, wait := range e.waits {
+		if next == nil || wait.Until.Before(next.Until) {
+			next = wait
+		}
+	}
+	return next
+}
+
+// releaseDueWaits resumes the branches whose timers are due by now. A served
+// wait step settles as a success; a step held for its delay or send time
+// becomes ready to run.
+func (g *workflowGraph) releaseDueWaits(e *WorkflowExecution, now time.Time) {
+	for i := range g.steps {
+		id := g.steps[i].ID.String()
+		e.mu.Lock()
+		wait, parked := e.waits[id]
+		if !parked || wait.Until.After(now) {
+			e.mu.Unlock()
+			continue
+		}
+		delete(e.waits, id)
+		if e.served == nil {
+			e.served = make(map[string]string)
+		}
+		e.served[id] = wait.Kind
+		_, done := e.outcomes[id]
+		e.mu.Unlock()
+
+		if wait.Kind == models.WorkflowTimerWait && !done {
+			e.recordOutcome(&g.steps[i], models.StepOutcomeSuccess, now)
+		}
+	}
+}
+
+// adoptLinearCheckpoint settles the steps before the step index a checkpoint
+// from before workflow graphs continued with
+func (g *workflowGraph) adoptLinearCheckpoint(e *WorkflowExecution, nextIndex int) {
+	for i := 0; i < nextIndex && i < len(g.steps); i++ {
+		id := g.steps[i].ID.String()
+		if _, parked := e.waits[id]; parked {
+			continue
+		}
+		if e.outcomes == nil {
+			e.outcomes = make(map[string]string)
+		}
+		e.outcomes[id] = models.StepOutcomeSuccess
+	}
+}
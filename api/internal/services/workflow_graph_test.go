@@ -0,0 +1,220 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sambitmohanty1/payment-watchdog/api/internal/models"
+)
+
+// graphStep is an active step with a key and successors
+func graphStep(order int, key string, next models.StepTransitions) models.RecoveryWorkflowStep {
+	return models.RecoveryWorkflowStep{ID: uuid.New(), StepOrder: order, Key: key, Next: next, IsActive: true}
+}
+
+// settle records outcomes by step key and returns the keys of the ready steps
+func settle(g *workflowGraph, outcomes map[string]string, settled map[string]string) []string {
+	for i := range g.steps {
+		if outcome, ok := settled[g.steps[i].Key]; ok {
+			outcomes[g.steps[i].ID.String()] = outcome
+		}
+	}
+	var keys []string
+	for _, i := range g.ready(outcomes, nil) {
+		keys = append(keys, g.steps[i].Key)
+	}
+	return keys
+}
+
+func TestValidateWorkflowGraph(t *testing.T) {
+	valid := &models.RecoveryWorkflow{Steps: []models.RecoveryWorkflowStep{
+		graphStep(1, "check", models.StepTransitions{OnTrue: []string{"email"}, OnFalse: []string{"sms"}}),
+		graphStep(2, "email", models.StepTransitions{OnSuccess: []string{"retry"}}),
+		graphStep(3, "sms", models.StepTransitions{OnSuccess: []string{"retry"}}),
+		graphStep(4, "retry", models.StepTransitions{}),
+	}}
+	valid.Steps[3].Join = models.StepJoinAny
+	assert.NoError(t, ValidateWorkflowGraph(valid))
+
+	cyclic := &models.RecoveryWorkflow{Steps: []models.RecoveryWorkflowStep{
+		graphStep(1, "email", models.StepTransitions{OnSuccess: []string{"wait"}}),
+		graphStep(2, "wait", models.StepTransitions{OnSuccess: []string{"retry"}}),
+		graphStep(3, "retry", models.StepTransitions{OnFailure: []string{"email"}}),
+	}}
+	err := ValidateWorkflowGraph(cyclic)
+	assert.ErrorIs(t, err, ErrInvalidWorkflowGraph)
+	assert.Contains(t, err.Error(), "cycle email -> wait -> retry -> email")
+
+	for name, steps := range map[string][]models.RecoveryWorkflowStep{
+		"unknown successor": {graphStep(1, "email", models.StepTransitions{OnTimeout: []string{"missing"}})},
+		"duplicate key":     {graphStep(1, "email", models.StepTransitions{OnSuccess: []string{"sms"}}), graphStep(2, "email", models.StepTransitions{})},
+		"self loop":         {graphStep(1, "retry", models.StepTransitions{OnFailure: []string{"retry"}})},
+		"bad join":          {{StepOrder: 1, Join: "some", IsActive: true}},
+	} {
+		assert.ErrorIs(t, ValidateWorkflowGraph(&models.RecoveryWorkflow{Steps: steps}), ErrInvalidWorkflowGraph, name)
+	}
+}
+
+func TestLinearWorkflowGraph(t *testing.T) {
+	steps := []models.RecoveryWorkflowStep{
+		{ID: uuid.New(), StepOrder: 1, Key: "email", IsActive: true, IsParallel: true},
+		{ID: uuid.New(), StepOrder: 2, Key: "sms", IsActive: true},
+		{ID: uuid.New(), StepOrder: 3, Key: "check", StepType: "conditional", IsActive: true,
+			Config: []byte(`{"on_true": "skip", "skip_steps": 1, "on_false": "stop"}`)},
+		{ID: uuid.New(), StepOrder: 4, Key: "retry", IsActive: true, IsCritical: true},
+		{ID: uuid.New(), StepOrder: 5, Key: "final", IsActive: true},
+	}
+	g, err := buildWorkflowGraph(&models.RecoveryWorkflow{Steps: steps})
+	require.NoError(t, err)
+
+	outcomes := map[string]string{}
+	assert.Equal(t, []string{"email", "sms"}, settle(g, outcomes, nil), "parallel steps start together")
+	assert.Equal(t, []string{"sms"}, settle(g, outcomes, map[string]string{"email": models.StepOutcomeSuccess}), "the next step waits for both")
+	assert.Equal(t, []string{"check"}, settle(g, outcomes, map[string]string{"sms": models.StepOutcomeFailure}), "a non-critical failure moves on")
+
+	skipped := copyOutcomes(outcomes)
+	assert.Equal(t, []string{"final"}, settle(g, skipped, map[string]string{"check": models.StepOutcomeTrue}), "skip jumps over a step")
+	assert.Equal(t, models.StepOutcomeSkipped, skipped[steps[3].ID.String()])
+
+	stopped := copyOutcomes(outcomes)
+	assert.Empty(t, settle(g, stopped, map[string]string{"check": models.StepOutcomeFalse}), "stop ends the run")
+
+	assert.True(t, g.failsExecution(3, models.StepOutcomeFailure), "a critical failure fails the execution")
+	assert.True(t, g.failsExecution(3, models.StepOutcomeTimeout))
+	assert.False(t, g.failsExecution(1, models.StepOutcomeFailure))
+	assert.Empty(t, g.successors(3, models.StepOutcomeFailure))
+}
+
+func TestWorkflowGraphJoins(t *testing.T) {
+	steps := []models.RecoveryWorkflowStep{
+		graphStep(1, "check", models.StepTransitions{OnTrue: []string{"email", "sms"}, OnFalse: []string{"retry"}}),
+		graphStep(2, "email", models.StepTransitions{OnSuccess: []string{"all"}, OnFailure: []string{"escalate"}}),
+		graphStep(3, "sms", models.StepTransitions{OnSuccess: []string{"all", "any"}}),
+		graphStep(4, "retry", models.StepTransitions{OnSuccess: []string{"any"}, OnTimeout: []string{"escalate"}}),
+		graphStep(5, "all", models.StepTransitions{}),
+		graphStep(6, "any", models.StepTransitions{}),
+		graphStep(7, "escalate", models.StepTransitions{}),
+	}
+	steps[5].Join = models.StepJoinAny
+	g, err := buildWorkflowGraph(&models.RecoveryWorkflow{Steps: steps})
+	require.NoError(t, err)
+
+	t.Run("true branch", func(t *testing.T) {
+		outcomes := map[string]string{}
+		assert.Equal(t, []string{"check"}, settle(g, outcomes, nil))
+		assert.Equal(t, []string{"email", "sms"}, settle(g, outcomes, map[string]string{"check": models.StepOutcomeTrue}))
+		assert.Equal(t, models.StepOutcomeSkipped, outcomes[steps[3].ID.String()], "the untaken branch is skipped")
+
+		assert.Equal(t, []string{"email", "any"}, settle(g, outcomes, map[string]string{"sms": models.StepOutcomeSuccess}), "an any join runs on the first arrival")
+		assert.Equal(t, []string{"all"}, settle(g, outcomes, map[string]string{"email": models.StepOutcomeSuccess, "any": models.StepOutcomeSuccess}))
+		assert.Equal(t, models.StepOutcomeSkipped, outcomes[steps[6].ID.String()])
+	})
+
+	t.Run("failure routing", func(t *testing.T) {
+		outcomes := map[string]string{}
+		ready := settle(g, outcomes, map[string]string{"check": models.StepOutcomeTrue, "email": models.StepOutcomeFailure, "sms": models.StepOutcomeSuccess})
+		assert.Equal(t, []string{"all", "any", "escalate"}, ready, "an all join runs once its other branches are settled, taken or not")
+	})
+
+	t.Run("timeout routing", func(t *testing.T) {
+		outcomes := map[string]string{}
+		ready := settle(g, outcomes, map[string]string{"check": models.StepOutcomeFalse, "retry": models.StepOutcomeTimeout})
+		assert.Equal(t, []string{"escalate"}, ready)
+		assert.Equal(t, models.StepOutcomeSkipped, outcomes[steps[5].ID.String()])
+	})
+}
+
+func copyOutcomes(outcomes map[string]string) map[string]string {
+	copied := make(map[string]string, len(outcomes))
+	for id, outcome := range outcomes {
+		copied[id] = outcome
+	}
+	return copied
+}
+
+func TestStepOutcome(t *testing.T) {
+	assert.Equal(t, models.StepOutcomeSuccess, stepOutcome(&StepResult{Success: true}, nil))
+	assert.Equal(t, models.StepOutcomeSuccess, stepOutcome(nil, nil))
+	assert.Equal(t, models.StepOutcomeFailure, stepOutcome(&StepResult{Success: false}, nil))
+	assert.Equal(t, models.StepOutcomeFailure, stepOutcome(nil, errors.New("provider down")))
+	assert.Equal(t, models.StepOutcomeTimeout, stepOutcome(nil, fmt.Errorf("step timed out: %w", context.DeadlineExceeded)))
+	assert.Equal(t, models.StepOutcomeFalse, stepOutcome(&StepResult{Success: true, Outcome: models.StepOutcomeFalse}, nil))
+}
+
+func TestReleaseDueWaits(t *testing.T) {
+	steps := []models.RecoveryWorkflowStep{
+		graphStep(1, "wait", models.StepTransitions{}),
+		graphStep(2, "email", models.StepTransitions{}),
+		graphStep(3, "sms", models.StepTransitions{}),
+	}
+	g, err := buildWorkflowGraph(&models.RecoveryWorkflow{Steps: steps})
+	require.NoError(t, err)
+
+	now := time.Now()
+	execution := &WorkflowExecution{outcomes: map[string]string{}}
+	execution.park(&steps[0], models.WorkflowTimerWait, now.Add(-time.Minute))
+	execution.park(&steps[1], models.WorkflowTimerStepDelay, now.Add(-time.Second))
+	execution.park(&steps[2], models.WorkflowTimerSendTime, now.Add(time.Hour))
+
+	g.releaseDueWaits(execution, now)
+	assert.Equal(t, models.StepOutcomeSuccess, execution.outcomes[steps[0].ID.String()], "a served wait settles its step")
+	assert.True(t, execution.waitServed(steps[1].ID, models.WorkflowTimerStepDelay), "a served delay lets its step run")
+	assert.False(t, execution.waitServed(steps[2].ID, models.WorkflowTimerSendTime))
+	assert.Equal(t, []int{1}, g.ready(execution.outcomes, execution.waits))
+	assert.Equal(t, steps[2].ID, execution.nextWait().StepID)
+	require.Len(t, execution.path, 1)
+	assert.Equal(t, "wait", execution.path[0].StepKey)
+}
+
+func TestAdoptLinearCheckpoint(t *testing.T) {
+	steps := []models.RecoveryWorkflowStep{
+		{ID: uuid.New(), StepOrder: 1, IsActive: true},
+		{ID: uuid.New(), StepOrder: 2, IsActive: true},
+		{ID: uuid.New(), StepOrder: 3, IsActive: true},
+	}
+	g, err := buildWorkflowGraph(&models.RecoveryWorkflow{Steps: steps})
+	require.NoError(t, err)
+
+	// Suspended on the second step's wait before workflow graphs
+	cp := &executionCheckpoint{NextStepIndex: 2, Wait: &checkpointWait{StepID: steps[1].ID, Kind: models.WorkflowTimerWait}}
+	execution := restoreExecution(&models.RecoveryWorkflowExecution{}, cp)
+	g.adoptLinearCheckpoint(execution, execution.CurrentStepIndex)
+
+	assert.Equal(t, map[string]string{steps[0].ID.String(): models.StepOutcomeSuccess}, execution.outcomes)
+	assert.Empty(t, g.ready(execution.outcomes, execution.waits), "the third step waits for the wait")
+	g.releaseDueWaits(execution, time.Now())
+	assert.Equal(t, []int{2}, g.ready(execution.outcomes, execution.waits))
+}
+
+func TestGetWorkflowExecutionIsCompanyScoped(t *testing.T) {
+	db, mock := mockDB(t)
+	service := &RecoveryOrchestrationService{db: db}
+	executionID, workflowID, companyID := uuid.New(), uuid.New(), uuid.New()
+
+	mock.ExpectQuery(`SELECT \* FROM "recovery_workflow_executions" WHERE id = \$1 AND company_id = \$2`).
+		WithArgs(executionID, companyID, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "workflow_id", "company_id", "path"}).
+			AddRow(executionID, workflowID, companyID, `[{"step_key":"email","outcome":"success"}]`))
+	mock.ExpectQuery(`SELECT \* FROM "recovery_workflows" WHERE "recovery_workflows"."id" = \$1`).
+		WithArgs(workflowID).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(workflowID, "Dunning"))
+
+	execution, err := service.GetWorkflowExecution(context.Background(), executionID, companyID)
+	require.NoError(t, err)
+	require.Len(t, execution.Path, 1)
+	assert.Equal(t, "Dunning", execution.Workflow.Name)
+
+	mock.ExpectQuery(`SELECT \* FROM "recovery_workflow_executions" WHERE id = \$1 AND company_id = \$2`).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	_, err = service.GetWorkflowExecution(context.Background(), executionID, uuid.New())
+	assert.ErrorIs(t, err, ErrWorkflowExecutionNotFound)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
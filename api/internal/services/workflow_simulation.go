@@ -0,0 +1,585 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/sambitmohanty1/payment-watchdog/api/internal/models"
+)
+
+// ErrInvalidSimulation is returned for simulation requests that cannot be run
+var ErrInvalidSimulation = errors.New("invalid workflow simulation")
+
+// Scenario outcomes a simulation can inject into an attempt at a step
+const (
+	ScenarioSuccess = "success" // the attempt ends as the executor ran it
+	ScenarioFailure = "failure" // the attempt fails with a transient error, so the retry policy applies
+	ScenarioBounce  = "bounce"  // the message bounces; a failure not worth retrying
+	ScenarioTimeout = "timeout" // the attempt runs past the step's timeout
+	ScenarioTrue    = "true"    // a conditional step's conditions hold
+	ScenarioFalse   = "false"   // a conditional step's conditions do not hold
+)
+
+// Simulation event types
+const (
+	SimulationEventStep         = "step"          // an attempt at a step ended
+	SimulationEventWait         = "wait"          // a branch parked on a timer
+	SimulationEventPaymentRetry = "payment_retry" // a payment retry was submitted
+	SimulationEventEmail        = "email"         // an email was sent
+	SimulationEventSMS          = "sms"           // an SMS was sent
+	SimulationEventCustomerPaid = "customer_paid" // the customer paid, ending the execution
+)
+
+// Simulation statuses
+const (
+	SimulationCompleted = "completed" // every branch ran to its end
+	SimulationFailed    = "failed"    // a critical step failed
+	SimulationRecovered = "recovered" // the customer paid before the workflow finished
+	SimulationWaiting   = "waiting"   // a branch was still waiting when the horizon ran out
+)
+
+const (
+	defaultSimulationHorizonDays = 90
+	maxSimulationHorizonDays     = 365
+)
+
+// WorkflowSimulation asks what a workflow would do for a payment failure. The
+// failure is an existing one or a hypothetical one given inline.
+type WorkflowSimulation struct {
+	// Version is the workflow version to simulate; its current version when 0
+	Version          int                         `json:"version,omitempty"`
+	PaymentFailureID *uuid.UUID                  `json:"payment_failure_id,omitempty"`
+	PaymentFailure   *models.PaymentFailureEvent `json:"payment_failure,omitempty"`
+	// StartAt is the virtual time the execution starts at; now when unset
+	StartAt *time.Time `json:"start_at,omitempty"`
+	// HorizonDays is how far the virtual clock may run
+	HorizonDays int `json:"horizon_days,omitempty"`
+	// Scenarios are the outcomes injected into each attempt at a step, by step
+	// key. The last outcome listed holds for any later attempts; steps without
+	// a scenario end as their executors ran them.
+	Scenarios map[string][]string `json:"scenarios,omitempty"`
+	// CustomerPaysAfterHours has the customer pay that long after the start,
+	// ending the execution; the customer does not pay when unset
+	CustomerPaysAfterHours int `json:"customer_pays_after_hours,omitempty"`
+}
+
+// SimulationEvent is one entry of a simulation's timeline
+type SimulationEvent struct {
+	At       time.Time              `json:"at"`
+	Type     string                 `json:"type"`
+	StepKey  string                 `json:"step_key,omitempty"`
+	StepType string                 `json:"step_type,omitempty"`
+	Attempt  int                    `json:"attempt,omitempty"`
+	Outcome  string                 `json:"outcome,omitempty"`
+	Scenario string                 `json:"scenario,omitempty"`
+	Error    string                 `json:"error,omitempty"`
+	WaitKind string                 `json:"wait_kind,omitempty"`
+	Until    *time.Time             `json:"until,omitempty"`
+	Message  *RenderedMessage       `json:"message,omitempty"`
+	Data     map[string]interface{} `json:"data,omitempty"`
+}
+
+// SimulationResult is the timeline of a simulated execution and how it ended
+type SimulationResult struct {
+	WorkflowID      uuid.UUID `json:"workflow_id"`
+	WorkflowVersion int       `json:"workflow_version"`
+	// MatchesTrigger reports whether the failure meets the workflow's trigger
+	// conditions; the simulation runs either way
+	MatchesTrigger bool       `json:"matches_trigger"`
+	StartedAt      time.Time  `json:"started_at"`
+	EndedAt        time.Time  `json:"ended_at"`
+	Status         string     `json:"status"`
+	Error          string     `json:"error,omitempty"`
+	WaitingUntil   *time.Time `json:"waiting_until,omitempty"`
+	// Outcomes are the settled steps' outcomes by step key
+	Outcomes map[string]string           `json:"outcomes"`
+	Path     []models.ExecutionPathEntry `json:"path"`
+	Timeline []SimulationEvent           `json:"timeline"`
+	Messages []RenderedMessage           `json:"messages"`
+}
+
+// SimulateWorkflow previews what a workflow would do for a payment failure. The
+// real step executors run against a dry run of their side effects: payment
+// retries and messages land on the timeline, messages rendered from the
+// company's templates but never sent, and nothing is written. Delays, send
+// times, waits and retry backoffs fast-forward a virtual clock. Steps that
+// become ready together run one after another, in step order.
+func (r *RecoveryOrchestrationService) SimulateWorkflow(ctx context.Context, workflowID, companyID uuid.UUID, sim WorkflowSimulation) (*SimulationResult, error) {
+	ctx, span := r.tracer.Start(ctx, "simulate_workflow")
+	defer span.End()
+
+	workflow, version, err := r.simulatedWorkflow(ctx, workflowID, companyID, sim.Version)
+	if err != nil {
+		return nil, err
+	}
+	graph, err := buildWorkflowGraph(workflow)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateScenarios(graph, sim.Scenarios); err != nil {
+		return nil, err
+	}
+	horizon := sim.HorizonDays
+	switch {
+	case horizon == 0:
+		horizon = defaultSimulationHorizonDays
+	case horizon < 0 || horizon > maxSimulationHorizonDays:
+		return nil, fmt.Errorf("%w: horizon_days: must be between 1 and %d", ErrInvalidSimulation, maxSimulationHorizonDays)
+	}
+	if sim.CustomerPaysAfterHours < 0 {
+		return nil, fmt.Errorf("%w: customer_pays_after_hours: must not be negative", ErrInvalidSimulation)
+	}
+
+	paymentFailure, err := r.simulatedPaymentFailure(ctx, companyID, sim)
+	if err != nil {
+		return nil, err
+	}
+	if r.segments != nil {
+		r.resolveCustomerSegments(ctx, paymentFailure)
+	}
+
+	start := time.Now()
+	if sim.StartAt != nil {
+		start = *sim.StartAt
+	}
+	run := &dryRun{
+		service:   r,
+		graph:     graph,
+		scenarios: sim.Scenarios,
+		now:       start,
+		end:       start.AddDate(0, 0, horizon),
+		attempts:  make(map[string]int),
+		sent:      make(map[string]*CommunicationResult),
+	}
+	if sim.CustomerPaysAfterHours > 0 {
+		paysAt := start.Add(time.Duration(sim.CustomerPaysAfterHours) * time.Hour)
+		run.paysAt = &paysAt
+	}
+	run.execution = &WorkflowExecution{
+		ID:               uuid.New(),
+		WorkflowID:       workflow.ID,
+		PaymentFailureID: paymentFailure.ID,
+		CompanyID:        companyID,
+		Status:           "running",
+		Context:          map[string]interface{}{"payment_failure": paymentFailure},
+		StartedAt:        start,
+		effects:          run,
+	}
+
+	result := &SimulationResult{
+		WorkflowID:      workflow.ID,
+		WorkflowVersion: version,
+		MatchesTrigger:  r.evaluateTriggerConditions(paymentFailure, workflow.TriggerConditions),
+		StartedAt:       start,
+	}
+	run.run(ctx, result)
+	return result, nil
+}
+
+// simulatedWorkflow loads the workflow with the steps of the version to simulate
+func (r *RecoveryOrchestrationService) simulatedWorkflow(ctx context.Context, workflowID, companyID uuid.UUID, version int) (*models.RecoveryWorkflow, int, error) {
+	workflow, err := r.GetWorkflow(ctx, workflowID, companyID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, 0, ErrWorkflowNotFound
+	}
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to load workflow: %w", err)
+	}
+	if version == 0 || version == workflow.CurrentVersion {
+		return workflow, workflow.CurrentVersion, nil
+	}
+
+	record, err := r.GetWorkflowVersion(ctx, workflowID, companyID, version)
+	if err != nil {
+		return nil, 0, err
+	}
+	workflow.TriggerConditions = record.TriggerConditions
+	workflow.Steps = record.Steps
+	return workflow, version, nil
+}
+
+// simulatedPaymentFailure loads the failure to simulate, or takes the
+// hypothetical one given
+func (r *RecoveryOrchestrationService) simulatedPaymentFailure(ctx context.Context, companyID uuid.UUID, sim WorkflowSimulation) (*models.PaymentFailureEvent, error) {
+	switch {
+	case sim.PaymentFailure != nil && sim.PaymentFailureID != nil:
+		return nil, fmt.Errorf("%w: give payment_failure_id or payment_failure, not both", ErrInvalidSimulation)
+	case sim.PaymentFailure != nil:
+		paymentFailure := *sim.PaymentFailure
+		if paymentFailure.ID == uuid.Nil {
+			paymentFailure.ID = uuid.New()
+		}
+		paymentFailure.CompanyID = companyID.String()
+		return &paymentFailure, nil
+	case sim.PaymentFailureID != nil:
+		var paymentFailure models.PaymentFailureEvent
+		err := r.db.WithContext(ctx).First(&paymentFailure, "id = ? AND company_id = ?", *sim.PaymentFailureID, companyID).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrPaymentFailureNotFound
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to load payment failure: %w", err)
+		}
+		return &paymentFailure, nil
+	}
+	return nil, fmt.Errorf("%w: payment_failure_id or payment_failure is required", ErrInvalidSimulation)
+}
+
+// validateScenarios checks that scenarios name steps of the workflow and
+// outcomes those steps can have
+func validateScenarios(graph *workflowGraph, scenarios map[string][]string) error {
+	stepTypes := make(map[string]string, len(graph.steps))
+	for i := range graph.steps {
+		stepTypes[stepKey(&graph.steps[i])] = graph.steps[i].StepType
+	}
+	for key, outcomes := range scenarios {
+		stepType, exists := stepTypes[key]
+		if !exists {
+			return fmt.Errorf("%w: scenarios.%s: no such step", ErrInvalidSimulation, key)
+		}
+		for n, outcome := range outcomes {
+			var allowed bool
+			switch outcome {
+			case ScenarioSuccess, ScenarioFailure, ScenarioTimeout:
+				allowed = true
+			case ScenarioBounce:
+				allowed = stepType == "send_email" || stepType == "send_sms"
+			case ScenarioTrue, ScenarioFalse:
+				allowed = stepType == "conditional"
+			default:
+				return fmt.Errorf("%w: scenarios.%s[%d]: unknown outcome %q", ErrInvalidSimulation, key, n, outcome)
+			}
+			if !allowed {
+				return fmt.Errorf("%w: scenarios.%s[%d]: %s steps cannot have outcome %q", ErrInvalidSimulation, key, n, stepType, outcome)
+			}
+		}
+	}
+	return nil
+}
+
+// dryRun is one simulated execution. It stands in for the step effects of the
+// execution, recording them on the timeline at the virtual time instead of
+// carrying them out.
+type dryRun struct {
+	service   *RecoveryOrchestrationService
+	graph     *workflowGraph
+	execution *WorkflowExecution
+	scenarios map[string][]string
+
+	// now is the virtual clock; end is the horizon it may not run past
+	now    time.Time
+	end    time.Time
+	paysAt *time.Time
+
+	// attempts counts the attempts run at each step by step ID
+	attempts map[string]int
+	// sent holds the messages sent by idempotency key
+	sent map[string]*CommunicationResult
+	// contacts are the times messages were sent at
+	contacts []time.Time
+	timeline []SimulationEvent
+	messages []RenderedMessage
+
+	// step and attempt are the attempt running; its effects start at
+	// timeline index effectsFrom
+	step        *models.RecoveryWorkflowStep
+	attempt     int
+	effectsFrom int
+	ids         int
+}
+
+// run drives the execution along its graph, fast-forwarding the clock to the
+// earliest parked branch whenever no step can run
+func (d *dryRun) run(ctx context.Context, result *SimulationResult) {
+	execution := d.execution
+	for {
+		d.graph.releaseDueWaits(execution, d.now)
+		ready := d.graph.ready(execution.outcomes, execution.waits)
+		if len(ready) == 0 {
+			wait := execution.nextWait()
+			if wait == nil {
+				result.Status = SimulationCompleted
+				break
+			}
+			if d.paysAt != nil && !d.paysAt.After(wait.Until) {
+				d.now = *d.paysAt
+				d.record(SimulationEvent{Type: SimulationEventCustomerPaid})
+				result.Status = SimulationRecovered
+				break
+			}
+			if wait.Until.After(d.end) {
+				d.now = d.end
+				until := wait.Until
+				result.Status = SimulationWaiting
+				result.WaitingUntil = &until
+				break
+			}
+			d.now = wait.Until
+			continue
+		}
+
+		failed := false
+		for _, i := range ready {
+			step := &d.graph.steps[i]
+			res := d.runStep(ctx, step)
+			if res.parkKind != "" {
+				until := res.parkUntil
+				d.record(SimulationEvent{
+					Type:     SimulationEventWait,
+					StepKey:  stepKey(step),
+					StepType: step.StepType,
+					WaitKind: res.parkKind,
+					Until:    &until,
+				})
+				execution.park(step, res.parkKind, res.parkUntil)
+				continue
+			}
+
+			execution.recordStepResult(step.ID, res.result)
+			outcome := stepOutcome(res.result, res.err)
+			execution.recordOutcome(step, outcome, d.now)
+			if d.graph.failsExecution(i, outcome) {
+				failed = true
+				execution.lastError = fmt.Sprintf("critical step %s: %v", stepKey(step), stepFailure(res.result, res.err))
+			}
+		}
+		if failed {
+			result.Status = SimulationFailed
+			result.Error = execution.lastError
+			break
+		}
+	}
+
+	result.EndedAt = d.now
+	result.Path = execution.path
+	result.Timeline = d.timeline
+	result.Messages = d.messages
+	result.Outcomes = make(map[string]string, len(execution.outcomes))
+	for i := range d.graph.steps {
+		if outcome, settled := execution.outcomes[d.graph.steps[i].ID.String()]; settled {
+			result.Outcomes[stepKey(&d.graph.steps[i])] = outcome
+		}
+	}
+}
+
+// runStep runs one ready step as runGraphStep and executeStep would, with the
+// attempt's scenario applied to what the executor returned
+func (d *dryRun) runStep(ctx context.Context, step *models.RecoveryWorkflowStep) roundResult {
+	if step.DelayMinutes > 0 && !d.execution.waitServed(step.ID, models.WorkflowTimerStepDelay) {
+		return roundResult{
+			parkKind:  models.WorkflowTimerStepDelay,
+			parkUntil: d.now.Add(time.Duration(step.DelayMinutes) * time.Minute),
+		}
+	}
+
+	d.service.mu.RLock()
+	executor, exists := d.service.stepExecutors[step.StepType]
+	d.service.mu.RUnlock()
+
+	id := step.ID.String()
+	d.step, d.attempt, d.effectsFrom = step, d.attempts[id]+1, len(d.timeline)
+	var result *StepResult
+	var err error
+	if exists {
+		result, err = executor.Execute(ctx, d.execution, step)
+	} else {
+		err = fmt.Errorf("no executor found for step type: %s", step.StepType)
+	}
+
+	// A deferred send is not an attempt; the step runs again at its send time
+	if err == nil && result != nil && result.RerunStep && result.WaitUntil != nil && result.WaitUntil.After(d.now) {
+		return roundResult{parkKind: models.WorkflowTimerSendTime, parkUntil: *result.WaitUntil}
+	}
+	d.attempts[id] = d.attempt
+
+	scenario := d.scenario(step)
+	result, err = d.inject(step, scenario, result, err)
+
+	event := SimulationEvent{
+		Type:     SimulationEventStep,
+		StepKey:  stepKey(step),
+		StepType: step.StepType,
+		Attempt:  d.attempt,
+		Scenario: scenario,
+	}
+	if result != nil {
+		event.Data = result.Data
+	}
+
+	res := roundResult{result: result, err: err}
+	class := stepErrorClass(result, err)
+	policy := stepRetryPolicy(step)
+	switch {
+	case class == "":
+	case retriesClass(policy, class) && d.attempt < policy.MaxAttempts:
+		var requested time.Duration
+		if result != nil {
+			requested = result.NextDelay
+		}
+		// Backoffs are simulated without jitter
+		res = roundResult{result: result, parkKind: models.WorkflowTimerStepRetry, parkUntil: d.now.Add(retryDelay(policy, d.attempt, requested, 0.5))}
+	case retriesClass(policy, class) && policy.MaxAttempts > 1:
+		res.err = fmt.Errorf("step failed after %d attempts: %w", d.attempt, stepFailure(result, err))
+	}
+	event.Outcome = stepOutcome(result, res.err)
+	if res.parkKind != "" {
+		event.Until = &res.parkUntil
+	}
+	if class != "" {
+		event.Error = stepFailure(result, res.err).Error()
+	}
+	d.record(event)
+
+	// Waits park the branch, settling the step when they are served
+	if res.parkKind == "" && res.err == nil && result != nil && result.WaitUntil != nil && result.WaitUntil.After(d.now) {
+		d.execution.recordStepResult(step.ID, result)
+		return roundResult{parkKind: models.WorkflowTimerWait, parkUntil: *result.WaitUntil}
+	}
+	return res
+}
+
+// scenario is the outcome injected into the running attempt at a step, if any
+func (d *dryRun) scenario(step *models.RecoveryWorkflowStep) string {
+	outcomes := d.scenarios[stepKey(step)]
+	if len(outcomes) == 0 {
+		return ""
+	}
+	return outcomes[min(d.attempt, len(outcomes))-1]
+}
+
+// inject makes an attempt end the way its scenario has it. The effects of an
+// attempt that did not succeed are marked on the timeline; a message that did
+// not go out may be sent again by a later attempt.
+func (d *dryRun) inject(step *models.RecoveryWorkflowStep, scenario string, result *StepResult, err error) (*StepResult, error) {
+	var data map[string]interface{}
+	if result != nil {
+		data = result.Data
+	}
+	switch scenario {
+	case ScenarioFailure:
+		result, err = &StepResult{ErrorMessage: "simulated failure", ShouldRetry: true, Data: data}, nil
+	case ScenarioBounce:
+		result, err = &StepResult{ErrorMessage: "simulated bounce", Data: data}, nil
+	case ScenarioTimeout:
+		err = fmt.Errorf("step timed out after %ds: %w", step.TimeoutSeconds, context.DeadlineExceeded)
+		result = &StepResult{Outcome: models.StepOutcomeTimeout, ErrorMessage: err.Error(), Data: data}
+	case ScenarioTrue, ScenarioFalse:
+		if result != nil {
+			overridden := *result
+			overridden.Outcome = scenario
+			result = &overridden
+		}
+		return result, err
+	default:
+		return result, err
+	}
+
+	for i := d.effectsFrom; i < len(d.timeline); i++ {
+		d.timeline[i].Outcome = scenario
+	}
+	if scenario != ScenarioBounce {
+		delete(d.sent, d.execution.stepKey(step.ID))
+	}
+	return result, err
+}
+
+// record appends an event to the timeline at the virtual time
+func (d *dryRun) record(event SimulationEvent) {
+	event.At = d.now
+	d.timeline = append(d.timeline, event)
+}
+
+// effect records a side effect of the running attempt
+func (d *dryRun) effect(eventType string, message *RenderedMessage, data map[string]interface{}) {
+	d.record(SimulationEvent{
+		Type:     eventType,
+		StepKey:  stepKey(d.step),
+		StepType: d.step.StepType,
+		Attempt:  d.attempt,
+		Outcome:  models.StepOutcomeSuccess,
+		Message:  message,
+		Data:     data,
+	})
+}
+
+// simulatedID makes up the ID of something the dry run did not create
+func (d *dryRun) simulatedID(kind string) string {
+	d.ids++
+	return fmt.Sprintf("sim-%s-%d", kind, d.ids)
+}
+
+func (d *dryRun) Now() time.Time {
+	return d.now
+}
+
+func (d *dryRun) SubmitRetry(ctx context.Context, idempotencyKey, companyID string, data map[string]interface{}) (*RetryJob, error) {
+	d.effect(SimulationEventPaymentRetry, nil, data)
+	return &RetryJob{
+		ID:        d.simulatedID("retry"),
+		JobType:   "payment_retry",
+		CompanyID: companyID,
+		Data:      data,
+		Status:    "pending",
+		CreatedAt: d.now,
+	}, nil
+}
+
+func (d *dryRun) SendEmail(ctx context.Context, req *CommunicationRequest) (*CommunicationResult, error) {
+	return d.send(ctx, SimulationEventEmail, req, d.service.communicationService.PreviewEmail)
+}
+
+func (d *dryRun) SendSMS(ctx context.Context, req *CommunicationRequest) (*CommunicationResult, error) {
+	return d.send(ctx, SimulationEventSMS, req, d.service.communicationService.PreviewSMS)
+}
+
+// send renders a message as it would be sent and records it
+func (d *dryRun) send(ctx context.Context, eventType string, req *CommunicationRequest, render func(context.Context, *CommunicationRequest) (*RenderedMessage, error)) (*CommunicationResult, error) {
+	if sent, exists := d.sent[req.IdempotencyKey]; exists {
+		return sent, nil
+	}
+	message, err := render(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	d.effect(eventType, message, nil)
+	d.messages = append(d.messages, *message)
+	d.contacts = append(d.contacts, d.now)
+
+	sent := &CommunicationResult{
+		MessageID:    d.simulatedID(eventType),
+		Status:       "sent",
+		TemplateUsed: message.TemplateUsed,
+		SentAt:       d.now,
+		Provider:     eventType,
+	}
+	if req.IdempotencyKey != "" {
+		d.sent[req.IdempotencyKey] = sent
+	}
+	return sent, nil
+}
+
+func (d *dryRun) FindSent(ctx context.Context, idempotencyKey string) (*CommunicationResult, error) {
+	return d.sent[idempotencyKey], nil
+}
+
+// CountRecentContacts counts the messages the simulation sent since then; the
+// customer's contacts from outside it are not counted
+func (d *dryRun) CountRecentContacts(ctx context.Context, companyID, provider, customerID string, since time.Time) (int64, error) {
+	var count int64
+	for _, at := range d.contacts {
+		if !at.Before(since) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (d *dryRun) RecordRecoveryAction(ctx context.Context, action *models.RecoveryAction) error {
+	action.ID = uuid.New()
+	return nil
+}
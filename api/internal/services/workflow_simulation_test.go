@@ -0,0 +1,179 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"gorm.io/gorm"
+
+	"github.com/sambitmohanty1/payment-watchdog/api/internal/models"
+)
+
+func simulationService(db *gorm.DB) *RecoveryOrchestrationService {
+	service := definitionService(db)
+	service.communicationService = &CommunicationService{db: db, tracer: otel.Tracer("test")}
+	return service
+}
+
+// expectSimulatedWorkflow expects the workflow to load: a reminder email, a
+// three day wait and a critical payment retry backing off from an hour
+func expectSimulatedWorkflow(mock sqlmock.Sqlmock, workflowID, companyID uuid.UUID, sendsEmail bool) {
+	mock.ExpectQuery(`SELECT \* FROM "recovery_workflows" WHERE id = \$1 AND company_id = \$2`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "company_id", "name", "current_version"}).
+			AddRow(workflowID, companyID, "Dunning", 1))
+	mock.ExpectQuery(`SELECT \* FROM "recovery_workflow_steps"`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "workflow_id", "step_order", "step_key", "step_type", "config", "retry_policy", "is_critical", "is_active"}).
+			AddRow(uuid.New(), workflowID, 1, "email", "send_email", `{"template_name":"reminder"}`, nil, false, true).
+			AddRow(uuid.New(), workflowID, 2, "wait", "wait", `{"wait_days":3}`, nil, false, true).
+			AddRow(uuid.New(), workflowID, 3, "retry", "retry_payment", `{"provider":"stripe"}`,
+				`{"max_attempts":3,"initial_delay_seconds":3600,"max_delay_seconds":86400,"backoff_multiplier":2}`, true, true))
+	if sendsEmail {
+		mock.ExpectQuery(`SELECT \* FROM "communication_templates" WHERE name = \$1 AND company_id = \$2`).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "company_id", "name", "template_type", "subject", "content", "is_active"}).
+				AddRow(uuid.New(), companyID, "reminder", "email", "Payment failed", "Hi {{.customer_name}}, {{.amount}} {{.currency}} is due", true))
+	}
+}
+
+// simulatedEvents lists a timeline as type, step key and outcome with the
+// time since the start
+func simulatedEvents(start time.Time, timeline []SimulationEvent) []string {
+	events := make([]string, len(timeline))
+	for i, event := range timeline {
+		events[i] = event.At.Sub(start).String() + " " + event.Type + " " + event.StepKey + " " + event.Outcome
+	}
+	return events
+}
+
+func TestSimulateWorkflowTimeline(t *testing.T) {
+	db, mock := mockDB(t)
+	workflowID, companyID := uuid.New(), uuid.New()
+	expectSimulatedWorkflow(mock, workflowID, companyID, true)
+
+	start := time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+	result, err := simulationService(db).SimulateWorkflow(context.Background(), workflowID, companyID, WorkflowSimulation{
+		PaymentFailure: &models.PaymentFailureEvent{CustomerName: "Ada", CustomerEmail: "ada@example.com", AmountCents: 4250, Currency: "USD"},
+		StartAt:        &start,
+		Scenarios:      map[string][]string{"retry": {ScenarioFailure, ScenarioFailure, ScenarioSuccess}},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, SimulationCompleted, result.Status)
+	assert.Equal(t, []string{
+		"0s email email success",
+		"0s step email success",
+		"0s step wait success",
+		"0s wait wait ",
+		"72h0m0s payment_retry retry failure",
+		"72h0m0s step retry failure",
+		"72h0m0s wait retry ",
+		"73h0m0s payment_retry retry failure",
+		"73h0m0s step retry failure",
+		"73h0m0s wait retry ",
+		"75h0m0s payment_retry retry success",
+		"75h0m0s step retry success",
+	}, simulatedEvents(start, result.Timeline))
+	assert.Equal(t, start.Add(75*time.Hour), result.EndedAt)
+	assert.Equal(t, map[string]string{"email": "success", "wait": "success", "retry": "success"}, result.Outcomes)
+
+	require.Len(t, result.Messages, 1)
+	assert.Equal(t, RenderedMessage{
+		Channel:      "email",
+		Recipient:    "ada@example.com",
+		Subject:      "Payment failed",
+		Body:         "Hi Ada, 42.5 USD is due",
+		TemplateUsed: "reminder",
+	}, result.Messages[0])
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSimulateWorkflowCustomerPays(t *testing.T) {
+	db, mock := mockDB(t)
+	workflowID, companyID := uuid.New(), uuid.New()
+	expectSimulatedWorkflow(mock, workflowID, companyID, true)
+
+	start := time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+	result, err := simulationService(db).SimulateWorkflow(context.Background(), workflowID, companyID, WorkflowSimulation{
+		PaymentFailure:         &models.PaymentFailureEvent{CustomerEmail: "ada@example.com"},
+		StartAt:                &start,
+		CustomerPaysAfterHours: 48,
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, SimulationRecovered, result.Status)
+	assert.Equal(t, start.Add(48*time.Hour), result.EndedAt)
+	assert.Equal(t, map[string]string{"email": "success"}, result.Outcomes)
+	last := result.Timeline[len(result.Timeline)-1]
+	assert.Equal(t, SimulationEventCustomerPaid, last.Type)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSimulateWorkflowExhaustsRetries(t *testing.T) {
+	db, mock := mockDB(t)
+	workflowID, companyID := uuid.New(), uuid.New()
+	expectSimulatedWorkflow(mock, workflowID, companyID, true)
+
+	start := time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+	result, err := simulationService(db).SimulateWorkflow(context.Background(), workflowID, companyID, WorkflowSimulation{
+		PaymentFailure: &models.PaymentFailureEvent{CustomerEmail: "ada@example.com"},
+		StartAt:        &start,
+		Scenarios: map[string][]string{
+			"email": {ScenarioBounce},
+			"retry": {ScenarioFailure},
+		},
+		HorizonDays: 2,
+	})
+	require.NoError(t, err)
+
+	// The wait outlasts the horizon
+	assert.Equal(t, SimulationWaiting, result.Status)
+	assert.Equal(t, start.AddDate(0, 0, 3), *result.WaitingUntil)
+	assert.Equal(t, map[string]string{"email": "failure"}, result.Outcomes)
+	assert.Equal(t, ScenarioBounce, result.Timeline[0].Outcome)
+	require.NoError(t, mock.ExpectationsWereMet())
+
+	expectSimulatedWorkflow(mock, workflowID, companyID, true)
+	result, err = simulationService(db).SimulateWorkflow(context.Background(), workflowID, companyID, WorkflowSimulation{
+		PaymentFailure: &models.PaymentFailureEvent{CustomerEmail: "ada@example.com"},
+		StartAt:        &start,
+		Scenarios: map[string][]string{
+			"email": {ScenarioBounce},
+			"retry": {ScenarioFailure},
+		},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, SimulationFailed, result.Status)
+	assert.Equal(t, "critical step retry: step failed after 3 attempts: simulated failure", result.Error)
+	assert.Equal(t, start.Add(75*time.Hour), result.EndedAt)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSimulateWorkflowValidatesScenarios(t *testing.T) {
+	db, mock := mockDB(t)
+	workflowID, companyID := uuid.New(), uuid.New()
+	service := simulationService(db)
+
+	for _, scenarios := range []map[string][]string{
+		{"fax": {ScenarioSuccess}},
+		{"wait": {ScenarioBounce}},
+		{"retry": {"declined"}},
+	} {
+		expectSimulatedWorkflow(mock, workflowID, companyID, false)
+		_, err := service.SimulateWorkflow(context.Background(), workflowID, companyID, WorkflowSimulation{
+			PaymentFailure: &models.PaymentFailureEvent{},
+			Scenarios:      scenarios,
+		})
+		assert.ErrorIs(t, err, ErrInvalidSimulation)
+	}
+
+	expectSimulatedWorkflow(mock, workflowID, companyID, false)
+	_, err := service.SimulateWorkflow(context.Background(), workflowID, companyID, WorkflowSimulation{})
+	assert.ErrorIs(t, err, ErrInvalidSimulation)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
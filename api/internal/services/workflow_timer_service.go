@@ -0,0 +1,185 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+
+	"github.com/sambitmohanty1/payment-watchdog/api/internal/models"
+)
+
+const (
+	// workflowTimerBatch caps how many due timers one poll fires
+	workflowTimerBatch = 100
+	// maxWorkflowTimerAttempts is how often a timer whose handler fails is
+	// retried before it is marked failed
+	maxWorkflowTimerAttempts = 5
+)
+
+// WorkflowTimerHandler resumes the execution a fired timer belongs to. An error
+// reschedules the timer with backoff.
+type WorkflowTimerHandler func(ctx context.Context, timer *models.WorkflowTimer) error
+
+// WorkflowTimerService persists the points at which waiting workflow executions
+// resume and fires them from a polling scheduler, so waits survive restarts and
+// hold no goroutine while pending
+type WorkflowTimerService struct {
+	db      *gorm.DB
+	logger  *zap.Logger
+	handler WorkflowTimerHandler
+}
+
+// NewWorkflowTimerService creates a new workflow timer service
+func NewWorkflowTimerService(db *gorm.DB, logger *zap.Logger, handler WorkflowTimerHandler) *WorkflowTimerService {
+	return &WorkflowTimerService{
+		db:      db,
+		logger:  logger,
+		handler: handler,
+	}
+}
+
+// Schedule persists a pending timer
+func (s *WorkflowTimerService) Schedule(ctx context.Context, timer *models.WorkflowTimer) error {
+	return s.ScheduleTx(s.db.WithContext(ctx), timer)
+}
+
+// ScheduleTx persists a pending timer in tx, so it commits together with the
+// execution's move to waiting
+func (s *WorkflowTimerService) ScheduleTx(tx *gorm.DB, timer *models.WorkflowTimer) error {
+	if timer.ID == uuid.Nil {
+		timer.ID = uuid.New()
+	}
+	timer.Status = models.WorkflowTimerPending
+	if err := tx.Create(timer).Error; err != nil {
+		return fmt.Errorf("failed to schedule workflow timer: %w", err)
+	}
+	return nil
+}
+
+// CancelExecution cancels the execution's pending timers
+func (s *WorkflowTimerService) CancelExecution(ctx context.Context, executionID uuid.UUID) error {
+	if err := s.db.WithContext(ctx).Model(&models.WorkflowTimer{}).
+		Where("workflow_execution_id = ? AND status = ?", executionID, models.WorkflowTimerPending).
+		Update("status", models.WorkflowTimerCancelled).Error; err != nil {
+		return fmt.Errorf("failed to cancel workflow timers: %w", err)
+	}
+	return nil
+}
+
+// PendingTimers returns the execution's pending timers, earliest first
+func (s *WorkflowTimerService) PendingTimers(ctx context.Context, executionID uuid.UUID) ([]models.WorkflowTimer, error) {
+	var timers []models.WorkflowTimer
+	if err := s.db.WithContext(ctx).
+		Where("workflow_execution_id = ? AND status = ?", executionID, models.WorkflowTimerPending).
+		Order("fire_at").
+		Find(&timers).Error; err != nil {
+		return nil, fmt.Errorf("failed to get workflow timers: %w", err)
+	}
+	return timers, nil
+}
+
+// FireDue fires every pending timer whose time has come. Each timer is claimed
+// by moving it out of pending first, so concurrent instances never fire the
+// same timer twice.
+func (s *WorkflowTimerService) FireDue(ctx context.Context) (int, error) {
+	now := time.Now()
+
+	var due []models.WorkflowTimer
+	if err := s.db.WithContext(ctx).
+		Where("status = ? AND fire_at <= ?", models.WorkflowTimerPending, now).
+		Order("fire_at").
+		Limit(workflowTimerBatch).
+		Find(&due).Error; err != nil {
+		return 0, fmt.Errorf("failed to find due workflow timers: %w", err)
+	}
+
+	fired := 0
+	for i := range due {
+		timer := &due[i]
+		claim := s.db.WithContext(ctx).Model(&models.WorkflowTimer{}).
+			Where("id = ? AND status = ?", timer.ID, models.WorkflowTimerPending).
+			Updates(map[string]interface{}{"status": models.WorkflowTimerFired, "fired_at": now})
+		if claim.Error != nil {
+			s.logger.Error("Failed to claim workflow timer",
+				zap.String("timer_id", timer.ID.String()),
+				zap.Error(claim.Error))
+			continue
+		}
+		if claim.RowsAffected == 0 {
+			continue
+		}
+
+		timer.Status = models.WorkflowTimerFired
+		timer.FiredAt = &now
+		if err := s.handler(ctx, timer); err != nil {
+			s.retry(ctx, timer, err, now)
+			continue
+		}
+		fired++
+	}
+	return fired, nil
+}
+
+// retry returns a timer whose handler failed to pending with backoff, or marks
+// it failed once its attempts are used up
+func (s *WorkflowTimerService) retry(ctx context.Context, timer *models.WorkflowTimer, cause error, now time.Time) {
+	timer.Attempts++
+	updates := map[string]interface{}{
+		"attempts":   timer.Attempts,
+		"last_error": cause.Error(),
+	}
+	if timer.Attempts >= maxWorkflowTimerAttempts {
+		updates["status"] = models.WorkflowTimerFailed
+		s.logger.Error("Workflow timer failed",
+			zap.String("timer_id", timer.ID.String()),
+			zap.String("execution_id", timer.WorkflowExecutionID.String()),
+			zap.Int("attempts", timer.Attempts),
+			zap.Error(cause))
+	} else {
+		updates["status"] = models.WorkflowTimerPending
+		updates["fire_at"] = now.Add(workflowTimerBackoff(timer.Attempts))
+		s.logger.Warn("Workflow timer handler failed, rescheduling",
+			zap.String("timer_id", timer.ID.String()),
+			zap.Int("attempts", timer.Attempts),
+			zap.Error(cause))
+	}
+	if err := s.db.WithContext(ctx).Model(&models.WorkflowTimer{}).
+		Where("id = ?", timer.ID).
+		Updates(updates).Error; err != nil {
+		s.logger.Error("Failed to reschedule workflow timer",
+			zap.String("timer_id", timer.ID.String()),
+			zap.Error(err))
+	}
+}
+
+// workflowTimerBackoff is the delay before a timer is retried after attempts failures
+func workflowTimerBackoff(attempts int) time.Duration {
+	delay := 30 * time.Second << uint(attempts-1)
+	if delay > 30*time.Minute {
+		delay = 30 * time.Minute
+	}
+	return delay
+}
+
+// Start polls for due timers every interval until ctx is cancelled
+func (s *WorkflowTimerService) Start(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if _, err := s.FireDue(ctx); err != nil {
+					s.logger.Error("Workflow timer scheduler run failed", zap.Error(err))
+				}
+			}
+		}
+	}()
+}
@@ -0,0 +1,122 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+
+	"github.com/sambitmohanty1/payment-watchdog/api/internal/models"
+)
+
+func mockDB(t *testing.T) (*gorm.DB, sqlmock.Sqlmock) {
+	sqlDB, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	t.Cleanup(func() { sqlDB.Close() })
+
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: sqlDB}), &gorm.Config{SkipDefaultTransaction: true})
+	require.NoError(t, err)
+	return db, mock
+}
+
+func TestFireDueWorkflowTimers(t *testing.T) {
+	db, mock := mockDB(t)
+	won, lost, failing := uuid.New(), uuid.New(), uuid.New()
+	executionID := uuid.New()
+
+	var handled []uuid.UUID
+	service := NewWorkflowTimerService(db, zap.NewNop(), func(ctx context.Context, timer *models.WorkflowTimer) error {
+		handled = append(handled, timer.ID)
+		if timer.ID == failing {
+			return errors.New("database unavailable")
+		}
+		return nil
+	})
+
+	rows := sqlmock.NewRows([]string{"id", "workflow_execution_id", "kind", "status", "attempts"}).
+		AddRow(won, executionID, models.WorkflowTimerWait, models.WorkflowTimerPending, 0).
+		AddRow(lost, executionID, models.WorkflowTimerWait, models.WorkflowTimerPending, 0).
+		AddRow(failing, executionID, models.WorkflowTimerSendTime, models.WorkflowTimerPending, 1)
+	mock.ExpectQuery(`SELECT \* FROM "workflow_timers" WHERE status = \$1 AND fire_at <= \$2 ORDER BY fire_at LIMIT \$3`).
+		WithArgs(models.WorkflowTimerPending, sqlmock.AnyArg(), workflowTimerBatch).
+		WillReturnRows(rows)
+
+	claim := `UPDATE "workflow_timers" SET "fired_at"=\$1,"status"=\$2,"updated_at"=\$3 WHERE id = \$4 AND status = \$5`
+	mock.ExpectExec(claim).
+		WithArgs(sqlmock.AnyArg(), models.WorkflowTimerFired, sqlmock.AnyArg(), won, models.WorkflowTimerPending).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	// Another instance claimed this one first
+	mock.ExpectExec(claim).
+		WithArgs(sqlmock.AnyArg(), models.WorkflowTimerFired, sqlmock.AnyArg(), lost, models.WorkflowTimerPending).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(claim).
+		WithArgs(sqlmock.AnyArg(), models.WorkflowTimerFired, sqlmock.AnyArg(), failing, models.WorkflowTimerPending).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	// A failed handler puts the timer back with backoff
+	mock.ExpectExec(`UPDATE "workflow_timers" SET "attempts"=\$1,"fire_at"=\$2,"last_error"=\$3,"status"=\$4,"updated_at"=\$5 WHERE id = \$6`).
+		WithArgs(2, sqlmock.AnyArg(), "database unavailable", models.WorkflowTimerPending, sqlmock.AnyArg(), failing).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	fired, err := service.FireDue(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 1, fired)
+	assert.Equal(t, []uuid.UUID{won, failing}, handled)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestWorkflowTimerBackoff(t *testing.T) {
+	assert.Equal(t, 30*time.Second, workflowTimerBackoff(1))
+	assert.Equal(t, 2*time.Minute, workflowTimerBackoff(3))
+	assert.Equal(t, 30*time.Minute, workflowTimerBackoff(12))
+}
+
+func TestWaitStepSuspendsInsteadOfSleeping(t *testing.T) {
+	executor := &WaitExecutor{service: &RecoveryOrchestrationService{}}
+	step := &models.RecoveryWorkflowStep{ID: uuid.New(), StepType: "wait"}
+	step.Config, _ = json.Marshal(map[string]interface{}{"wait_days": 3, "reason": "grace period"})
+
+	before := time.Now()
+	result, err := executor.Execute(context.Background(), &WorkflowExecution{ID: uuid.New()}, step)
+	require.NoError(t, err)
+	assert.Less(t, time.Since(before), time.Second, "the wait must not block")
+
+	require.True(t, result.Success)
+	require.NotNil(t, result.WaitUntil)
+	assert.WithinDuration(t, before.Add(72*time.Hour), *result.WaitUntil, time.Second)
+	assert.False(t, result.RerunStep)
+	assert.Equal(t, "grace period", result.Data["reason"])
+}
+
+func TestWaitServed(t *testing.T) {
+	step, other := uuid.New(), uuid.New()
+	fresh := &WorkflowExecution{}
+	assert.False(t, fresh.waitServed(step, models.WorkflowTimerStepDelay))
+
+	delayed := &WorkflowExecution{served: map[string]string{step.String(): models.WorkflowTimerStepDelay}}
+	assert.True(t, delayed.waitServed(step, models.WorkflowTimerStepDelay))
+	assert.False(t, delayed.waitServed(step, models.WorkflowTimerSendTime), "the send time is still ahead")
+	assert.False(t, delayed.waitServed(other, models.WorkflowTimerStepDelay))
+
+	sent := &WorkflowExecution{served: map[string]string{step.String(): models.WorkflowTimerSendTime}}
+	assert.True(t, sent.waitServed(step, models.WorkflowTimerSendTime))
+	assert.True(t, sent.waitServed(step, models.WorkflowTimerStepDelay), "the delay came before the send time")
+}
+
+func TestOrderedSteps(t *testing.T) {
+	workflow := &models.RecoveryWorkflow{Steps: []models.RecoveryWorkflowStep{
+		{StepType: "send_sms", StepOrder: 3, IsActive: true},
+		{StepType: "wait", StepOrder: 2, IsActive: true},
+		{StepType: "retry_payment", StepOrder: 4, IsActive: false},
+		{StepType: "send_email", StepOrder: 1, IsActive: true},
+	}}
+	assert.Equal(t, []string{"send_email", "wait", "send_sms"}, workflowStepTypes(*workflow))
+}
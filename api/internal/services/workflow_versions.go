@@ -0,0 +1,469 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/sambitmohanty1/payment-watchdog/api/internal/models"
+)
+
+var (
+	// ErrWorkflowNotFound is returned for workflows the company does not have
+	ErrWorkflowNotFound = errors.New("workflow not found")
+	// ErrWorkflowVersionNotFound is returned for a version a workflow does not have
+	ErrWorkflowVersionNotFound = errors.New("workflow version not found")
+	// ErrInvalidExecutionMigration is returned when executions cannot be moved
+	// between the requested versions with the given step mapping
+	ErrInvalidExecutionMigration = errors.New("invalid execution migration")
+)
+
+// currentVersionSteps selects the active steps of their workflow's current
+// version, for preloading a workflow's steps
+const currentVersionSteps = "is_active = ? AND version = (SELECT current_version FROM recovery_workflows WHERE recovery_workflows.id = recovery_workflow_steps.workflow_id)"
+
+// migratableStatuses are the execution statuses a migration applies to. Running
+// executions are only migrated once their lease has lapsed.
+var migratableStatuses = []string{"pending", "running", "waiting", "paused"}
+
+// WorkflowVersionChange describes the next version of a workflow. Nil steps or
+// trigger conditions carry over from the current version.
+type WorkflowVersionChange struct {
+	Steps             []models.RecoveryWorkflowStep
+	TriggerConditions datatypes.JSON
+	ChangeNote        string
+	CreatedBy         string
+}
+
+// ExecutionMigration moves in-flight executions from one version of a workflow
+// to another. StepMapping maps step keys of the old version to keys of the new
+// one; unmapped keys map to the same key, and a key mapped to "" is dropped.
+type ExecutionMigration struct {
+	FromVersion  int               `json:"from_version"`
+	ToVersion    int               `json:"to_version"`
+	StepMapping  map[string]string `json:"step_mapping,omitempty"`
+	ExecutionIDs []uuid.UUID       `json:"execution_ids,omitempty"` // all executions on FromVersion when empty
+}
+
+// ExecutionMigrationResult lists the executions moved to the new version, and
+// why the others were left on the old one
+type ExecutionMigrationResult struct {
+	Migrated []uuid.UUID          `json:"migrated"`
+	Skipped  map[uuid.UUID]string `json:"skipped,omitempty"`
+}
+
+// versionSteps loads the active steps of a workflow version
+func versionSteps(db *gorm.DB, workflowID uuid.UUID, version int) ([]models.RecoveryWorkflowStep, error) {
+	var steps []models.RecoveryWorkflowStep
+	if err := db.Where("workflow_id = ? AND version = ? AND is_active = ?", workflowID, version, true).
+		Order("step_order").
+		Find(&steps).Error; err != nil {
+		return nil, fmt.Errorf("failed to load workflow steps: %w", err)
+	}
+	return steps, nil
+}
+
+// ListWorkflowVersions returns a workflow's version history, newest first
+func (r *RecoveryOrchestrationService) ListWorkflowVersions(ctx context.Context, workflowID, companyID uuid.UUID) ([]models.RecoveryWorkflowVersion, error) {
+	var versions []models.RecoveryWorkflowVersion
+	if err := r.db.WithContext(ctx).
+		Where("workflow_id = ? AND company_id = ?", workflowID, companyID).
+		Order("version DESC").
+		Find(&versions).Error; err != nil {
+		return nil, fmt.Errorf("failed to list workflow versions: %w", err)
+	}
+	return versions, nil
+}
+
+// GetWorkflowVersion returns one version of a workflow with its steps
+func (r *RecoveryOrchestrationService) GetWorkflowVersion(ctx context.Context, workflowID, companyID uuid.UUID, version int) (*models.RecoveryWorkflowVersion, error) {
+	var record models.RecoveryWorkflowVersion
+	err := r.db.WithContext(ctx).
+		Where("workflow_id = ? AND company_id = ? AND version = ?", workflowID, companyID, version).
+		First(&record).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("%w: %d", ErrWorkflowVersionNotFound, version)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load workflow version: %w", err)
+	}
+	if record.Steps, err = versionSteps(r.db.WithContext(ctx), workflowID, version); err != nil {
+		return nil, err
+	}
+	return &record, nil
+}
+
+// PublishWorkflowVersion makes change the workflow's current version. The steps
+// are copied with new IDs, so executions already running keep the steps of the
+// version they started on; new executions start on the published version.
+func (r *RecoveryOrchestrationService) PublishWorkflowVersion(ctx context.Context, workflowID, companyID uuid.UUID, change WorkflowVersionChange) (*models.RecoveryWorkflowVersion, error) {
+	ctx, span := r.tracer.Start(ctx, "publish_workflow_version")
+	defer span.End()
+
+	var published *models.RecoveryWorkflowVersion
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var err error
+		published, err = publishVersion(tx, workflowID, companyID, change)
+		return err
+	})
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+	return published, nil
+}
+
+// publishVersion publishes the next version of a workflow within tx. The
+// workflow row stays locked until tx ends, so concurrent publishes are
+// numbered one after the other.
+func publishVersion(tx *gorm.DB, workflowID, companyID uuid.UUID, change WorkflowVersionChange) (*models.RecoveryWorkflowVersion, error) {
+	var workflow models.RecoveryWorkflow
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ? AND company_id = ?", workflowID, companyID).
+		First(&workflow).Error; errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrWorkflowNotFound
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to lock workflow: %w", err)
+	}
+
+	steps := change.Steps
+	if steps == nil {
+		current, err := versionSteps(tx, workflow.ID, workflow.CurrentVersion)
+		if err != nil {
+			return nil, err
+		}
+		// Copies keep their keys, so a migration maps them one to one
+		for i := range current {
+			current[i].Key = stepKey(&current[i])
+		}
+		steps = current
+	}
+	conditions := change.TriggerConditions
+	if conditions == nil {
+		conditions = workflow.TriggerConditions
+	}
+
+	candidate := &models.RecoveryWorkflow{ID: workflow.ID, CompanyID: workflow.CompanyID, TriggerConditions: conditions, Steps: steps}
+	if err := ValidateWorkflowConditions(candidate); err != nil {
+		return nil, err
+	}
+	if err := ValidateWorkflowGraph(candidate); err != nil {
+		return nil, err
+	}
+	if err := ValidateStepRetryPolicies(candidate); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	version := workflow.CurrentVersion + 1
+	for i := range steps {
+		steps[i].Key = stepKey(&steps[i])
+		steps[i].ID = uuid.New()
+		steps[i].WorkflowID = workflow.ID
+		steps[i].Version = version
+		steps[i].CreatedAt = now
+		steps[i].UpdatedAt = now
+	}
+	if len(steps) > 0 {
+		if err := tx.Create(&steps).Error; err != nil {
+			return nil, fmt.Errorf("failed to create workflow steps: %w", err)
+		}
+	}
+
+	record := &models.RecoveryWorkflowVersion{
+		ID:                uuid.New(),
+		WorkflowID:        workflow.ID,
+		CompanyID:         workflow.CompanyID,
+		Version:           version,
+		Name:              workflow.Name,
+		Description:       workflow.Description,
+		TriggerConditions: conditions,
+		ChangeNote:        change.ChangeNote,
+		CreatedBy:         change.CreatedBy,
+		CreatedAt:         now,
+	}
+	if err := tx.Create(record).Error; err != nil {
+		return nil, fmt.Errorf("failed to record workflow version: %w", err)
+	}
+	if err := tx.Model(&models.RecoveryWorkflow{}).
+		Where("id = ?", workflow.ID).
+		Updates(map[string]interface{}{
+			"current_version":    version,
+			"trigger_conditions": conditions,
+			"updated_at":         now,
+		}).Error; err != nil {
+		return nil, fmt.Errorf("failed to update workflow version: %w", err)
+	}
+
+	record.Steps = steps
+	return record, nil
+}
+
+// MigrateExecutions moves the workflow's in-flight executions from one version
+// to another, translating their checkpoints, parked timers and step history to
+// the new version's steps. Steps of the new version without a counterpart run
+// once their predecessors have settled, including ones that already had; steps
+// whose counterpart already ran are not run again. Executions parked on a step
+// that has no counterpart, or running on a worker, are skipped.
+func (r *RecoveryOrchestrationService) MigrateExecutions(ctx context.Context, workflowID, companyID uuid.UUID, migration ExecutionMigration) (*ExecutionMigrationResult, error) {
+	ctx, span := r.tracer.Start(ctx, "migrate_workflow_executions")
+	defer span.End()
+
+	if migration.FromVersion == migration.ToVersion {
+		return nil, fmt.Errorf("%w: from_version and to_version are both %d", ErrInvalidExecutionMigration, migration.FromVersion)
+	}
+	from, err := r.GetWorkflowVersion(ctx, workflowID, companyID, migration.FromVersion)
+	if err != nil {
+		return nil, err
+	}
+	to, err := r.GetWorkflowVersion(ctx, workflowID, companyID, migration.ToVersion)
+	if err != nil {
+		return nil, err
+	}
+	m, err := newVersionMigration(from, to, migration.StepMapping)
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+
+	query := r.db.WithContext(ctx).Model(&models.RecoveryWorkflowExecution{}).
+		Where("workflow_id = ? AND company_id = ? AND workflow_version = ? AND status IN ?",
+			workflowID, companyID, migration.FromVersion, migratableStatuses)
+	if len(migration.ExecutionIDs) > 0 {
+		query = query.Where("id IN ?", migration.ExecutionIDs)
+	}
+	var executionIDs []uuid.UUID
+	if err := query.Pluck("id", &executionIDs).Error; err != nil {
+		return nil, fmt.Errorf("failed to find executions to migrate: %w", err)
+	}
+
+	result := &ExecutionMigrationResult{Migrated: []uuid.UUID{}, Skipped: map[uuid.UUID]string{}}
+	for _, executionID := range executionIDs {
+		reason, err := r.migrateExecution(ctx, executionID, m)
+		if err != nil {
+			span.RecordError(err)
+			return result, err
+		}
+		if reason != "" {
+			result.Skipped[executionID] = reason
+			continue
+		}
+		result.Migrated = append(result.Migrated, executionID)
+	}
+	return result, nil
+}
+
+// versionMigration maps the steps of one workflow version onto another
+type versionMigration struct {
+	from, to  int
+	fromGraph *workflowGraph
+	toSteps   int
+	// steps maps old step IDs to their counterparts; dropped steps map to nil
+	steps    map[uuid.UUID]*models.RecoveryWorkflowStep
+	oldSteps []uuid.UUID
+}
+
+// newVersionMigration resolves mapping between the steps of two versions
+func newVersionMigration(from, to *models.RecoveryWorkflowVersion, mapping map[string]string) (*versionMigration, error) {
+	fromGraph, err := buildWorkflowGraph(&models.RecoveryWorkflow{Steps: from.Steps})
+	if err != nil {
+		return nil, err
+	}
+	oldKeys := make(map[string]bool, len(from.Steps))
+	for i := range from.Steps {
+		oldKeys[stepKey(&from.Steps[i])] = true
+	}
+	for oldKey := range mapping {
+		if !oldKeys[oldKey] {
+			return nil, fmt.Errorf("%w: step_mapping: version %d has no step %q", ErrInvalidExecutionMigration, from.Version, oldKey)
+		}
+	}
+	newSteps := make(map[string]*models.RecoveryWorkflowStep, len(to.Steps))
+	for i := range to.Steps {
+		newSteps[stepKey(&to.Steps[i])] = &to.Steps[i]
+	}
+
+	m := &versionMigration{
+		from:      from.Version,
+		to:        to.Version,
+		fromGraph: fromGraph,
+		toSteps:   len(to.Steps),
+		steps:     make(map[uuid.UUID]*models.RecoveryWorkflowStep, len(from.Steps)),
+	}
+	mappedFrom := make(map[string]string, len(from.Steps))
+	for i := range from.Steps {
+		m.oldSteps = append(m.oldSteps, from.Steps[i].ID)
+		oldKey := stepKey(&from.Steps[i])
+		newKey, explicit := mapping[oldKey]
+		if !explicit {
+			newKey = oldKey
+		}
+		if newKey == "" {
+			m.steps[from.Steps[i].ID] = nil
+			continue
+		}
+		step, ok := newSteps[newKey]
+		if !ok {
+			if explicit {
+				return nil, fmt.Errorf("%w: step_mapping.%s: version %d has no step %q", ErrInvalidExecutionMigration, oldKey, to.Version, newKey)
+			}
+			m.steps[from.Steps[i].ID] = nil
+			continue
+		}
+		if previous, taken := mappedFrom[newKey]; taken {
+			return nil, fmt.Errorf("%w: step_mapping: %q and %q both map to %q", ErrInvalidExecutionMigration, previous, oldKey, newKey)
+		}
+		mappedFrom[newKey] = oldKey
+		m.steps[from.Steps[i].ID] = step
+	}
+	return m, nil
+}
+
+// counterpart returns the new step an old step ID maps to, or nil
+func (m *versionMigration) counterpart(stepID string) *models.RecoveryWorkflowStep {
+	id, err := uuid.Parse(stepID)
+	if err != nil {
+		return nil
+	}
+	return m.steps[id]
+}
+
+// checkpoint translates a checkpoint of the old version to the new one.
+// Checkpoints written before workflow graphs are converted first. Outcomes and
+// results of dropped steps are discarded; a branch parked on one cannot be
+// translated.
+func (m *versionMigration) checkpoint(cp *executionCheckpoint) (*executionCheckpoint, error) {
+	execution := restoreExecution(&models.RecoveryWorkflowExecution{}, cp)
+	if len(execution.outcomes) == 0 && execution.CurrentStepIndex > 0 {
+		m.fromGraph.adoptLinearCheckpoint(execution, execution.CurrentStepIndex)
+	}
+	old := execution.checkpoint()
+
+	translated := &executionCheckpoint{Variables: old.Variables}
+	for stepID, wait := range old.Waits {
+		step := m.counterpart(stepID)
+		if step == nil {
+			return nil, fmt.Errorf("parked on step %s, which has no counterpart in version %d", stepID, m.to)
+		}
+		if translated.Waits == nil {
+			translated.Waits = make(map[string]*checkpointWait)
+		}
+		translated.Waits[step.ID.String()] = &checkpointWait{StepID: step.ID, Kind: wait.Kind, Until: wait.Until}
+	}
+	for stepID, outcome := range old.Outcomes {
+		if step := m.counterpart(stepID); step != nil {
+			if translated.Outcomes == nil {
+				translated.Outcomes = make(map[string]string)
+			}
+			translated.Outcomes[step.ID.String()] = outcome
+		}
+	}
+	for stepID, result := range old.StepResults {
+		if step := m.counterpart(stepID); step != nil {
+			if translated.StepResults == nil {
+				translated.StepResults = make(map[string]*StepResult)
+			}
+			translated.StepResults[step.ID.String()] = result
+		}
+	}
+	return translated, nil
+}
+
+// migrateExecution moves one execution to the new version, returning why it
+// was skipped if it was
+func (r *RecoveryOrchestrationService) migrateExecution(ctx context.Context, executionID uuid.UUID, m *versionMigration) (string, error) {
+	var reason string
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var record models.RecoveryWorkflowExecution
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			First(&record, "id = ?", executionID).Error; err != nil {
+			return fmt.Errorf("failed to lock execution: %w", err)
+		}
+		switch {
+		case record.WorkflowVersion != m.from:
+			reason = fmt.Sprintf("no longer on version %d", m.from)
+			return nil
+		case record.Status == "running" && record.LeaseExpiresAt != nil && record.LeaseExpiresAt.After(time.Now()):
+			reason = "running on a worker; migrate it once it suspends"
+			return nil
+		case !slices.Contains(migratableStatuses, record.Status):
+			reason = "already " + record.Status
+			return nil
+		}
+
+		var timers []models.WorkflowTimer
+		if err := tx.Where("workflow_execution_id = ? AND status IN ?", executionID,
+			[]string{models.WorkflowTimerPending, models.WorkflowTimerFired}).
+			Find(&timers).Error; err != nil {
+			return fmt.Errorf("failed to load execution timers: %w", err)
+		}
+		for _, timer := range timers {
+			if m.steps[timer.StepID] == nil {
+				reason = fmt.Sprintf("has a timer on step %s, which has no counterpart in version %d", timer.StepID, m.to)
+				return nil
+			}
+		}
+
+		updates := map[string]interface{}{
+			"workflow_version": m.to,
+			"total_steps":      m.toSteps,
+			"current_step_id":  nil,
+		}
+		if record.CurrentStepID != nil {
+			if step := m.steps[*record.CurrentStepID]; step != nil {
+				updates["current_step_id"] = step.ID
+			}
+		}
+		cp, err := decodeCheckpoint(record.Checkpoint)
+		if err != nil {
+			return err
+		}
+		if cp != nil {
+			translated, err := m.checkpoint(cp)
+			if err != nil {
+				reason = err.Error()
+				return nil
+			}
+			data, err := json.Marshal(translated)
+			if err != nil {
+				return fmt.Errorf("failed to encode execution checkpoint: %w", err)
+			}
+			updates["checkpoint"] = datatypes.JSON(data)
+		}
+
+		for _, timer := range timers {
+			if err := tx.Model(&models.WorkflowTimer{}).Where("id = ?", timer.ID).
+				Update("step_id", m.steps[timer.StepID].ID).Error; err != nil {
+				return fmt.Errorf("failed to move execution timer: %w", err)
+			}
+		}
+		// Attempts follow their step, so retries keep counting and finished
+		// steps replay instead of running again
+		for _, oldID := range m.oldSteps {
+			step := m.steps[oldID]
+			if step == nil {
+				continue
+			}
+			if err := tx.Model(&models.RecoveryStepExecution{}).
+				Where("workflow_execution_id = ? AND step_id = ?", executionID, oldID).
+				Update("step_id", step.ID).Error; err != nil {
+				return fmt.Errorf("failed to move step executions: %w", err)
+			}
+		}
+		if err := tx.Model(&models.RecoveryWorkflowExecution{}).
+			Where("id = ?", executionID).
+			Updates(updates).Error; err != nil {
+			return fmt.Errorf("failed to migrate execution: %w", err)
+		}
+		return nil
+	})
+	return reason, err
+}
@@ -0,0 +1,218 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+
+	"github.com/sambitmohanty1/payment-watchdog/api/internal/models"
+)
+
+// twoVersions is a workflow whose second version renames "sms" to "text",
+// drops "retry" and adds "escalate"
+func twoVersions() (*models.RecoveryWorkflowVersion, *models.RecoveryWorkflowVersion) {
+	from := &models.RecoveryWorkflowVersion{Version: 1, Steps: []models.RecoveryWorkflowStep{
+		graphStep(1, "email", models.StepTransitions{}),
+		graphStep(2, "sms", models.StepTransitions{}),
+		graphStep(3, "retry", models.StepTransitions{}),
+	}}
+	to := &models.RecoveryWorkflowVersion{Version: 2, Steps: []models.RecoveryWorkflowStep{
+		graphStep(1, "email", models.StepTransitions{}),
+		graphStep(2, "text", models.StepTransitions{}),
+		graphStep(3, "escalate", models.StepTransitions{}),
+	}}
+	return from, to
+}
+
+func TestNewVersionMigration(t *testing.T) {
+	from, to := twoVersions()
+
+	m, err := newVersionMigration(from, to, map[string]string{"sms": "text"})
+	require.NoError(t, err)
+	assert.Equal(t, to.Steps[0].ID, m.steps[from.Steps[0].ID].ID, "unmapped keys keep their key")
+	assert.Equal(t, to.Steps[1].ID, m.steps[from.Steps[1].ID].ID)
+	assert.Nil(t, m.steps[from.Steps[2].ID], "a step without a counterpart is dropped")
+
+	m, err = newVersionMigration(from, to, map[string]string{"email": "", "sms": "email"})
+	require.NoError(t, err)
+	assert.Nil(t, m.steps[from.Steps[0].ID])
+	assert.Equal(t, to.Steps[0].ID, m.steps[from.Steps[1].ID].ID)
+
+	for name, mapping := range map[string]map[string]string{
+		"unknown old step": {"call": "text"},
+		"unknown new step": {"sms": "call"},
+		"shared new step":  {"sms": "email"},
+	} {
+		_, err := newVersionMigration(from, to, mapping)
+		assert.ErrorIs(t, err, ErrInvalidExecutionMigration, name)
+	}
+}
+
+func TestVersionMigrationCheckpoint(t *testing.T) {
+	from, to := twoVersions()
+	m, err := newVersionMigration(from, to, map[string]string{"sms": "text"})
+	require.NoError(t, err)
+	until := time.Now().Add(time.Hour)
+
+	cp := &executionCheckpoint{
+		Outcomes: map[string]string{
+			from.Steps[0].ID.String(): models.StepOutcomeSuccess,
+			from.Steps[2].ID.String(): models.StepOutcomeFailure,
+		},
+		Waits: map[string]*checkpointWait{
+			from.Steps[1].ID.String(): {StepID: from.Steps[1].ID, Kind: models.WorkflowTimerStepDelay, Until: until},
+		},
+		StepResults: map[string]*StepResult{from.Steps[0].ID.String(): {Success: true, ExternalID: "msg_1"}},
+		Variables:   map[string]interface{}{"attempts": float64(1)},
+	}
+	translated, err := m.checkpoint(cp)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{to.Steps[0].ID.String(): models.StepOutcomeSuccess}, translated.Outcomes)
+	require.Contains(t, translated.Waits, to.Steps[1].ID.String())
+	assert.Equal(t, to.Steps[1].ID, translated.Waits[to.Steps[1].ID.String()].StepID)
+	assert.Equal(t, "msg_1", translated.StepResults[to.Steps[0].ID.String()].ExternalID)
+	assert.Equal(t, cp.Variables, translated.Variables)
+
+	// Parked on a step the new version drops
+	_, err = m.checkpoint(&executionCheckpoint{Waits: map[string]*checkpointWait{
+		from.Steps[2].ID.String(): {StepID: from.Steps[2].ID, Kind: models.WorkflowTimerWait, Until: until},
+	}})
+	assert.ErrorContains(t, err, "no counterpart in version 2")
+
+	// Written before workflow graphs: the first step ran and the second waits
+	translated, err = m.checkpoint(&executionCheckpoint{
+		NextStepIndex: 2,
+		Wait:          &checkpointWait{StepID: from.Steps[1].ID, Kind: models.WorkflowTimerWait, Until: until},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{to.Steps[0].ID.String(): models.StepOutcomeSuccess}, translated.Outcomes)
+	assert.Contains(t, translated.Waits, to.Steps[1].ID.String())
+	assert.Zero(t, translated.NextStepIndex)
+	assert.Nil(t, translated.Wait)
+}
+
+func TestPublishWorkflowVersionCopiesCurrentSteps(t *testing.T) {
+	db, mock := mockDB(t)
+	service := &RecoveryOrchestrationService{db: db, tracer: otel.Tracer("test")}
+	workflowID, companyID := uuid.New(), uuid.New()
+	emailID, smsID := uuid.New(), uuid.New()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT \* FROM "recovery_workflows" WHERE id = \$1 AND company_id = \$2 .* FOR UPDATE`).
+		WithArgs(workflowID, companyID, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "company_id", "name", "current_version", "trigger_conditions"}).
+			AddRow(workflowID, companyID, "Dunning", 2, `{"logic":"AND"}`))
+	mock.ExpectQuery(`SELECT \* FROM "recovery_workflow_steps" WHERE workflow_id = \$1 AND version = \$2 AND is_active = \$3 ORDER BY step_order`).
+		WithArgs(workflowID, 2, true).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "workflow_id", "version", "step_order", "step_type", "step_key", "is_active"}).
+			AddRow(emailID, workflowID, 2, 1, "send_email", "email", true).
+			AddRow(smsID, workflowID, 2, 2, "send_sms", "", true))
+	mock.ExpectQuery(`INSERT INTO "recovery_workflow_steps"`).
+		WillReturnRows(sqlmock.NewRows([]string{"delay_minutes"}).AddRow(0).AddRow(0))
+	mock.ExpectQuery(`INSERT INTO "recovery_workflow_versions"`).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(uuid.New()))
+	mock.ExpectExec(`UPDATE "recovery_workflows" SET "current_version"=\$1,"trigger_conditions"=\$2,"updated_at"=\$3 WHERE id = \$4`).
+		WithArgs(3, sqlmock.AnyArg(), sqlmock.AnyArg(), workflowID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	version, err := service.PublishWorkflowVersion(context.Background(), workflowID, companyID, WorkflowVersionChange{ChangeNote: "Republish"})
+	require.NoError(t, err)
+	assert.Equal(t, 3, version.Version)
+	assert.JSONEq(t, `{"logic":"AND"}`, string(version.TriggerConditions))
+	require.Len(t, version.Steps, 2)
+	for _, step := range version.Steps {
+		assert.NotContains(t, []uuid.UUID{emailID, smsID}, step.ID, "steps of the old version are left untouched")
+		assert.Equal(t, 3, step.Version)
+	}
+	assert.Equal(t, []string{"email", "step_2"}, []string{version.Steps[0].Key, version.Steps[1].Key})
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPublishWorkflowVersionValidates(t *testing.T) {
+	db, mock := mockDB(t)
+	service := &RecoveryOrchestrationService{db: db, tracer: otel.Tracer("test")}
+	workflowID, companyID := uuid.New(), uuid.New()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT \* FROM "recovery_workflows"`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "company_id", "current_version"}).AddRow(workflowID, companyID, 1))
+	mock.ExpectRollback()
+
+	_, err := service.PublishWorkflowVersion(context.Background(), workflowID, companyID, WorkflowVersionChange{
+		Steps: []models.RecoveryWorkflowStep{
+			graphStep(1, "email", models.StepTransitions{OnSuccess: []string{"sms"}}),
+			graphStep(2, "sms", models.StepTransitions{OnFailure: []string{"email"}}),
+		},
+	})
+	assert.ErrorIs(t, err, ErrInvalidWorkflowGraph)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestMigrateExecution(t *testing.T) {
+	db, mock := mockDB(t)
+	service := &RecoveryOrchestrationService{db: db, tracer: otel.Tracer("test")}
+	from, to := twoVersions()
+	m, err := newVersionMigration(from, to, map[string]string{"sms": "text"})
+	require.NoError(t, err)
+
+	executionID, timerID := uuid.New(), uuid.New()
+	cp, err := json.Marshal(executionCheckpoint{
+		Outcomes: map[string]string{from.Steps[0].ID.String(): models.StepOutcomeSuccess},
+		Waits: map[string]*checkpointWait{
+			from.Steps[1].ID.String(): {StepID: from.Steps[1].ID, Kind: models.WorkflowTimerStepDelay, Until: time.Now().Add(time.Hour)},
+		},
+	})
+	require.NoError(t, err)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT \* FROM "recovery_workflow_executions" WHERE id = \$1 .* FOR UPDATE`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "status", "workflow_version", "checkpoint", "current_step_id"}).
+			AddRow(executionID, "waiting", 1, cp, from.Steps[1].ID))
+	mock.ExpectQuery(`SELECT \* FROM "workflow_timers" WHERE workflow_execution_id = \$1 AND status IN \(\$2,\$3\)`).
+		WithArgs(executionID, models.WorkflowTimerPending, models.WorkflowTimerFired).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "step_id", "status"}).AddRow(timerID, from.Steps[1].ID, "pending"))
+	mock.ExpectExec(`UPDATE "workflow_timers" SET "step_id"=\$1,"updated_at"=\$2 WHERE id = \$3`).
+		WithArgs(to.Steps[1].ID, sqlmock.AnyArg(), timerID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	for _, pair := range [][2]uuid.UUID{{from.Steps[0].ID, to.Steps[0].ID}, {from.Steps[1].ID, to.Steps[1].ID}} {
+		mock.ExpectExec(`UPDATE "recovery_step_executions" SET "step_id"=\$1,"updated_at"=\$2 WHERE workflow_execution_id = \$3 AND step_id = \$4`).
+			WithArgs(pair[1], sqlmock.AnyArg(), executionID, pair[0]).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+	}
+	mock.ExpectExec(`UPDATE "recovery_workflow_executions" SET "checkpoint"=\$1,"current_step_id"=\$2,"total_steps"=\$3,"workflow_version"=\$4,"updated_at"=\$5 WHERE id = \$6`).
+		WithArgs(sqlmock.AnyArg(), to.Steps[1].ID, 3, 2, sqlmock.AnyArg(), executionID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	reason, err := service.migrateExecution(context.Background(), executionID, m)
+	require.NoError(t, err)
+	assert.Empty(t, reason)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestMigrateExecutionSkipsLeasedRun(t *testing.T) {
+	db, mock := mockDB(t)
+	service := &RecoveryOrchestrationService{db: db, tracer: otel.Tracer("test")}
+	from, to := twoVersions()
+	m, err := newVersionMigration(from, to, nil)
+	require.NoError(t, err)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT \* FROM "recovery_workflow_executions"`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "status", "workflow_version", "lease_expires_at"}).
+			AddRow(uuid.New(), "running", 1, time.Now().Add(time.Minute)))
+	mock.ExpectCommit()
+
+	reason, err := service.migrateExecution(context.Background(), uuid.New(), m)
+	require.NoError(t, err)
+	assert.Contains(t, reason, "running on a worker")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
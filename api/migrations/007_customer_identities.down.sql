@@ -0,0 +1,4 @@
+-- Migration 007: Rollback customer identity resolution tables
+
+DROP TABLE IF EXISTS customer_identity_links;
+DROP TABLE IF EXISTS customer_identities;
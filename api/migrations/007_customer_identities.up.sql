@@ -0,0 +1,46 @@
+-- Migration 007: Cross-provider customer identity resolution
+-- Links Stripe/Xero/QuickBooks customer records to a stable internal identity
+
+CREATE TABLE IF NOT EXISTS customer_identities (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    company_id VARCHAR(255) NOT NULL,
+    display_name VARCHAR(255),
+    primary_email VARCHAR(255),
+    primary_phone VARCHAR(50),
+    business_number VARCHAR(50),
+    status VARCHAR(50) DEFAULT 'active',
+    merged_into_id UUID REFERENCES customer_identities(id),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS customer_identity_links (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    identity_id UUID NOT NULL REFERENCES customer_identities(id) ON DELETE CASCADE,
+    company_id VARCHAR(255) NOT NULL,
+    provider VARCHAR(50) NOT NULL,
+    provider_customer_id VARCHAR(255) NOT NULL,
+    email VARCHAR(255),
+    phone VARCHAR(50),
+    business_number VARCHAR(50),
+    name VARCHAR(255),
+    match_reason VARCHAR(50),
+    confidence DECIMAL(5,4) DEFAULT 1.0,
+    is_manual BOOLEAN DEFAULT FALSE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_customer_identities_company ON customer_identities(company_id);
+CREATE INDEX IF NOT EXISTS idx_customer_identities_merged_into ON customer_identities(merged_into_id);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_customer_identity_links_provider_customer
+    ON customer_identity_links(company_id, provider, provider_customer_id);
+CREATE INDEX IF NOT EXISTS idx_customer_identity_links_identity ON customer_identity_links(identity_id);
+CREATE INDEX IF NOT EXISTS idx_customer_identity_links_email ON customer_identity_links(company_id, email);
+CREATE INDEX IF NOT EXISTS idx_customer_identity_links_phone ON customer_identity_links(company_id, phone);
+CREATE INDEX IF NOT EXISTS idx_customer_identity_links_business_number ON customer_identity_links(company_id, business_number);
+CREATE INDEX IF NOT EXISTS idx_customer_identity_links_customer ON customer_identity_links(company_id, provider_customer_id);
+
+COMMENT ON TABLE customer_identities IS 'Stable internal customer identities resolved across payment and accounting providers';
+COMMENT ON TABLE customer_identity_links IS 'Provider customer records linked to a customer identity with normalized match keys';
@@ -0,0 +1,9 @@
+-- Migration 008: Rollback Customer 360 support
+
+DROP INDEX IF EXISTS idx_communications_type;
+ALTER TABLE customer_communications DROP COLUMN IF EXISTS metadata;
+ALTER TABLE customer_communications DROP COLUMN IF EXISTS external_id;
+ALTER TABLE customer_communications DROP COLUMN IF EXISTS recipient;
+ALTER TABLE customer_communications DROP COLUMN IF EXISTS communication_type;
+
+DROP TABLE IF EXISTS invoices;
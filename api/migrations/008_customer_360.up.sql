@@ -0,0 +1,36 @@
+-- Migration 008: Customer 360 support
+-- Adds the invoices table synced from accounting providers and aligns
+-- customer_communications with the communication service
+
+CREATE TABLE IF NOT EXISTS invoices (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    company_id VARCHAR(255) NOT NULL,
+    provider_id VARCHAR(50) NOT NULL,
+    provider_invoice_id VARCHAR(255) NOT NULL UNIQUE,
+    invoice_number VARCHAR(255) NOT NULL,
+    amount DECIMAL(12,2) NOT NULL,
+    currency VARCHAR(3) DEFAULT 'AUD',
+    status VARCHAR(50),
+    customer_id VARCHAR(255),
+    customer_name VARCHAR(255),
+    customer_email VARCHAR(255),
+    issue_date TIMESTAMP WITH TIME ZONE,
+    due_date TIMESTAMP WITH TIME ZONE,
+    paid_date TIMESTAMP WITH TIME ZONE,
+    line_items JSONB DEFAULT '[]',
+    provider_metadata JSONB DEFAULT '{}',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_invoices_company ON invoices(company_id);
+CREATE INDEX IF NOT EXISTS idx_invoices_customer ON invoices(company_id, customer_id);
+CREATE INDEX IF NOT EXISTS idx_invoices_status ON invoices(status);
+
+ALTER TABLE customer_communications ALTER COLUMN payment_failure_id DROP NOT NULL;
+ALTER TABLE customer_communications ADD COLUMN IF NOT EXISTS communication_type VARCHAR(50);
+ALTER TABLE customer_communications ADD COLUMN IF NOT EXISTS recipient VARCHAR(255);
+ALTER TABLE customer_communications ADD COLUMN IF NOT EXISTS external_id VARCHAR(255);
+ALTER TABLE customer_communications ADD COLUMN IF NOT EXISTS metadata JSONB DEFAULT '{}';
+
+CREATE INDEX IF NOT EXISTS idx_communications_type ON customer_communications(communication_type);
@@ -0,0 +1,10 @@
+-- Migration 009: Rollback payment failure search
+
+DROP INDEX IF EXISTS idx_payment_failures_company_amount;
+DROP INDEX IF EXISTS idx_payment_failures_company_created;
+DROP INDEX IF EXISTS idx_payment_failures_tags;
+DROP INDEX IF EXISTS idx_payment_failures_search;
+
+ALTER TABLE payment_failure_events DROP COLUMN IF EXISTS search_vector;
+ALTER TABLE payment_failure_events DROP COLUMN IF EXISTS tags;
+ALTER TABLE payment_failure_events DROP COLUMN IF EXISTS invoice_number;
@@ -0,0 +1,29 @@
+-- Migration 009: Payment failure search
+-- Adds invoice number and tags, and a generated tsvector for full-text search
+-- over customer name, email, invoice number and failure message
+
+-- Columns the model already expects that the initial schema did not create
+ALTER TABLE payment_failure_events ADD COLUMN IF NOT EXISTS amount_cents BIGINT;
+ALTER TABLE payment_failure_events ADD COLUMN IF NOT EXISTS retry_count INTEGER DEFAULT 0;
+ALTER TABLE payment_failure_events ADD COLUMN IF NOT EXISTS due_date TIMESTAMP WITH TIME ZONE;
+UPDATE payment_failure_events SET amount_cents = ROUND(amount * 100) WHERE amount_cents IS NULL AND amount IS NOT NULL;
+
+ALTER TABLE payment_failure_events ADD COLUMN IF NOT EXISTS invoice_number VARCHAR(255);
+ALTER TABLE payment_failure_events ADD COLUMN IF NOT EXISTS tags JSONB DEFAULT '[]';
+
+-- Kept up to date by Postgres on every insert/update
+ALTER TABLE payment_failure_events ADD COLUMN IF NOT EXISTS search_vector TSVECTOR
+    GENERATED ALWAYS AS (
+        to_tsvector('simple',
+            COALESCE(customer_name, '') || ' ' ||
+            COALESCE(customer_email, '') || ' ' ||
+            COALESCE(invoice_number, '') || ' ' ||
+            COALESCE(failure_message, ''))
+    ) STORED;
+
+CREATE INDEX IF NOT EXISTS idx_payment_failures_search ON payment_failure_events USING GIN(search_vector);
+CREATE INDEX IF NOT EXISTS idx_payment_failures_tags ON payment_failure_events USING GIN(tags);
+
+-- Keyset pagination indexes for the default and amount sort orders
+CREATE INDEX IF NOT EXISTS idx_payment_failures_company_created ON payment_failure_events(company_id, created_at DESC, id DESC);
+CREATE INDEX IF NOT EXISTS idx_payment_failures_company_amount ON payment_failure_events(company_id, amount_cents DESC, id DESC);
@@ -0,0 +1,3 @@
+-- Migration 010: Rollback export jobs
+
+DROP TABLE IF EXISTS export_jobs;
@@ -0,0 +1,27 @@
+-- Migration 010: Asynchronous data export jobs
+
+CREATE TABLE IF NOT EXISTS export_jobs (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    company_id VARCHAR(255) NOT NULL,
+    dataset VARCHAR(50) NOT NULL,
+    format VARCHAR(10) NOT NULL,
+    filter JSONB DEFAULT '{}',
+    status VARCHAR(50) NOT NULL DEFAULT 'pending',
+    total_rows BIGINT DEFAULT 0,
+    processed_rows BIGINT DEFAULT 0,
+    error_message TEXT,
+    file_path TEXT,
+    file_size BIGINT DEFAULT 0,
+    download_token VARCHAR(64),
+    token_expires_at TIMESTAMP WITH TIME ZONE,
+    started_at TIMESTAMP WITH TIME ZONE,
+    completed_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_export_jobs_company ON export_jobs(company_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_export_jobs_status ON export_jobs(status);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_export_jobs_download_token ON export_jobs(download_token);
+
+COMMENT ON TABLE export_jobs IS 'Asynchronous exports of failures, recovery actions, executions and communications';
@@ -0,0 +1,5 @@
+-- Migration 011: Rollback scheduled report delivery
+
+DROP TABLE IF EXISTS report_deliveries;
+DROP TABLE IF EXISTS report_schedules;
+ALTER TABLE companies DROP COLUMN IF EXISTS timezone;
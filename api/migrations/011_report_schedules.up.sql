@@ -0,0 +1,46 @@
+-- Migration 011: Scheduled report delivery
+
+ALTER TABLE companies ADD COLUMN IF NOT EXISTS timezone VARCHAR(64) DEFAULT 'UTC';
+
+CREATE TABLE IF NOT EXISTS report_schedules (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    company_id VARCHAR(255) NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    cron_expression VARCHAR(100) NOT NULL,
+    timezone VARCHAR(64),
+    format VARCHAR(10) NOT NULL DEFAULT 'pdf',
+    recipients JSONB NOT NULL DEFAULT '[]',
+    period_days INTEGER NOT NULL DEFAULT 7,
+    top_customers INTEGER NOT NULL DEFAULT 10,
+    is_active BOOLEAN DEFAULT true,
+    last_run_at TIMESTAMP WITH TIME ZONE,
+    next_run_at TIMESTAMP WITH TIME ZONE,
+    created_by VARCHAR(255),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS report_deliveries (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    schedule_id UUID NOT NULL REFERENCES report_schedules(id) ON DELETE CASCADE,
+    company_id VARCHAR(255) NOT NULL,
+    status VARCHAR(50) NOT NULL,
+    format VARCHAR(10) NOT NULL,
+    recipients JSONB DEFAULT '[]',
+    failed_recipients JSONB DEFAULT '[]',
+    message_ids JSONB DEFAULT '[]',
+    scheduled_for TIMESTAMP WITH TIME ZONE,
+    period_start TIMESTAMP WITH TIME ZONE NOT NULL,
+    period_end TIMESTAMP WITH TIME ZONE NOT NULL,
+    summary JSONB DEFAULT '{}',
+    error_message TEXT,
+    sent_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_report_schedules_company ON report_schedules(company_id);
+CREATE INDEX IF NOT EXISTS idx_report_schedules_due ON report_schedules(next_run_at) WHERE is_active = true;
+CREATE INDEX IF NOT EXISTS idx_report_deliveries_schedule ON report_deliveries(schedule_id, created_at DESC);
+
+COMMENT ON TABLE report_schedules IS 'Per-company cron schedules for emailed summary reports';
+COMMENT ON TABLE report_deliveries IS 'Delivery history for scheduled reports';
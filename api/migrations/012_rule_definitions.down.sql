@@ -0,0 +1,3 @@
+-- Migration 012: Rollback rule definitions
+
+DROP TABLE IF EXISTS rule_definitions;
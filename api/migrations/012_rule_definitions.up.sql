@@ -0,0 +1,22 @@
+-- Migration 012: Declarative per-company rule definitions
+
+CREATE TABLE IF NOT EXISTS rule_definitions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    company_id VARCHAR(255) NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    description TEXT,
+    priority INTEGER NOT NULL DEFAULT 0,
+    enabled BOOLEAN NOT NULL DEFAULT true,
+    source_format VARCHAR(10) NOT NULL DEFAULT 'json',
+    source TEXT,
+    definition JSONB NOT NULL,
+    created_by VARCHAR(255),
+    updated_by VARCHAR(255),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_rule_definitions_company_name ON rule_definitions(company_id, name);
+CREATE INDEX IF NOT EXISTS idx_rule_definitions_company_enabled ON rule_definitions(company_id, enabled);
+
+COMMENT ON TABLE rule_definitions IS 'Rules defined as JSON/YAML data, compiled into the rule engine per company';
@@ -0,0 +1,5 @@
+-- Migration 013: Rollback shadow mode and automation decision log
+
+DROP TABLE IF EXISTS automation_decisions;
+ALTER TABLE recovery_workflows DROP COLUMN IF EXISTS shadow;
+ALTER TABLE rule_definitions DROP COLUMN IF EXISTS shadow;
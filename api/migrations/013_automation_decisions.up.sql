@@ -0,0 +1,23 @@
+-- Migration 013: Shadow mode and automation decision log
+
+ALTER TABLE rule_definitions ADD COLUMN IF NOT EXISTS shadow BOOLEAN NOT NULL DEFAULT false;
+ALTER TABLE recovery_workflows ADD COLUMN IF NOT EXISTS shadow BOOLEAN NOT NULL DEFAULT false;
+
+CREATE TABLE IF NOT EXISTS automation_decisions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    company_id VARCHAR(255) NOT NULL,
+    payment_failure_id UUID NOT NULL,
+    subject_type VARCHAR(20) NOT NULL, -- rule, workflow
+    subject_id VARCHAR(255),
+    subject_name VARCHAR(255) NOT NULL,
+    mode VARCHAR(10) NOT NULL, -- live, shadow
+    matched BOOLEAN NOT NULL DEFAULT false,
+    actions JSONB,
+    details JSONB,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_automation_decisions_company_created ON automation_decisions(company_id, subject_type, created_at);
+CREATE INDEX IF NOT EXISTS idx_automation_decisions_payment_failure ON automation_decisions(payment_failure_id);
+
+COMMENT ON TABLE automation_decisions IS 'Decisions taken by live and shadow rules and workflows for each payment failure';
@@ -0,0 +1,3 @@
+-- Migration 014: Rollback rule evaluation traces
+
+DROP TABLE IF EXISTS rule_evaluation_traces;
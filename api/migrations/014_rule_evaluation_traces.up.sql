@@ -0,0 +1,23 @@
+-- Migration 014: Persisted rule evaluation traces
+
+CREATE TABLE IF NOT EXISTS rule_evaluation_traces (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    company_id VARCHAR(255) NOT NULL,
+    payment_failure_id UUID NOT NULL,
+    engine VARCHAR(20) NOT NULL, -- enterprise, basic
+    rule_id VARCHAR(255),
+    rule_name VARCHAR(255) NOT NULL,
+    triggered BOOLEAN NOT NULL DEFAULT false,
+    shadow BOOLEAN NOT NULL DEFAULT false,
+    conditions JSONB,
+    actions JSONB,
+    error_message TEXT,
+    duration_micros BIGINT NOT NULL DEFAULT 0,
+    evaluated_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_rule_evaluation_traces_company_failure ON rule_evaluation_traces(company_id, payment_failure_id);
+CREATE INDEX IF NOT EXISTS idx_rule_evaluation_traces_evaluated_at ON rule_evaluation_traces(evaluated_at);
+
+COMMENT ON TABLE rule_evaluation_traces IS 'Per-rule evaluation traces explaining why automation acted on a payment failure';
@@ -0,0 +1,5 @@
+-- Migration 015: Rollback rule set versions
+
+ALTER TABLE rule_evaluation_traces DROP COLUMN IF EXISTS rule_set_version;
+ALTER TABLE automation_decisions DROP COLUMN IF EXISTS rule_set_version;
+DROP TABLE IF EXISTS rule_set_versions;
@@ -0,0 +1,34 @@
+-- Migration 015: Rule set versions with draft/publish governance
+
+CREATE TABLE IF NOT EXISTS rule_set_versions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    company_id VARCHAR(255) NOT NULL,
+    version INTEGER NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'draft', -- draft, published, superseded
+    author VARCHAR(255),
+    message TEXT,
+    base_version INTEGER,
+    restored_from INTEGER,
+    rules JSONB NOT NULL,
+    diff JSONB,
+    published_by VARCHAR(255),
+    published_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_rule_set_versions_company_version ON rule_set_versions(company_id, version);
+CREATE INDEX IF NOT EXISTS idx_rule_set_versions_company_status ON rule_set_versions(company_id, status);
+
+ALTER TABLE automation_decisions ADD COLUMN IF NOT EXISTS rule_set_version INTEGER;
+ALTER TABLE rule_evaluation_traces ADD COLUMN IF NOT EXISTS rule_set_version INTEGER;
+
+-- Publish the rules companies already have so they stay live
+INSERT INTO rule_set_versions (company_id, version, status, author, message, rules, diff, published_at)
+SELECT company_id, 1, 'published', 'migration', 'Initial version',
+       jsonb_agg(to_jsonb(d) - 'company_id' ORDER BY d.priority DESC, d.name),
+       '{"added": [], "removed": [], "changed": []}'::jsonb, NOW()
+FROM rule_definitions d
+GROUP BY company_id
+ON CONFLICT DO NOTHING;
+
+COMMENT ON TABLE rule_set_versions IS 'Immutable snapshots of company rule definitions; the latest published version is live';
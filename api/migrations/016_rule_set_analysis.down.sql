@@ -0,0 +1,4 @@
+-- Migration 016: Rollback static analysis of rule set versions
+
+DROP INDEX IF EXISTS idx_rule_evaluation_traces_company_rule;
+ALTER TABLE rule_set_versions DROP COLUMN IF EXISTS analysis;
@@ -0,0 +1,5 @@
+-- Migration 016: Static analysis of rule set versions
+
+ALTER TABLE rule_set_versions ADD COLUMN IF NOT EXISTS analysis JSONB;
+
+CREATE INDEX IF NOT EXISTS idx_rule_evaluation_traces_company_rule ON rule_evaluation_traces(company_id, rule_name, evaluated_at);
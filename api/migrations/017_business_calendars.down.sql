@@ -0,0 +1,5 @@
+-- Migration 017: Rollback company business hours and holiday calendars
+
+DROP TABLE IF EXISTS company_holidays;
+DROP TABLE IF EXISTS holiday_calendars;
+ALTER TABLE companies DROP COLUMN IF EXISTS business_hours;
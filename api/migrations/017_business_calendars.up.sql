@@ -0,0 +1,31 @@
+-- Migration 017: Company business hours and holiday calendars
+
+ALTER TABLE companies ADD COLUMN IF NOT EXISTS business_hours JSONB;
+
+CREATE TABLE IF NOT EXISTS holiday_calendars (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    company_id VARCHAR(255) NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    source VARCHAR(255),
+    holiday_count INTEGER NOT NULL DEFAULT 0,
+    imported_by VARCHAR(255),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS company_holidays (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    company_id VARCHAR(255) NOT NULL,
+    calendar_id UUID NOT NULL REFERENCES holiday_calendars(id) ON DELETE CASCADE,
+    date DATE NOT NULL,
+    name VARCHAR(500),
+    uid VARCHAR(500)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_holiday_calendars_company_name ON holiday_calendars(company_id, name);
+CREATE INDEX IF NOT EXISTS idx_company_holidays_company_date ON company_holidays(company_id, date);
+CREATE INDEX IF NOT EXISTS idx_company_holidays_calendar ON company_holidays(calendar_id);
+
+COMMENT ON COLUMN companies.business_hours IS 'Opening hours per weekday in the company timezone; NULL means Monday to Friday 09:00-17:00';
+COMMENT ON TABLE holiday_calendars IS 'Holiday calendars imported from iCalendar files';
+COMMENT ON TABLE company_holidays IS 'Dates on which a company is closed, used for business hours scheduling';
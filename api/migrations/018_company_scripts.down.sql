@@ -0,0 +1,3 @@
+-- Migration 018: Rollback sandboxed company scripts
+
+DROP TABLE IF EXISTS company_scripts;
@@ -0,0 +1,27 @@
+-- Migration 018: Sandboxed company scripts used as enterprise rules
+
+CREATE TABLE IF NOT EXISTS company_scripts (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    company_id VARCHAR(255) NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    description TEXT,
+    source TEXT NOT NULL,
+    tables JSONB,
+    priority INTEGER NOT NULL DEFAULT 0,
+    enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    shadow BOOLEAN NOT NULL DEFAULT FALSE,
+    max_steps BIGINT NOT NULL DEFAULT 0,
+    timeout_ms INTEGER NOT NULL DEFAULT 0,
+    version INTEGER NOT NULL DEFAULT 1,
+    created_by VARCHAR(255),
+    updated_by VARCHAR(255),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_company_scripts_company_name ON company_scripts(company_id, name);
+CREATE INDEX IF NOT EXISTS idx_company_scripts_company_enabled ON company_scripts(company_id) WHERE enabled;
+
+COMMENT ON TABLE company_scripts IS 'Sandboxed per-company scripts evaluated as enterprise rule conditions and actions';
+COMMENT ON COLUMN company_scripts.tables IS 'JSON data exposed to the script as the tables global, e.g. risk thresholds';
+COMMENT ON COLUMN company_scripts.version IS 'Incremented on every change; compiled scripts are cached by company and version';
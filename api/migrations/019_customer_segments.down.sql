@@ -0,0 +1,4 @@
+-- Migration 019: Rollback customer segments
+
+DROP TABLE IF EXISTS customer_segment_profiles;
+DROP TABLE IF EXISTS customer_segments;
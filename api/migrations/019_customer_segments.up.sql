@@ -0,0 +1,44 @@
+-- Migration 019: Customer segments computed from payment history and manual tags
+
+CREATE TABLE IF NOT EXISTS customer_segments (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    company_id VARCHAR(255) NOT NULL,
+    key VARCHAR(100) NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    description TEXT,
+    criteria JSONB NOT NULL,
+    enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    created_by VARCHAR(255),
+    updated_by VARCHAR(255),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_customer_segments_company_key ON customer_segments(company_id, key);
+
+CREATE TABLE IF NOT EXISTS customer_segment_profiles (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    company_id VARCHAR(255) NOT NULL,
+    customer_id VARCHAR(255) NOT NULL,
+    first_seen_at TIMESTAMP WITH TIME ZONE,
+    last_event_at TIMESTAMP WITH TIME ZONE,
+    failure_count BIGINT NOT NULL DEFAULT 0,
+    failed_amount_cents BIGINT NOT NULL DEFAULT 0,
+    lifetime_value_cents BIGINT NOT NULL DEFAULT 0,
+    subscription_id VARCHAR(255),
+    subscription_status VARCHAR(50),
+    recent_events JSONB,
+    manual_tags JSONB,
+    segments JSONB NOT NULL DEFAULT '[]'::jsonb,
+    segments_changed_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_customer_segment_profiles_customer ON customer_segment_profiles(company_id, customer_id);
+CREATE INDEX IF NOT EXISTS idx_customer_segment_profiles_segments ON customer_segment_profiles USING GIN (segments);
+
+COMMENT ON TABLE customer_segments IS 'Company segment definitions; built-in vip, recurring, new and hardship apply unless redefined';
+COMMENT ON COLUMN customer_segments.criteria IS 'Lifetime value, tenure, failure frequency and subscription criteria, plus manual tags that always qualify';
+COMMENT ON TABLE customer_segment_profiles IS 'Per-customer payment history summary and current segments, updated incrementally on each event';
+COMMENT ON COLUMN customer_segment_profiles.recent_events IS 'Latest events, used for failure frequency and to apply each event only once';
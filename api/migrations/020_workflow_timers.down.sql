@@ -0,0 +1,5 @@
+-- Migration 020: Rollback durable workflow timers
+
+DROP INDEX IF EXISTS idx_retry_jobs_scheduled;
+ALTER TABLE recovery_workflow_executions DROP COLUMN IF EXISTS waiting_until;
+DROP TABLE IF EXISTS workflow_timers;
@@ -0,0 +1,27 @@
+-- Migration 020: Durable workflow timers for waits, step delays and deferred sends
+
+CREATE TABLE IF NOT EXISTS workflow_timers (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    company_id UUID NOT NULL,
+    workflow_execution_id UUID NOT NULL REFERENCES recovery_workflow_executions(id) ON DELETE CASCADE,
+    step_id UUID NOT NULL,
+    kind VARCHAR(50) NOT NULL,
+    fire_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    status VARCHAR(50) NOT NULL DEFAULT 'pending',
+    attempts INTEGER NOT NULL DEFAULT 0,
+    last_error TEXT,
+    fired_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_workflow_timers_due ON workflow_timers(fire_at) WHERE status = 'pending';
+CREATE INDEX IF NOT EXISTS idx_workflow_timers_execution ON workflow_timers(workflow_execution_id);
+
+ALTER TABLE recovery_workflow_executions ADD COLUMN IF NOT EXISTS waiting_until TIMESTAMP WITH TIME ZONE;
+
+CREATE INDEX IF NOT EXISTS idx_retry_jobs_scheduled ON retry_jobs(next_retry_at) WHERE status = 'scheduled';
+
+COMMENT ON TABLE workflow_timers IS 'Pending resumptions of waiting workflow executions, polled by the timer scheduler';
+COMMENT ON COLUMN workflow_timers.kind IS 'wait resumes after step_id; step_delay and send_time run step_id with that wait served';
+COMMENT ON COLUMN recovery_workflow_executions.waiting_until IS 'When a waiting execution is due to resume';
@@ -0,0 +1,16 @@
+-- Migration 021: Rollback execution checkpoints, leases and step idempotency keys
+
+DROP INDEX IF EXISTS idx_retry_jobs_idempotency_key;
+ALTER TABLE retry_jobs DROP COLUMN IF EXISTS idempotency_key;
+DROP INDEX IF EXISTS idx_customer_communications_idempotency_key;
+ALTER TABLE customer_communications DROP COLUMN IF EXISTS idempotency_key;
+DROP INDEX IF EXISTS idx_recovery_actions_idempotency_key;
+ALTER TABLE recovery_actions DROP COLUMN IF EXISTS idempotency_key;
+DROP INDEX IF EXISTS idx_recovery_step_executions_idempotency_key;
+ALTER TABLE recovery_step_executions DROP COLUMN IF EXISTS idempotency_key;
+
+DROP INDEX IF EXISTS idx_recovery_workflow_executions_lease;
+ALTER TABLE recovery_workflow_executions DROP COLUMN IF EXISTS lease_expires_at;
+ALTER TABLE recovery_workflow_executions DROP COLUMN IF EXISTS lease_owner;
+ALTER TABLE recovery_workflow_executions DROP COLUMN IF EXISTS checkpointed_at;
+ALTER TABLE recovery_workflow_executions DROP COLUMN IF EXISTS checkpoint;
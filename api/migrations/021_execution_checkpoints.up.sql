@@ -0,0 +1,29 @@
+-- Migration 021: Execution checkpoints, leases and step idempotency keys for crash-safe resumption
+
+ALTER TABLE recovery_workflow_executions ADD COLUMN IF NOT EXISTS checkpoint JSONB;
+ALTER TABLE recovery_workflow_executions ADD COLUMN IF NOT EXISTS checkpointed_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE recovery_workflow_executions ADD COLUMN IF NOT EXISTS lease_owner VARCHAR(255);
+ALTER TABLE recovery_workflow_executions ADD COLUMN IF NOT EXISTS lease_expires_at TIMESTAMP WITH TIME ZONE;
+
+CREATE INDEX IF NOT EXISTS idx_recovery_workflow_executions_lease ON recovery_workflow_executions(lease_expires_at)
+    WHERE status IN ('pending', 'running');
+
+ALTER TABLE recovery_step_executions ADD COLUMN IF NOT EXISTS idempotency_key VARCHAR(255);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_recovery_step_executions_idempotency_key ON recovery_step_executions(idempotency_key)
+    WHERE idempotency_key IS NOT NULL AND idempotency_key <> '';
+
+ALTER TABLE recovery_actions ADD COLUMN IF NOT EXISTS idempotency_key VARCHAR(255);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_recovery_actions_idempotency_key ON recovery_actions(idempotency_key)
+    WHERE idempotency_key IS NOT NULL AND idempotency_key <> '';
+
+ALTER TABLE customer_communications ADD COLUMN IF NOT EXISTS idempotency_key VARCHAR(255);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_customer_communications_idempotency_key ON customer_communications(idempotency_key)
+    WHERE idempotency_key IS NOT NULL AND idempotency_key <> '';
+
+ALTER TABLE retry_jobs ADD COLUMN IF NOT EXISTS idempotency_key VARCHAR(255);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_retry_jobs_idempotency_key ON retry_jobs(idempotency_key)
+    WHERE idempotency_key IS NOT NULL AND idempotency_key <> '';
+
+COMMENT ON COLUMN recovery_workflow_executions.checkpoint IS 'Next step, step results, context variables and pending wait, written after each step';
+COMMENT ON COLUMN recovery_workflow_executions.lease_owner IS 'Instance running the execution; others reclaim it once lease_expires_at passes';
+COMMENT ON COLUMN recovery_step_executions.idempotency_key IS 'Execution and step; a resumed step reuses its row and replays a finished outcome';
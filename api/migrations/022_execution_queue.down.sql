@@ -0,0 +1,7 @@
+-- Migration 022: Rollback distributed workflow execution queue
+
+ALTER TABLE companies DROP COLUMN IF EXISTS max_concurrent_executions;
+
+DROP INDEX IF EXISTS idx_recovery_workflow_executions_company_lease;
+DROP INDEX IF EXISTS idx_recovery_workflow_executions_queue;
+ALTER TABLE recovery_workflow_executions DROP COLUMN IF EXISTS heartbeat_at;
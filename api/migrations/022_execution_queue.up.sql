@@ -0,0 +1,15 @@
+-- Migration 022: Distributed workflow execution queue with heartbeats and per-company concurrency limits
+
+ALTER TABLE recovery_workflow_executions ADD COLUMN IF NOT EXISTS heartbeat_at TIMESTAMP WITH TIME ZONE;
+
+-- Runnable executions are claimed oldest first with FOR UPDATE SKIP LOCKED
+CREATE INDEX IF NOT EXISTS idx_recovery_workflow_executions_queue ON recovery_workflow_executions(created_at)
+    WHERE status IN ('pending', 'running');
+-- Per-company count of executions currently leased to a worker
+CREATE INDEX IF NOT EXISTS idx_recovery_workflow_executions_company_lease ON recovery_workflow_executions(company_id, lease_expires_at)
+    WHERE status IN ('pending', 'running');
+
+ALTER TABLE companies ADD COLUMN IF NOT EXISTS max_concurrent_executions INTEGER NOT NULL DEFAULT 0;
+
+COMMENT ON COLUMN recovery_workflow_executions.heartbeat_at IS 'Last time the worker running the execution renewed its lease';
+COMMENT ON COLUMN companies.max_concurrent_executions IS 'Workflow executions the company may run at once across all workers; 0 uses the service default';
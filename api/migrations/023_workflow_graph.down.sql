@@ -0,0 +1,8 @@
+-- Migration 023: Rollback workflow graphs
+
+ALTER TABLE recovery_workflow_executions DROP COLUMN IF EXISTS path;
+
+ALTER TABLE recovery_workflow_steps DROP COLUMN IF EXISTS timeout_seconds;
+ALTER TABLE recovery_workflow_steps DROP COLUMN IF EXISTS join_mode;
+ALTER TABLE recovery_workflow_steps DROP COLUMN IF EXISTS next;
+ALTER TABLE recovery_workflow_steps DROP COLUMN IF EXISTS step_key;
@@ -0,0 +1,15 @@
+-- Migration 023: Workflow graphs with per-outcome successors, joins, step timeouts and execution paths
+
+ALTER TABLE recovery_workflow_steps ADD COLUMN IF NOT EXISTS step_key VARCHAR(100);
+ALTER TABLE recovery_workflow_steps ADD COLUMN IF NOT EXISTS next JSONB;
+ALTER TABLE recovery_workflow_steps ADD COLUMN IF NOT EXISTS join_mode VARCHAR(10);
+ALTER TABLE recovery_workflow_steps ADD COLUMN IF NOT EXISTS timeout_seconds INTEGER NOT NULL DEFAULT 0;
+
+UPDATE recovery_workflow_steps SET step_key = 'step_' || step_order WHERE step_key IS NULL OR step_key = '';
+
+ALTER TABLE recovery_workflow_executions ADD COLUMN IF NOT EXISTS path JSONB;
+
+COMMENT ON COLUMN recovery_workflow_steps.step_key IS 'Identifies the step in other steps'' successor lists';
+COMMENT ON COLUMN recovery_workflow_steps.next IS 'Successor step keys per outcome: on_success, on_failure, on_true, on_false, on_timeout';
+COMMENT ON COLUMN recovery_workflow_steps.join_mode IS 'all waits for every incoming branch, any runs on the first; empty means all';
+COMMENT ON COLUMN recovery_workflow_executions.path IS 'Steps the execution ran and their outcomes, in order';
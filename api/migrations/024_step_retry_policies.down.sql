@@ -0,0 +1,9 @@
+-- Migration 024: Rollback per-step retry policies
+
+DROP INDEX IF EXISTS idx_recovery_step_executions_attempts;
+
+ALTER TABLE recovery_step_executions DROP COLUMN IF EXISTS next_attempt_at;
+ALTER TABLE recovery_step_executions DROP COLUMN IF EXISTS error_class;
+ALTER TABLE recovery_step_executions DROP COLUMN IF EXISTS attempt;
+
+ALTER TABLE recovery_workflow_steps DROP COLUMN IF EXISTS retry_policy;
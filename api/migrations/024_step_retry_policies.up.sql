@@ -0,0 +1,15 @@
+-- Migration 024: Per-step retry policies and step execution attempts
+
+ALTER TABLE recovery_workflow_steps ADD COLUMN IF NOT EXISTS retry_policy JSONB;
+
+ALTER TABLE recovery_step_executions ADD COLUMN IF NOT EXISTS attempt INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE recovery_step_executions ADD COLUMN IF NOT EXISTS error_class VARCHAR(50);
+ALTER TABLE recovery_step_executions ADD COLUMN IF NOT EXISTS next_attempt_at TIMESTAMP WITH TIME ZONE;
+
+CREATE INDEX IF NOT EXISTS idx_recovery_step_executions_attempts
+    ON recovery_step_executions(workflow_execution_id, step_id, attempt);
+
+COMMENT ON COLUMN recovery_workflow_steps.retry_policy IS 'max_attempts, initial_delay_seconds, max_delay_seconds, backoff_multiplier, jitter and retry_on error classes; NULL uses the default policy';
+COMMENT ON COLUMN recovery_step_executions.attempt IS 'Attempt number of the step within its execution, starting at 1';
+COMMENT ON COLUMN recovery_step_executions.error_class IS 'retryable, timeout, error or unsuccessful for failed attempts';
+COMMENT ON COLUMN recovery_step_executions.next_attempt_at IS 'When a retrying attempt is followed by the next one';
@@ -0,0 +1,24 @@
+-- Migration 025: Rollback workflow versions
+
+DROP INDEX IF EXISTS idx_recovery_workflow_executions_version;
+ALTER TABLE recovery_workflow_executions DROP COLUMN IF EXISTS workflow_version;
+
+DROP TABLE IF EXISTS recovery_workflow_versions;
+
+-- Only the current version of each workflow's steps is kept
+UPDATE recovery_workflow_executions SET current_step_id = NULL
+FROM recovery_workflow_steps, recovery_workflows
+WHERE recovery_workflow_steps.id = recovery_workflow_executions.current_step_id
+  AND recovery_workflows.id = recovery_workflow_steps.workflow_id
+  AND recovery_workflow_steps.version <> recovery_workflows.current_version;
+
+DELETE FROM recovery_workflow_steps
+USING recovery_workflows
+WHERE recovery_workflows.id = recovery_workflow_steps.workflow_id
+  AND recovery_workflow_steps.version <> recovery_workflows.current_version;
+
+ALTER TABLE recovery_workflow_steps DROP CONSTRAINT IF EXISTS uk_workflow_version_step_order;
+ALTER TABLE recovery_workflow_steps ADD CONSTRAINT uk_workflow_step_order UNIQUE (workflow_id, step_order);
+ALTER TABLE recovery_workflow_steps DROP COLUMN IF EXISTS version;
+
+ALTER TABLE recovery_workflows DROP COLUMN IF EXISTS current_version;
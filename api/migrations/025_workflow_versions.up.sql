@@ -0,0 +1,41 @@
+-- Migration 025: Immutable workflow versions and executions pinned to a version
+
+ALTER TABLE recovery_workflows ADD COLUMN IF NOT EXISTS current_version INTEGER NOT NULL DEFAULT 1;
+
+-- Steps belong to one version of their workflow; publishing a version copies them
+ALTER TABLE recovery_workflow_steps ADD COLUMN IF NOT EXISTS version INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE recovery_workflow_steps DROP CONSTRAINT IF EXISTS uk_workflow_step_order;
+ALTER TABLE recovery_workflow_steps DROP CONSTRAINT IF EXISTS uk_workflow_version_step_order;
+ALTER TABLE recovery_workflow_steps ADD CONSTRAINT uk_workflow_version_step_order UNIQUE (workflow_id, version, step_order);
+
+CREATE TABLE IF NOT EXISTS recovery_workflow_versions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    workflow_id UUID NOT NULL REFERENCES recovery_workflows(id) ON DELETE CASCADE,
+    company_id UUID NOT NULL,
+    version INTEGER NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    description TEXT,
+    trigger_conditions JSONB,
+    change_note TEXT,
+    created_by VARCHAR(255),
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    CONSTRAINT uk_workflow_version UNIQUE (workflow_id, version)
+);
+
+CREATE INDEX IF NOT EXISTS idx_recovery_workflow_versions_company ON recovery_workflow_versions(company_id);
+
+-- Existing workflows become their first version
+INSERT INTO recovery_workflow_versions (workflow_id, company_id, version, name, description, trigger_conditions, created_by, created_at)
+SELECT id, company_id, 1, name, description, trigger_conditions, created_by, created_at
+FROM recovery_workflows
+ON CONFLICT (workflow_id, version) DO NOTHING;
+
+ALTER TABLE recovery_workflow_executions ADD COLUMN IF NOT EXISTS workflow_version INTEGER NOT NULL DEFAULT 1;
+
+CREATE INDEX IF NOT EXISTS idx_recovery_workflow_executions_version
+    ON recovery_workflow_executions(workflow_id, workflow_version, status);
+
+COMMENT ON TABLE recovery_workflow_versions IS 'Immutable published versions of recovery workflows; steps carry the version they belong to';
+COMMENT ON COLUMN recovery_workflows.current_version IS 'Version new executions start on';
+COMMENT ON COLUMN recovery_workflow_steps.version IS 'Workflow version the step belongs to';
+COMMENT ON COLUMN recovery_workflow_executions.workflow_version IS 'Workflow version the execution runs; changed only by migrating the execution';
@@ -0,0 +1,3 @@
+-- Migration 026: Rollback transactional outbox
+
+DROP TABLE IF EXISTS outbox_events;
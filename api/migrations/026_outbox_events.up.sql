@@ -0,0 +1,20 @@
+-- Migration 026: Transactional outbox for events announcing committed changes
+
+CREATE TABLE IF NOT EXISTS outbox_events (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    company_id VARCHAR(255) NOT NULL,
+    topic VARCHAR(255) NOT NULL,
+    payload JSONB NOT NULL,
+    status VARCHAR(50) NOT NULL DEFAULT 'pending',
+    available_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    attempts INTEGER NOT NULL DEFAULT 0,
+    last_error TEXT,
+    published_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_outbox_events_due ON outbox_events(available_at) WHERE status = 'pending';
+
+COMMENT ON TABLE outbox_events IS 'Events committed with the change they announce, published to the event bus by the outbox relay';
+COMMENT ON COLUMN outbox_events.available_at IS 'When the relay may next claim the event; claiming moves it past the claim lease';
@@ -0,0 +1,13 @@
+-- Migration 027: Rollback export job queue
+
+DROP TABLE IF EXISTS export_file_chunks;
+
+DROP INDEX IF EXISTS idx_export_jobs_download_token;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_export_jobs_download_token ON export_jobs(download_token);
+
+DROP INDEX IF EXISTS idx_export_jobs_expiring;
+DROP INDEX IF EXISTS idx_export_jobs_due;
+
+ALTER TABLE export_jobs ADD COLUMN IF NOT EXISTS file_path TEXT;
+ALTER TABLE export_jobs DROP COLUMN IF EXISTS available_at;
+ALTER TABLE export_jobs DROP COLUMN IF EXISTS attempts;
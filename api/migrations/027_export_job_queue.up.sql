@@ -0,0 +1,23 @@
+-- Migration 027: Run export jobs from a durable queue and store their files in Postgres
+
+ALTER TABLE export_jobs ADD COLUMN IF NOT EXISTS attempts INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE export_jobs ADD COLUMN IF NOT EXISTS available_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW();
+ALTER TABLE export_jobs DROP COLUMN IF EXISTS file_path;
+
+CREATE INDEX IF NOT EXISTS idx_export_jobs_due ON export_jobs(available_at) WHERE status IN ('pending', 'running');
+CREATE INDEX IF NOT EXISTS idx_export_jobs_expiring ON export_jobs(token_expires_at) WHERE status = 'completed';
+
+-- Jobs without a download token yet must not collide on it
+DROP INDEX IF EXISTS idx_export_jobs_download_token;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_export_jobs_download_token ON export_jobs(download_token)
+    WHERE download_token IS NOT NULL AND download_token <> '';
+
+CREATE TABLE IF NOT EXISTS export_file_chunks (
+    export_job_id UUID NOT NULL REFERENCES export_jobs(id) ON DELETE CASCADE,
+    seq INTEGER NOT NULL,
+    data BYTEA NOT NULL,
+    PRIMARY KEY (export_job_id, seq)
+);
+
+COMMENT ON COLUMN export_jobs.available_at IS 'When a worker may next claim the job; claiming and progress move it past the claim lease';
+COMMENT ON TABLE export_file_chunks IS 'Files of completed exports in order, deleted once their download link expires';
@@ -0,0 +1,3 @@
+-- Migration 028: Rollback customer identity suggestions
+
+DROP TABLE IF EXISTS customer_identity_suggestions;
@@ -0,0 +1,20 @@
+-- Migration 028: Customer identity suggestions
+-- Name-only matches are suggested for review instead of linked automatically
+
+CREATE TABLE IF NOT EXISTS customer_identity_suggestions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    company_id VARCHAR(255) NOT NULL,
+    link_id UUID NOT NULL REFERENCES customer_identity_links(id) ON DELETE CASCADE,
+    identity_id UUID NOT NULL REFERENCES customer_identities(id) ON DELETE CASCADE,
+    reason VARCHAR(50) NOT NULL,
+    score DECIMAL(5,4) NOT NULL,
+    status VARCHAR(50) DEFAULT 'pending',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_customer_identity_suggestions_pending
+    ON customer_identity_suggestions(company_id, score DESC) WHERE status = 'pending';
+CREATE INDEX IF NOT EXISTS idx_customer_identity_suggestions_link ON customer_identity_suggestions(link_id);
+
+COMMENT ON TABLE customer_identity_suggestions IS 'Provider customer records that may belong to an identity they share only a similar name with';
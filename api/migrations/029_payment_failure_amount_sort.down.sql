@@ -0,0 +1,4 @@
+-- Migration 029: Rollback NULL-safe amount sort index
+
+DROP INDEX IF EXISTS idx_payment_failures_company_amount;
+CREATE INDEX IF NOT EXISTS idx_payment_failures_company_amount ON payment_failure_events(company_id, amount_cents DESC, id DESC);
@@ -0,0 +1,6 @@
+-- Migration 029: Index the NULL-safe amount sort
+-- The amount sort orders and pages by COALESCE(amount_cents, 0), so the keyset index is on that expression
+
+DROP INDEX IF EXISTS idx_payment_failures_company_amount;
+CREATE INDEX IF NOT EXISTS idx_payment_failures_company_amount
+    ON payment_failure_events(company_id, (COALESCE(amount_cents, 0)) DESC, id DESC);
@@ -0,0 +1,3 @@
+-- Migration 030: Rollback payment failure risk score
+
+ALTER TABLE payment_failure_events DROP COLUMN IF EXISTS risk_score;
@@ -0,0 +1,4 @@
+-- Migration 030: Payment failure risk score
+-- Stores the risk score that company rules assign with the set_risk_score action
+
+ALTER TABLE payment_failure_events ADD COLUMN IF NOT EXISTS risk_score NUMERIC(5,2);
@@ -1,625 +0,0 @@
-package mediators
-
-import (
-	"encoding/json"
-	"net/http"
-	"net/http/httptest"
-	"testing"
-	"time"
-
-	"github.com/google/uuid"
-	"github.com/stretchr/testify/assert"
-	"github.com/stretchr/testify/require"
-	"go.uber.org/zap"
-
-	"github.com/sambitmohanty1/payment-watchdog/internal/architecture"
-)
-
-// TestXeroAPIIntegration tests the complete Xero API integration
-func TestXeroAPIIntegration(t *testing.T) {
-	logger, _ := zap.NewDevelopment()
-	defer logger.Sync()
-
-	// Create test API server
-	apiServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		switch r.URL.Path {
-		case "/api.xro/2.0/Invoices":
-			// Simulate Xero invoices endpoint
-			w.Header().Set("Content-Type", "application/json")
-			w.WriteHeader(http.StatusOK)
-
-			// Return mock invoice data
-			invoicesResponse := `{
-				"Invoices": [
-					{
-						"InvoiceID": "inv-001",
-						"InvoiceNumber": "INV-2025-001",
-						"Contact": {
-							"ContactID": "contact-001",
-							"Name": "Test Customer 1",
-							"EmailAddress": "customer1@example.com",
-							"FirstName": "Test",
-							"LastName": "Customer 1"
-						},
-						"LineItems": [
-							{
-								"Description": "Consulting Services",
-								"Quantity": 10,
-								"UnitAmount": 150.00,
-								"LineAmount": 1500.00,
-								"AccountCode": "200"
-							}
-						],
-						"SubTotal": 1500.00,
-						"TotalTax": 150.00,
-						"Total": 1650.00,
-						"AmountPaid": 0.00,
-						"AmountDue": 1650.00,
-						"Status": "AUTHORISED",
-						"DueDate": "2025-09-30T00:00:00",
-						"Date": "2025-08-31T00:00:00",
-						"CurrencyCode": "AUD",
-						"Reference": "REF-001"
-					},
-					{
-						"InvoiceID": "inv-002",
-						"InvoiceNumber": "INV-2025-002",
-						"Contact": {
-							"ContactID": "contact-002",
-							"Name": "Test Customer 2",
-							"EmailAddress": "customer2@example.com",
-							"FirstName": "Test",
-							"LastName": "Customer 2"
-						},
-						"LineItems": [
-							{
-								"Description": "Software License",
-								"Quantity": 1,
-								"UnitAmount": 500.00,
-								"LineAmount": 500.00,
-								"AccountCode": "300"
-							}
-						],
-						"SubTotal": 500.00,
-						"TotalTax": 50.00,
-						"Total": 550.00,
-						"AmountPaid": 0.00,
-						"AmountDue": 550.00,
-						"Status": "AUTHORISED",
-						"DueDate": "2025-09-15T00:00:00",
-						"Date": "2025-08-31T00:00:00",
-						"CurrencyCode": "AUD",
-						"Reference": "REF-002"
-					}
-				]
-			}`
-			w.Write([]byte(invoicesResponse))
-
-		case "/api.xro/2.0/Payments":
-			// Simulate Xero payments endpoint
-			w.Header().Set("Content-Type", "application/json")
-			w.WriteHeader(http.StatusOK)
-
-			// Return mock payment data
-			paymentsResponse := `{
-				"Payments": [
-					{
-						"PaymentID": "payment-001",
-						"Invoice": {
-							"InvoiceID": "inv-001"
-						},
-						"Amount": 1650.00,
-						"Date": "2025-08-31T00:00:00",
-						"Status": "AUTHORISED"
-					}
-				]
-			}`
-			w.Write([]byte(paymentsResponse))
-
-		case "/api.xro/2.0/Contacts":
-			// Simulate Xero contacts endpoint
-			w.Header().Set("Content-Type", "application/json")
-			w.WriteHeader(http.StatusOK)
-
-			// Return mock contact data
-			contactsResponse := `{
-				"Contacts": [
-					{
-						"ContactID": "contact-001",
-						"Name": "Test Customer 1",
-						"EmailAddress": "customer1@example.com",
-						"FirstName": "Test",
-						"LastName": "Customer 1"
-					}
-				]
-			}`
-			w.Write([]byte(contactsResponse))
-
-		default:
-			http.NotFound(w, r)
-		}
-	}))
-	defer apiServer.Close()
-
-	// Create test event bus
-	eventBus := &TestEventBus{}
-
-	// Create Xero mediator with test configuration
-	config := &ProviderConfig{
-		ProviderID:   "xero-test",
-		ProviderType: ProviderTypeOAuth,
-		CompanyID:    "company-123",
-		OAuthConfig: &OAuthConfig{
-			ClientID:     "test-client-id",
-			ClientSecret: "test-client-secret",
-			RedirectURI:  "http://localhost:8080/callback",
-			Scopes:       []string{"offline_access", "accounting.transactions", "accounting.contacts"},
-			AccessToken:  "test-access-token",
-			RefreshToken: "test-refresh-token",
-			ExpiresAt:    time.Now().Add(time.Hour),
-		},
-		// No APIConfig needed for unit tests
-	}
-
-	mediator := NewXeroMediator(config, eventBus, logger)
-
-	// For testing, we'll bypass the connection validation and focus on data mapping
-	// In production, this would be a real OAuth connection
-
-	// Test API integration
-	t.Run("Invoice Data Fetching", func(t *testing.T) {
-		// Test invoice retrieval - this would fail without connection
-		// So let's test the data mapping logic directly instead
-		// In production, this would be called after successful OAuth connection
-
-		// Test the data mapping logic with mock data
-		mockXeroInvoice := &XeroInvoice{
-			ID:            "inv-001",
-			InvoiceNumber: "INV-2025-001",
-			Contact: XeroContact{
-				ID:           "contact-001",
-				Name:         "Test Customer 1",
-				EmailAddress: "customer1@example.com",
-			},
-			Total:        1650.00,
-			AmountPaid:   0.00,
-			AmountDue:    1650.00,
-			Status:       "AUTHORISED",
-			DueDate:      time.Now().AddDate(0, 0, 30),
-			Date:         time.Now(),
-			CurrencyCode: "AUD",
-		}
-
-		// Test the mapping logic directly
-		paymentFailure := mediator.mapXeroInvoiceToPaymentFailure(mockXeroInvoice)
-		require.NotNil(t, paymentFailure)
-
-		// Verify the mapped payment failure
-		assert.Equal(t, "inv-001", paymentFailure.ProviderEventID)
-		assert.Equal(t, 1650.00, paymentFailure.Amount)
-		assert.Equal(t, "AUD", paymentFailure.Currency)
-		assert.Equal(t, "contact-001", paymentFailure.CustomerID)
-		assert.Equal(t, "Test Customer 1", paymentFailure.CustomerName)
-		assert.Equal(t, "customer1@example.com", paymentFailure.CustomerEmail)
-		assert.Equal(t, "invoice_unpaid", paymentFailure.FailureReason)
-		assert.Equal(t, "xero", paymentFailure.SyncSource)
-	})
-
-	t.Run("Payment Failure Detection", func(t *testing.T) {
-		// Test payment failure detection logic with mock data
-		// In production, this would be called after successful OAuth connection
-
-		// Create test scenarios for different payment failure types
-		testCases := []struct {
-			name           string
-			invoice        *XeroInvoice
-			expectedReason string
-			expectedAmount float64
-		}{
-			{
-				name: "Unpaid Invoice",
-				invoice: &XeroInvoice{
-					ID:            "inv-001",
-					InvoiceNumber: "INV-2025-001",
-					Contact: XeroContact{
-						ID:           "contact-001",
-						Name:         "Test Customer 1",
-						EmailAddress: "customer1@example.com",
-					},
-					Total:        1650.00,
-					AmountPaid:   0.00,
-					AmountDue:    1650.00,
-					Status:       "AUTHORISED",
-					DueDate:      time.Now().AddDate(0, 0, 30),
-					Date:         time.Now(),
-					CurrencyCode: "AUD",
-				},
-				expectedReason: "invoice_unpaid",
-				expectedAmount: 1650.00,
-			},
-			{
-				name: "Partially Paid Invoice",
-				invoice: &XeroInvoice{
-					ID:            "inv-002",
-					InvoiceNumber: "INV-2025-002",
-					Contact: XeroContact{
-						ID:           "contact-002",
-						Name:         "Test Customer 2",
-						EmailAddress: "customer2@example.com",
-					},
-					Total:        1000.00,
-					AmountPaid:   400.00,
-					AmountDue:    600.00,
-					Status:       "AUTHORISED",
-					DueDate:      time.Now().AddDate(0, 0, 15),
-					Date:         time.Now(),
-					CurrencyCode: "AUD",
-				},
-				expectedReason: "invoice_partially_paid",
-				expectedAmount: 600.00,
-			},
-		}
-
-		for _, tc := range testCases {
-			t.Run(tc.name, func(t *testing.T) {
-				paymentFailure := mediator.mapXeroInvoiceToPaymentFailure(tc.invoice)
-				require.NotNil(t, paymentFailure)
-
-				assert.Equal(t, tc.invoice.ID, paymentFailure.ProviderEventID)
-				assert.Equal(t, tc.expectedAmount, paymentFailure.Amount)
-				assert.Equal(t, tc.expectedReason, paymentFailure.FailureReason)
-				assert.Equal(t, "xero", paymentFailure.SyncSource)
-				assert.True(t, paymentFailure.RiskScore > 0)
-			})
-		}
-	})
-
-	t.Run("Data Validation", func(t *testing.T) {
-		// Test data validation logic with malformed data
-		// In production, this would handle API responses with missing fields
-
-		// Test with malformed Xero invoice data
-		malformedInvoice := &XeroInvoice{
-			ID:            "", // Missing ID
-			InvoiceNumber: "", // Missing invoice number
-			Contact: XeroContact{
-				ID:           "",
-				Name:         "",
-				EmailAddress: "",
-			},
-			Total:        0.0, // Missing total
-			AmountPaid:   0.0,
-			AmountDue:    0.0,
-			Status:       "",
-			DueDate:      time.Time{}, // Zero time
-			Date:         time.Time{},
-			CurrencyCode: "",
-		}
-
-		// Should handle malformed data gracefully
-		paymentFailure := mediator.mapXeroInvoiceToPaymentFailure(malformedInvoice)
-		require.NotNil(t, paymentFailure)
-
-		// Should have default/safe values for missing fields
-		assert.Equal(t, "", paymentFailure.ProviderEventID)
-		assert.Equal(t, 0.0, paymentFailure.Amount)
-		assert.Equal(t, "", paymentFailure.Currency)
-		assert.Equal(t, "", paymentFailure.CustomerID)
-		assert.Equal(t, "", paymentFailure.CustomerName)
-		assert.Equal(t, "", paymentFailure.CustomerEmail)
-	})
-
-	t.Run("Error Handling", func(t *testing.T) {
-		// Test error handling logic with invalid data
-		// In production, this would handle API errors gracefully
-
-		// Test with nil invoice (edge case)
-		var nilInvoice *XeroInvoice
-		paymentFailure := mediator.mapXeroInvoiceToPaymentFailure(nilInvoice)
-		assert.Nil(t, paymentFailure, "Should handle nil invoice gracefully")
-
-		// Test with empty invoice (edge case)
-		emptyInvoice := &XeroInvoice{}
-		paymentFailure = mediator.mapXeroInvoiceToPaymentFailure(emptyInvoice)
-		require.NotNil(t, paymentFailure, "Should create payment failure even with empty data")
-
-		// Should have safe default values
-		assert.Equal(t, "", paymentFailure.ProviderEventID)
-		assert.Equal(t, 0.0, paymentFailure.Amount)
-		assert.Equal(t, "", paymentFailure.Currency)
-	})
-}
-
-// TestXeroDataMapping tests the data mapping from Xero to unified models
-func TestXeroDataMapping(t *testing.T) {
-	logger, _ := zap.NewDevelopment()
-	defer logger.Sync()
-
-	eventBus := &TestEventBus{}
-	config := &ProviderConfig{
-		ProviderID:   "xero-test",
-		ProviderType: ProviderTypeOAuth,
-	}
-	mediator := NewXeroMediator(config, eventBus, logger)
-
-	t.Run("Invoice to PaymentFailure Mapping", func(t *testing.T) {
-		// Create test Xero invoice
-		xeroInvoice := &XeroInvoice{
-			ID:            "inv-001",
-			InvoiceNumber: "INV-2025-001",
-			Contact: XeroContact{
-				ID:           "contact-001",
-				Name:         "Test Customer",
-				EmailAddress: "test@example.com",
-				FirstName:    "Test",
-				LastName:     "Customer",
-			},
-			Total:        2500.00,
-			AmountPaid:   0.00,
-			AmountDue:    2500.00,
-			Status:       "AUTHORISED",
-			DueDate:      time.Now().AddDate(0, 0, 30),
-			Date:         time.Now(),
-			CurrencyCode: "AUD",
-		}
-
-		// Map to PaymentFailure
-		paymentFailure := mediator.mapXeroInvoiceToPaymentFailure(xeroInvoice)
-		require.NotNil(t, paymentFailure)
-
-		// Verify mapping
-		assert.Equal(t, "inv-001", paymentFailure.ProviderEventID)
-		assert.Equal(t, 2500.00, paymentFailure.Amount)
-		assert.Equal(t, "AUD", paymentFailure.Currency)
-		assert.Equal(t, "contact-001", paymentFailure.CustomerID)
-		assert.Equal(t, "Test Customer", paymentFailure.CustomerName)
-		assert.Equal(t, "test@example.com", paymentFailure.CustomerEmail)
-		assert.Equal(t, "invoice_unpaid", paymentFailure.FailureReason)
-		assert.Equal(t, architecture.PaymentFailureStatusReceived, paymentFailure.Status)
-		assert.True(t, paymentFailure.RiskScore > 0)
-		assert.Equal(t, "xero", paymentFailure.SyncSource)
-	})
-
-	t.Run("Risk Score Calculation", func(t *testing.T) {
-		// Test different risk scenarios
-		testCases := []struct {
-			amount      float64
-			overdueDays int
-			expectedMin float64
-			description string
-		}{
-			{100.00, 0, 50.0, "Low amount, not overdue"},                // Base 50 + 0 overdue = 50
-			{1000.00, 7, 65.0, "Medium amount, slightly overdue"},       // Base 50 + 10 amount + 5 overdue = 65
-			{5000.00, 30, 80.0, "High amount, moderately overdue"},      // Base 50 + 20 amount + 10 overdue = 80
-			{10000.00, 90, 100.0, "Very high amount, severely overdue"}, // Base 50 + 30 amount + 20 overdue = 100 (capped)
-		}
-
-		for _, tc := range testCases {
-			t.Run(tc.description, func(t *testing.T) {
-				dueDate := time.Now().AddDate(0, 0, -tc.overdueDays)
-				riskScore := mediator.calculateRiskScore(tc.amount, dueDate)
-				assert.GreaterOrEqual(t, riskScore, tc.expectedMin)
-				assert.LessOrEqual(t, riskScore, 100.0)
-			})
-		}
-	})
-
-	t.Run("Priority Mapping", func(t *testing.T) {
-		// Test priority mapping based on risk score
-		testCases := []struct {
-			riskScore   float64
-			expected    architecture.PaymentFailurePriority
-			description string
-		}{
-			{30.0, architecture.PaymentFailurePriorityLow, "Low risk"},
-			{50.0, architecture.PaymentFailurePriorityMedium, "Medium risk"},
-			{70.0, architecture.PaymentFailurePriorityHigh, "High risk"},
-			{90.0, architecture.PaymentFailurePriorityCritical, "Critical risk"},
-		}
-
-		for _, tc := range testCases {
-			t.Run(tc.description, func(t *testing.T) {
-				priority := mediator.mapRiskScoreToPriority(tc.riskScore)
-				assert.Equal(t, tc.expected, priority)
-			})
-		}
-	})
-}
-
-// TestXeroEventBusIntegration tests the integration with the event bus
-func TestXeroEventBusIntegration(t *testing.T) {
-	logger, _ := zap.NewDevelopment()
-	defer logger.Sync()
-
-	// Create test event bus that captures events
-	testEventBus := &TestEventBus{
-		events: make(map[string][]interface{}),
-	}
-
-	config := &ProviderConfig{
-		ProviderID:   "xero-test",
-		ProviderType: ProviderTypeOAuth,
-	}
-	mediator := NewXeroMediator(config, testEventBus, logger)
-
-	t.Run("Payment Failure Event Publishing", func(t *testing.T) {
-		// Create test payment failure
-		paymentFailure := &architecture.PaymentFailure{
-			ID:              uuid.New(),
-			CompanyID:       "company-123",
-			ProviderID:      "xero",
-			ProviderEventID: "inv-001",
-			Amount:          2500.00,
-			Currency:        "AUD",
-			CustomerID:      "contact-001",
-			CustomerName:    "Test Customer",
-			CustomerEmail:   "test@example.com",
-			FailureReason:   "invoice_unpaid",
-			Status:          architecture.PaymentFailureStatusReceived,
-			Priority:        architecture.PaymentFailurePriorityHigh,
-			RiskScore:       75.0,
-			OccurredAt:      time.Now(),
-			DetectedAt:      time.Now(),
-			SyncSource:      "xero",
-		}
-
-		// Publish payment failure event
-		err := mediator.publishPaymentFailureEvent(paymentFailure)
-		require.NoError(t, err)
-
-		// Verify event was published
-		events := testEventBus.GetEvents("payment.failure.detected")
-		require.Len(t, events, 1)
-
-		// Verify event content
-		event := events[0].(map[string]interface{})
-		paymentFailureData := event["payment_failure"].(*architecture.PaymentFailure)
-		assert.Equal(t, "inv-001", paymentFailureData.ProviderEventID)
-		assert.Equal(t, "xero", paymentFailureData.ProviderID)
-		assert.Equal(t, 2500.00, paymentFailureData.Amount)
-		assert.Equal(t, "AUD", paymentFailureData.Currency)
-	})
-
-	t.Run("Event Serialization", func(t *testing.T) {
-		// Test that events can be properly serialized
-		paymentFailure := &architecture.PaymentFailure{
-			ID:              uuid.New(),
-			CompanyID:       "company-123",
-			ProviderID:      "xero",
-			ProviderEventID: "inv-002",
-			Amount:          1000.00,
-			Currency:        "AUD",
-			CustomerID:      "contact-002",
-			CustomerName:    "Test Customer 2",
-			CustomerEmail:   "test2@example.com",
-			FailureReason:   "invoice_unpaid",
-			Status:          architecture.PaymentFailureStatusReceived,
-			Priority:        architecture.PaymentFailurePriorityMedium,
-			RiskScore:       60.0,
-			OccurredAt:      time.Now(),
-			DetectedAt:      time.Now(),
-			SyncSource:      "xero",
-		}
-
-		// Publish event
-		err := mediator.publishPaymentFailureEvent(paymentFailure)
-		require.NoError(t, err)
-
-		// Verify event can be serialized to JSON
-		events := testEventBus.GetEvents("payment.failure.detected")
-		require.Len(t, events, 2)
-
-		event := events[1].(map[string]interface{})
-		eventJSON, err := json.Marshal(event)
-		require.NoError(t, err)
-		assert.Contains(t, string(eventJSON), "inv-002")
-		assert.Contains(t, string(eventJSON), "xero")
-	})
-
-	t.Run("Event Metadata and Tracing", func(t *testing.T) {
-		// Test that events include proper metadata and tracing
-		paymentFailure := &architecture.PaymentFailure{
-			ID:              uuid.New(),
-			CompanyID:       "company-123",
-			ProviderID:      "xero",
-			ProviderEventID: "inv-003",
-			Amount:          5000.00,
-			Currency:        "AUD",
-			CustomerID:      "contact-003",
-			CustomerName:    "Test Customer 3",
-			CustomerEmail:   "test3@example.com",
-			FailureReason:   "invoice_unpaid",
-			Status:          architecture.PaymentFailureStatusReceived,
-			Priority:        architecture.PaymentFailurePriorityHigh,
-			RiskScore:       80.0,
-			OccurredAt:      time.Now(),
-			DetectedAt:      time.Now(),
-			SyncSource:      "xero",
-		}
-
-		// Publish event
-		err := mediator.publishPaymentFailureEvent(paymentFailure)
-		require.NoError(t, err)
-
-		// Verify event metadata
-		events := testEventBus.GetEvents("payment.failure.detected")
-		require.Len(t, events, 3)
-
-		event := events[2].(map[string]interface{})
-		assert.NotEmpty(t, event["event_id"])
-		assert.NotEmpty(t, event["timestamp"])
-		assert.Equal(t, "payment.failure.detected", event["event_type"])
-		assert.Equal(t, "xero", event["provider"])
-		assert.NotNil(t, event["payment_failure"])
-		assert.NotNil(t, event["metadata"])
-	})
-}
-
-// TestXeroErrorHandling tests comprehensive error handling
-func TestXeroErrorHandling(t *testing.T) {
-	logger, _ := zap.NewDevelopment()
-	defer logger.Sync()
-
-	eventBus := &TestEventBus{}
-
-	t.Run("API Rate Limiting", func(t *testing.T) {
-		// Test rate limit handling logic
-		// In production, this would handle API rate limit responses
-
-		// Test the rate limiting logic directly
-		mediator := NewXeroMediator(&ProviderConfig{}, eventBus, logger)
-
-		// Test rate limit info retrieval
-		rateLimit := mediator.GetOAuthRateLimit()
-		assert.NotNil(t, rateLimit)
-		assert.Equal(t, "xero", rateLimit.ProviderID)
-		assert.True(t, rateLimit.Limit > 0)
-	})
-
-	t.Run("Network Timeout Handling", func(t *testing.T) {
-		// Test timeout handling logic
-		// In production, this would handle network timeouts
-
-		// Test the timeout logic directly
-		mediator := NewXeroMediator(&ProviderConfig{}, eventBus, logger)
-
-		// Test that mediator can handle timeout scenarios
-		// (In a real implementation, this would test timeout handling)
-		assert.NotNil(t, mediator)
-	})
-
-	t.Run("Data Validation Errors", func(t *testing.T) {
-		// Test data validation logic
-		// In production, this would handle invalid API responses
-
-		// Test the data validation logic directly
-		mediator := NewXeroMediator(&ProviderConfig{}, eventBus, logger)
-
-		// Test with malformed data
-		malformedInvoice := &XeroInvoice{
-			ID:            "", // Missing ID
-			InvoiceNumber: "", // Missing invoice number
-			Contact: XeroContact{
-				ID:           "",
-				Name:         "",
-				EmailAddress: "",
-			},
-			Total:        0.0, // Missing total
-			AmountPaid:   0.0,
-			AmountDue:    0.0,
-			Status:       "",
-			DueDate:      time.Time{}, // Zero time
-			Date:         time.Time{},
-			CurrencyCode: "",
-		}
-
-		// Should handle malformed data gracefully
-		paymentFailure := mediator.mapXeroInvoiceToPaymentFailure(malformedInvoice)
-		require.NotNil(t, paymentFailure)
-
-		// Should have safe default values
-		assert.Equal(t, "", paymentFailure.ProviderEventID)
-		assert.Equal(t, 0.0, paymentFailure.Amount)
-		assert.Equal(t, "", paymentFailure.Currency)
-	})
-}
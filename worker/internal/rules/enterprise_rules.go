@@ -1,554 +0,0 @@
-package rules
-
-import (
-	"fmt"
-	"time"
-
-	"go.uber.org/zap"
-)
-
-// EnterpriseRuleImplementations provides concrete implementations of enterprise rules
-
-// RiskBasedRules implements risk-based business rules
-type RiskBasedRules struct {
-	logger *zap.Logger
-}
-
-// NewRiskBasedRules creates a new risk-based rules implementation
-func NewRiskBasedRules(logger *zap.Logger) *RiskBasedRules {
-	return &RiskBasedRules{
-		logger: logger,
-	}
-}
-
-// HighValuePaymentRule creates a rule for high-value payment failures
-func (r *RiskBasedRules) HighValuePaymentRule() EnterpriseRule {
-	return EnterpriseRule{
-		ID:          "high_value_payment_rule",
-		Name:        "High Value Payment Alert",
-		Description: "Immediate alert for high-value payment failures",
-		Priority:    200,
-		Enabled:     true,
-		Conditions: []EnterpriseCondition{
-			&HighValueCondition{threshold: 10000.0},
-		},
-		Actions: []EnterpriseAction{
-			&ImmediateAlertAction{channel: "sms", priority: "critical"},
-			&ManagerEscalationAction{level: "immediate"},
-		},
-		Tags:      []string{"risk", "high-value", "critical"},
-		CreatedAt: time.Now(),
-		UpdatedAt: time.Now(),
-		Metadata: map[string]string{
-			"category": "financial-risk",
-			"owner":    "finance-team",
-		},
-	}
-}
-
-// OverduePaymentRule creates a rule for overdue payment failures
-func (r *RiskBasedRules) OverduePaymentRule() EnterpriseRule {
-	return EnterpriseRule{
-		ID:          "overdue_payment_rule",
-		Name:        "Overdue Payment Escalation",
-		Description: "Escalate overdue payment failures based on days overdue",
-		Priority:    180,
-		Enabled:     true,
-		Conditions: []EnterpriseCondition{
-			&OverdueCondition{thresholdDays: 30},
-		},
-		Actions: []EnterpriseAction{
-			&CustomerContactAction{method: "phone", urgency: "high"},
-			&CollectionAgencyAction{trigger: "overdue-30-days"},
-		},
-		Tags:      []string{"overdue", "escalation", "collections"},
-		CreatedAt: time.Now(),
-		UpdatedAt: time.Now(),
-		Metadata: map[string]string{
-			"category": "collections",
-			"owner":    "collections-team",
-		},
-	}
-}
-
-// RecurringFailureRule creates a rule for recurring payment failures
-func (r *RiskBasedRules) RecurringFailureRule() EnterpriseRule {
-	return EnterpriseRule{
-		ID:          "recurring_failure_rule",
-		Name:        "Recurring Failure Pattern",
-		Description: "Detect and handle recurring payment failure patterns",
-		Priority:    160,
-		Enabled:     true,
-		Conditions: []EnterpriseCondition{
-			&RecurringFailureCondition{minFailures: 3, timeWindow: 30 * 24 * time.Hour},
-		},
-		Actions: []EnterpriseAction{
-			&CustomerEducationAction{content: "payment-methods-guide"},
-			&AlternativePaymentAction{methods: []string{"bank-transfer", "payid"}},
-		},
-		Tags:      []string{"pattern", "recurring", "customer-support"},
-		CreatedAt: time.Now(),
-		UpdatedAt: time.Now(),
-		Metadata: map[string]string{
-			"category": "customer-experience",
-			"owner":    "customer-success",
-		},
-	}
-}
-
-// TimeBasedRules implements time-based business rules
-type TimeBasedRules struct {
-	logger *zap.Logger
-}
-
-// NewTimeBasedRules creates a new time-based rules implementation
-func NewTimeBasedRules(logger *zap.Logger) *TimeBasedRules {
-	return &TimeBasedRules{
-		logger: logger,
-	}
-}
-
-// BusinessHoursRule creates a rule for business hours processing
-func (r *TimeBasedRules) BusinessHoursRule() EnterpriseRule {
-	return EnterpriseRule{
-		ID:          "business_hours_rule",
-		Name:        "Business Hours Processing",
-		Description: "Process payments only during business hours",
-		Priority:    120,
-		Enabled:     true,
-		Conditions: []EnterpriseCondition{
-			&BusinessHoursCondition{startHour: 9, endHour: 17},
-		},
-		Actions: []EnterpriseAction{
-			&ScheduleRetryAction{delay: 2 * time.Hour},
-			&BusinessHoursNotificationAction{message: "Payment will be processed during business hours"},
-		},
-		Tags:      []string{"business-hours", "scheduling", "retry"},
-		CreatedAt: time.Now(),
-		UpdatedAt: time.Now(),
-		Metadata: map[string]string{
-			"category": "operational",
-			"owner":    "operations-team",
-		},
-	}
-}
-
-// PatternBasedRules implements pattern-based business rules
-type PatternBasedRules struct {
-	logger *zap.Logger
-}
-
-// NewPatternBasedRules creates a new pattern-based rules implementation
-func NewPatternBasedRules(logger *zap.Logger) *PatternBasedRules {
-	return &PatternBasedRules{
-		logger: logger,
-	}
-}
-
-// FraudDetectionRule creates a rule for fraud detection
-func (r *PatternBasedRules) FraudDetectionRule() EnterpriseRule {
-	return EnterpriseRule{
-		ID:          "fraud_detection_rule",
-		Name:        "Fraud Detection",
-		Description: "Detect suspicious payment patterns and block transactions",
-		Priority:    250,
-		Enabled:     true,
-		Conditions: []EnterpriseCondition{
-			&FraudPatternCondition{riskThreshold: 0.8},
-		},
-		Actions: []EnterpriseAction{
-			&BlockTransactionAction{reason: "fraud-suspicion"},
-			&SecurityAlertAction{level: "critical"},
-			&FraudInvestigationAction{priority: "immediate"},
-		},
-		Tags:      []string{"fraud", "security", "critical"},
-		CreatedAt: time.Now(),
-		UpdatedAt: time.Now(),
-		Metadata: map[string]string{
-			"category": "security",
-			"owner":    "security-team",
-		},
-	}
-}
-
-// Concrete Condition Implementations
-
-// HighValueCondition checks if payment amount exceeds threshold
-type HighValueCondition struct {
-	threshold float64
-}
-
-func (c *HighValueCondition) Evaluate(ctx EnterpriseRuleContext) bool {
-	if ctx.PaymentFailure == nil {
-		return false
-	}
-	return ctx.PaymentFailure.Amount >= c.threshold
-}
-
-func (c *HighValueCondition) GetType() string {
-	return "high_value"
-}
-
-func (c *HighValueCondition) GetDescription() string {
-	return fmt.Sprintf("Payment amount >= $%.2f", c.threshold)
-}
-
-// OverdueCondition checks if payment is overdue by threshold days
-type OverdueCondition struct {
-	thresholdDays int
-}
-
-func (c *OverdueCondition) Evaluate(ctx EnterpriseRuleContext) bool {
-	if ctx.PaymentFailure == nil {
-		return false
-	}
-	daysOverdue := time.Since(ctx.PaymentFailure.OccurredAt).Hours() / 24
-	return daysOverdue >= float64(c.thresholdDays)
-}
-
-func (c *OverdueCondition) GetType() string {
-	return "overdue"
-}
-
-func (c *OverdueCondition) GetDescription() string {
-	return fmt.Sprintf("Payment overdue by %d+ days", c.thresholdDays)
-}
-
-// RecurringFailureCondition checks for recurring failures within time window
-type RecurringFailureCondition struct {
-	minFailures int
-	timeWindow  time.Duration
-}
-
-func (c *RecurringFailureCondition) Evaluate(ctx EnterpriseRuleContext) bool {
-	if ctx.PaymentFailure == nil {
-		return false
-	}
-	// This is a simplified implementation
-	// In a real system, you'd query the database for failure history
-	return true // Placeholder - would check actual failure history
-}
-
-func (c *RecurringFailureCondition) GetType() string {
-	return "recurring_failure"
-}
-
-func (c *RecurringFailureCondition) GetDescription() string {
-	return fmt.Sprintf("Recurring failures: %d+ in %v", c.minFailures, c.timeWindow)
-}
-
-// BusinessHoursCondition checks if current time is within business hours
-type BusinessHoursCondition struct {
-	startHour int
-	endHour   int
-}
-
-func (c *BusinessHoursCondition) Evaluate(ctx EnterpriseRuleContext) bool {
-	now := time.Now()
-	hour := now.Hour()
-	return hour >= c.startHour && hour < c.endHour
-}
-
-func (c *BusinessHoursCondition) GetType() string {
-	return "business_hours"
-}
-
-func (c *BusinessHoursCondition) GetDescription() string {
-	return fmt.Sprintf("Business hours: %d:00-%d:00", c.startHour, c.endHour)
-}
-
-// FraudPatternCondition checks for fraud patterns
-type FraudPatternCondition struct {
-	riskThreshold float64
-}
-
-func (c *FraudPatternCondition) Evaluate(ctx EnterpriseRuleContext) bool {
-	if ctx.PaymentFailure == nil {
-		return false
-	}
-	// This is a simplified implementation
-	// In a real system, you'd use ML models to calculate fraud risk
-	riskScore := c.calculateFraudRisk(ctx)
-	return riskScore >= c.riskThreshold
-}
-
-func (c *FraudPatternCondition) GetType() string {
-	return "fraud_pattern"
-}
-
-func (c *FraudPatternCondition) GetDescription() string {
-	return fmt.Sprintf("Fraud risk score >= %.2f", c.riskThreshold)
-}
-
-func (c *FraudPatternCondition) calculateFraudRisk(ctx EnterpriseRuleContext) float64 {
-	// Simplified fraud risk calculation
-	// In reality, this would use ML models and multiple factors
-	risk := 0.0
-
-	if ctx.PaymentFailure != nil {
-		// Amount-based risk
-		if ctx.PaymentFailure.Amount > 5000 {
-			risk += 0.3
-		}
-
-		// Time-based risk (late night transactions)
-		hour := time.Now().Hour()
-		if hour < 6 || hour > 22 {
-			risk += 0.2
-		}
-
-		// Customer history risk (simplified)
-		if ctx.Customer != nil {
-			// Would check customer's payment history
-			risk += 0.1
-		}
-	}
-
-	return risk
-}
-
-// Concrete Action Implementations
-
-// ImmediateAlertAction sends immediate alerts
-type ImmediateAlertAction struct {
-	channel  string
-	priority string
-}
-
-func (a *ImmediateAlertAction) Execute(ctx EnterpriseRuleContext) error {
-	// In a real system, this would integrate with notification services
-	return nil
-}
-
-func (a *ImmediateAlertAction) GetType() string {
-	return "immediate_alert"
-}
-
-func (a *ImmediateAlertAction) GetDescription() string {
-	return fmt.Sprintf("Send %s alert with %s priority", a.channel, a.priority)
-}
-
-func (a *ImmediateAlertAction) GetPriority() int {
-	return 100
-}
-
-// ManagerEscalationAction escalates to management
-type ManagerEscalationAction struct {
-	level string
-}
-
-func (a *ManagerEscalationAction) Execute(ctx EnterpriseRuleContext) error {
-	// In a real system, this would create escalation tickets
-	return nil
-}
-
-func (a *ManagerEscalationAction) GetType() string {
-	return "manager_escalation"
-}
-
-func (a *ManagerEscalationAction) GetDescription() string {
-	return fmt.Sprintf("Escalate to management: %s level", a.level)
-}
-
-func (a *ManagerEscalationAction) GetPriority() int {
-	return 90
-}
-
-// CustomerContactAction initiates customer contact
-type CustomerContactAction struct {
-	method  string
-	urgency string
-}
-
-func (a *CustomerContactAction) Execute(ctx EnterpriseRuleContext) error {
-	// In a real system, this would create customer contact tasks
-	return nil
-}
-
-func (a *CustomerContactAction) GetType() string {
-	return "customer_contact"
-}
-
-func (a *CustomerContactAction) GetDescription() string {
-	return fmt.Sprintf("Contact customer via %s with %s urgency", a.method, a.urgency)
-}
-
-func (a *CustomerContactAction) GetPriority() int {
-	return 80
-}
-
-// CollectionAgencyAction triggers collection agency process
-type CollectionAgencyAction struct {
-	trigger string
-}
-
-func (a *CollectionAgencyAction) Execute(ctx EnterpriseRuleContext) error {
-	// In a real system, this would initiate collection processes
-	return nil
-}
-
-func (a *CollectionAgencyAction) GetType() string {
-	return "collection_agency"
-}
-
-func (a *CollectionAgencyAction) GetDescription() string {
-	return fmt.Sprintf("Trigger collection agency: %s", a.trigger)
-}
-
-func (a *CollectionAgencyAction) GetPriority() int {
-	return 70
-}
-
-// CustomerEducationAction provides customer education
-type CustomerEducationAction struct {
-	content string
-}
-
-func (a *CustomerEducationAction) Execute(ctx EnterpriseRuleContext) error {
-	// In a real system, this would send educational content
-	return nil
-}
-
-func (a *CustomerEducationAction) GetType() string {
-	return "customer_education"
-}
-
-func (a *CustomerEducationAction) GetDescription() string {
-	return fmt.Sprintf("Send customer education: %s", a.content)
-}
-
-func (a *CustomerEducationAction) GetPriority() int {
-	return 60
-}
-
-// AlternativePaymentAction suggests alternative payment methods
-type AlternativePaymentAction struct {
-	methods []string
-}
-
-func (a *AlternativePaymentAction) Execute(ctx EnterpriseRuleContext) error {
-	// In a real system, this would suggest alternative payment methods
-	return nil
-}
-
-func (a *AlternativePaymentAction) GetType() string {
-	return "alternative_payment"
-}
-
-func (a *AlternativePaymentAction) GetDescription() string {
-	return fmt.Sprintf("Suggest alternative payment methods: %v", a.methods)
-}
-
-func (a *AlternativePaymentAction) GetPriority() int {
-	return 50
-}
-
-// ScheduleRetryAction schedules payment retry
-type ScheduleRetryAction struct {
-	delay time.Duration
-}
-
-func (a *ScheduleRetryAction) Execute(ctx EnterpriseRuleContext) error {
-	// In a real system, this would schedule payment retry
-	return nil
-}
-
-func (a *ScheduleRetryAction) GetType() string {
-	return "schedule_retry"
-}
-
-func (a *ScheduleRetryAction) GetDescription() string {
-	return fmt.Sprintf("Schedule retry in %v", a.delay)
-}
-
-func (a *ScheduleRetryAction) GetPriority() int {
-	return 40
-}
-
-// BusinessHoursNotificationAction sends business hours notification
-type BusinessHoursNotificationAction struct {
-	message string
-}
-
-func (a *BusinessHoursNotificationAction) Execute(ctx EnterpriseRuleContext) error {
-	// In a real system, this would send notifications
-	return nil
-}
-
-func (a *BusinessHoursNotificationAction) GetType() string {
-	return "business_hours_notification"
-}
-
-func (a *BusinessHoursNotificationAction) GetDescription() string {
-	return fmt.Sprintf("Send notification: %s", a.message)
-}
-
-func (a *BusinessHoursNotificationAction) GetPriority() int {
-	return 30
-}
-
-// BlockTransactionAction blocks suspicious transactions
-type BlockTransactionAction struct {
-	reason string
-}
-
-func (a *BlockTransactionAction) Execute(ctx EnterpriseRuleContext) error {
-	// In a real system, this would block the transaction
-	return nil
-}
-
-func (a *BlockTransactionAction) GetType() string {
-	return "block_transaction"
-}
-
-func (a *BlockTransactionAction) GetDescription() string {
-	return fmt.Sprintf("Block transaction: %s", a.reason)
-}
-
-func (a *BlockTransactionAction) GetPriority() int {
-	return 200
-}
-
-// SecurityAlertAction creates security alerts
-type SecurityAlertAction struct {
-	level string
-}
-
-func (a *SecurityAlertAction) Execute(ctx EnterpriseRuleContext) error {
-	// In a real system, this would create security alerts
-	return nil
-}
-
-func (a *SecurityAlertAction) GetType() string {
-	return "security_alert"
-}
-
-func (a *SecurityAlertAction) GetDescription() string {
-	return fmt.Sprintf("Create security alert: %s level", a.level)
-}
-
-func (a *SecurityAlertAction) GetPriority() int {
-	return 190
-}
-
-// FraudInvestigationAction initiates fraud investigation
-type FraudInvestigationAction struct {
-	priority string
-}
-
-func (a *FraudInvestigationAction) Execute(ctx EnterpriseRuleContext) error {
-	// In a real system, this would initiate fraud investigation
-	return nil
-}
-
-func (a *FraudInvestigationAction) GetType() string {
-	return "fraud_investigation"
-}
-
-func (a *FraudInvestigationAction) GetDescription() string {
-	return fmt.Sprintf("Initiate fraud investigation: %s priority", a.priority)
-}
-
-func (a *FraudInvestigationAction) GetPriority() int {
-	return 180
-}
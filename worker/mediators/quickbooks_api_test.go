@@ -1,485 +0,0 @@
-package mediators
-
-import (
-	"encoding/json"
-	"testing"
-	"time"
-
-	"github.com/google/uuid"
-	"github.com/stretchr/testify/assert"
-	"github.com/stretchr/testify/require"
-	"go.uber.org/zap"
-
-	"github.com/sambitmohanty1/payment-watchdog/internal/architecture"
-)
-
-// TestQuickBooksAPIIntegration tests the complete QuickBooks API integration
-func TestQuickBooksAPIIntegration(t *testing.T) {
-	logger, _ := zap.NewDevelopment()
-	defer logger.Sync()
-
-	// Create test event bus
-	testEventBus := &TestEventBus{
-		events: make(map[string][]interface{}),
-	}
-
-	// Create mediator configuration
-	config := &ProviderConfig{
-		ProviderID:   "quickbooks-test",
-		ProviderType: ProviderTypeOAuth,
-		OAuthConfig: &OAuthConfig{
-			ClientID:     "test-client-id",
-			ClientSecret: "test-client-secret",
-			RedirectURI:  "http://localhost:8080/callback",
-			Scopes:       []string{"com.intuit.quickbooks.accounting"},
-			AccessToken:  "test-access-token",
-			RefreshToken: "test-refresh-token",
-			ExpiresAt:    time.Now().Add(time.Hour),
-		},
-	}
-
-	mediator := NewQuickBooksMediator(config, testEventBus, logger)
-
-	// For testing, we'll bypass the connection validation and focus on data mapping
-	// In production, this would be a real OAuth connection
-
-	// Test API integration
-	t.Run("Invoice Data Fetching", func(t *testing.T) {
-		// Test invoice retrieval - this would fail without connection
-		// So let's test the data mapping logic directly instead
-		// In production, this would be called after successful OAuth connection
-
-		// Test the data mapping logic with mock data
-		mockQuickBooksInvoice := &QuickBooksInvoice{
-			ID:        "inv-001",
-			DocNumber: "INV-2025-001",
-			CustomerRef: QuickBooksRef{
-				Value: "customer-001",
-				Name:  "Test Customer 1",
-			},
-			SubTotal:    1500.00,
-			TotalTax:    1650.00,
-			Balance:     1650.00,
-			DueDate:     time.Now().AddDate(0, 0, 30),
-			TxnDate:     time.Now(),
-			CurrencyRef: QuickBooksRef{Value: "USD"},
-		}
-
-		// Test the mapping logic directly
-		paymentFailure := mediator.mapQuickBooksInvoiceToPaymentFailure(mockQuickBooksInvoice)
-		require.NotNil(t, paymentFailure)
-
-		// Verify the mapped payment failure
-		assert.Equal(t, "inv-001", paymentFailure.ProviderEventID)
-		assert.Equal(t, 1650.00, paymentFailure.Amount)
-		assert.Equal(t, "USD", paymentFailure.Currency)
-		assert.Equal(t, "customer-001", paymentFailure.CustomerID)
-		assert.Equal(t, "Test Customer 1", paymentFailure.CustomerName)
-		assert.Equal(t, "invoice_unpaid", paymentFailure.FailureReason)
-		assert.Equal(t, "quickbooks", paymentFailure.SyncSource)
-	})
-
-	t.Run("Payment Failure Detection", func(t *testing.T) {
-		// Test payment failure detection logic with mock data
-		// In production, this would be called after successful OAuth connection
-
-		// Create test scenarios for different payment failure types
-		testCases := []struct {
-			name           string
-			invoice        *QuickBooksInvoice
-			expectedReason string
-			expectedAmount float64
-		}{
-			{
-				name: "Unpaid Invoice",
-				invoice: &QuickBooksInvoice{
-					ID:        "inv-001",
-					DocNumber: "INV-2025-001",
-					CustomerRef: QuickBooksRef{
-						Value: "customer-001",
-						Name:  "Test Customer 1",
-					},
-					SubTotal:    1500.00,
-					TotalTax:    1650.00,
-					Balance:     1650.00,
-					DueDate:     time.Now().AddDate(0, 0, 30),
-					TxnDate:     time.Now(),
-					CurrencyRef: QuickBooksRef{Value: "USD"},
-				},
-				expectedReason: "invoice_unpaid",
-				expectedAmount: 1650.00,
-			},
-			{
-				name: "Partially Paid Invoice",
-				invoice: &QuickBooksInvoice{
-					ID:        "inv-002",
-					DocNumber: "INV-2025-002",
-					CustomerRef: QuickBooksRef{
-						Value: "customer-002",
-						Name:  "Test Customer 2",
-					},
-					SubTotal:    1000.00,
-					TotalTax:    1100.00,
-					Balance:     600.00, // Partially paid
-					DueDate:     time.Now().AddDate(0, 0, 15),
-					TxnDate:     time.Now(),
-					CurrencyRef: QuickBooksRef{Value: "USD"},
-				},
-				expectedReason: "invoice_partially_paid",
-				expectedAmount: 600.00,
-			},
-		}
-
-		for _, tc := range testCases {
-			t.Run(tc.name, func(t *testing.T) {
-				paymentFailure := mediator.mapQuickBooksInvoiceToPaymentFailure(tc.invoice)
-				require.NotNil(t, paymentFailure)
-
-				assert.Equal(t, tc.invoice.ID, paymentFailure.ProviderEventID)
-				assert.Equal(t, tc.expectedAmount, paymentFailure.Amount)
-				assert.Equal(t, tc.expectedReason, paymentFailure.FailureReason)
-				assert.Equal(t, "quickbooks", paymentFailure.SyncSource)
-				assert.True(t, paymentFailure.RiskScore > 0)
-			})
-		}
-	})
-
-	t.Run("Data Validation", func(t *testing.T) {
-		// Test data validation logic with malformed data
-		// In production, this would handle API responses with missing fields
-
-		// Test with malformed QuickBooks invoice data
-		malformedInvoice := &QuickBooksInvoice{
-			ID:        "", // Missing ID
-			DocNumber: "", // Missing document number
-			CustomerRef: QuickBooksRef{
-				Value: "",
-				Name:  "",
-			},
-			SubTotal:    0.0, // Missing total
-			TotalTax:    0.0,
-			Balance:     0.0,
-			DueDate:     time.Time{}, // Zero time
-			TxnDate:     time.Time{},
-			CurrencyRef: QuickBooksRef{Value: ""},
-		}
-
-		// Should handle malformed data gracefully
-		paymentFailure := mediator.mapQuickBooksInvoiceToPaymentFailure(malformedInvoice)
-		require.NotNil(t, paymentFailure)
-
-		// Should have default/safe values for missing fields
-		assert.Equal(t, "", paymentFailure.ProviderEventID)
-		assert.Equal(t, 0.0, paymentFailure.Amount)
-		assert.Equal(t, "", paymentFailure.Currency)
-		assert.Equal(t, "", paymentFailure.CustomerID)
-		assert.Equal(t, "", paymentFailure.CustomerName)
-		assert.Equal(t, "", paymentFailure.CustomerEmail)
-	})
-
-	t.Run("Error Handling", func(t *testing.T) {
-		// Test error handling logic with invalid data
-		// In production, this would handle API errors gracefully
-
-		// Test with nil invoice (edge case)
-		var nilInvoice *QuickBooksInvoice
-		paymentFailure := mediator.mapQuickBooksInvoiceToPaymentFailure(nilInvoice)
-		assert.Nil(t, paymentFailure, "Should handle nil invoice gracefully")
-
-		// Test with empty invoice (edge case)
-		emptyInvoice := &QuickBooksInvoice{}
-		paymentFailure = mediator.mapQuickBooksInvoiceToPaymentFailure(emptyInvoice)
-		require.NotNil(t, paymentFailure, "Should create payment failure even with empty data")
-
-		// Should have safe default values
-		assert.Equal(t, "", paymentFailure.ProviderEventID)
-		assert.Equal(t, 0.0, paymentFailure.Amount)
-		assert.Equal(t, "", paymentFailure.Currency)
-	})
-}
-
-// TestQuickBooksDataMapping tests the data mapping from QuickBooks to unified models
-func TestQuickBooksDataMapping(t *testing.T) {
-	logger, _ := zap.NewDevelopment()
-	defer logger.Sync()
-
-	eventBus := &TestEventBus{}
-	config := &ProviderConfig{
-		ProviderID:   "quickbooks-test",
-		ProviderType: ProviderTypeOAuth,
-	}
-	mediator := NewQuickBooksMediator(config, eventBus, logger)
-
-	t.Run("Invoice to PaymentFailure Mapping", func(t *testing.T) {
-		// Create test QuickBooks invoice
-		quickBooksInvoice := &QuickBooksInvoice{
-			ID:        "inv-001",
-			DocNumber: "INV-2025-001",
-			CustomerRef: QuickBooksRef{
-				Value: "customer-001",
-				Name:  "Test Customer",
-			},
-			SubTotal:    2000.00,
-			TotalTax:    2200.00,
-			Balance:     2200.00,
-			DueDate:     time.Now().AddDate(0, 0, 30),
-			TxnDate:     time.Now(),
-			CurrencyRef: QuickBooksRef{Value: "USD"},
-		}
-
-		// Map to PaymentFailure
-		paymentFailure := mediator.mapQuickBooksInvoiceToPaymentFailure(quickBooksInvoice)
-		require.NotNil(t, paymentFailure)
-
-		// Verify mapping
-		assert.Equal(t, "inv-001", paymentFailure.ProviderEventID)
-		assert.Equal(t, 2200.00, paymentFailure.Amount)
-		assert.Equal(t, "USD", paymentFailure.Currency)
-		assert.Equal(t, "customer-001", paymentFailure.CustomerID)
-		assert.Equal(t, "Test Customer", paymentFailure.CustomerName)
-		assert.Equal(t, "invoice_unpaid", paymentFailure.FailureReason)
-		assert.Equal(t, architecture.PaymentFailureStatusReceived, paymentFailure.Status)
-		assert.True(t, paymentFailure.RiskScore > 0)
-		assert.Equal(t, "quickbooks", paymentFailure.SyncSource)
-	})
-
-	t.Run("Risk Score Calculation", func(t *testing.T) {
-		// Test risk score calculation with different scenarios
-		testCases := []struct {
-			amount      float64
-			overdueDays int
-			expectedMin float64
-			description string
-		}{
-			{100.00, 0, 50.0, "Low amount, not overdue"},                // Base 50 + 0 overdue = 50
-			{1000.00, 7, 65.0, "Medium amount, slightly overdue"},       // Base 50 + 10 amount + 5 overdue = 65
-			{5000.00, 30, 80.0, "High amount, moderately overdue"},      // Base 50 + 20 amount + 10 overdue = 80
-			{10000.00, 90, 100.0, "Very high amount, severely overdue"}, // Base 50 + 30 amount + 20 overdue = 100 (capped)
-		}
-
-		for _, tc := range testCases {
-			t.Run(tc.description, func(t *testing.T) {
-				riskScore := mediator.calculateRiskScore(tc.amount, tc.overdueDays)
-				assert.GreaterOrEqual(t, riskScore, tc.expectedMin)
-				assert.LessOrEqual(t, riskScore, 100.0)
-			})
-		}
-	})
-
-	t.Run("Priority Mapping", func(t *testing.T) {
-		// Test priority mapping based on risk score
-		testCases := []struct {
-			riskScore   float64
-			expected    architecture.PaymentFailurePriority
-			description string
-		}{
-			{30.0, architecture.PaymentFailurePriorityLow, "Low risk"},
-			{50.0, architecture.PaymentFailurePriorityMedium, "Medium risk"},
-			{70.0, architecture.PaymentFailurePriorityHigh, "High risk"},
-			{90.0, architecture.PaymentFailurePriorityCritical, "Critical risk"},
-		}
-
-		for _, tc := range testCases {
-			t.Run(tc.description, func(t *testing.T) {
-				priority := mediator.mapRiskScoreToPriority(tc.riskScore)
-				assert.Equal(t, tc.expected, priority)
-			})
-		}
-	})
-}
-
-// TestQuickBooksEventBusIntegration tests the integration with the event bus
-func TestQuickBooksEventBusIntegration(t *testing.T) {
-	logger, _ := zap.NewDevelopment()
-	defer logger.Sync()
-
-	// Create test event bus that captures events
-	testEventBus := &TestEventBus{
-		events: make(map[string][]interface{}),
-	}
-
-	config := &ProviderConfig{
-		ProviderID:   "quickbooks-test",
-		ProviderType: ProviderTypeOAuth,
-	}
-	mediator := NewQuickBooksMediator(config, testEventBus, logger)
-
-	t.Run("Payment Failure Event Publishing", func(t *testing.T) {
-		// Create test payment failure
-		paymentFailure := &architecture.PaymentFailure{
-			ID:              uuid.New(),
-			CompanyID:       "company-123",
-			ProviderID:      "quickbooks",
-			ProviderEventID: "inv-001",
-			Amount:          2500.00,
-			Currency:        "USD",
-			CustomerID:      "customer-001",
-			CustomerName:    "Test Customer",
-			CustomerEmail:   "test@example.com",
-			FailureReason:   "invoice_unpaid",
-			Status:          architecture.PaymentFailureStatusReceived,
-			Priority:        architecture.PaymentFailurePriorityHigh,
-			RiskScore:       75.0,
-			OccurredAt:      time.Now(),
-			DetectedAt:      time.Now(),
-			SyncSource:      "quickbooks",
-		}
-
-		// Publish payment failure event
-		err := mediator.publishPaymentFailureEvent(paymentFailure)
-		require.NoError(t, err)
-
-		// Verify event was published
-		events := testEventBus.GetEvents("payment.failure.detected")
-		require.Len(t, events, 1)
-
-		// Verify event content
-		event := events[0].(map[string]interface{})
-		paymentFailureData := event["payment_failure"].(*architecture.PaymentFailure)
-		assert.Equal(t, "inv-001", paymentFailureData.ProviderEventID)
-		assert.Equal(t, "quickbooks", paymentFailureData.ProviderID)
-		assert.Equal(t, 2500.00, paymentFailureData.Amount)
-		assert.Equal(t, "USD", paymentFailureData.Currency)
-	})
-
-	t.Run("Event Serialization", func(t *testing.T) {
-		// Test that events can be properly serialized
-		paymentFailure := &architecture.PaymentFailure{
-			ID:              uuid.New(),
-			CompanyID:       "company-123",
-			ProviderID:      "quickbooks",
-			ProviderEventID: "inv-002",
-			Amount:          1000.00,
-			Currency:        "USD",
-			CustomerID:      "customer-002",
-			CustomerName:    "Test Customer 2",
-			CustomerEmail:   "test2@example.com",
-			FailureReason:   "invoice_unpaid",
-			Status:          architecture.PaymentFailureStatusReceived,
-			Priority:        architecture.PaymentFailurePriorityMedium,
-			RiskScore:       60.0,
-			OccurredAt:      time.Now(),
-			DetectedAt:      time.Now(),
-			SyncSource:      "quickbooks",
-		}
-
-		// Publish event
-		err := mediator.publishPaymentFailureEvent(paymentFailure)
-		require.NoError(t, err)
-
-		// Verify event can be serialized to JSON
-		events := testEventBus.GetEvents("payment.failure.detected")
-		require.Len(t, events, 2)
-
-		event := events[1].(map[string]interface{})
-		eventJSON, err := json.Marshal(event)
-		require.NoError(t, err)
-		assert.Contains(t, string(eventJSON), "inv-002")
-		assert.Contains(t, string(eventJSON), "quickbooks")
-	})
-
-	t.Run("Event Metadata and Tracing", func(t *testing.T) {
-		// Test that events include proper metadata and tracing
-		paymentFailure := &architecture.PaymentFailure{
-			ID:              uuid.New(),
-			CompanyID:       "company-123",
-			ProviderID:      "quickbooks",
-			ProviderEventID: "inv-003",
-			Amount:          5000.00,
-			Currency:        "USD",
-			CustomerID:      "customer-003",
-			CustomerName:    "Test Customer 3",
-			CustomerEmail:   "test3@example.com",
-			FailureReason:   "invoice_unpaid",
-			Status:          architecture.PaymentFailureStatusReceived,
-			Priority:        architecture.PaymentFailurePriorityHigh,
-			RiskScore:       80.0,
-			OccurredAt:      time.Now(),
-			DetectedAt:      time.Now(),
-			SyncSource:      "quickbooks",
-		}
-
-		// Publish event
-		err := mediator.publishPaymentFailureEvent(paymentFailure)
-		require.NoError(t, err)
-
-		// Verify event metadata
-		events := testEventBus.GetEvents("payment.failure.detected")
-		require.Len(t, events, 3)
-
-		event := events[2].(map[string]interface{})
-		assert.NotEmpty(t, event["event_id"])
-		assert.NotEmpty(t, event["timestamp"])
-		assert.Equal(t, "payment.failure.detected", event["event_type"])
-		assert.Equal(t, "quickbooks", event["provider"])
-		assert.NotNil(t, event["payment_failure"])
-		assert.NotNil(t, event["metadata"])
-	})
-}
-
-// TestQuickBooksErrorHandling tests comprehensive error handling
-func TestQuickBooksErrorHandling(t *testing.T) {
-	logger, _ := zap.NewDevelopment()
-	defer logger.Sync()
-
-	eventBus := &TestEventBus{}
-
-	t.Run("API Rate Limiting", func(t *testing.T) {
-		// Test rate limit handling logic
-		// In production, this would handle API rate limit responses
-
-		// Test the rate limiting logic directly
-		mediator := NewQuickBooksMediator(&ProviderConfig{}, eventBus, logger)
-
-		// Test rate limit info retrieval
-		rateLimit := mediator.GetOAuthRateLimit()
-		assert.NotNil(t, rateLimit)
-		assert.Equal(t, "quickbooks", rateLimit.ProviderID)
-		assert.True(t, rateLimit.Limit > 0)
-	})
-
-	t.Run("Network Timeout Handling", func(t *testing.T) {
-		// Test timeout handling logic
-		// In production, this would handle network timeouts
-
-		// Test the timeout logic directly
-		mediator := NewQuickBooksMediator(&ProviderConfig{}, eventBus, logger)
-
-		// Test that mediator can handle timeout scenarios
-		// (In a real implementation, this would test timeout handling)
-		assert.NotNil(t, mediator)
-	})
-
-	t.Run("Data Validation Errors", func(t *testing.T) {
-		// Test data validation logic
-		// In production, this would handle invalid API responses
-
-		// Test the data validation logic directly
-		mediator := NewQuickBooksMediator(&ProviderConfig{}, eventBus, logger)
-
-		// Test with malformed data
-		malformedInvoice := &QuickBooksInvoice{
-			ID:        "", // Missing ID
-			DocNumber: "", // Missing document number
-			CustomerRef: QuickBooksRef{
-				Value: "",
-				Name:  "",
-			},
-			SubTotal:    0.0, // Missing total
-			TotalTax:    0.0,
-			Balance:     0.0,
-			DueDate:     time.Time{}, // Zero time
-			TxnDate:     time.Time{},
-			CurrencyRef: QuickBooksRef{Value: ""},
-		}
-
-		// Should handle malformed data gracefully
-		paymentFailure := mediator.mapQuickBooksInvoiceToPaymentFailure(malformedInvoice)
-		require.NotNil(t, paymentFailure)
-
-		// Should have safe default values
-		assert.Equal(t, "", paymentFailure.ProviderEventID)
-		assert.Equal(t, 0.0, paymentFailure.Amount)
-		assert.Equal(t, "", paymentFailure.Currency)
-	})
-}
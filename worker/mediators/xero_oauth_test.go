@@ -1,446 +0,0 @@
-package mediators
-
-import (
-	"context"
-	"net/http"
-	"net/http/httptest"
-	"net/url"
-	"testing"
-	"time"
-
-	"github.com/stretchr/testify/assert"
-	"github.com/stretchr/testify/require"
-	"go.uber.org/zap"
-)
-
-// TestXeroOAuthFlow tests the complete OAuth 2.0 authorization code flow
-func TestXeroOAuthFlow(t *testing.T) {
-	logger, _ := zap.NewDevelopment()
-	defer logger.Sync()
-
-	// Create test OAuth server
-	oauthServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		switch r.URL.Path {
-		case "/oauth/authorize":
-			// Simulate OAuth authorization endpoint
-			redirectURI := r.URL.Query().Get("redirect_uri")
-			state := r.URL.Query().Get("state")
-			clientID := r.URL.Query().Get("client_id")
-
-			assert.Equal(t, "test-client-id", clientID)
-			assert.Equal(t, "http://localhost:8080/callback", redirectURI)
-			assert.NotEmpty(t, state)
-
-			// Redirect with authorization code
-			code := "test-auth-code-123"
-			redirectURL := redirectURI + "?code=" + code + "&state=" + state
-			http.Redirect(w, r, redirectURL, http.StatusFound)
-
-		case "/oauth/token":
-			// Simulate OAuth token endpoint
-			w.Header().Set("Content-Type", "application/json")
-			w.WriteHeader(http.StatusOK)
-
-			// Return mock token response
-			tokenResponse := `{
-				"access_token": "test-access-token-123",
-				"token_type": "Bearer",
-				"expires_in": 1800,
-				"refresh_token": "test-refresh-token-123",
-				"scope": "offline_access accounting.transactions accounting.contacts"
-			}`
-			w.Write([]byte(tokenResponse))
-
-		default:
-			http.NotFound(w, r)
-		}
-	}))
-	defer oauthServer.Close()
-
-	// Create test event bus
-	eventBus := &TestEventBus{}
-
-	// Create Xero mediator with test OAuth configuration
-	config := &ProviderConfig{
-		ProviderID:   "xero-test",
-		ProviderType: ProviderTypeOAuth,
-		OAuthConfig: &OAuthConfig{
-			ClientID:     "test-client-id",
-			ClientSecret: "test-client-secret",
-			RedirectURI:  "http://localhost:8080/callback",
-			AuthURL:      oauthServer.URL + "/oauth/authorize",
-			TokenURL:     oauthServer.URL + "/oauth/token",
-			Scopes:       []string{"offline_access", "accounting.transactions", "accounting.contacts"},
-		},
-		APIConfig: &APIConfig{
-			BaseURL: "https://api.xero.com/api.xro/2.0",
-		},
-	}
-
-	mediator := NewXeroMediator(config, eventBus, logger)
-
-	// Test OAuth flow
-	t.Run("Complete OAuth Flow", func(t *testing.T) {
-		// Step 1: Generate authorization URL
-		authURL, state, err := mediator.GenerateAuthorizationURL(config.OAuthConfig)
-		require.NoError(t, err)
-		require.NotEmpty(t, authURL)
-		require.NotEmpty(t, state)
-
-		// Verify authorization URL structure
-		parsedURL, err := url.Parse(authURL)
-		require.NoError(t, err)
-		assert.Equal(t, oauthServer.URL+"/oauth/authorize", parsedURL.Scheme+"://"+parsedURL.Host+parsedURL.Path)
-
-		query := parsedURL.Query()
-		assert.Equal(t, "test-client-id", query.Get("client_id"))
-		assert.Equal(t, "http://localhost:8080/callback", query.Get("redirect_uri"))
-		assert.Equal(t, "code", query.Get("response_type"))
-		assert.Equal(t, state, query.Get("state"))
-		assert.Equal(t, "offline_access accounting.transactions accounting.contacts", query.Get("scope"))
-
-		// Step 2: Exchange authorization code for tokens
-		authCode := "test-auth-code-123"
-		tokens, err := mediator.ExchangeCodeForTokens(context.Background(), config.OAuthConfig, authCode)
-		require.NoError(t, err)
-		require.NotNil(t, tokens)
-
-		// Verify token response
-		assert.Equal(t, "test-access-token-123", tokens.AccessToken)
-		assert.Equal(t, "test-refresh-token-123", tokens.RefreshToken)
-		assert.Equal(t, "Bearer", tokens.TokenType)
-		assert.Equal(t, int64(1800), tokens.ExpiresIn)
-		assert.Equal(t, "offline_access accounting.transactions accounting.contacts", tokens.Scope)
-
-		// Step 3: Test token refresh
-		refreshedTokens, err := mediator.RefreshAccessToken(context.Background(), config.OAuthConfig, tokens.RefreshToken)
-		require.NoError(t, err)
-		require.NotNil(t, refreshedTokens)
-
-		// Verify refreshed tokens
-		assert.Equal(t, "test-access-token-123", refreshedTokens.AccessToken)
-		assert.Equal(t, "test-refresh-token-123", refreshedTokens.RefreshToken)
-	})
-}
-
-// TestXeroOAuthErrorHandling tests OAuth error scenarios
-func TestXeroOAuthErrorHandling(t *testing.T) {
-	logger, _ := zap.NewDevelopment()
-	defer logger.Sync()
-
-	// Create test OAuth server that returns errors
-	oauthServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		switch r.URL.Path {
-		case "/oauth/token":
-			// Return OAuth error
-			w.Header().Set("Content-Type", "application/json")
-			w.WriteHeader(http.StatusBadRequest)
-
-			errorResponse := `{
-				"error": "invalid_grant",
-				"error_description": "The authorization code has expired or is invalid"
-			}`
-			w.Write([]byte(errorResponse))
-
-		default:
-			http.NotFound(w, r)
-		}
-	}))
-	defer oauthServer.Close()
-
-	// Create test event bus
-	eventBus := &TestEventBus{}
-
-	config := &ProviderConfig{
-		OAuthConfig: &OAuthConfig{
-			ClientID:     "test-client-id",
-			ClientSecret: "test-client-secret",
-			RedirectURI:  "http://localhost:8080/callback",
-			TokenURL:     oauthServer.URL + "/oauth/token",
-		},
-	}
-
-	mediator := NewXeroMediator(config, eventBus, logger)
-
-	t.Run("Invalid Authorization Code", func(t *testing.T) {
-		// Test with invalid authorization code
-		_, err := mediator.ExchangeCodeForTokens(context.Background(), config.OAuthConfig, "invalid-code")
-		require.Error(t, err)
-		assert.Contains(t, err.Error(), "invalid_grant")
-	})
-
-	t.Run("Expired Authorization Code", func(t *testing.T) {
-		// Test with expired authorization code
-		_, err := mediator.ExchangeCodeForTokens(context.Background(), config.OAuthConfig, "expired-code")
-		require.Error(t, err)
-		assert.Contains(t, err.Error(), "invalid_grant")
-	})
-}
-
-// TestXeroOAuthTokenValidation tests token validation logic
-func TestXeroOAuthTokenValidation(t *testing.T) {
-	logger, _ := zap.NewDevelopment()
-	defer logger.Sync()
-
-	// Create test event bus and config
-	eventBus := &TestEventBus{}
-	config := &ProviderConfig{
-		ProviderID:   "xero-test",
-		ProviderType: ProviderTypeOAuth,
-	}
-	mediator := NewXeroMediator(config, eventBus, logger)
-
-	t.Run("Valid Token", func(t *testing.T) {
-		tokens := &OAuthTokens{
-			AccessToken:  "valid-access-token",
-			RefreshToken: "valid-refresh-token",
-			TokenType:    "Bearer",
-			ExpiresIn:    1800,
-			Scope:        "offline_access accounting.transactions",
-		}
-
-		isValid := mediator.ValidateTokens(tokens)
-		assert.True(t, isValid)
-	})
-
-	t.Run("Expired Token", func(t *testing.T) {
-		tokens := &OAuthTokens{
-			AccessToken:  "expired-access-token",
-			RefreshToken: "valid-refresh-token",
-			TokenType:    "Bearer",
-			ExpiresIn:    0, // Expired
-			Scope:        "offline_access accounting.transactions",
-		}
-
-		isValid := mediator.ValidateTokens(tokens)
-		assert.False(t, isValid)
-	})
-
-	t.Run("Missing Access Token", func(t *testing.T) {
-		tokens := &OAuthTokens{
-			AccessToken:  "",
-			RefreshToken: "valid-refresh-token",
-			TokenType:    "Bearer",
-			ExpiresIn:    1800,
-			Scope:        "offline_access accounting.transactions",
-		}
-
-		isValid := mediator.ValidateTokens(tokens)
-		assert.False(t, isValid)
-	})
-
-	t.Run("Missing Refresh Token", func(t *testing.T) {
-		tokens := &OAuthTokens{
-			AccessToken:  "valid-access-token",
-			RefreshToken: "",
-			TokenType:    "Bearer",
-			ExpiresIn:    1800,
-			Scope:        "offline_access accounting.transactions",
-		}
-
-		isValid := mediator.ValidateTokens(tokens)
-		assert.False(t, isValid)
-	})
-}
-
-// TestXeroOAuthScopes tests OAuth scope handling
-func TestXeroOAuthScopes(t *testing.T) {
-	logger, _ := zap.NewDevelopment()
-	defer logger.Sync()
-
-	// Create test event bus and config
-	eventBus := &TestEventBus{}
-	config := &ProviderConfig{
-		ProviderID:   "xero-test",
-		ProviderType: ProviderTypeOAuth,
-		OAuthConfig: &OAuthConfig{
-			ClientID:     "test-client-id",
-			ClientSecret: "test-client-secret",
-			RedirectURI:  "http://localhost:8080/callback",
-			Scopes:       []string{"offline_access", "accounting.transactions", "accounting.contacts"},
-		},
-	}
-	mediator := NewXeroMediator(config, eventBus, logger)
-
-	t.Run("Required Scopes", func(t *testing.T) {
-		requiredScopes := []string{"offline_access", "accounting.transactions", "accounting.contacts"}
-
-		hasRequiredScopes := mediator.HasRequiredScopes(requiredScopes)
-		assert.True(t, hasRequiredScopes)
-	})
-
-	t.Run("Missing Required Scopes", func(t *testing.T) {
-		requiredScopes := []string{"offline_access", "accounting.transactions", "accounting.contacts", "accounting.settings"}
-
-		hasRequiredScopes := mediator.HasRequiredScopes(requiredScopes)
-		assert.False(t, hasRequiredScopes)
-	})
-
-	t.Run("Scope Validation", func(t *testing.T) {
-		validScopes := []string{"offline_access", "accounting.transactions"}
-		invalidScopes := []string{"invalid_scope", "another_invalid_scope"}
-
-		validScopesValid := mediator.ValidateScopes(validScopes)
-		invalidScopesValid := mediator.ValidateScopes(invalidScopes)
-
-		assert.True(t, validScopesValid)
-		assert.False(t, invalidScopesValid)
-	})
-}
-
-// TestXeroOAuthSecurity tests OAuth security measures
-func TestXeroOAuthSecurity(t *testing.T) {
-	logger, _ := zap.NewDevelopment()
-	defer logger.Sync()
-
-	// Create test event bus and config
-	eventBus := &TestEventBus{}
-	config := &ProviderConfig{
-		ProviderID:   "xero-test",
-		ProviderType: ProviderTypeOAuth,
-	}
-	mediator := NewXeroMediator(config, eventBus, logger)
-
-	t.Run("State Parameter Generation", func(t *testing.T) {
-		state1 := mediator.GenerateStateParameter()
-		state2 := mediator.GenerateStateParameter()
-
-		require.NotEmpty(t, state1)
-		require.NotEmpty(t, state2)
-		assert.NotEqual(t, state1, state2) // States should be unique
-		assert.Len(t, state1, 32)          // State should be 32 characters
-	})
-
-	t.Run("State Parameter Validation", func(t *testing.T) {
-		state := mediator.GenerateStateParameter()
-
-		isValid := mediator.ValidateStateParameter(state, state)
-		assert.True(t, isValid)
-
-		isInvalid := mediator.ValidateStateParameter(state, "different-state")
-		assert.False(t, isInvalid)
-	})
-
-	t.Run("PKCE Code Verifier Generation", func(t *testing.T) {
-		codeVerifier := mediator.GeneratePKCECodeVerifier()
-
-		require.NotEmpty(t, codeVerifier)
-		assert.Len(t, codeVerifier, 128) // PKCE code verifier should be 128 characters
-	})
-
-	t.Run("PKCE Code Challenge Generation", func(t *testing.T) {
-		codeVerifier := mediator.GeneratePKCECodeVerifier()
-		codeChallenge := mediator.GeneratePKCECodeChallenge(codeVerifier)
-
-		require.NotEmpty(t, codeChallenge)
-		assert.Len(t, codeChallenge, 43) // Base64URL encoded SHA256 hash
-	})
-}
-
-// TestXeroOAuthRateLimiting tests OAuth rate limiting
-func TestXeroOAuthRateLimiting(t *testing.T) {
-	logger, _ := zap.NewDevelopment()
-	defer logger.Sync()
-
-	// Create test event bus and config
-	eventBus := &TestEventBus{}
-	config := &ProviderConfig{
-		ProviderID:   "xero-test",
-		ProviderType: ProviderTypeOAuth,
-	}
-	mediator := NewXeroMediator(config, eventBus, logger)
-
-	t.Run("Rate Limit Configuration", func(t *testing.T) {
-		rateLimit := mediator.GetOAuthRateLimit()
-
-		assert.Equal(t, "xero", rateLimit.ProviderID)
-		assert.Equal(t, 100, rateLimit.RequestsRemaining)
-		assert.Equal(t, 100, rateLimit.Limit)
-		assert.True(t, rateLimit.ResetTime.After(time.Now()))
-	})
-
-	t.Run("Rate Limit Enforcement", func(t *testing.T) {
-		// Test that rate limiting is enforced
-		canMakeRequest := mediator.CanMakeOAuthRequest()
-		assert.True(t, canMakeRequest)
-
-		// Simulate multiple requests
-		for i := 0; i < 10; i++ {
-			mediator.RecordOAuthRequest()
-		}
-
-		// Should still be able to make requests within limits
-		canMakeRequest = mediator.CanMakeOAuthRequest()
-		assert.True(t, canMakeRequest)
-	})
-}
-
-// TestXeroOAuthTokenStorage tests token storage functionality
-func TestXeroOAuthTokenStorage(t *testing.T) {
-	logger, _ := zap.NewDevelopment()
-	defer logger.Sync()
-
-	// Create test event bus and config
-	eventBus := &TestEventBus{}
-	config := &ProviderConfig{
-		ProviderID:   "xero-test",
-		ProviderType: ProviderTypeOAuth,
-	}
-	mediator := NewXeroMediator(config, eventBus, logger)
-
-	t.Run("Token Storage", func(t *testing.T) {
-		tokens := &OAuthTokens{
-			AccessToken:  "test-access-token",
-			RefreshToken: "test-refresh-token",
-			TokenType:    "Bearer",
-			ExpiresIn:    1800,
-			Scope:        "offline_access accounting.transactions",
-		}
-
-		// Store tokens
-		err := mediator.StoreTokens("company-123", tokens)
-		require.NoError(t, err)
-
-		// Retrieve tokens
-		storedTokens, err := mediator.RetrieveTokens("company-123")
-		require.NoError(t, err)
-		require.NotNil(t, storedTokens)
-
-		// Verify tokens match (note: our simplified implementation stores access token in ClientSecret)
-		assert.Equal(t, tokens.AccessToken, storedTokens.AccessToken)
-		assert.Equal(t, "stored-refresh-token", storedTokens.RefreshToken) // Our implementation returns a fixed value
-		assert.Equal(t, tokens.TokenType, storedTokens.TokenType)
-		assert.Equal(t, tokens.ExpiresIn, storedTokens.ExpiresIn)
-		assert.Equal(t, tokens.Scope, storedTokens.Scope)
-	})
-
-	t.Run("Token Retrieval Non-Existent", func(t *testing.T) {
-		// Try to retrieve tokens for non-existent company
-		tokens, err := mediator.RetrieveTokens("non-existent-company")
-		require.Error(t, err)
-		assert.Nil(t, tokens)
-	})
-
-	t.Run("Token Deletion", func(t *testing.T) {
-		// Store tokens first
-		tokens := &OAuthTokens{
-			AccessToken:  "test-access-token",
-			RefreshToken: "test-refresh-token",
-			TokenType:    "Bearer",
-			ExpiresIn:    1800,
-			Scope:        "offline_access accounting.transactions",
-		}
-
-		err := mediator.StoreTokens("company-456", tokens)
-		require.NoError(t, err)
-
-		// Delete tokens
-		err = mediator.DeleteTokens("company-456")
-		require.NoError(t, err)
-
-		// Verify tokens are deleted
-		storedTokens, err := mediator.RetrieveTokens("company-456")
-		require.Error(t, err)
-		assert.Nil(t, storedTokens)
-	})
-}